@@ -7,9 +7,11 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -28,6 +30,7 @@ import (
 	"github.com/mailit-dev/mailit/internal/config"
 	"github.com/mailit-dev/mailit/internal/engine"
 	"github.com/mailit-dev/mailit/internal/handler"
+	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/server"
 	"github.com/mailit-dev/mailit/internal/server/middleware"
@@ -70,6 +73,12 @@ func main() {
 		setupCmd.StringVar(&configPath, "config", "config/mailit.yaml", "config file path")
 		_ = setupCmd.Parse(os.Args[2:])
 		runSetup(configPath)
+	case "rekey":
+		rekeyCmd := flag.NewFlagSet("rekey", flag.ExitOnError)
+		rekeyCmd.StringVar(&configPath, "config", "config/mailit.yaml", "config file path")
+		dryRun := rekeyCmd.Bool("dry-run", false, "report the keys to re-encrypt without changing them")
+		_ = rekeyCmd.Parse(os.Args[2:])
+		runRekey(configPath, *dryRun)
 	case "version":
 		fmt.Printf("mailit %s\n", Version)
 	default:
@@ -85,6 +94,7 @@ func printUsage() {
 	fmt.Println("  mailit serve   [--config path]             Start API server, workers, and SMTP")
 	fmt.Println("  mailit migrate [--config path] --up/--down Run database migrations")
 	fmt.Println("  mailit setup   [--config path]             First-run setup (admin + DKIM)")
+	fmt.Println("  mailit rekey   [--config path] [--dry-run] Re-encrypt DKIM keys with the current master key")
 	fmt.Println("  mailit version                             Print version")
 }
 
@@ -206,15 +216,19 @@ func runServe(configPath string) {
 	audienceRepo := postgres.NewAudienceRepository(pool)
 	contactRepo := postgres.NewContactRepository(pool)
 	contactPropertyRepo := postgres.NewContactPropertyRepository(pool)
+	contactPropertyValueRepo := postgres.NewContactPropertyValueRepository(pool)
 	topicRepo := postgres.NewTopicRepository(pool)
+	contactTopicRepo := postgres.NewContactTopicRepository(pool)
 	segmentRepo := postgres.NewSegmentRepository(pool)
 	templateRepo := postgres.NewTemplateRepository(pool)
 	templateVersionRepo := postgres.NewTemplateVersionRepository(pool)
 	broadcastRepo := postgres.NewBroadcastRepository(pool)
+	broadcastVariantRepo := postgres.NewBroadcastVariantRepository(pool)
 	webhookRepo := postgres.NewWebhookRepository(pool)
 	webhookEventRepo := postgres.NewWebhookEventRepository(pool)
 	suppressionRepo := postgres.NewSuppressionRepository(pool)
 	inboundEmailRepo := postgres.NewInboundEmailRepository(pool)
+	dmarcReportRepo := postgres.NewDMARCReportRepository(pool)
 	logRepo := postgres.NewLogRepository(pool)
 	metricsRepo := postgres.NewMetricsRepository(pool)
 	trackingLinkRepo := postgres.NewTrackingLinkRepository(pool)
@@ -223,14 +237,27 @@ func runServe(configPath string) {
 	invitationRepo := postgres.NewTeamInvitationRepository(pool)
 
 	// --- Engine ---
+	keyring, err := buildKeyring(cfg.DKIM)
+	if err != nil {
+		logger.Error("configuring DKIM key encryption", "error", err)
+		os.Exit(1)
+	}
+	if !keyring.Encrypts() {
+		logger.Warn("no DKIM master key configured; DKIM private keys are stored unencrypted")
+	}
+
 	dnsResolver := engine.NewDNSResolver(cfg.DNS.Resolver, cfg.DNS.Timeout)
 	smtpSender := engine.NewSender(engine.SenderConfig{
 		Hostname:       cfg.SMTPOutbound.Hostname,
 		HeloDomain:     cfg.SMTPOutbound.HELODomain,
+		Port:           cfg.SMTPOutbound.Port,
 		TLSPolicy:      cfg.SMTPOutbound.TLSPolicy,
+		MTASTS:         cfg.SMTPOutbound.MTASTS,
+		DANE:           cfg.SMTPOutbound.DANE,
 		ConnectTimeout: cfg.SMTPOutbound.ConnectTimeout,
 		SendTimeout:    cfg.SMTPOutbound.SendTimeout,
 		MaxRecipients:  cfg.SMTPOutbound.MaxRecipients,
+		Keyring:        keyring,
 	}, dnsResolver, logger)
 	emailSenderAdapter := engine.NewWorkerAdapter(smtpSender)
 
@@ -240,8 +267,9 @@ func runServe(configPath string) {
 		webhookEventRepo,
 		asynqClient,
 		webhook.DispatcherConfig{
-			Timeout:    cfg.Webhooks.Timeout,
-			MaxRetries: cfg.Webhooks.MaxRetries,
+			Timeout:              cfg.Webhooks.Timeout,
+			MaxRetries:           cfg.Webhooks.MaxRetries,
+			DisableAfterFailures: cfg.Webhooks.DisableAfterFailures,
 		},
 		logger,
 	)
@@ -252,22 +280,45 @@ func runServe(configPath string) {
 		}
 	}
 
+	// --- Attachment Storage ---
+	var attachmentStorage service.AttachmentStorage
+	switch cfg.Storage.Type {
+	case "s3":
+		s3Storage, err := service.NewS3AttachmentStorage(service.S3StorageConfig{
+			Bucket:    cfg.Storage.S3.Bucket,
+			Region:    cfg.Storage.S3.Region,
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+		})
+		if err != nil {
+			logger.Error("failed to configure attachment storage", "error", err)
+			os.Exit(1)
+		}
+		attachmentStorage = s3Storage
+	default:
+		attachmentStorage = service.NewLocalAttachmentStorage(cfg.Storage.LocalPath)
+	}
+
 	// --- Services ---
+	emailService := service.NewEmailService(emailRepo, domainRepo, teamRepo, teamMemberRepo, suppressionRepo, templateRepo, templateVersionRepo, asynqClient, rdb, attachmentStorage, cfg.Storage.InlineMaxBytes)
 	services := &service.Services{
 		Auth:            service.NewAuthService(userRepo, teamRepo, teamMemberRepo, cfg.Auth.JWTSecret, cfg.Auth.JWTExpiry, cfg.Auth.BcryptCost),
-		Email:           service.NewEmailService(emailRepo, suppressionRepo, asynqClient, rdb),
-		Domain:          service.NewDomainService(domainRepo, dnsRecordRepo, asynqClient, cfg.DKIM.Selector, cfg.DKIM.MasterEncryptionKey),
-		APIKey:          service.NewAPIKeyService(apiKeyRepo, cfg.Auth.APIKeyPrefix),
+		Email:           emailService,
+		Domain:          service.NewDomainService(domainRepo, dnsRecordRepo, asynqClient, cfg.DKIM.Selector, keyring),
+		DMARC:           service.NewDMARCService(domainRepo, dmarcReportRepo),
+		APIKey:          service.NewAPIKeyService(apiKeyRepo, domainRepo, cfg.Auth.APIKeyPrefix),
 		Audience:        service.NewAudienceService(audienceRepo),
-		Contact:         service.NewContactService(contactRepo, audienceRepo),
+		Contact:         service.NewContactService(contactRepo, audienceRepo, contactPropertyRepo, contactPropertyValueRepo, segmentRepo, topicRepo, contactTopicRepo, logger),
 		ContactProperty: service.NewContactPropertyService(contactPropertyRepo),
 		Topic:           service.NewTopicService(topicRepo),
-		Segment:         service.NewSegmentService(segmentRepo, audienceRepo),
-		Template:        service.NewTemplateService(templateRepo, templateVersionRepo),
-		Broadcast:       service.NewBroadcastService(broadcastRepo, asynqClient),
-		Webhook:         service.NewWebhookService(webhookRepo),
+		Segment:         service.NewSegmentService(segmentRepo, audienceRepo, contactPropertyRepo, topicRepo),
+		Template:        service.NewTemplateService(templateRepo, templateVersionRepo, emailService),
+		Broadcast:       service.NewBroadcastService(broadcastRepo, broadcastVariantRepo, topicRepo, contactPropertyRepo, templateVersionRepo, emailRepo, domainRepo, asynqClient),
+		Webhook:         service.NewWebhookService(webhookRepo, webhookEventRepo, dispatcher, asynqClient),
 		InboundEmail:    service.NewInboundEmailService(inboundEmailRepo),
 		Log:             service.NewLogService(logRepo),
+		Suppression:     service.NewSuppressionService(suppressionRepo),
 		Metrics: service.NewMetricsService(metricsRepo),
 		Settings: service.NewSettingsService(
 			settingsRepo,
@@ -301,9 +352,18 @@ func runServe(configPath string) {
 		webhookDispatchFn,
 		metricsIncrementFn,
 	)
+	services.Preference = service.NewPreferenceService(
+		contactRepo,
+		topicRepo,
+		contactTopicRepo,
+		segmentRepo,
+		webhookDispatchFn,
+		cfg.Auth.JWTSecret,
+		logger,
+	)
 
 	// --- Handlers ---
-	handlers := handler.NewHandlers(services, importJobRepo, audienceRepo, asynqClient)
+	handlers := handler.NewHandlers(services, importJobRepo, audienceRepo, asynqClient, logger)
 
 	// --- API Key auth closures ---
 	apiKeyLookup := func(ctx context.Context, keyHash string) (*middleware.AuthContext, error) {
@@ -311,18 +371,56 @@ func runServe(configPath string) {
 		if err != nil {
 			return nil, err
 		}
-		return &middleware.AuthContext{
+		authCtx := &middleware.AuthContext{
 			TeamID:     key.TeamID,
 			Permission: key.Permission,
 			AuthMethod: "api_key",
-		}, nil
+			APIKeyID:   &key.ID,
+			Scopes:     key.Scopes,
+			DomainID:   key.DomainID,
+			AllowedIPs: key.AllowedIPs,
+			ExpiresAt:  key.ExpiresAt,
+		}
+		// Resolve the domain a restricted key may send from. A key whose
+		// domain no longer exists is rejected rather than left unrestricted.
+		if key.DomainID != nil {
+			domain, err := domainRepo.GetByID(ctx, *key.DomainID)
+			if err != nil {
+				return nil, fmt.Errorf("api key domain: %w", err)
+			}
+			authCtx.Domain = domain.Name
+		}
+		return authCtx, nil
 	}
 
 	apiKeyLastUsed := func(ctx context.Context, keyHash string, usedAt time.Time) {
 		_ = apiKeyRepo.UpdateLastUsed(ctx, keyHash, usedAt)
 	}
 
+	memberRole := func(ctx context.Context, teamID, userID uuid.UUID) (string, error) {
+		member, err := teamMemberRepo.GetByTeamAndUser(ctx, teamID, userID)
+		if err != nil {
+			return "", err
+		}
+		return member.Role, nil
+	}
+
+	// --- API Request Logs ---
+	var logWriter *service.LogWriter
+	var requestLog middleware.RequestLogFunc
+	if cfg.RequestLogs.Enabled {
+		logWriter = service.NewLogWriter(logRepo, cfg.RequestLogs.BatchSize, cfg.RequestLogs.BufferSize, cfg.RequestLogs.FlushInterval, logger)
+		requestLog = logWriter.Record
+	}
+
 	// --- HTTP Server ---
+	rateLimitCfg := middleware.RateLimitConfig{
+		Enabled:    cfg.RateLimit.Enabled,
+		DefaultRPS: cfg.RateLimit.DefaultRPS,
+		SendRPS:    cfg.RateLimit.SendRPS,
+		BatchRPS:   cfg.RateLimit.BatchRPS,
+		Window:     cfg.RateLimit.Window,
+	}
 	httpServer := server.New(server.Config{
 		Addr:           cfg.Server.HTTPAddr,
 		ReadTimeout:    cfg.Server.ReadTimeout,
@@ -330,16 +428,14 @@ func runServe(configPath string) {
 		JWTSecret:      cfg.Auth.JWTSecret,
 		APIKeyPrefix:   cfg.Auth.APIKeyPrefix,
 		CORSOrigins:    cfg.Server.CORSOrigins,
-		RateLimitCfg: middleware.RateLimitConfig{
-			Enabled:    cfg.RateLimit.Enabled,
-			DefaultRPS: cfg.RateLimit.DefaultRPS,
-			SendRPS:    cfg.RateLimit.SendRPS,
-			BatchRPS:   cfg.RateLimit.BatchRPS,
-			Window:     cfg.RateLimit.Window,
-		},
+		TrustedProxies: cfg.Server.TrustedProxies,
+		RateLimitCfg:   rateLimitCfg,
 		Redis:          rdb,
 		APIKeyLookup:   apiKeyLookup,
 		APIKeyLastUsed: apiKeyLastUsed,
+		MemberRole:     memberRole,
+		RequestLog:        requestLog,
+		RequestLogMaxBody: cfg.RequestLogs.MaxBodyBytes,
 		Handlers:       handlers,
 		HealthHandler:  healthHandler,
 		Logger:         logger,
@@ -347,29 +443,57 @@ func runServe(configPath string) {
 
 	// --- Worker Mux ---
 	workerHandlers := worker.Handlers{
-		EmailSend:      worker.NewEmailSendHandler(emailRepo, emailEventRepo, domainRepo, suppressionRepo, trackingLinkRepo, emailSenderAdapter, webhookDispatchFn, metricsIncrementFn, cfg.Server.BaseURL, logger),
+		EmailSend:      worker.NewEmailSendHandler(emailRepo, emailEventRepo, domainRepo, dnsRecordRepo, suppressionRepo, trackingLinkRepo, broadcastRepo, attachmentStorage, emailSenderAdapter, webhookDispatchFn, metricsIncrementFn, cfg.Server.BaseURL, cfg.Auth.JWTSecret, logger),
 		EmailBatchSend: worker.NewBatchEmailSendHandler(asynqClient, logger),
-		BroadcastSend:  worker.NewBroadcastSendHandler(broadcastRepo, contactRepo, audienceRepo, emailRepo, templateVersionRepo, asynqClient, logger),
-		DomainVerify:   worker.NewDomainVerifyHandler(domainRepo, dnsRecordRepo, logger),
-		Bounce:         worker.NewBounceHandler(emailRepo, emailEventRepo, suppressionRepo, logger),
+		BroadcastSend:  worker.NewBroadcastSendHandler(broadcastRepo, broadcastVariantRepo, contactRepo, audienceRepo, segmentRepo, topicRepo, contactTopicRepo, contactPropertyRepo, contactPropertyValueRepo, emailRepo, domainRepo, templateVersionRepo, asynqClient, cfg.Server.BaseURL, cfg.Auth.JWTSecret, logger),
+		BroadcastDispatch: worker.NewBroadcastDispatchHandler(broadcastRepo, emailRepo, domainRepo, asynqClient, logger),
+		DomainVerify:   worker.NewDomainVerifyHandler(domainRepo, dnsRecordRepo, cfg.DKIM.RetireAfter, cfg.DomainMonitor.PauseOnDKIMMissing, webhookDispatchFn, logger),
+		DKIMMaintenance: worker.NewDKIMMaintenanceHandler(domainRepo, dnsRecordRepo, asynqClient, sealedDKIMKeyGenerator(keyring), cfg.DKIM.Selector, cfg.DKIM.KeyBits, logger),
+		DomainMonitor:   worker.NewDomainMonitorHandler(domainRepo, asynqClient, cfg.DomainMonitor.Interval, logger),
+		Bounce:         worker.NewBounceHandler(emailRepo, emailEventRepo, suppressionRepo, webhookDispatchFn, metricsIncrementFn, logger),
 		Inbound:        worker.NewInboundHandler(inboundEmailRepo, webhookDispatchFn, logger),
-		Cleanup:        worker.NewCleanupHandler(webhookEventRepo, logRepo, logger),
+		Cleanup:        worker.NewCleanupHandler(webhookEventRepo, logRepo, cfg.RequestLogs.Retention, logger),
 		WebhookDeliver:   worker.NewWebhookDeliverHandler(dispatcher, logger),
+		WebhookBackfill:  worker.NewWebhookBackfillHandler(webhookRepo, webhookEventRepo, dispatcher, asynqClient, logger),
 		MetricsAggregate: worker.NewMetricsAggregateHandler(pool, metricsRepo, logger),
-		ContactImport:    worker.NewContactImportHandler(importJobRepo, contactRepo, logger),
+		ContactImport:    worker.NewContactImportHandler(importJobRepo, contactRepo, segmentRepo, logger),
 	}
 	mux := worker.NewMux(workerHandlers)
 
+	// --- Periodic tasks ---
+	scheduler := asynq.NewScheduler(asynqRedisOpt, &asynq.SchedulerOpts{
+		Logger:   newAsynqLogger(logger),
+		Location: time.UTC,
+	})
+	dkimMaintenanceTask, _ := worker.NewDKIMMaintenanceTask()
+	if _, err := scheduler.Register(worker.DKIMMaintenanceSchedule, dkimMaintenanceTask); err != nil {
+		logger.Error("failed to schedule DKIM maintenance", "error", err)
+		os.Exit(1)
+	}
+	broadcastDispatchTask, _ := worker.NewBroadcastDispatchTask()
+	if _, err := scheduler.Register(worker.BroadcastDispatchSchedule, broadcastDispatchTask); err != nil {
+		logger.Error("failed to schedule broadcast dispatch", "error", err)
+		os.Exit(1)
+	}
+	if cfg.DomainMonitor.Enabled {
+		domainMonitorTask, _ := worker.NewDomainMonitorTask()
+		if _, err := scheduler.Register(worker.DomainMonitorSchedule, domainMonitorTask); err != nil {
+			logger.Error("failed to schedule domain monitoring", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// --- Inbound SMTP server (optional) ---
 	var smtpServer *gosmtp.Server
 	if cfg.SMTPInbound.Enabled {
-		attachmentStorage := service.NewLocalAttachmentStorage(cfg.Storage.LocalPath)
 		smtpBackend := smtppkg.NewBackend(
 			domainRepo,
 			inboundEmailRepo,
+			dmarcReportRepo,
 			attachmentStorage,
 			asynqClient,
 			int64(cfg.SMTPInbound.MaxMessageBytes),
+			cfg.Auth.JWTSecret,
 			logger,
 		)
 		smtpServer = smtppkg.NewServer(smtppkg.ServerConfig{
@@ -381,6 +505,40 @@ func runServe(configPath string) {
 		}, smtpBackend, logger)
 	}
 
+	// --- SMTP submission servers (optional) ---
+	var submissionServer, submissionTLSServer *gosmtp.Server
+	if cfg.SMTPSubmission.Enabled {
+		submissionAuth := func(ctx context.Context, apiKey string, remoteIP net.IP) (uuid.UUID, string, error) {
+			authCtx, err := middleware.AuthenticateAPIKey(ctx, apiKey, remoteIP, apiKeyLookup, apiKeyLastUsed)
+			if err != nil {
+				return uuid.Nil, "", err
+			}
+			if !authCtx.HasScope(model.ScopeEmailsSend) {
+				return uuid.Nil, "", errors.New("api key cannot send email")
+			}
+			return authCtx.TeamID, authCtx.Domain, nil
+		}
+		submissionSendLimit := func(ctx context.Context, teamID uuid.UUID) bool {
+			return middleware.SendAllowed(ctx, rdb, rateLimitCfg, teamID)
+		}
+		submissionBackend := smtppkg.NewSubmissionBackend(submissionAuth, services.Email, submissionSendLimit, logger)
+		submissionServer, submissionTLSServer, err = smtppkg.NewSubmissionServers(smtppkg.SubmissionServerConfig{
+			ListenAddr:        cfg.SMTPSubmission.ListenAddr,
+			TLSListenAddr:     cfg.SMTPSubmission.TLSListenAddr,
+			Domain:            cfg.SMTPSubmission.Domain,
+			MaxMessageBytes:   int64(cfg.SMTPSubmission.MaxMessageBytes),
+			ReadTimeout:       cfg.SMTPSubmission.ReadTimeout,
+			WriteTimeout:      cfg.SMTPSubmission.WriteTimeout,
+			TLSCert:           cfg.SMTPSubmission.TLSCert,
+			TLSKey:            cfg.SMTPSubmission.TLSKey,
+			AllowInsecureAuth: cfg.SMTPSubmission.AllowInsecureAuth,
+		}, submissionBackend, logger)
+		if err != nil {
+			logger.Error("failed to create SMTP submission server", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Run all servers concurrently using errgroup.
 	g, gctx := errgroup.WithContext(ctx)
 
@@ -393,6 +551,14 @@ func runServe(configPath string) {
 		return nil
 	})
 
+	// API request log writer.
+	if logWriter != nil {
+		g.Go(func() error {
+			logWriter.Run()
+			return nil
+		})
+	}
+
 	// Asynq worker server.
 	g.Go(func() error {
 		logger.Info("starting worker server", "concurrency", cfg.Workers.Concurrency)
@@ -402,6 +568,15 @@ func runServe(configPath string) {
 		return nil
 	})
 
+	// Periodic task scheduler.
+	g.Go(func() error {
+		logger.Info("starting task scheduler")
+		if err := scheduler.Start(); err != nil {
+			return fmt.Errorf("asynq scheduler: %w", err)
+		}
+		return nil
+	})
+
 	// Inbound SMTP server.
 	if smtpServer != nil {
 		g.Go(func() error {
@@ -413,6 +588,26 @@ func runServe(configPath string) {
 		})
 	}
 
+	// SMTP submission servers.
+	if submissionServer != nil {
+		g.Go(func() error {
+			logger.Info("starting SMTP submission server", "addr", cfg.SMTPSubmission.ListenAddr)
+			if err := submissionServer.ListenAndServe(); err != nil {
+				return fmt.Errorf("smtp submission server: %w", err)
+			}
+			return nil
+		})
+	}
+	if submissionTLSServer != nil {
+		g.Go(func() error {
+			logger.Info("starting SMTP submission server (implicit TLS)", "addr", cfg.SMTPSubmission.TLSListenAddr)
+			if err := submissionTLSServer.ListenAndServeTLS(); err != nil {
+				return fmt.Errorf("smtp submission tls server: %w", err)
+			}
+			return nil
+		})
+	}
+
 	// Graceful shutdown goroutine.
 	g.Go(func() error {
 		<-gctx.Done()
@@ -427,8 +622,14 @@ func runServe(configPath string) {
 			logger.Error("http server shutdown", "error", err)
 		}
 
-		// Shutdown Asynq worker server.
+		// Write the remaining request logs.
+		if logWriter != nil {
+			logWriter.Close()
+		}
+
+		// Shutdown Asynq worker server and scheduler.
 		asynqSrv.Shutdown()
+		scheduler.Shutdown()
 
 		// Shutdown inbound SMTP server.
 		if smtpServer != nil {
@@ -437,6 +638,15 @@ func runServe(configPath string) {
 			}
 		}
 
+		// Shutdown SMTP submission servers.
+		for _, srv := range []*gosmtp.Server{submissionServer, submissionTLSServer} {
+			if srv != nil {
+				if err := srv.Close(); err != nil {
+					logger.Error("smtp submission server shutdown", "error", err)
+				}
+			}
+		}
+
 		return nil
 	})
 
@@ -660,6 +870,196 @@ func runSetup(configPath string) {
 	fmt.Println("Setup complete! You can now start the server with: mailit serve")
 }
 
+// runRekey re-encrypts every stored DKIM private key with the current master
+// key, opening keys sealed with a previous master key or stored in the clear.
+// Run it after changing dkim.master_encryption_key (keeping the old key in
+// dkim.previous_master_keys) or switching dkim.key_wrapper; once it succeeds,
+// the previous keys can be removed from the configuration.
+func runRekey(configPath string, dryRun bool) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	keyring, err := buildKeyring(cfg.DKIM)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring DKIM key encryption: %v\n", err)
+		os.Exit(1)
+	}
+	if !keyring.Encrypts() {
+		fmt.Fprintln(os.Stderr, "Warning: no DKIM master key configured; keys will be stored unencrypted")
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting transaction: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx,
+		`SELECT id, name, dkim_private_key, dkim_pending_private_key FROM domains
+		 WHERE dkim_private_key IS NOT NULL OR dkim_pending_private_key IS NOT NULL
+		 FOR UPDATE`)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing domains: %v\n", err)
+		os.Exit(1)
+	}
+	type domainKeys struct {
+		id              uuid.UUID
+		name            string
+		active, pending *string
+	}
+	var domains []domainKeys
+	for rows.Next() {
+		var d domainKeys
+		if err := rows.Scan(&d.id, &d.name, &d.active, &d.pending); err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning domain: %v\n", err)
+			os.Exit(1)
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing domains: %v\n", err)
+		os.Exit(1)
+	}
+
+	reseal := func(stored *string) (*string, bool, error) {
+		if stored == nil || keyring.SealedWithPrimary(*stored) {
+			return stored, false, nil
+		}
+		plaintext, err := keyring.Open(ctx, *stored)
+		if err != nil {
+			return nil, false, err
+		}
+		sealed, err := keyring.Seal(ctx, plaintext)
+		if err != nil {
+			return nil, false, err
+		}
+		return &sealed, true, nil
+	}
+
+	rekeyed := 0
+	for _, d := range domains {
+		active, activeChanged, err := reseal(d.active)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error re-encrypting DKIM key of %s: %v\n", d.name, err)
+			os.Exit(1)
+		}
+		pending, pendingChanged, err := reseal(d.pending)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error re-encrypting pending DKIM key of %s: %v\n", d.name, err)
+			os.Exit(1)
+		}
+		if !activeChanged && !pendingChanged {
+			continue
+		}
+
+		rekeyed++
+		fmt.Printf("  %s\n", d.name)
+		if dryRun {
+			continue
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE domains SET dkim_private_key = $2, dkim_pending_private_key = $3, updated_at = NOW() WHERE id = $1`,
+			d.id, active, pending,
+		); err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", d.name, err)
+			os.Exit(1)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("%d of %d domains have DKIM keys to re-encrypt.\n", rekeyed, len(domains))
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error committing transaction: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Re-encrypted the DKIM keys of %d of %d domains.\n", rekeyed, len(domains))
+}
+
+// buildKeyring creates the keyring DKIM private keys are sealed with. Keys
+// are sealed with the transit service or the local master key, as selected
+// by dkim.key_wrapper; the previous master keys, and the local master key
+// when transit is used, remain available for opening existing keys. Without
+// any master key, keys are stored in the clear.
+func buildKeyring(cfg config.DKIMConfig) (*engine.Keyring, error) {
+	var previous []engine.KeyWrapper
+	for _, key := range cfg.PreviousMasterKeys {
+		w, err := engine.NewLocalKeyWrapper(key)
+		if err != nil {
+			return nil, fmt.Errorf("dkim.previous_master_keys: %w", err)
+		}
+		previous = append(previous, w)
+	}
+
+	var local engine.KeyWrapper
+	switch {
+	case cfg.MasterKeyFile != "":
+		w, err := engine.LoadLocalKeyWrapper(cfg.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("dkim.master_key_file: %w", err)
+		}
+		local = w
+	case cfg.MasterEncryptionKey != "":
+		w, err := engine.NewLocalKeyWrapper(cfg.MasterEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("dkim.master_encryption_key: %w", err)
+		}
+		local = w
+	}
+
+	if cfg.KeyWrapper != "transit" {
+		return engine.NewKeyring(local, previous...), nil
+	}
+
+	transit, err := engine.NewTransitKeyWrapper(engine.TransitConfig{
+		Address: cfg.Transit.Address,
+		Token:   cfg.Transit.Token,
+		Mount:   cfg.Transit.Mount,
+		KeyName: cfg.Transit.KeyName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if local != nil {
+		previous = append(previous, local)
+	}
+	return engine.NewKeyring(transit, previous...), nil
+}
+
+// sealedDKIMKeyGenerator returns a worker.DKIMKeyGenerator generating keys
+// sealed with keyring.
+func sealedDKIMKeyGenerator(keyring *engine.Keyring) worker.DKIMKeyGenerator {
+	return func(ctx context.Context, algorithm string, bits int) (string, string, error) {
+		privateKey, dnsValue, err := engine.GenerateDKIMKey(algorithm, bits)
+		if err != nil {
+			return "", "", err
+		}
+		sealed, err := keyring.Seal(ctx, privateKey)
+		if err != nil {
+			return "", "", fmt.Errorf("encrypting DKIM private key: %w", err)
+		}
+		return sealed, dnsValue, nil
+	}
+}
+
 // setupLogger creates a slog.Logger based on the logging config.
 func setupLogger(cfg config.LoggingConfig) *slog.Logger {
 	var level slog.Level
@@ -0,0 +1,2 @@
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS topic_id;
+ALTER TABLE topics DROP COLUMN IF EXISTS default_subscription;
@@ -0,0 +1,7 @@
+-- Topics declare whether contacts without an explicit contact_topics row are
+-- subscribed (opt_in) or not (opt_out).
+ALTER TABLE topics ADD COLUMN default_subscription VARCHAR(10) NOT NULL DEFAULT 'opt_in'
+    CHECK (default_subscription IN ('opt_in', 'opt_out'));
+
+-- Broadcasts may be sent on behalf of a topic; contacts not subscribed to it are skipped.
+ALTER TABLE broadcasts ADD COLUMN topic_id UUID REFERENCES topics(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_webhook_events_webhook_created;
+
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS webhook_events_status_check;
+UPDATE webhook_events SET status = 'sent' WHERE status = 'delivered';
+ALTER TABLE webhook_events ADD CONSTRAINT webhook_events_status_check
+    CHECK (status IN ('pending', 'sent', 'failed'));
@@ -0,0 +1,10 @@
+-- The dispatcher records successful deliveries as 'delivered'; the original
+-- constraint only allowed 'sent'.
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS webhook_events_status_check;
+UPDATE webhook_events SET status = 'delivered' WHERE status = 'sent';
+ALTER TABLE webhook_events ADD CONSTRAINT webhook_events_status_check
+    CHECK (status IN ('pending', 'delivered', 'failed'));
+
+-- Support the per-webhook event log, newest first.
+CREATE INDEX IF NOT EXISTS idx_webhook_events_webhook_created
+    ON webhook_events (webhook_id, created_at DESC);
@@ -0,0 +1,12 @@
+DELETE FROM webhook_events WHERE status = 'skipped';
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS webhook_events_status_check;
+ALTER TABLE webhook_events ADD CONSTRAINT webhook_events_status_check
+    CHECK (status IN ('pending', 'delivered', 'failed'));
+
+ALTER TABLE webhooks DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS last_failed_at;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS last_delivered_at;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS failed_count;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS delivered_count;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS consecutive_failures;
@@ -0,0 +1,14 @@
+-- Delivery health per endpoint, used to disable endpoints that keep failing.
+ALTER TABLE webhooks ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhooks ADD COLUMN delivered_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhooks ADD COLUMN failed_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhooks ADD COLUMN last_delivered_at TIMESTAMPTZ;
+ALTER TABLE webhooks ADD COLUMN last_failed_at TIMESTAMPTZ;
+ALTER TABLE webhooks ADD COLUMN disabled_at TIMESTAMPTZ;
+ALTER TABLE webhooks ADD COLUMN disabled_reason TEXT;
+
+-- Events raised while an endpoint is automatically disabled are kept as
+-- 'skipped' so they can be backfilled when it is re-enabled.
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS webhook_events_status_check;
+ALTER TABLE webhook_events ADD CONSTRAINT webhook_events_status_check
+    CHECK (status IN ('pending', 'delivered', 'failed', 'skipped'));
@@ -0,0 +1,8 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS allowed_ips;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS scopes;
+
+DELETE FROM api_keys WHERE permission NOT IN ('full', 'sending');
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_permission_check;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_permission_check
+    CHECK (permission IN ('full', 'sending'));
@@ -0,0 +1,9 @@
+-- Finer-grained API key permissions: read-only and custom scoped keys, IP
+-- allowlists and expiry dates.
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_permission_check;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_permission_check
+    CHECK (permission IN ('full', 'sending', 'read_only', 'custom'));
+
+ALTER TABLE api_keys ADD COLUMN scopes TEXT[];
+ALTER TABLE api_keys ADD COLUMN allowed_ips TEXT[];
+ALTER TABLE api_keys ADD COLUMN expires_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Sandbox mode lets a team send from domains that are not verified yet, but
+-- only to the addresses of its own members.
+ALTER TABLE teams ADD COLUMN sandbox_mode BOOLEAN NOT NULL DEFAULT false;
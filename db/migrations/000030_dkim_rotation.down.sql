@@ -0,0 +1,8 @@
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_rotated_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_rotation_interval_days;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_previous_retire_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_previous_selector;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_pending_algorithm;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_pending_private_key;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_pending_selector;
+ALTER TABLE domains DROP COLUMN IF EXISTS dkim_algorithm;
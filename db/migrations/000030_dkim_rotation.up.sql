@@ -0,0 +1,14 @@
+-- DKIM key rotation. A rotation publishes a pending selector and key next to
+-- the active one; once its DNS record verifies, signing switches to it and
+-- the previous selector stays published until it is retired.
+ALTER TABLE domains ADD COLUMN dkim_algorithm VARCHAR(10) NOT NULL DEFAULT 'rsa'
+    CHECK (dkim_algorithm IN ('rsa', 'ed25519'));
+ALTER TABLE domains ADD COLUMN dkim_pending_selector VARCHAR(63);
+ALTER TABLE domains ADD COLUMN dkim_pending_private_key TEXT;
+ALTER TABLE domains ADD COLUMN dkim_pending_algorithm VARCHAR(10)
+    CHECK (dkim_pending_algorithm IN ('rsa', 'ed25519'));
+ALTER TABLE domains ADD COLUMN dkim_previous_selector VARCHAR(63);
+ALTER TABLE domains ADD COLUMN dkim_previous_retire_at TIMESTAMPTZ;
+ALTER TABLE domains ADD COLUMN dkim_rotation_interval_days INTEGER
+    CHECK (dkim_rotation_interval_days > 0);
+ALTER TABLE domains ADD COLUMN dkim_rotated_at TIMESTAMPTZ;
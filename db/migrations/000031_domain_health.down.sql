@@ -0,0 +1,6 @@
+ALTER TABLE domains DROP COLUMN IF EXISTS last_checked_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS sending_paused_at;
+UPDATE domains SET status = 'failed' WHERE status = 'degraded';
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS domains_status_check;
+ALTER TABLE domains ADD CONSTRAINT domains_status_check
+    CHECK (status IN ('pending', 'verified', 'failed'));
@@ -0,0 +1,8 @@
+-- Continuous domain health monitoring. A verified domain whose critical DNS
+-- records stop verifying becomes 'degraded' rather than 'failed', and sending
+-- can be paused while its active DKIM record is missing.
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS domains_status_check;
+ALTER TABLE domains ADD CONSTRAINT domains_status_check
+    CHECK (status IN ('pending', 'verified', 'degraded', 'failed'));
+ALTER TABLE domains ADD COLUMN sending_paused_at TIMESTAMPTZ;
+ALTER TABLE domains ADD COLUMN last_checked_at TIMESTAMPTZ;
@@ -0,0 +1,38 @@
+-- DMARC aggregate (RUA) reports received for a domain, one row per report.
+-- Reporters resend reports, so (domain, reporter, report id) is unique.
+CREATE TABLE dmarc_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    domain_id UUID NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    org_name VARCHAR(255) NOT NULL,
+    reporter_email VARCHAR(255) NOT NULL DEFAULT '',
+    report_id VARCHAR(255) NOT NULL,
+    policy_domain VARCHAR(255) NOT NULL,
+    policy VARCHAR(20) NOT NULL DEFAULT '',
+    date_begin TIMESTAMPTZ NOT NULL,
+    date_end TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (domain_id, org_name, report_id)
+);
+
+CREATE INDEX idx_dmarc_reports_domain_date ON dmarc_reports (domain_id, date_begin DESC);
+
+-- The rows of a report: the results for the messages from one source IP.
+-- dkim_result and spf_result are the aligned, policy-evaluated results.
+CREATE TABLE dmarc_report_records (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    report_id UUID NOT NULL REFERENCES dmarc_reports(id) ON DELETE CASCADE,
+    source_ip VARCHAR(45) NOT NULL,
+    message_count INTEGER NOT NULL,
+    disposition VARCHAR(20) NOT NULL DEFAULT '',
+    dkim_result VARCHAR(20) NOT NULL DEFAULT '',
+    spf_result VARCHAR(20) NOT NULL DEFAULT '',
+    header_from VARCHAR(255) NOT NULL DEFAULT '',
+    envelope_from VARCHAR(255) NOT NULL DEFAULT '',
+    dkim_domain VARCHAR(255) NOT NULL DEFAULT '',
+    dkim_auth_result VARCHAR(20) NOT NULL DEFAULT '',
+    spf_domain VARCHAR(255) NOT NULL DEFAULT '',
+    spf_auth_result VARCHAR(20) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_dmarc_report_records_report_id ON dmarc_report_records (report_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_logs_api_key_id;
+DROP INDEX IF EXISTS idx_logs_team_created_at;
+ALTER TABLE logs DROP COLUMN IF EXISTS response_body;
+ALTER TABLE logs DROP COLUMN IF EXISTS request_body;
+ALTER TABLE logs DROP COLUMN IF EXISTS api_key_id;
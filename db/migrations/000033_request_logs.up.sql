@@ -0,0 +1,8 @@
+-- API request logging. Each authenticated request is logged with the API key
+-- that made it and its redacted request and response bodies.
+ALTER TABLE logs ADD COLUMN api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
+ALTER TABLE logs ADD COLUMN request_body JSONB;
+ALTER TABLE logs ADD COLUMN response_body JSONB;
+
+CREATE INDEX idx_logs_team_created_at ON logs(team_id, created_at DESC);
+CREATE INDEX idx_logs_api_key_id ON logs(api_key_id) WHERE api_key_id IS NOT NULL;
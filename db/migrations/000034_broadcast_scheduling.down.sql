@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_broadcasts_next_send_at;
+
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS local_sent_until;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS next_send_at;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS timezone_property;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS send_in_local_time;
+
+UPDATE broadcasts SET status = 'draft' WHERE status = 'scheduled';
+ALTER TABLE broadcasts DROP CONSTRAINT broadcasts_status_check;
+ALTER TABLE broadcasts ADD CONSTRAINT broadcasts_status_check
+    CHECK (status IN ('draft', 'queued', 'sending', 'sent', 'cancelled'));
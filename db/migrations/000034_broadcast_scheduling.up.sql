@@ -0,0 +1,14 @@
+-- Scheduled broadcasts. A scheduled broadcast waits in Postgres until
+-- next_send_at, when the dispatcher queues it. Broadcasts sent in each
+-- recipient's local time go out in hourly waves; local_sent_until is the
+-- latest send time already covered by a wave.
+ALTER TABLE broadcasts DROP CONSTRAINT broadcasts_status_check;
+ALTER TABLE broadcasts ADD CONSTRAINT broadcasts_status_check
+    CHECK (status IN ('draft', 'scheduled', 'queued', 'sending', 'sent', 'cancelled'));
+
+ALTER TABLE broadcasts ADD COLUMN send_in_local_time BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE broadcasts ADD COLUMN timezone_property VARCHAR(255);
+ALTER TABLE broadcasts ADD COLUMN next_send_at TIMESTAMPTZ;
+ALTER TABLE broadcasts ADD COLUMN local_sent_until TIMESTAMPTZ;
+
+CREATE INDEX idx_broadcasts_next_send_at ON broadcasts(next_send_at) WHERE next_send_at IS NOT NULL;
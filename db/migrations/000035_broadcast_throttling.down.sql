@@ -0,0 +1,15 @@
+ALTER TABLE domains DROP COLUMN IF EXISTS warmup_started_at;
+
+DROP INDEX IF EXISTS idx_emails_domain_id;
+DROP INDEX IF EXISTS idx_emails_tags;
+
+UPDATE emails SET status = 'cancelled' WHERE status = 'held';
+ALTER TABLE emails DROP CONSTRAINT emails_status_check;
+ALTER TABLE emails ADD CONSTRAINT emails_status_check
+    CHECK (status IN ('queued', 'scheduled', 'sending', 'sent', 'delivered', 'bounced', 'failed', 'cancelled'));
+
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS send_rate;
+UPDATE broadcasts SET status = 'cancelled' WHERE status = 'paused';
+ALTER TABLE broadcasts DROP CONSTRAINT broadcasts_status_check;
+ALTER TABLE broadcasts ADD CONSTRAINT broadcasts_status_check
+    CHECK (status IN ('draft', 'scheduled', 'queued', 'sending', 'sent', 'cancelled'));
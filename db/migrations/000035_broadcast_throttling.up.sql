@@ -0,0 +1,17 @@
+-- Throttled broadcasts. A broadcast's emails are created "held" and released
+-- to the send queue at up to send_rate a minute, and within the daily limit
+-- of a domain warming up. Pausing a broadcast holds its queued emails again.
+ALTER TABLE broadcasts DROP CONSTRAINT broadcasts_status_check;
+ALTER TABLE broadcasts ADD CONSTRAINT broadcasts_status_check
+    CHECK (status IN ('draft', 'scheduled', 'queued', 'sending', 'paused', 'sent', 'cancelled'));
+ALTER TABLE broadcasts ADD COLUMN send_rate INTEGER CHECK (send_rate > 0);
+
+ALTER TABLE emails DROP CONSTRAINT emails_status_check;
+ALTER TABLE emails ADD CONSTRAINT emails_status_check
+    CHECK (status IN ('held', 'queued', 'scheduled', 'sending', 'sent', 'delivered', 'bounced', 'failed', 'cancelled'));
+
+-- Broadcast emails are found by their broadcast:<id> tag.
+CREATE INDEX idx_emails_tags ON emails USING GIN (tags);
+CREATE INDEX idx_emails_domain_id ON emails(domain_id);
+
+ALTER TABLE domains ADD COLUMN warmup_started_at TIMESTAMPTZ;
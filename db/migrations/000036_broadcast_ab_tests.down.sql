@@ -0,0 +1,7 @@
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS ab_winner_variant_id;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS ab_test_ends_at;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS ab_winner_metric;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS ab_test_wait_minutes;
+ALTER TABLE broadcasts DROP COLUMN IF EXISTS ab_test_percentage;
+
+DROP TABLE IF EXISTS broadcast_variants;
@@ -0,0 +1,23 @@
+-- A/B tested broadcasts. Each variant first goes to ab_test_percentage
+-- percent of the audience; ab_test_wait_minutes after that test group is
+-- sent (ab_test_ends_at), the variant with the best ab_winner_metric goes to
+-- the rest. Test emails are found by their broadcast_variant:<id> tag.
+CREATE TABLE broadcast_variants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    broadcast_id UUID NOT NULL REFERENCES broadcasts(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL,
+    subject TEXT,
+    from_name VARCHAR(255),
+    template_version INTEGER,
+    html_body TEXT,
+    text_body TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(broadcast_id, position)
+);
+
+ALTER TABLE broadcasts ADD COLUMN ab_test_percentage INTEGER CHECK (ab_test_percentage BETWEEN 1 AND 100);
+ALTER TABLE broadcasts ADD COLUMN ab_test_wait_minutes INTEGER CHECK (ab_test_wait_minutes > 0);
+ALTER TABLE broadcasts ADD COLUMN ab_winner_metric VARCHAR(20) CHECK (ab_winner_metric IN ('open_rate', 'click_rate'));
+ALTER TABLE broadcasts ADD COLUMN ab_test_ends_at TIMESTAMPTZ;
+ALTER TABLE broadcasts ADD COLUMN ab_winner_variant_id UUID REFERENCES broadcast_variants(id) ON DELETE SET NULL;
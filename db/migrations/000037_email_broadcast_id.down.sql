@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_emails_broadcast_variant_id;
+DROP INDEX IF EXISTS idx_emails_broadcast_id;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS broadcast_variant_id;
+ALTER TABLE emails DROP COLUMN IF EXISTS broadcast_id;
@@ -0,0 +1,16 @@
+-- Broadcast emails point at their broadcast, and A/B test emails at their
+-- variant, through foreign keys. They keep their broadcast:<id> and
+-- broadcast_variant:<id> tags, which existing emails are matched by here.
+ALTER TABLE emails ADD COLUMN broadcast_id UUID REFERENCES broadcasts(id) ON DELETE SET NULL;
+ALTER TABLE emails ADD COLUMN broadcast_variant_id UUID REFERENCES broadcast_variants(id) ON DELETE SET NULL;
+
+UPDATE emails e SET broadcast_id = b.id
+FROM broadcasts b
+WHERE e.tags @> ARRAY['broadcast:' || b.id::text];
+
+UPDATE emails e SET broadcast_variant_id = v.id
+FROM broadcast_variants v
+WHERE e.tags @> ARRAY['broadcast_variant:' || v.id::text];
+
+CREATE INDEX idx_emails_broadcast_id ON emails(broadcast_id, status) WHERE broadcast_id IS NOT NULL;
+CREATE INDEX idx_emails_broadcast_variant_id ON emails(broadcast_variant_id) WHERE broadcast_variant_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- An A/B test none of whose variants was opened or clicked, by its
+-- ab_winner_metric, has no winner. The rest of the audience is sent the
+-- broadcast's own content instead.
+ALTER TABLE broadcasts ADD COLUMN ab_test_inconclusive BOOLEAN NOT NULL DEFAULT false;
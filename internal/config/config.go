@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -14,21 +15,24 @@ import (
 
 // Config holds the complete application configuration.
 type Config struct {
-	Server        ServerConfig        `mapstructure:"server"`
-	Database      DatabaseConfig      `mapstructure:"database"`
-	Redis         RedisConfig         `mapstructure:"redis"`
-	Auth          AuthConfig          `mapstructure:"auth"`
-	SMTPOutbound  SMTPOutboundConfig  `mapstructure:"smtp_outbound"`
-	SMTPInbound   SMTPInboundConfig   `mapstructure:"smtp_inbound"`
-	DKIM          DKIMConfig          `mapstructure:"dkim"`
-	Workers       WorkersConfig       `mapstructure:"workers"`
-	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
-	Webhooks      WebhooksConfig      `mapstructure:"webhooks"`
-	DNS           DNSConfig           `mapstructure:"dns"`
-	Logging       LoggingConfig       `mapstructure:"logging"`
-	Storage       StorageConfig       `mapstructure:"storage"`
-	Suppression   SuppressionConfig   `mapstructure:"suppression"`
-	Observability ObservabilityConfig `mapstructure:"observability"`
+	Server         ServerConfig         `mapstructure:"server"`
+	Database       DatabaseConfig       `mapstructure:"database"`
+	Redis          RedisConfig          `mapstructure:"redis"`
+	Auth           AuthConfig           `mapstructure:"auth"`
+	SMTPOutbound   SMTPOutboundConfig   `mapstructure:"smtp_outbound"`
+	SMTPInbound    SMTPInboundConfig    `mapstructure:"smtp_inbound"`
+	SMTPSubmission SMTPSubmissionConfig `mapstructure:"smtp_submission"`
+	DKIM           DKIMConfig           `mapstructure:"dkim"`
+	DomainMonitor  DomainMonitorConfig  `mapstructure:"domain_monitor"`
+	RequestLogs    RequestLogsConfig    `mapstructure:"request_logs"`
+	Workers        WorkersConfig        `mapstructure:"workers"`
+	RateLimit      RateLimitConfig      `mapstructure:"rate_limit"`
+	Webhooks       WebhooksConfig       `mapstructure:"webhooks"`
+	DNS            DNSConfig            `mapstructure:"dns"`
+	Logging        LoggingConfig        `mapstructure:"logging"`
+	Storage        StorageConfig        `mapstructure:"storage"`
+	Suppression    SuppressionConfig    `mapstructure:"suppression"`
+	Observability  ObservabilityConfig  `mapstructure:"observability"`
 }
 
 // ServerConfig holds HTTP server settings.
@@ -39,6 +43,9 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 	CORSOrigins     []string      `mapstructure:"cors_origins"`
+	// TrustedProxies lists the reverse proxies, IP addresses or CIDR
+	// ranges, whose X-Forwarded-For and X-Real-IP headers are believed.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // DatabaseConfig holds PostgreSQL connection settings.
@@ -85,6 +92,8 @@ type SMTPOutboundConfig struct {
 	Port           int           `mapstructure:"port"`
 	HELODomain     string        `mapstructure:"helo_domain"`
 	TLSPolicy      string        `mapstructure:"tls_policy"`
+	MTASTS         bool          `mapstructure:"mta_sts"` // honour recipients' MTA-STS policies
+	DANE           bool          `mapstructure:"dane"`    // honour DANE TLSA records; needs a validating resolver
 	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
 	SendTimeout    time.Duration `mapstructure:"send_timeout"`
 	MaxRecipients  int           `mapstructure:"max_recipients"`
@@ -106,11 +115,66 @@ type SMTPInboundConfig struct {
 	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
 }
 
+// SMTPSubmissionConfig holds settings for the authenticated SMTP submission
+// server, which accepts outbound mail from legacy applications and relays
+// using an API key as the AUTH password.
+type SMTPSubmissionConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	ListenAddr        string        `mapstructure:"listen_addr"`     // STARTTLS
+	TLSListenAddr     string        `mapstructure:"tls_listen_addr"` // implicit TLS
+	Domain            string        `mapstructure:"domain"`
+	MaxMessageBytes   int           `mapstructure:"max_message_bytes"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	TLSCert           string        `mapstructure:"tls_cert"`
+	TLSKey            string        `mapstructure:"tls_key"`
+	AllowInsecureAuth bool          `mapstructure:"allow_insecure_auth"`
+}
+
 // DKIMConfig holds DKIM signing settings.
 type DKIMConfig struct {
-	Selector            string `mapstructure:"selector"`
-	KeyBits             int    `mapstructure:"key_bits"`
-	MasterEncryptionKey string `mapstructure:"master_encryption_key"`
+	Selector            string        `mapstructure:"selector"`
+	KeyBits             int           `mapstructure:"key_bits"`
+	MasterEncryptionKey string        `mapstructure:"master_encryption_key"`
+	RetireAfter         time.Duration `mapstructure:"retire_after"` // how long a rotated-out key stays published
+	// KeyWrapper selects how stored private keys are encrypted: "local"
+	// uses master_encryption_key or master_key_file, "transit" an external
+	// Vault-style transit service.
+	KeyWrapper    string `mapstructure:"key_wrapper"`
+	MasterKeyFile string `mapstructure:"master_key_file"`
+	// PreviousMasterKeys are retired local master keys, still used to
+	// decrypt keys until `mailit rekey` re-encrypts them.
+	PreviousMasterKeys []string      `mapstructure:"previous_master_keys"`
+	Transit            TransitConfig `mapstructure:"transit"`
+}
+
+// TransitConfig holds the settings of a Vault-style transit encryption
+// service.
+type TransitConfig struct {
+	Address string `mapstructure:"address"`
+	Token   string `mapstructure:"token"`
+	Mount   string `mapstructure:"mount"`
+	KeyName string `mapstructure:"key_name"`
+}
+
+// DomainMonitorConfig holds the settings of the periodic re-verification
+// of verified domains' DNS records.
+type DomainMonitorConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"` // how often each domain is re-checked
+	// PauseOnDKIMMissing pauses sending from a domain whose DKIM record has
+	// disappeared from DNS until the record is back.
+	PauseOnDKIMMissing bool `mapstructure:"pause_on_dkim_missing"`
+}
+
+// RequestLogsConfig holds the settings of API request logging.
+type RequestLogsConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Retention     time.Duration `mapstructure:"retention"`      // how long logs are kept
+	BatchSize     int           `mapstructure:"batch_size"`     // logs written per insert
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // longest wait before a partial batch is written
+	BufferSize    int           `mapstructure:"buffer_size"`    // logs held in memory before new ones are dropped
+	MaxBodyBytes  int           `mapstructure:"max_body_bytes"` // larger request and response bodies are not stored
 }
 
 // WorkersConfig holds background worker settings.
@@ -148,6 +212,9 @@ type WebhooksConfig struct {
 	Timeout          time.Duration `mapstructure:"timeout"`
 	MaxRetries       int      `mapstructure:"max_retries"`
 	RetryDelays      []string `mapstructure:"retry_delays"`
+	// DisableAfterFailures disables an endpoint after this many consecutive
+	// failed delivery attempts. A negative value never disables endpoints.
+	DisableAfterFailures int `mapstructure:"disable_after_failures"`
 }
 
 // ParseRetryDelays parses the string retry delays into time.Duration values.
@@ -182,6 +249,9 @@ type StorageConfig struct {
 	Type      string   `mapstructure:"type"`
 	LocalPath string   `mapstructure:"local_path"`
 	S3        S3Config `mapstructure:"s3"`
+	// InlineMaxBytes is the largest outbound attachment kept inline in the
+	// email record; larger attachments are written to storage.
+	InlineMaxBytes int `mapstructure:"inline_max_bytes"`
 }
 
 // S3Config holds S3-compatible storage settings.
@@ -261,6 +331,8 @@ func defaults() map[string]interface{} {
 		"smtp_outbound.port":            25,
 		"smtp_outbound.helo_domain":     "",
 		"smtp_outbound.tls_policy":      "opportunistic",
+		"smtp_outbound.mta_sts":         true,
+		"smtp_outbound.dane":            true,
 		"smtp_outbound.connect_timeout": "30s",
 		"smtp_outbound.send_timeout":    "5m",
 		"smtp_outbound.max_recipients":  50,
@@ -273,10 +345,39 @@ func defaults() map[string]interface{} {
 		"smtp_inbound.read_timeout":      "60s",
 		"smtp_inbound.write_timeout":     "60s",
 
+		// SMTP Submission
+		"smtp_submission.enabled":             false,
+		"smtp_submission.listen_addr":         ":587",
+		"smtp_submission.tls_listen_addr":     ":465",
+		"smtp_submission.domain":              "",
+		"smtp_submission.max_message_bytes":   41943040,
+		"smtp_submission.read_timeout":        "60s",
+		"smtp_submission.write_timeout":       "60s",
+		"smtp_submission.tls_cert":            "",
+		"smtp_submission.tls_key":             "",
+		"smtp_submission.allow_insecure_auth": false,
+
 		// DKIM
 		"dkim.selector":              "mailit",
 		"dkim.key_bits":              2048,
 		"dkim.master_encryption_key": "",
+		"dkim.retire_after":          "168h",
+		"dkim.key_wrapper":           "local",
+		"dkim.master_key_file":       "",
+		"dkim.transit.mount":         "transit",
+
+		// Domain Monitor
+		"domain_monitor.enabled":               true,
+		"domain_monitor.interval":              "6h",
+		"domain_monitor.pause_on_dkim_missing": false,
+
+		// Request Logs
+		"request_logs.enabled":        true,
+		"request_logs.retention":      "2160h",
+		"request_logs.batch_size":     100,
+		"request_logs.flush_interval": "2s",
+		"request_logs.buffer_size":    10000,
+		"request_logs.max_body_bytes": 65536,
 
 		// Workers
 		"workers.concurrency": 20,
@@ -292,6 +393,7 @@ func defaults() map[string]interface{} {
 		"webhooks.signing_algorithm": "hmac-sha256",
 		"webhooks.timeout":           "30s",
 		"webhooks.max_retries":       5,
+		"webhooks.disable_after_failures": 50,
 
 		// DNS
 		"dns.resolver":  "system",
@@ -304,8 +406,9 @@ func defaults() map[string]interface{} {
 		"logging.output": "stdout",
 
 		// Storage
-		"storage.type":       "local",
-		"storage.local_path": "./data/attachments",
+		"storage.type":             "local",
+		"storage.local_path":       "./data/attachments",
+		"storage.inline_max_bytes": 262144,
 
 		// SMTP Outbound Relay
 		"smtp_outbound.relay_mode": "direct",
@@ -365,5 +468,13 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("unmarshalling config: %w", err)
 	}
 
+	for _, proxy := range cfg.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("server.trusted_proxies: %q is not an IP address or CIDR range", proxy)
+			}
+		}
+	}
+
 	return &cfg, nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -54,6 +55,8 @@ func TestLoad_Defaults(t *testing.T) {
 	// SMTP Outbound defaults.
 	assert.Equal(t, 25, cfg.SMTPOutbound.Port)
 	assert.Equal(t, "opportunistic", cfg.SMTPOutbound.TLSPolicy)
+	assert.True(t, cfg.SMTPOutbound.MTASTS)
+	assert.True(t, cfg.SMTPOutbound.DANE)
 	assert.Equal(t, 50, cfg.SMTPOutbound.MaxRecipients)
 
 	// SMTP Inbound defaults.
@@ -65,6 +68,19 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "mailit", cfg.DKIM.Selector)
 	assert.Equal(t, 2048, cfg.DKIM.KeyBits)
 
+	// Domain monitor defaults.
+	assert.True(t, cfg.DomainMonitor.Enabled)
+	assert.Equal(t, 6*time.Hour, cfg.DomainMonitor.Interval)
+	assert.False(t, cfg.DomainMonitor.PauseOnDKIMMissing)
+
+	// Request log defaults.
+	assert.True(t, cfg.RequestLogs.Enabled)
+	assert.Equal(t, 90*24*time.Hour, cfg.RequestLogs.Retention)
+	assert.Equal(t, 100, cfg.RequestLogs.BatchSize)
+	assert.Equal(t, 2*time.Second, cfg.RequestLogs.FlushInterval)
+	assert.Equal(t, 10000, cfg.RequestLogs.BufferSize)
+	assert.Equal(t, 65536, cfg.RequestLogs.MaxBodyBytes)
+
 	// Workers defaults.
 	assert.Equal(t, 20, cfg.Workers.Concurrency)
 
@@ -42,11 +42,48 @@ func (c *Config) Validate() error {
 
 	// DKIM master encryption key (optional, but validated if set)
 	if c.DKIM.MasterEncryptionKey != "" {
-		decoded, err := hex.DecodeString(c.DKIM.MasterEncryptionKey)
-		if err != nil {
-			errs = append(errs, "dkim.master_encryption_key must be valid hex")
-		} else if len(decoded) < 32 {
-			errs = append(errs, "dkim.master_encryption_key must be at least 32 bytes (64 hex chars)")
+		errs = append(errs, validateMasterKey("dkim.master_encryption_key", c.DKIM.MasterEncryptionKey)...)
+	}
+	for _, key := range c.DKIM.PreviousMasterKeys {
+		errs = append(errs, validateMasterKey("dkim.previous_master_keys", key)...)
+	}
+	switch c.DKIM.KeyWrapper {
+	case "", "local":
+		if c.DKIM.MasterEncryptionKey != "" && c.DKIM.MasterKeyFile != "" {
+			errs = append(errs, "dkim.master_encryption_key and dkim.master_key_file are mutually exclusive")
+		}
+	case "transit":
+		if c.DKIM.Transit.Address == "" {
+			errs = append(errs, "dkim.transit.address is required when dkim.key_wrapper is transit")
+		}
+		if c.DKIM.Transit.KeyName == "" {
+			errs = append(errs, "dkim.transit.key_name is required when dkim.key_wrapper is transit")
+		}
+	default:
+		errs = append(errs, "dkim.key_wrapper must be local or transit")
+	}
+
+	// Domain Monitor
+	if c.DomainMonitor.Enabled && c.DomainMonitor.Interval <= 0 {
+		errs = append(errs, "domain_monitor.interval must be positive")
+	}
+
+	// Request Logs
+	if c.RequestLogs.Enabled {
+		if c.RequestLogs.Retention <= 0 {
+			errs = append(errs, "request_logs.retention must be positive")
+		}
+		if c.RequestLogs.BatchSize <= 0 {
+			errs = append(errs, "request_logs.batch_size must be positive")
+		}
+		if c.RequestLogs.FlushInterval <= 0 {
+			errs = append(errs, "request_logs.flush_interval must be positive")
+		}
+		if c.RequestLogs.BufferSize <= 0 {
+			errs = append(errs, "request_logs.buffer_size must be positive")
+		}
+		if c.RequestLogs.MaxBodyBytes <= 0 {
+			errs = append(errs, "request_logs.max_body_bytes must be positive")
 		}
 	}
 
@@ -55,3 +92,14 @@ func (c *Config) Validate() error {
 	}
 	return nil
 }
+
+func validateMasterKey(field, key string) []string {
+	decoded, err := hex.DecodeString(key)
+	if err != nil {
+		return []string{field + " must be valid hex"}
+	}
+	if len(decoded) < 32 {
+		return []string{field + " must be at least 32 bytes (64 hex chars)"}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -113,6 +114,78 @@ func TestValidate_ValidDKIMKey(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestValidate_DKIMKeyWrapper(t *testing.T) {
+	t.Run("unknown wrapper", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.DKIM.KeyWrapper = "kms"
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dkim.key_wrapper must be local or transit")
+	})
+
+	t.Run("key and key file", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.DKIM.MasterEncryptionKey = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		cfg.DKIM.MasterKeyFile = "/run/secrets/dkim-master-key"
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mutually exclusive")
+	})
+
+	t.Run("short previous key", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.DKIM.PreviousMasterKeys = []string{"0123456789abcdef"}
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dkim.previous_master_keys must be at least 32 bytes")
+	})
+
+	t.Run("transit", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.DKIM.KeyWrapper = "transit"
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dkim.transit.address is required")
+		assert.Contains(t, err.Error(), "dkim.transit.key_name is required")
+
+		cfg.DKIM.Transit = TransitConfig{Address: "https://vault:8200", KeyName: "mailit-dkim"}
+		assert.NoError(t, cfg.Validate())
+	})
+}
+
+func TestValidate_DomainMonitorInterval(t *testing.T) {
+	cfg := validConfig()
+	cfg.DomainMonitor.Enabled = true
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "domain_monitor.interval must be positive")
+
+	cfg.DomainMonitor.Interval = time.Hour
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_RequestLogs(t *testing.T) {
+	cfg := validConfig()
+	cfg.RequestLogs = RequestLogsConfig{Enabled: true}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request_logs.retention must be positive")
+	assert.Contains(t, err.Error(), "request_logs.batch_size must be positive")
+	assert.Contains(t, err.Error(), "request_logs.flush_interval must be positive")
+	assert.Contains(t, err.Error(), "request_logs.buffer_size must be positive")
+	assert.Contains(t, err.Error(), "request_logs.max_body_bytes must be positive")
+
+	cfg.RequestLogs = RequestLogsConfig{
+		Enabled:       true,
+		Retention:     24 * time.Hour,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		BufferSize:    1000,
+		MaxBodyBytes:  1024,
+	}
+	assert.NoError(t, cfg.Validate())
+}
+
 func TestValidate_MultipleErrors(t *testing.T) {
 	cfg := &Config{} // All required fields missing
 	err := cfg.Validate()
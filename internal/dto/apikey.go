@@ -1,16 +1,24 @@
 package dto
 
 type CreateAPIKeyRequest struct {
-	Name       string  `json:"name" validate:"required"`
-	Permission string  `json:"permission" validate:"omitempty,oneof=full sending"`
-	DomainID   *string `json:"domain_id,omitempty" validate:"omitempty,uuid"`
+	Name       string   `json:"name" validate:"required"`
+	Permission string   `json:"permission" validate:"omitempty,oneof=full sending read_only custom"`
+	Scopes     []string `json:"scopes,omitempty"`
+	DomainID   *string  `json:"domain_id,omitempty" validate:"omitempty,uuid"`
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+	ExpiresAt  *string  `json:"expires_at,omitempty"`
 }
 
 type APIKeyResponse struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	Token      string `json:"token,omitempty"` // only on create
-	KeyPrefix  string `json:"key_prefix,omitempty"`
-	Permission string `json:"permission"`
-	CreatedAt  string `json:"created_at"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Token      string   `json:"token,omitempty"` // only on create
+	KeyPrefix  string   `json:"key_prefix,omitempty"`
+	Permission string   `json:"permission"`
+	Scopes     []string `json:"scopes,omitempty"`
+	DomainID   *string  `json:"domain_id,omitempty"`
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+	ExpiresAt  *string  `json:"expires_at,omitempty"`
+	LastUsedAt *string  `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
 }
@@ -19,4 +19,24 @@ type AuthResponse struct {
 		Email string `json:"email"`
 		Name  string `json:"name"`
 	} `json:"user"`
+	Team *TeamSummaryResponse `json:"team,omitempty"`
+}
+
+// SwitchTeamRequest is the request body for POST /auth/switch-team.
+type SwitchTeamRequest struct {
+	TeamID string `json:"team_id" validate:"required,uuid"`
+}
+
+// CreateTeamRequest is the request body for POST /teams.
+type CreateTeamRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// TeamSummaryResponse describes a team the current user belongs to.
+type TeamSummaryResponse struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	Role    string `json:"role"`
+	Current bool   `json:"current"`
 }
@@ -5,20 +5,69 @@ type CreateBroadcastRequest struct {
 	AudienceID *string `json:"audience_id,omitempty" validate:"omitempty,uuid"`
 	SegmentID  *string `json:"segment_id,omitempty" validate:"omitempty,uuid"`
 	TemplateID *string `json:"template_id,omitempty" validate:"omitempty,uuid"`
+	TopicID    *string `json:"topic_id,omitempty" validate:"omitempty,uuid"`
 	From       *string `json:"from,omitempty" validate:"omitempty,email"`
 	Subject    *string `json:"subject,omitempty"`
 	HTML       *string `json:"html,omitempty"`
 	Text       *string `json:"text,omitempty"`
+	// ScheduledAt (RFC 3339) schedules the broadcast when it is sent.
+	ScheduledAt      *string `json:"scheduled_at,omitempty"`
+	SendInLocalTime  *bool   `json:"send_in_local_time,omitempty"`
+	TimezoneProperty *string `json:"timezone_property,omitempty"`
+	// SendRate caps how many emails the broadcast sends a minute.
+	SendRate *int                    `json:"send_rate,omitempty" validate:"omitempty,min=1"`
+	ABTest   *BroadcastABTestRequest `json:"ab_test,omitempty"`
 }
 
 type UpdateBroadcastRequest struct {
 	Name       *string `json:"name,omitempty"`
 	AudienceID *string `json:"audience_id,omitempty" validate:"omitempty,uuid"`
 	SegmentID  *string `json:"segment_id,omitempty" validate:"omitempty,uuid"`
+	TopicID    *string `json:"topic_id,omitempty" validate:"omitempty,uuid"`
 	From       *string `json:"from,omitempty" validate:"omitempty,email"`
 	Subject    *string `json:"subject,omitempty"`
 	HTML       *string `json:"html,omitempty"`
 	Text       *string `json:"text,omitempty"`
+	// An empty ScheduledAt clears the schedule.
+	ScheduledAt      *string `json:"scheduled_at,omitempty"`
+	SendInLocalTime  *bool   `json:"send_in_local_time,omitempty"`
+	TimezoneProperty *string `json:"timezone_property,omitempty"`
+	// A SendRate of 0 removes the cap.
+	SendRate *int `json:"send_rate,omitempty" validate:"omitempty,min=0"`
+	// An ABTest without variants removes the A/B test.
+	ABTest *BroadcastABTestRequest `json:"ab_test,omitempty"`
+}
+
+// BroadcastABTestRequest sets up an A/B test. Each variant is sent to
+// TestPercentage percent of the audience; WaitMinutes later, the variant with
+// the best WinnerMetric is sent to the rest.
+type BroadcastABTestRequest struct {
+	Variants       []BroadcastVariantRequest `json:"variants" validate:"max=5,dive"`
+	TestPercentage int                       `json:"test_percentage" validate:"required,min=1,max=50"`
+	WaitMinutes    int                       `json:"wait_minutes" validate:"required,min=1,max=10080"`
+	WinnerMetric   string                    `json:"winner_metric" validate:"required,oneof=open_rate click_rate"`
+}
+
+// BroadcastVariantRequest describes one variant of an A/B test. Fields left
+// out are taken from the broadcast; TemplateVersion picks a version of the
+// broadcast's template instead of the published one.
+type BroadcastVariantRequest struct {
+	Name            string  `json:"name,omitempty" validate:"max=100"`
+	Subject         *string `json:"subject,omitempty"`
+	FromName        *string `json:"from_name,omitempty" validate:"omitempty,max=255"`
+	TemplateVersion *int    `json:"template_version,omitempty" validate:"omitempty,min=1"`
+	HTML            *string `json:"html,omitempty"`
+	Text            *string `json:"text,omitempty"`
+}
+
+// ScheduleBroadcastRequest schedules or reschedules a broadcast. When
+// SendInLocalTime is set, the wall-clock time of ScheduledAt is used in each
+// recipient's time zone, read from the contact property TimezoneProperty
+// ("timezone" by default).
+type ScheduleBroadcastRequest struct {
+	ScheduledAt      string  `json:"scheduled_at" validate:"required"`
+	SendInLocalTime  *bool   `json:"send_in_local_time,omitempty"`
+	TimezoneProperty *string `json:"timezone_property,omitempty"`
 }
 
 type BroadcastResponse struct {
@@ -26,8 +75,83 @@ type BroadcastResponse struct {
 	Name         string  `json:"name"`
 	AudienceID   *string `json:"audience_id,omitempty"`
 	AudienceName *string `json:"audience_name,omitempty"`
+	TopicID      *string `json:"topic_id,omitempty"`
 	Status       string  `json:"status"`
 	Recipients   int     `json:"recipients"`
 	Sent         int     `json:"sent"`
 	CreatedAt    string  `json:"created_at"`
+
+	ScheduledAt      *string `json:"scheduled_at,omitempty"`
+	SendInLocalTime  bool    `json:"send_in_local_time"`
+	TimezoneProperty *string `json:"timezone_property,omitempty"`
+	NextSendAt       *string `json:"next_send_at,omitempty"`
+	SendRate         *int    `json:"send_rate,omitempty"`
+
+	ABTest *BroadcastABTestResponse `json:"ab_test,omitempty"`
+}
+
+type BroadcastABTestResponse struct {
+	TestPercentage  int                        `json:"test_percentage"`
+	WaitMinutes     int                        `json:"wait_minutes"`
+	WinnerMetric    string                     `json:"winner_metric"`
+	TestEndsAt      *string                    `json:"test_ends_at,omitempty"`
+	WinnerVariantID *string                    `json:"winner_variant_id,omitempty"`
+	Inconclusive    bool                       `json:"inconclusive"`
+	Variants        []BroadcastVariantResponse `json:"variants"`
+}
+
+// BroadcastVariantResponse describes a variant and how its test emails did.
+// The rates are shares of the sent test emails.
+type BroadcastVariantResponse struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Subject         *string `json:"subject,omitempty"`
+	FromName        *string `json:"from_name,omitempty"`
+	TemplateVersion *int    `json:"template_version,omitempty"`
+	Recipients      int     `json:"recipients"`
+	Sent            int     `json:"sent"`
+	Opened          int     `json:"opened"`
+	Clicked         int     `json:"clicked"`
+	OpenRate        float64 `json:"open_rate"`
+	ClickRate       float64 `json:"click_rate"`
+}
+
+// BroadcastStatsResponse is the response for GET /broadcasts/{id}/stats.
+// Delivery and bounce rates are shares of the emails that reached a
+// receiving server, delivered or bounced; the other rates are shares of the
+// delivered emails.
+type BroadcastStatsResponse struct {
+	BroadcastID     string                       `json:"broadcast_id"`
+	Recipients      int                          `json:"recipients"`
+	Delivered       int                          `json:"delivered"`
+	Bounced         int                          `json:"bounced"`
+	Opened          int                          `json:"opened"`
+	Clicked         int                          `json:"clicked"`
+	Unsubscribed    int                          `json:"unsubscribed"`
+	Complained      int                          `json:"complained"`
+	DeliveryRate    float64                      `json:"delivery_rate"`
+	BounceRate      float64                      `json:"bounce_rate"`
+	OpenRate        float64                      `json:"open_rate"`
+	ClickRate       float64                      `json:"click_rate"`
+	UnsubscribeRate float64                      `json:"unsubscribe_rate"`
+	ComplaintRate   float64                      `json:"complaint_rate"`
+	Links           []BroadcastLinkStatsResponse `json:"links"`
+}
+
+type BroadcastLinkStatsResponse struct {
+	URL          string `json:"url"`
+	Clicks       int    `json:"clicks"`
+	UniqueClicks int    `json:"unique_clicks"`
+}
+
+// BroadcastRecipientResponse is an email of a broadcast and the last event
+// recorded for it.
+type BroadcastRecipientResponse struct {
+	EmailID     string  `json:"email_id"`
+	Email       string  `json:"email"`
+	Status      string  `json:"status"`
+	VariantID   *string `json:"variant_id,omitempty"`
+	SentAt      *string `json:"sent_at,omitempty"`
+	LastEvent   *string `json:"last_event,omitempty"`
+	LastEventAt *string `json:"last_event_at,omitempty"`
 }
@@ -1,23 +1,28 @@
 package dto
 
 type CreateContactRequest struct {
-	Email        string  `json:"email" validate:"required,email"`
-	FirstName    *string `json:"first_name,omitempty"`
-	LastName     *string `json:"last_name,omitempty"`
-	Unsubscribed *bool   `json:"unsubscribed,omitempty"`
+	Email        string                 `json:"email" validate:"required,email"`
+	FirstName    *string                `json:"first_name,omitempty"`
+	LastName     *string                `json:"last_name,omitempty"`
+	Unsubscribed *bool                  `json:"unsubscribed,omitempty"`
+	Properties   map[string]interface{} `json:"properties,omitempty"`
 }
 
+// UpdateContactRequest updates a contact. Properties are merged into the
+// existing values; a null value removes the property from the contact.
 type UpdateContactRequest struct {
-	FirstName    *string `json:"first_name,omitempty"`
-	LastName     *string `json:"last_name,omitempty"`
-	Unsubscribed *bool   `json:"unsubscribed,omitempty"`
+	FirstName    *string                `json:"first_name,omitempty"`
+	LastName     *string                `json:"last_name,omitempty"`
+	Unsubscribed *bool                  `json:"unsubscribed,omitempty"`
+	Properties   map[string]interface{} `json:"properties,omitempty"`
 }
 
 type ContactResponse struct {
-	ID           string  `json:"id"`
-	Email        string  `json:"email"`
-	FirstName    *string `json:"first_name,omitempty"`
-	LastName     *string `json:"last_name,omitempty"`
-	Unsubscribed bool    `json:"unsubscribed"`
-	CreatedAt    string  `json:"created_at"`
+	ID           string            `json:"id"`
+	Email        string            `json:"email"`
+	FirstName    *string           `json:"first_name,omitempty"`
+	LastName     *string           `json:"last_name,omitempty"`
+	Unsubscribed bool              `json:"unsubscribed"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	CreatedAt    string            `json:"created_at"`
 }
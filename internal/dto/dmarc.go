@@ -0,0 +1,59 @@
+package dto
+
+import "time"
+
+// DMARCReportResponse summarises a DMARC aggregate report received for a
+// domain.
+type DMARCReportResponse struct {
+	ID             string `json:"id"`
+	OrgName        string `json:"org_name"`
+	ReporterEmail  string `json:"reporter_email,omitempty"`
+	ReportID       string `json:"report_id"`
+	PolicyDomain   string `json:"policy_domain"`
+	Policy         string `json:"policy"`
+	DateBegin      string `json:"date_begin"`
+	DateEnd        string `json:"date_end"`
+	MessageCount   int    `json:"message_count"`
+	FailedMessages int    `json:"failed_messages"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// DMARCTotals holds a domain's DMARC results over the requested range.
+type DMARCTotals struct {
+	Messages int     `json:"messages"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// DMARCSourceResponse holds the DMARC results of the messages sent from one
+// IP address. DKIMDomains and SPFDomains are the domains its messages were
+// signed or sent with, which identify third-party senders.
+type DMARCSourceResponse struct {
+	SourceIP    string   `json:"source_ip"`
+	Messages    int      `json:"messages"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	DKIMAligned int      `json:"dkim_aligned"`
+	SPFAligned  int      `json:"spf_aligned"`
+	DKIMDomains []string `json:"dkim_domains"`
+	SPFDomains  []string `json:"spf_domains"`
+}
+
+// DMARCDataPoint holds the DMARC results reported for one day.
+type DMARCDataPoint struct {
+	Date     string `json:"date"`
+	Messages int    `json:"messages"`
+	Passed   int    `json:"passed"`
+	Failed   int    `json:"failed"`
+}
+
+// DMARCStatsResponse is the response for GET /domains/{domainId}/dmarc/stats.
+// Sources are ordered by failed messages, most first.
+type DMARCStatsResponse struct {
+	From    time.Time             `json:"from"`
+	To      time.Time             `json:"to"`
+	Totals  DMARCTotals           `json:"totals"`
+	Sources []DMARCSourceResponse `json:"sources"`
+	Data    []DMARCDataPoint      `json:"data"`
+}
@@ -1,7 +1,13 @@
 package dto
 
 type CreateDomainRequest struct {
-	Name string `json:"name" validate:"required,fqdn"`
+	Name          string `json:"name" validate:"required,fqdn"`
+	DKIMAlgorithm string `json:"dkim_algorithm,omitempty" validate:"omitempty,oneof=rsa ed25519"`
+	// DKIMSelector and DKIMPrivateKey import an existing DKIM key, e.g. one
+	// already published when migrating from another provider, instead of
+	// generating a new one. The selector defaults to the configured one.
+	DKIMSelector   string `json:"dkim_selector,omitempty" validate:"omitempty,hostname_rfc1123,max=63"`
+	DKIMPrivateKey string `json:"dkim_private_key,omitempty" validate:"required_with=DKIMSelector"`
 }
 
 type DomainResponse struct {
@@ -10,9 +16,36 @@ type DomainResponse struct {
 	Status    string              `json:"status"`
 	Region    *string             `json:"region,omitempty"`
 	DNSRecords []DNSRecordResponse `json:"dns_records"`
+	DKIM      DKIMResponse        `json:"dkim"`
+	// SendingPausedAt is set while sending is paused because the domain's
+	// DKIM record is missing from DNS.
+	SendingPausedAt *string `json:"sending_paused_at,omitempty"`
+	LastCheckedAt   *string `json:"last_checked_at,omitempty"`
+	// Warmup is set while the domain's daily sending volume is ramping up.
+	Warmup    *WarmupResponse     `json:"warmup,omitempty"`
 	CreatedAt string              `json:"created_at"`
 }
 
+// WarmupResponse describes a domain's warm-up: when it started and how many
+// emails the domain may send in a rolling 24 hours today.
+type WarmupResponse struct {
+	StartedAt  string `json:"started_at"`
+	DailyLimit int    `json:"daily_limit"`
+}
+
+// DKIMResponse describes a domain's DKIM keys: the active one and, during a
+// rotation, the pending key and the previous selector still published.
+type DKIMResponse struct {
+	Selector             string  `json:"selector"`
+	Algorithm            string  `json:"algorithm"`
+	PendingSelector      *string `json:"pending_selector,omitempty"`
+	PendingAlgorithm     *string `json:"pending_algorithm,omitempty"`
+	PreviousSelector     *string `json:"previous_selector,omitempty"`
+	PreviousRetireAt     *string `json:"previous_retire_at,omitempty"`
+	RotationIntervalDays *int    `json:"rotation_interval_days,omitempty"`
+	RotatedAt            *string `json:"rotated_at,omitempty"`
+}
+
 type DNSRecordResponse struct {
 	Type     string `json:"type"`
 	Name     string `json:"name"`
@@ -20,10 +53,29 @@ type DNSRecordResponse struct {
 	Priority *int   `json:"priority,omitempty"`
 	Status   string `json:"status"`
 	TTL      string `json:"ttl"`
+	LastCheckedAt *string `json:"last_checked_at,omitempty"`
 }
 
 type UpdateDomainRequest struct {
 	OpenTracking  *bool   `json:"open_tracking,omitempty"`
 	ClickTracking *bool   `json:"click_tracking,omitempty"`
 	TLSPolicy     *string `json:"tls_policy,omitempty" validate:"omitempty,oneof=opportunistic enforce"`
+	// DKIMRotationIntervalDays schedules automatic DKIM key rotation; 0
+	// turns it off.
+	DKIMRotationIntervalDays *int `json:"dkim_rotation_interval_days,omitempty" validate:"omitempty,min=0,max=3650"`
+	// Warmup starts or stops ramping up the domain's daily sending volume.
+	Warmup *bool `json:"warmup,omitempty"`
+}
+
+// RotateDKIMRequest starts a DKIM key rotation. The algorithm defaults to the
+// active key's.
+type RotateDKIMRequest struct {
+	Algorithm string `json:"algorithm,omitempty" validate:"omitempty,oneof=rsa ed25519"`
+}
+
+// ImportDKIMRequest imports an existing DKIM key and selector as a pending
+// key. Signing switches to it once its DNS record verifies.
+type ImportDKIMRequest struct {
+	Selector   string `json:"selector" validate:"required,hostname_rfc1123,max=63"`
+	PrivateKey string `json:"private_key" validate:"required"`
 }
@@ -1,19 +1,21 @@
 package dto
 
 type SendEmailRequest struct {
-	From           string            `json:"from" validate:"required,email"`
-	To             []string          `json:"to" validate:"required,min=1,dive,email"`
-	Cc             []string          `json:"cc,omitempty" validate:"omitempty,dive,email"`
-	Bcc            []string          `json:"bcc,omitempty" validate:"omitempty,dive,email"`
-	ReplyTo        *string           `json:"reply_to,omitempty" validate:"omitempty,email"`
-	Subject        string            `json:"subject" validate:"required"`
-	HTML           *string           `json:"html,omitempty"`
-	Text           *string           `json:"text,omitempty"`
-	ScheduledAt    *string           `json:"scheduled_at,omitempty"`
-	Tags           []Tag             `json:"tags,omitempty"`
-	Headers        map[string]string `json:"headers,omitempty"`
-	Attachments    []Attachment      `json:"attachments,omitempty"`
-	IdempotencyKey *string           `json:"-"` // from header
+	From           string                 `json:"from" validate:"required,email"`
+	To             []string               `json:"to" validate:"required,min=1,dive,email"`
+	Cc             []string               `json:"cc,omitempty" validate:"omitempty,dive,email"`
+	Bcc            []string               `json:"bcc,omitempty" validate:"omitempty,dive,email"`
+	ReplyTo        *string                `json:"reply_to,omitempty" validate:"omitempty,email"`
+	Subject        string                 `json:"subject" validate:"required_without=TemplateID"`
+	HTML           *string                `json:"html,omitempty"`
+	Text           *string                `json:"text,omitempty"`
+	TemplateID     *string                `json:"template_id,omitempty" validate:"omitempty,uuid"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	ScheduledAt    *string                `json:"scheduled_at,omitempty"`
+	Tags           []Tag                  `json:"tags,omitempty"`
+	Headers        map[string]string      `json:"headers,omitempty"`
+	Attachments    []Attachment           `json:"attachments,omitempty"`
+	IdempotencyKey *string                `json:"-"` // from header
 }
 
 type Tag struct {
@@ -58,4 +60,5 @@ type EmailResponse struct {
 	SentAt      *string  `json:"sent_at,omitempty"`
 	CreatedAt   string   `json:"created_at"`
 	LastEvent   string   `json:"last_event,omitempty"`
+	BroadcastID *string  `json:"broadcast_id,omitempty"`
 }
@@ -8,7 +8,13 @@ type LogResponse struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// LogListParams are the pagination and filters of GET /logs.
 type LogListParams struct {
 	PaginationParams
-	Level string `json:"level,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Method    string `json:"method,omitempty"`
+	Path      string `json:"path,omitempty"`   // path prefix
+	Status    string `json:"status,omitempty"` // a status code such as 404, or a class such as 4xx
+	APIKeyID  string `json:"api_key_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
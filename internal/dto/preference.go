@@ -0,0 +1,23 @@
+package dto
+
+// PreferencesResponse describes a recipient's subscriptions as shown on the
+// hosted preference page.
+type PreferencesResponse struct {
+	Email        string            `json:"email"`
+	Unsubscribed bool              `json:"unsubscribed"`
+	Topics       []TopicPreference `json:"topics"`
+}
+
+type TopicPreference struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	Subscribed  bool    `json:"subscribed"`
+}
+
+// UpdatePreferencesRequest replaces a recipient's subscriptions. Topics lists
+// the IDs of the topics to stay subscribed to; all other topics are opted out.
+type UpdatePreferencesRequest struct {
+	Topics         []string `json:"topics"`
+	UnsubscribeAll bool     `json:"unsubscribe_all"`
+}
@@ -16,3 +16,10 @@ type SegmentResponse struct {
 	Conditions interface{} `json:"conditions"`
 	CreatedAt  string      `json:"created_at"`
 }
+
+// SegmentRefreshResponse reports the membership of a segment after its
+// conditions were re-evaluated.
+type SegmentRefreshResponse struct {
+	ID       string `json:"id"`
+	Contacts int    `json:"contacts"`
+}
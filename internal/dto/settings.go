@@ -12,23 +12,28 @@ type UsageResponse struct {
 
 // TeamMemberResponse represents a team member with user info.
 type TeamMemberResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
 }
 
 // TeamResponse holds team info and member list.
 type TeamResponse struct {
-	ID      string               `json:"id"`
-	Name    string               `json:"name"`
-	Slug    string               `json:"slug"`
-	Members []TeamMemberResponse `json:"members"`
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Slug        string               `json:"slug"`
+	SandboxMode bool                 `json:"sandbox_mode"`
+	Members     []TeamMemberResponse `json:"members"`
 }
 
-// UpdateTeamRequest is the request body for PATCH /settings/team.
+// UpdateTeamRequest is the request body for PATCH /settings/team. Omitted
+// fields are left unchanged.
 type UpdateTeamRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=100"`
+	Name        string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	SandboxMode *bool  `json:"sandbox_mode,omitempty"`
 }
 
 // SMTPConfigResponse holds SMTP configuration for display.
@@ -46,6 +51,16 @@ type InviteMemberRequest struct {
 	Role  string `json:"role" validate:"required,oneof=admin member"`
 }
 
+// UpdateMemberRoleRequest is the request body for PATCH /settings/team/members/{memberId}.
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// TransferOwnershipRequest is the request body for POST /settings/team/transfer-ownership.
+type TransferOwnershipRequest struct {
+	MemberID string `json:"member_id" validate:"required,uuid"`
+}
+
 // AcceptInviteRequest is the request body for POST /auth/accept-invite.
 type AcceptInviteRequest struct {
 	Token    string `json:"token" validate:"required"`
@@ -0,0 +1,24 @@
+package dto
+
+type CreateSuppressionRequest struct {
+	Email   string  `json:"email" validate:"required,email"`
+	Details *string `json:"details,omitempty"`
+}
+
+type SuppressionResponse struct {
+	ID        string  `json:"id"`
+	Email     string  `json:"email"`
+	Reason    string  `json:"reason"`
+	Details   *string `json:"details,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// SuppressionImportResponse summarises a bulk CSV import. Rows for addresses
+// that are already suppressed are counted as skipped.
+type SuppressionImportResponse struct {
+	Total    int      `json:"total"`
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Invalid  int      `json:"invalid"`
+	Errors   []string `json:"errors,omitempty"`
+}
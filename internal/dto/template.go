@@ -1,19 +1,30 @@
 package dto
 
 type CreateTemplateRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description *string `json:"description,omitempty"`
-	Subject     *string `json:"subject,omitempty"`
-	HTML        *string `json:"html,omitempty"`
-	Text        *string `json:"text,omitempty"`
+	Name        string             `json:"name" validate:"required"`
+	Description *string            `json:"description,omitempty"`
+	Subject     *string            `json:"subject,omitempty"`
+	HTML        *string            `json:"html,omitempty"`
+	Text        *string            `json:"text,omitempty"`
+	Variables   []TemplateVariable `json:"variables,omitempty" validate:"omitempty,dive"`
 }
 
 type UpdateTemplateRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Subject     *string `json:"subject,omitempty"`
-	HTML        *string `json:"html,omitempty"`
-	Text        *string `json:"text,omitempty"`
+	Name        *string            `json:"name,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	Subject     *string            `json:"subject,omitempty"`
+	HTML        *string            `json:"html,omitempty"`
+	Text        *string            `json:"text,omitempty"`
+	Variables   []TemplateVariable `json:"variables,omitempty" validate:"omitempty,dive"`
+}
+
+// TemplateVariable declares a variable that senders pass in the variables
+// object of POST /emails.
+type TemplateVariable struct {
+	Name     string      `json:"name" validate:"required"`
+	Type     string      `json:"type,omitempty" validate:"omitempty,oneof=string number boolean array object"`
+	Required bool        `json:"required,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
 }
 
 type TemplateResponse struct {
@@ -25,13 +36,69 @@ type TemplateResponse struct {
 }
 
 type TemplateDetailResponse struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
-	Subject     *string `json:"subject,omitempty"`
-	HTML        *string `json:"html,omitempty"`
-	Text        *string `json:"text,omitempty"`
-	Published   bool    `json:"published"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description *string            `json:"description,omitempty"`
+	Subject     *string            `json:"subject,omitempty"`
+	HTML        *string            `json:"html,omitempty"`
+	Text        *string            `json:"text,omitempty"`
+	Variables   []TemplateVariable `json:"variables"`
+	Published   bool               `json:"published"`
+	CreatedAt   string             `json:"created_at"`
+	UpdatedAt   string             `json:"updated_at"`
+}
+
+// TemplateVersionResponse describes one version of a template.
+type TemplateVersionResponse struct {
+	ID        string             `json:"id"`
+	Version   int                `json:"version"`
+	Subject   *string            `json:"subject,omitempty"`
+	HTML      *string            `json:"html,omitempty"`
+	Text      *string            `json:"text,omitempty"`
+	Variables []TemplateVariable `json:"variables"`
+	Published bool               `json:"published"`
+	CreatedAt string             `json:"created_at"`
+}
+
+// PreviewTemplateRequest renders a template version with sample data. When
+// Version is omitted the latest version is used. Contact supplies sample
+// values for {{contact.*}}.
+type PreviewTemplateRequest struct {
+	Version   *int                   `json:"version,omitempty" validate:"omitempty,min=1"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Contact   map[string]interface{} `json:"contact,omitempty"`
+}
+
+type PreviewTemplateResponse struct {
+	Version int     `json:"version"`
+	Subject *string `json:"subject,omitempty"`
+	HTML    *string `json:"html,omitempty"`
+	Text    *string `json:"text,omitempty"`
+}
+
+// TestTemplateRequest sends a rendered template version to a single address.
+type TestTemplateRequest struct {
+	From      string                 `json:"from" validate:"required,email"`
+	To        string                 `json:"to" validate:"required,email"`
+	Version   *int                   `json:"version,omitempty" validate:"omitempty,min=1"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Contact   map[string]interface{} `json:"contact,omitempty"`
+}
+
+// TemplateDiffResponse lists the parts that differ between two versions.
+// Variables are compared as indented JSON.
+type TemplateDiffResponse struct {
+	From    int                 `json:"from"`
+	To      int                 `json:"to"`
+	Changes []TemplateFieldDiff `json:"changes"`
+}
+
+type TemplateFieldDiff struct {
+	Field string             `json:"field"`
+	Lines []TemplateDiffLine `json:"lines"`
+}
+
+type TemplateDiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
 }
@@ -1,18 +1,40 @@
 package dto
 
 type CreateTopicRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description *string `json:"description,omitempty"`
+	Name                string  `json:"name" validate:"required"`
+	Description         *string `json:"description,omitempty"`
+	DefaultSubscription string  `json:"default_subscription,omitempty" validate:"omitempty,oneof=opt_in opt_out"`
 }
 
 type UpdateTopicRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Name                *string `json:"name,omitempty"`
+	Description         *string `json:"description,omitempty"`
+	DefaultSubscription *string `json:"default_subscription,omitempty" validate:"omitempty,oneof=opt_in opt_out"`
 }
 
 type TopicResponse struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
-	CreatedAt   string  `json:"created_at"`
+	ID                  string  `json:"id"`
+	Name                string  `json:"name"`
+	Description         *string `json:"description,omitempty"`
+	DefaultSubscription string  `json:"default_subscription"`
+	CreatedAt           string  `json:"created_at"`
+}
+
+// ContactTopicResponse is a contact's effective subscription state for one
+// topic. Explicit is false when the contact has no preference recorded and
+// the topic's default subscription applies.
+type ContactTopicResponse struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Subscription string `json:"subscription"`
+	Explicit     bool   `json:"explicit"`
+}
+
+type ContactTopicUpdate struct {
+	ID           string `json:"id" validate:"required,uuid"`
+	Subscription string `json:"subscription" validate:"required,oneof=opt_in opt_out"`
+}
+
+type UpdateContactTopicsRequest struct {
+	Topics []ContactTopicUpdate `json:"topics" validate:"required,min=1,dive"`
 }
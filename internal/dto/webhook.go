@@ -12,9 +12,66 @@ type UpdateWebhookRequest struct {
 }
 
 type WebhookResponse struct {
-	ID        string   `json:"id"`
-	URL       string   `json:"url"`
-	Events    []string `json:"events"`
-	Active    bool     `json:"active"`
-	CreatedAt string   `json:"created_at"`
+	ID        string                `json:"id"`
+	URL       string                `json:"url"`
+	Events    []string              `json:"events"`
+	Active    bool                  `json:"active"`
+	Health    WebhookHealthResponse `json:"health"`
+	CreatedAt string                `json:"created_at"`
+}
+
+// WebhookHealthResponse summarises an endpoint's delivery history. The
+// success rate is omitted until a delivery has been attempted.
+type WebhookHealthResponse struct {
+	ConsecutiveFailures int      `json:"consecutive_failures"`
+	DeliveredCount      int      `json:"delivered_count"`
+	FailedCount         int      `json:"failed_count"`
+	SuccessRate         *float64 `json:"success_rate,omitempty"`
+	LastDeliveredAt     *string  `json:"last_delivered_at,omitempty"`
+	LastFailedAt        *string  `json:"last_failed_at,omitempty"`
+	DisabledAt          *string  `json:"disabled_at,omitempty"`
+	DisabledReason      *string  `json:"disabled_reason,omitempty"`
+}
+
+// EnableWebhookRequest re-enables a webhook. With Backfill set, the events
+// skipped while it was disabled are delivered.
+type EnableWebhookRequest struct {
+	Backfill bool `json:"backfill"`
+}
+
+// EnableWebhookResponse is the re-enabled webhook and the number of skipped
+// events queued for delivery in the background.
+type EnableWebhookResponse struct {
+	Webhook     WebhookResponse `json:"webhook"`
+	Backfilling int             `json:"backfilling"`
+}
+
+// WebhookEventResponse is one entry in a webhook's delivery log.
+type WebhookEventResponse struct {
+	ID           string                 `json:"id"`
+	EventType    string                 `json:"event_type"`
+	Payload      map[string]interface{} `json:"payload"`
+	Status       string                 `json:"status"`
+	ResponseCode *int                   `json:"response_code,omitempty"`
+	ResponseBody *string                `json:"response_body,omitempty"`
+	Attempts     int                    `json:"attempts"`
+	NextRetryAt  *string                `json:"next_retry_at,omitempty"`
+	CreatedAt    string                 `json:"created_at"`
+}
+
+// ReplayWebhookEventsRequest selects the events created in [since, until) to
+// deliver again, optionally narrowed by status and event type.
+type ReplayWebhookEventsRequest struct {
+	Since     string `json:"since" validate:"required"`
+	Until     string `json:"until" validate:"required"`
+	Status    string `json:"status,omitempty" validate:"omitempty,oneof=delivered failed skipped"`
+	EventType string `json:"event_type,omitempty"`
+}
+
+type ReplayWebhookEventsResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+type TestWebhookRequest struct {
+	EventType string `json:"event_type" validate:"required"`
 }
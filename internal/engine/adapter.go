@@ -21,6 +21,7 @@ func NewWorkerAdapter(s *Sender) *WorkerAdapter {
 func (a *WorkerAdapter) SendEmail(ctx context.Context, msg *worker.OutboundMessage) ([]worker.RecipientResult, error) {
 	outgoing := &OutgoingMessage{
 		From:         msg.From,
+		ReturnPath:   msg.ReturnPath,
 		To:           msg.To,
 		Cc:           msg.Cc,
 		Bcc:          msg.Bcc,
@@ -34,6 +35,13 @@ func (a *WorkerAdapter) SendEmail(ctx context.Context, msg *worker.OutboundMessa
 		DKIMSelector: msg.DKIMSelector,
 		DKIMKey:      string(msg.DKIMKey),
 	}
+	for _, a := range msg.Attachments {
+		outgoing.Attachments = append(outgoing.Attachments, MessageAttachment{
+			Filename:    a.Filename,
+			Content:     a.Content,
+			ContentType: a.ContentType,
+		})
+	}
 
 	result, err := a.sender.SendEmail(ctx, outgoing)
 	if err != nil {
@@ -42,13 +50,22 @@ func (a *WorkerAdapter) SendEmail(ctx context.Context, msg *worker.OutboundMessa
 
 	var results []worker.RecipientResult
 	for recipient, r := range result.Recipients {
-		results = append(results, worker.RecipientResult{
+		res := worker.RecipientResult{
 			Recipient: recipient,
 			Success:   r.Status == "sent",
 			Code:      r.Code,
 			Message:   r.Message,
 			Permanent: r.Permanent,
-		})
+		}
+		if r.TLS.Policy != "" {
+			res.TLS = &worker.DeliveryTLS{
+				Policy:   r.TLS.Policy,
+				Version:  r.TLS.Version,
+				Cipher:   r.TLS.Cipher,
+				Verified: r.TLS.Verified,
+			}
+		}
+		results = append(results, res)
 	}
 
 	return results, nil
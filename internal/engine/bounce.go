@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"mime"
 	"mime/multipart"
 	"net/mail"
@@ -161,11 +162,102 @@ func ClassifyDSN(rawMessage []byte) (*BounceInfo, error) {
 	return &info, nil
 }
 
+// ClassifyReport parses a bounce or feedback message received at a return
+// path. Delivery status notifications (RFC 3464) are classified with
+// ClassifyDSN and feedback reports (RFC 5965) with ParseARF. A DSN reporting
+// successful delivery yields a BounceInfo with an empty Type.
+func ClassifyReport(rawMessage []byte) (*BounceInfo, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(rawMessage))
+	if err != nil {
+		return nil, fmt.Errorf("parsing report message: %w", err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing Content-Type: %w", err)
+	}
+	if params["report-type"] == "feedback-report" {
+		return ParseARF(rawMessage)
+	}
+	return ClassifyDSN(rawMessage)
+}
+
+// ParseARF parses an Abuse Reporting Format feedback report (RFC 5965), as
+// sent by mailbox providers' feedback loops when a recipient marks a message
+// as spam. ARF messages use Content-Type multipart/report with
+// report-type=feedback-report. The recipient is taken from the
+// Original-Rcpt-To field, or from the To header of the returned message
+// when the provider redacts it.
+func ParseARF(rawMessage []byte) (*BounceInfo, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(rawMessage))
+	if err != nil {
+		return nil, fmt.Errorf("parsing ARF message: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing Content-Type: %w", err)
+	}
+	if mediaType != "multipart/report" || params["report-type"] != "feedback-report" {
+		return nil, fmt.Errorf("unexpected Content-Type %q, expected multipart/report with report-type=feedback-report", mediaType)
+	}
+	if params["boundary"] == "" {
+		return nil, fmt.Errorf("missing boundary in Content-Type")
+	}
+
+	info := BounceInfo{Type: BounceComplaint, Permanent: true}
+	feedbackType := ""
+	foundReport := false
+	originalTo := ""
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+
+		partMedia, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch partMedia {
+		case "message/feedback-report":
+			fields, err := mail.ReadMessage(io.MultiReader(part, strings.NewReader("\r\n\r\n")))
+			if err != nil {
+				_ = part.Close()
+				return nil, fmt.Errorf("parsing feedback-report: %w", err)
+			}
+			feedbackType = strings.ToLower(strings.TrimSpace(fields.Header.Get("Feedback-Type")))
+			info.Recipient = strings.TrimSpace(fields.Header.Get("Original-Rcpt-To"))
+			foundReport = true
+		case "message/rfc822", "text/rfc822-headers":
+			if original, err := mail.ReadMessage(part); err == nil {
+				if to, err := mail.ParseAddressList(original.Header.Get("To")); err == nil && len(to) == 1 {
+					originalTo = to[0].Address
+				}
+			}
+		}
+
+		_ = part.Close()
+	}
+
+	if !foundReport {
+		return nil, fmt.Errorf("no message/feedback-report part found in ARF message")
+	}
+	if feedbackType == "" {
+		feedbackType = "abuse"
+	}
+	if info.Recipient == "" {
+		info.Recipient = originalTo
+	}
+	info.Message = "feedback-type: " + feedbackType
+
+	return &info, nil
+}
+
 // parseDSNStatus reads a message/delivery-status MIME part and populates
 // the BounceInfo from its fields. The delivery-status part contains groups
 // of header-like fields separated by blank lines.
 func parseDSNStatus(part *multipart.Part, info *BounceInfo) error {
 	scanner := bufio.NewScanner(part)
+	delivered := false
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -211,6 +303,8 @@ func parseDSNStatus(part *multipart.Part, info *BounceInfo) error {
 			case "delayed", "relayed", "expanded":
 				info.Permanent = false
 				info.Type = BounceSoft
+			case "delivered":
+				delivered = true
 			}
 		}
 	}
@@ -219,8 +313,12 @@ func parseDSNStatus(part *multipart.Part, info *BounceInfo) error {
 		return fmt.Errorf("reading delivery-status: %w", err)
 	}
 
-	// Default classification if not set by action field.
-	if info.Type == "" {
+	// A delivery report is not a bounce. Otherwise default to a soft
+	// bounce if the action field did not classify it.
+	if delivered {
+		info.Type = ""
+		info.Permanent = false
+	} else if info.Type == "" {
 		info.Type = BounceSoft
 	}
 
@@ -289,6 +289,95 @@ func TestClassifyDSN(t *testing.T) {
 	})
 }
 
+func TestClassifyDSN_Delivered(t *testing.T) {
+	rawMessage := "From: mailer-daemon@example.com\r\n" +
+		"Content-Type: multipart/report; report-type=delivery-status; boundary=b\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: message/delivery-status\r\n" +
+		"\r\n" +
+		"Final-Recipient: rfc822;bob@example.com\r\n" +
+		"Action: delivered\r\n" +
+		"Status: 2.0.0\r\n" +
+		"--b--\r\n"
+
+	info, err := ClassifyDSN([]byte(rawMessage))
+	require.NoError(t, err)
+	assert.Equal(t, BounceType(""), info.Type)
+	assert.False(t, info.Permanent)
+}
+
+func arfMessage(feedbackFields, originalTo string) string {
+	return "From: feedback@mailbox.example\r\n" +
+		"To: bounces+abc@bounce.example.com\r\n" +
+		"Subject: Abuse report\r\n" +
+		"Content-Type: multipart/report; report-type=feedback-report; boundary=arf\r\n" +
+		"\r\n" +
+		"--arf\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"This is an email abuse report.\r\n" +
+		"--arf\r\n" +
+		"Content-Type: message/feedback-report\r\n" +
+		"\r\n" +
+		feedbackFields +
+		"--arf\r\n" +
+		"Content-Type: message/rfc822\r\n" +
+		"\r\n" +
+		"From: news@example.com\r\n" +
+		"To: " + originalTo + "\r\n" +
+		"Subject: Our newsletter\r\n" +
+		"\r\n" +
+		"Hello\r\n" +
+		"--arf--\r\n"
+}
+
+func TestParseARF(t *testing.T) {
+	t.Run("abuse report with original recipient", func(t *testing.T) {
+		raw := arfMessage("Feedback-Type: abuse\r\nUser-Agent: FBL/1.0\r\nVersion: 1\r\nOriginal-Rcpt-To: carol@example.net\r\n", "carol@example.net")
+
+		info, err := ParseARF([]byte(raw))
+		require.NoError(t, err)
+		assert.Equal(t, BounceComplaint, info.Type)
+		assert.True(t, info.Permanent)
+		assert.Equal(t, "carol@example.net", info.Recipient)
+		assert.Equal(t, "feedback-type: abuse", info.Message)
+	})
+
+	t.Run("redacted recipient falls back to the returned message", func(t *testing.T) {
+		raw := arfMessage("Feedback-Type: fraud\r\nVersion: 1\r\n", "Dave <dave@example.net>")
+
+		info, err := ParseARF([]byte(raw))
+		require.NoError(t, err)
+		assert.Equal(t, "dave@example.net", info.Recipient)
+		assert.Equal(t, "feedback-type: fraud", info.Message)
+	})
+
+	t.Run("missing feedback-report part", func(t *testing.T) {
+		raw := "Content-Type: multipart/report; report-type=feedback-report; boundary=x\r\n\r\n--x\r\nContent-Type: text/plain\r\n\r\nhi\r\n--x--\r\n"
+		_, err := ParseARF([]byte(raw))
+		assert.ErrorContains(t, err, "no message/feedback-report part")
+	})
+}
+
+func TestClassifyReport(t *testing.T) {
+	arf := arfMessage("Feedback-Type: abuse\r\nOriginal-Rcpt-To: carol@example.net\r\n", "carol@example.net")
+	info, err := ClassifyReport([]byte(arf))
+	require.NoError(t, err)
+	assert.Equal(t, BounceComplaint, info.Type)
+
+	dsn := "Content-Type: multipart/report; report-type=delivery-status; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: message/delivery-status\r\n\r\n" +
+		"Final-Recipient: rfc822;bob@example.com\r\nAction: failed\r\nStatus: 5.1.1\r\n--b--\r\n"
+	info, err = ClassifyReport([]byte(dsn))
+	require.NoError(t, err)
+	assert.Equal(t, BounceHard, info.Type)
+	assert.Equal(t, "bob@example.com", info.Recipient)
+
+	_, err = ClassifyReport([]byte("Subject: hello\r\n\r\nplain mail"))
+	assert.Error(t, err)
+}
+
 func TestContainsAny(t *testing.T) {
 	tests := []struct {
 		name    string
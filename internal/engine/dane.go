@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/miekg/dns"
+)
+
+// TLSA certificate usages that DANE for SMTP relies on (RFC 7672, section
+// 3.1.3). PKIX-TA and PKIX-EE records are not usable for SMTP.
+const (
+	tlsaUsageDANETA = 2
+	tlsaUsageDANEEE = 3
+)
+
+// LookupTLSA returns the usable DANE TLSA records for SMTP on host. DANE
+// relies on DNSSEC, so records are only returned when the resolver reports
+// the answer as authenticated; the resolver must be a validating one.
+func (r *DNSResolver) LookupTLSA(host string) ([]*dns.TLSA, error) {
+	name := "_25._tcp." + host
+	c := &dns.Client{
+		Timeout: r.timeout,
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeTLSA)
+	m.RecursionDesired = true
+	m.AuthenticatedData = true
+	m.SetEdns0(4096, true)
+
+	reply, _, err := c.Exchange(m, r.nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("DNS query for %s (type TLSA): %w", name, err)
+	}
+	if reply.Rcode == dns.RcodeNameError {
+		return nil, nil
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("DNS query for %s returned %s", name, dns.RcodeToString[reply.Rcode])
+	}
+	if !reply.AuthenticatedData {
+		return nil, nil
+	}
+
+	var records []*dns.TLSA
+	for _, ans := range reply.Answer {
+		tlsa, ok := ans.(*dns.TLSA)
+		if !ok {
+			continue
+		}
+		if tlsa.Usage != tlsaUsageDANETA && tlsa.Usage != tlsaUsageDANEEE {
+			continue
+		}
+		if tlsa.Selector > 1 || tlsa.MatchingType > 2 {
+			continue
+		}
+		records = append(records, tlsa)
+	}
+
+	return records, nil
+}
+
+// verifyDANE checks the server certificate of a connection to host against
+// its TLSA records. A DANE-EE record must match the server's own certificate,
+// whose name and expiry are not checked. A DANE-TA record must match a
+// certificate the server sent as a trust anchor, which must then issue a
+// valid certificate for host.
+func verifyDANE(cs tls.ConnectionState, records []*dns.TLSA, host string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("DANE: server sent no certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+
+	for _, record := range records {
+		if record.Usage == tlsaUsageDANEEE && record.Verify(leaf) == nil {
+			return nil
+		}
+	}
+
+	for _, record := range records {
+		if record.Usage != tlsaUsageDANETA {
+			continue
+		}
+		for i, anchor := range cs.PeerCertificates[1:] {
+			if record.Verify(anchor) != nil {
+				continue
+			}
+			roots := x509.NewCertPool()
+			roots.AddCert(anchor)
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1 : i+1] {
+				intermediates.AddCert(cert)
+			}
+			_, err := leaf.Verify(x509.VerifyOptions{
+				DNSName:       host,
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			if err == nil {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("DANE: certificate of %s matches none of its TLSA records", host)
+}
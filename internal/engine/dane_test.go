@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert is a certificate and its key, for TLS stand-ins.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert creates a certificate for host, signed by issuer or, if issuer
+// is nil, self-signed. A certificate without a host is a CA.
+func newTestCert(t *testing.T, host string, issuer *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "MailIt Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if host == "" {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+
+	parent, parentKey := tmpl, key
+	if issuer != nil {
+		parent, parentKey = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+// tlsCertificate returns c for a TLS server, sending chain after it.
+func (c *testCert) tlsCertificate(chain ...*testCert) tls.Certificate {
+	tc := tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+	for _, ca := range chain {
+		tc.Certificate = append(tc.Certificate, ca.cert.Raw)
+	}
+	return tc
+}
+
+// tlsaRecord returns a TLSA record for SMTP on host that matches cert's
+// public key by SHA-256.
+func tlsaRecord(t *testing.T, host string, usage int, cert *x509.Certificate) string {
+	t.Helper()
+	digest, err := dns.CertificateToDANE(1, 1, cert)
+	require.NoError(t, err)
+	return fmt.Sprintf("_25._tcp.%s. 300 IN TLSA %d 1 1 %s", host, usage, digest)
+}
+
+func TestDNSResolver_LookupTLSA(t *testing.T) {
+	cert := newTestCert(t, "mx.example.com", nil)
+	records := []string{
+		tlsaRecord(t, "mx.example.com", 3, cert.cert),
+		tlsaRecord(t, "mx.example.com", 1, cert.cert), // PKIX-EE is not used for SMTP
+	}
+
+	t.Run("authenticated answer", func(t *testing.T) {
+		r := NewDNSResolver(newTestDNSServer(t, true, records...), time.Second)
+		got, err := r.LookupTLSA("mx.example.com")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, uint8(3), got[0].Usage)
+	})
+
+	t.Run("unauthenticated answer is ignored", func(t *testing.T) {
+		r := NewDNSResolver(newTestDNSServer(t, false, records...), time.Second)
+		got, err := r.LookupTLSA("mx.example.com")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("no records", func(t *testing.T) {
+		r := NewDNSResolver(newTestDNSServer(t, true, records...), time.Second)
+		got, err := r.LookupTLSA("mx2.example.com")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestVerifyDANE(t *testing.T) {
+	ca := newTestCert(t, "", nil)
+	leaf := newTestCert(t, "mx.example.com", ca)
+	other := newTestCert(t, "mx.example.com", nil)
+	cs := tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf.cert, ca.cert}}
+
+	parse := func(record string) []*dns.TLSA {
+		rr, err := dns.NewRR(record)
+		require.NoError(t, err)
+		return []*dns.TLSA{rr.(*dns.TLSA)}
+	}
+
+	t.Run("DANE-EE matches the server certificate", func(t *testing.T) {
+		assert.NoError(t, verifyDANE(cs, parse(tlsaRecord(t, "mx.example.com", 3, leaf.cert)), "mx.example.com"))
+		// Names are not checked for DANE-EE.
+		assert.NoError(t, verifyDANE(cs, parse(tlsaRecord(t, "mx.example.com", 3, leaf.cert)), "mx.example.org"))
+	})
+
+	t.Run("DANE-TA matches the issuer", func(t *testing.T) {
+		records := parse(tlsaRecord(t, "mx.example.com", 2, ca.cert))
+		assert.NoError(t, verifyDANE(cs, records, "mx.example.com"))
+		assert.Error(t, verifyDANE(cs, records, "mx.example.org"))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		assert.Error(t, verifyDANE(cs, parse(tlsaRecord(t, "mx.example.com", 3, other.cert)), "mx.example.com"))
+		assert.Error(t, verifyDANE(cs, parse(tlsaRecord(t, "mx.example.com", 2, other.cert)), "mx.example.com"))
+	})
+}
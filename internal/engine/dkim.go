@@ -5,6 +5,7 @@ import (
 	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -46,6 +47,81 @@ func GenerateDKIMKeyPair(bits int) (privateKeyPEM string, publicKeyBase64 string
 	return string(privPEM), pubBase64, nil
 }
 
+// DKIM key algorithms.
+const (
+	DKIMAlgorithmRSA     = "rsa"
+	DKIMAlgorithmEd25519 = "ed25519" // RFC 8463
+)
+
+// GenerateDKIMKey generates a new DKIM key with the given algorithm; bits is
+// the RSA key size and is ignored for Ed25519. It returns the private key in
+// PEM format and the value of the DKIM DNS TXT record publishing its public key.
+func GenerateDKIMKey(algorithm string, bits int) (privateKeyPEM string, dnsValue string, err error) {
+	switch algorithm {
+	case DKIMAlgorithmRSA:
+		privPEM, pubBase64, err := GenerateDKIMKeyPair(bits)
+		if err != nil {
+			return "", "", err
+		}
+		return privPEM, DKIMRecordValue(algorithm, pubBase64), nil
+
+	case DKIMAlgorithmEd25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", fmt.Errorf("generating Ed25519 key: %w", err)
+		}
+		privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return "", "", fmt.Errorf("marshaling private key: %w", err)
+		}
+		privPEM := pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: privBytes,
+		})
+		// RFC 8463 publishes the raw 32-byte public key, not a DER structure.
+		return string(privPEM), DKIMRecordValue(algorithm, base64.StdEncoding.EncodeToString(pub)), nil
+
+	default:
+		return "", "", fmt.Errorf("unsupported DKIM key algorithm %q", algorithm)
+	}
+}
+
+// DKIMRecordValue returns the DKIM DNS TXT record value for a base64-encoded
+// public key.
+func DKIMRecordValue(algorithm, publicKeyBase64 string) string {
+	return "v=DKIM1; k=" + algorithm + "; p=" + publicKeyBase64
+}
+
+// DKIMKeyRecord inspects an existing PEM-encoded DKIM private key, such as
+// one imported from another provider, and returns its algorithm and the
+// value of the DNS TXT record publishing its public key. RSA keys must be at
+// least 1024 bits.
+func DKIMKeyRecord(privateKeyPEM string) (algorithm string, dnsValue string, err error) {
+	signer, err := ParseSigningKey(privateKeyPEM)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch k := signer.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 1024 {
+			return "", "", fmt.Errorf("key size must be at least 1024 bits, got %d", k.N.BitLen())
+		}
+		pubBytes, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+		if err != nil {
+			return "", "", fmt.Errorf("marshaling public key: %w", err)
+		}
+		return DKIMAlgorithmRSA, DKIMRecordValue(DKIMAlgorithmRSA, base64.StdEncoding.EncodeToString(pubBytes)), nil
+
+	case ed25519.PrivateKey:
+		pub := k.Public().(ed25519.PublicKey)
+		return DKIMAlgorithmEd25519, DKIMRecordValue(DKIMAlgorithmEd25519, base64.StdEncoding.EncodeToString(pub)), nil
+
+	default:
+		return "", "", fmt.Errorf("unsupported key type %T", signer)
+	}
+}
+
 // EncryptPrivateKey encrypts a PEM-encoded private key using AES-256-GCM.
 // The master key must be exactly 32 bytes for AES-256.
 func EncryptPrivateKey(plaintext string, masterKey []byte) (string, error) {
@@ -124,11 +200,42 @@ func ParsePrivateKey(privateKeyPEM string) (*rsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
+// ParseSigningKey parses a PEM-encoded DKIM private key: an RSA key in PKCS #1
+// form, or an RSA or Ed25519 key in PKCS #8 form.
+func ParseSigningKey(privateKeyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing private key: %w", err)
+		}
+		return privateKey, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("parsing private key: unsupported key type %T", key)
+	}
+}
+
 // SignMessage signs an email message with DKIM. It reads the raw RFC 5322
 // message, signs it, and returns the complete message with the DKIM-Signature
-// header prepended.
+// header prepended. The signing algorithm follows the key: rsa-sha256 or
+// ed25519-sha256.
 func SignMessage(message []byte, domain, selector string, privateKeyPEM string) ([]byte, error) {
-	privateKey, err := ParsePrivateKey(privateKeyPEM)
+	privateKey, err := ParseSigningKey(privateKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("parsing private key for DKIM: %w", err)
 	}
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
@@ -8,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/emersion/go-msgauth/dkim"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -257,3 +260,86 @@ func TestSignMessage(t *testing.T) {
 			"DKIM-Signature header should be at the start of the signed message")
 	})
 }
+
+func TestGenerateDKIMKey(t *testing.T) {
+	msg := &OutgoingMessage{
+		From:     "sender@example.com",
+		To:       []string{"recipient@example.com"},
+		Subject:  "Key algorithms",
+		TextBody: "Body.",
+	}
+	rawMessage, err := BuildMessage(msg)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		algorithm string
+		keyType   interface{}
+		sigAlgo   string
+	}{
+		{DKIMAlgorithmRSA, &rsa.PrivateKey{}, "a=rsa-sha256"},
+		{DKIMAlgorithmEd25519, ed25519.PrivateKey{}, "a=ed25519-sha256"},
+	} {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			privPEM, dnsValue, err := GenerateDKIMKey(tt.algorithm, 1024)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(dnsValue, "v=DKIM1; k="+tt.algorithm+"; p="))
+
+			key, err := ParseSigningKey(privPEM)
+			require.NoError(t, err)
+			assert.IsType(t, tt.keyType, key)
+
+			signed, err := SignMessage(rawMessage, "example.com", "mailit", privPEM)
+			require.NoError(t, err)
+			assert.Contains(t, string(signed), tt.sigAlgo)
+
+			// The signature must verify against the published DNS record.
+			verifications, err := dkim.VerifyWithOptions(bytes.NewReader(signed), &dkim.VerifyOptions{
+				LookupTXT: func(domain string) ([]string, error) {
+					assert.Equal(t, "mailit._domainkey.example.com", domain)
+					return []string{dnsValue}, nil
+				},
+			})
+			require.NoError(t, err)
+			require.Len(t, verifications, 1)
+			assert.NoError(t, verifications[0].Err)
+		})
+	}
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, _, err := GenerateDKIMKey("dsa", 1024)
+		assert.ErrorContains(t, err, "unsupported DKIM key algorithm")
+	})
+}
+
+func TestDKIMKeyRecord(t *testing.T) {
+	for _, algorithm := range []string{DKIMAlgorithmRSA, DKIMAlgorithmEd25519} {
+		t.Run(algorithm, func(t *testing.T) {
+			privPEM, dnsValue, err := GenerateDKIMKey(algorithm, 1024)
+			require.NoError(t, err)
+
+			gotAlgorithm, gotValue, err := DKIMKeyRecord(privPEM)
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, gotAlgorithm)
+			assert.Equal(t, dnsValue, gotValue)
+		})
+	}
+
+	t.Run("PKCS8 RSA key", func(t *testing.T) {
+		privPEM, dnsValue, err := GenerateDKIMKey(DKIMAlgorithmRSA, 1024)
+		require.NoError(t, err)
+		key, err := ParsePrivateKey(privPEM)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		algorithm, value, err := DKIMKeyRecord(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+		require.NoError(t, err)
+		assert.Equal(t, DKIMAlgorithmRSA, algorithm)
+		assert.Equal(t, dnsValue, value)
+	})
+
+	t.Run("invalid PEM", func(t *testing.T) {
+		_, _, err := DKIMKeyRecord("not a key")
+		assert.Error(t, err)
+	})
+}
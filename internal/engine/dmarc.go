@@ -0,0 +1,299 @@
+package engine
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDMARCReportBytes bounds the decompressed size of a DMARC aggregate
+// report, guarding against compression bombs.
+const maxDMARCReportBytes = 32 << 20
+
+// ErrNoDMARCReport is returned by ParseDMARCReportMessage when a message
+// carries no DMARC aggregate report.
+var ErrNoDMARCReport = errors.New("no DMARC aggregate report found")
+
+// DMARCReport is a parsed DMARC aggregate (RUA) report, per RFC 7489
+// appendix C.
+type DMARCReport struct {
+	OrgName   string
+	Email     string
+	ReportID  string
+	DateBegin time.Time
+	DateEnd   time.Time
+	// Domain and Policy are the published policy the report was evaluated
+	// against: the domain of the _dmarc record and its p= tag.
+	Domain  string
+	Policy  string
+	Records []DMARCReportRecord
+}
+
+// DMARCReportRecord holds the results for messages from one source IP with
+// the same identifiers and results. DKIM and SPF are the policy-evaluated,
+// i.e. aligned, results; the auth fields are the raw results of the first
+// DKIM signature and SPF check, whatever their domain.
+type DMARCReportRecord struct {
+	SourceIP       string
+	Count          int
+	Disposition    string
+	DKIM           string
+	SPF            string
+	HeaderFrom     string
+	EnvelopeFrom   string
+	DKIMDomain     string
+	DKIMAuthResult string
+	SPFDomain      string
+	SPFAuthResult  string
+}
+
+// Passed reports whether the messages passed DMARC, i.e. DKIM or SPF passed
+// and was aligned with the From domain.
+func (r DMARCReportRecord) Passed() bool {
+	return r.DKIM == "pass" || r.SPF == "pass"
+}
+
+// ParseDMARCReportMessage finds the aggregate report in an email sent to a
+// DMARC rua address and parses it. Reports are attached as XML, gzipped XML
+// or a zip archive holding the XML, either as a MIME part or as the whole
+// message body.
+func ParseDMARCReportMessage(rawMessage []byte) (*DMARCReport, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(rawMessage))
+	if err != nil {
+		return nil, fmt.Errorf("parsing report message: %w", err)
+	}
+
+	data, err := findDMARCAttachment(mail.Header(msg.Header), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDMARCReport(data)
+}
+
+// findDMARCAttachment walks a MIME entity and returns the decoded content of
+// the first part that looks like a DMARC report.
+func findDMARCAttachment(header mail.Header, body io.Reader) ([]byte, error) {
+	mediaType, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if params["boundary"] == "" {
+			return nil, fmt.Errorf("missing boundary in Content-Type")
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, ErrNoDMARCReport
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading MIME part: %w", err)
+			}
+			data, err := findDMARCAttachment(mail.Header(part.Header), part)
+			if !errors.Is(err, ErrNoDMARCReport) {
+				return data, err
+			}
+		}
+	}
+
+	filename := params["name"]
+	if _, dparams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && dparams["filename"] != "" {
+		filename = dparams["filename"]
+	}
+	if !isDMARCAttachment(mediaType, filename) {
+		return nil, ErrNoDMARCReport
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding report attachment: %w", err)
+	}
+	return decompressDMARCReport(data)
+}
+
+// isDMARCAttachment reports whether a MIME part can hold a DMARC report,
+// going by its media type or, for senders that use a generic type, its
+// file name.
+func isDMARCAttachment(mediaType, filename string) bool {
+	switch mediaType {
+	case "application/gzip", "application/x-gzip", "application/zip",
+		"application/x-zip", "application/x-zip-compressed", "application/xml", "text/xml":
+		return true
+	}
+	filename = strings.ToLower(filename)
+	return strings.HasSuffix(filename, ".xml") || strings.HasSuffix(filename, ".gz") || strings.HasSuffix(filename, ".zip")
+}
+
+// decompressDMARCReport returns the XML of a report attachment. The format
+// is detected from the content rather than the declared type, which senders
+// often get wrong.
+func decompressDMARCReport(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip report: %w", err)
+		}
+		defer func() { _ = zr.Close() }()
+		return readDMARCReport(zr)
+
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("opening zip report: %w", err)
+		}
+		for _, f := range zr.File {
+			if !strings.EqualFold(path.Ext(f.Name), ".xml") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("opening %s in zip report: %w", f.Name, err)
+			}
+			defer func() { _ = rc.Close() }()
+			return readDMARCReport(rc)
+		}
+		return nil, fmt.Errorf("zip report holds no XML file")
+
+	default:
+		return data, nil
+	}
+}
+
+func readDMARCReport(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxDMARCReportBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing report: %w", err)
+	}
+	if len(data) > maxDMARCReportBytes {
+		return nil, fmt.Errorf("report exceeds %d bytes", maxDMARCReportBytes)
+	}
+	return data, nil
+}
+
+// dmarcFeedback mirrors the XML schema of an aggregate report. Elements are
+// matched by local name, so reports with and without the schema namespace
+// both parse.
+type dmarcFeedback struct {
+	Metadata struct {
+		OrgName   string `xml:"org_name"`
+		Email     string `xml:"email"`
+		ReportID  string `xml:"report_id"`
+		DateRange struct {
+			Begin string `xml:"begin"`
+			End   string `xml:"end"`
+		} `xml:"date_range"`
+	} `xml:"report_metadata"`
+	Policy struct {
+		Domain string `xml:"domain"`
+		P      string `xml:"p"`
+	} `xml:"policy_published"`
+	Records []struct {
+		Row struct {
+			SourceIP        string `xml:"source_ip"`
+			Count           string `xml:"count"`
+			PolicyEvaluated struct {
+				Disposition string `xml:"disposition"`
+				DKIM        string `xml:"dkim"`
+				SPF         string `xml:"spf"`
+			} `xml:"policy_evaluated"`
+		} `xml:"row"`
+		Identifiers struct {
+			HeaderFrom   string `xml:"header_from"`
+			EnvelopeFrom string `xml:"envelope_from"`
+		} `xml:"identifiers"`
+		AuthResults struct {
+			DKIM []struct {
+				Domain string `xml:"domain"`
+				Result string `xml:"result"`
+			} `xml:"dkim"`
+			SPF []struct {
+				Domain string `xml:"domain"`
+				Result string `xml:"result"`
+			} `xml:"spf"`
+		} `xml:"auth_results"`
+	} `xml:"record"`
+}
+
+// ParseDMARCReport parses the XML of a DMARC aggregate report.
+func ParseDMARCReport(data []byte) (*DMARCReport, error) {
+	var fb dmarcFeedback
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	// Reports are ASCII in practice, whatever encoding they declare.
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := dec.Decode(&fb); err != nil {
+		return nil, fmt.Errorf("parsing report XML: %w", err)
+	}
+
+	report := &DMARCReport{
+		OrgName:  strings.TrimSpace(fb.Metadata.OrgName),
+		Email:    strings.TrimSpace(fb.Metadata.Email),
+		ReportID: strings.TrimSpace(fb.Metadata.ReportID),
+		Domain:   strings.ToLower(strings.TrimSuffix(strings.TrimSpace(fb.Policy.Domain), ".")),
+		Policy:   strings.ToLower(strings.TrimSpace(fb.Policy.P)),
+	}
+	if report.OrgName == "" || report.ReportID == "" || report.Domain == "" {
+		return nil, fmt.Errorf("report is missing org_name, report_id or policy domain")
+	}
+
+	var err error
+	if report.DateBegin, err = parseUnixTime(fb.Metadata.DateRange.Begin); err != nil {
+		return nil, fmt.Errorf("parsing date_range begin: %w", err)
+	}
+	if report.DateEnd, err = parseUnixTime(fb.Metadata.DateRange.End); err != nil {
+		return nil, fmt.Errorf("parsing date_range end: %w", err)
+	}
+
+	for _, rec := range fb.Records {
+		count, err := strconv.Atoi(strings.TrimSpace(rec.Row.Count))
+		if err != nil {
+			return nil, fmt.Errorf("parsing count for %s: %w", rec.Row.SourceIP, err)
+		}
+		r := DMARCReportRecord{
+			SourceIP:     strings.TrimSpace(rec.Row.SourceIP),
+			Count:        count,
+			Disposition:  strings.ToLower(strings.TrimSpace(rec.Row.PolicyEvaluated.Disposition)),
+			DKIM:         strings.ToLower(strings.TrimSpace(rec.Row.PolicyEvaluated.DKIM)),
+			SPF:          strings.ToLower(strings.TrimSpace(rec.Row.PolicyEvaluated.SPF)),
+			HeaderFrom:   strings.ToLower(strings.TrimSpace(rec.Identifiers.HeaderFrom)),
+			EnvelopeFrom: strings.ToLower(strings.TrimSpace(rec.Identifiers.EnvelopeFrom)),
+		}
+		if len(rec.AuthResults.DKIM) > 0 {
+			r.DKIMDomain = strings.ToLower(strings.TrimSpace(rec.AuthResults.DKIM[0].Domain))
+			r.DKIMAuthResult = strings.ToLower(strings.TrimSpace(rec.AuthResults.DKIM[0].Result))
+		}
+		if len(rec.AuthResults.SPF) > 0 {
+			r.SPFDomain = strings.ToLower(strings.TrimSpace(rec.AuthResults.SPF[0].Domain))
+			r.SPFAuthResult = strings.ToLower(strings.TrimSpace(rec.AuthResults.SPF[0].Result))
+		}
+		report.Records = append(report.Records, r)
+	}
+
+	return report, nil
+}
+
+func parseUnixTime(s string) (time.Time, error) {
+	secs, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
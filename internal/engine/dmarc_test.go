@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDMARCReport = `<?xml version="1.0" encoding="UTF-8" ?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>17419398612381237</report_id>
+    <date_range>
+      <begin>1760572800</begin>
+      <end>1760659199</end>
+    </date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>example.com</domain>
+    <adkim>r</adkim>
+    <aspf>r</aspf>
+    <p>none</p>
+    <sp>none</sp>
+    <pct>100</pct>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>203.0.113.10</source_ip>
+      <count>12</count>
+      <policy_evaluated>
+        <disposition>none</disposition>
+        <dkim>pass</dkim>
+        <spf>pass</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>example.com</header_from>
+    </identifiers>
+    <auth_results>
+      <dkim>
+        <domain>example.com</domain>
+        <result>pass</result>
+        <selector>mailit</selector>
+      </dkim>
+      <spf>
+        <domain>bounce.example.com</domain>
+        <result>pass</result>
+      </spf>
+    </auth_results>
+  </record>
+  <record>
+    <row>
+      <source_ip>198.51.100.7</source_ip>
+      <count>3</count>
+      <policy_evaluated>
+        <disposition>none</disposition>
+        <dkim>fail</dkim>
+        <spf>fail</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>Example.com</header_from>
+      <envelope_from>crm-vendor.net</envelope_from>
+    </identifiers>
+    <auth_results>
+      <spf>
+        <domain>crm-vendor.net</domain>
+        <result>pass</result>
+      </spf>
+    </auth_results>
+  </record>
+</feedback>`
+
+func TestParseDMARCReport(t *testing.T) {
+	report, err := ParseDMARCReport([]byte(testDMARCReport))
+	require.NoError(t, err)
+
+	assert.Equal(t, "google.com", report.OrgName)
+	assert.Equal(t, "17419398612381237", report.ReportID)
+	assert.Equal(t, "example.com", report.Domain)
+	assert.Equal(t, "none", report.Policy)
+	assert.Equal(t, time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC), report.DateBegin)
+	require.Len(t, report.Records, 2)
+
+	pass := report.Records[0]
+	assert.Equal(t, "203.0.113.10", pass.SourceIP)
+	assert.Equal(t, 12, pass.Count)
+	assert.True(t, pass.Passed())
+	assert.Equal(t, "example.com", pass.DKIMDomain)
+	assert.Equal(t, "bounce.example.com", pass.SPFDomain)
+
+	// SPF passed for the vendor's own domain, which is not aligned.
+	fail := report.Records[1]
+	assert.Equal(t, 3, fail.Count)
+	assert.False(t, fail.Passed())
+	assert.Equal(t, "example.com", fail.HeaderFrom)
+	assert.Equal(t, "crm-vendor.net", fail.EnvelopeFrom)
+	assert.Equal(t, "", fail.DKIMDomain)
+	assert.Equal(t, "crm-vendor.net", fail.SPFDomain)
+	assert.Equal(t, "pass", fail.SPFAuthResult)
+}
+
+func TestParseDMARCReport_Namespaced(t *testing.T) {
+	xml := strings.Replace(testDMARCReport, "<feedback>", `<feedback xmlns="urn:ietf:params:xml:ns:dmarc-2.0">`, 1)
+	report, err := ParseDMARCReport([]byte(xml))
+	require.NoError(t, err)
+	assert.Len(t, report.Records, 2)
+}
+
+func TestParseDMARCReport_Invalid(t *testing.T) {
+	_, err := ParseDMARCReport([]byte("not xml"))
+	assert.Error(t, err)
+
+	_, err = ParseDMARCReport([]byte("<feedback><report_metadata><org_name>x</org_name></report_metadata></feedback>"))
+	assert.ErrorContains(t, err, "missing")
+
+	bad := strings.Replace(testDMARCReport, "<count>12</count>", "<count>many</count>", 1)
+	_, err = ParseDMARCReport([]byte(bad))
+	assert.ErrorContains(t, err, "count")
+}
+
+func reportMessage(contentType, filename string, content []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	var lines []string
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+	lines = append(lines, encoded)
+
+	return []byte(strings.Join([]string{
+		"From: noreply-dmarc-support@google.com",
+		"To: dmarc@example.com",
+		"Subject: Report domain: example.com Submitter: google.com Report-ID: 17419398612381237",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain",
+		"",
+		"This is an aggregate report from google.com.",
+		"--b1",
+		"Content-Type: " + contentType + `; name="` + filename + `"`,
+		`Content-Disposition: attachment; filename="` + filename + `"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		strings.Join(lines, "\r\n"),
+		"--b1--",
+		"",
+	}, "\r\n"))
+}
+
+func TestParseDMARCReportMessage(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte(testDMARCReport))
+	require.NoError(t, gw.Close())
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	f, err := zw.Create("google.com!example.com!1760572800!1760659199.xml")
+	require.NoError(t, err)
+	_, _ = f.Write([]byte(testDMARCReport))
+	require.NoError(t, zw.Close())
+
+	tests := []struct {
+		name    string
+		message []byte
+	}{
+		{"gzip", reportMessage("application/gzip", "report.xml.gz", gz.Bytes())},
+		{"zip", reportMessage("application/zip", "report.zip", zipped.Bytes())},
+		{"xml", reportMessage("text/xml", "report.xml", []byte(testDMARCReport))},
+		{"generic type", reportMessage("application/octet-stream", "report.xml.gz", gz.Bytes())},
+		{"single part", []byte("From: dmarc@example.net\r\nContent-Type: application/gzip\r\n" +
+			"Content-Transfer-Encoding: base64\r\n\r\n" + base64.StdEncoding.EncodeToString(gz.Bytes()) + "\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ParseDMARCReportMessage(tt.message)
+			require.NoError(t, err)
+			assert.Equal(t, "17419398612381237", report.ReportID)
+			assert.Len(t, report.Records, 2)
+		})
+	}
+
+	t.Run("no report", func(t *testing.T) {
+		_, err := ParseDMARCReportMessage([]byte("From: someone@example.net\r\nSubject: hi\r\n\r\nhello\r\n"))
+		assert.ErrorIs(t, err, ErrNoDMARCReport)
+	})
+}
@@ -2,9 +2,12 @@ package engine
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -125,3 +128,43 @@ func TestNewDNSResolver(t *testing.T) {
 		assert.Contains(t, resolver.nameserver, ":53")
 	})
 }
+
+// newTestDNSServer starts a DNS server on localhost that answers from the
+// given records, parsed from zone file lines. It sets the AD bit on answers
+// when authenticated is true, like a validating resolver for a signed zone.
+func newTestDNSServer(t *testing.T, authenticated bool, records ...string) string {
+	t.Helper()
+
+	var rrs []dns.RR
+	for _, r := range records {
+		rr, err := dns.NewRR(r)
+		require.NoError(t, err)
+		rrs = append(rrs, rr)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &dns.Server{
+		PacketConn: pc,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(req)
+			q := req.Question[0]
+			for _, rr := range rrs {
+				if strings.EqualFold(rr.Header().Name, q.Name) && rr.Header().Rrtype == q.Qtype {
+					m.Answer = append(m.Answer, rr)
+				}
+			}
+			m.AuthenticatedData = authenticated
+			if len(m.Answer) == 0 {
+				m.Rcode = dns.RcodeNameError
+			}
+			_ = w.WriteMsg(m)
+		}),
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	return pc.LocalAddr().String()
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// sealedPrefix marks a secret sealed by a Keyring. Sealed secrets have the
+// form "enc:v1:<wrapper id>:<base64 ciphertext>"; anything else is taken to be
+// stored in the clear, as DKIM keys were before encryption at rest.
+const sealedPrefix = "enc:v1:"
+
+// KeyWrapper encrypts and decrypts secrets under one master key, which may be
+// held locally or by an external key management service.
+type KeyWrapper interface {
+	// ID identifies the master key. It is stored with every secret the
+	// wrapper seals, so it must not contain colons and must stay stable.
+	ID() string
+	Wrap(ctx context.Context, plaintext []byte) ([]byte, error)
+	Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// Keyring seals secrets such as DKIM private keys with its primary wrapper
+// and opens secrets sealed by any of its wrappers, so that keys sealed under
+// a previous master key stay readable until they are re-encrypted. A nil
+// Keyring, or one without a primary wrapper, stores secrets in the clear.
+type Keyring struct {
+	primary  KeyWrapper
+	wrappers map[string]KeyWrapper
+}
+
+// NewKeyring creates a Keyring sealing with primary, which may be nil, and
+// also opening secrets sealed with the previous wrappers.
+func NewKeyring(primary KeyWrapper, previous ...KeyWrapper) *Keyring {
+	k := &Keyring{primary: primary, wrappers: make(map[string]KeyWrapper)}
+	for _, w := range previous {
+		k.wrappers[w.ID()] = w
+	}
+	if primary != nil {
+		k.wrappers[primary.ID()] = primary
+	}
+	return k
+}
+
+// Encrypts reports whether the keyring seals secrets rather than storing
+// them in the clear.
+func (k *Keyring) Encrypts() bool {
+	return k != nil && k.primary != nil
+}
+
+// Seal encrypts a secret for storage.
+func (k *Keyring) Seal(ctx context.Context, plaintext string) (string, error) {
+	if !k.Encrypts() {
+		return plaintext, nil
+	}
+	ciphertext, err := k.primary.Wrap(ctx, []byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("wrapping with %s: %w", k.primary.ID(), err)
+	}
+	return sealedPrefix + k.primary.ID() + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a stored secret. Secrets stored in the clear are returned
+// as they are.
+func (k *Keyring) Open(ctx context.Context, stored string) (string, error) {
+	id, ciphertext, sealed, err := parseSealed(stored)
+	if err != nil || !sealed {
+		return stored, err
+	}
+	var w KeyWrapper
+	if k != nil {
+		w = k.wrappers[id]
+	}
+	if w == nil {
+		return "", fmt.Errorf("secret is sealed with unknown master key %q", id)
+	}
+	plaintext, err := w.Unwrap(ctx, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("unwrapping with %s: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// SealedWithPrimary reports whether a stored secret is sealed with the
+// primary wrapper, or, for a keyring that does not encrypt, stored in the
+// clear. Secrets for which it is false need re-encrypting.
+func (k *Keyring) SealedWithPrimary(stored string) bool {
+	id, _, sealed, _ := parseSealed(stored)
+	if !k.Encrypts() {
+		return !sealed
+	}
+	return sealed && id == k.primary.ID()
+}
+
+func parseSealed(stored string) (id string, ciphertext []byte, sealed bool, err error) {
+	rest, ok := strings.CutPrefix(stored, sealedPrefix)
+	if !ok {
+		return "", nil, false, nil
+	}
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", nil, true, fmt.Errorf("malformed sealed secret")
+	}
+	ciphertext, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, true, fmt.Errorf("decoding sealed secret: %w", err)
+	}
+	return id, ciphertext, true, nil
+}
+
+// LocalKeyWrapper wraps secrets with AES-256-GCM under a master key held in
+// the configuration or a local file.
+type LocalKeyWrapper struct {
+	key []byte
+	id  string
+}
+
+// NewLocalKeyWrapper creates a LocalKeyWrapper from a hex-encoded master key
+// of at least 32 bytes. Keys longer than 32 bytes are hashed down to 32.
+func NewLocalKeyWrapper(hexKey string) (*LocalKeyWrapper, error) {
+	decoded, err := hex.DecodeString(strings.TrimSpace(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("master key must be hex: %w", err)
+	}
+	if len(decoded) < 32 {
+		return nil, fmt.Errorf("master key must be at least 32 bytes, got %d", len(decoded))
+	}
+	if len(decoded) > 32 {
+		sum := sha256.Sum256(decoded)
+		decoded = sum[:]
+	}
+	fingerprint := sha256.Sum256(decoded)
+	return &LocalKeyWrapper{key: decoded, id: "local-" + hex.EncodeToString(fingerprint[:4])}, nil
+}
+
+// LoadLocalKeyWrapper creates a LocalKeyWrapper from a file holding the
+// hex-encoded master key, such as a secret mounted from a KMS or HSM.
+func LoadLocalKeyWrapper(path string) (*LocalKeyWrapper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading master key file: %w", err)
+	}
+	return NewLocalKeyWrapper(string(data))
+}
+
+// ID returns "local-" followed by a fingerprint of the master key.
+func (w *LocalKeyWrapper) ID() string { return w.id }
+
+// Wrap encrypts plaintext with AES-256-GCM.
+func (w *LocalKeyWrapper) Wrap(_ context.Context, plaintext []byte) ([]byte, error) {
+	encrypted, err := EncryptPrivateKey(string(plaintext), w.key)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(encrypted)
+}
+
+// Unwrap decrypts ciphertext produced by Wrap.
+func (w *LocalKeyWrapper) Unwrap(_ context.Context, ciphertext []byte) ([]byte, error) {
+	plaintext, err := DecryptPrivateKey(base64.StdEncoding.EncodeToString(ciphertext), w.key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plaintext), nil
+}
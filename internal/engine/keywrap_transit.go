@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TransitConfig holds the settings for a TransitKeyWrapper.
+type TransitConfig struct {
+	// Address is the base URL of the service, e.g. https://vault:8200.
+	Address string
+	Token   string
+	// Mount is the path the transit secrets engine is mounted at; it
+	// defaults to "transit".
+	Mount   string
+	KeyName string
+}
+
+// TransitKeyWrapper wraps secrets with a Vault-style transit encryption
+// service, so the master key never leaves the key management service.
+type TransitKeyWrapper struct {
+	cfg    TransitConfig
+	client *http.Client
+}
+
+// NewTransitKeyWrapper creates a new TransitKeyWrapper.
+func NewTransitKeyWrapper(cfg TransitConfig) (*TransitKeyWrapper, error) {
+	if cfg.KeyName == "" {
+		return nil, fmt.Errorf("transit: key name is required")
+	}
+	if strings.Contains(cfg.KeyName, ":") {
+		return nil, fmt.Errorf("transit: key name must not contain ':'")
+	}
+	u, err := url.Parse(cfg.Address)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("transit: invalid address %q", cfg.Address)
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+
+	return &TransitKeyWrapper{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// ID returns "transit-" followed by the transit key name.
+func (w *TransitKeyWrapper) ID() string { return "transit-" + w.cfg.KeyName }
+
+// Wrap encrypts plaintext with the transit key. The ciphertext is the
+// service's own ciphertext string, which records the key version used.
+func (w *TransitKeyWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	req := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+	if err := w.do(ctx, "encrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Ciphertext == "" {
+		return nil, fmt.Errorf("transit: empty ciphertext in response")
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+// Unwrap decrypts ciphertext produced by Wrap.
+func (w *TransitKeyWrapper) Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	req := map[string]string{"ciphertext": string(ciphertext)}
+	if err := w.do(ctx, "decrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("transit: decoding plaintext: %w", err)
+	}
+	return plaintext, nil
+}
+
+// do posts body to the transit endpoint for operation and decodes the JSON
+// response into out.
+func (w *TransitKeyWrapper) do(ctx context.Context, operation string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/%s/%s", w.cfg.Address, w.cfg.Mount, operation, url.PathEscape(w.cfg.KeyName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.cfg.Token != "" {
+		req.Header.Set("X-Vault-Token", w.cfg.Token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("transit %s: %w", operation, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("transit %s: status %d: %s", operation, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("transit %s: decoding response: %w", operation, err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MTA-STS policy modes (RFC 8461).
+const (
+	MTASTSModeEnforce = "enforce"
+	MTASTSModeTesting = "testing"
+	MTASTSModeNone    = "none"
+)
+
+const (
+	// maxMTASTSPolicyBytes bounds the size of a fetched policy file.
+	maxMTASTSPolicyBytes = 64 << 10
+	// maxMTASTSMaxAge is the largest max_age a policy may declare, one year.
+	maxMTASTSMaxAge = 31557600 * time.Second
+	// mtastsFetchTimeout bounds fetching a policy file.
+	mtastsFetchTimeout = 10 * time.Second
+)
+
+// MTASTSPolicy is a domain's MTA-STS policy, which lists the MX hosts that
+// may receive its mail and whether they must be reached over authenticated
+// TLS.
+type MTASTSPolicy struct {
+	ID      string // id of the _mta-sts TXT record the policy was fetched for
+	Mode    string
+	MX      []string
+	MaxAge  time.Duration
+	Expires time.Time
+}
+
+// MatchesMX reports whether host is one of the policy's MX patterns. A
+// pattern starting with "*." matches a single leftmost label.
+func (p *MTASTSPolicy) MatchesMX(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range p.MX {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			label, rest, found := strings.Cut(host, ".")
+			if found && label != "" && rest == suffix {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseMTASTSPolicy parses an MTA-STS policy file.
+func ParseMTASTSPolicy(data []byte) (*MTASTSPolicy, error) {
+	p := &MTASTSPolicy{}
+	var version, maxAge string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "version":
+			version = value
+		case "mode":
+			p.Mode = value
+		case "max_age":
+			maxAge = value
+		case "mx":
+			p.MX = append(p.MX, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading MTA-STS policy: %w", err)
+	}
+
+	if version != "STSv1" {
+		return nil, fmt.Errorf("unsupported MTA-STS policy version %q", version)
+	}
+	switch p.Mode {
+	case MTASTSModeEnforce, MTASTSModeTesting:
+		if len(p.MX) == 0 {
+			return nil, fmt.Errorf("MTA-STS policy in %s mode lists no mx", p.Mode)
+		}
+	case MTASTSModeNone:
+	default:
+		return nil, fmt.Errorf("invalid MTA-STS policy mode %q", p.Mode)
+	}
+	seconds, err := strconv.ParseUint(maxAge, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MTA-STS policy max_age %q", maxAge)
+	}
+	p.MaxAge = min(time.Duration(seconds)*time.Second, maxMTASTSMaxAge)
+
+	return p, nil
+}
+
+// MTASTSCache discovers and caches the MTA-STS policies of recipient domains.
+// A cached policy is used until its max_age runs out, and is refetched early
+// when the domain publishes a new policy id.
+type MTASTSCache struct {
+	resolver *DNSResolver
+	client   *http.Client
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	policies map[string]*MTASTSPolicy
+}
+
+// NewMTASTSCache creates an MTASTSCache that looks up policy records with the
+// given resolver.
+func NewMTASTSCache(resolver *DNSResolver, logger *slog.Logger) *MTASTSCache {
+	return &MTASTSCache{
+		resolver: resolver,
+		client: &http.Client{
+			Timeout: mtastsFetchTimeout,
+			// Policy fetches must not follow redirects (RFC 8461, section 3.3).
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger:   logger,
+		now:      time.Now,
+		policies: make(map[string]*MTASTSPolicy),
+	}
+}
+
+// Policy returns the MTA-STS policy of domain, or nil if it has none. Lookup
+// and fetch failures fall back to a cached policy that hasn't expired, so an
+// attacker who blocks them can't turn an enforced policy off.
+func (c *MTASTSCache) Policy(ctx context.Context, domain string) *MTASTSPolicy {
+	domain = strings.ToLower(domain)
+	now := c.now()
+
+	c.mu.Lock()
+	cached := c.policies[domain]
+	c.mu.Unlock()
+	if cached != nil && !now.Before(cached.Expires) {
+		cached = nil
+	}
+
+	id, err := c.lookupID(domain)
+	if err != nil || id == "" {
+		return cached
+	}
+	if cached != nil && cached.ID == id {
+		return cached
+	}
+
+	policy, err := c.fetch(ctx, domain)
+	if err != nil {
+		c.logger.Warn("fetching MTA-STS policy failed", "domain", domain, "error", err)
+		return cached
+	}
+	policy.ID = id
+	policy.Expires = now.Add(policy.MaxAge)
+
+	c.mu.Lock()
+	c.policies[domain] = policy
+	c.mu.Unlock()
+	return policy
+}
+
+// lookupID returns the id of the domain's _mta-sts TXT record, or an empty
+// string if it doesn't publish exactly one.
+func (c *MTASTSCache) lookupID(domain string) (string, error) {
+	records, err := c.resolver.lookupTXT("_mta-sts." + domain)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for _, record := range records {
+		if !strings.HasPrefix(record, "v=STSv1") {
+			continue
+		}
+		for _, field := range strings.Split(record, ";") {
+			if name, value, ok := strings.Cut(strings.TrimSpace(field), "="); ok && name == "id" {
+				ids = append(ids, value)
+			}
+		}
+	}
+	if len(ids) != 1 {
+		return "", nil
+	}
+	return ids[0], nil
+}
+
+// fetch downloads and parses the policy file of domain.
+func (c *MTASTSCache) fetch(ctx context.Context, domain string) (*MTASTSPolicy, error) {
+	url := "https://mta-sts." + domain + "/.well-known/mta-sts.txt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/plain" {
+		return nil, fmt.Errorf("GET %s: unexpected content type %q", url, mediaType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMTASTSPolicyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if len(body) > maxMTASTSPolicyBytes {
+		return nil, fmt.Errorf("%s exceeds %d bytes", url, maxMTASTSPolicyBytes)
+	}
+	return ParseMTASTSPolicy(body)
+}
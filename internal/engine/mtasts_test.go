@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMTASTSPolicy = "version: STSv1\r\nmode: enforce\r\nmx: mx1.example.com\r\nmx: *.mail.example.net\r\nmax_age: 86400\r\n"
+
+func TestParseMTASTSPolicy(t *testing.T) {
+	p, err := ParseMTASTSPolicy([]byte(testMTASTSPolicy))
+	require.NoError(t, err)
+	assert.Equal(t, MTASTSModeEnforce, p.Mode)
+	assert.Equal(t, []string{"mx1.example.com", "*.mail.example.net"}, p.MX)
+	assert.Equal(t, 24*time.Hour, p.MaxAge)
+
+	t.Run("max_age is capped at a year", func(t *testing.T) {
+		p, err := ParseMTASTSPolicy([]byte("version: STSv1\nmode: none\nmax_age: 99999999\n"))
+		require.NoError(t, err)
+		assert.Equal(t, maxMTASTSMaxAge, p.MaxAge)
+	})
+
+	invalid := map[string]string{
+		"wrong version":   "version: STSv2\nmode: enforce\nmx: mx.example.com\nmax_age: 86400\n",
+		"unknown mode":    "version: STSv1\nmode: strict\nmx: mx.example.com\nmax_age: 86400\n",
+		"no mx":           "version: STSv1\nmode: enforce\nmax_age: 86400\n",
+		"missing max_age": "version: STSv1\nmode: testing\nmx: mx.example.com\n",
+		"not a policy":    "<html>Not Found</html>",
+	}
+	for name, policy := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseMTASTSPolicy([]byte(policy))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMTASTSPolicy_MatchesMX(t *testing.T) {
+	p := &MTASTSPolicy{MX: []string{"mx1.example.com", "*.mail.example.net"}}
+
+	assert.True(t, p.MatchesMX("mx1.example.com"))
+	assert.True(t, p.MatchesMX("MX1.Example.com."))
+	assert.True(t, p.MatchesMX("a.mail.example.net"))
+	assert.False(t, p.MatchesMX("mail.example.net"))
+	assert.False(t, p.MatchesMX("a.b.mail.example.net"))
+	assert.False(t, p.MatchesMX("mx2.example.com"))
+}
+
+// newTestMTASTSCache returns an MTASTSCache that resolves names with the DNS
+// server at dnsAddr and fetches every policy from an HTTPS server running
+// handler, whose certificate is valid for *.example.com.
+func newTestMTASTSCache(t *testing.T, dnsAddr string, handler http.Handler) *MTASTSCache {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewMTASTSCache(NewDNSResolver(dnsAddr, time.Second), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	c.client.Transport = transport
+	return c
+}
+
+func TestMTASTSCache_Policy(t *testing.T) {
+	dnsAddr := newTestDNSServer(t, false, `_mta-sts.example.com. 300 IN TXT "v=STSv1; id=20261016T000000"`)
+
+	var fetches atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	c := newTestMTASTSCache(t, dnsAddr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		assert.Equal(t, "mta-sts.example.com", r.Host)
+		assert.Equal(t, "/.well-known/mta-sts.txt", r.URL.Path)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(int(status.Load()))
+		_, _ = io.WriteString(w, testMTASTSPolicy)
+	}))
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	p := c.Policy(context.Background(), "example.com")
+	require.NotNil(t, p)
+	assert.Equal(t, "20261016T000000", p.ID)
+	assert.Equal(t, MTASTSModeEnforce, p.Mode)
+	assert.Equal(t, now.Add(24*time.Hour), p.Expires)
+
+	t.Run("cached while the id is unchanged", func(t *testing.T) {
+		assert.Same(t, p, c.Policy(context.Background(), "example.com"))
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("refetched when the id changes", func(t *testing.T) {
+		p.ID = "20261001T000000"
+		fresh := c.Policy(context.Background(), "example.com")
+		require.NotNil(t, fresh)
+		assert.Equal(t, "20261016T000000", fresh.ID)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("cached policy is kept when the fetch fails", func(t *testing.T) {
+		status.Store(http.StatusNotFound)
+		c.policies["example.com"].ID = "20261001T000000"
+		kept := c.Policy(context.Background(), "example.com")
+		require.NotNil(t, kept)
+		assert.Equal(t, "20261001T000000", kept.ID)
+
+		now = now.Add(25 * time.Hour)
+		assert.Nil(t, c.Policy(context.Background(), "example.com"))
+	})
+
+	t.Run("no policy without a TXT record", func(t *testing.T) {
+		assert.Nil(t, c.Policy(context.Background(), "example.org"))
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,12 +14,31 @@ import (
 	"mime/multipart"
 	"mime/quotedprintable"
 	"net"
+	"net/mail"
 	"net/smtp"
 	"net/textproto"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/miekg/dns"
+)
+
+// TLS policies a delivery can be made under. The sender's own policy,
+// opportunistic or enforce, applies to destinations that publish neither
+// DANE TLSA records nor an MTA-STS policy.
+const (
+	TLSPolicyOpportunistic = "opportunistic"
+	TLSPolicyEnforce       = "enforce"
+	TLSPolicyMTASTS        = "mta-sts"
+	TLSPolicyMTASTSTesting = "mta-sts-testing"
+	TLSPolicyDANE          = "dane"
 )
 
+// errSTARTTLS marks a failed STARTTLS handshake, after which the connection
+// can't be used any more.
+var errSTARTTLS = errors.New("STARTTLS failed")
+
 // SenderMetrics is an optional interface for recording SMTP metrics.
 // Pass nil to disable metrics.
 type SenderMetrics interface {
@@ -29,7 +50,11 @@ type SenderMetrics interface {
 type Sender struct {
 	hostname       string
 	heloDomain     string
+	port           int
 	tlsPolicy      string // "opportunistic" or "enforce"
+	mtaSTS         *MTASTSCache
+	dane           bool
+	rootCAs        *x509.CertPool // nil for the system roots
 	connectTimeout time.Duration
 	sendTimeout    time.Duration
 	maxRecipients  int
@@ -37,6 +62,7 @@ type Sender struct {
 	logger         *slog.Logger
 	circuitBreaker *CircuitBreaker
 	metrics        SenderMetrics
+	keyring        *Keyring
 
 	// Relay mode (SES)
 	relayMode     string // "direct" or "relay"
@@ -51,11 +77,21 @@ type Sender struct {
 type SenderConfig struct {
 	Hostname       string
 	HeloDomain     string
+	Port           int
 	TLSPolicy      string
 	ConnectTimeout time.Duration
 	SendTimeout    time.Duration
 	MaxRecipients  int
 	Metrics        SenderMetrics
+	// Keyring opens DKIM keys stored sealed. Without one, keys must be
+	// given in the clear.
+	Keyring *Keyring
+	// MTASTS enables discovery of recipient domains' MTA-STS policies
+	// (RFC 8461).
+	MTASTS bool
+	// DANE enables DANE TLSA lookups for MX hosts (RFC 7672). It needs a
+	// DNSSEC-validating resolver.
+	DANE bool
 
 	// Relay mode fields
 	RelayMode     string
@@ -69,6 +105,7 @@ type SenderConfig struct {
 // OutgoingMessage holds all the data needed to build and send an email.
 type OutgoingMessage struct {
 	From         string
+	ReturnPath   string // envelope sender (MAIL FROM); defaults to From
 	To           []string
 	Cc           []string
 	Bcc          []string
@@ -81,7 +118,7 @@ type OutgoingMessage struct {
 	MessageID    string
 	DKIMDomain   string
 	DKIMSelector string
-	DKIMKey      string // decrypted PEM private key
+	DKIMKey      string // PEM private key, in the clear or sealed by the sender's Keyring
 }
 
 // MessageAttachment represents a file attached to an email.
@@ -103,6 +140,24 @@ type RecipientResult struct {
 	Code      int    // SMTP response code
 	Message   string // SMTP response message
 	Permanent bool   // true for 5xx errors
+	TLS       TLSOutcome
+}
+
+// TLSOutcome records how the connection a recipient was handled on was
+// secured.
+type TLSOutcome struct {
+	Policy   string // the TLS policy the delivery was made under
+	Version  string // negotiated TLS version; empty if no TLS was used
+	Cipher   string
+	Verified bool // the server certificate was authenticated
+}
+
+// hostTLS is the TLS requirement for delivering to one host.
+type hostTLS struct {
+	policy   string
+	required bool        // refuse delivery without TLS
+	pkix     bool        // the certificate must be valid for the host name
+	tlsa     []*dns.TLSA // DANE records the certificate must match
 }
 
 // NewSender creates a new SMTP sender with the given configuration.
@@ -116,8 +171,11 @@ func NewSender(cfg SenderConfig, resolver *DNSResolver, logger *slog.Logger) *Se
 	if cfg.MaxRecipients == 0 {
 		cfg.MaxRecipients = 50
 	}
+	if cfg.Port == 0 {
+		cfg.Port = 25
+	}
 	if cfg.TLSPolicy == "" {
-		cfg.TLSPolicy = "opportunistic"
+		cfg.TLSPolicy = TLSPolicyOpportunistic
 	}
 	if cfg.HeloDomain == "" {
 		cfg.HeloDomain = cfg.Hostname
@@ -136,10 +194,18 @@ func NewSender(cfg SenderConfig, resolver *DNSResolver, logger *slog.Logger) *Se
 		relayTLS = "starttls"
 	}
 
+	var mtaSTS *MTASTSCache
+	if cfg.MTASTS {
+		mtaSTS = NewMTASTSCache(resolver, logger)
+	}
+
 	return &Sender{
 		hostname:       cfg.Hostname,
 		heloDomain:     cfg.HeloDomain,
+		port:           cfg.Port,
 		tlsPolicy:      cfg.TLSPolicy,
+		mtaSTS:         mtaSTS,
+		dane:           cfg.DANE,
 		connectTimeout: cfg.ConnectTimeout,
 		sendTimeout:    cfg.SendTimeout,
 		maxRecipients:  cfg.MaxRecipients,
@@ -147,6 +213,7 @@ func NewSender(cfg SenderConfig, resolver *DNSResolver, logger *slog.Logger) *Se
 		logger:         logger,
 		circuitBreaker: NewCircuitBreaker(defaultFailureThreshold, defaultResetTimeout),
 		metrics:        cfg.Metrics,
+		keyring:        cfg.Keyring,
 		relayMode:      relayMode,
 		relayHost:      cfg.RelayHost,
 		relayPort:      relayPort,
@@ -178,7 +245,11 @@ func (s *Sender) SendEmail(ctx context.Context, msg *OutgoingMessage) (*SendResu
 	// Sign with DKIM if configured.
 	signedMessage := rawMessage
 	if msg.DKIMKey != "" && msg.DKIMDomain != "" && msg.DKIMSelector != "" {
-		signedMessage, err = SignMessage(rawMessage, msg.DKIMDomain, msg.DKIMSelector, msg.DKIMKey)
+		dkimKey, err := s.keyring.Open(ctx, msg.DKIMKey)
+		if err != nil {
+			return nil, fmt.Errorf("opening DKIM key: %w", err)
+		}
+		signedMessage, err = SignMessage(rawMessage, msg.DKIMDomain, msg.DKIMSelector, dkimKey)
 		if err != nil {
 			return nil, fmt.Errorf("DKIM signing: %w", err)
 		}
@@ -189,14 +260,25 @@ func (s *Sender) SendEmail(ctx context.Context, msg *OutgoingMessage) (*SendResu
 		Recipients: make(map[string]RecipientResult),
 	}
 
+	// Bounces go to the envelope sender, so a per-message return path lets
+	// asynchronous bounces be matched to the message.
+	envelopeFrom := msg.ReturnPath
+	if envelopeFrom == "" {
+		envelopeFrom = msg.From
+		// The From header may carry a display name.
+		if addr, err := mail.ParseAddress(msg.From); err == nil {
+			envelopeFrom = addr.Address
+		}
+	}
+
 	if s.relayMode == "relay" {
 		// Deliver through a relay (e.g. SES) instead of direct MX delivery.
-		s.deliverViaRelay(ctx, allRecipients, msg.From, signedMessage, result)
+		s.deliverViaRelay(ctx, allRecipients, envelopeFrom, signedMessage, result)
 	} else {
 		// Direct delivery: group recipients by domain.
 		domainRecipients := groupByDomain(allRecipients)
 		for domain, recipients := range domainRecipients {
-			s.deliverToDomain(ctx, domain, recipients, msg.From, signedMessage, result)
+			s.deliverToDomain(ctx, domain, recipients, envelopeFrom, signedMessage, result)
 		}
 	}
 
@@ -243,16 +325,20 @@ func (s *Sender) deliverViaRelay(
 	message []byte,
 	result *SendResult,
 ) {
-	addr := fmt.Sprintf("%s:%d", s.relayHost, s.relayPort)
+	addr := net.JoinHostPort(s.relayHost, strconv.Itoa(s.relayPort))
+	enforce := s.tlsPolicy == TLSPolicyEnforce
+	t := hostTLS{policy: s.tlsPolicy, required: enforce, pkix: enforce}
 	for _, rcpt := range recipients {
-		if err := s.deliverToHost(ctx, addr, from, []string{rcpt}, message, result); err != nil {
+		if err := s.deliverToHost(ctx, addr, s.relayHost, t, from, []string{rcpt}, message, result); err != nil {
 			s.logger.Warn("relay delivery failed", "relay", addr, "recipient", rcpt, "error", err)
 		}
 	}
 }
 
 // deliverToDomain resolves MX records for the domain and attempts delivery
-// through each MX host in priority order until one succeeds.
+// through each MX host in priority order until one succeeds. Hosts are held
+// to the TLS requirements of their DANE records or the domain's MTA-STS
+// policy.
 func (s *Sender) deliverToDomain(
 	ctx context.Context,
 	domain string,
@@ -275,6 +361,11 @@ func (s *Sender) deliverToDomain(
 		return
 	}
 
+	var stsPolicy *MTASTSPolicy
+	if s.mtaSTS != nil {
+		stsPolicy = s.mtaSTS.Policy(ctx, domain)
+	}
+
 	// Try each MX host in priority order.
 	var lastErr error
 	for _, mx := range mxRecords {
@@ -302,14 +393,23 @@ func (s *Sender) deliverToDomain(
 			continue
 		}
 
+		t, err := s.hostTLS(mx.Host, stsPolicy)
+		if err != nil {
+			lastErr = err
+			s.logger.Warn("skipping MX host", "domain", domain, "mx_host", mx.Host, "error", err)
+			continue
+		}
+
 		s.logger.Debug("attempting delivery",
 			"domain", domain,
 			"mx_host", mx.Host,
 			"mx_priority", mx.Priority,
+			"tls_policy", t.policy,
 			"recipients", len(recipients),
 		)
 
-		err := s.deliverToHost(ctx, mx.Host, from, recipients, message, result)
+		addr := net.JoinHostPort(mx.Host, strconv.Itoa(s.port))
+		err = s.deliverToHost(ctx, addr, mx.Host, t, from, recipients, message, result)
 		if err == nil {
 			s.circuitBreaker.RecordSuccess(mx.Host)
 			return // Successfully delivered.
@@ -335,17 +435,80 @@ func (s *Sender) deliverToDomain(
 	}
 }
 
-// deliverToHost connects to a single MX host and attempts SMTP delivery.
+// hostTLS works out the TLS requirement for delivering to an MX host. DANE
+// records take precedence over an MTA-STS policy (RFC 8461, section 2).
+func (s *Sender) hostTLS(host string, stsPolicy *MTASTSPolicy) (hostTLS, error) {
+	enforce := s.tlsPolicy == TLSPolicyEnforce
+	if s.dane {
+		records, err := s.resolver.LookupTLSA(host)
+		if err != nil {
+			// The host may have records that couldn't be checked, so it
+			// can't be used until they can (RFC 7672, section 2.2).
+			return hostTLS{}, fmt.Errorf("looking up TLSA records: %w", err)
+		}
+		if len(records) > 0 {
+			return hostTLS{policy: TLSPolicyDANE, required: true, tlsa: records}, nil
+		}
+	}
+
+	if stsPolicy != nil {
+		switch stsPolicy.Mode {
+		case MTASTSModeEnforce:
+			if !stsPolicy.MatchesMX(host) {
+				return hostTLS{}, fmt.Errorf("MX host %s is not listed in the MTA-STS policy", host)
+			}
+			return hostTLS{policy: TLSPolicyMTASTS, required: true, pkix: true}, nil
+		case MTASTSModeTesting:
+			if !stsPolicy.MatchesMX(host) {
+				s.logger.Warn("MX host is not listed in the MTA-STS policy", "mx_host", host, "mode", stsPolicy.Mode)
+			}
+			return hostTLS{policy: TLSPolicyMTASTSTesting, required: enforce, pkix: enforce}, nil
+		}
+	}
+
+	// enforce requires a certificate that is valid for the host name, not
+	// just an encrypted connection.
+	return hostTLS{policy: s.tlsPolicy, required: enforce, pkix: enforce}, nil
+}
+
+// deliverToHost delivers to a single host at addr. If STARTTLS fails and the
+// host's policy doesn't require TLS, the message is sent again in plaintext
+// over a new connection.
 func (s *Sender) deliverToHost(
 	ctx context.Context,
+	addr string,
+	host string,
+	t hostTLS,
+	from string,
+	recipients []string,
+	message []byte,
+	result *SendResult,
+) error {
+	err := s.deliverOnce(ctx, addr, host, t, true, from, recipients, message, result)
+	if errors.Is(err, errSTARTTLS) && !t.required {
+		s.logger.Warn("STARTTLS failed, retrying without TLS",
+			"host", host,
+			"error", err,
+		)
+		err = s.deliverOnce(ctx, addr, host, t, false, from, recipients, message, result)
+	}
+	return err
+}
+
+// deliverOnce connects to a host and attempts SMTP delivery, using STARTTLS
+// when useTLS is set and the host offers it.
+func (s *Sender) deliverOnce(
+	ctx context.Context,
+	addr string,
 	host string,
+	t hostTLS,
+	useTLS bool,
 	from string,
 	recipients []string,
 	message []byte,
 	result *SendResult,
 ) error {
 	start := time.Now()
-	addr := host + ":25"
 
 	// Connect with timeout.
 	dialer := net.Dialer{Timeout: s.connectTimeout}
@@ -374,21 +537,21 @@ func (s *Sender) deliverToHost(
 	}
 
 	// Attempt STARTTLS.
-	if ok, _ := client.Extension("STARTTLS"); ok {
-		tlsConfig := &tls.Config{
-			ServerName: host,
-		}
-		if err := client.StartTLS(tlsConfig); err != nil {
-			if s.tlsPolicy == "enforce" {
-				return fmt.Errorf("STARTTLS required but failed for %s: %w", host, err)
+	outcome := TLSOutcome{Policy: t.policy}
+	if ok, _ := client.Extension("STARTTLS"); ok && useTLS {
+		if err := client.StartTLS(s.tlsConfig(host, t, &outcome)); err != nil {
+			s.recordSMTPConnection(host, "tls_error")
+			if t.required {
+				return fmt.Errorf("%w for %s, which the %s policy requires: %v", errSTARTTLS, host, t.policy, err)
 			}
-			s.logger.Warn("STARTTLS failed, continuing without TLS",
-				"host", host,
-				"error", err,
-			)
+			return fmt.Errorf("%w for %s: %v", errSTARTTLS, host, err)
+		}
+		if cs, ok := client.TLSConnectionState(); ok {
+			outcome.Version = tls.VersionName(cs.Version)
+			outcome.Cipher = tls.CipherSuiteName(cs.CipherSuite)
 		}
-	} else if s.tlsPolicy == "enforce" {
-		return fmt.Errorf("STARTTLS required but not offered by %s", host)
+	} else if t.required {
+		return fmt.Errorf("STARTTLS not offered by %s, which the %s policy requires", host, t.policy)
 	}
 
 	// MAIL FROM.
@@ -407,6 +570,7 @@ func (s *Sender) deliverToHost(
 				Code:      code,
 				Message:   msg,
 				Permanent: bounce.Permanent,
+				TLS:       outcome,
 			}
 			s.logger.Warn("RCPT TO rejected",
 				"recipient", rcpt,
@@ -434,6 +598,7 @@ func (s *Sender) deliverToHost(
 				Code:      code,
 				Message:   msg,
 				Permanent: code >= 500,
+				TLS:       outcome,
 			}
 		}
 		return fmt.Errorf("DATA to %s: %w", host, err)
@@ -452,6 +617,7 @@ func (s *Sender) deliverToHost(
 				Code:      code,
 				Message:   msg,
 				Permanent: code >= 500,
+				TLS:       outcome,
 			}
 		}
 		return fmt.Errorf("closing DATA to %s: %w", host, err)
@@ -463,6 +629,7 @@ func (s *Sender) deliverToHost(
 			Status:  "sent",
 			Code:    250,
 			Message: "OK",
+			TLS:     outcome,
 		}
 	}
 
@@ -472,6 +639,48 @@ func (s *Sender) deliverToHost(
 	return nil
 }
 
+// tlsConfig returns the TLS configuration for STARTTLS with host. The
+// certificate is checked in VerifyConnection rather than by crypto/tls, so
+// that policies which don't require authentication still record whether it
+// could have been verified. outcome.Verified is set accordingly.
+func (s *Sender) tlsConfig(host string, t hostTLS, outcome *TLSOutcome) *tls.Config {
+	return &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, //nolint:gosec // verified in VerifyConnection
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			var err error
+			if len(t.tlsa) > 0 {
+				err = verifyDANE(cs, t.tlsa, host)
+			} else {
+				err = s.verifyPKIX(cs, host)
+			}
+			if err != nil && (len(t.tlsa) > 0 || t.pkix) {
+				return err
+			}
+			outcome.Verified = err == nil
+			return nil
+		},
+	}
+}
+
+// verifyPKIX checks that the server certificate chains to a trusted root and
+// is valid for host.
+func (s *Sender) verifyPKIX(cs tls.ConnectionState, host string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server sent no certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         s.rootCAs,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
 // recordSMTPConnection records an SMTP connection metric if metrics are configured.
 func (s *Sender) recordSMTPConnection(host, result string) {
 	if s.metrics != nil {
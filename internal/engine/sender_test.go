@@ -1,10 +1,20 @@
 package engine
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	gosmtp "github.com/emersion/go-smtp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -462,3 +472,220 @@ func TestStatusFromBounce(t *testing.T) {
 		})
 	}
 }
+
+// testMX is an SMTP server stand-in for a recipient's MX host. It counts the
+// messages it accepts.
+type testMX struct {
+	mu       sync.Mutex
+	messages int
+}
+
+func (m *testMX) NewSession(*gosmtp.Conn) (gosmtp.Session, error) {
+	return &testMXSession{mx: m}, nil
+}
+
+type testMXSession struct {
+	mx *testMX
+}
+
+func (s *testMXSession) Reset()                                  {}
+func (s *testMXSession) Logout() error                           { return nil }
+func (s *testMXSession) Mail(string, *gosmtp.MailOptions) error { return nil }
+func (s *testMXSession) Rcpt(string, *gosmtp.RcptOptions) error { return nil }
+
+func (s *testMXSession) Data(r io.Reader) error {
+	if _, err := io.ReadAll(r); err != nil {
+		return err
+	}
+	s.mx.mu.Lock()
+	s.mx.messages++
+	s.mx.mu.Unlock()
+	return nil
+}
+
+func (m *testMX) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.messages
+}
+
+// newTestMX starts an SMTP server on localhost that offers STARTTLS with
+// cert, or no STARTTLS if cert is nil, and returns it with its port.
+func newTestMX(t *testing.T, cert *testCert) (*testMX, int) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mx := &testMX{}
+	srv := gosmtp.NewServer(mx)
+	srv.Domain = "localhost"
+	if cert != nil {
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert.tlsCertificate()}}
+	}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return mx, ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestSender_DeliveryTLSPolicies(t *testing.T) {
+	cert := newTestCert(t, "localhost", nil)
+	otherCert := newTestCert(t, "localhost", nil)
+	trusted := x509.NewCertPool()
+	trusted.AddCert(cert.cert)
+
+	const mxRecord = "example.com. 300 IN MX 10 localhost."
+	const stsRecord = `_mta-sts.example.com. 300 IN TXT "v=STSv1; id=1"`
+	enforcePolicy := "version: STSv1\nmode: enforce\nmx: localhost\nmax_age: 86400\n"
+
+	tests := []struct {
+		name       string
+		records    []string
+		policy     string // MTA-STS policy file served for example.com
+		tlsPolicy  string // smtp_outbound.tls_policy
+		noSTARTTLS bool
+		rootCAs    *x509.CertPool
+		wantStatus string
+		wantTLS    TLSOutcome
+		wantErr    string
+	}{
+		{
+			name:       "opportunistic TLS with an untrusted certificate",
+			records:    []string{mxRecord},
+			wantStatus: "sent",
+			wantTLS:    TLSOutcome{Policy: TLSPolicyOpportunistic, Version: "TLS 1.3"},
+		},
+		{
+			name:       "opportunistic delivery without STARTTLS",
+			records:    []string{mxRecord},
+			noSTARTTLS: true,
+			wantStatus: "sent",
+			wantTLS:    TLSOutcome{Policy: TLSPolicyOpportunistic},
+		},
+		{
+			name:       "enforce with a trusted certificate",
+			records:    []string{mxRecord},
+			tlsPolicy:  TLSPolicyEnforce,
+			rootCAs:    trusted,
+			wantStatus: "sent",
+			wantTLS:    TLSOutcome{Policy: TLSPolicyEnforce, Version: "TLS 1.3", Verified: true},
+		},
+		{
+			name:       "enforce refuses an untrusted certificate",
+			records:    []string{mxRecord},
+			tlsPolicy:  TLSPolicyEnforce,
+			wantStatus: "deferred",
+			wantErr:    "enforce policy requires",
+		},
+		{
+			name:       "MTA-STS testing under enforce refuses an untrusted certificate",
+			records:    []string{mxRecord, stsRecord},
+			policy:     "version: STSv1\nmode: testing\nmx: localhost\nmax_age: 86400\n",
+			tlsPolicy:  TLSPolicyEnforce,
+			wantStatus: "deferred",
+			wantErr:    "mta-sts-testing policy requires",
+		},
+		{
+			name:       "MTA-STS enforce with a trusted certificate",
+			records:    []string{mxRecord, stsRecord},
+			policy:     enforcePolicy,
+			rootCAs:    trusted,
+			wantStatus: "sent",
+			wantTLS:    TLSOutcome{Policy: TLSPolicyMTASTS, Version: "TLS 1.3", Verified: true},
+		},
+		{
+			name:       "MTA-STS enforce refuses an untrusted certificate",
+			records:    []string{mxRecord, stsRecord},
+			policy:     enforcePolicy,
+			wantStatus: "deferred",
+			wantErr:    "mta-sts policy requires",
+		},
+		{
+			name:       "MTA-STS enforce refuses a host without STARTTLS",
+			records:    []string{mxRecord, stsRecord},
+			policy:     enforcePolicy,
+			noSTARTTLS: true,
+			rootCAs:    trusted,
+			wantStatus: "deferred",
+			wantErr:    "STARTTLS not offered",
+		},
+		{
+			name:       "MTA-STS enforce refuses an MX host not in the policy",
+			records:    []string{mxRecord, stsRecord},
+			policy:     "version: STSv1\nmode: enforce\nmx: mx.example.com\nmax_age: 86400\n",
+			rootCAs:    trusted,
+			wantStatus: "deferred",
+			wantErr:    "not listed in the MTA-STS policy",
+		},
+		{
+			name:       "MTA-STS testing delivers despite an untrusted certificate",
+			records:    []string{mxRecord, stsRecord},
+			policy:     "version: STSv1\nmode: testing\nmx: localhost\nmax_age: 86400\n",
+			wantStatus: "sent",
+			wantTLS:    TLSOutcome{Policy: TLSPolicyMTASTSTesting, Version: "TLS 1.3"},
+		},
+		{
+			name:       "DANE-EE takes precedence over MTA-STS",
+			records:    []string{mxRecord, stsRecord, tlsaRecord(t, "localhost", 3, cert.cert)},
+			policy:     enforcePolicy,
+			wantStatus: "sent",
+			wantTLS:    TLSOutcome{Policy: TLSPolicyDANE, Version: "TLS 1.3", Verified: true},
+		},
+		{
+			name:       "DANE refuses a mismatching certificate",
+			records:    []string{mxRecord, tlsaRecord(t, "localhost", 3, otherCert.cert)},
+			rootCAs:    trusted,
+			wantStatus: "deferred",
+			wantErr:    "dane policy requires",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mxCert := cert
+			if tt.noSTARTTLS {
+				mxCert = nil
+			}
+			mx, port := newTestMX(t, mxCert)
+			dnsAddr := newTestDNSServer(t, true, tt.records...)
+
+			s := NewSender(SenderConfig{
+				Hostname:       "mail.test",
+				Port:           port,
+				ConnectTimeout: 5 * time.Second,
+				SendTimeout:    10 * time.Second,
+				TLSPolicy:      tt.tlsPolicy,
+				MTASTS:         true,
+				DANE:           true,
+			}, NewDNSResolver(dnsAddr, time.Second), slog.New(slog.NewTextHandler(io.Discard, nil)))
+			s.rootCAs = tt.rootCAs
+			s.mtaSTS = newTestMTASTSCache(t, dnsAddr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = io.WriteString(w, tt.policy)
+			}))
+
+			result, err := s.SendEmail(context.Background(), &OutgoingMessage{
+				From:     "sender@mail.test",
+				To:       []string{"rcpt@example.com"},
+				Subject:  "Hello",
+				TextBody: "Hello there.",
+			})
+			require.NoError(t, err)
+
+			got := result.Recipients["rcpt@example.com"]
+			assert.Equal(t, tt.wantStatus, got.Status, got.Message)
+			if tt.wantStatus == "sent" {
+				assert.Equal(t, 1, mx.count())
+				if tt.wantTLS.Version != "" {
+					assert.NotEmpty(t, got.TLS.Cipher)
+					got.TLS.Cipher = ""
+				}
+				assert.Equal(t, tt.wantTLS, got.TLS)
+			} else {
+				assert.Equal(t, 0, mx.count())
+				assert.Contains(t, got.Message, tt.wantErr)
+			}
+		})
+	}
+}
@@ -37,6 +37,16 @@ func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A key cannot create keys with more access than it has itself.
+	if !auth.CanGrant(req.Permission, req.Scopes) {
+		pkg.Error(w, http.StatusForbidden, "cannot create an API key with access beyond your own")
+		return
+	}
+	if auth.DomainID != nil && (req.DomainID == nil || *req.DomainID != auth.DomainID.String()) {
+		pkg.Error(w, http.StatusForbidden, "API key can only create keys restricted to "+auth.Domain)
+		return
+	}
+
 	resp, err := h.service.Create(r.Context(), auth.TeamID, &req)
 	if err != nil {
 		pkg.HandleError(w, err)
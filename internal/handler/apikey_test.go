@@ -145,3 +145,21 @@ func TestAPIKeyHandler_Delete_InvalidID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestAPIKeyHandler_Create_CannotEscalate(t *testing.T) {
+	mockSvc := new(mockpkg.MockAPIKeyService)
+	h := NewAPIKeyHandler(mockSvc)
+
+	body := []byte(`{"name":"Escalated","permission":"full"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.APIKeyRequest(req, testutil.TestTeamID, "sending")
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/api-keys", h.Create) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
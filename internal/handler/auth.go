@@ -5,6 +5,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/server/middleware"
 	"github.com/mailit-dev/mailit/internal/service"
 )
 
@@ -55,3 +56,83 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg.JSON(w, http.StatusOK, resp)
 }
+
+// ListTeams handles GET /teams.
+func (h *AuthHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if auth.UserID == nil {
+		pkg.Error(w, http.StatusForbidden, "teams can only be listed by signed-in users")
+		return
+	}
+
+	teams, err := h.service.ListTeams(r.Context(), *auth.UserID, auth.TeamID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, &dto.ListResponse[dto.TeamSummaryResponse]{Data: teams})
+}
+
+// CreateTeam handles POST /teams.
+func (h *AuthHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if auth.UserID == nil {
+		pkg.Error(w, http.StatusForbidden, "teams can only be created by signed-in users")
+		return
+	}
+
+	var req dto.CreateTeamRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.CreateTeam(r.Context(), *auth.UserID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusCreated, resp)
+}
+
+// SwitchTeam handles POST /auth/switch-team.
+func (h *AuthHandler) SwitchTeam(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if auth.UserID == nil {
+		pkg.Error(w, http.StatusForbidden, "API keys are bound to a single team")
+		return
+	}
+
+	var req dto.SwitchTeamRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.SwitchTeam(r.Context(), *auth.UserID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
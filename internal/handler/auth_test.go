@@ -9,10 +9,12 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/testutil"
 	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
 )
 
@@ -193,3 +195,45 @@ func TestAuthHandler_Login_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	mockSvc.AssertExpectations(t)
 }
+
+func TestAuthHandler_SwitchTeam_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockAuthService)
+	h := NewAuthHandler(mockSvc)
+
+	teamID := uuid.New()
+	body, _ := json.Marshal(dto.SwitchTeamRequest{TeamID: teamID.String()})
+
+	expected := &dto.AuthResponse{Token: "new-token", Team: &dto.TeamSummaryResponse{ID: teamID.String(), Role: "admin", Current: true}}
+	mockSvc.On("SwitchTeam", mock.Anything, testutil.TestUserID, mock.AnythingOfType("*dto.SwitchTeamRequest")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/switch-team", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/auth/switch-team", h.SwitchTeam) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp dto.AuthResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	assert.Equal(t, "new-token", resp.Token)
+	assert.Equal(t, teamID.String(), resp.Team.ID)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAuthHandler_ListTeams_APIKey(t *testing.T) {
+	mockSvc := new(mockpkg.MockAuthService)
+	h := NewAuthHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/teams", nil)
+	req = testutil.APIKeyRequest(req, testutil.TestTeamID, "full")
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/teams", h.ListTeams) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockSvc.AssertNotCalled(t, "ListTeams")
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"encoding/csv"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -155,3 +157,230 @@ func (h *BroadcastHandler) Send(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg.JSON(w, http.StatusOK, resp)
 }
+
+// Schedule handles POST /broadcasts/{broadcastId}/schedule.
+func (h *BroadcastHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	var req dto.ScheduleBroadcastRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.Schedule(r.Context(), auth.TeamID, broadcastID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Unschedule handles POST /broadcasts/{broadcastId}/unschedule.
+func (h *BroadcastHandler) Unschedule(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	resp, err := h.service.Unschedule(r.Context(), auth.TeamID, broadcastID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Pause handles POST /broadcasts/{broadcastId}/pause.
+func (h *BroadcastHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	resp, err := h.service.Pause(r.Context(), auth.TeamID, broadcastID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Resume handles POST /broadcasts/{broadcastId}/resume.
+func (h *BroadcastHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	resp, err := h.service.Resume(r.Context(), auth.TeamID, broadcastID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Cancel handles POST /broadcasts/{broadcastId}/cancel.
+func (h *BroadcastHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	resp, err := h.service.Cancel(r.Context(), auth.TeamID, broadcastID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Stats handles GET /broadcasts/{broadcastId}/stats.
+func (h *BroadcastHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	resp, err := h.service.Stats(r.Context(), auth.TeamID, broadcastID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// ListRecipients handles GET /broadcasts/{broadcastId}/recipients.
+func (h *BroadcastHandler) ListRecipients(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	params := parsePagination(r)
+	q := r.URL.Query()
+
+	resp, err := h.service.ListRecipients(r.Context(), auth.TeamID, broadcastID, q.Get("status"), q.Get("event"), q.Get("search"), &params)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// ExportRecipients handles GET /broadcasts/{broadcastId}/recipients/export.
+func (h *BroadcastHandler) ExportRecipients(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	broadcastID, err := uuid.Parse(chi.URLParam(r, "broadcastId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid broadcast id")
+		return
+	}
+
+	q := r.URL.Query()
+	status, event, search := q.Get("status"), q.Get("event"), q.Get("search")
+
+	// Fetch the first page before writing headers so filter errors can
+	// still be reported as JSON.
+	const pageSize = 100
+	params := &dto.PaginationParams{Page: 1, PerPage: pageSize}
+	resp, err := h.service.ListRecipients(r.Context(), auth.TeamID, broadcastID, status, event, search, params)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="broadcast-%s-recipients.csv"`, broadcastID))
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"email", "status", "variant_id", "sent_at", "last_event", "last_event_at"})
+
+	for {
+		for _, rc := range resp.Data {
+			_ = writer.Write([]string{rc.Email, rc.Status, valueOrEmpty(rc.VariantID), valueOrEmpty(rc.SentAt), valueOrEmpty(rc.LastEvent), valueOrEmpty(rc.LastEventAt)})
+		}
+
+		if !resp.HasMore {
+			break
+		}
+		params = &dto.PaginationParams{Page: resp.Page + 1, PerPage: pageSize}
+		resp, err = h.service.ListRecipients(r.Context(), auth.TeamID, broadcastID, status, event, search, params)
+		if err != nil {
+			// Headers are already written, so the export just stops here.
+			break
+		}
+	}
+
+	writer.Flush()
+}
+
+// valueOrEmpty returns *s, or "" if s is nil.
+func valueOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
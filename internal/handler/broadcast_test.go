@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
 )
@@ -198,3 +200,156 @@ func TestBroadcastHandler_Send_InvalidID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestBroadcastHandler_Schedule_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	body, _ := json.Marshal(dto.ScheduleBroadcastRequest{ScheduledAt: "2026-11-02T09:00:00Z"})
+
+	expected := &dto.BroadcastResponse{ID: broadcastID.String(), Status: "scheduled"}
+	mockSvc.On("Schedule", mock.Anything, testutil.TestTeamID, broadcastID, mock.AnythingOfType("*dto.ScheduleBroadcastRequest")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/broadcasts/"+broadcastID.String()+"/schedule", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	req = testutil.WithURLParam(req, "broadcastId", broadcastID.String())
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/broadcasts/{broadcastId}/schedule", h.Schedule) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBroadcastHandler_Schedule_MissingTime(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	req := httptest.NewRequest(http.MethodPost, "/broadcasts/"+broadcastID.String()+"/schedule", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	req = testutil.WithURLParam(req, "broadcastId", broadcastID.String())
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/broadcasts/{broadcastId}/schedule", h.Schedule) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertNotCalled(t, "Schedule")
+}
+
+func TestBroadcastHandler_Pause_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	expected := &dto.BroadcastResponse{ID: broadcastID.String(), Status: "paused"}
+	mockSvc.On("Pause", mock.Anything, testutil.TestTeamID, broadcastID).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/broadcasts/"+broadcastID.String()+"/pause", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	req = testutil.WithURLParam(req, "broadcastId", broadcastID.String())
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/broadcasts/{broadcastId}/pause", h.Pause) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBroadcastHandler_Cancel_AlreadySent(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	mockSvc.On("Cancel", mock.Anything, testutil.TestTeamID, broadcastID).Return(nil, fmt.Errorf("%w: a sent broadcast cannot be cancelled", pkg.ErrValidation))
+
+	req := httptest.NewRequest(http.MethodPost, "/broadcasts/"+broadcastID.String()+"/cancel", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	req = testutil.WithURLParam(req, "broadcastId", broadcastID.String())
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/broadcasts/{broadcastId}/cancel", h.Cancel) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBroadcastHandler_ListRecipients_WithFilters(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	expected := &dto.PaginatedResponse[dto.BroadcastRecipientResponse]{
+		Data: []dto.BroadcastRecipientResponse{{Email: "reader@example.com", Status: "sent"}},
+	}
+	mockSvc.On("ListRecipients", mock.Anything, testutil.TestTeamID, broadcastID, "sent", "clicked", "example.com", mock.AnythingOfType("*dto.PaginationParams")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/broadcasts/"+broadcastID.String()+"/recipients?status=sent&event=clicked&search=example.com", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/broadcasts/{broadcastId}/recipients", h.ListRecipients) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBroadcastHandler_ExportRecipients_Pages(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	sentAt, opened, openedAt := "2026-03-02T09:00:00Z", "opened", "2026-03-02T09:15:00Z"
+	page := func(n int, hasMore bool) *dto.PaginatedResponse[dto.BroadcastRecipientResponse] {
+		rc := dto.BroadcastRecipientResponse{Email: fmt.Sprintf("user%d@example.com", n), Status: "sent", SentAt: &sentAt}
+		if n == 1 {
+			rc.LastEvent, rc.LastEventAt = &opened, &openedAt
+		}
+		return &dto.PaginatedResponse[dto.BroadcastRecipientResponse]{Data: []dto.BroadcastRecipientResponse{rc}, Page: n, HasMore: hasMore}
+	}
+	mockSvc.On("ListRecipients", mock.Anything, testutil.TestTeamID, broadcastID, "", "", "", mock.MatchedBy(func(p *dto.PaginationParams) bool { return p.Page == 1 })).Return(page(1, true), nil)
+	mockSvc.On("ListRecipients", mock.Anything, testutil.TestTeamID, broadcastID, "", "", "", mock.MatchedBy(func(p *dto.PaginationParams) bool { return p.Page == 2 })).Return(page(2, false), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/broadcasts/"+broadcastID.String()+"/recipients/export", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/broadcasts/{broadcastId}/recipients/export", h.ExportRecipients) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Equal(t,
+		"email,status,variant_id,sent_at,last_event,last_event_at\n"+
+			"user1@example.com,sent,,2026-03-02T09:00:00Z,opened,2026-03-02T09:15:00Z\n"+
+			"user2@example.com,sent,,2026-03-02T09:00:00Z,,\n",
+		rec.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBroadcastHandler_ExportRecipients_InvalidEvent(t *testing.T) {
+	mockSvc := new(mockpkg.MockBroadcastService)
+	h := NewBroadcastHandler(mockSvc)
+
+	broadcastID := uuid.New()
+	mockSvc.On("ListRecipients", mock.Anything, testutil.TestTeamID, broadcastID, "", "read", "", mock.AnythingOfType("*dto.PaginationParams")).
+		Return(nil, fmt.Errorf("%w: invalid event", pkg.ErrValidation))
+
+	req := httptest.NewRequest(http.MethodGet, "/broadcasts/"+broadcastID.String()+"/recipients/export?event=read", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/broadcasts/{broadcastId}/recipients/export", h.ExportRecipients) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.NotEqual(t, "text/csv", rec.Header().Get("Content-Type"))
+}
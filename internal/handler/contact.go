@@ -166,6 +166,72 @@ func (h *ContactHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	pkg.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
 }
 
+// ListTopics handles GET /audiences/{audienceId}/contacts/{contactId}/topics.
+func (h *ContactHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	audienceID, err := uuid.Parse(chi.URLParam(r, "audienceId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid audience id")
+		return
+	}
+
+	contactID, err := uuid.Parse(chi.URLParam(r, "contactId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid contact id")
+		return
+	}
+
+	resp, err := h.service.ListTopics(r.Context(), auth.TeamID, audienceID, contactID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// UpdateTopics handles PATCH /audiences/{audienceId}/contacts/{contactId}/topics.
+func (h *ContactHandler) UpdateTopics(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	audienceID, err := uuid.Parse(chi.URLParam(r, "audienceId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid audience id")
+		return
+	}
+
+	contactID, err := uuid.Parse(chi.URLParam(r, "contactId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid contact id")
+		return
+	}
+
+	var req dto.UpdateContactTopicsRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.UpdateTopics(r.Context(), auth.TeamID, audienceID, contactID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
 // Export handles GET /audiences/{audienceId}/contacts/export.
 func (h *ContactHandler) Export(w http.ResponseWriter, r *http.Request) {
 	auth := middleware.GetAuth(r.Context())
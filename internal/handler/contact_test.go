@@ -192,3 +192,55 @@ func TestContactHandler_Delete_InvalidAudienceID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestContactHandler_UpdateTopics_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockContactService)
+	h := NewContactHandler(mockSvc)
+
+	audienceID := uuid.New()
+	contactID := uuid.New()
+	topicID := uuid.New()
+	reqBody := dto.UpdateContactTopicsRequest{
+		Topics: []dto.ContactTopicUpdate{{ID: topicID.String(), Subscription: "opt_out"}},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	expected := &dto.ListResponse[dto.ContactTopicResponse]{Data: []dto.ContactTopicResponse{
+		{ID: topicID.String(), Name: "Newsletter", Subscription: "opt_out", Explicit: true},
+	}}
+	mockSvc.On("UpdateTopics", mock.Anything, testutil.TestTeamID, audienceID, contactID, &reqBody).Return(expected, nil)
+
+	path := "/audiences/" + audienceID.String() + "/contacts/" + contactID.String() + "/topics"
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) {
+		r.Patch("/audiences/{audienceId}/contacts/{contactId}/topics", h.UpdateTopics)
+	})
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestContactHandler_UpdateTopics_ValidationError(t *testing.T) {
+	mockSvc := new(mockpkg.MockContactService)
+	h := NewContactHandler(mockSvc)
+
+	body := []byte(`{"topics":[{"id":"` + uuid.New().String() + `","subscription":"maybe"}]}`)
+	path := "/audiences/" + uuid.New().String() + "/contacts/" + uuid.New().String() + "/topics"
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) {
+		r.Patch("/audiences/{audienceId}/contacts/{contactId}/topics", h.UpdateTopics)
+	})
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertNotCalled(t, "UpdateTopics", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
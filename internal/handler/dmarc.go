@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/server/middleware"
+	"github.com/mailit-dev/mailit/internal/service"
+)
+
+// defaultDMARCRange is the time range of DMARC queries without from and to.
+const defaultDMARCRange = 30 * 24 * time.Hour
+
+type DMARCHandler struct {
+	service service.DMARCService
+}
+
+func NewDMARCHandler(s service.DMARCService) *DMARCHandler {
+	return &DMARCHandler{service: s}
+}
+
+// ListReports handles GET /domains/{domainId}/dmarc/reports?from=&to=.
+func (h *DMARCHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultDMARCRange)
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := parsePagination(r)
+	resp, err := h.service.ListReports(r.Context(), auth.TeamID, domainID, from, to, &params)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// GetStats handles GET /domains/{domainId}/dmarc/stats?from=&to=.
+func (h *DMARCHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultDMARCRange)
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.service.GetStats(r.Context(), auth.TeamID, domainID, from, to)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// parseTimeRange reads the from and to query parameters, each an RFC 3339
+// time or a date. to defaults to now and from to def before to.
+func parseTimeRange(r *http.Request, def time.Duration) (from, to time.Time, err error) {
+	to = time.Now().UTC()
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = parseTimeParam(v); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	from = to.Add(-def)
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = parseTimeParam(v); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	return from, to, nil
+}
+
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	return t.UTC(), nil
+}
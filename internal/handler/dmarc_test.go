@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func TestDMARCHandler_GetStats(t *testing.T) {
+	mockSvc := new(mockpkg.MockDMARCService)
+	h := NewDMARCHandler(mockSvc)
+	domainID := uuid.New()
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockSvc.On("GetStats", mock.Anything, testutil.TestTeamID, domainID, from, to).
+		Return(&dto.DMARCStatsResponse{From: from, To: to}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/domains/"+domainID.String()+"/dmarc/stats?from=2026-09-01&to=2026-10-01T12:00:00Z", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/domains/{domainId}/dmarc/stats", h.GetStats) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestDMARCHandler_ListReports_DefaultRange(t *testing.T) {
+	mockSvc := new(mockpkg.MockDMARCService)
+	h := NewDMARCHandler(mockSvc)
+	domainID := uuid.New()
+
+	mockSvc.On("ListReports", mock.Anything, testutil.TestTeamID, domainID,
+		mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), mock.AnythingOfType("*dto.PaginationParams")).
+		Run(func(args mock.Arguments) {
+			from, to := args.Get(3).(time.Time), args.Get(4).(time.Time)
+			assert.Equal(t, defaultDMARCRange, to.Sub(from))
+		}).
+		Return(&dto.PaginatedResponse[dto.DMARCReportResponse]{Data: []dto.DMARCReportResponse{}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/domains/"+domainID.String()+"/dmarc/reports", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/domains/{domainId}/dmarc/reports", h.ListReports) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestDMARCHandler_InvalidTimeRange(t *testing.T) {
+	h := NewDMARCHandler(new(mockpkg.MockDMARCService))
+
+	req := httptest.NewRequest(http.MethodGet, "/domains/"+uuid.New().String()+"/dmarc/stats?from=yesterday", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/domains/{domainId}/dmarc/stats", h.GetStats) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
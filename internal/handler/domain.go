@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -155,3 +157,59 @@ func (h *DomainHandler) Verify(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg.JSON(w, http.StatusOK, resp)
 }
+
+// RotateDKIM handles POST /domains/{domainId}/dkim/rotate.
+func (h *DomainHandler) RotateDKIM(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	var req dto.RotateDKIMRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.service.RotateDKIM(r.Context(), auth.TeamID, domainID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// ImportDKIM handles POST /domains/{domainId}/dkim/import.
+func (h *DomainHandler) ImportDKIM(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	var req dto.ImportDKIMRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.service.ImportDKIM(r.Context(), auth.TeamID, domainID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
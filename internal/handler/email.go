@@ -38,6 +38,11 @@ func (h *EmailHandler) Send(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.AllowsSender(req.From) {
+		pkg.Error(w, http.StatusForbidden, "API key can only send from "+auth.Domain)
+		return
+	}
+
 	if key := r.Header.Get("Idempotency-Key"); key != "" {
 		req.IdempotencyKey = &key
 	}
@@ -68,6 +73,13 @@ func (h *EmailHandler) BatchSend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range req.Emails {
+		if !auth.AllowsSender(req.Emails[i].From) {
+			pkg.Error(w, http.StatusForbidden, "API key can only send from "+auth.Domain)
+			return
+		}
+	}
+
 	resp, err := h.service.BatchSend(r.Context(), auth.TeamID, &req)
 	if err != nil {
 		pkg.HandleError(w, err)
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/server/middleware"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
 )
@@ -51,6 +53,29 @@ func TestEmailHandler_Send_Success(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestEmailHandler_Send_WithTemplate(t *testing.T) {
+	mockSvc := new(mockpkg.MockEmailService)
+	h := NewEmailHandler(mockSvc)
+
+	templateID := uuid.New().String()
+	body := []byte(`{"from":"sender@example.com","to":["recipient@example.com"],"template_id":"` + templateID + `","variables":{"order_id":"A-1","total":9.5}}`)
+
+	mockSvc.On("Send", mock.Anything, testutil.TestTeamID, mock.MatchedBy(func(r *dto.SendEmailRequest) bool {
+		return *r.TemplateID == templateID && r.Variables["order_id"] == "A-1" && r.Variables["total"] == 9.5
+	})).Return(&dto.SendEmailResponse{ID: uuid.New().String()}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/emails", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/emails", h.Send) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, "subject is optional when a template is given")
+	mockSvc.AssertExpectations(t)
+}
+
 func TestEmailHandler_Send_Unauthorized(t *testing.T) {
 	mockSvc := new(mockpkg.MockEmailService)
 	h := NewEmailHandler(mockSvc)
@@ -139,6 +164,30 @@ func TestEmailHandler_Send_WithIdempotencyKey(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestEmailHandler_Send_DomainRestrictedKey(t *testing.T) {
+	mockSvc := new(mockpkg.MockEmailService)
+	h := NewEmailHandler(mockSvc)
+
+	body := []byte(`{"from":"sender@other.example","to":["recipient@example.com"],"subject":"Hi","text":"Hello"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/emails", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.AuthContextKey, &middleware.AuthContext{
+		TeamID:     testutil.TestTeamID,
+		Permission: "sending",
+		AuthMethod: "api_key",
+		Domain:     "example.com",
+	}))
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/emails", h.Send) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "example.com")
+	mockSvc.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestEmailHandler_Get_Success(t *testing.T) {
 	mockSvc := new(mockpkg.MockEmailService)
 	h := NewEmailHandler(mockSvc)
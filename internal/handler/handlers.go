@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"log/slog"
+
 	"github.com/hibiken/asynq"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/service"
@@ -11,6 +13,7 @@ type Handlers struct {
 	Auth            *AuthHandler
 	Email           *EmailHandler
 	Domain          *DomainHandler
+	DMARC           *DMARCHandler
 	APIKey          *APIKeyHandler
 	Audience        *AudienceHandler
 	Contact         *ContactHandler
@@ -26,6 +29,8 @@ type Handlers struct {
 	Settings        *SettingsHandler
 	Tracking        *TrackingHandler
 	ContactImport   *ContactImportHandler
+	Suppression     *SuppressionHandler
+	Preference      *PreferenceHandler
 }
 
 func NewHandlers(
@@ -33,11 +38,13 @@ func NewHandlers(
 	importJobRepo postgres.ContactImportJobRepository,
 	audienceRepo postgres.AudienceRepository,
 	asynqClient *asynq.Client,
+	logger *slog.Logger,
 ) *Handlers {
 	return &Handlers{
 		Auth:            NewAuthHandler(svc.Auth),
 		Email:           NewEmailHandler(svc.Email),
 		Domain:          NewDomainHandler(svc.Domain),
+		DMARC:           NewDMARCHandler(svc.DMARC),
 		APIKey:          NewAPIKeyHandler(svc.APIKey),
 		Audience:        NewAudienceHandler(svc.Audience),
 		Contact:         NewContactHandler(svc.Contact),
@@ -53,5 +60,7 @@ func NewHandlers(
 		Settings:        NewSettingsHandler(svc.Settings),
 		Tracking:        NewTrackingHandler(svc.Tracking),
 		ContactImport:   NewContactImportHandler(importJobRepo, audienceRepo, asynqClient),
+		Suppression:     NewSuppressionHandler(svc.Suppression, logger),
+		Preference:      NewPreferenceHandler(svc.Preference),
 	}
 }
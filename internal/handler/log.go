@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/server/middleware"
 	"github.com/mailit-dev/mailit/internal/service"
@@ -16,7 +17,7 @@ func NewLogHandler(s service.LogService) *LogHandler {
 	return &LogHandler{service: s}
 }
 
-// List handles GET /logs.
+// List handles GET /logs?level=&method=&path=&status=&api_key_id=&request_id=.
 func (h *LogHandler) List(w http.ResponseWriter, r *http.Request) {
 	auth := middleware.GetAuth(r.Context())
 	if auth == nil {
@@ -24,10 +25,18 @@ func (h *LogHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	params := parsePagination(r)
-	level := r.URL.Query().Get("level")
+	q := r.URL.Query()
+	params := dto.LogListParams{
+		PaginationParams: parsePagination(r),
+		Level:            q.Get("level"),
+		Method:           q.Get("method"),
+		Path:             q.Get("path"),
+		Status:           q.Get("status"),
+		APIKeyID:         q.Get("api_key_id"),
+		RequestID:        q.Get("request_id"),
+	}
 
-	resp, err := h.service.List(r.Context(), auth.TeamID, level, &params)
+	resp, err := h.service.List(r.Context(), auth.TeamID, &params)
 	if err != nil {
 		pkg.HandleError(w, err)
 		return
@@ -34,7 +34,7 @@ func TestLogHandler_List_Success(t *testing.T) {
 		Page:    1,
 		PerPage: 20,
 	}
-	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", mock.AnythingOfType("*dto.PaginationParams")).Return(expected, nil)
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, mock.AnythingOfType("*dto.LogListParams")).Return(expected, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/logs", nil)
 	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
@@ -64,7 +64,9 @@ func TestLogHandler_List_WithLevelFilter(t *testing.T) {
 		Page:    1,
 		PerPage: 20,
 	}
-	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "error", mock.AnythingOfType("*dto.PaginationParams")).Return(expected, nil)
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, mock.MatchedBy(func(p *dto.LogListParams) bool {
+		return p.Level == "error"
+	})).Return(expected, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/logs?level=error", nil)
 	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
@@ -77,6 +79,28 @@ func TestLogHandler_List_WithLevelFilter(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestLogHandler_List_WithRequestFilters(t *testing.T) {
+	mockSvc := new(mockpkg.MockLogService)
+	h := NewLogHandler(mockSvc)
+
+	expected := &dto.PaginatedResponse[model.Log]{Data: []model.Log{}, Page: 1, PerPage: 20}
+	apiKeyID := uuid.New().String()
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, mock.MatchedBy(func(p *dto.LogListParams) bool {
+		return p.Method == "POST" && p.Path == "/emails" && p.Status == "4xx" &&
+			p.APIKeyID == apiKeyID && p.RequestID == "req-123"
+	})).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/logs?method=POST&path=/emails&status=4xx&api_key_id="+apiKeyID+"&request_id=req-123", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/logs", h.List) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
 func TestLogHandler_List_Unauthorized(t *testing.T) {
 	mockSvc := new(mockpkg.MockLogService)
 	h := NewLogHandler(mockSvc)
@@ -94,7 +118,7 @@ func TestLogHandler_List_ServiceError(t *testing.T) {
 	mockSvc := new(mockpkg.MockLogService)
 	h := NewLogHandler(mockSvc)
 
-	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", mock.AnythingOfType("*dto.PaginationParams")).Return(nil, errors.New("db error"))
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, mock.AnythingOfType("*dto.LogListParams")).Return(nil, errors.New("db error"))
 
 	req := httptest.NewRequest(http.MethodGet, "/logs", nil)
 	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/service"
+)
+
+var preferencePage = template.Must(template.New("preferences").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Email preferences</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:60px auto;padding:0 16px">
+<h1>Email preferences</h1>
+<p>Manage the emails sent to <strong>{{.Prefs.Email}}</strong>.</p>
+{{if .Saved}}<p style="color:#15803d">Your preferences have been saved.</p>{{end}}
+<form method="post" action="?token={{.Token}}">
+{{range .Prefs.Topics}}<p><label><input type="checkbox" name="topic" value="{{.ID}}"{{if .Subscribed}} checked{{end}}> <strong>{{.Name}}</strong></label>{{with .Description}}<br><small>{{.}}</small>{{end}}</p>
+{{end}}<p><label><input type="checkbox" name="unsubscribe_all" value="true"{{if .Prefs.Unsubscribed}} checked{{end}}> Unsubscribe from all emails</label></p>
+<p><button type="submit">Save preferences</button></p>
+</form>
+</body></html>`))
+
+type PreferenceHandler struct {
+	svc service.PreferenceService
+}
+
+func NewPreferenceHandler(svc service.PreferenceService) *PreferenceHandler {
+	return &PreferenceHandler{svc: svc}
+}
+
+// Show handles GET /preferences?token={token} — renders the hosted
+// preference page for the recipient identified by the signed token.
+func (h *PreferenceHandler) Show(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	prefs, err := h.svc.Get(r.Context(), token)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.render(w, token, prefs, false)
+}
+
+// Update handles POST /preferences?token={token} — saves the topics selected
+// on the preference page.
+func (h *PreferenceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	if err := r.ParseForm(); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+
+	req := &dto.UpdatePreferencesRequest{
+		Topics:         r.PostForm["topic"],
+		UnsubscribeAll: r.PostForm.Get("unsubscribe_all") == "true",
+	}
+
+	prefs, err := h.svc.Update(r.Context(), token, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.render(w, token, prefs, true)
+}
+
+// Unsubscribe handles POST /preferences/unsubscribe?token={token} — the
+// RFC 8058 one-click target of the List-Unsubscribe header. Mail providers
+// post "List-Unsubscribe=One-Click"; the body is not required.
+func (h *PreferenceHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Unsubscribe(r.Context(), r.URL.Query().Get("token")); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`<!DOCTYPE html>
+<html><head><title>Unsubscribed</title></head>
+<body style="font-family:sans-serif;text-align:center;padding:60px">
+<h1>You have been unsubscribed</h1>
+<p>You will no longer receive emails from this sender.</p>
+</body></html>`))
+}
+
+func (h *PreferenceHandler) render(w http.ResponseWriter, token string, prefs *dto.PreferencesResponse, saved bool) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_ = preferencePage.Execute(w, map[string]interface{}{
+		"Token": token,
+		"Prefs": prefs,
+		"Saved": saved,
+	})
+}
+
+func (h *PreferenceHandler) handleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, pkg.ErrInvalidToken) {
+		pkg.Error(w, http.StatusBadRequest, "invalid or expired link")
+		return
+	}
+	pkg.HandleError(w, err)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func TestPreferenceHandler_Show(t *testing.T) {
+	mockSvc := new(mockpkg.MockPreferenceService)
+	h := NewPreferenceHandler(mockSvc)
+
+	prefs := &dto.PreferencesResponse{
+		Email: "john@example.com",
+		Topics: []dto.TopicPreference{
+			{ID: "topic-1", Name: "Newsletter", Subscribed: true},
+			{ID: "topic-2", Name: "Product <updates>"},
+		},
+	}
+	mockSvc.On("Get", mock.Anything, "tok").Return(prefs, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/preferences?token=tok", nil)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/preferences", h.Show) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	body := rec.Body.String()
+	assert.Contains(t, body, "john@example.com")
+	assert.Contains(t, body, `value="topic-1" checked`)
+	assert.Contains(t, body, "Product &lt;updates&gt;")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestPreferenceHandler_Show_InvalidToken(t *testing.T) {
+	mockSvc := new(mockpkg.MockPreferenceService)
+	h := NewPreferenceHandler(mockSvc)
+
+	mockSvc.On("Get", mock.Anything, "forged").Return(nil, pkg.ErrInvalidToken)
+
+	req := httptest.NewRequest(http.MethodGet, "/preferences?token=forged", nil)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/preferences", h.Show) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPreferenceHandler_Update(t *testing.T) {
+	mockSvc := new(mockpkg.MockPreferenceService)
+	h := NewPreferenceHandler(mockSvc)
+
+	mockSvc.On("Update", mock.Anything, "tok", &dto.UpdatePreferencesRequest{Topics: []string{"topic-1"}}).
+		Return(&dto.PreferencesResponse{Email: "john@example.com"}, nil)
+
+	form := url.Values{"topic": {"topic-1"}}
+	req := httptest.NewRequest(http.MethodPost, "/preferences?token=tok", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/preferences", h.Update) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Your preferences have been saved")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestPreferenceHandler_Unsubscribe_OneClick(t *testing.T) {
+	mockSvc := new(mockpkg.MockPreferenceService)
+	h := NewPreferenceHandler(mockSvc)
+
+	mockSvc.On("Unsubscribe", mock.Anything, "tok").Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/preferences/unsubscribe?token=tok", strings.NewReader("List-Unsubscribe=One-Click"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/preferences/unsubscribe", h.Unsubscribe) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestPreferenceHandler_Unsubscribe_Error(t *testing.T) {
+	mockSvc := new(mockpkg.MockPreferenceService)
+	h := NewPreferenceHandler(mockSvc)
+
+	mockSvc.On("Unsubscribe", mock.Anything, "tok").Return(fmt.Errorf("listing contacts: %w", assert.AnError))
+
+	req := httptest.NewRequest(http.MethodPost, "/preferences/unsubscribe?token=tok", nil)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/preferences/unsubscribe", h.Unsubscribe) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -133,3 +133,31 @@ func (h *SegmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
 }
+
+// Refresh handles POST /audiences/{audienceId}/segments/{segmentId}/refresh.
+func (h *SegmentHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	audienceID, err := uuid.Parse(chi.URLParam(r, "audienceId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid audience id")
+		return
+	}
+
+	segmentID, err := uuid.Parse(chi.URLParam(r, "segmentId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid segment id")
+		return
+	}
+
+	resp, err := h.service.Refresh(r.Context(), auth.TeamID, audienceID, segmentID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
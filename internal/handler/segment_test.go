@@ -155,3 +155,28 @@ func TestSegmentHandler_Delete_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	mockSvc.AssertExpectations(t)
 }
+
+func TestSegmentHandler_Refresh_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockSegmentService)
+	h := NewSegmentHandler(mockSvc)
+
+	audienceID := uuid.New()
+	segmentID := uuid.New()
+	expected := &dto.SegmentRefreshResponse{ID: segmentID.String(), Contacts: 7}
+	mockSvc.On("Refresh", mock.Anything, testutil.TestTeamID, audienceID, segmentID).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/audiences/"+audienceID.String()+"/segments/"+segmentID.String()+"/refresh", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	req = testutil.WithURLParams(req, map[string]string{"audienceId": audienceID.String(), "segmentId": segmentID.String()})
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/audiences/{audienceId}/segments/{segmentId}/refresh", h.Refresh) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp dto.SegmentRefreshResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, 7, resp.Contacts)
+	mockSvc.AssertExpectations(t)
+}
@@ -3,7 +3,11 @@ package handler
 import (
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
 	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/server/middleware"
 	"github.com/mailit-dev/mailit/internal/service"
@@ -112,6 +116,142 @@ func (h *SettingsHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
 	pkg.JSON(w, http.StatusCreated, invitation)
 }
 
+// ListMembers handles GET /settings/team/members.
+func (h *SettingsHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	members, err := h.service.ListMembers(r.Context(), auth.TeamID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, &dto.ListResponse[dto.TeamMemberResponse]{Data: members})
+}
+
+// UpdateMemberRole handles PATCH /settings/team/members/{memberId}.
+func (h *SettingsHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid member id")
+		return
+	}
+
+	var req dto.UpdateMemberRoleRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	member, err := h.service.UpdateMemberRole(r.Context(), auth.TeamID, memberID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, member)
+}
+
+// RemoveMember handles DELETE /settings/team/members/{memberId}.
+func (h *SettingsHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid member id")
+		return
+	}
+
+	if err := h.service.RemoveMember(r.Context(), auth.TeamID, memberID); err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// TransferOwnership handles POST /settings/team/transfer-ownership. Only a
+// signed-in owner can transfer ownership, so API keys are rejected.
+func (h *SettingsHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if auth.UserID == nil {
+		pkg.Error(w, http.StatusForbidden, "ownership can only be transferred by the team owner")
+		return
+	}
+
+	var req dto.TransferOwnershipRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := h.service.TransferOwnership(r.Context(), auth.TeamID, *auth.UserID, &req); err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, map[string]string{"message": "ownership transferred"})
+}
+
+// ListInvitations handles GET /settings/team/invitations.
+func (h *SettingsHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invitations, err := h.service.ListInvitations(r.Context(), auth.TeamID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, &dto.ListResponse[model.TeamInvitation]{Data: invitations})
+}
+
+// RevokeInvitation handles DELETE /settings/team/invitations/{invitationId}.
+func (h *SettingsHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid invitation id")
+		return
+	}
+
+	if err := h.service.RevokeInvitation(r.Context(), auth.TeamID, invitationID); err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
 // AcceptInvite handles POST /auth/accept-invite.
 func (h *SettingsHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
 	var req dto.AcceptInviteRequest
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func TestSettingsHandler_UpdateMemberRole_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockSettingsService)
+	h := NewSettingsHandler(mockSvc)
+
+	memberID := uuid.New()
+	body, _ := json.Marshal(dto.UpdateMemberRoleRequest{Role: model.RoleAdmin})
+
+	expected := &model.TeamMember{ID: memberID, TeamID: testutil.TestTeamID, Role: model.RoleAdmin}
+	mockSvc.On("UpdateMemberRole", mock.Anything, testutil.TestTeamID, memberID, mock.AnythingOfType("*dto.UpdateMemberRoleRequest")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/settings/team/members/"+memberID.String(), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Patch("/settings/team/members/{memberId}", h.UpdateMemberRole) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSettingsHandler_UpdateMemberRole_CannotMakeOwner(t *testing.T) {
+	mockSvc := new(mockpkg.MockSettingsService)
+	h := NewSettingsHandler(mockSvc)
+
+	body, _ := json.Marshal(dto.UpdateMemberRoleRequest{Role: model.RoleOwner})
+
+	req := httptest.NewRequest(http.MethodPatch, "/settings/team/members/"+uuid.New().String(), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Patch("/settings/team/members/{memberId}", h.UpdateMemberRole) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertNotCalled(t, "UpdateMemberRole")
+}
+
+func TestSettingsHandler_RemoveMember_Owner(t *testing.T) {
+	mockSvc := new(mockpkg.MockSettingsService)
+	h := NewSettingsHandler(mockSvc)
+
+	memberID := uuid.New()
+	mockSvc.On("RemoveMember", mock.Anything, testutil.TestTeamID, memberID).
+		Return(fmt.Errorf("%w: the team owner cannot be removed", pkg.ErrForbidden))
+
+	req := httptest.NewRequest(http.MethodDelete, "/settings/team/members/"+memberID.String(), nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Delete("/settings/team/members/{memberId}", h.RemoveMember) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSettingsHandler_TransferOwnership_APIKey(t *testing.T) {
+	mockSvc := new(mockpkg.MockSettingsService)
+	h := NewSettingsHandler(mockSvc)
+
+	body, _ := json.Marshal(dto.TransferOwnershipRequest{MemberID: uuid.New().String()})
+
+	req := httptest.NewRequest(http.MethodPost, "/settings/team/transfer-ownership", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.APIKeyRequest(req, testutil.TestTeamID, "full")
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/settings/team/transfer-ownership", h.TransferOwnership) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockSvc.AssertNotCalled(t, "TransferOwnership")
+}
+
+func TestSettingsHandler_RevokeInvitation_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockSettingsService)
+	h := NewSettingsHandler(mockSvc)
+
+	invitationID := uuid.New()
+	mockSvc.On("RevokeInvitation", mock.Anything, testutil.TestTeamID, invitationID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/settings/team/invitations/"+invitationID.String(), nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Delete("/settings/team/invitations/{invitationId}", h.RevokeInvitation) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/server/middleware"
+	"github.com/mailit-dev/mailit/internal/service"
+)
+
+type SuppressionHandler struct {
+	service service.SuppressionService
+	logger  *slog.Logger
+}
+
+func NewSuppressionHandler(s service.SuppressionService, logger *slog.Logger) *SuppressionHandler {
+	return &SuppressionHandler{service: s, logger: logger}
+}
+
+// List handles GET /suppressions.
+func (h *SuppressionHandler) List(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	params := parsePagination(r)
+	search := r.URL.Query().Get("search")
+	reason := r.URL.Query().Get("reason")
+
+	resp, err := h.service.List(r.Context(), auth.TeamID, search, reason, &params)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Create handles POST /suppressions.
+func (h *SuppressionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req dto.CreateSuppressionRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.Create(r.Context(), auth.TeamID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusCreated, resp)
+}
+
+// Import handles POST /suppressions/import.
+func (h *SuppressionHandler) Import(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Parse multipart form (max 10MB).
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid multipart form")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "missing 'file' field")
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	resp, err := h.service.Import(r.Context(), auth.TeamID, file)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Export handles GET /suppressions/export.
+func (h *SuppressionHandler) Export(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	search := r.URL.Query().Get("search")
+	reason := r.URL.Query().Get("reason")
+
+	// Fetch the first page before writing headers so filter errors can
+	// still be reported as JSON.
+	const pageSize = 100
+	params := &dto.PaginationParams{Page: 1, PerPage: pageSize}
+	resp, err := h.service.List(r.Context(), auth.TeamID, search, reason, params)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="suppressions.csv"`)
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"email", "reason", "details", "created_at"})
+
+	for {
+		for _, e := range resp.Data {
+			details := ""
+			if e.Details != nil {
+				details = *e.Details
+			}
+			_ = writer.Write([]string{e.Email, e.Reason, details, e.CreatedAt})
+		}
+
+		if !resp.HasMore {
+			break
+		}
+		params = &dto.PaginationParams{Page: resp.Page + 1, PerPage: pageSize}
+		resp, err = h.service.List(r.Context(), auth.TeamID, search, reason, params)
+		if err != nil {
+			// Headers are already written, so the export just stops here.
+			h.logger.Error("suppression export stopped early", "team_id", auth.TeamID, "page", params.Page, "error", err)
+			break
+		}
+	}
+
+	writer.Flush()
+}
+
+// Delete handles DELETE /suppressions/{suppressionId}.
+func (h *SuppressionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	suppressionID, err := uuid.Parse(chi.URLParam(r, "suppressionId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid suppression id")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), auth.TeamID, suppressionID); err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	mockpkg "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func TestSuppressionHandler_List_WithFilters(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	expected := &dto.PaginatedResponse[dto.SuppressionResponse]{
+		Data:    []dto.SuppressionResponse{{ID: uuid.New().String(), Email: "bounced@example.com", Reason: "bounce"}},
+		Total:   1,
+		Page:    1,
+		PerPage: 20,
+	}
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "example.com", "bounce", mock.AnythingOfType("*dto.PaginationParams")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/suppressions?search=example.com&reason=bounce", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/suppressions", h.List) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSuppressionHandler_Create_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	body, _ := json.Marshal(dto.CreateSuppressionRequest{Email: "blocked@example.com"})
+	expected := &dto.SuppressionResponse{ID: uuid.New().String(), Email: "blocked@example.com", Reason: "manual"}
+	mockSvc.On("Create", mock.Anything, testutil.TestTeamID, mock.AnythingOfType("*dto.CreateSuppressionRequest")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/suppressions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/suppressions", h.Create) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSuppressionHandler_Create_ValidationError(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	body, _ := json.Marshal(dto.CreateSuppressionRequest{Email: "not-an-email"})
+
+	req := httptest.NewRequest(http.MethodPost, "/suppressions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/suppressions", h.Create) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSuppressionHandler_Import_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", "suppressions.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("email\nblocked@example.com\n"))
+	require.NoError(t, mw.Close())
+
+	expected := &dto.SuppressionImportResponse{Total: 1, Imported: 1}
+	mockSvc.On("Import", mock.Anything, testutil.TestTeamID, mock.Anything).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/suppressions/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/suppressions/import", h.Import) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSuppressionHandler_Import_MissingFile(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	req := httptest.NewRequest(http.MethodPost, "/suppressions/import", strings.NewReader("email\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/suppressions/import", h.Import) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSuppressionHandler_Export_Pages(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	page := func(n int, hasMore bool) *dto.PaginatedResponse[dto.SuppressionResponse] {
+		return &dto.PaginatedResponse[dto.SuppressionResponse]{
+			Data:    []dto.SuppressionResponse{{Email: fmt.Sprintf("user%d@example.com", n), Reason: "manual", CreatedAt: "2025-01-15T10:30:00Z"}},
+			Page:    n,
+			HasMore: hasMore,
+		}
+	}
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", "", mock.MatchedBy(func(p *dto.PaginationParams) bool { return p.Page == 1 })).Return(page(1, true), nil)
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", "", mock.MatchedBy(func(p *dto.PaginationParams) bool { return p.Page == 2 })).Return(page(2, false), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/suppressions/export", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/suppressions/export", h.Export) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Equal(t,
+		"email,reason,details,created_at\nuser1@example.com,manual,,2025-01-15T10:30:00Z\nuser2@example.com,manual,,2025-01-15T10:30:00Z\n",
+		rec.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSuppressionHandler_Export_FailsMidStream(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	var logs bytes.Buffer
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	first := &dto.PaginatedResponse[dto.SuppressionResponse]{
+		Data:    []dto.SuppressionResponse{{Email: "user1@example.com", Reason: "manual", CreatedAt: "2025-01-15T10:30:00Z"}},
+		Page:    1,
+		HasMore: true,
+	}
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", "", mock.MatchedBy(func(p *dto.PaginationParams) bool { return p.Page == 1 })).Return(first, nil)
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", "", mock.MatchedBy(func(p *dto.PaginationParams) bool { return p.Page == 2 })).Return(nil, assert.AnError)
+
+	req := httptest.NewRequest(http.MethodGet, "/suppressions/export", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/suppressions/export", h.Export) })
+	r.ServeHTTP(rec, req)
+
+	// The rows written before the failure are kept, and the failure logged.
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "email,reason,details,created_at\nuser1@example.com,manual,,2025-01-15T10:30:00Z\n", rec.Body.String())
+	assert.Contains(t, logs.String(), "suppression export stopped early")
+}
+
+func TestSuppressionHandler_Export_InvalidReason(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	mockSvc.On("List", mock.Anything, testutil.TestTeamID, "", "spam", mock.AnythingOfType("*dto.PaginationParams")).
+		Return(nil, fmt.Errorf("%w: invalid reason", pkg.ErrValidation))
+
+	req := httptest.NewRequest(http.MethodGet, "/suppressions/export?reason=spam", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/suppressions/export", h.Export) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestSuppressionHandler_Delete_NotFound(t *testing.T) {
+	mockSvc := new(mockpkg.MockSuppressionService)
+	h := NewSuppressionHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	suppressionID := uuid.New()
+	mockSvc.On("Delete", mock.Anything, testutil.TestTeamID, suppressionID).Return(fmt.Errorf("suppression not found: %w", postgres.ErrNotFound))
+
+	req := httptest.NewRequest(http.MethodDelete, "/suppressions/"+suppressionID.String(), nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	req = testutil.WithURLParam(req, "suppressionId", suppressionID.String())
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Delete("/suppressions/{suppressionId}", h.Delete) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
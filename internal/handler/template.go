@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -155,3 +156,151 @@ func (h *TemplateHandler) Publish(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg.JSON(w, http.StatusOK, resp)
 }
+
+// ListVersions handles GET /templates/{templateId}/versions.
+func (h *TemplateHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid template id")
+		return
+	}
+
+	resp, err := h.service.ListVersions(r.Context(), auth.TeamID, templateID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// PublishVersion handles POST /templates/{templateId}/versions/{version}/publish.
+func (h *TemplateHandler) PublishVersion(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid template id")
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		pkg.Error(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+
+	resp, err := h.service.PublishVersion(r.Context(), auth.TeamID, templateID, version)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Diff handles GET /templates/{templateId}/versions/diff?from=1&to=2.
+func (h *TemplateHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid template id")
+		return
+	}
+
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil {
+		pkg.Error(w, http.StatusBadRequest, "from and to must be version numbers")
+		return
+	}
+
+	resp, err := h.service.Diff(r.Context(), auth.TeamID, templateID, from, to)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// Preview handles POST /templates/{templateId}/preview.
+func (h *TemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid template id")
+		return
+	}
+
+	var req dto.PreviewTemplateRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.Preview(r.Context(), auth.TeamID, templateID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// SendTest handles POST /templates/{templateId}/test.
+func (h *TemplateHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid template id")
+		return
+	}
+
+	var req dto.TestTemplateRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if !auth.AllowsSender(req.From) {
+		pkg.Error(w, http.StatusForbidden, "API key can only send from "+auth.Domain)
+		return
+	}
+
+	resp, err := h.service.SendTest(r.Context(), auth.TeamID, templateID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusAccepted, resp)
+}
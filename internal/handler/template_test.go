@@ -184,3 +184,85 @@ func TestTemplateHandler_Publish_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	mockSvc.AssertExpectations(t)
 }
+
+func TestTemplateHandler_Preview_Success(t *testing.T) {
+	mockSvc := new(mockpkg.MockTemplateService)
+	h := NewTemplateHandler(mockSvc)
+
+	templateID := uuid.New()
+	subject := "Hello Ada"
+	expected := &dto.PreviewTemplateResponse{Version: 2, Subject: &subject}
+	mockSvc.On("Preview", mock.Anything, testutil.TestTeamID, templateID, mock.AnythingOfType("*dto.PreviewTemplateRequest")).Return(expected, nil)
+
+	body := []byte(`{"version": 2, "variables": {"name": "Ada"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/templates/"+templateID.String()+"/preview", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/templates/{templateId}/preview", h.Preview) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"subject":"Hello Ada"`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestTemplateHandler_SendTest_ValidationError(t *testing.T) {
+	mockSvc := new(mockpkg.MockTemplateService)
+	h := NewTemplateHandler(mockSvc)
+
+	templateID := uuid.New()
+	body := []byte(`{"from": "sender@example.com", "to": "not-an-email"}`)
+	req := httptest.NewRequest(http.MethodPost, "/templates/"+templateID.String()+"/test", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/templates/{templateId}/test", h.SendTest) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertNotCalled(t, "SendTest")
+}
+
+func TestTemplateHandler_Diff(t *testing.T) {
+	mockSvc := new(mockpkg.MockTemplateService)
+	h := NewTemplateHandler(mockSvc)
+
+	templateID := uuid.New()
+	mockSvc.On("Diff", mock.Anything, testutil.TestTeamID, templateID, 1, 3).Return(&dto.TemplateDiffResponse{From: 1, To: 3}, nil)
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/templates/{templateId}/versions/diff", h.Diff) })
+
+	req := httptest.NewRequest(http.MethodGet, "/templates/"+templateID.String()+"/versions/diff?from=1&to=3", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/templates/"+templateID.String()+"/versions/diff?from=1", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestTemplateHandler_PublishVersion(t *testing.T) {
+	mockSvc := new(mockpkg.MockTemplateService)
+	h := NewTemplateHandler(mockSvc)
+
+	templateID := uuid.New()
+	mockSvc.On("PublishVersion", mock.Anything, testutil.TestTeamID, templateID, 2).Return(&dto.TemplateResponse{ID: templateID.String()}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/templates/"+templateID.String()+"/versions/2/publish", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/templates/{templateId}/versions/{version}/publish", h.PublishVersion) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
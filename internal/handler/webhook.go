@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -131,3 +133,150 @@ func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
 }
+
+// Enable handles POST /webhooks/{webhookId}/enable. The request body is
+// optional.
+func (h *WebhookHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+
+	var req dto.EnableWebhookRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.service.Enable(r.Context(), auth.TeamID, webhookID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// ListEvents handles GET /webhooks/{webhookId}/events.
+func (h *WebhookHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+
+	params := parsePagination(r)
+	status := r.URL.Query().Get("status")
+	eventType := r.URL.Query().Get("event_type")
+
+	resp, err := h.service.ListEvents(r.Context(), auth.TeamID, webhookID, status, eventType, &params)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
+
+// ReplayEvent handles POST /webhooks/{webhookId}/events/{eventId}/replay.
+func (h *WebhookHandler) ReplayEvent(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid event id")
+		return
+	}
+
+	resp, err := h.service.ReplayEvent(r.Context(), auth.TeamID, webhookID, eventID)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusAccepted, resp)
+}
+
+// ReplayEvents handles POST /webhooks/{webhookId}/events/replay.
+func (h *WebhookHandler) ReplayEvents(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+
+	var req dto.ReplayWebhookEventsRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.ReplayEvents(r.Context(), auth.TeamID, webhookID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusAccepted, resp)
+}
+
+// Test handles POST /webhooks/{webhookId}/test.
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	auth := middleware.GetAuth(r.Context())
+	if auth == nil {
+		pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+
+	var req dto.TestWebhookRequest
+	if err := pkg.DecodeJSON(r, &req); err != nil {
+		pkg.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pkg.Validate(&req); err != nil {
+		pkg.Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp, err := h.service.Test(r.Context(), auth.TeamID, webhookID, &req)
+	if err != nil {
+		pkg.HandleError(w, err)
+		return
+	}
+	pkg.JSON(w, http.StatusOK, resp)
+}
@@ -171,3 +171,113 @@ func TestWebhookHandler_Delete_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	mockSvc.AssertExpectations(t)
 }
+
+func TestWebhookHandler_ListEvents_Filters(t *testing.T) {
+	mockSvc := new(mockpkg.MockWebhookService)
+	h := NewWebhookHandler(mockSvc)
+
+	webhookID := uuid.New()
+	expected := &dto.PaginatedResponse[dto.WebhookEventResponse]{Data: []dto.WebhookEventResponse{{ID: uuid.New().String(), Status: "failed"}}, Total: 1}
+	mockSvc.On("ListEvents", mock.Anything, testutil.TestTeamID, webhookID, "failed", "email.sent", mock.AnythingOfType("*dto.PaginationParams")).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/"+webhookID.String()+"/events?status=failed&event_type=email.sent", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Get("/webhooks/{webhookId}/events", h.ListEvents) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestWebhookHandler_ReplayEvent(t *testing.T) {
+	mockSvc := new(mockpkg.MockWebhookService)
+	h := NewWebhookHandler(mockSvc)
+
+	webhookID, eventID := uuid.New(), uuid.New()
+	mockSvc.On("ReplayEvent", mock.Anything, testutil.TestTeamID, webhookID, eventID).Return(&dto.WebhookEventResponse{ID: eventID.String(), Status: "pending"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID.String()+"/events/"+eventID.String()+"/replay", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/webhooks/{webhookId}/events/{eventId}/replay", h.ReplayEvent) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestWebhookHandler_ReplayEvents_ValidationError(t *testing.T) {
+	mockSvc := new(mockpkg.MockWebhookService)
+	h := NewWebhookHandler(mockSvc)
+
+	webhookID := uuid.New()
+	body := []byte(`{"since": "2026-01-01T00:00:00Z", "status": "pending"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID.String()+"/events/replay", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/webhooks/{webhookId}/events/replay", h.ReplayEvents) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockSvc.AssertNotCalled(t, "ReplayEvents")
+}
+
+func TestWebhookHandler_Test(t *testing.T) {
+	mockSvc := new(mockpkg.MockWebhookService)
+	h := NewWebhookHandler(mockSvc)
+
+	webhookID := uuid.New()
+	code := 200
+	mockSvc.On("Test", mock.Anything, testutil.TestTeamID, webhookID, &dto.TestWebhookRequest{EventType: "email.opened"}).
+		Return(&dto.WebhookEventResponse{Status: "delivered", ResponseCode: &code}, nil)
+
+	body := []byte(`{"event_type": "email.opened"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID.String()+"/test", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/webhooks/{webhookId}/test", h.Test) })
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"delivered"`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestWebhookHandler_Enable(t *testing.T) {
+	mockSvc := new(mockpkg.MockWebhookService)
+	h := NewWebhookHandler(mockSvc)
+
+	webhookID := uuid.New()
+	mockSvc.On("Enable", mock.Anything, testutil.TestTeamID, webhookID, &dto.EnableWebhookRequest{Backfill: true}).
+		Return(&dto.EnableWebhookResponse{Webhook: dto.WebhookResponse{ID: webhookID.String(), Active: true}, Backfilling: 4}, nil)
+	mockSvc.On("Enable", mock.Anything, testutil.TestTeamID, webhookID, &dto.EnableWebhookRequest{}).
+		Return(&dto.EnableWebhookResponse{Webhook: dto.WebhookResponse{ID: webhookID.String(), Active: true}}, nil)
+
+	r := testutil.SetupRouter(func(r chi.Router) { r.Post("/webhooks/{webhookId}/enable", h.Enable) })
+
+	body := []byte(`{"backfill": true}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID.String()+"/enable", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"backfilling":4`)
+
+	// The body is optional.
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID.String()+"/enable", nil)
+	req = testutil.AuthenticatedRequest(req, testutil.TestTeamID, testutil.TestUserID)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
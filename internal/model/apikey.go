@@ -1,6 +1,8 @@
 package model
 
 import (
+	"net"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,12 +15,143 @@ type APIKey struct {
 	KeyHash    string     `json:"-" db:"key_hash"`
 	KeyPrefix  string     `json:"key_prefix" db:"key_prefix"`
 	Permission string     `json:"permission" db:"permission"`
+	Scopes     []string   `json:"scopes,omitempty" db:"scopes"` // only for PermissionCustom
 	DomainID   *uuid.UUID `json:"domain_id,omitempty" db:"domain_id"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty" db:"allowed_ips"` // IPs or CIDR ranges; empty allows any
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 }
 
 const (
-	PermissionFull    = "full"
-	PermissionSending = "sending"
+	PermissionFull     = "full"
+	PermissionSending  = "sending"
+	PermissionReadOnly = "read_only"
+	PermissionCustom   = "custom"
 )
+
+// Scopes have the form "<resource>:<access>", where access is read or write
+// and write implies read. A "*" resource matches every resource. Sending
+// email is its own scope, emails:send, granted only explicitly or by full
+// access.
+const (
+	ScopeAll        = "*"
+	ScopeEmailsSend = "emails:send"
+
+	ScopeAccessRead  = "read"
+	ScopeAccessWrite = "write"
+)
+
+// ScopeResources lists the resources scopes can name.
+var ScopeResources = []string{
+	"emails", "domains", "api_keys", "contacts", "templates", "broadcasts",
+	"webhooks", "suppressions", "inbound", "logs", "metrics", "settings",
+}
+
+// GrantedScopes returns the scopes the key's permission grants.
+func (k *APIKey) GrantedScopes() []string {
+	return PermissionScopes(k.Permission, k.Scopes)
+}
+
+// ResolvePermission returns the permission a key is created with: the given
+// one, or custom when only scopes are given, or full when neither is.
+func ResolvePermission(permission string, scopes []string) string {
+	switch {
+	case permission != "":
+		return permission
+	case len(scopes) > 0:
+		return PermissionCustom
+	default:
+		return PermissionFull
+	}
+}
+
+// PermissionScopes returns the scopes granted by a permission. A custom
+// permission grants exactly the given scopes.
+func PermissionScopes(permission string, scopes []string) []string {
+	switch permission {
+	case PermissionFull:
+		return []string{ScopeAll}
+	case PermissionSending:
+		return []string{ScopeEmailsSend}
+	case PermissionReadOnly:
+		return []string{"*:" + ScopeAccessRead}
+	case PermissionCustom:
+		return scopes
+	}
+	return nil
+}
+
+// ValidScope reports whether s is a scope a custom key can be given.
+func ValidScope(s string) bool {
+	if s == ScopeEmailsSend {
+		return true
+	}
+	resource, access, ok := strings.Cut(s, ":")
+	if !ok || (access != ScopeAccessRead && access != ScopeAccessWrite) {
+		return false
+	}
+	if resource == ScopeAll {
+		return true
+	}
+	for _, r := range ScopeResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeAllows reports whether the granted scopes include required, which
+// may itself use a "*" resource.
+func ScopeAllows(granted []string, required string) bool {
+	for _, g := range granted {
+		if g == ScopeAll || g == required {
+			return true
+		}
+		if required == ScopeAll {
+			continue
+		}
+		gResource, gAccess, _ := strings.Cut(g, ":")
+		rResource, rAccess, _ := strings.Cut(required, ":")
+		if gResource != ScopeAll && gResource != rResource {
+			continue
+		}
+		if gAccess == rAccess || (gAccess == ScopeAccessWrite && rAccess == ScopeAccessRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidIPRange reports whether s is an IP address or CIDR range.
+func ValidIPRange(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
+// IPAllowed reports whether ip matches one of the allowlist's addresses or
+// CIDR ranges. An empty allowlist allows every address.
+func IPAllowed(allowlist []string, ip net.IP) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if allowed := net.ParseIP(entry); allowed != nil {
+			if allowed.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
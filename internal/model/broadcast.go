@@ -13,6 +13,7 @@ type Broadcast struct {
 	AudienceID      *uuid.UUID `json:"audience_id,omitempty" db:"audience_id"`
 	SegmentID       *uuid.UUID `json:"segment_id,omitempty" db:"segment_id"`
 	TemplateID      *uuid.UUID `json:"template_id,omitempty" db:"template_id"`
+	TopicID         *uuid.UUID `json:"topic_id,omitempty" db:"topic_id"`
 	FromAddress     *string    `json:"from,omitempty" db:"from_address"`
 	Subject         *string    `json:"subject,omitempty" db:"subject"`
 	HTMLBody        *string    `json:"html_body,omitempty" db:"html_body"`
@@ -25,12 +26,141 @@ type Broadcast struct {
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 	AudienceName    *string    `json:"-" db:"-"` // populated via JOIN, not a DB column
+
+	// SendInLocalTime sends the broadcast at the wall-clock time of
+	// ScheduledAt in each recipient's time zone, read from the contact
+	// property named TimezoneProperty.
+	SendInLocalTime  bool       `json:"send_in_local_time" db:"send_in_local_time"`
+	TimezoneProperty *string    `json:"timezone_property,omitempty" db:"timezone_property"`
+	NextSendAt       *time.Time `json:"next_send_at,omitempty" db:"next_send_at"`         // when the broadcast, or its next wave, is due
+	LocalSentUntil   *time.Time `json:"local_sent_until,omitempty" db:"local_sent_until"` // latest local send time already covered by a wave
+
+	// SendRate caps how many of the broadcast's emails are sent a minute;
+	// nil sends them as fast as the queue allows.
+	SendRate *int `json:"send_rate,omitempty" db:"send_rate"`
+
+	// An A/B tested broadcast first sends each of its variants to
+	// ABTestPercentage percent of the audience. ABTestWaitMinutes after that
+	// test group is sent, at ABTestEndsAt, the variant with the best ABWinnerMetric goes to the
+	// rest of the audience. If no variant was opened or clicked, by the
+	// metric, the test is inconclusive and the rest get the broadcast itself.
+	ABTestPercentage   *int       `json:"ab_test_percentage,omitempty" db:"ab_test_percentage"`
+	ABTestWaitMinutes  *int       `json:"ab_test_wait_minutes,omitempty" db:"ab_test_wait_minutes"`
+	ABWinnerMetric     *string    `json:"ab_winner_metric,omitempty" db:"ab_winner_metric"`
+	ABTestEndsAt       *time.Time `json:"ab_test_ends_at,omitempty" db:"ab_test_ends_at"`
+	ABWinnerVariantID  *uuid.UUID `json:"ab_winner_variant_id,omitempty" db:"ab_winner_variant_id"`
+	ABTestInconclusive bool       `json:"ab_test_inconclusive" db:"ab_test_inconclusive"`
+}
+
+// BroadcastVariant is one version of an A/B tested broadcast. Fields left
+// nil are taken from the broadcast; TemplateVersion picks a version of the
+// broadcast's template in place of the published one.
+type BroadcastVariant struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	BroadcastID     uuid.UUID `json:"broadcast_id" db:"broadcast_id"`
+	Name            string    `json:"name" db:"name"`
+	Position        int       `json:"position" db:"position"`
+	Subject         *string   `json:"subject,omitempty" db:"subject"`
+	FromName        *string   `json:"from_name,omitempty" db:"from_name"`
+	TemplateVersion *int      `json:"template_version,omitempty" db:"template_version"`
+	HTMLBody        *string   `json:"html_body,omitempty" db:"html_body"`
+	TextBody        *string   `json:"text_body,omitempty" db:"text_body"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// BroadcastVariantStats counts a variant's test emails and how many of them
+// were opened or clicked at least once.
+type BroadcastVariantStats struct {
+	VariantID  uuid.UUID `json:"variant_id" db:"variant_id"`
+	Recipients int       `json:"recipients" db:"recipients"`
+	Sent       int       `json:"sent" db:"sent"`
+	Opened     int       `json:"opened" db:"opened"`
+	Clicked    int       `json:"clicked" db:"clicked"`
+}
+
+// OpenRate returns the share of sent emails that were opened.
+func (s BroadcastVariantStats) OpenRate() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Opened) / float64(s.Sent)
+}
+
+// ClickRate returns the share of sent emails that were clicked.
+func (s BroadcastVariantStats) ClickRate() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Clicked) / float64(s.Sent)
+}
+
+// Rate returns the rate an A/B test with the given winner metric compares.
+func (s BroadcastVariantStats) Rate(metric string) float64 {
+	if metric == ABWinnerMetricClickRate {
+		return s.ClickRate()
+	}
+	return s.OpenRate()
 }
 
 const (
 	BroadcastStatusDraft     = "draft"
+	BroadcastStatusScheduled = "scheduled"
 	BroadcastStatusQueued    = "queued"
 	BroadcastStatusSending   = "sending"
+	BroadcastStatusPaused    = "paused"
 	BroadcastStatusSent      = "sent"
 	BroadcastStatusCancelled = "cancelled"
 )
+
+// DefaultTimezoneProperty is the contact property that holds recipients'
+// time zones when a broadcast sent in local time doesn't name one.
+const DefaultTimezoneProperty = "timezone"
+
+// Metrics the winner of an A/B test can be picked by.
+const (
+	ABWinnerMetricOpenRate  = "open_rate"
+	ABWinnerMetricClickRate = "click_rate"
+)
+
+// BroadcastTag returns the tag carried by every email of a broadcast.
+func BroadcastTag(broadcastID uuid.UUID) string {
+	return "broadcast:" + broadcastID.String()
+}
+
+// BroadcastVariantTag returns the tag carried by the test emails of a
+// broadcast variant.
+func BroadcastVariantTag(variantID uuid.UUID) string {
+	return "broadcast_variant:" + variantID.String()
+}
+
+// BroadcastStats counts a broadcast's emails by what became of them. Each
+// email is counted once per outcome, however often it was opened or clicked.
+type BroadcastStats struct {
+	Recipients   int `json:"recipients" db:"recipients"`
+	Delivered    int `json:"delivered" db:"delivered"`
+	Bounced      int `json:"bounced" db:"bounced"`
+	Opened       int `json:"opened" db:"opened"`
+	Clicked      int `json:"clicked" db:"clicked"`
+	Unsubscribed int `json:"unsubscribed" db:"unsubscribed"`
+	Complained   int `json:"complained" db:"complained"`
+}
+
+// BroadcastLinkStats counts the clicks on one of a broadcast's links, and
+// how many emails they came from.
+type BroadcastLinkStats struct {
+	URL          string `json:"url" db:"url"`
+	Clicks       int    `json:"clicks" db:"clicks"`
+	UniqueClicks int    `json:"unique_clicks" db:"unique_clicks"`
+}
+
+// BroadcastRecipient is one of a broadcast's emails, with the last event
+// recorded for it.
+type BroadcastRecipient struct {
+	EmailID     uuid.UUID  `json:"email_id" db:"email_id"`
+	Email       string     `json:"email" db:"email"`
+	Status      string     `json:"status" db:"status"`
+	VariantID   *uuid.UUID `json:"variant_id,omitempty" db:"broadcast_variant_id"`
+	SentAt      *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	LastEvent   *string    `json:"last_event,omitempty" db:"last_event"`
+	LastEventAt *time.Time `json:"last_event_at,omitempty" db:"last_event_at"`
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,3 +37,74 @@ type ContactPropertyValue struct {
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// ParseDateValue parses a date-typed value given either as an RFC 3339
+// timestamp or as a plain YYYY-MM-DD date.
+func ParseDateValue(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q must be an RFC 3339 timestamp or YYYY-MM-DD date", s)
+	}
+	return t, nil
+}
+
+// NormalizePropertyValue converts a JSON value supplied for a custom contact
+// property into its stored text form, checking it against the property type.
+func NormalizePropertyValue(valueType string, v interface{}) (string, error) {
+	switch valueType {
+	case ValueTypeString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return "", fmt.Errorf("must be a string")
+	case ValueTypeNumber:
+		switch n := v.(type) {
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		case string:
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return "", fmt.Errorf("must be a number")
+			}
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("must be a number")
+	case ValueTypeBoolean:
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("must be a boolean")
+	case ValueTypeDate:
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("must be a date string")
+		}
+		t, err := ParseDateValue(s)
+		if err != nil {
+			return "", err
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unknown property type %q", valueType)
+	}
+}
+
+// PropertyValue converts a stored custom property value back into a typed
+// value: numbers become float64 and booleans bool. Strings, dates and values
+// that no longer parse are returned as stored.
+func PropertyValue(valueType, stored string) interface{} {
+	switch valueType {
+	case ValueTypeNumber:
+		if f, err := strconv.ParseFloat(stored, 64); err == nil {
+			return f
+		}
+	case ValueTypeBoolean:
+		if b, err := strconv.ParseBool(stored); err == nil {
+			return b
+		}
+	}
+	return stored
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DMARCReport is a DMARC aggregate report received for a domain.
+type DMARCReport struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	TeamID        uuid.UUID `json:"team_id" db:"team_id"`
+	DomainID      uuid.UUID `json:"domain_id" db:"domain_id"`
+	OrgName       string    `json:"org_name" db:"org_name"`
+	ReporterEmail string    `json:"reporter_email" db:"reporter_email"`
+	ReportID      string    `json:"report_id" db:"report_id"`
+	PolicyDomain  string    `json:"policy_domain" db:"policy_domain"`
+	Policy        string    `json:"policy" db:"policy"`
+	DateBegin     time.Time `json:"date_begin" db:"date_begin"`
+	DateEnd       time.Time `json:"date_end" db:"date_end"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+
+	// Records is set when a report is created. Listings fill in the message
+	// totals instead.
+	Records        []DMARCReportRecord `json:"records,omitempty" db:"-"`
+	MessageCount   int                 `json:"message_count" db:"-"`
+	FailedMessages int                 `json:"failed_messages" db:"-"`
+}
+
+// DMARCReportRecord holds a report's results for the messages from one
+// source IP. DKIMResult and SPFResult are the aligned, policy-evaluated
+// results; the auth fields are the raw results for whatever domain signed
+// or sent the messages.
+type DMARCReportRecord struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	ReportID       uuid.UUID `json:"report_id" db:"report_id"`
+	SourceIP       string    `json:"source_ip" db:"source_ip"`
+	MessageCount   int       `json:"message_count" db:"message_count"`
+	Disposition    string    `json:"disposition" db:"disposition"`
+	DKIMResult     string    `json:"dkim_result" db:"dkim_result"`
+	SPFResult      string    `json:"spf_result" db:"spf_result"`
+	HeaderFrom     string    `json:"header_from" db:"header_from"`
+	EnvelopeFrom   string    `json:"envelope_from" db:"envelope_from"`
+	DKIMDomain     string    `json:"dkim_domain" db:"dkim_domain"`
+	DKIMAuthResult string    `json:"dkim_auth_result" db:"dkim_auth_result"`
+	SPFDomain      string    `json:"spf_domain" db:"spf_domain"`
+	SPFAuthResult  string    `json:"spf_auth_result" db:"spf_auth_result"`
+}
+
+// DMARCSourceStats totals a domain's DMARC results for one source IP.
+// Passed counts messages with aligned DKIM or SPF.
+type DMARCSourceStats struct {
+	SourceIP    string   `json:"source_ip"`
+	Messages    int      `json:"messages"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	DKIMAligned int      `json:"dkim_aligned"`
+	SPFAligned  int      `json:"spf_aligned"`
+	DKIMDomains []string `json:"dkim_domains"`
+	SPFDomains  []string `json:"spf_domains"`
+}
+
+// DMARCDailyStats totals a domain's DMARC results for the reports that
+// begin on a day.
+type DMARCDailyStats struct {
+	Day      time.Time `json:"day"`
+	Messages int       `json:"messages"`
+	Passed   int       `json:"passed"`
+	Failed   int       `json:"failed"`
+}
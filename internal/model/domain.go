@@ -14,11 +14,113 @@ type Domain struct {
 	Region         *string   `json:"region,omitempty" db:"region"`
 	DKIMPrivateKey *string   `json:"-" db:"dkim_private_key"`
 	DKIMSelector   string    `json:"dkim_selector" db:"dkim_selector"`
+	DKIMAlgorithm  string    `json:"dkim_algorithm" db:"dkim_algorithm"`
 	OpenTracking   bool      `json:"open_tracking" db:"open_tracking"`
 	ClickTracking  bool      `json:"click_tracking" db:"click_tracking"`
 	TLSPolicy      string    `json:"tls_policy" db:"tls_policy"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+
+	// A DKIM rotation in progress: the pending key is published next to the
+	// active one and takes over once its DNS record verifies. The previous
+	// selector's record is kept until its retire time, so that messages
+	// signed before the switch still verify.
+	DKIMPendingSelector      *string    `json:"dkim_pending_selector,omitempty" db:"dkim_pending_selector"`
+	DKIMPendingPrivateKey    *string    `json:"-" db:"dkim_pending_private_key"`
+	DKIMPendingAlgorithm     *string    `json:"dkim_pending_algorithm,omitempty" db:"dkim_pending_algorithm"`
+	DKIMPreviousSelector     *string    `json:"dkim_previous_selector,omitempty" db:"dkim_previous_selector"`
+	DKIMPreviousRetireAt     *time.Time `json:"dkim_previous_retire_at,omitempty" db:"dkim_previous_retire_at"`
+	DKIMRotationIntervalDays *int       `json:"dkim_rotation_interval_days,omitempty" db:"dkim_rotation_interval_days"` // nil disables scheduled rotation
+	DKIMRotatedAt            *time.Time `json:"dkim_rotated_at,omitempty" db:"dkim_rotated_at"`
+
+	// SendingPausedAt is set while sending is paused because the active DKIM
+	// record has disappeared from DNS. LastCheckedAt is when the domain's DNS
+	// records were last verified.
+	SendingPausedAt *time.Time `json:"sending_paused_at,omitempty" db:"sending_paused_at"`
+	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty" db:"last_checked_at"`
+
+	// WarmupStartedAt is set while the domain is warming up; see
+	// WarmupDailyLimit.
+	WarmupStartedAt *time.Time `json:"warmup_started_at,omitempty" db:"warmup_started_at"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DKIMRecordName returns the DNS name publishing the DKIM key of selector.
+func (d *Domain) DKIMRecordName(selector string) string {
+	return selector + "._domainkey." + d.Name
+}
+
+// StartDKIMRotation makes the given key the domain's pending DKIM key and
+// returns the DNS record that publishes it.
+func (d *Domain) StartDKIMRotation(selector, algorithm, privateKeyPEM, dnsValue string, now time.Time) DomainDNSRecord {
+	d.DKIMPendingSelector = &selector
+	d.DKIMPendingAlgorithm = &algorithm
+	d.DKIMPendingPrivateKey = &privateKeyPEM
+
+	return DomainDNSRecord{
+		ID:         uuid.New(),
+		DomainID:   d.ID,
+		RecordType: "DKIM",
+		DNSType:    "TXT",
+		Name:       d.DKIMRecordName(selector),
+		Value:      dnsValue,
+		Status:     DomainStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// PromoteDKIMKey makes the pending DKIM key the active one. The replaced
+// selector is kept as the previous one until retireAt.
+func (d *Domain) PromoteDKIMKey(now, retireAt time.Time) {
+	if d.DKIMPendingSelector == nil {
+		return
+	}
+	previous := d.DKIMSelector
+	d.DKIMPreviousSelector = &previous
+	d.DKIMPreviousRetireAt = &retireAt
+
+	d.DKIMSelector = *d.DKIMPendingSelector
+	d.DKIMPrivateKey = d.DKIMPendingPrivateKey
+	if d.DKIMPendingAlgorithm != nil {
+		d.DKIMAlgorithm = *d.DKIMPendingAlgorithm
+	}
+	d.DKIMRotatedAt = &now
+
+	d.DKIMPendingSelector = nil
+	d.DKIMPendingPrivateKey = nil
+	d.DKIMPendingAlgorithm = nil
+}
+
+// DKIMRotationSelector returns the selector for a key rotated in at now,
+// derived from the configured base selector.
+func DKIMRotationSelector(base string, now time.Time) string {
+	return base + "-" + now.UTC().Format("200601021504")
+}
+
+// A domain warming up builds a sending reputation gradually: over any 24
+// hours, broadcasts may send WarmupInitialDailyLimit emails from it on its
+// first day, twice as many each following day, until the limit would reach
+// WarmupFinalDailyLimit and warm-up ends.
+const (
+	WarmupInitialDailyLimit = 50
+	WarmupFinalDailyLimit   = 100000
+)
+
+// WarmupDailyLimit returns how many emails broadcasts may send from the
+// domain over the 24 hours before now, or 0 if the domain isn't warming up.
+func (d *Domain) WarmupDailyLimit(now time.Time) int {
+	if d.WarmupStartedAt == nil {
+		return 0
+	}
+	limit := WarmupInitialDailyLimit
+	for days := now.Sub(*d.WarmupStartedAt) / (24 * time.Hour); days > 0; days-- {
+		limit *= 2
+		if limit >= WarmupFinalDailyLimit {
+			return 0
+		}
+	}
+	return limit
 }
 
 // DomainDNSRecord is a DNS record associated with a domain.
@@ -36,8 +138,17 @@ type DomainDNSRecord struct {
 	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// A degraded domain was verified but some of its critical DNS records have
+// stopped verifying since. It can still send.
 const (
 	DomainStatusPending  = "pending"
 	DomainStatusVerified = "verified"
+	DomainStatusDegraded = "degraded"
 	DomainStatusFailed   = "failed"
 )
+
+// Sendable reports whether mail can be sent from the domain: it is verified,
+// or was and has degraded, and sending is not paused.
+func (d *Domain) Sendable() bool {
+	return (d.Status == DomainStatusVerified || d.Status == DomainStatusDegraded) && d.SendingPausedAt == nil
+}
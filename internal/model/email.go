@@ -10,6 +10,7 @@ type Email struct {
 	ID             uuid.UUID  `json:"id" db:"id"`
 	TeamID         uuid.UUID  `json:"team_id" db:"team_id"`
 	DomainID       *uuid.UUID `json:"domain_id,omitempty" db:"domain_id"`
+	BroadcastID    *uuid.UUID `json:"broadcast_id,omitempty" db:"broadcast_id"`
 	FromAddress    string     `json:"from" db:"from_address"`
 	ToAddresses    []string   `json:"to" db:"to_addresses"`
 	CcAddresses    []string   `json:"cc,omitempty" db:"cc_addresses"`
@@ -31,10 +32,17 @@ type Email struct {
 	RetryCount     int        `json:"retry_count" db:"retry_count"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+
+	// BroadcastVariantID is the A/B test variant a broadcast's test email
+	// was sent with.
+	BroadcastVariantID *uuid.UUID `json:"broadcast_variant_id,omitempty" db:"broadcast_variant_id"`
 }
 
-// Email status constants
+// Email status constants. A held email belongs to a broadcast that hasn't
+// released it to the send queue yet, because of the broadcast's send rate or
+// its domain's warm-up limit, or because the broadcast is paused.
 const (
+	EmailStatusHeld      = "held"
 	EmailStatusQueued    = "queued"
 	EmailStatusScheduled = "scheduled"
 	EmailStatusSending   = "sending"
@@ -45,6 +53,20 @@ const (
 	EmailStatusCancelled = "cancelled"
 )
 
+// StoredAttachmentKeys returns the storage keys of the email's attachments
+// that were stored by reference rather than inline.
+func (e *Email) StoredAttachmentKeys() []string {
+	var keys []string
+	for _, entry := range e.Attachments {
+		if m, ok := entry.(map[string]interface{}); ok {
+			if key, ok := m["path"].(string); ok && key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
 // EmailEvent tracks delivery lifecycle.
 type EmailEvent struct {
 	ID        uuid.UUID `json:"id" db:"id"`
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,3 +22,104 @@ type SegmentContact struct {
 	ContactID uuid.UUID `json:"contact_id" db:"contact_id"`
 	AddedAt   time.Time `json:"added_at" db:"added_at"`
 }
+
+// SegmentCondition is a single node of a segment's condition tree. A leaf sets
+// exactly one of Field, Property, Topic or Event together with Op; a group
+// sets All (every child must match) or Any (at least one child must match).
+// The top-level conditions array of a segment is an implicit All group.
+type SegmentCondition struct {
+	Field      string             `json:"field,omitempty"`
+	Property   string             `json:"property,omitempty"`
+	Topic      string             `json:"topic,omitempty"`
+	Event      string             `json:"event,omitempty"`
+	Op         string             `json:"op,omitempty"`
+	Value      interface{}        `json:"value,omitempty"`
+	WithinDays int                `json:"within_days,omitempty"`
+	All        []SegmentCondition `json:"all,omitempty"`
+	Any        []SegmentCondition `json:"any,omitempty"`
+}
+
+// Segment condition operators.
+const (
+	SegmentOpEq            = "eq"
+	SegmentOpNeq           = "neq"
+	SegmentOpContains      = "contains"
+	SegmentOpNotContains   = "not_contains"
+	SegmentOpStartsWith    = "starts_with"
+	SegmentOpEndsWith      = "ends_with"
+	SegmentOpGt            = "gt"
+	SegmentOpGte           = "gte"
+	SegmentOpLt            = "lt"
+	SegmentOpLte           = "lte"
+	SegmentOpIsSet         = "is_set"
+	SegmentOpIsNotSet      = "is_not_set"
+	SegmentOpSubscribed    = "subscribed"
+	SegmentOpNotSubscribed = "not_subscribed"
+	SegmentOpPerformed     = "performed"
+	SegmentOpNotPerformed  = "not_performed"
+)
+
+// Value types shared by built-in contact fields and custom contact properties.
+const (
+	ValueTypeString  = "string"
+	ValueTypeNumber  = "number"
+	ValueTypeBoolean = "boolean"
+	ValueTypeDate    = "date"
+)
+
+// SegmentContactFields maps the built-in contact columns usable in segment
+// conditions to their value type.
+var SegmentContactFields = map[string]string{
+	"email":        ValueTypeString,
+	"first_name":   ValueTypeString,
+	"last_name":    ValueTypeString,
+	"unsubscribed": ValueTypeBoolean,
+	"created_at":   ValueTypeDate,
+}
+
+// SegmentEventTypes lists the email engagement events usable in segment conditions.
+var SegmentEventTypes = map[string]bool{
+	EventSent:         true,
+	EventDelivered:    true,
+	EventOpened:       true,
+	EventClicked:      true,
+	EventBounced:      true,
+	EventComplained:   true,
+	EventUnsubscribed: true,
+}
+
+// segmentOperatorsByType lists the comparison operators valid for each value type.
+var segmentOperatorsByType = map[string][]string{
+	ValueTypeString:  {SegmentOpEq, SegmentOpNeq, SegmentOpContains, SegmentOpNotContains, SegmentOpStartsWith, SegmentOpEndsWith, SegmentOpIsSet, SegmentOpIsNotSet},
+	ValueTypeNumber:  {SegmentOpEq, SegmentOpNeq, SegmentOpGt, SegmentOpGte, SegmentOpLt, SegmentOpLte, SegmentOpIsSet, SegmentOpIsNotSet},
+	ValueTypeBoolean: {SegmentOpEq, SegmentOpNeq, SegmentOpIsSet, SegmentOpIsNotSet},
+	ValueTypeDate:    {SegmentOpGt, SegmentOpGte, SegmentOpLt, SegmentOpLte, SegmentOpIsSet, SegmentOpIsNotSet},
+}
+
+// SegmentOperatorAllowed reports whether op can be applied to a value of the given type.
+func SegmentOperatorAllowed(valueType, op string) bool {
+	for _, o := range segmentOperatorsByType[valueType] {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSegmentConditions decodes a segment's stored conditions into a
+// condition tree. It checks shape only; semantic validation is done by the
+// segment service before a segment is saved.
+func ParseSegmentConditions(raw JSONArray) ([]SegmentCondition, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("encoding segment conditions: %w", err)
+	}
+	var conds []SegmentCondition
+	if err := json.Unmarshal(data, &conds); err != nil {
+		return nil, fmt.Errorf("decoding segment conditions: %w", err)
+	}
+	return conds, nil
+}
@@ -7,11 +7,12 @@ import (
 )
 
 type Team struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Slug      string    `json:"slug" db:"slug"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Slug        string    `json:"slug" db:"slug"`
+	SandboxMode bool      `json:"sandbox_mode" db:"sandbox_mode"` // allows unverified domains, to members only
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type TeamMember struct {
@@ -22,9 +23,42 @@ type TeamMember struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// UserTeam is a team together with the role a user holds in it.
+type UserTeam struct {
+	Team
+	Role string `json:"role" db:"role"`
+}
+
 // Role constants
 const (
 	RoleOwner  = "owner"
 	RoleAdmin  = "admin"
 	RoleMember = "member"
 )
+
+// RoleScopes returns the scopes a team member's role grants, in the same
+// form as API key scopes. Owners and admins can do everything; members can
+// read everything and send and manage content, but not change domains, API
+// keys, webhooks or team settings.
+func RoleScopes(role string) []string {
+	switch role {
+	case RoleOwner, RoleAdmin:
+		return []string{ScopeAll}
+	case RoleMember:
+		return []string{
+			"*:" + ScopeAccessRead,
+			ScopeEmailsSend,
+			"emails:" + ScopeAccessWrite,
+			"contacts:" + ScopeAccessWrite,
+			"templates:" + ScopeAccessWrite,
+			"broadcasts:" + ScopeAccessWrite,
+			"suppressions:" + ScopeAccessWrite,
+		}
+	}
+	return nil
+}
+
+// ValidRole reports whether role is a team member role.
+func ValidRole(role string) bool {
+	return role == RoleOwner || role == RoleAdmin || role == RoleMember
+}
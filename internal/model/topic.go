@@ -7,12 +7,26 @@ import (
 )
 
 type Topic struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	TeamID      uuid.UUID `json:"team_id" db:"team_id"`
-	Name        string    `json:"name" db:"name"`
-	Description *string   `json:"description,omitempty" db:"description"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID                  uuid.UUID `json:"id" db:"id"`
+	TeamID              uuid.UUID `json:"team_id" db:"team_id"`
+	Name                string    `json:"name" db:"name"`
+	Description         *string   `json:"description,omitempty" db:"description"`
+	DefaultSubscription string    `json:"default_subscription" db:"default_subscription"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Topic default subscription policies. They decide whether a contact with no
+// explicit contact_topics row receives broadcasts sent to the topic.
+const (
+	TopicDefaultOptIn  = "opt_in"
+	TopicDefaultOptOut = "opt_out"
+)
+
+// SubscribedByDefault reports whether contacts are subscribed to the topic
+// until they opt out.
+func (t *Topic) SubscribedByDefault() bool {
+	return t.DefaultSubscription != TopicDefaultOptOut
 }
 
 type ContactTopic struct {
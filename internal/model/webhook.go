@@ -15,6 +15,16 @@ type Webhook struct {
 	Active        bool      `json:"active" db:"active"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	// Delivery health. DisabledAt and DisabledReason are set when the
+	// endpoint was disabled automatically after repeated failures.
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	DeliveredCount      int        `json:"delivered_count" db:"delivered_count"`
+	FailedCount         int        `json:"failed_count" db:"failed_count"`
+	LastDeliveredAt     *time.Time `json:"last_delivered_at,omitempty" db:"last_delivered_at"`
+	LastFailedAt        *time.Time `json:"last_failed_at,omitempty" db:"last_failed_at"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	DisabledReason      *string    `json:"disabled_reason,omitempty" db:"disabled_reason"`
 }
 
 type WebhookEvent struct {
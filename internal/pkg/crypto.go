@@ -1,12 +1,22 @@
 package pkg
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
+// ErrInvalidToken is returned when a signed token is malformed or its
+// signature does not match.
+var ErrInvalidToken = errors.New("invalid token")
+
 // GenerateAPIKey generates a new API key with the given prefix (e.g., "re_").
 // It returns the plaintext key, its SHA-256 hash, and a truncated prefix for display.
 func GenerateAPIKey(prefix string) (plaintext string, hash string, keyPrefix string, err error) {
@@ -41,3 +51,87 @@ func GenerateRandomString(length int) (string, error) {
 func GenerateWebhookSecret() (string, error) {
 	return GenerateRandomString(32)
 }
+
+// SignPreferenceToken returns a URL-safe token identifying a recipient of a
+// team's mail. It is signed with secret so that the unsubscribe and
+// preference links of one recipient cannot be forged for another address.
+func SignPreferenceToken(secret string, teamID uuid.UUID, email string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(teamID.String() + ":" + strings.ToLower(email)))
+	return payload + "." + preferenceSignature(secret, payload)
+}
+
+// VerifyPreferenceToken checks a token created by SignPreferenceToken and
+// returns the team and email address it identifies.
+func VerifyPreferenceToken(secret, token string) (uuid.UUID, string, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(preferenceSignature(secret, payload))) {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	team, email, ok := strings.Cut(string(raw), ":")
+	if !ok || email == "" {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	teamID, err := uuid.Parse(team)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	return teamID, email, nil
+}
+
+func preferenceSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("preferences:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verpPrefix starts the local part of every VERP return path.
+const verpPrefix = "bounces+"
+
+// VERPAddress returns the per-message return path for an email: an address
+// at host whose local part carries the email ID and a signature, so bounces
+// and feedback reports sent back to it can be matched to the email.
+func VERPAddress(secret string, emailID uuid.UUID, host string) string {
+	id := hex.EncodeToString(emailID[:])
+	return verpPrefix + id + "." + verpSignature(secret, id) + "@" + strings.ToLower(host)
+}
+
+// ParseVERPAddress checks an address created by VERPAddress and returns the
+// email ID it carries. The local part is matched case-insensitively, as some
+// mail servers change its case.
+func ParseVERPAddress(secret, address string) (uuid.UUID, error) {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return uuid.Nil, ErrInvalidToken
+	}
+	local := strings.ToLower(address[:at])
+	if !strings.HasPrefix(local, verpPrefix) {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	id, sig, ok := strings.Cut(strings.TrimPrefix(local, verpPrefix), ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(verpSignature(secret, id))) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	raw, err := hex.DecodeString(id)
+	if err != nil || len(raw) != len(uuid.UUID{}) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return uuid.UUID(raw), nil
+}
+
+// IsVERPAddress reports whether address looks like a VERP return path,
+// without checking its signature.
+func IsVERPAddress(address string) bool {
+	return strings.HasPrefix(strings.ToLower(address), verpPrefix)
+}
+
+func verpSignature(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("verp:" + id))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
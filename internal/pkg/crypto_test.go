@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -171,3 +172,80 @@ func TestGenerateRandomString(t *testing.T) {
 		}
 	})
 }
+
+func TestPreferenceToken(t *testing.T) {
+	teamID := uuid.New()
+
+	t.Run("round trips team and lowercased email", func(t *testing.T) {
+		token := SignPreferenceToken("secret", teamID, "Alice@Example.com")
+		gotTeam, gotEmail, err := VerifyPreferenceToken("secret", token)
+		require.NoError(t, err)
+		assert.Equal(t, teamID, gotTeam)
+		assert.Equal(t, "alice@example.com", gotEmail)
+	})
+
+	t.Run("is URL safe", func(t *testing.T) {
+		token := SignPreferenceToken("secret", teamID, "alice@example.com")
+		assert.NotContains(t, token, "+")
+		assert.NotContains(t, token, "/")
+		assert.NotContains(t, token, "=")
+	})
+
+	t.Run("rejects a different secret", func(t *testing.T) {
+		token := SignPreferenceToken("secret", teamID, "alice@example.com")
+		_, _, err := VerifyPreferenceToken("other", token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects a tampered payload", func(t *testing.T) {
+		token := SignPreferenceToken("secret", teamID, "alice@example.com")
+		other := SignPreferenceToken("secret", teamID, "bob@example.com")
+		payload, _, _ := strings.Cut(other, ".")
+		_, sig, _ := strings.Cut(token, ".")
+		_, _, err := VerifyPreferenceToken("secret", payload+"."+sig)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects malformed tokens", func(t *testing.T) {
+		for _, token := range []string{"", "abc", uuid.NewString()} {
+			_, _, err := VerifyPreferenceToken("secret", token)
+			assert.ErrorIs(t, err, ErrInvalidToken, "token %q", token)
+		}
+	})
+}
+
+func TestVERPAddress(t *testing.T) {
+	emailID := uuid.New()
+
+	t.Run("round trips the email ID", func(t *testing.T) {
+		addr := VERPAddress("secret", emailID, "Bounce.Example.com")
+		assert.True(t, strings.HasPrefix(addr, "bounces+"))
+		assert.True(t, strings.HasSuffix(addr, "@bounce.example.com"))
+		assert.LessOrEqual(t, strings.Index(addr, "@"), 64, "local part must fit in 64 octets")
+		assert.True(t, IsVERPAddress(addr))
+
+		got, err := ParseVERPAddress("secret", addr)
+		require.NoError(t, err)
+		assert.Equal(t, emailID, got)
+	})
+
+	t.Run("ignores the case of the local part", func(t *testing.T) {
+		addr := VERPAddress("secret", emailID, "bounce.example.com")
+		got, err := ParseVERPAddress("secret", strings.ToUpper(addr))
+		require.NoError(t, err)
+		assert.Equal(t, emailID, got)
+	})
+
+	t.Run("rejects a different secret", func(t *testing.T) {
+		_, err := ParseVERPAddress("other", VERPAddress("secret", emailID, "bounce.example.com"))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects other addresses", func(t *testing.T) {
+		for _, addr := range []string{"", "bounces@example.com", "bounces+abc@example.com", "user@example.com"} {
+			_, err := ParseVERPAddress("secret", addr)
+			assert.ErrorIs(t, err, ErrInvalidToken, "address %q", addr)
+		}
+		assert.False(t, IsVERPAddress("user@example.com"))
+	})
+}
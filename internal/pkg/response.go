@@ -24,13 +24,30 @@ func Error(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+// ErrValidation marks errors caused by invalid client input that can only be
+// detected by the service layer. Wrap it to have HandleError respond with 422.
+var ErrValidation = errors.New("validation failed")
+
+// ErrForbidden marks requests the caller is authenticated for but not allowed
+// to make. Wrap it to have HandleError respond with 403.
+var ErrForbidden = errors.New("forbidden")
+
 // HandleError writes a JSON error response, mapping known error types to
-// appropriate HTTP status codes (404 for not-found, 500 for everything else).
+// appropriate HTTP status codes (404 for not-found, 403 for forbidden
+// actions, 422 for validation failures, 500 for everything else).
 func HandleError(w http.ResponseWriter, err error) {
 	if errors.Is(err, postgres.ErrNotFound) {
 		Error(w, http.StatusNotFound, "not found")
 		return
 	}
+	if errors.Is(err, ErrForbidden) {
+		Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, ErrValidation) {
+		Error(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
 	Error(w, http.StatusInternalServerError, err.Error())
 }
 
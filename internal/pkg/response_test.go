@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 func TestJSON(t *testing.T) {
@@ -147,6 +151,41 @@ func TestError(t *testing.T) {
 	})
 }
 
+func TestHandleError(t *testing.T) {
+	t.Run("not found maps to 404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HandleError(w, fmt.Errorf("contact not found: %w", postgres.ErrNotFound))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("validation maps to 422", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HandleError(w, fmt.Errorf("%w: unknown contact field \"age\"", ErrValidation))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var result map[string]interface{}
+		err := json.NewDecoder(w.Body).Decode(&result)
+		require.NoError(t, err)
+		assert.Contains(t, result["message"], "unknown contact field")
+	})
+
+	t.Run("forbidden maps to 403", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HandleError(w, fmt.Errorf("%w: the team owner cannot be removed", ErrForbidden))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("other errors map to 500", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HandleError(w, errors.New("connection refused"))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
 func TestDecodeJSON(t *testing.T) {
 	t.Run("valid JSON decodes correctly", func(t *testing.T) {
 		body := `{"name":"Alice","age":30}`
@@ -11,6 +11,8 @@ import (
 	"github.com/mailit-dev/mailit/internal/model"
 )
 
+const apiKeyColumns = `id, team_id, name, key_hash, key_prefix, permission, scopes, domain_id, allowed_ips, expires_at, last_used_at, created_at`
+
 type apiKeyRepository struct {
 	pool *pgxpool.Pool
 }
@@ -22,27 +24,20 @@ func NewAPIKeyRepository(pool *pgxpool.Pool) APIKeyRepository {
 
 func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
 	query := `
-		INSERT INTO api_keys (id, team_id, name, key_hash, key_prefix, permission, domain_id, last_used_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, team_id, name, key_hash, key_prefix, permission, domain_id, last_used_at, created_at`
-
-	return r.pool.QueryRow(ctx, query,
-		key.ID, key.TeamID, key.Name, key.KeyHash, key.KeyPrefix, key.Permission, key.DomainID, key.LastUsedAt, key.CreatedAt,
-	).Scan(
-		&key.ID, &key.TeamID, &key.Name, &key.KeyHash, &key.KeyPrefix, &key.Permission, &key.DomainID, &key.LastUsedAt, &key.CreatedAt,
-	)
+		INSERT INTO api_keys (` + apiKeyColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING ` + apiKeyColumns
+
+	return scanAPIKey(r.pool.QueryRow(ctx, query,
+		key.ID, key.TeamID, key.Name, key.KeyHash, key.KeyPrefix, key.Permission, key.Scopes, key.DomainID, key.AllowedIPs, key.ExpiresAt, key.LastUsedAt, key.CreatedAt,
+	), key)
 }
 
 func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
-	query := `
-		SELECT id, team_id, name, key_hash, key_prefix, permission, domain_id, last_used_at, created_at
-		FROM api_keys WHERE key_hash = $1`
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
 
 	key := &model.APIKey{}
-	err := r.pool.QueryRow(ctx, query, keyHash).Scan(
-		&key.ID, &key.TeamID, &key.Name, &key.KeyHash, &key.KeyPrefix, &key.Permission, &key.DomainID, &key.LastUsedAt, &key.CreatedAt,
-	)
-	if err != nil {
+	if err := scanAPIKey(r.pool.QueryRow(ctx, query, keyHash), key); err != nil {
 		if isNoRows(err) {
 			return nil, notFound("api key")
 		}
@@ -53,7 +48,7 @@ func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*mode
 
 func (r *apiKeyRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID) ([]model.APIKey, error) {
 	query := `
-		SELECT id, team_id, name, key_hash, key_prefix, permission, domain_id, last_used_at, created_at
+		SELECT ` + apiKeyColumns + `
 		FROM api_keys WHERE team_id = $1
 		ORDER BY created_at DESC`
 
@@ -65,9 +60,7 @@ func (r *apiKeyRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID) (
 
 	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.APIKey, error) {
 		var k model.APIKey
-		err := row.Scan(
-			&k.ID, &k.TeamID, &k.Name, &k.KeyHash, &k.KeyPrefix, &k.Permission, &k.DomainID, &k.LastUsedAt, &k.CreatedAt,
-		)
+		err := scanAPIKey(row, &k)
 		return k, err
 	})
 }
@@ -97,3 +90,9 @@ func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, keyHash string, u
 	}
 	return nil
 }
+
+func scanAPIKey(row pgx.Row, k *model.APIKey) error {
+	return row.Scan(
+		&k.ID, &k.TeamID, &k.Name, &k.KeyHash, &k.KeyPrefix, &k.Permission, &k.Scopes, &k.DomainID, &k.AllowedIPs, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt,
+	)
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
 )
 
 func TestAPIKeyRepository_Create(t *testing.T) {
@@ -36,6 +38,11 @@ func TestAPIKeyRepository_GetByHash(t *testing.T) {
 
 	repo := NewAPIKeyRepository(testPool)
 	key := newTestAPIKey()
+	expiresAt := fixedTime.Add(30 * 24 * time.Hour)
+	key.Permission = model.PermissionCustom
+	key.Scopes = []string{"emails:send", "templates:read"}
+	key.AllowedIPs = []string{"10.0.0.0/8"}
+	key.ExpiresAt = &expiresAt
 
 	err := repo.Create(ctx, key)
 	require.NoError(t, err)
@@ -47,6 +54,10 @@ func TestAPIKeyRepository_GetByHash(t *testing.T) {
 	assert.Equal(t, key.TeamID, got.TeamID)
 	assert.Equal(t, key.Name, got.Name)
 	assert.Equal(t, key.KeyHash, got.KeyHash)
+	assert.Equal(t, key.Scopes, got.Scopes)
+	assert.Equal(t, key.AllowedIPs, got.AllowedIPs)
+	require.NotNil(t, got.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*got.ExpiresAt))
 
 	// Non-existent hash
 	_, err = repo.GetByHash(ctx, "nonexistent_hash")
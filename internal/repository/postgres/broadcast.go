@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,17 +20,23 @@ func NewBroadcastRepository(pool *pgxpool.Pool) BroadcastRepository {
 	return &broadcastRepository{pool: pool}
 }
 
-const broadcastColumns = `id, team_id, name, audience_id, segment_id, template_id, from_address,
+const broadcastColumns = `id, team_id, name, audience_id, segment_id, template_id, topic_id, from_address,
 	subject, html_body, text_body, status, scheduled_at, sent_at,
-	total_recipients, sent_count, created_at, updated_at`
+	total_recipients, sent_count, created_at, updated_at,
+	send_in_local_time, timezone_property, next_send_at, local_sent_until, send_rate,
+	ab_test_percentage, ab_test_wait_minutes, ab_winner_metric, ab_test_ends_at, ab_winner_variant_id,
+	ab_test_inconclusive`
 
 func scanBroadcastPtr(row pgx.Row) (*model.Broadcast, error) {
 	b := &model.Broadcast{}
 	err := row.Scan(
-		&b.ID, &b.TeamID, &b.Name, &b.AudienceID, &b.SegmentID, &b.TemplateID,
+		&b.ID, &b.TeamID, &b.Name, &b.AudienceID, &b.SegmentID, &b.TemplateID, &b.TopicID,
 		&b.FromAddress, &b.Subject, &b.HTMLBody, &b.TextBody, &b.Status,
 		&b.ScheduledAt, &b.SentAt, &b.TotalRecipients, &b.SentCount,
 		&b.CreatedAt, &b.UpdatedAt,
+		&b.SendInLocalTime, &b.TimezoneProperty, &b.NextSendAt, &b.LocalSentUntil, &b.SendRate,
+		&b.ABTestPercentage, &b.ABTestWaitMinutes, &b.ABWinnerMetric, &b.ABTestEndsAt, &b.ABWinnerVariantID,
+		&b.ABTestInconclusive,
 	)
 	return b, err
 }
@@ -37,14 +44,18 @@ func scanBroadcastPtr(row pgx.Row) (*model.Broadcast, error) {
 func (r *broadcastRepository) Create(ctx context.Context, broadcast *model.Broadcast) error {
 	query := fmt.Sprintf(`
 		INSERT INTO broadcasts (%s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+			$19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)
 		RETURNING %s`, broadcastColumns, broadcastColumns)
 
 	row := r.pool.QueryRow(ctx, query,
 		broadcast.ID, broadcast.TeamID, broadcast.Name, broadcast.AudienceID, broadcast.SegmentID,
-		broadcast.TemplateID, broadcast.FromAddress, broadcast.Subject, broadcast.HTMLBody,
+		broadcast.TemplateID, broadcast.TopicID, broadcast.FromAddress, broadcast.Subject, broadcast.HTMLBody,
 		broadcast.TextBody, broadcast.Status, broadcast.ScheduledAt, broadcast.SentAt,
 		broadcast.TotalRecipients, broadcast.SentCount, broadcast.CreatedAt, broadcast.UpdatedAt,
+		broadcast.SendInLocalTime, broadcast.TimezoneProperty, broadcast.NextSendAt, broadcast.LocalSentUntil,
+		broadcast.SendRate, broadcast.ABTestPercentage, broadcast.ABTestWaitMinutes, broadcast.ABWinnerMetric,
+		broadcast.ABTestEndsAt, broadcast.ABWinnerVariantID, broadcast.ABTestInconclusive,
 	)
 	scanned, err := scanBroadcastPtr(row)
 	if err != nil {
@@ -89,10 +100,13 @@ func (r *broadcastRepository) List(ctx context.Context, teamID uuid.UUID, limit,
 	}
 
 	query := `
-		SELECT b.id, b.team_id, b.name, b.audience_id, b.segment_id, b.template_id,
+		SELECT b.id, b.team_id, b.name, b.audience_id, b.segment_id, b.template_id, b.topic_id,
 			b.from_address, b.subject, b.html_body, b.text_body, b.status,
 			b.scheduled_at, b.sent_at, b.total_recipients, b.sent_count,
-			b.created_at, b.updated_at, a.name AS audience_name
+			b.created_at, b.updated_at, b.send_in_local_time, b.timezone_property,
+			b.next_send_at, b.local_sent_until, b.send_rate, b.ab_test_percentage,
+			b.ab_test_wait_minutes, b.ab_winner_metric, b.ab_test_ends_at, b.ab_winner_variant_id,
+			b.ab_test_inconclusive, a.name AS audience_name
 		FROM broadcasts b
 		LEFT JOIN audiences a ON a.id = b.audience_id
 		WHERE b.team_id = $1
@@ -108,10 +122,13 @@ func (r *broadcastRepository) List(ctx context.Context, teamID uuid.UUID, limit,
 	broadcasts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Broadcast, error) {
 		var b model.Broadcast
 		err := row.Scan(
-			&b.ID, &b.TeamID, &b.Name, &b.AudienceID, &b.SegmentID, &b.TemplateID,
+			&b.ID, &b.TeamID, &b.Name, &b.AudienceID, &b.SegmentID, &b.TemplateID, &b.TopicID,
 			&b.FromAddress, &b.Subject, &b.HTMLBody, &b.TextBody, &b.Status,
 			&b.ScheduledAt, &b.SentAt, &b.TotalRecipients, &b.SentCount,
-			&b.CreatedAt, &b.UpdatedAt, &b.AudienceName,
+			&b.CreatedAt, &b.UpdatedAt, &b.SendInLocalTime, &b.TimezoneProperty,
+			&b.NextSendAt, &b.LocalSentUntil, &b.SendRate, &b.ABTestPercentage,
+			&b.ABTestWaitMinutes, &b.ABWinnerMetric, &b.ABTestEndsAt, &b.ABWinnerVariantID,
+			&b.ABTestInconclusive, &b.AudienceName,
 		)
 		return b, err
 	})
@@ -122,20 +139,28 @@ func (r *broadcastRepository) List(ctx context.Context, teamID uuid.UUID, limit,
 	return broadcasts, total, nil
 }
 
+// Update saves a broadcast. sent_count is left alone: it is only changed by
+// IncrementSentCount, as the broadcast's emails are sent.
 func (r *broadcastRepository) Update(ctx context.Context, broadcast *model.Broadcast) error {
 	query := fmt.Sprintf(`
 		UPDATE broadcasts
-		SET name = $2, audience_id = $3, segment_id = $4, template_id = $5, from_address = $6,
-		    subject = $7, html_body = $8, text_body = $9, status = $10, scheduled_at = $11,
-		    sent_at = $12, total_recipients = $13, sent_count = $14, updated_at = $15
+		SET name = $2, audience_id = $3, segment_id = $4, template_id = $5, topic_id = $6,
+		    from_address = $7, subject = $8, html_body = $9, text_body = $10, status = $11,
+		    scheduled_at = $12, sent_at = $13, total_recipients = $14, updated_at = $15,
+		    send_in_local_time = $16, timezone_property = $17, next_send_at = $18, local_sent_until = $19,
+		    send_rate = $20, ab_test_percentage = $21, ab_test_wait_minutes = $22, ab_winner_metric = $23,
+		    ab_test_ends_at = $24, ab_winner_variant_id = $25, ab_test_inconclusive = $26
 		WHERE id = $1
 		RETURNING %s`, broadcastColumns)
 
 	row := r.pool.QueryRow(ctx, query,
 		broadcast.ID, broadcast.Name, broadcast.AudienceID, broadcast.SegmentID, broadcast.TemplateID,
-		broadcast.FromAddress, broadcast.Subject, broadcast.HTMLBody, broadcast.TextBody,
+		broadcast.TopicID, broadcast.FromAddress, broadcast.Subject, broadcast.HTMLBody, broadcast.TextBody,
 		broadcast.Status, broadcast.ScheduledAt, broadcast.SentAt,
-		broadcast.TotalRecipients, broadcast.SentCount, broadcast.UpdatedAt,
+		broadcast.TotalRecipients, broadcast.UpdatedAt,
+		broadcast.SendInLocalTime, broadcast.TimezoneProperty, broadcast.NextSendAt, broadcast.LocalSentUntil,
+		broadcast.SendRate, broadcast.ABTestPercentage, broadcast.ABTestWaitMinutes, broadcast.ABWinnerMetric,
+		broadcast.ABTestEndsAt, broadcast.ABWinnerVariantID, broadcast.ABTestInconclusive,
 	)
 	scanned, err := scanBroadcastPtr(row)
 	if err != nil {
@@ -148,6 +173,90 @@ func (r *broadcastRepository) Update(ctx context.Context, broadcast *model.Broad
 	return nil
 }
 
+func (r *broadcastRepository) ListDue(ctx context.Context, now time.Time) ([]model.Broadcast, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM broadcasts
+		WHERE status IN ('scheduled', 'sending') AND next_send_at <= $1
+		ORDER BY next_send_at`, broadcastColumns)
+
+	rows, err := r.pool.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("list due broadcasts: %w", err)
+	}
+	defer rows.Close()
+
+	broadcasts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Broadcast, error) {
+		b, err := scanBroadcastPtr(row)
+		if err != nil {
+			return model.Broadcast{}, err
+		}
+		return *b, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect due broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
+
+// AddRecipients adds n to the recipients of a broadcast and returns the
+// broadcast as it now is.
+func (r *broadcastRepository) AddRecipients(ctx context.Context, id uuid.UUID, n int) (*model.Broadcast, error) {
+	query := fmt.Sprintf(`
+		UPDATE broadcasts
+		SET total_recipients = total_recipients + $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING %s`, broadcastColumns)
+
+	b, err := scanBroadcastPtr(r.pool.QueryRow(ctx, query, id, n))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, notFound("broadcast")
+		}
+		return nil, fmt.Errorf("add broadcast recipients: %w", err)
+	}
+	return b, nil
+}
+
+// IncrementSentCount counts one more email of a broadcast as sent.
+func (r *broadcastRepository) IncrementSentCount(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE broadcasts SET sent_count = sent_count + 1 WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("increment broadcast sent count: %w", err)
+	}
+	return nil
+}
+
+// ListReleasable returns the sending broadcasts that have held emails left
+// to release.
+func (r *broadcastRepository) ListReleasable(ctx context.Context) ([]model.Broadcast, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM broadcasts b
+		WHERE status = 'sending'
+		  AND EXISTS (
+		      SELECT 1 FROM emails e
+		      WHERE e.broadcast_id = b.id AND e.status = 'held')
+		ORDER BY updated_at`, broadcastColumns)
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list releasable broadcasts: %w", err)
+	}
+	defer rows.Close()
+
+	broadcasts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Broadcast, error) {
+		b, err := scanBroadcastPtr(row)
+		if err != nil {
+			return model.Broadcast{}, err
+		}
+		return *b, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect releasable broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
+
 func (r *broadcastRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM broadcasts WHERE id = $1`
 
@@ -160,3 +269,225 @@ func (r *broadcastRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// GetStats counts a broadcast's emails by outcome. An email bounced if it
+// was returned, or rejected outright by the receiving server; it was
+// delivered if it was sent and hasn't bounced since.
+func (r *broadcastRepository) GetStats(ctx context.Context, id uuid.UUID) (*model.BroadcastStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE e.status IN ('sent', 'delivered')),
+		       COUNT(*) FILTER (WHERE e.status = 'bounced' OR EXISTS (
+		           SELECT 1 FROM email_events ev
+		           WHERE ev.email_id = e.id AND ev.type = 'bounced' AND ev.payload->>'type' = 'hard')),
+		       COUNT(*) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = 'opened')),
+		       COUNT(*) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = 'clicked')),
+		       COUNT(*) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = 'unsubscribed')),
+		       COUNT(*) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = 'complained'))
+		FROM emails e
+		WHERE e.broadcast_id = $1`
+
+	stats := &model.BroadcastStats{}
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&stats.Recipients, &stats.Delivered, &stats.Bounced, &stats.Opened,
+		&stats.Clicked, &stats.Unsubscribed, &stats.Complained,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get broadcast stats: %w", err)
+	}
+	return stats, nil
+}
+
+// ListLinkStats counts the clicks on each link of a broadcast's emails, most
+// clicked first. Clicks are matched to links by URL.
+func (r *broadcastRepository) ListLinkStats(ctx context.Context, id uuid.UUID) ([]model.BroadcastLinkStats, error) {
+	query := `
+		SELECT l.url, COUNT(ev.id), COUNT(DISTINCT ev.email_id)
+		FROM (
+		    SELECT DISTINCT tl.email_id, tl.original_url AS url
+		    FROM email_tracking_links tl
+		    JOIN emails e ON e.id = tl.email_id
+		    WHERE e.broadcast_id = $1 AND tl.type = 'click' AND tl.original_url IS NOT NULL) l
+		LEFT JOIN email_events ev ON ev.email_id = l.email_id
+		      AND ev.type = 'clicked' AND ev.payload->>'url' = l.url
+		GROUP BY l.url
+		ORDER BY COUNT(ev.id) DESC, l.url`
+
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("list broadcast link stats: %w", err)
+	}
+	defer rows.Close()
+
+	links, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BroadcastLinkStats, error) {
+		var l model.BroadcastLinkStats
+		err := row.Scan(&l.URL, &l.Clicks, &l.UniqueClicks)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect broadcast link stats: %w", err)
+	}
+	return links, nil
+}
+
+// ListRecipients lists a broadcast's emails in the order they were created,
+// each with its last event. status keeps the emails in that status, event
+// those that had such an event, and search those whose recipient contains it.
+func (r *broadcastRepository) ListRecipients(ctx context.Context, id uuid.UUID, status, event, search string, limit, offset int) ([]model.BroadcastRecipient, int, error) {
+	where := "e.broadcast_id = $1"
+	args := []interface{}{id}
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(" AND e.status = $%d", len(args))
+	}
+	if event != "" {
+		args = append(args, event)
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = $%d)", len(args))
+	}
+	if search != "" {
+		args = append(args, "%"+escapeLike(search)+"%")
+		where += fmt.Sprintf(" AND e.to_addresses[1] ILIKE $%d", len(args))
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM emails e WHERE %s`, where)
+	var total int
+	if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count broadcast recipients: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT e.id, COALESCE(e.to_addresses[1], ''), e.status, e.broadcast_variant_id, e.sent_at,
+		       last.type, last.created_at
+		FROM emails e
+		LEFT JOIN LATERAL (
+		    SELECT ev.type, ev.created_at FROM email_events ev
+		    WHERE ev.email_id = e.id
+		    ORDER BY ev.created_at DESC, ev.id DESC
+		    LIMIT 1) last ON true
+		WHERE %s
+		ORDER BY e.created_at, e.id
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list broadcast recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BroadcastRecipient, error) {
+		var rc model.BroadcastRecipient
+		err := row.Scan(&rc.EmailID, &rc.Email, &rc.Status, &rc.VariantID, &rc.SentAt, &rc.LastEvent, &rc.LastEventAt)
+		return rc, err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("collect broadcast recipients: %w", err)
+	}
+	return recipients, total, nil
+}
+
+type broadcastVariantRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBroadcastVariantRepository creates a new BroadcastVariantRepository backed by PostgreSQL.
+func NewBroadcastVariantRepository(pool *pgxpool.Pool) BroadcastVariantRepository {
+	return &broadcastVariantRepository{pool: pool}
+}
+
+const broadcastVariantColumns = `id, broadcast_id, name, position, subject, from_name, template_version,
+	html_body, text_body, created_at`
+
+func (r *broadcastVariantRepository) ListByBroadcastID(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariant, error) {
+	query := fmt.Sprintf(`SELECT %s FROM broadcast_variants WHERE broadcast_id = $1 ORDER BY position`, broadcastVariantColumns)
+
+	rows, err := r.pool.Query(ctx, query, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("list broadcast variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BroadcastVariant, error) {
+		var v model.BroadcastVariant
+		err := row.Scan(
+			&v.ID, &v.BroadcastID, &v.Name, &v.Position, &v.Subject, &v.FromName, &v.TemplateVersion,
+			&v.HTMLBody, &v.TextBody, &v.CreatedAt,
+		)
+		return v, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect broadcast variants: %w", err)
+	}
+	return variants, nil
+}
+
+// ReplaceForBroadcast replaces the variants of a broadcast with the given
+// ones, in order. No variants ends the broadcast's A/B test.
+func (r *broadcastVariantRepository) ReplaceForBroadcast(ctx context.Context, broadcastID uuid.UUID, variants []model.BroadcastVariant) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin broadcast variants replace: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM broadcast_variants WHERE broadcast_id = $1`, broadcastID); err != nil {
+		return fmt.Errorf("delete broadcast variants: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO broadcast_variants (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, broadcastVariantColumns)
+	for i := range variants {
+		v := &variants[i]
+		v.BroadcastID = broadcastID
+		v.Position = i
+		if _, err := tx.Exec(ctx, query,
+			v.ID, v.BroadcastID, v.Name, v.Position, v.Subject, v.FromName, v.TemplateVersion,
+			v.HTMLBody, v.TextBody, v.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("insert broadcast variant: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit broadcast variants replace: %w", err)
+	}
+	return nil
+}
+
+// ListStats counts the test emails of each variant of a broadcast, in order,
+// and how many of them were opened or clicked.
+func (r *broadcastVariantRepository) ListStats(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariantStats, error) {
+	query := `
+		SELECT v.id,
+		       COUNT(e.id),
+		       COUNT(e.id) FILTER (WHERE e.sent_at IS NOT NULL),
+		       COUNT(e.id) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = 'opened')),
+		       COUNT(e.id) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM email_events ev WHERE ev.email_id = e.id AND ev.type = 'clicked'))
+		FROM broadcast_variants v
+		LEFT JOIN emails e ON e.broadcast_variant_id = v.id
+		WHERE v.broadcast_id = $1
+		GROUP BY v.id, v.position
+		ORDER BY v.position`
+
+	rows, err := r.pool.Query(ctx, query, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("list broadcast variant stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BroadcastVariantStats, error) {
+		var s model.BroadcastVariantStats
+		err := row.Scan(&s.VariantID, &s.Recipients, &s.Sent, &s.Opened, &s.Clicked)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect broadcast variant stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,174 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func TestBroadcastVariantRepository_ListStats(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	broadcastRepo := NewBroadcastRepository(testPool)
+	variantRepo := NewBroadcastVariantRepository(testPool)
+	emailRepo := NewEmailRepository(testPool)
+	eventRepo := NewEmailEventRepository(testPool)
+
+	percentage := 10
+	broadcast := &model.Broadcast{
+		ID:               uuid.New(),
+		TeamID:           testTeamID,
+		Name:             "Launch",
+		Status:           model.BroadcastStatusDraft,
+		ABTestPercentage: &percentage,
+		CreatedAt:        fixedTime,
+		UpdatedAt:        fixedTime,
+	}
+	require.NoError(t, broadcastRepo.Create(ctx, broadcast))
+
+	subjectA, subjectB := "Big news", "You'll want to see this"
+	variants := []model.BroadcastVariant{
+		{ID: uuid.New(), Name: "A", Subject: &subjectA, CreatedAt: fixedTime},
+		{ID: uuid.New(), Name: "B", Subject: &subjectB, CreatedAt: fixedTime},
+	}
+	require.NoError(t, variantRepo.ReplaceForBroadcast(ctx, broadcast.ID, variants))
+
+	// Variant A: two sent emails, one opened twice and clicked. Variant B:
+	// one sent email and one still held.
+	sentAt := fixedTime
+	newVariantEmail := func(variant uuid.UUID, sent bool) *model.Email {
+		email := newTestEmail()
+		email.ID = uuid.New()
+		email.BroadcastID, email.BroadcastVariantID = &broadcast.ID, &variant
+		email.Status = model.EmailStatusHeld
+		if sent {
+			email.Status = model.EmailStatusSent
+			email.SentAt = &sentAt
+		}
+		require.NoError(t, emailRepo.Create(ctx, email))
+		return email
+	}
+	opened := newVariantEmail(variants[0].ID, true)
+	newVariantEmail(variants[0].ID, true)
+	newVariantEmail(variants[1].ID, true)
+	newVariantEmail(variants[1].ID, false)
+	for _, typ := range []string{model.EventOpened, model.EventOpened, model.EventClicked} {
+		require.NoError(t, eventRepo.Create(ctx, &model.EmailEvent{ID: uuid.New(), EmailID: opened.ID, Type: typ, Payload: model.JSONMap{}, CreatedAt: fixedTime}))
+	}
+
+	stats, err := variantRepo.ListStats(ctx, broadcast.ID)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, model.BroadcastVariantStats{VariantID: variants[0].ID, Recipients: 2, Sent: 2, Opened: 1, Clicked: 1}, stats[0])
+	assert.Equal(t, model.BroadcastVariantStats{VariantID: variants[1].ID, Recipients: 2, Sent: 1}, stats[1])
+
+	// Replacing the variants drops the old ones.
+	require.NoError(t, variantRepo.ReplaceForBroadcast(ctx, broadcast.ID, nil))
+	got, err := variantRepo.ListByBroadcastID(ctx, broadcast.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestBroadcastRepository_Stats(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	broadcastRepo := NewBroadcastRepository(testPool)
+	emailRepo := NewEmailRepository(testPool)
+	eventRepo := NewEmailEventRepository(testPool)
+	linkRepo := NewTrackingLinkRepository(testPool)
+
+	broadcast := &model.Broadcast{ID: uuid.New(), TeamID: testTeamID, Name: "Launch", Status: model.BroadcastStatusSending, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, broadcastRepo.Create(ctx, broadcast))
+
+	sentAt := fixedTime
+	newBroadcastEmail := func(to, status string, minute int) *model.Email {
+		email := newTestEmail()
+		email.ID = uuid.New()
+		email.BroadcastID = &broadcast.ID
+		email.ToAddresses = []string{to}
+		email.Status = status
+		if status != model.EmailStatusHeld {
+			email.SentAt = &sentAt
+		}
+		email.CreatedAt = fixedTime.Add(time.Duration(minute) * time.Minute)
+		require.NoError(t, emailRepo.Create(ctx, email))
+		return email
+	}
+	addEvent := func(email *model.Email, typ string, payload model.JSONMap, minute int) {
+		require.NoError(t, eventRepo.Create(ctx, &model.EmailEvent{
+			ID: uuid.New(), EmailID: email.ID, Type: typ, Payload: payload, CreatedAt: fixedTime.Add(time.Duration(minute) * time.Minute),
+		}))
+	}
+	addLink := func(email *model.Email, url string) {
+		require.NoError(t, linkRepo.Create(ctx, &model.TrackingLink{
+			ID: uuid.New(), EmailID: email.ID, TeamID: testTeamID, Type: model.TrackingTypeClick, OriginalURL: &url, Recipient: email.ToAddresses[0], CreatedAt: fixedTime,
+		}))
+	}
+
+	// ada opened and clicked the launch link twice, bob clicked pricing and
+	// complained, carol bounced outright and dave is still held.
+	ada := newBroadcastEmail("ada@example.com", model.EmailStatusSent, 0)
+	bob := newBroadcastEmail("bob@example.com", model.EmailStatusSent, 1)
+	carol := newBroadcastEmail("carol@example.com", model.EmailStatusFailed, 2)
+	newBroadcastEmail("dave@example.com", model.EmailStatusHeld, 3)
+	for _, email := range []*model.Email{ada, bob} {
+		addLink(email, "https://example.com/launch")
+		addLink(email, "https://example.com/pricing")
+	}
+	addEvent(ada, model.EventOpened, model.JSONMap{}, 10)
+	addEvent(ada, model.EventClicked, model.JSONMap{"url": "https://example.com/launch"}, 11)
+	addEvent(ada, model.EventClicked, model.JSONMap{"url": "https://example.com/launch"}, 12)
+	addEvent(bob, model.EventClicked, model.JSONMap{"url": "https://example.com/pricing"}, 20)
+	addEvent(bob, model.EventComplained, model.JSONMap{}, 21)
+	addEvent(carol, model.EventBounced, model.JSONMap{"type": "hard"}, 5)
+
+	// An email outside the broadcast is not counted.
+	other := newTestEmail()
+	other.ID = uuid.New()
+	require.NoError(t, emailRepo.Create(ctx, other))
+	addEvent(other, model.EventOpened, model.JSONMap{}, 30)
+
+	stats, err := broadcastRepo.GetStats(ctx, broadcast.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &model.BroadcastStats{Recipients: 4, Delivered: 2, Bounced: 1, Opened: 1, Clicked: 2, Complained: 1}, stats)
+
+	links, err := broadcastRepo.ListLinkStats(ctx, broadcast.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.BroadcastLinkStats{
+		{URL: "https://example.com/launch", Clicks: 2, UniqueClicks: 1},
+		{URL: "https://example.com/pricing", Clicks: 1, UniqueClicks: 1},
+	}, links)
+
+	recipients, total, err := broadcastRepo.ListRecipients(ctx, broadcast.ID, "", "", "", 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, recipients, 2)
+	assert.Equal(t, "ada@example.com", recipients[0].Email)
+	require.NotNil(t, recipients[0].LastEvent)
+	assert.Equal(t, model.EventClicked, *recipients[0].LastEvent)
+	assert.Equal(t, model.EventComplained, *recipients[1].LastEvent)
+
+	recipients, total, err = broadcastRepo.ListRecipients(ctx, broadcast.ID, model.EmailStatusSent, model.EventClicked, "BOB", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, recipients, 1)
+	assert.Equal(t, bob.ID, recipients[0].EmailID)
+
+	recipients, _, err = broadcastRepo.ListRecipients(ctx, broadcast.ID, model.EmailStatusHeld, "", "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, recipients, 1)
+	assert.Nil(t, recipients[0].LastEvent)
+	assert.Nil(t, recipients[0].SentAt)
+}
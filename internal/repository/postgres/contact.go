@@ -87,6 +87,34 @@ func (r *contactRepository) GetByAudienceAndEmail(ctx context.Context, audienceI
 	return c, nil
 }
 
+func (r *contactRepository) ListByTeamAndEmail(ctx context.Context, teamID uuid.UUID, email string) ([]model.Contact, error) {
+	query := fmt.Sprintf(`
+		SELECT c.%s FROM contacts c
+		JOIN audiences a ON a.id = c.audience_id
+		WHERE a.team_id = $1 AND lower(c.email) = lower($2)
+		ORDER BY c.created_at`, contactColumns)
+
+	rows, err := r.pool.Query(ctx, query, teamID, email)
+	if err != nil {
+		return nil, fmt.Errorf("list contacts by team and email: %w", err)
+	}
+	defer rows.Close()
+
+	contacts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Contact, error) {
+		var c model.Contact
+		err := row.Scan(
+			&c.ID, &c.AudienceID, &c.Email, &c.FirstName, &c.LastName,
+			&c.Unsubscribed, &c.CreatedAt, &c.UpdatedAt,
+		)
+		return c, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect contacts by team and email: %w", err)
+	}
+
+	return contacts, nil
+}
+
 func (r *contactRepository) List(ctx context.Context, audienceID uuid.UUID, limit, offset int) ([]model.Contact, int, error) {
 	countQuery := `SELECT COUNT(*) FROM contacts WHERE audience_id = $1`
 	var total int
@@ -117,3 +117,78 @@ func (r *contactPropertyRepository) Delete(ctx context.Context, id uuid.UUID) er
 	}
 	return nil
 }
+
+type contactPropertyValueRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewContactPropertyValueRepository creates a new ContactPropertyValueRepository backed by PostgreSQL.
+func NewContactPropertyValueRepository(pool *pgxpool.Pool) ContactPropertyValueRepository {
+	return &contactPropertyValueRepository{pool: pool}
+}
+
+const contactPropertyValueColumns = `id, contact_id, property_id, value, created_at, updated_at`
+
+func (r *contactPropertyValueRepository) ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactPropertyValue, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM contact_property_values WHERE contact_id = $1
+		ORDER BY created_at`, contactPropertyValueColumns)
+
+	rows, err := r.pool.Query(ctx, query, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("list contact property values: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ContactPropertyValue, error) {
+		var v model.ContactPropertyValue
+		err := row.Scan(&v.ID, &v.ContactID, &v.PropertyID, &v.Value, &v.CreatedAt, &v.UpdatedAt)
+		return v, err
+	})
+}
+
+func (r *contactPropertyValueRepository) ListByContactIDs(ctx context.Context, contactIDs []uuid.UUID) ([]model.ContactPropertyValue, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM contact_property_values WHERE contact_id = ANY($1)
+		ORDER BY created_at`, contactPropertyValueColumns)
+
+	rows, err := r.pool.Query(ctx, query, contactIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list contact property values by contacts: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ContactPropertyValue, error) {
+		var v model.ContactPropertyValue
+		err := row.Scan(&v.ID, &v.ContactID, &v.PropertyID, &v.Value, &v.CreatedAt, &v.UpdatedAt)
+		return v, err
+	})
+}
+
+func (r *contactPropertyValueRepository) Upsert(ctx context.Context, value *model.ContactPropertyValue) error {
+	query := fmt.Sprintf(`
+		INSERT INTO contact_property_values (%s)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (contact_id, property_id)
+		DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at
+		RETURNING %s`, contactPropertyValueColumns, contactPropertyValueColumns)
+
+	err := r.pool.QueryRow(ctx, query,
+		value.ID, value.ContactID, value.PropertyID, value.Value, value.CreatedAt, value.UpdatedAt,
+	).Scan(
+		&value.ID, &value.ContactID, &value.PropertyID, &value.Value, &value.CreatedAt, &value.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert contact property value: %w", err)
+	}
+	return nil
+}
+
+func (r *contactPropertyValueRepository) Delete(ctx context.Context, contactID, propertyID uuid.UUID) error {
+	query := `DELETE FROM contact_property_values WHERE contact_id = $1 AND property_id = $2`
+
+	if _, err := r.pool.Exec(ctx, query, contactID, propertyID); err != nil {
+		return fmt.Errorf("delete contact property value: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+type dmarcReportRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDMARCReportRepository creates a new DMARCReportRepository backed by PostgreSQL.
+func NewDMARCReportRepository(pool *pgxpool.Pool) DMARCReportRepository {
+	return &dmarcReportRepository{pool: pool}
+}
+
+const dmarcReportColumns = `id, team_id, domain_id, org_name, reporter_email, report_id, policy_domain, policy, date_begin, date_end, created_at`
+
+// dmarcPassed is the SQL condition for records that passed DMARC.
+const dmarcPassed = `(rec.dkim_result = 'pass' OR rec.spf_result = 'pass')`
+
+func (r *dmarcReportRepository) Create(ctx context.Context, report *model.DMARCReport) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin dmarc report insert: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := fmt.Sprintf(`
+		INSERT INTO dmarc_reports (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (domain_id, org_name, report_id) DO NOTHING`, dmarcReportColumns)
+
+	tag, err := tx.Exec(ctx, query,
+		report.ID, report.TeamID, report.DomainID, report.OrgName, report.ReporterEmail, report.ReportID,
+		report.PolicyDomain, report.Policy, report.DateBegin, report.DateEnd, report.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert dmarc report: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	rows := make([][]interface{}, 0, len(report.Records))
+	for i := range report.Records {
+		rec := &report.Records[i]
+		if rec.ID == uuid.Nil {
+			rec.ID = uuid.New()
+		}
+		rec.ReportID = report.ID
+		rows = append(rows, []interface{}{
+			rec.ID, rec.ReportID, rec.SourceIP, rec.MessageCount, rec.Disposition, rec.DKIMResult, rec.SPFResult,
+			rec.HeaderFrom, rec.EnvelopeFrom, rec.DKIMDomain, rec.DKIMAuthResult, rec.SPFDomain, rec.SPFAuthResult,
+		})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"dmarc_report_records"}, []string{
+		"id", "report_id", "source_ip", "message_count", "disposition", "dkim_result", "spf_result",
+		"header_from", "envelope_from", "dkim_domain", "dkim_auth_result", "spf_domain", "spf_auth_result",
+	}, pgx.CopyFromRows(rows))
+	if err != nil {
+		return false, fmt.Errorf("insert dmarc report records: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit dmarc report insert: %w", err)
+	}
+	return true, nil
+}
+
+func (r *dmarcReportRepository) ListByDomainID(ctx context.Context, domainID uuid.UUID, from, to time.Time, limit, offset int) ([]model.DMARCReport, int, error) {
+	var total int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM dmarc_reports
+		WHERE domain_id = $1 AND date_begin >= $2 AND date_begin < $3`, domainID, from, to).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count dmarc reports: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s,
+			COALESCE((SELECT SUM(rec.message_count) FROM dmarc_report_records rec WHERE rec.report_id = dmarc_reports.id), 0),
+			COALESCE((SELECT SUM(rec.message_count) FROM dmarc_report_records rec
+				WHERE rec.report_id = dmarc_reports.id AND NOT %s), 0)
+		FROM dmarc_reports
+		WHERE domain_id = $1 AND date_begin >= $2 AND date_begin < $3
+		ORDER BY date_begin DESC, created_at DESC
+		LIMIT $4 OFFSET $5`, dmarcReportColumns, dmarcPassed)
+
+	rows, err := r.pool.Query(ctx, query, domainID, from, to, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list dmarc reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.DMARCReport, error) {
+		var d model.DMARCReport
+		err := row.Scan(
+			&d.ID, &d.TeamID, &d.DomainID, &d.OrgName, &d.ReporterEmail, &d.ReportID,
+			&d.PolicyDomain, &d.Policy, &d.DateBegin, &d.DateEnd, &d.CreatedAt,
+			&d.MessageCount, &d.FailedMessages,
+		)
+		return d, err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("collect dmarc reports: %w", err)
+	}
+
+	return reports, total, nil
+}
+
+func (r *dmarcReportRepository) SourceStats(ctx context.Context, domainID uuid.UUID, from, to time.Time) ([]model.DMARCSourceStats, error) {
+	query := fmt.Sprintf(`
+		SELECT rec.source_ip,
+			SUM(rec.message_count),
+			COALESCE(SUM(rec.message_count) FILTER (WHERE %[1]s), 0),
+			COALESCE(SUM(rec.message_count) FILTER (WHERE NOT %[1]s), 0),
+			COALESCE(SUM(rec.message_count) FILTER (WHERE rec.dkim_result = 'pass'), 0),
+			COALESCE(SUM(rec.message_count) FILTER (WHERE rec.spf_result = 'pass'), 0),
+			COALESCE(array_agg(DISTINCT rec.dkim_domain) FILTER (WHERE rec.dkim_domain <> ''), '{}'),
+			COALESCE(array_agg(DISTINCT rec.spf_domain) FILTER (WHERE rec.spf_domain <> ''), '{}')
+		FROM dmarc_report_records rec
+		JOIN dmarc_reports rep ON rep.id = rec.report_id
+		WHERE rep.domain_id = $1 AND rep.date_begin >= $2 AND rep.date_begin < $3
+		GROUP BY rec.source_ip
+		ORDER BY 4 DESC, 2 DESC, rec.source_ip`, dmarcPassed)
+
+	rows, err := r.pool.Query(ctx, query, domainID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("dmarc source stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.DMARCSourceStats, error) {
+		var s model.DMARCSourceStats
+		err := row.Scan(&s.SourceIP, &s.Messages, &s.Passed, &s.Failed, &s.DKIMAligned, &s.SPFAligned, &s.DKIMDomains, &s.SPFDomains)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect dmarc source stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (r *dmarcReportRepository) DailyStats(ctx context.Context, domainID uuid.UUID, from, to time.Time) ([]model.DMARCDailyStats, error) {
+	query := fmt.Sprintf(`
+		SELECT date_trunc('day', rep.date_begin AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS day,
+			SUM(rec.message_count),
+			COALESCE(SUM(rec.message_count) FILTER (WHERE %[1]s), 0),
+			COALESCE(SUM(rec.message_count) FILTER (WHERE NOT %[1]s), 0)
+		FROM dmarc_report_records rec
+		JOIN dmarc_reports rep ON rep.id = rec.report_id
+		WHERE rep.domain_id = $1 AND rep.date_begin >= $2 AND rep.date_begin < $3
+		GROUP BY day
+		ORDER BY day ASC`, dmarcPassed)
+
+	rows, err := r.pool.Query(ctx, query, domainID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("dmarc daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.DMARCDailyStats, error) {
+		var s model.DMARCDailyStats
+		err := row.Scan(&s.Day, &s.Messages, &s.Passed, &s.Failed)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect dmarc daily stats: %w", err)
+	}
+
+	return stats, nil
+}
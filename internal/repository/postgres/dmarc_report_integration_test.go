@@ -0,0 +1,91 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func newTestDMARCReport(domainID uuid.UUID, reportID string, begin time.Time, records ...model.DMARCReportRecord) *model.DMARCReport {
+	return &model.DMARCReport{
+		ID:           uuid.New(),
+		TeamID:       testTeamID,
+		DomainID:     domainID,
+		OrgName:      "google.com",
+		ReportID:     reportID,
+		PolicyDomain: "example.com",
+		Policy:       "none",
+		DateBegin:    begin,
+		DateEnd:      begin.Add(24*time.Hour - time.Second),
+		CreatedAt:    fixedTime,
+		Records:      records,
+	}
+}
+
+func TestDMARCReportRepository(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	domain := newTestDomain()
+	require.NoError(t, NewDomainRepository(testPool).Create(ctx, domain))
+	repo := NewDMARCReportRepository(testPool)
+
+	day1 := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	aligned := model.DMARCReportRecord{SourceIP: "203.0.113.10", MessageCount: 40, DKIMResult: "pass", SPFResult: "pass", DKIMDomain: "example.com"}
+	vendor := model.DMARCReportRecord{SourceIP: "198.51.100.7", MessageCount: 5, DKIMResult: "fail", SPFResult: "fail", SPFDomain: "crm-vendor.net"}
+
+	created, err := repo.Create(ctx, newTestDMARCReport(domain.ID, "r-1", day1, aligned, vendor))
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = repo.Create(ctx, newTestDMARCReport(domain.ID, "r-2", day2, vendor))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// A resent report is ignored.
+	created, err = repo.Create(ctx, newTestDMARCReport(domain.ID, "r-1", day1, aligned))
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	from, to := day1.AddDate(0, 0, -1), day2.AddDate(0, 0, 1)
+
+	reports, total, err := repo.ListByDomainID(ctx, domain.ID, from, to, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "r-2", reports[0].ReportID)
+	assert.Equal(t, 45, reports[1].MessageCount)
+	assert.Equal(t, 5, reports[1].FailedMessages)
+
+	sources, err := repo.SourceStats(ctx, domain.ID, from, to)
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "198.51.100.7", sources[0].SourceIP)
+	assert.Equal(t, 10, sources[0].Failed)
+	assert.Equal(t, []string{"crm-vendor.net"}, sources[0].SPFDomains)
+	assert.Empty(t, sources[0].DKIMDomains)
+	assert.Equal(t, 40, sources[1].Passed)
+	assert.Equal(t, 40, sources[1].DKIMAligned)
+
+	daily, err := repo.DailyStats(ctx, domain.ID, from, to)
+	require.NoError(t, err)
+	require.Len(t, daily, 2)
+	assert.True(t, day1.Equal(daily[0].Day))
+	assert.Equal(t, 45, daily[0].Messages)
+	assert.Equal(t, 5, daily[1].Failed)
+
+	// Reports outside the range are excluded.
+	reports, total, err = repo.ListByDomainID(ctx, domain.ID, day2, to, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, reports, 1)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,28 +20,45 @@ func NewDomainRepository(pool *pgxpool.Pool) DomainRepository {
 	return &domainRepository{pool: pool}
 }
 
-const domainColumns = `id, team_id, name, status, region, dkim_private_key, dkim_selector, open_tracking, click_tracking, tls_policy, created_at, updated_at`
+const domainColumns = `id, team_id, name, status, region, dkim_private_key, dkim_selector, dkim_algorithm, open_tracking, click_tracking, tls_policy,
+	dkim_pending_selector, dkim_pending_private_key, dkim_pending_algorithm, dkim_previous_selector, dkim_previous_retire_at,
+	dkim_rotation_interval_days, dkim_rotated_at, sending_paused_at, last_checked_at, created_at, updated_at,
+	warmup_started_at`
 
 func scanDomain(row pgx.Row) (*model.Domain, error) {
 	d := &model.Domain{}
 	err := row.Scan(
 		&d.ID, &d.TeamID, &d.Name, &d.Status, &d.Region,
-		&d.DKIMPrivateKey, &d.DKIMSelector, &d.OpenTracking, &d.ClickTracking,
-		&d.TLSPolicy, &d.CreatedAt, &d.UpdatedAt,
+		&d.DKIMPrivateKey, &d.DKIMSelector, &d.DKIMAlgorithm, &d.OpenTracking, &d.ClickTracking, &d.TLSPolicy,
+		&d.DKIMPendingSelector, &d.DKIMPendingPrivateKey, &d.DKIMPendingAlgorithm, &d.DKIMPreviousSelector, &d.DKIMPreviousRetireAt,
+		&d.DKIMRotationIntervalDays, &d.DKIMRotatedAt, &d.SendingPausedAt, &d.LastCheckedAt, &d.CreatedAt, &d.UpdatedAt,
+		&d.WarmupStartedAt,
 	)
 	return d, err
 }
 
+func collectDomains(rows pgx.Rows) ([]model.Domain, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Domain, error) {
+		d, err := scanDomain(row)
+		if err != nil {
+			return model.Domain{}, err
+		}
+		return *d, nil
+	})
+}
+
 func (r *domainRepository) Create(ctx context.Context, domain *model.Domain) error {
 	query := fmt.Sprintf(`
 		INSERT INTO domains (%s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING %s`, domainColumns, domainColumns)
 
 	row := r.pool.QueryRow(ctx, query,
 		domain.ID, domain.TeamID, domain.Name, domain.Status, domain.Region,
-		domain.DKIMPrivateKey, domain.DKIMSelector, domain.OpenTracking, domain.ClickTracking,
-		domain.TLSPolicy, domain.CreatedAt, domain.UpdatedAt,
+		domain.DKIMPrivateKey, domain.DKIMSelector, dkimAlgorithm(domain.DKIMAlgorithm), domain.OpenTracking, domain.ClickTracking, domain.TLSPolicy,
+		domain.DKIMPendingSelector, domain.DKIMPendingPrivateKey, domain.DKIMPendingAlgorithm, domain.DKIMPreviousSelector, domain.DKIMPreviousRetireAt,
+		domain.DKIMRotationIntervalDays, domain.DKIMRotatedAt, domain.SendingPausedAt, domain.LastCheckedAt, domain.CreatedAt, domain.UpdatedAt,
+		domain.WarmupStartedAt,
 	)
 	scanned, err := scanDomain(row)
 	if err != nil {
@@ -89,8 +107,10 @@ func (r *domainRepository) GetByTeamAndName(ctx context.Context, teamID uuid.UUI
 	return d, nil
 }
 
+// GetVerifiedByName returns the verified domain with the given name. A
+// degraded domain was verified and still counts.
 func (r *domainRepository) GetVerifiedByName(ctx context.Context, name string) (*model.Domain, error) {
-	query := fmt.Sprintf(`SELECT %s FROM domains WHERE name = $1 AND status = 'verified' LIMIT 1`, domainColumns)
+	query := fmt.Sprintf(`SELECT %s FROM domains WHERE name = $1 AND status IN ('verified', 'degraded') LIMIT 1`, domainColumns)
 
 	d, err := scanDomain(r.pool.QueryRow(ctx, query, name))
 	if err != nil {
@@ -121,15 +141,7 @@ func (r *domainRepository) List(ctx context.Context, teamID uuid.UUID, limit, of
 	}
 	defer rows.Close()
 
-	domains, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Domain, error) {
-		var d model.Domain
-		err := row.Scan(
-			&d.ID, &d.TeamID, &d.Name, &d.Status, &d.Region,
-			&d.DKIMPrivateKey, &d.DKIMSelector, &d.OpenTracking, &d.ClickTracking,
-			&d.TLSPolicy, &d.CreatedAt, &d.UpdatedAt,
-		)
-		return d, err
-	})
+	domains, err := collectDomains(rows)
 	if err != nil {
 		return nil, 0, fmt.Errorf("collect domains: %w", err)
 	}
@@ -138,17 +150,50 @@ func (r *domainRepository) List(ctx context.Context, teamID uuid.UUID, limit, of
 }
 
 func (r *domainRepository) Update(ctx context.Context, domain *model.Domain) error {
+	return updateDomain(ctx, r.pool, domain)
+}
+
+// StartDKIMRotation saves a domain whose DKIM rotation has started and
+// creates the DNS record of its pending selector in a single transaction, so
+// that neither is left without the other.
+func (r *domainRepository) StartDKIMRotation(ctx context.Context, domain *model.Domain, record *model.DomainDNSRecord) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := createDNSRecord(ctx, tx, record); err != nil {
+		return fmt.Errorf("create domain dns record: %w", err)
+	}
+	if err := updateDomain(ctx, tx, domain); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func updateDomain(ctx context.Context, q querier, domain *model.Domain) error {
 	query := fmt.Sprintf(`
 		UPDATE domains
-		SET name = $2, status = $3, region = $4, dkim_private_key = $5, dkim_selector = $6,
-		    open_tracking = $7, click_tracking = $8, tls_policy = $9, updated_at = $10
+		SET name = $2, status = $3, region = $4, dkim_private_key = $5, dkim_selector = $6, dkim_algorithm = $7,
+		    open_tracking = $8, click_tracking = $9, tls_policy = $10,
+		    dkim_pending_selector = $11, dkim_pending_private_key = $12, dkim_pending_algorithm = $13,
+		    dkim_previous_selector = $14, dkim_previous_retire_at = $15,
+		    dkim_rotation_interval_days = $16, dkim_rotated_at = $17,
+		    sending_paused_at = $18, last_checked_at = $19, updated_at = $20,
+		    warmup_started_at = $21
 		WHERE id = $1
 		RETURNING %s`, domainColumns)
 
-	row := r.pool.QueryRow(ctx, query,
+	row := q.QueryRow(ctx, query,
 		domain.ID, domain.Name, domain.Status, domain.Region,
-		domain.DKIMPrivateKey, domain.DKIMSelector, domain.OpenTracking, domain.ClickTracking,
-		domain.TLSPolicy, domain.UpdatedAt,
+		domain.DKIMPrivateKey, domain.DKIMSelector, dkimAlgorithm(domain.DKIMAlgorithm), domain.OpenTracking, domain.ClickTracking, domain.TLSPolicy,
+		domain.DKIMPendingSelector, domain.DKIMPendingPrivateKey, domain.DKIMPendingAlgorithm,
+		domain.DKIMPreviousSelector, domain.DKIMPreviousRetireAt,
+		domain.DKIMRotationIntervalDays, domain.DKIMRotatedAt,
+		domain.SendingPausedAt, domain.LastCheckedAt, domain.UpdatedAt,
+		domain.WarmupStartedAt,
 	)
 	scanned, err := scanDomain(row)
 	if err != nil {
@@ -161,6 +206,63 @@ func (r *domainRepository) Update(ctx context.Context, domain *model.Domain) err
 	return nil
 }
 
+// ListDKIMMaintenanceDue returns the domains with DKIM rotation work to do at
+// now: a pending key awaiting verification, a previous selector due for
+// retirement, or a verified domain due for its scheduled rotation.
+func (r *domainRepository) ListDKIMMaintenanceDue(ctx context.Context, now time.Time) ([]model.Domain, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM domains
+		WHERE dkim_pending_selector IS NOT NULL
+		   OR dkim_previous_retire_at <= $1
+		   OR (status = 'verified' AND dkim_rotation_interval_days IS NOT NULL AND dkim_previous_selector IS NULL
+		       AND COALESCE(dkim_rotated_at, created_at) + make_interval(days => dkim_rotation_interval_days) <= $1)
+		ORDER BY created_at ASC`, domainColumns)
+
+	rows, err := r.pool.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("list domains due for dkim maintenance: %w", err)
+	}
+	defer rows.Close()
+
+	domains, err := collectDomains(rows)
+	if err != nil {
+		return nil, fmt.Errorf("collect domains: %w", err)
+	}
+	return domains, nil
+}
+
+// ListMonitoringDue returns the verified and degraded domains whose DNS was
+// last checked at or before checkedBefore, or never, least recently checked
+// first.
+func (r *domainRepository) ListMonitoringDue(ctx context.Context, checkedBefore time.Time) ([]model.Domain, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM domains
+		WHERE status IN ('verified', 'degraded')
+		  AND (last_checked_at IS NULL OR last_checked_at <= $1)
+		ORDER BY last_checked_at ASC NULLS FIRST`, domainColumns)
+
+	rows, err := r.pool.Query(ctx, query, checkedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("list domains due for monitoring: %w", err)
+	}
+	defer rows.Close()
+
+	domains, err := collectDomains(rows)
+	if err != nil {
+		return nil, fmt.Errorf("collect domains: %w", err)
+	}
+	return domains, nil
+}
+
+// dkimAlgorithm defaults an unset DKIM algorithm to RSA, the algorithm of
+// keys created before Ed25519 support.
+func dkimAlgorithm(algorithm string) string {
+	if algorithm == "" {
+		return "rsa"
+	}
+	return algorithm
+}
+
 func (r *domainRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM domains WHERE id = $1`
 
@@ -188,12 +290,16 @@ func NewDomainDNSRecordRepository(pool *pgxpool.Pool) DomainDNSRecordRepository
 const dnsRecordColumns = `id, domain_id, record_type, dns_type, name, value, priority, status, last_checked_at, created_at, updated_at`
 
 func (r *domainDNSRecordRepository) Create(ctx context.Context, record *model.DomainDNSRecord) error {
+	return createDNSRecord(ctx, r.pool, record)
+}
+
+func createDNSRecord(ctx context.Context, q querier, record *model.DomainDNSRecord) error {
 	query := fmt.Sprintf(`
 		INSERT INTO domain_dns_records (%s)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING %s`, dnsRecordColumns, dnsRecordColumns)
 
-	return r.pool.QueryRow(ctx, query,
+	return q.QueryRow(ctx, query,
 		record.ID, record.DomainID, record.RecordType, record.DNSType, record.Name, record.Value,
 		record.Priority, record.Status, record.LastCheckedAt, record.CreatedAt, record.UpdatedAt,
 	).Scan(
@@ -247,6 +353,19 @@ func (r *domainDNSRecordRepository) Update(ctx context.Context, record *model.Do
 	return nil
 }
 
+func (r *domainDNSRecordRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM domain_dns_records WHERE id = $1`
+
+	result, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete domain dns record: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("domain dns record")
+	}
+	return nil
+}
+
 func (r *domainDNSRecordRepository) DeleteByDomainID(ctx context.Context, domainID uuid.UUID) error {
 	query := `DELETE FROM domain_dns_records WHERE domain_id = $1`
 
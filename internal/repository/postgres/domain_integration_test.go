@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -118,3 +119,132 @@ func TestDomainRepository_Delete(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, ErrNotFound))
 }
+
+func TestDomainRepository_ListDKIMMaintenanceDue(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewDomainRepository(testPool)
+	now := fixedTime.AddDate(1, 0, 0)
+	interval := 90
+
+	idle := newTestDomain()
+	idle.Name = "idle.example.com"
+	require.NoError(t, repo.Create(ctx, idle))
+
+	rotationDue := newTestDomain()
+	rotationDue.ID = uuid.New()
+	rotationDue.Name = "due.example.com"
+	rotationDue.Status = model.DomainStatusVerified
+	rotationDue.DKIMRotationIntervalDays = &interval
+	require.NoError(t, repo.Create(ctx, rotationDue))
+
+	recentlyRotated := newTestDomain()
+	recentlyRotated.ID = uuid.New()
+	recentlyRotated.Name = "recent.example.com"
+	recentlyRotated.Status = model.DomainStatusVerified
+	recentlyRotated.DKIMRotationIntervalDays = &interval
+	rotatedAt := now.AddDate(0, 0, -1)
+	recentlyRotated.DKIMRotatedAt = &rotatedAt
+	require.NoError(t, repo.Create(ctx, recentlyRotated))
+
+	pending := newTestDomain()
+	pending.ID = uuid.New()
+	pending.Name = "pending.example.com"
+	pendingKey := "pending-key"
+	pending.StartDKIMRotation("mailit-2", "ed25519", pendingKey, "v=DKIM1; k=ed25519; p=x", now)
+	require.NoError(t, repo.Create(ctx, pending))
+
+	domains, err := repo.ListDKIMMaintenanceDue(ctx, now)
+	require.NoError(t, err)
+	names := make([]string, 0, len(domains))
+	for _, d := range domains {
+		names = append(names, d.Name)
+	}
+	assert.ElementsMatch(t, []string{"due.example.com", "pending.example.com"}, names)
+
+	got, err := repo.GetByID(ctx, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rsa", got.DKIMAlgorithm)
+	assert.Equal(t, "mailit-2", *got.DKIMPendingSelector)
+	assert.Equal(t, "ed25519", *got.DKIMPendingAlgorithm)
+	assert.Equal(t, pendingKey, *got.DKIMPendingPrivateKey)
+}
+
+func TestDomainRepository_StartDKIMRotation(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewDomainRepository(testPool)
+	dnsRepo := NewDomainDNSRecordRepository(testPool)
+
+	domain := newTestDomain()
+	require.NoError(t, repo.Create(ctx, domain))
+
+	record := domain.StartDKIMRotation("mailit-2", "ed25519", "pending-key", "v=DKIM1; k=ed25519; p=x", fixedTime)
+	require.NoError(t, repo.StartDKIMRotation(ctx, domain, &record))
+
+	got, err := repo.GetByID(ctx, domain.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "mailit-2", *got.DKIMPendingSelector)
+	records, err := dnsRepo.ListByDomainID(ctx, domain.ID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "mailit-2._domainkey."+domain.Name, records[0].Name)
+
+	// A failed domain update doesn't leave the new record behind.
+	missing := newTestDomain()
+	missing.ID = uuid.New()
+	orphan := missing.StartDKIMRotation("mailit-3", "rsa", "key", "v=DKIM1; p=y", fixedTime)
+	orphan.DomainID = domain.ID
+	err = repo.StartDKIMRotation(ctx, missing, &orphan)
+	assert.ErrorIs(t, err, ErrNotFound)
+	records, err = dnsRepo.ListByDomainID(ctx, domain.ID)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestDomainRepository_ListMonitoringDue(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewDomainRepository(testPool)
+	checkedBefore := fixedTime.Add(-6 * time.Hour)
+
+	pending := newTestDomain()
+	pending.Name = "pending.example.com"
+	require.NoError(t, repo.Create(ctx, pending))
+
+	neverChecked := newTestDomain()
+	neverChecked.ID = uuid.New()
+	neverChecked.Name = "never.example.com"
+	neverChecked.Status = model.DomainStatusVerified
+	require.NoError(t, repo.Create(ctx, neverChecked))
+
+	stale := newTestDomain()
+	stale.ID = uuid.New()
+	stale.Name = "stale.example.com"
+	stale.Status = model.DomainStatusDegraded
+	staleCheck := checkedBefore.Add(-time.Hour)
+	stale.LastCheckedAt = &staleCheck
+	stale.SendingPausedAt = &staleCheck
+	require.NoError(t, repo.Create(ctx, stale))
+
+	fresh := newTestDomain()
+	fresh.ID = uuid.New()
+	fresh.Name = "fresh.example.com"
+	fresh.Status = model.DomainStatusVerified
+	fresh.LastCheckedAt = &fixedTime
+	require.NoError(t, repo.Create(ctx, fresh))
+
+	domains, err := repo.ListMonitoringDue(ctx, checkedBefore)
+	require.NoError(t, err)
+	require.Len(t, domains, 2)
+	assert.Equal(t, "never.example.com", domains[0].Name)
+	assert.Equal(t, "stale.example.com", domains[1].Name)
+	require.NotNil(t, domains[1].SendingPausedAt)
+	assert.True(t, staleCheck.Equal(*domains[1].SendingPausedAt))
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -24,7 +25,7 @@ const emailColumns = `id, team_id, domain_id, from_address, to_addresses,
 	html_body, text_body, status, scheduled_at, sent_at,
 	delivered_at, tags, headers, attachments,
 	idempotency_key, message_id, last_error, retry_count,
-	created_at, updated_at`
+	created_at, updated_at, broadcast_id, broadcast_variant_id`
 
 func scanEmail(row pgx.Row) (*model.Email, error) {
 	e := &model.Email{}
@@ -34,7 +35,7 @@ func scanEmail(row pgx.Row) (*model.Email, error) {
 		&e.HTMLBody, &e.TextBody, &e.Status, &e.ScheduledAt, &e.SentAt,
 		&e.DeliveredAt, &e.Tags, &e.Headers, &e.Attachments,
 		&e.IdempotencyKey, &e.MessageID, &e.LastError, &e.RetryCount,
-		&e.CreatedAt, &e.UpdatedAt,
+		&e.CreatedAt, &e.UpdatedAt, &e.BroadcastID, &e.BroadcastVariantID,
 	)
 	return e, err
 }
@@ -47,7 +48,7 @@ func scanEmailValue(row pgx.CollectableRow) (model.Email, error) {
 		&e.HTMLBody, &e.TextBody, &e.Status, &e.ScheduledAt, &e.SentAt,
 		&e.DeliveredAt, &e.Tags, &e.Headers, &e.Attachments,
 		&e.IdempotencyKey, &e.MessageID, &e.LastError, &e.RetryCount,
-		&e.CreatedAt, &e.UpdatedAt,
+		&e.CreatedAt, &e.UpdatedAt, &e.BroadcastID, &e.BroadcastVariantID,
 	)
 	return e, err
 }
@@ -55,7 +56,7 @@ func scanEmailValue(row pgx.CollectableRow) (model.Email, error) {
 func (r *emailRepository) Create(ctx context.Context, email *model.Email) error {
 	query := fmt.Sprintf(`
 		INSERT INTO emails (%s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
 		RETURNING %s`, emailColumns, emailColumns)
 
 	row := r.pool.QueryRow(ctx, query,
@@ -64,7 +65,7 @@ func (r *emailRepository) Create(ctx context.Context, email *model.Email) error
 		email.HTMLBody, email.TextBody, email.Status, email.ScheduledAt, email.SentAt,
 		email.DeliveredAt, email.Tags, email.Headers, email.Attachments,
 		email.IdempotencyKey, email.MessageID, email.LastError, email.RetryCount,
-		email.CreatedAt, email.UpdatedAt,
+		email.CreatedAt, email.UpdatedAt, email.BroadcastID, email.BroadcastVariantID,
 	)
 	scanned, err := scanEmail(row)
 	if err != nil {
@@ -156,6 +157,114 @@ func (r *emailRepository) Update(ctx context.Context, email *model.Email) error
 	return nil
 }
 
+// ReleaseHeld moves up to limit of a broadcast's held emails to the send
+// queue, oldest first, and returns their IDs.
+func (r *emailRepository) ReleaseHeld(ctx context.Context, broadcastID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		UPDATE emails SET status = 'queued', updated_at = NOW()
+		WHERE id IN (
+		    SELECT id FROM emails
+		    WHERE broadcast_id = $1 AND status = 'held'
+		    ORDER BY created_at, id
+		    LIMIT $2
+		    FOR UPDATE SKIP LOCKED)
+		RETURNING id`
+
+	rows, err := r.pool.Query(ctx, query, broadcastID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("release held emails: %w", err)
+	}
+	defer rows.Close()
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("collect released emails: %w", err)
+	}
+	return ids, nil
+}
+
+// HoldQueued moves the given emails back on hold if they are still queued.
+func (r *emailRepository) HoldQueued(ctx context.Context, ids []uuid.UUID) error {
+	query := `UPDATE emails SET status = 'held', updated_at = NOW() WHERE id = ANY($1) AND status = 'queued'`
+
+	if _, err := r.pool.Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("hold queued emails: %w", err)
+	}
+	return nil
+}
+
+// UpdateStatusByBroadcast moves a broadcast's emails whose status is one of
+// from to status to, and returns how many were moved.
+func (r *emailRepository) UpdateStatusByBroadcast(ctx context.Context, broadcastID uuid.UUID, from []string, to string) (int64, error) {
+	query := `
+		UPDATE emails SET status = $3, updated_at = NOW()
+		WHERE broadcast_id = $1 AND status = ANY($2)`
+
+	result, err := r.pool.Exec(ctx, query, broadcastID, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("update email status by broadcast: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// CountByBroadcast counts a broadcast's emails whose status is one of
+// statuses.
+func (r *emailRepository) CountByBroadcast(ctx context.Context, broadcastID uuid.UUID, statuses []string) (int, error) {
+	query := `SELECT COUNT(*) FROM emails WHERE broadcast_id = $1 AND status = ANY($2)`
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, broadcastID, statuses).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count emails by broadcast: %w", err)
+	}
+	return count, nil
+}
+
+// LastSentAtByBroadcast returns when the last of a broadcast's emails was
+// sent, or nil if none has been.
+func (r *emailRepository) LastSentAtByBroadcast(ctx context.Context, broadcastID uuid.UUID) (*time.Time, error) {
+	query := `SELECT MAX(sent_at) FROM emails WHERE broadcast_id = $1`
+
+	var sentAt *time.Time
+	if err := r.pool.QueryRow(ctx, query, broadcastID).Scan(&sentAt); err != nil {
+		return nil, fmt.Errorf("last sent at by broadcast: %w", err)
+	}
+	return sentAt, nil
+}
+
+// ListAddressesByBroadcast returns those of addresses that one of a
+// broadcast's emails was sent to.
+func (r *emailRepository) ListAddressesByBroadcast(ctx context.Context, broadcastID uuid.UUID, addresses []string) ([]string, error) {
+	query := `
+		SELECT DISTINCT to_addresses[1] FROM emails
+		WHERE broadcast_id = $1 AND to_addresses[1] = ANY($2)`
+
+	rows, err := r.pool.Query(ctx, query, broadcastID, addresses)
+	if err != nil {
+		return nil, fmt.Errorf("list addresses by broadcast: %w", err)
+	}
+	defer rows.Close()
+
+	found, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("collect addresses by broadcast: %w", err)
+	}
+	return found, nil
+}
+
+// CountOutgoingByDomain counts the emails from a domain that were sent since
+// the given time, or are waiting in the send queue.
+func (r *emailRepository) CountOutgoingByDomain(ctx context.Context, domainID uuid.UUID, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM emails
+		WHERE domain_id = $1 AND (sent_at >= $2 OR status IN ('queued', 'sending'))`
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, domainID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count outgoing emails by domain: %w", err)
+	}
+	return count, nil
+}
+
 // --- EmailEventRepository ---
 
 type emailEventRepository struct {
@@ -5,6 +5,7 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -124,3 +125,94 @@ func TestEmailRepository_UpdateStatus(t *testing.T) {
 	assert.Equal(t, model.EmailStatusSent, got.Status)
 	assert.NotNil(t, got.SentAt)
 }
+
+func TestEmailRepository_HeldBroadcastEmails(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewEmailRepository(testPool)
+	broadcastRepo := NewBroadcastRepository(testPool)
+	var broadcasts []*model.Broadcast
+	for _, name := range []string{"Launch", "Other"} {
+		b := &model.Broadcast{ID: uuid.New(), TeamID: testTeamID, Name: name, Status: model.BroadcastStatusSending, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+		require.NoError(t, broadcastRepo.Create(ctx, b))
+		broadcasts = append(broadcasts, b)
+	}
+	broadcastID := broadcasts[0].ID
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		email := newTestEmail()
+		email.ID = uuid.New()
+		email.Status = model.EmailStatusHeld
+		email.BroadcastID = &broadcastID
+		email.ToAddresses = []string{fmt.Sprintf("r%d@example.com", i)}
+		email.CreatedAt = fixedTime.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.Create(ctx, email))
+		ids = append(ids, email.ID)
+	}
+	other := newTestEmail()
+	other.ID = uuid.New()
+	other.Status = model.EmailStatusHeld
+	other.BroadcastID = &broadcasts[1].ID
+	other.ToAddresses = []string{"other@example.com"}
+	require.NoError(t, repo.Create(ctx, other))
+
+	// Only addresses the broadcast itself has an email to.
+	addresses, err := repo.ListAddressesByBroadcast(ctx, broadcastID, []string{"r0@example.com", "r2@example.com", "other@example.com", "new@example.com"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"r0@example.com", "r2@example.com"}, addresses)
+
+	// Oldest first, and only the broadcast's own emails.
+	released, err := repo.ReleaseHeld(ctx, broadcastID, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids[:2], released)
+
+	count, err := repo.CountByBroadcast(ctx, broadcastID, []string{model.EmailStatusQueued})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Pausing holds the queued emails again.
+	moved, err := repo.UpdateStatusByBroadcast(ctx, broadcastID, []string{model.EmailStatusQueued}, model.EmailStatusHeld)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), moved)
+
+	count, err = repo.CountByBroadcast(ctx, broadcastID, []string{model.EmailStatusHeld})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Emails whose send task couldn't be queued go back on hold.
+	released, err = repo.ReleaseHeld(ctx, broadcastID, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.HoldQueued(ctx, released))
+	count, err = repo.CountByBroadcast(ctx, broadcastID, []string{model.EmailStatusHeld})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// None of the broadcast's emails is sent yet.
+	lastSent, err := repo.LastSentAtByBroadcast(ctx, broadcastID)
+	require.NoError(t, err)
+	assert.Nil(t, lastSent)
+
+	got, err := repo.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.EmailStatusHeld, got.Status)
+
+	// The last sent email of the broadcast, not of another one.
+	for i, id := range ids[:2] {
+		sent, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		sentAt := fixedTime.Add(time.Duration(i+1) * time.Hour)
+		sent.Status, sent.SentAt = model.EmailStatusSent, &sentAt
+		require.NoError(t, repo.Update(ctx, sent))
+	}
+	otherSentAt := fixedTime.Add(5 * time.Hour)
+	got.Status, got.SentAt = model.EmailStatusSent, &otherSentAt
+	require.NoError(t, repo.Update(ctx, got))
+
+	lastSent, err = repo.LastSentAtByBroadcast(ctx, broadcastID)
+	require.NoError(t, err)
+	require.NotNil(t, lastSent)
+	assert.True(t, fixedTime.Add(2*time.Hour).Equal(*lastSent))
+}
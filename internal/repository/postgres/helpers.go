@@ -1,12 +1,20 @@
 package postgres
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
 )
 
+// querier runs a single-row query. It is implemented by both the pool and a
+// transaction, so a statement can be shared by plain and transactional
+// writes.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // ErrNotFound is returned when a database query returns no rows.
 var ErrNotFound = errors.New("record not found")
 
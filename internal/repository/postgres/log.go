@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,56 +21,98 @@ func NewLogRepository(pool *pgxpool.Pool) LogRepository {
 	return &logRepository{pool: pool}
 }
 
-const logColumns = `id, team_id, level, message, metadata, request_id, method, path, status_code, duration_ms, ip_address, created_at`
+const logColumns = `id, team_id, level, message, metadata, request_id, method, path, status_code, duration_ms, ip_address, api_key_id, request_body, response_body, created_at`
 
 func (r *logRepository) Create(ctx context.Context, log *model.Log) error {
 	query := fmt.Sprintf(`
 		INSERT INTO logs (%s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING %s`, logColumns, logColumns)
 
 	return r.pool.QueryRow(ctx, query,
 		log.ID, log.TeamID, log.Level, log.Message, log.Metadata, log.RequestID,
-		log.Method, log.Path, log.StatusCode, log.DurationMs, log.IPAddress, log.CreatedAt,
+		log.Method, log.Path, log.StatusCode, log.DurationMs, log.IPAddress,
+		log.APIKeyID, log.RequestBody, log.ResponseBody, log.CreatedAt,
 	).Scan(
 		&log.ID, &log.TeamID, &log.Level, &log.Message, &log.Metadata, &log.RequestID,
-		&log.Method, &log.Path, &log.StatusCode, &log.DurationMs, &log.IPAddress, &log.CreatedAt,
+		&log.Method, &log.Path, &log.StatusCode, &log.DurationMs, &log.IPAddress,
+		&log.APIKeyID, &log.RequestBody, &log.ResponseBody, &log.CreatedAt,
 	)
 }
 
-func (r *logRepository) List(ctx context.Context, teamID uuid.UUID, level string, limit, offset int) ([]model.Log, int, error) {
-	var countQuery string
-	var dataQuery string
-	var countArgs []interface{}
-	var dataArgs []interface{}
-
-	if level != "" {
-		countQuery = `SELECT COUNT(*) FROM logs WHERE team_id = $1 AND level = $2`
-		countArgs = []interface{}{teamID, level}
-
-		dataQuery = fmt.Sprintf(`
-			SELECT %s FROM logs WHERE team_id = $1 AND level = $2
-			ORDER BY created_at DESC
-			LIMIT $3 OFFSET $4`, logColumns)
-		dataArgs = []interface{}{teamID, level, limit, offset}
-	} else {
-		countQuery = `SELECT COUNT(*) FROM logs WHERE team_id = $1`
-		countArgs = []interface{}{teamID}
-
-		dataQuery = fmt.Sprintf(`
-			SELECT %s FROM logs WHERE team_id = $1
-			ORDER BY created_at DESC
-			LIMIT $2 OFFSET $3`, logColumns)
-		dataArgs = []interface{}{teamID, limit, offset}
+func (r *logRepository) CreateBatch(ctx context.Context, logs []model.Log) error {
+	rows := make([][]interface{}, 0, len(logs))
+	for _, l := range logs {
+		rows = append(rows, []interface{}{
+			l.ID, l.TeamID, l.Level, l.Message, l.Metadata, l.RequestID,
+			l.Method, l.Path, l.StatusCode, l.DurationMs, l.IPAddress,
+			l.APIKeyID, l.RequestBody, l.ResponseBody, l.CreatedAt,
+		})
 	}
 
+	_, err := r.pool.CopyFrom(ctx, pgx.Identifier{"logs"}, strings.Split(logColumns, ", "), pgx.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("insert logs: %w", err)
+	}
+	return nil
+}
+
+// LogFilter narrows the logs returned by List. Zero values match everything.
+type LogFilter struct {
+	Level      string
+	Method     string
+	PathPrefix string
+	StatusFrom int // inclusive lower bound of the status code
+	StatusTo   int // inclusive upper bound of the status code
+	APIKeyID   *uuid.UUID
+	RequestID  string
+}
+
+func (r *logRepository) List(ctx context.Context, teamID uuid.UUID, filter LogFilter, limit, offset int) ([]model.Log, int, error) {
+	where := "team_id = $1"
+	args := []interface{}{teamID}
+	if filter.Level != "" {
+		args = append(args, filter.Level)
+		where += fmt.Sprintf(" AND level = $%d", len(args))
+	}
+	if filter.Method != "" {
+		args = append(args, filter.Method)
+		where += fmt.Sprintf(" AND method = $%d", len(args))
+	}
+	if filter.PathPrefix != "" {
+		args = append(args, filter.PathPrefix)
+		where += fmt.Sprintf(" AND starts_with(path, $%d)", len(args))
+	}
+	if filter.StatusFrom > 0 {
+		args = append(args, filter.StatusFrom)
+		where += fmt.Sprintf(" AND status_code >= $%d", len(args))
+	}
+	if filter.StatusTo > 0 {
+		args = append(args, filter.StatusTo)
+		where += fmt.Sprintf(" AND status_code <= $%d", len(args))
+	}
+	if filter.APIKeyID != nil {
+		args = append(args, *filter.APIKeyID)
+		where += fmt.Sprintf(" AND api_key_id = $%d", len(args))
+	}
+	if filter.RequestID != "" {
+		args = append(args, filter.RequestID)
+		where += fmt.Sprintf(" AND request_id = $%d", len(args))
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM logs WHERE %s`, where)
 	var total int
-	err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total)
+	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("count logs: %w", err)
 	}
 
-	rows, err := r.pool.Query(ctx, dataQuery, dataArgs...)
+	query := fmt.Sprintf(`
+		SELECT %s FROM logs WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, logColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("list logs: %w", err)
 	}
@@ -78,7 +122,8 @@ func (r *logRepository) List(ctx context.Context, teamID uuid.UUID, level string
 		var l model.Log
 		err := row.Scan(
 			&l.ID, &l.TeamID, &l.Level, &l.Message, &l.Metadata, &l.RequestID,
-			&l.Method, &l.Path, &l.StatusCode, &l.DurationMs, &l.IPAddress, &l.CreatedAt,
+			&l.Method, &l.Path, &l.StatusCode, &l.DurationMs, &l.IPAddress,
+			&l.APIKeyID, &l.RequestBody, &l.ResponseBody, &l.CreatedAt,
 		)
 		return l, err
 	})
@@ -88,3 +133,13 @@ func (r *logRepository) List(ctx context.Context, teamID uuid.UUID, level string
 
 	return logs, total, nil
 }
+
+func (r *logRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM logs WHERE created_at < $1`
+
+	result, err := r.pool.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete old logs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -21,8 +21,10 @@ type UserRepository interface {
 // TeamRepository defines persistence operations for teams.
 type TeamRepository interface {
 	Create(ctx context.Context, team *model.Team) error
+	CreateWithOwner(ctx context.Context, team *model.Team, owner *model.TeamMember) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Team, error)
 	GetBySlug(ctx context.Context, slug string) (*model.Team, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserTeam, error)
 }
 
 // TeamMemberRepository defines persistence operations for team members.
@@ -30,6 +32,11 @@ type TeamMemberRepository interface {
 	Create(ctx context.Context, member *model.TeamMember) error
 	ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.TeamMember, error)
 	GetByTeamAndUser(ctx context.Context, teamID, userID uuid.UUID) (*model.TeamMember, error)
+	GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.TeamMember, error)
+	UpdateRole(ctx context.Context, teamID, id uuid.UUID, role string) error
+	Delete(ctx context.Context, teamID, id uuid.UUID) error
+	TransferOwnership(ctx context.Context, teamID, fromID, toID uuid.UUID) error
+	ListEmailsByTeamID(ctx context.Context, teamID uuid.UUID) ([]string, error)
 }
 
 // EmailRepository defines persistence operations for emails.
@@ -39,6 +46,13 @@ type EmailRepository interface {
 	GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.Email, error)
 	List(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.Email, int, error)
 	Update(ctx context.Context, email *model.Email) error
+	ReleaseHeld(ctx context.Context, broadcastID uuid.UUID, limit int) ([]uuid.UUID, error)
+	HoldQueued(ctx context.Context, ids []uuid.UUID) error
+	UpdateStatusByBroadcast(ctx context.Context, broadcastID uuid.UUID, from []string, to string) (int64, error)
+	CountByBroadcast(ctx context.Context, broadcastID uuid.UUID, statuses []string) (int, error)
+	LastSentAtByBroadcast(ctx context.Context, broadcastID uuid.UUID) (*time.Time, error)
+	ListAddressesByBroadcast(ctx context.Context, broadcastID uuid.UUID, addresses []string) ([]string, error)
+	CountOutgoingByDomain(ctx context.Context, domainID uuid.UUID, since time.Time) (int, error)
 }
 
 // EmailEventRepository defines persistence operations for email events.
@@ -55,7 +69,10 @@ type DomainRepository interface {
 	GetByTeamAndName(ctx context.Context, teamID uuid.UUID, name string) (*model.Domain, error)
 	GetVerifiedByName(ctx context.Context, name string) (*model.Domain, error)
 	List(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.Domain, int, error)
+	ListDKIMMaintenanceDue(ctx context.Context, now time.Time) ([]model.Domain, error)
+	ListMonitoringDue(ctx context.Context, checkedBefore time.Time) ([]model.Domain, error)
 	Update(ctx context.Context, domain *model.Domain) error
+	StartDKIMRotation(ctx context.Context, domain *model.Domain, record *model.DomainDNSRecord) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -64,6 +81,7 @@ type DomainDNSRecordRepository interface {
 	Create(ctx context.Context, record *model.DomainDNSRecord) error
 	ListByDomainID(ctx context.Context, domainID uuid.UUID) ([]model.DomainDNSRecord, error)
 	Update(ctx context.Context, record *model.DomainDNSRecord) error
+	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByDomainID(ctx context.Context, domainID uuid.UUID) error
 }
 
@@ -91,6 +109,9 @@ type ContactRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Contact, error)
 	GetByAudienceAndID(ctx context.Context, audienceID, id uuid.UUID) (*model.Contact, error)
 	GetByAudienceAndEmail(ctx context.Context, audienceID uuid.UUID, email string) (*model.Contact, error)
+	// ListByTeamAndEmail returns the contacts with the given address in every
+	// audience of the team, matching the address case-insensitively.
+	ListByTeamAndEmail(ctx context.Context, teamID uuid.UUID, email string) ([]model.Contact, error)
 	List(ctx context.Context, audienceID uuid.UUID, limit, offset int) ([]model.Contact, int, error)
 	ListBySegmentID(ctx context.Context, segmentID uuid.UUID, limit, offset int) ([]model.Contact, int, error)
 	Update(ctx context.Context, contact *model.Contact) error
@@ -107,6 +128,14 @@ type ContactPropertyRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// ContactPropertyValueRepository defines persistence operations for custom property values on contacts.
+type ContactPropertyValueRepository interface {
+	ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactPropertyValue, error)
+	ListByContactIDs(ctx context.Context, contactIDs []uuid.UUID) ([]model.ContactPropertyValue, error)
+	Upsert(ctx context.Context, value *model.ContactPropertyValue) error
+	Delete(ctx context.Context, contactID, propertyID uuid.UUID) error
+}
+
 // TopicRepository defines persistence operations for topics.
 type TopicRepository interface {
 	Create(ctx context.Context, topic *model.Topic) error
@@ -117,6 +146,13 @@ type TopicRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// ContactTopicRepository defines persistence operations for contact topic subscriptions.
+type ContactTopicRepository interface {
+	ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactTopic, error)
+	ListByTopicAndContacts(ctx context.Context, topicID uuid.UUID, contactIDs []uuid.UUID) ([]model.ContactTopic, error)
+	Upsert(ctx context.Context, ct *model.ContactTopic) error
+}
+
 // SegmentRepository defines persistence operations for segments.
 type SegmentRepository interface {
 	Create(ctx context.Context, segment *model.Segment) error
@@ -125,6 +161,11 @@ type SegmentRepository interface {
 	ListByAudienceID(ctx context.Context, audienceID uuid.UUID) ([]model.Segment, error)
 	Update(ctx context.Context, segment *model.Segment) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// RefreshMembers recomputes segment_contacts from the segment's conditions
+	// and returns the resulting member count.
+	RefreshMembers(ctx context.Context, segmentID uuid.UUID) (int, error)
+	// RefreshContact re-evaluates every segment of the contact's audience for that contact.
+	RefreshContact(ctx context.Context, contactID uuid.UUID) error
 }
 
 // TemplateRepository defines persistence operations for templates.
@@ -142,6 +183,7 @@ type TemplateVersionRepository interface {
 	Create(ctx context.Context, version *model.TemplateVersion) error
 	GetLatestByTemplateID(ctx context.Context, templateID uuid.UUID) (*model.TemplateVersion, error)
 	GetPublishedByTemplateID(ctx context.Context, templateID uuid.UUID) (*model.TemplateVersion, error)
+	GetByTemplateIDAndVersion(ctx context.Context, templateID uuid.UUID, version int) (*model.TemplateVersion, error)
 	ListByTemplateID(ctx context.Context, templateID uuid.UUID) ([]model.TemplateVersion, error)
 	Publish(ctx context.Context, versionID uuid.UUID) error
 }
@@ -153,9 +195,24 @@ type BroadcastRepository interface {
 	GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.Broadcast, error)
 	List(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.Broadcast, int, error)
 	Update(ctx context.Context, broadcast *model.Broadcast) error
+	ListDue(ctx context.Context, now time.Time) ([]model.Broadcast, error)
+	ListReleasable(ctx context.Context) ([]model.Broadcast, error)
+	AddRecipients(ctx context.Context, id uuid.UUID, n int) (*model.Broadcast, error)
+	IncrementSentCount(ctx context.Context, id uuid.UUID) error
+	GetStats(ctx context.Context, id uuid.UUID) (*model.BroadcastStats, error)
+	ListLinkStats(ctx context.Context, id uuid.UUID) ([]model.BroadcastLinkStats, error)
+	ListRecipients(ctx context.Context, id uuid.UUID, status, event, search string, limit, offset int) ([]model.BroadcastRecipient, int, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// BroadcastVariantRepository defines persistence operations for the A/B test
+// variants of broadcasts.
+type BroadcastVariantRepository interface {
+	ListByBroadcastID(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariant, error)
+	ReplaceForBroadcast(ctx context.Context, broadcastID uuid.UUID, variants []model.BroadcastVariant) error
+	ListStats(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariantStats, error)
+}
+
 // WebhookRepository defines persistence operations for webhooks.
 type WebhookRepository interface {
 	Create(ctx context.Context, webhook *model.Webhook) error
@@ -163,6 +220,8 @@ type WebhookRepository interface {
 	GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.Webhook, error)
 	ListByTeamID(ctx context.Context, teamID uuid.UUID) ([]model.Webhook, error)
 	Update(ctx context.Context, webhook *model.Webhook) error
+	RecordDelivery(ctx context.Context, id uuid.UUID, delivered bool, at time.Time) (*model.Webhook, error)
+	Disable(ctx context.Context, id uuid.UUID, reason string, at time.Time) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -171,7 +230,7 @@ type WebhookEventRepository interface {
 	Create(ctx context.Context, event *model.WebhookEvent) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.WebhookEvent, error)
 	Update(ctx context.Context, event *model.WebhookEvent) error
-	ListByWebhookID(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]model.WebhookEvent, int, error)
+	ListByWebhookID(ctx context.Context, webhookID uuid.UUID, filter WebhookEventFilter, limit, offset int) ([]model.WebhookEvent, int, error)
 	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
 }
 
@@ -179,7 +238,12 @@ type WebhookEventRepository interface {
 type SuppressionRepository interface {
 	Create(ctx context.Context, entry *model.SuppressionEntry) error
 	GetByTeamAndEmail(ctx context.Context, teamID uuid.UUID, email string) (*model.SuppressionEntry, error)
-	ListByTeamID(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.SuppressionEntry, int, error)
+	GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.SuppressionEntry, error)
+	// ListByTeamID lists entries newest first. An empty search or reason disables that filter.
+	ListByTeamID(ctx context.Context, teamID uuid.UUID, search, reason string, limit, offset int) ([]model.SuppressionEntry, int, error)
+	// CreateBatch inserts entries, skipping addresses already suppressed, and
+	// returns the number of rows inserted.
+	CreateBatch(ctx context.Context, entries []*model.SuppressionEntry) (int, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -192,10 +256,25 @@ type InboundEmailRepository interface {
 	Update(ctx context.Context, email *model.InboundEmail) error
 }
 
+// DMARCReportRepository defines persistence operations for DMARC aggregate reports.
+type DMARCReportRepository interface {
+	// Create stores a report with its records. It returns false, and stores
+	// nothing, when the domain already has the reporter's report.
+	Create(ctx context.Context, report *model.DMARCReport) (bool, error)
+	// ListByDomainID lists reports beginning in [from, to), newest first,
+	// with their message totals.
+	ListByDomainID(ctx context.Context, domainID uuid.UUID, from, to time.Time, limit, offset int) ([]model.DMARCReport, int, error)
+	SourceStats(ctx context.Context, domainID uuid.UUID, from, to time.Time) ([]model.DMARCSourceStats, error)
+	DailyStats(ctx context.Context, domainID uuid.UUID, from, to time.Time) ([]model.DMARCDailyStats, error)
+}
+
 // LogRepository defines persistence operations for logs.
 type LogRepository interface {
 	Create(ctx context.Context, log *model.Log) error
-	List(ctx context.Context, teamID uuid.UUID, level string, limit, offset int) ([]model.Log, int, error)
+	// CreateBatch inserts many logs at once.
+	CreateBatch(ctx context.Context, logs []model.Log) error
+	List(ctx context.Context, teamID uuid.UUID, filter LogFilter, limit, offset int) ([]model.Log, int, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
 }
 
 // SettingsRepository defines read operations for the settings page.
@@ -203,6 +282,7 @@ type SettingsRepository interface {
 	GetUsageCounts(ctx context.Context, teamID uuid.UUID) (*dto.UsageResponse, error)
 	GetTeamWithMembers(ctx context.Context, teamID uuid.UUID) (*dto.TeamResponse, error)
 	UpdateTeamName(ctx context.Context, teamID uuid.UUID, name string) error
+	UpdateSandboxMode(ctx context.Context, teamID uuid.UUID, enabled bool) error
 }
 
 // TeamInvitationRepository defines persistence operations for team invitations.
@@ -117,3 +117,141 @@ func (r *segmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+func (r *segmentRepository) RefreshMembers(ctx context.Context, segmentID uuid.UUID) (int, error) {
+	segment, err := r.GetByID(ctx, segmentID)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin segment refresh: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.syncMembers(ctx, tx, segment, "c.audience_id = $2", segment.AudienceID); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM segment_contacts WHERE segment_id = $1`, segmentID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count segment members: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit segment refresh: %w", err)
+	}
+	return count, nil
+}
+
+func (r *segmentRepository) RefreshContact(ctx context.Context, contactID uuid.UUID) error {
+	var audienceID uuid.UUID
+	err := r.pool.QueryRow(ctx, `SELECT audience_id FROM contacts WHERE id = $1`, contactID).Scan(&audienceID)
+	if err != nil {
+		if isNoRows(err) {
+			return notFound("contact")
+		}
+		return fmt.Errorf("get contact audience: %w", err)
+	}
+
+	segments, err := r.ListByAudienceID(ctx, audienceID)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin contact segment refresh: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for i := range segments {
+		if err := r.syncMembers(ctx, tx, &segments[i], "c.id = $2", contactID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit contact segment refresh: %w", err)
+	}
+	return nil
+}
+
+// syncMembers reconciles segment_contacts for the contacts selected by scope
+// (a predicate over "c" using $2) with the segment's compiled conditions.
+// Existing members keep their original added_at.
+func (r *segmentRepository) syncMembers(ctx context.Context, tx pgx.Tx, segment *model.Segment, scope string, scopeArg uuid.UUID) error {
+	var teamID uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT team_id FROM audiences WHERE id = $1`, segment.AudienceID).Scan(&teamID)
+	if err != nil {
+		return fmt.Errorf("get segment team: %w", err)
+	}
+
+	properties, err := r.propertiesByName(ctx, tx, teamID)
+	if err != nil {
+		return err
+	}
+
+	conds, err := model.ParseSegmentConditions(segment.Conditions)
+	if err != nil {
+		return fmt.Errorf("segment %s: %w", segment.ID, err)
+	}
+
+	q := newSegmentQuery(teamID, properties, segment.ID, scopeArg)
+	where, err := q.where(conds)
+	if err != nil {
+		return fmt.Errorf("compile segment %s conditions: %w", segment.ID, err)
+	}
+
+	// COALESCE guards against comparisons on NULL columns yielding NULL,
+	// which would otherwise neither keep nor remove a contact.
+	deleteQuery := fmt.Sprintf(`
+		DELETE FROM segment_contacts sc
+		USING contacts c
+		WHERE sc.segment_id = $1 AND c.id = sc.contact_id AND %s
+		  AND NOT COALESCE(%s, false)`, scope, where)
+	if _, err := tx.Exec(ctx, deleteQuery, q.args...); err != nil {
+		return fmt.Errorf("remove segment members: %w", err)
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO segment_contacts (segment_id, contact_id, added_at)
+		SELECT $1::uuid, c.id, NOW() FROM contacts c
+		WHERE %s AND COALESCE(%s, false)
+		ON CONFLICT (segment_id, contact_id) DO NOTHING`, scope, where)
+	if _, err := tx.Exec(ctx, insertQuery, q.args...); err != nil {
+		return fmt.Errorf("add segment members: %w", err)
+	}
+	return nil
+}
+
+// propertiesByName loads the team's custom contact properties keyed by name.
+func (r *segmentRepository) propertiesByName(ctx context.Context, tx pgx.Tx, teamID uuid.UUID) (map[string]model.ContactProperty, error) {
+	query := fmt.Sprintf(`SELECT %s FROM contact_properties WHERE team_id = $1`, contactPropertyColumns)
+
+	rows, err := tx.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("list contact properties: %w", err)
+	}
+	defer rows.Close()
+
+	props, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ContactProperty, error) {
+		var p model.ContactProperty
+		err := row.Scan(&p.ID, &p.TeamID, &p.Name, &p.Label, &p.Type, &p.CreatedAt, &p.UpdatedAt)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect contact properties: %w", err)
+	}
+
+	byName := make(map[string]model.ContactProperty, len(props))
+	for _, p := range props {
+		byName[p.Name] = p
+	}
+	return byName, nil
+}
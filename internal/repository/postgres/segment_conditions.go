@@ -0,0 +1,295 @@
+package postgres
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+// segmentQuery compiles a segment condition tree into a SQL boolean
+// expression over the contacts table aliased as "c". Values are never
+// interpolated; each one is appended to args and referenced by placeholder,
+// so the expression can be combined with statement-specific leading args.
+type segmentQuery struct {
+	teamID     uuid.UUID
+	properties map[string]model.ContactProperty
+	args       []interface{}
+}
+
+// newSegmentQuery creates a compiler whose placeholders continue after the
+// given leading statement arguments.
+func newSegmentQuery(teamID uuid.UUID, properties map[string]model.ContactProperty, leading ...interface{}) *segmentQuery {
+	return &segmentQuery{
+		teamID:     teamID,
+		properties: properties,
+		args:       leading,
+	}
+}
+
+// arg registers a bind value and returns its placeholder.
+func (q *segmentQuery) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// where compiles the top-level conditions. An empty condition list matches
+// every contact in the audience.
+func (q *segmentQuery) where(conds []model.SegmentCondition) (string, error) {
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+	return q.group(conds, " AND ")
+}
+
+func (q *segmentQuery) group(conds []model.SegmentCondition, joiner string) (string, error) {
+	if len(conds) == 0 {
+		return "", fmt.Errorf("empty condition group")
+	}
+	parts := make([]string, 0, len(conds))
+	for _, c := range conds {
+		expr, err := q.condition(c)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, expr)
+	}
+	return "(" + strings.Join(parts, joiner) + ")", nil
+}
+
+func (q *segmentQuery) condition(c model.SegmentCondition) (string, error) {
+	switch {
+	case len(c.All) > 0:
+		return q.group(c.All, " AND ")
+	case len(c.Any) > 0:
+		return q.group(c.Any, " OR ")
+	case c.Field != "":
+		return q.field(c)
+	case c.Property != "":
+		return q.property(c)
+	case c.Topic != "":
+		return q.topic(c)
+	case c.Event != "":
+		return q.event(c)
+	default:
+		return "", fmt.Errorf("condition must set one of field, property, topic, event, all or any")
+	}
+}
+
+func (q *segmentQuery) field(c model.SegmentCondition) (string, error) {
+	valueType, ok := model.SegmentContactFields[c.Field]
+	if !ok {
+		return "", fmt.Errorf("unknown contact field %q", c.Field)
+	}
+	// Field names come from the fixed allow-list above, never from user input.
+	col := "c." + c.Field
+	return q.compare(col, valueType, c.Op, c.Value)
+}
+
+func (q *segmentQuery) property(c model.SegmentCondition) (string, error) {
+	prop, ok := q.properties[c.Property]
+	if !ok {
+		return "", fmt.Errorf("unknown contact property %q", c.Property)
+	}
+
+	// Negative operators match contacts that have no value for the property
+	// at all, so they are compiled as NOT EXISTS over the positive form.
+	op, negate := c.Op, false
+	switch c.Op {
+	case model.SegmentOpNeq:
+		op, negate = model.SegmentOpEq, true
+	case model.SegmentOpNotContains:
+		op, negate = model.SegmentOpContains, true
+	case model.SegmentOpIsNotSet:
+		op, negate = model.SegmentOpIsSet, true
+	}
+
+	cmp, err := q.compare(propertyValueExpr(prop.Type), prop.Type, op, c.Value)
+	if err != nil {
+		return "", fmt.Errorf("property %q: %w", c.Property, err)
+	}
+
+	expr := fmt.Sprintf(`EXISTS (SELECT 1 FROM contact_property_values pv
+		WHERE pv.contact_id = c.id AND pv.property_id = %s AND %s)`, q.arg(prop.ID), cmp)
+	if negate {
+		return "NOT " + expr, nil
+	}
+	return expr, nil
+}
+
+// propertyValueExpr casts the text value column to the property's type.
+// Values are normalised on write, but the guards keep a stray value from
+// failing the whole membership query.
+func propertyValueExpr(valueType string) string {
+	switch valueType {
+	case model.ValueTypeNumber:
+		return `(CASE WHEN pv.value ~ '^-?[0-9]+(\.[0-9]+)?$' THEN pv.value::numeric END)`
+	case model.ValueTypeDate:
+		return `(CASE WHEN pv.value ~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}' THEN pv.value::timestamptz END)`
+	default:
+		return "pv.value"
+	}
+}
+
+func (q *segmentQuery) topic(c model.SegmentCondition) (string, error) {
+	topicID, err := uuid.Parse(c.Topic)
+	if err != nil {
+		return "", fmt.Errorf("invalid topic id %q", c.Topic)
+	}
+
+	// A contact without an explicit contact_topics row follows the topic's
+	// default subscription policy.
+	id := q.arg(topicID)
+	expr := fmt.Sprintf(`COALESCE(
+		(SELECT ct.subscribed FROM contact_topics ct WHERE ct.contact_id = c.id AND ct.topic_id = %s),
+		(SELECT t.default_subscription = 'opt_in' FROM topics t WHERE t.id = %s),
+		FALSE)`, id, id)
+
+	switch c.Op {
+	case model.SegmentOpSubscribed:
+		return expr, nil
+	case model.SegmentOpNotSubscribed:
+		return "NOT " + expr, nil
+	default:
+		return "", fmt.Errorf("operator %q is not valid for topic conditions", c.Op)
+	}
+}
+
+func (q *segmentQuery) event(c model.SegmentCondition) (string, error) {
+	if !model.SegmentEventTypes[c.Event] {
+		return "", fmt.Errorf("unknown event type %q", c.Event)
+	}
+	if c.WithinDays < 0 {
+		return "", fmt.Errorf("within_days must not be negative")
+	}
+
+	expr := fmt.Sprintf(`EXISTS (SELECT 1 FROM email_events ee
+		JOIN emails em ON em.id = ee.email_id
+		WHERE em.team_id = %s AND ee.type = %s AND ee.recipient = c.email`,
+		q.arg(q.teamID), q.arg(c.Event))
+	if c.WithinDays > 0 {
+		expr += fmt.Sprintf(" AND ee.created_at >= NOW() - make_interval(days => %s)", q.arg(c.WithinDays))
+	}
+	expr += ")"
+
+	switch c.Op {
+	case model.SegmentOpPerformed:
+		return expr, nil
+	case model.SegmentOpNotPerformed:
+		return "NOT " + expr, nil
+	default:
+		return "", fmt.Errorf("operator %q is not valid for event conditions", c.Op)
+	}
+}
+
+// compare builds a comparison of a column expression against a condition value.
+func (q *segmentQuery) compare(col, valueType, op string, value interface{}) (string, error) {
+	if !model.SegmentOperatorAllowed(valueType, op) {
+		return "", fmt.Errorf("operator %q is not valid for %s values", op, valueType)
+	}
+
+	switch op {
+	case model.SegmentOpIsSet:
+		if valueType == model.ValueTypeString {
+			return fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", col, col), nil
+		}
+		return fmt.Sprintf("%s IS NOT NULL", col), nil
+	case model.SegmentOpIsNotSet:
+		if valueType == model.ValueTypeString {
+			return fmt.Sprintf("(%s IS NULL OR %s = '')", col, col), nil
+		}
+		return fmt.Sprintf("%s IS NULL", col), nil
+	}
+
+	v, err := segmentValue(valueType, value)
+	if err != nil {
+		return "", err
+	}
+
+	if valueType == model.ValueTypeString {
+		s := v.(string)
+		switch op {
+		case model.SegmentOpEq:
+			return fmt.Sprintf("lower(%s) = lower(%s)", col, q.arg(s)), nil
+		case model.SegmentOpNeq:
+			return fmt.Sprintf("(%s IS NULL OR lower(%s) <> lower(%s))", col, col, q.arg(s)), nil
+		case model.SegmentOpContains:
+			return fmt.Sprintf("%s ILIKE %s", col, q.arg("%"+escapeLike(s)+"%")), nil
+		case model.SegmentOpNotContains:
+			return fmt.Sprintf("(%s IS NULL OR %s NOT ILIKE %s)", col, col, q.arg("%"+escapeLike(s)+"%")), nil
+		case model.SegmentOpStartsWith:
+			return fmt.Sprintf("%s ILIKE %s", col, q.arg(escapeLike(s)+"%")), nil
+		case model.SegmentOpEndsWith:
+			return fmt.Sprintf("%s ILIKE %s", col, q.arg("%"+escapeLike(s))), nil
+		}
+	}
+
+	sqlOps := map[string]string{
+		model.SegmentOpEq:  "=",
+		model.SegmentOpNeq: "<>",
+		model.SegmentOpGt:  ">",
+		model.SegmentOpGte: ">=",
+		model.SegmentOpLt:  "<",
+		model.SegmentOpLte: "<=",
+	}
+	sqlOp, ok := sqlOps[op]
+	if !ok {
+		return "", fmt.Errorf("unsupported operator %q", op)
+	}
+
+	// Built-in boolean columns compare directly; property values are stored
+	// as normalised "true"/"false" text.
+	if b, ok := v.(bool); ok && strings.HasPrefix(col, "pv.") {
+		v = strconv.FormatBool(b)
+	}
+
+	placeholder := q.arg(v)
+	switch valueType {
+	case model.ValueTypeNumber:
+		placeholder += "::numeric"
+	case model.ValueTypeDate:
+		placeholder += "::timestamptz"
+	}
+	return fmt.Sprintf("%s %s %s", col, sqlOp, placeholder), nil
+}
+
+// segmentValue converts a JSON condition value into the bind value for a type.
+func segmentValue(valueType string, value interface{}) (interface{}, error) {
+	switch valueType {
+	case model.ValueTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value must be a string")
+		}
+		return s, nil
+	case model.ValueTypeNumber:
+		f, ok := value.(float64)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("value must be a number")
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case model.ValueTypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value must be a boolean")
+		}
+		return b, nil
+	case model.ValueTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value must be a date string")
+		}
+		return model.ParseDateValue(s)
+	default:
+		return nil, fmt.Errorf("unknown value type %q", valueType)
+	}
+}
+
+// escapeLike escapes LIKE wildcards so user values match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
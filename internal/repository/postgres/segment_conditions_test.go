@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func TestSegmentQuery_Where(t *testing.T) {
+	teamID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seatsID := uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	topicID := uuid.MustParse("00000000-0000-0000-0000-0000000000b1")
+	properties := map[string]model.ContactProperty{
+		"seats": {ID: seatsID, Name: "seats", Type: model.ValueTypeNumber},
+	}
+
+	t.Run("empty conditions match everything", func(t *testing.T) {
+		q := newSegmentQuery(teamID, properties, "seg", "scope")
+		where, err := q.where(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "TRUE", where)
+		assert.Len(t, q.args, 2)
+	})
+
+	t.Run("placeholders continue after leading args", func(t *testing.T) {
+		q := newSegmentQuery(teamID, properties, "seg", "scope")
+		where, err := q.where([]model.SegmentCondition{
+			{Field: "email", Op: model.SegmentOpEndsWith, Value: "@example.com"},
+			{Field: "unsubscribed", Op: model.SegmentOpEq, Value: false},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "(c.email ILIKE $3 AND c.unsubscribed = $4)", where)
+		assert.Equal(t, []interface{}{"seg", "scope", "%@example.com", false}, q.args)
+	})
+
+	t.Run("like wildcards are escaped", func(t *testing.T) {
+		q := newSegmentQuery(teamID, properties)
+		_, err := q.where([]model.SegmentCondition{
+			{Field: "first_name", Op: model.SegmentOpContains, Value: "50%_off"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{`%50\%\_off%`}, q.args)
+	})
+
+	t.Run("any group joins with OR", func(t *testing.T) {
+		q := newSegmentQuery(teamID, properties)
+		where, err := q.where([]model.SegmentCondition{
+			{Any: []model.SegmentCondition{
+				{Topic: topicID.String(), Op: model.SegmentOpSubscribed},
+				{Event: model.EventOpened, Op: model.SegmentOpPerformed, WithinDays: 30},
+			}},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, where, " OR ")
+		assert.Contains(t, where, "ct.topic_id = $1)")
+		assert.Contains(t, where, "t.default_subscription = 'opt_in' FROM topics t WHERE t.id = $1")
+		assert.Contains(t, where, "make_interval(days => $4)")
+		assert.Equal(t, []interface{}{topicID, teamID, model.EventOpened, 30}, q.args)
+	})
+
+	t.Run("negated property compiles to NOT EXISTS", func(t *testing.T) {
+		q := newSegmentQuery(teamID, properties)
+		where, err := q.where([]model.SegmentCondition{
+			{Property: "seats", Op: model.SegmentOpNeq, Value: float64(5)},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, where, "NOT EXISTS (SELECT 1 FROM contact_property_values pv")
+		assert.Contains(t, where, "= $1::numeric")
+		assert.Equal(t, []interface{}{"5", seatsID}, q.args)
+	})
+
+	t.Run("date field binds a time value", func(t *testing.T) {
+		q := newSegmentQuery(teamID, properties)
+		where, err := q.where([]model.SegmentCondition{
+			{Field: "created_at", Op: model.SegmentOpGte, Value: "2025-01-15"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "(c.created_at >= $1::timestamptz)", where)
+		assert.Equal(t, []interface{}{time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)}, q.args)
+	})
+
+	t.Run("invalid conditions are rejected", func(t *testing.T) {
+		invalid := []model.SegmentCondition{
+			{Field: "password_hash", Op: model.SegmentOpEq, Value: "x"},
+			{Field: "email", Op: model.SegmentOpGt, Value: "x"},
+			{Property: "plan", Op: model.SegmentOpEq, Value: "pro"},
+			{Property: "seats", Op: model.SegmentOpGt, Value: "ten"},
+			{Topic: "not-a-uuid", Op: model.SegmentOpSubscribed},
+			{Event: "viewed", Op: model.SegmentOpPerformed},
+			{Op: model.SegmentOpEq},
+		}
+		for _, c := range invalid {
+			q := newSegmentQuery(teamID, properties)
+			_, err := q.where([]model.SegmentCondition{c})
+			assert.Error(t, err, "condition %+v", c)
+		}
+	})
+}
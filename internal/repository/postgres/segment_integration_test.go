@@ -0,0 +1,110 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func seedSegmentAudience(t *testing.T, ctx context.Context) (*model.Audience, []*model.Contact) {
+	t.Helper()
+
+	audience := &model.Audience{ID: uuid.New(), TeamID: testTeamID, Name: "Newsletter", CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, NewAudienceRepository(testPool).Create(ctx, audience))
+
+	contactRepo := NewContactRepository(testPool)
+	var contacts []*model.Contact
+	for _, email := range []string{"alice@example.com", "bob@example.org", "carol@example.com"} {
+		c := &model.Contact{ID: uuid.New(), AudienceID: audience.ID, Email: email, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+		require.NoError(t, contactRepo.Create(ctx, c))
+		contacts = append(contacts, c)
+	}
+	return audience, contacts
+}
+
+func TestSegmentRepository_RefreshMembers(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	audience, contacts := seedSegmentAudience(t, ctx)
+
+	prop := &model.ContactProperty{ID: uuid.New(), TeamID: testTeamID, Name: "seats", Label: "Seats", Type: model.ValueTypeNumber, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, NewContactPropertyRepository(testPool).Create(ctx, prop))
+
+	seats := "12"
+	require.NoError(t, NewContactPropertyValueRepository(testPool).Upsert(ctx, &model.ContactPropertyValue{
+		ID: uuid.New(), ContactID: contacts[0].ID, PropertyID: prop.ID, Value: &seats, CreatedAt: fixedTime, UpdatedAt: fixedTime,
+	}))
+
+	repo := NewSegmentRepository(testPool)
+	segment := &model.Segment{
+		ID:         uuid.New(),
+		AudienceID: audience.ID,
+		Name:       "Example.com",
+		Conditions: model.JSONArray{
+			map[string]interface{}{"field": "email", "op": "ends_with", "value": "@example.com"},
+		},
+		CreatedAt: fixedTime,
+		UpdatedAt: fixedTime,
+	}
+	require.NoError(t, repo.Create(ctx, segment))
+
+	count, err := repo.RefreshMembers(ctx, segment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Narrowing the conditions removes members that no longer match.
+	segment.Conditions = append(segment.Conditions,
+		map[string]interface{}{"property": "seats", "op": "gte", "value": float64(10)})
+	require.NoError(t, repo.Update(ctx, segment))
+
+	count, err = repo.RefreshMembers(ctx, segment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	members, total, err := NewContactRepository(testPool).ListBySegmentID(ctx, segment.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, contacts[0].ID, members[0].ID)
+}
+
+func TestSegmentRepository_RefreshContact(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	audience, contacts := seedSegmentAudience(t, ctx)
+
+	repo := NewSegmentRepository(testPool)
+	segment := &model.Segment{
+		ID:         uuid.New(),
+		AudienceID: audience.ID,
+		Name:       "Subscribed",
+		Conditions: model.JSONArray{
+			map[string]interface{}{"field": "unsubscribed", "op": "eq", "value": false},
+		},
+		CreatedAt: fixedTime,
+		UpdatedAt: fixedTime,
+	}
+	require.NoError(t, repo.Create(ctx, segment))
+
+	count, err := repo.RefreshMembers(ctx, segment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	contacts[1].Unsubscribed = true
+	require.NoError(t, NewContactRepository(testPool).Update(ctx, contacts[1]))
+	require.NoError(t, repo.RefreshContact(ctx, contacts[1].ID))
+
+	_, total, err := NewContactRepository(testPool).ListBySegmentID(ctx, segment.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
@@ -54,8 +54,8 @@ func (r *settingsRepository) GetTeamWithMembers(ctx context.Context, teamID uuid
 	// Get team info.
 	var team dto.TeamResponse
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, name, slug FROM teams WHERE id = $1`, teamID,
-	).Scan(&team.ID, &team.Name, &team.Slug)
+		`SELECT id, name, slug, sandbox_mode FROM teams WHERE id = $1`, teamID,
+	).Scan(&team.ID, &team.Name, &team.Slug, &team.SandboxMode)
 	if err != nil {
 		if isNoRows(err) {
 			return nil, notFound("team")
@@ -65,7 +65,7 @@ func (r *settingsRepository) GetTeamWithMembers(ctx context.Context, teamID uuid
 
 	// Get members with user info.
 	rows, err := r.pool.Query(ctx, `
-		SELECT tm.id, u.name, u.email, tm.role
+		SELECT tm.id, tm.user_id, u.name, u.email, tm.role, tm.created_at
 		FROM team_members tm
 		JOIN users u ON u.id = tm.user_id
 		WHERE tm.team_id = $1
@@ -77,7 +77,9 @@ func (r *settingsRepository) GetTeamWithMembers(ctx context.Context, teamID uuid
 
 	members, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (dto.TeamMemberResponse, error) {
 		var m dto.TeamMemberResponse
-		err := row.Scan(&m.ID, &m.Name, &m.Email, &m.Role)
+		var createdAt time.Time
+		err := row.Scan(&m.ID, &m.UserID, &m.Name, &m.Email, &m.Role, &createdAt)
+		m.CreatedAt = createdAt.Format(time.RFC3339)
 		return m, err
 	})
 	if err != nil {
@@ -97,3 +99,13 @@ func (r *settingsRepository) UpdateTeamName(ctx context.Context, teamID uuid.UUI
 	}
 	return nil
 }
+
+func (r *settingsRepository) UpdateSandboxMode(ctx context.Context, teamID uuid.UUID, enabled bool) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE teams SET sandbox_mode = $1, updated_at = $2 WHERE id = $3`,
+		enabled, time.Now().UTC(), teamID)
+	if err != nil {
+		return fmt.Errorf("updating sandbox mode: %w", err)
+	}
+	return nil
+}
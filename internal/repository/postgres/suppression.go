@@ -35,7 +35,7 @@ func (r *suppressionRepository) Create(ctx context.Context, entry *model.Suppres
 }
 
 func (r *suppressionRepository) GetByTeamAndEmail(ctx context.Context, teamID uuid.UUID, email string) (*model.SuppressionEntry, error) {
-	query := fmt.Sprintf(`SELECT %s FROM suppression_list WHERE team_id = $1 AND email = $2`, suppressionColumns)
+	query := fmt.Sprintf(`SELECT %s FROM suppression_list WHERE team_id = $1 AND lower(email) = lower($2)`, suppressionColumns)
 
 	entry := &model.SuppressionEntry{}
 	err := r.pool.QueryRow(ctx, query, teamID, email).Scan(
@@ -50,20 +50,47 @@ func (r *suppressionRepository) GetByTeamAndEmail(ctx context.Context, teamID uu
 	return entry, nil
 }
 
-func (r *suppressionRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.SuppressionEntry, int, error) {
-	countQuery := `SELECT COUNT(*) FROM suppression_list WHERE team_id = $1`
+func (r *suppressionRepository) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.SuppressionEntry, error) {
+	query := fmt.Sprintf(`SELECT %s FROM suppression_list WHERE team_id = $1 AND id = $2`, suppressionColumns)
+
+	entry := &model.SuppressionEntry{}
+	err := r.pool.QueryRow(ctx, query, teamID, id).Scan(
+		&entry.ID, &entry.TeamID, &entry.Email, &entry.Reason, &entry.Details, &entry.CreatedAt,
+	)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, notFound("suppression entry")
+		}
+		return nil, fmt.Errorf("get suppression entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *suppressionRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID, search, reason string, limit, offset int) ([]model.SuppressionEntry, int, error) {
+	where := "team_id = $1"
+	args := []interface{}{teamID}
+	if search != "" {
+		args = append(args, "%"+escapeLike(search)+"%")
+		where += fmt.Sprintf(" AND email ILIKE $%d", len(args))
+	}
+	if reason != "" {
+		args = append(args, reason)
+		where += fmt.Sprintf(" AND reason = $%d", len(args))
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM suppression_list WHERE %s`, where)
 	var total int
-	err := r.pool.QueryRow(ctx, countQuery, teamID).Scan(&total)
+	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("count suppression entries: %w", err)
 	}
 
 	query := fmt.Sprintf(`
-		SELECT %s FROM suppression_list WHERE team_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`, suppressionColumns)
+		SELECT %s FROM suppression_list WHERE %s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d`, suppressionColumns, where, len(args)+1, len(args)+2)
 
-	rows, err := r.pool.Query(ctx, query, teamID, limit, offset)
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("list suppression entries: %w", err)
 	}
@@ -81,6 +108,35 @@ func (r *suppressionRepository) ListByTeamID(ctx context.Context, teamID uuid.UU
 	return entries, total, nil
 }
 
+func (r *suppressionRepository) CreateBatch(ctx context.Context, entries []*model.SuppressionEntry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(
+			`INSERT INTO suppression_list (id, team_id, email, reason, details, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (team_id, email) DO NOTHING`,
+			e.ID, e.TeamID, e.Email, e.Reason, e.Details, e.CreatedAt,
+		)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer func() { _ = br.Close() }()
+
+	inserted := 0
+	for range entries {
+		tag, err := br.Exec()
+		if err != nil {
+			return inserted, fmt.Errorf("batch inserting suppression entries: %w", err)
+		}
+		inserted += int(tag.RowsAffected())
+	}
+	return inserted, nil
+}
+
 func (r *suppressionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM suppression_list WHERE id = $1`
 
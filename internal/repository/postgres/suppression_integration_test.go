@@ -10,6 +10,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
 )
 
 func TestSuppressionRepository_Create(t *testing.T) {
@@ -100,3 +102,65 @@ func TestSuppressionRepository_Delete(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, ErrNotFound))
 }
+
+func TestSuppressionRepository_ListByTeamID_Filters(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewSuppressionRepository(testPool)
+	for _, e := range []struct{ email, reason string }{
+		{"bounced@example.com", model.SuppressionBounce},
+		{"manual@example.com", model.SuppressionManual},
+		{"other@example.org", model.SuppressionManual},
+	} {
+		entry := newTestSuppressionEntry()
+		entry.ID = uuid.New()
+		entry.Email = e.email
+		entry.Reason = e.reason
+		require.NoError(t, repo.Create(ctx, entry))
+	}
+
+	entries, total, err := repo.ListByTeamID(ctx, testTeamID, "", "", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, entries, 3)
+
+	entries, total, err = repo.ListByTeamID(ctx, testTeamID, "EXAMPLE.COM", model.SuppressionManual, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "manual@example.com", entries[0].Email)
+
+	// LIKE wildcards in the search term match literally.
+	_, total, err = repo.ListByTeamID(ctx, testTeamID, "%", "", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func TestSuppressionRepository_CreateBatch(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewSuppressionRepository(testPool)
+	existing := newTestSuppressionEntry()
+	require.NoError(t, repo.Create(ctx, existing))
+
+	fresh := newTestSuppressionEntry()
+	fresh.ID = uuid.New()
+	fresh.Email = "fresh@example.com"
+	duplicate := newTestSuppressionEntry()
+	duplicate.ID = uuid.New()
+
+	inserted, err := repo.CreateBatch(ctx, []*model.SuppressionEntry{fresh, duplicate})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted)
+
+	got, err := repo.GetByTeamAndID(ctx, testTeamID, fresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh@example.com", got.Email)
+
+	_, err = repo.GetByTeamAndID(ctx, uuid.New(), fresh.ID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
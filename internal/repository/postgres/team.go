@@ -21,25 +21,62 @@ func NewTeamRepository(pool *pgxpool.Pool) TeamRepository {
 
 func (r *teamRepository) Create(ctx context.Context, team *model.Team) error {
 	query := `
-		INSERT INTO teams (id, name, slug, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, name, slug, created_at, updated_at`
+		INSERT INTO teams (id, name, slug, sandbox_mode, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, slug, sandbox_mode, created_at, updated_at`
 
 	return r.pool.QueryRow(ctx, query,
-		team.ID, team.Name, team.Slug, team.CreatedAt, team.UpdatedAt,
+		team.ID, team.Name, team.Slug, team.SandboxMode, team.CreatedAt, team.UpdatedAt,
+	).Scan(
+		&team.ID, &team.Name, &team.Slug, &team.SandboxMode, &team.CreatedAt, &team.UpdatedAt,
+	)
+}
+
+// CreateWithOwner creates a team and its owner's membership in a single
+// transaction, so that a team is never left without an owner.
+func (r *teamRepository) CreateWithOwner(ctx context.Context, team *model.Team, owner *model.TeamMember) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO teams (id, name, slug, sandbox_mode, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, slug, sandbox_mode, created_at, updated_at`,
+		team.ID, team.Name, team.Slug, team.SandboxMode, team.CreatedAt, team.UpdatedAt,
+	).Scan(
+		&team.ID, &team.Name, &team.Slug, &team.SandboxMode, &team.CreatedAt, &team.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	owner.TeamID = team.ID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO team_members (id, team_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, team_id, user_id, role, created_at`,
+		owner.ID, owner.TeamID, owner.UserID, owner.Role, owner.CreatedAt,
 	).Scan(
-		&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt,
+		&owner.ID, &owner.TeamID, &owner.UserID, &owner.Role, &owner.CreatedAt,
 	)
+	if err != nil {
+		return fmt.Errorf("create team owner: %w", err)
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *teamRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Team, error) {
 	query := `
-		SELECT id, name, slug, created_at, updated_at
+		SELECT id, name, slug, sandbox_mode, created_at, updated_at
 		FROM teams WHERE id = $1`
 
 	team := &model.Team{}
 	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt,
+		&team.ID, &team.Name, &team.Slug, &team.SandboxMode, &team.CreatedAt, &team.UpdatedAt,
 	)
 	if err != nil {
 		if isNoRows(err) {
@@ -52,12 +89,12 @@ func (r *teamRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Team
 
 func (r *teamRepository) GetBySlug(ctx context.Context, slug string) (*model.Team, error) {
 	query := `
-		SELECT id, name, slug, created_at, updated_at
+		SELECT id, name, slug, sandbox_mode, created_at, updated_at
 		FROM teams WHERE slug = $1`
 
 	team := &model.Team{}
 	err := r.pool.QueryRow(ctx, query, slug).Scan(
-		&team.ID, &team.Name, &team.Slug, &team.CreatedAt, &team.UpdatedAt,
+		&team.ID, &team.Name, &team.Slug, &team.SandboxMode, &team.CreatedAt, &team.UpdatedAt,
 	)
 	if err != nil {
 		if isNoRows(err) {
@@ -68,6 +105,29 @@ func (r *teamRepository) GetBySlug(ctx context.Context, slug string) (*model.Tea
 	return team, nil
 }
 
+// ListByUserID returns the teams the user belongs to, oldest membership
+// first, with the user's role in each.
+func (r *teamRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserTeam, error) {
+	query := `
+		SELECT t.id, t.name, t.slug, t.sandbox_mode, t.created_at, t.updated_at, tm.role
+		FROM teams t
+		JOIN team_members tm ON tm.team_id = t.id
+		WHERE tm.user_id = $1
+		ORDER BY tm.created_at ASC`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list teams by user: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.UserTeam, error) {
+		var t model.UserTeam
+		err := row.Scan(&t.ID, &t.Name, &t.Slug, &t.SandboxMode, &t.CreatedAt, &t.UpdatedAt, &t.Role)
+		return t, err
+	})
+}
+
 // --- TeamMemberRepository ---
 
 type teamMemberRepository struct {
@@ -128,3 +188,100 @@ func (r *teamMemberRepository) GetByTeamAndUser(ctx context.Context, teamID, use
 	}
 	return member, nil
 }
+
+func (r *teamMemberRepository) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.TeamMember, error) {
+	query := `
+		SELECT id, team_id, user_id, role, created_at
+		FROM team_members WHERE team_id = $1 AND id = $2`
+
+	member := &model.TeamMember{}
+	err := r.pool.QueryRow(ctx, query, teamID, id).Scan(
+		&member.ID, &member.TeamID, &member.UserID, &member.Role, &member.CreatedAt,
+	)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, notFound("team member")
+		}
+		return nil, fmt.Errorf("get team member by id: %w", err)
+	}
+	return member, nil
+}
+
+func (r *teamMemberRepository) UpdateRole(ctx context.Context, teamID, id uuid.UUID, role string) error {
+	result, err := r.pool.Exec(ctx,
+		`UPDATE team_members SET role = $3 WHERE team_id = $1 AND id = $2`,
+		teamID, id, role,
+	)
+	if err != nil {
+		return fmt.Errorf("update team member role: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("team member")
+	}
+	return nil
+}
+
+func (r *teamMemberRepository) Delete(ctx context.Context, teamID, id uuid.UUID) error {
+	result, err := r.pool.Exec(ctx,
+		`DELETE FROM team_members WHERE team_id = $1 AND id = $2`,
+		teamID, id,
+	)
+	if err != nil {
+		return fmt.Errorf("delete team member: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("team member")
+	}
+	return nil
+}
+
+// TransferOwnership makes the member toID the team's owner and demotes the
+// member fromID, the current owner, to admin, in a single transaction.
+func (r *teamMemberRepository) TransferOwnership(ctx context.Context, teamID, fromID, toID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	result, err := tx.Exec(ctx,
+		`UPDATE team_members SET role = $3 WHERE team_id = $1 AND id = $2 AND role = $4`,
+		teamID, fromID, model.RoleAdmin, model.RoleOwner,
+	)
+	if err != nil {
+		return fmt.Errorf("demote team owner: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("team owner")
+	}
+
+	result, err = tx.Exec(ctx,
+		`UPDATE team_members SET role = $3 WHERE team_id = $1 AND id = $2`,
+		teamID, toID, model.RoleOwner,
+	)
+	if err != nil {
+		return fmt.Errorf("promote team member: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("team member")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListEmailsByTeamID returns the email addresses of the team's members.
+func (r *teamMemberRepository) ListEmailsByTeamID(ctx context.Context, teamID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT u.email
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		WHERE tm.team_id = $1`
+
+	rows, err := r.pool.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("list team member emails: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,48 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func TestTeamRepository_CreateWithOwner(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewTeamRepository(testPool)
+	memberRepo := NewTeamMemberRepository(testPool)
+
+	team := &model.Team{ID: uuid.New(), Name: "Client C", Slug: "client-c", CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	owner := &model.TeamMember{ID: uuid.New(), UserID: testUserID, Role: model.RoleOwner, CreatedAt: fixedTime}
+	require.NoError(t, repo.CreateWithOwner(ctx, team, owner))
+
+	member, err := memberRepo.GetByTeamAndUser(ctx, team.ID, testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleOwner, member.Role)
+}
+
+func TestTeamRepository_CreateWithOwner_RollsBack(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	repo := NewTeamRepository(testPool)
+
+	// The owner doesn't exist, so the membership can't be created, and
+	// neither is the team.
+	team := &model.Team{ID: uuid.New(), Name: "Client D", Slug: "client-d", CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	owner := &model.TeamMember{ID: uuid.New(), UserID: uuid.New(), Role: model.RoleOwner, CreatedAt: fixedTime}
+	require.Error(t, repo.CreateWithOwner(ctx, team, owner))
+
+	_, err := repo.GetByID(ctx, team.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -207,6 +207,21 @@ func (r *templateVersionRepository) GetPublishedByTemplateID(ctx context.Context
 	return v, nil
 }
 
+func (r *templateVersionRepository) GetByTemplateIDAndVersion(ctx context.Context, templateID uuid.UUID, version int) (*model.TemplateVersion, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM template_versions
+		WHERE template_id = $1 AND version = $2`, templateVersionColumns)
+
+	v, err := scanTemplateVersionPtr(r.pool.QueryRow(ctx, query, templateID, version))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, notFound("template version")
+		}
+		return nil, fmt.Errorf("get template version: %w", err)
+	}
+	return v, nil
+}
+
 func (r *templateVersionRepository) ListByTemplateID(ctx context.Context, templateID uuid.UUID) ([]model.TemplateVersion, error) {
 	query := fmt.Sprintf(`
 		SELECT %s FROM template_versions
@@ -87,7 +87,7 @@ func truncateAll(t *testing.T) {
 	ctx := context.Background()
 	tables := []string{
 		"email_tracking_links", "email_events", "emails",
-		"domain_dns_records", "domains",
+		"dmarc_report_records", "dmarc_reports", "domain_dns_records", "domains",
 		"suppression_list", "api_keys",
 		"email_metrics", "webhook_events", "webhooks",
 		"broadcasts", "template_versions", "templates",
@@ -19,18 +19,18 @@ func NewTopicRepository(pool *pgxpool.Pool) TopicRepository {
 	return &topicRepository{pool: pool}
 }
 
-const topicColumns = `id, team_id, name, description, created_at, updated_at`
+const topicColumns = `id, team_id, name, description, default_subscription, created_at, updated_at`
 
 func (r *topicRepository) Create(ctx context.Context, topic *model.Topic) error {
 	query := fmt.Sprintf(`
 		INSERT INTO topics (%s)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING %s`, topicColumns, topicColumns)
 
 	return r.pool.QueryRow(ctx, query,
-		topic.ID, topic.TeamID, topic.Name, topic.Description, topic.CreatedAt, topic.UpdatedAt,
+		topic.ID, topic.TeamID, topic.Name, topic.Description, topic.DefaultSubscription, topic.CreatedAt, topic.UpdatedAt,
 	).Scan(
-		&topic.ID, &topic.TeamID, &topic.Name, &topic.Description, &topic.CreatedAt, &topic.UpdatedAt,
+		&topic.ID, &topic.TeamID, &topic.Name, &topic.Description, &topic.DefaultSubscription, &topic.CreatedAt, &topic.UpdatedAt,
 	)
 }
 
@@ -39,7 +39,7 @@ func (r *topicRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Top
 
 	t := &model.Topic{}
 	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&t.ID, &t.TeamID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt,
+		&t.ID, &t.TeamID, &t.Name, &t.Description, &t.DefaultSubscription, &t.CreatedAt, &t.UpdatedAt,
 	)
 	if err != nil {
 		if isNoRows(err) {
@@ -55,7 +55,7 @@ func (r *topicRepository) GetByTeamAndID(ctx context.Context, teamID, id uuid.UU
 
 	t := &model.Topic{}
 	err := r.pool.QueryRow(ctx, query, teamID, id).Scan(
-		&t.ID, &t.TeamID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt,
+		&t.ID, &t.TeamID, &t.Name, &t.Description, &t.DefaultSubscription, &t.CreatedAt, &t.UpdatedAt,
 	)
 	if err != nil {
 		if isNoRows(err) {
@@ -79,7 +79,7 @@ func (r *topicRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID) ([
 
 	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Topic, error) {
 		var t model.Topic
-		err := row.Scan(&t.ID, &t.TeamID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt)
+		err := row.Scan(&t.ID, &t.TeamID, &t.Name, &t.Description, &t.DefaultSubscription, &t.CreatedAt, &t.UpdatedAt)
 		return t, err
 	})
 }
@@ -87,14 +87,14 @@ func (r *topicRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID) ([
 func (r *topicRepository) Update(ctx context.Context, topic *model.Topic) error {
 	query := fmt.Sprintf(`
 		UPDATE topics
-		SET name = $2, description = $3, updated_at = $4
+		SET name = $2, description = $3, default_subscription = $4, updated_at = $5
 		WHERE id = $1
 		RETURNING %s`, topicColumns)
 
 	err := r.pool.QueryRow(ctx, query,
-		topic.ID, topic.Name, topic.Description, topic.UpdatedAt,
+		topic.ID, topic.Name, topic.Description, topic.DefaultSubscription, topic.UpdatedAt,
 	).Scan(
-		&topic.ID, &topic.TeamID, &topic.Name, &topic.Description, &topic.CreatedAt, &topic.UpdatedAt,
+		&topic.ID, &topic.TeamID, &topic.Name, &topic.Description, &topic.DefaultSubscription, &topic.CreatedAt, &topic.UpdatedAt,
 	)
 	if err != nil {
 		if isNoRows(err) {
@@ -117,3 +117,67 @@ func (r *topicRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+type contactTopicRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewContactTopicRepository creates a new ContactTopicRepository backed by PostgreSQL.
+func NewContactTopicRepository(pool *pgxpool.Pool) ContactTopicRepository {
+	return &contactTopicRepository{pool: pool}
+}
+
+const contactTopicColumns = `id, contact_id, topic_id, subscribed, created_at, updated_at`
+
+func (r *contactTopicRepository) ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactTopic, error) {
+	query := fmt.Sprintf(`SELECT %s FROM contact_topics WHERE contact_id = $1`, contactTopicColumns)
+
+	rows, err := r.pool.Query(ctx, query, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("list contact topics: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ContactTopic, error) {
+		var ct model.ContactTopic
+		err := row.Scan(&ct.ID, &ct.ContactID, &ct.TopicID, &ct.Subscribed, &ct.CreatedAt, &ct.UpdatedAt)
+		return ct, err
+	})
+}
+
+func (r *contactTopicRepository) ListByTopicAndContacts(ctx context.Context, topicID uuid.UUID, contactIDs []uuid.UUID) ([]model.ContactTopic, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM contact_topics
+		WHERE topic_id = $1 AND contact_id = ANY($2)`, contactTopicColumns)
+
+	rows, err := r.pool.Query(ctx, query, topicID, contactIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list contact topics by topic: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ContactTopic, error) {
+		var ct model.ContactTopic
+		err := row.Scan(&ct.ID, &ct.ContactID, &ct.TopicID, &ct.Subscribed, &ct.CreatedAt, &ct.UpdatedAt)
+		return ct, err
+	})
+}
+
+func (r *contactTopicRepository) Upsert(ctx context.Context, ct *model.ContactTopic) error {
+	query := fmt.Sprintf(`
+		INSERT INTO contact_topics (%s)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (contact_id, topic_id)
+		DO UPDATE SET subscribed = EXCLUDED.subscribed, updated_at = EXCLUDED.updated_at
+		RETURNING %s`, contactTopicColumns, contactTopicColumns)
+
+	err := r.pool.QueryRow(ctx, query,
+		ct.ID, ct.ContactID, ct.TopicID, ct.Subscribed, ct.CreatedAt, ct.UpdatedAt,
+	).Scan(
+		&ct.ID, &ct.ContactID, &ct.TopicID, &ct.Subscribed, &ct.CreatedAt, &ct.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert contact topic: %w", err)
+	}
+	return nil
+}
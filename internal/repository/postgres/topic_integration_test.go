@@ -0,0 +1,82 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func TestContactTopicRepository_Upsert(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	_, contacts := seedSegmentAudience(t, ctx)
+	topic := &model.Topic{ID: uuid.New(), TeamID: testTeamID, Name: "Newsletter", DefaultSubscription: model.TopicDefaultOptIn, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, NewTopicRepository(testPool).Create(ctx, topic))
+
+	repo := NewContactTopicRepository(testPool)
+	ct := &model.ContactTopic{ID: uuid.New(), ContactID: contacts[0].ID, TopicID: topic.ID, Subscribed: true, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, repo.Upsert(ctx, ct))
+
+	// A second upsert for the same contact and topic updates the row in place.
+	optOut := &model.ContactTopic{ID: uuid.New(), ContactID: contacts[0].ID, TopicID: topic.ID, Subscribed: false, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, repo.Upsert(ctx, optOut))
+	assert.Equal(t, ct.ID, optOut.ID)
+
+	list, err := repo.ListByContactID(ctx, contacts[0].ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.False(t, list[0].Subscribed)
+}
+
+func TestContactTopicRepository_ListByTopicAndContacts(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	_, contacts := seedSegmentAudience(t, ctx)
+	topic := &model.Topic{ID: uuid.New(), TeamID: testTeamID, Name: "Beta", DefaultSubscription: model.TopicDefaultOptOut, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, NewTopicRepository(testPool).Create(ctx, topic))
+	assert.Equal(t, model.TopicDefaultOptOut, topic.DefaultSubscription)
+
+	repo := NewContactTopicRepository(testPool)
+	for i, subscribed := range []bool{true, false} {
+		ct := &model.ContactTopic{ID: uuid.New(), ContactID: contacts[i].ID, TopicID: topic.ID, Subscribed: subscribed, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+		require.NoError(t, repo.Upsert(ctx, ct))
+	}
+
+	list, err := repo.ListByTopicAndContacts(ctx, topic.ID, []uuid.UUID{contacts[0].ID, contacts[2].ID})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, contacts[0].ID, list[0].ContactID)
+	assert.True(t, list[0].Subscribed)
+}
+
+func TestContactRepository_ListByTeamAndEmail(t *testing.T) {
+	truncateAll(t)
+	ctx := context.Background()
+	seedTeam(t, ctx)
+
+	_, _ = seedSegmentAudience(t, ctx)
+	other := &model.Audience{ID: uuid.New(), TeamID: testTeamID, Name: "Customers", CreatedAt: fixedTime, UpdatedAt: fixedTime}
+	require.NoError(t, NewAudienceRepository(testPool).Create(ctx, other))
+
+	repo := NewContactRepository(testPool)
+	require.NoError(t, repo.Create(ctx, &model.Contact{ID: uuid.New(), AudienceID: other.ID, Email: "Alice@Example.com", CreatedAt: fixedTime, UpdatedAt: fixedTime}))
+
+	contacts, err := repo.ListByTeamAndEmail(ctx, testTeamID, "alice@example.com")
+	require.NoError(t, err)
+	assert.Len(t, contacts, 2)
+
+	contacts, err = repo.ListByTeamAndEmail(ctx, uuid.New(), "alice@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, contacts)
+}
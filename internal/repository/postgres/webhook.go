@@ -20,12 +20,15 @@ func NewWebhookRepository(pool *pgxpool.Pool) WebhookRepository {
 	return &webhookRepository{pool: pool}
 }
 
-const webhookColumns = `id, team_id, url, events, signing_secret, active, created_at, updated_at`
+const webhookColumns = `id, team_id, url, events, signing_secret, active, created_at, updated_at,
+	consecutive_failures, delivered_count, failed_count, last_delivered_at, last_failed_at, disabled_at, disabled_reason`
 
 func scanWebhookPtr(row pgx.Row) (*model.Webhook, error) {
 	w := &model.Webhook{}
 	err := row.Scan(
 		&w.ID, &w.TeamID, &w.URL, &w.Events, &w.SigningSecret, &w.Active, &w.CreatedAt, &w.UpdatedAt,
+		&w.ConsecutiveFailures, &w.DeliveredCount, &w.FailedCount, &w.LastDeliveredAt, &w.LastFailedAt,
+		&w.DisabledAt, &w.DisabledReason,
 	)
 	return w, err
 }
@@ -33,12 +36,14 @@ func scanWebhookPtr(row pgx.Row) (*model.Webhook, error) {
 func (r *webhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
 	query := fmt.Sprintf(`
 		INSERT INTO webhooks (%s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING %s`, webhookColumns, webhookColumns)
 
 	row := r.pool.QueryRow(ctx, query,
 		webhook.ID, webhook.TeamID, webhook.URL, webhook.Events,
 		webhook.SigningSecret, webhook.Active, webhook.CreatedAt, webhook.UpdatedAt,
+		webhook.ConsecutiveFailures, webhook.DeliveredCount, webhook.FailedCount,
+		webhook.LastDeliveredAt, webhook.LastFailedAt, webhook.DisabledAt, webhook.DisabledReason,
 	)
 	scanned, err := scanWebhookPtr(row)
 	if err != nil {
@@ -86,21 +91,25 @@ func (r *webhookRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID)
 	defer rows.Close()
 
 	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Webhook, error) {
-		var w model.Webhook
-		err := row.Scan(&w.ID, &w.TeamID, &w.URL, &w.Events, &w.SigningSecret, &w.Active, &w.CreatedAt, &w.UpdatedAt)
-		return w, err
+		w, err := scanWebhookPtr(row)
+		if err != nil {
+			return model.Webhook{}, err
+		}
+		return *w, nil
 	})
 }
 
 func (r *webhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
 	query := fmt.Sprintf(`
 		UPDATE webhooks
-		SET url = $2, events = $3, signing_secret = $4, active = $5, updated_at = $6
+		SET url = $2, events = $3, signing_secret = $4, active = $5, updated_at = $6,
+			consecutive_failures = $7, disabled_at = $8, disabled_reason = $9
 		WHERE id = $1
 		RETURNING %s`, webhookColumns)
 
 	row := r.pool.QueryRow(ctx, query,
 		webhook.ID, webhook.URL, webhook.Events, webhook.SigningSecret, webhook.Active, webhook.UpdatedAt,
+		webhook.ConsecutiveFailures, webhook.DisabledAt, webhook.DisabledReason,
 	)
 	scanned, err := scanWebhookPtr(row)
 	if err != nil {
@@ -113,6 +122,45 @@ func (r *webhookRepository) Update(ctx context.Context, webhook *model.Webhook)
 	return nil
 }
 
+// RecordDelivery updates the endpoint's health counters after a delivery
+// attempt and returns the updated webhook. Counters are updated in place so
+// concurrent deliveries do not lose updates.
+func (r *webhookRepository) RecordDelivery(ctx context.Context, id uuid.UUID, delivered bool, at time.Time) (*model.Webhook, error) {
+	query := fmt.Sprintf(`
+		UPDATE webhooks
+		SET consecutive_failures = CASE WHEN $2 THEN 0 ELSE consecutive_failures + 1 END,
+			delivered_count = delivered_count + CASE WHEN $2 THEN 1 ELSE 0 END,
+			failed_count = failed_count + CASE WHEN $2 THEN 0 ELSE 1 END,
+			last_delivered_at = CASE WHEN $2 THEN $3 ELSE last_delivered_at END,
+			last_failed_at = CASE WHEN $2 THEN last_failed_at ELSE $3 END
+		WHERE id = $1
+		RETURNING %s`, webhookColumns)
+
+	w, err := scanWebhookPtr(r.pool.QueryRow(ctx, query, id, delivered, at))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, notFound("webhook")
+		}
+		return nil, fmt.Errorf("record webhook delivery: %w", err)
+	}
+	return w, nil
+}
+
+// Disable deactivates an active webhook and records why. It reports whether
+// the webhook was active, so callers can act only on the transition.
+func (r *webhookRepository) Disable(ctx context.Context, id uuid.UUID, reason string, at time.Time) (bool, error) {
+	query := `
+		UPDATE webhooks
+		SET active = false, disabled_at = $3, disabled_reason = $2, updated_at = $3
+		WHERE id = $1 AND active`
+
+	result, err := r.pool.Exec(ctx, query, id, reason, at)
+	if err != nil {
+		return false, fmt.Errorf("disable webhook: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
 func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM webhooks WHERE id = $1`
 
@@ -200,20 +248,48 @@ func (r *webhookEventRepository) Update(ctx context.Context, event *model.Webhoo
 	return nil
 }
 
-func (r *webhookEventRepository) ListByWebhookID(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]model.WebhookEvent, int, error) {
-	countQuery := `SELECT COUNT(*) FROM webhook_events WHERE webhook_id = $1`
+// WebhookEventFilter narrows a webhook event listing. Zero-valued fields
+// match every event; Since is inclusive and Until exclusive.
+type WebhookEventFilter struct {
+	Status    string
+	EventType string
+	Since     *time.Time
+	Until     *time.Time
+}
+
+func (r *webhookEventRepository) ListByWebhookID(ctx context.Context, webhookID uuid.UUID, filter WebhookEventFilter, limit, offset int) ([]model.WebhookEvent, int, error) {
+	where := "webhook_id = $1"
+	args := []interface{}{webhookID}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if filter.EventType != "" {
+		args = append(args, filter.EventType)
+		where += fmt.Sprintf(" AND event_type = $%d", len(args))
+	}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.Until != nil {
+		args = append(args, *filter.Until)
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM webhook_events WHERE %s`, where)
 	var total int
-	err := r.pool.QueryRow(ctx, countQuery, webhookID).Scan(&total)
+	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("count webhook events: %w", err)
 	}
 
 	query := fmt.Sprintf(`
-		SELECT %s FROM webhook_events WHERE webhook_id = $1
+		SELECT %s FROM webhook_events WHERE %s
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`, webhookEventColumns)
+		LIMIT $%d OFFSET $%d`, webhookEventColumns, where, len(args)+1, len(args)+2)
 
-	rows, err := r.pool.Query(ctx, query, webhookID, limit, offset)
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("list webhook events: %w", err)
 	}
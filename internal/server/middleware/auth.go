@@ -2,15 +2,17 @@ package middleware
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
+	"net"
 	"net/http"
+	"net/mail"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
+	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/pkg"
 )
 
@@ -19,18 +21,74 @@ type AuthContext struct {
 	UserID     *uuid.UUID
 	Permission string
 	AuthMethod string // "api_key" or "jwt"
+	APIKeyID   *uuid.UUID
+
+	// Role is the team member role of a JWT caller. When set it decides the
+	// caller's scopes instead of Permission.
+	Role string
+
+	// API key restrictions. Scopes apply to the custom permission only;
+	// Domain is the name of the domain a key may send from.
+	Scopes     []string
+	DomainID   *uuid.UUID
+	Domain     string
+	AllowedIPs []string
+	ExpiresAt  *time.Time
 }
 
+// HasScope reports whether the caller's role or permission grants scope.
+func (a *AuthContext) HasScope(scope string) bool {
+	if a.Role != "" {
+		return model.ScopeAllows(model.RoleScopes(a.Role), scope)
+	}
+	return model.ScopeAllows(model.PermissionScopes(a.Permission, a.Scopes), scope)
+}
+
+// CanGrant reports whether the caller may create an API key with the given
+// permission and scopes, which must not grant anything the caller lacks.
+func (a *AuthContext) CanGrant(permission string, scopes []string) bool {
+	for _, scope := range model.PermissionScopes(model.ResolvePermission(permission, scopes), scopes) {
+		if !a.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsSender reports whether the caller may send from the given address.
+// Keys restricted to a domain may only send from that domain.
+func (a *AuthContext) AllowsSender(from string) bool {
+	if a.Domain == "" {
+		return true
+	}
+	if addr, err := mail.ParseAddress(from); err == nil {
+		from = addr.Address
+	}
+	at := strings.LastIndex(from, "@")
+	return at >= 0 && strings.EqualFold(from[at+1:], a.Domain)
+}
+
+var (
+	ErrAPIKeyExpired = errors.New("api key expired")
+	ErrIPNotAllowed  = errors.New("api key not allowed from this ip address")
+)
+
 const AuthContextKey contextKey = "auth"
 
 // APIKeyLookup is the function signature for looking up an API key by hash.
 type APIKeyLookup func(ctx context.Context, keyHash string) (*AuthContext, error)
 
+// MemberRoleLookup is the function signature for looking up the role of a
+// user in a team. It returns an error if the user is not a member.
+type MemberRoleLookup func(ctx context.Context, teamID, userID uuid.UUID) (string, error)
+
 // APIKeyLastUsedUpdate is the function signature for updating last_used_at on an API key.
 type APIKeyLastUsedUpdate func(ctx context.Context, keyHash string, usedAt time.Time)
 
 // Auth creates middleware that supports both API key and JWT authentication.
-func Auth(jwtSecret string, apiKeyPrefix string, lookupKey APIKeyLookup, updateLastUsed APIKeyLastUsedUpdate) func(http.Handler) http.Handler {
+// JWT callers get the role they currently hold in the token's team, so role
+// changes and removals apply immediately; lookupRole may be nil to skip this.
+func Auth(jwtSecret string, apiKeyPrefix string, lookupKey APIKeyLookup, updateLastUsed APIKeyLastUsedUpdate, lookupRole MemberRoleLookup) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -45,17 +103,27 @@ func Auth(jwtSecret string, apiKeyPrefix string, lookupKey APIKeyLookup, updateL
 			if strings.HasPrefix(authHeader, "Bearer "+apiKeyPrefix) {
 				// API Key auth
 				apiKey := strings.TrimPrefix(authHeader, "Bearer ")
-				authCtx, err = authenticateAPIKey(r.Context(), apiKey, lookupKey, updateLastUsed)
+				authCtx, err = AuthenticateAPIKey(r.Context(), apiKey, clientIP(r), lookupKey, updateLastUsed)
 			} else if strings.HasPrefix(authHeader, "Bearer ") {
 				// JWT auth
 				token := strings.TrimPrefix(authHeader, "Bearer ")
 				authCtx, err = authenticateJWT(token, jwtSecret)
+				if err == nil && lookupRole != nil {
+					authCtx.Role, err = lookupRole(r.Context(), authCtx.TeamID, *authCtx.UserID)
+				}
 			} else {
 				pkg.Error(w, http.StatusUnauthorized, "invalid authorization format")
 				return
 			}
 
-			if err != nil {
+			switch {
+			case errors.Is(err, ErrAPIKeyExpired):
+				pkg.Error(w, http.StatusUnauthorized, "API key has expired")
+				return
+			case errors.Is(err, ErrIPNotAllowed):
+				pkg.Error(w, http.StatusForbidden, "API key is not allowed from this IP address")
+				return
+			case err != nil:
 				pkg.Error(w, http.StatusUnauthorized, "invalid credentials")
 				return
 			}
@@ -66,15 +134,24 @@ func Auth(jwtSecret string, apiKeyPrefix string, lookupKey APIKeyLookup, updateL
 	}
 }
 
-func authenticateAPIKey(ctx context.Context, key string, lookup APIKeyLookup, updateLastUsed APIKeyLastUsedUpdate) (*AuthContext, error) {
-	h := sha256.Sum256([]byte(key))
-	keyHash := hex.EncodeToString(h[:])
+// AuthenticateAPIKey looks up an API key presented by a client at ip and
+// rejects it if it has expired or may not be used from ip. It is shared by
+// the HTTP API and the SMTP submission listener; callers check scopes.
+func AuthenticateAPIKey(ctx context.Context, key string, ip net.IP, lookup APIKeyLookup, updateLastUsed APIKeyLastUsedUpdate) (*AuthContext, error) {
+	keyHash := pkg.HashAPIKey(key)
 
 	authCtx, err := lookup(ctx, keyHash)
 	if err != nil {
 		return nil, err
 	}
 
+	if authCtx.ExpiresAt != nil && !time.Now().Before(*authCtx.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+	if !model.IPAllowed(authCtx.AllowedIPs, ip) {
+		return nil, ErrIPNotAllowed
+	}
+
 	if updateLastUsed != nil {
 		go updateLastUsed(context.Background(), keyHash, time.Now())
 	}
@@ -116,6 +193,35 @@ func authenticateJWT(tokenStr string, secret string) (*AuthContext, error) {
 	}, nil
 }
 
+// clientIP returns the IP address of the client: the peer address, or the
+// address a trusted proxy forwarded the request for, as set by RealIP.
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// RequireScope creates middleware that rejects callers whose permission does
+// not grant scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := GetAuth(r.Context())
+			if auth == nil {
+				pkg.Error(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			if !auth.HasScope(scope) {
+				pkg.Error(w, http.StatusForbidden, "insufficient permissions: requires the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // GetAuth extracts the auth context from the request context.
 func GetAuth(ctx context.Context) *AuthContext {
 	if auth, ok := ctx.Value(AuthContextKey).(*AuthContext); ok {
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -13,7 +14,7 @@ import (
 )
 
 func TestAuth_MissingAuthorizationHeader(t *testing.T) {
-	handler := Auth("secret", "re_", nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth("secret", "re_", nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -26,7 +27,7 @@ func TestAuth_MissingAuthorizationHeader(t *testing.T) {
 }
 
 func TestAuth_InvalidFormat(t *testing.T) {
-	handler := Auth("secret", "re_", nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth("secret", "re_", nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -48,7 +49,7 @@ func TestAuth_ValidJWT(t *testing.T) {
 	assert.NoError(t, err)
 
 	var capturedAuth *AuthContext
-	handler := Auth(secret, "re_", nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth(secret, "re_", nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		capturedAuth = GetAuth(r.Context())
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -67,6 +68,47 @@ func TestAuth_ValidJWT(t *testing.T) {
 	assert.Equal(t, "full", capturedAuth.Permission)
 }
 
+func TestAuth_JWTLoadsRole(t *testing.T) {
+	secret := "test-secret-key"
+	userID := uuid.New()
+	teamID := uuid.New()
+
+	token, err := GenerateJWT(secret, userID, teamID, 1*time.Hour)
+	assert.NoError(t, err)
+
+	roles := map[uuid.UUID]string{userID: "member"}
+	lookupRole := func(ctx context.Context, gotTeamID, gotUserID uuid.UUID) (string, error) {
+		assert.Equal(t, teamID, gotTeamID)
+		role, ok := roles[gotUserID]
+		if !ok {
+			return "", errors.New("not a member")
+		}
+		return role, nil
+	}
+
+	var capturedAuth *AuthContext
+	handler := Auth(secret, "re_", nil, nil, lookupRole)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedAuth = GetAuth(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "member", capturedAuth.Role)
+	assert.False(t, capturedAuth.HasScope("domains:write"))
+
+	// A removed member's token stops working.
+	delete(roles, userID)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
 func TestAuth_ExpiredJWT(t *testing.T) {
 	secret := "test-secret-key"
 	userID := uuid.New()
@@ -76,7 +118,7 @@ func TestAuth_ExpiredJWT(t *testing.T) {
 	token, err := GenerateJWT(secret, userID, teamID, -1*time.Hour)
 	assert.NoError(t, err)
 
-	handler := Auth(secret, "re_", nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth(secret, "re_", nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -97,7 +139,7 @@ func TestAuth_InvalidJWTSignature(t *testing.T) {
 	token, err := GenerateJWT("wrong-secret", userID, teamID, 1*time.Hour)
 	assert.NoError(t, err)
 
-	handler := Auth("correct-secret", "re_", nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth("correct-secret", "re_", nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -129,7 +171,7 @@ func TestAuth_ValidAPIKey(t *testing.T) {
 	}
 
 	var capturedAuth *AuthContext
-	handler := Auth("jwt-secret", apiKeyPrefix, lookup, updateLastUsed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth("jwt-secret", apiKeyPrefix, lookup, updateLastUsed, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		capturedAuth = GetAuth(r.Context())
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -158,7 +200,7 @@ func TestAuth_InvalidAPIKey(t *testing.T) {
 		return nil, assert.AnError
 	}
 
-	handler := Auth("jwt-secret", apiKeyPrefix, lookup, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth("jwt-secret", apiKeyPrefix, lookup, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -200,3 +242,155 @@ func TestGetAuth_WithContext(t *testing.T) {
 	assert.NotNil(t, auth)
 	assert.Equal(t, teamID, auth.TeamID)
 }
+
+func TestAuth_APIKeyRestrictions(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		authCtx    AuthContext
+		remoteAddr string
+		wantStatus int
+	}{
+		{"unrestricted", AuthContext{}, "198.51.100.1:1234", http.StatusOK},
+		{"not yet expired", AuthContext{ExpiresAt: &future}, "198.51.100.1:1234", http.StatusOK},
+		{"expired", AuthContext{ExpiresAt: &past}, "198.51.100.1:1234", http.StatusUnauthorized},
+		{"allowed ip", AuthContext{AllowedIPs: []string{"198.51.100.1"}}, "198.51.100.1:1234", http.StatusOK},
+		{"allowed range", AuthContext{AllowedIPs: []string{"10.0.0.0/8", "198.51.100.0/24"}}, "198.51.100.7:1234", http.StatusOK},
+		{"ip not allowed", AuthContext{AllowedIPs: []string{"10.0.0.0/8"}}, "198.51.100.7:1234", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(ctx context.Context, keyHash string) (*AuthContext, error) {
+				authCtx := tt.authCtx
+				authCtx.TeamID = uuid.New()
+				authCtx.Permission = "full"
+				authCtx.AuthMethod = "api_key"
+				return &authCtx, nil
+			}
+			handler := Auth("jwt-secret", "re_", lookup, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "Bearer re_test_key")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAuth_APIKeyAllowedIPsBehindProxy(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		headers        map[string]string
+		wantStatus     int
+	}{
+		{"spoofed forwarded for", nil, "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, http.StatusForbidden},
+		{"spoofed real ip", nil, "203.0.113.9:1234", map[string]string{"X-Real-IP": "198.51.100.1"}, http.StatusForbidden},
+		{"spoofed via untrusted peer", []string{"10.0.0.0/8"}, "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, http.StatusForbidden},
+		{"forwarded by trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, http.StatusOK},
+		{"spoofed ahead of trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9"}, http.StatusForbidden},
+		{"through two trusted proxies", []string{"10.0.0.2", "10.0.0.3"}, "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.3"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(ctx context.Context, keyHash string) (*AuthContext, error) {
+				return &AuthContext{TeamID: uuid.New(), Permission: "full", AuthMethod: "api_key", AllowedIPs: []string{"198.51.100.1"}}, nil
+			}
+			handler := RealIP(tt.trustedProxies)(Auth("jwt-secret", "re_", lookup, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "Bearer re_test_key")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		authCtx    *AuthContext
+		scope      string
+		wantStatus int
+	}{
+		{"jwt", &AuthContext{Permission: "full", AuthMethod: "jwt"}, "domains:write", http.StatusOK},
+		{"sending key sends", &AuthContext{Permission: "sending"}, "emails:send", http.StatusOK},
+		{"sending key reads", &AuthContext{Permission: "sending"}, "emails:read", http.StatusForbidden},
+		{"sending key deletes domain", &AuthContext{Permission: "sending"}, "domains:write", http.StatusForbidden},
+		{"read only key reads", &AuthContext{Permission: "read_only"}, "contacts:read", http.StatusOK},
+		{"read only key writes", &AuthContext{Permission: "read_only"}, "contacts:write", http.StatusForbidden},
+		{"read only key sends", &AuthContext{Permission: "read_only"}, "emails:send", http.StatusForbidden},
+		{"write implies read", &AuthContext{Permission: "custom", Scopes: []string{"templates:write"}}, "templates:read", http.StatusOK},
+		{"other resource", &AuthContext{Permission: "custom", Scopes: []string{"templates:write"}}, "webhooks:read", http.StatusForbidden},
+		{"admin deletes domain", &AuthContext{Permission: "full", Role: "admin"}, "domains:write", http.StatusOK},
+		{"member sends", &AuthContext{Permission: "full", Role: "member"}, "emails:send", http.StatusOK},
+		{"member edits contacts", &AuthContext{Permission: "full", Role: "member"}, "contacts:write", http.StatusOK},
+		{"member reads api keys", &AuthContext{Permission: "full", Role: "member"}, "api_keys:read", http.StatusOK},
+		{"member deletes domain", &AuthContext{Permission: "full", Role: "member"}, "domains:write", http.StatusForbidden},
+		{"member invites", &AuthContext{Permission: "full", Role: "member"}, "settings:write", http.StatusForbidden},
+		{"no auth", nil, "emails:read", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireScope(tt.scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.authCtx != nil {
+				req = req.WithContext(context.WithValue(req.Context(), AuthContextKey, tt.authCtx))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAuthContext_CanGrant(t *testing.T) {
+	full := &AuthContext{Permission: "full"}
+	assert.True(t, full.CanGrant("", nil))
+	assert.True(t, full.CanGrant("custom", []string{"emails:send"}))
+
+	scoped := &AuthContext{Permission: "custom", Scopes: []string{"api_keys:write", "emails:send", "*:read"}}
+	assert.True(t, scoped.CanGrant("sending", nil))
+	assert.True(t, scoped.CanGrant("read_only", nil))
+	assert.True(t, scoped.CanGrant("", []string{"domains:read", "emails:send"}))
+	assert.False(t, scoped.CanGrant("", nil))
+	assert.False(t, scoped.CanGrant("custom", []string{"domains:write"}))
+}
+
+func TestAuthContext_AllowsSender(t *testing.T) {
+	unrestricted := &AuthContext{}
+	assert.True(t, unrestricted.AllowsSender("app@anything.test"))
+
+	restricted := &AuthContext{Domain: "example.com"}
+	assert.True(t, restricted.AllowsSender("app@example.com"))
+	assert.True(t, restricted.AllowsSender("App <app@Example.COM>"))
+	assert.False(t, restricted.AllowsSender("app@mail.example.com"))
+	assert.False(t, restricted.AllowsSender("app@example.org"))
+	assert.False(t, restricted.AllowsSender("not-an-address"))
+}
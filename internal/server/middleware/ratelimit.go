@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/mailit-dev/mailit/internal/pkg"
@@ -35,29 +37,16 @@ func RateLimit(rdb *redis.Client, cfg RateLimitConfig) func(http.Handler) http.H
 			}
 
 			limit := cfg.DefaultRPS
-			key := fmt.Sprintf("ratelimit:%s:default", auth.TeamID.String())
-
-			window := cfg.Window
-			if window == 0 {
-				window = time.Second
-			}
-
-			// Use a sliding window counter in Redis
+			window := rateLimitWindow(cfg)
 			now := time.Now()
-			windowKey := fmt.Sprintf("%s:%d", key, now.Unix())
 
-			pipe := rdb.Pipeline()
-			incr := pipe.Incr(r.Context(), windowKey)
-			pipe.Expire(r.Context(), windowKey, window*2)
-			_, err := pipe.Exec(r.Context())
+			count, err := countTeamRequest(r.Context(), rdb, auth.TeamID, now, window)
 			if err != nil {
 				// If Redis is down, allow the request
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			count := incr.Val()
-
 			// Set rate limit headers
 			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
 			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(0, limit-int(count))))
@@ -74,6 +63,46 @@ func RateLimit(rdb *redis.Client, cfg RateLimitConfig) func(http.Handler) http.H
 	}
 }
 
+// SendAllowed counts a send by teamID against the send rate limit and reports
+// whether it is within the limit. It applies the limit of the email sending
+// endpoints to sends that don't come through the HTTP API, such as SMTP
+// submissions.
+func SendAllowed(ctx context.Context, rdb *redis.Client, cfg RateLimitConfig, teamID uuid.UUID) bool {
+	if !cfg.Enabled {
+		return true
+	}
+	count, err := countTeamRequest(ctx, rdb, teamID, time.Now(), rateLimitWindow(cfg))
+	if err != nil {
+		// If Redis is down, allow the send
+		return true
+	}
+	return int(count) <= cfg.SendRPS
+}
+
+// rateLimitWindow returns the configured rate limit window, one second by
+// default.
+func rateLimitWindow(cfg RateLimitConfig) time.Duration {
+	if cfg.Window == 0 {
+		return time.Second
+	}
+	return cfg.Window
+}
+
+// countTeamRequest counts a request by teamID in the current window and
+// returns the number of requests the team made in it.
+func countTeamRequest(ctx context.Context, rdb *redis.Client, teamID uuid.UUID, now time.Time, window time.Duration) (int64, error) {
+	// Use a sliding window counter in Redis
+	windowKey := fmt.Sprintf("ratelimit:%s:default:%d", teamID.String(), now.Unix())
+
+	pipe := rdb.Pipeline()
+	incr := pipe.Incr(ctx, windowKey)
+	pipe.Expire(ctx, windowKey, window*2)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 // IPRateLimit creates an IP-based rate limiter for public endpoints (e.g. auth).
 // rps is the maximum requests per second allowed per IP address.
 func IPRateLimit(rdb *redis.Client, rps int, window time.Duration) func(http.Handler) http.Handler {
@@ -195,6 +195,27 @@ func TestSendRateLimit(t *testing.T) {
 	assert.Equal(t, "10", rec.Header().Get("X-RateLimit-Limit"))
 }
 
+func TestSendAllowed(t *testing.T) {
+	mr, rdb := setupMiniredis(t)
+	ctx := context.Background()
+	teamID := uuid.New()
+
+	cfg := RateLimitConfig{Enabled: true, DefaultRPS: 100, SendRPS: 2, Window: time.Second}
+
+	assert.True(t, SendAllowed(ctx, rdb, cfg, teamID))
+	assert.True(t, SendAllowed(ctx, rdb, cfg, teamID))
+	assert.False(t, SendAllowed(ctx, rdb, cfg, teamID), "third send in the window exceeds SendRPS")
+	assert.True(t, SendAllowed(ctx, rdb, cfg, uuid.New()), "other teams have their own limit")
+
+	cfg.Enabled = false
+	assert.True(t, SendAllowed(ctx, rdb, cfg, teamID))
+
+	// If Redis is down, sends are allowed.
+	cfg.Enabled = true
+	mr.Close()
+	assert.True(t, SendAllowed(ctx, rdb, cfg, teamID))
+}
+
 func TestBatchRateLimit(t *testing.T) {
 	_, rdb := setupMiniredis(t)
 
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP sets r.RemoteAddr to the client's IP address. The X-Forwarded-For
+// and X-Real-IP headers are only believed on requests that come from one of
+// trustedProxies, IP addresses or CIDR ranges: anyone else could name any
+// address in them, and get past the IP allowlists of API keys. Entries that
+// don't parse are ignored.
+func RealIP(trustedProxies []string) func(http.Handler) http.Handler {
+	var trusted []*net.IPNet
+	for _, entry := range trustedProxies {
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			trusted = append(trusted, network)
+		}
+	}
+	isTrusted := func(ip net.IP) bool {
+		for _, network := range trusted {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) > 0 && isTrusted(clientIP(r)) {
+				if ip := forwardedIP(r, isTrusted); ip != nil {
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the client address named by a trusted proxy. Proxies
+// append to X-Forwarded-For, so it is read from the right, past the trusted
+// proxies, to the first address a trusted proxy saw the request come from.
+func forwardedIP(r *http.Request, isTrusted func(net.IP) bool) net.IP {
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		var client net.IP
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			client = ip
+			if !isTrusted(ip) {
+				break
+			}
+		}
+		return client
+	}
+	return net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+// RequestLogFunc receives the log entry of an API request. It must not block.
+type RequestLogFunc func(entry *model.Log)
+
+// redacted replaces the values of secrets in logged bodies.
+const redacted = "[REDACTED]"
+
+// maxRequestIDLength is the longest request ID that fits the logs table.
+const maxRequestIDLength = 36
+
+// RequestLog creates middleware that logs each authenticated request with
+// its JSON request and response bodies, redacted, to record. It must run
+// after Auth. Bodies over maxBodyBytes are not stored, nor are requests to
+// the skipped paths.
+func RequestLog(record RequestLogFunc, maxBodyBytes int, skip ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := GetAuth(r.Context())
+			if auth == nil || record == nil || containsString(skip, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+
+			var reqBody []byte
+			if r.Body != nil && isJSON(r.Header.Get("Content-Type")) {
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, int64(maxBodyBytes)+1))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+
+			respBody := &limitedBuffer{max: maxBodyBytes}
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(respBody)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := "info"
+			switch {
+			case status >= 500:
+				level = "error"
+			case status >= 400:
+				level = "warn"
+			}
+
+			requestID := GetRequestID(r.Context())
+			if len(requestID) > maxRequestIDLength {
+				requestID = requestID[:maxRequestIDLength]
+			}
+			method := r.Method
+			path := r.URL.Path
+			duration := int(time.Since(start).Milliseconds())
+			ip := clientIP(r).String()
+
+			metadata := model.JSONMap{"auth_method": auth.AuthMethod}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				metadata["route"] = rctx.RoutePattern()
+			}
+			if ua := r.UserAgent(); ua != "" {
+				metadata["user_agent"] = ua
+			}
+
+			entry := &model.Log{
+				ID:         uuid.New(),
+				TeamID:     auth.TeamID,
+				Level:      level,
+				Message:    fmt.Sprintf("%s %s %d", method, path, status),
+				Metadata:   metadata,
+				Method:     &method,
+				Path:       &path,
+				StatusCode: &status,
+				DurationMs: &duration,
+				IPAddress:  &ip,
+				APIKeyID:   auth.APIKeyID,
+				CreatedAt:  start.UTC(),
+			}
+			if requestID != "" {
+				entry.RequestID = &requestID
+			}
+			if len(reqBody) > 0 {
+				entry.RequestBody = redactBody(reqBody, maxBodyBytes)
+			}
+			if respBody.Len() > 0 && isJSON(ww.Header().Get("Content-Type")) {
+				if respBody.truncated {
+					entry.ResponseBody = tooLargeBody(maxBodyBytes)
+				} else {
+					entry.ResponseBody = redactBody(respBody.Bytes(), maxBodyBytes)
+				}
+			}
+			record(entry)
+		})
+	}
+}
+
+// redactBody returns a JSON body with the values of secrets replaced. Bodies
+// that are too large or not JSON are left out.
+func redactBody(body []byte, maxBodyBytes int) json.RawMessage {
+	if len(body) > maxBodyBytes {
+		return tooLargeBody(maxBodyBytes)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func tooLargeBody(maxBodyBytes int) json.RawMessage {
+	out, _ := json.Marshal(fmt.Sprintf("[body larger than %d bytes not logged]", maxBodyBytes))
+	return out
+}
+
+// redactValue replaces secrets, such as passwords, tokens and private keys,
+// and the content of attachments in a decoded JSON value.
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			switch {
+			case isSecretField(k):
+				v[k] = redacted
+			case strings.EqualFold(k, "attachments"):
+				v[k] = redactAttachments(val)
+			default:
+				v[k] = redactValue(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactValue(val)
+		}
+	}
+	return v
+}
+
+func redactAttachments(v interface{}) interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return redactValue(v)
+	}
+	for _, item := range list {
+		if a, ok := item.(map[string]interface{}); ok {
+			if _, has := a["content"]; has {
+				a["content"] = redacted
+			}
+		}
+	}
+	return redactValue(list)
+}
+
+// isSecretField reports whether a JSON field holds a secret.
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range []string{"password", "secret", "token", "private_key", "authorization", "api_key"} {
+		if name == secret || strings.HasSuffix(name, "_"+secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readCloser pairs a reader with the closer of the body it was built from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedBuffer keeps the first max bytes written to it and notes whether
+// more were written.
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
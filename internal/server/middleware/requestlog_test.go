@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func withAPIKeyAuth(r *http.Request, teamID, apiKeyID uuid.UUID) *http.Request {
+	authCtx := &AuthContext{
+		TeamID:     teamID,
+		Permission: "full",
+		AuthMethod: "api_key",
+		APIKeyID:   &apiKeyID,
+	}
+	return r.WithContext(context.WithValue(r.Context(), AuthContextKey, authCtx))
+}
+
+func TestRequestLog_RecordsRequest(t *testing.T) {
+	teamID, apiKeyID := uuid.New(), uuid.New()
+
+	var entries []*model.Log
+	var handlerBody string
+	handler := RequestID(RequestLog(func(entry *model.Log) { entries = append(entries, entry) }, 1024)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			handlerBody = string(body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"invalid","token":"tok_123"}`))
+		})))
+
+	reqBody := `{"to":["a@example.com"],"attachments":[{"filename":"a.pdf","content":"JVBERi0="}],"smtp":{"password":"hunter2"}}`
+	req := httptest.NewRequest(http.MethodPost, "/emails", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-123")
+	req = withAPIKeyAuth(req, teamID, apiKeyID)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, reqBody, handlerBody, "handler must see the original body")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{"error":"invalid","token":"tok_123"}`, rec.Body.String())
+
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, teamID, entry.TeamID)
+	assert.Equal(t, &apiKeyID, entry.APIKeyID)
+	assert.Equal(t, "warn", entry.Level)
+	assert.Equal(t, "POST /emails 422", entry.Message)
+	assert.Equal(t, "req-123", *entry.RequestID)
+	assert.Equal(t, http.MethodPost, *entry.Method)
+	assert.Equal(t, "/emails", *entry.Path)
+	assert.Equal(t, http.StatusUnprocessableEntity, *entry.StatusCode)
+	assert.Equal(t, "api_key", entry.Metadata["auth_method"])
+	assert.JSONEq(t, `{"to":["a@example.com"],"attachments":[{"filename":"a.pdf","content":"[REDACTED]"}],"smtp":{"password":"[REDACTED]"}}`, string(entry.RequestBody))
+	assert.JSONEq(t, `{"error":"invalid","token":"[REDACTED]"}`, string(entry.ResponseBody))
+}
+
+func TestRequestLog_LargeBodies(t *testing.T) {
+	var entry *model.Log
+	handler := RequestLog(func(e *model.Log) { entry = e }, 16)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+		}))
+
+	reqBody := `{"html":"` + strings.Repeat("x", 64) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/emails", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req = withAPIKeyAuth(req, uuid.New(), uuid.New())
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, reqBody, rec.Body.String(), "large bodies must pass through unchanged")
+	require.NotNil(t, entry)
+	var note string
+	require.NoError(t, json.Unmarshal(entry.RequestBody, &note))
+	assert.Contains(t, note, "not logged")
+	assert.Equal(t, entry.RequestBody, entry.ResponseBody)
+}
+
+func TestRequestLog_NotRecorded(t *testing.T) {
+	var recorded int
+	handler := RequestLog(func(*model.Log) { recorded++ }, 1024, "/logs")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/emails", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("skipped path", func(t *testing.T) {
+		req := withAPIKeyAuth(httptest.NewRequest(http.MethodGet, "/logs", nil), uuid.New(), uuid.New())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	assert.Zero(t, recorded)
+}
+
+func TestIsSecretField(t *testing.T) {
+	for _, name := range []string{"password", "Password", "secret", "token", "refresh_token", "private_key", "dkim_private_key", "authorization", "api_key"} {
+		assert.True(t, isSecretField(name), name)
+	}
+	for _, name := range []string{"subject", "tokens_used", "api_keys", "idempotency_key", "secretary"} {
+		assert.False(t, isSecretField(name), name)
+	}
+}
@@ -15,26 +15,35 @@ import (
 )
 
 type Config struct {
-	Addr           string
-	ReadTimeout    time.Duration
-	WriteTimeout   time.Duration
-	JWTSecret      string
-	APIKeyPrefix   string
-	CORSOrigins    []string
+	Addr         string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	JWTSecret    string
+	APIKeyPrefix string
+	CORSOrigins  []string
+	// TrustedProxies lists the proxies, IP addresses or CIDR ranges, whose
+	// X-Forwarded-For and X-Real-IP headers name the client's address.
+	TrustedProxies []string
 	RateLimitCfg   middleware.RateLimitConfig
 	Redis          *redis.Client
 	APIKeyLookup   middleware.APIKeyLookup
 	APIKeyLastUsed middleware.APIKeyLastUsedUpdate
-	Handlers       *handler.Handlers
-	HealthHandler  *handler.HealthHandler
-	Logger         *slog.Logger
+	MemberRole     middleware.MemberRoleLookup
+	// RequestLog receives a log of each authenticated API request; nil
+	// disables request logging. Bodies over RequestLogMaxBody bytes are not
+	// logged.
+	RequestLog        middleware.RequestLogFunc
+	RequestLogMaxBody int
+	Handlers          *handler.Handlers
+	HealthHandler     *handler.HealthHandler
+	Logger            *slog.Logger
 }
 
 func New(cfg Config) *http.Server {
 	r := chi.NewRouter()
 
 	// Global middleware
-	r.Use(chimw.RealIP)
+	r.Use(middleware.RealIP(cfg.TrustedProxies))
 	r.Use(middleware.RequestID)
 	r.Use(chimw.Recoverer)
 	r.Use(chimw.Timeout(30 * time.Second))
@@ -51,7 +60,7 @@ func New(cfg Config) *http.Server {
 	r.Get("/readyz", cfg.HealthHandler.Readyz)
 
 	// Auth middleware
-	authMw := middleware.Auth(cfg.JWTSecret, cfg.APIKeyPrefix, cfg.APIKeyLookup, cfg.APIKeyLastUsed)
+	authMw := middleware.Auth(cfg.JWTSecret, cfg.APIKeyPrefix, cfg.APIKeyLookup, cfg.APIKeyLastUsed, cfg.MemberRole)
 	rateLimitMw := middleware.RateLimit(cfg.Redis, cfg.RateLimitCfg)
 	sendLimitMw := middleware.SendRateLimit(cfg.Redis, cfg.RateLimitCfg)
 	batchLimitMw := middleware.BatchRateLimit(cfg.Redis, cfg.RateLimitCfg)
@@ -72,105 +81,161 @@ func New(cfg Config) *http.Server {
 	r.Get("/track/click/{id}", h.Tracking.TrackClick)
 	r.Post("/unsubscribe", h.Tracking.Unsubscribe)
 
+	// Public preference center, addressed by signed per-recipient tokens.
+	r.Get("/preferences", h.Preference.Show)
+	r.Post("/preferences", h.Preference.Update)
+	r.Get("/preferences/unsubscribe", h.Preference.Show)
+	r.Post("/preferences/unsubscribe", h.Preference.Unsubscribe)
+
 	// Authenticated API routes
 	r.Group(func(r chi.Router) {
 		r.Use(authMw)
+		if cfg.RequestLog != nil {
+			// Reading logs is not logged, so that it doesn't add to them.
+			r.Use(middleware.RequestLog(cfg.RequestLog, cfg.RequestLogMaxBody, "/logs"))
+		}
 		r.Use(rateLimitMw)
 
+		// API keys are limited to the scopes their permission grants.
+		scope := middleware.RequireScope
+
+		// Teams of the signed-in user
+		r.Get("/teams", h.Auth.ListTeams)
+		r.Post("/teams", h.Auth.CreateTeam)
+		r.Post("/auth/switch-team", h.Auth.SwitchTeam)
+
 		// Emails
-		r.With(sendLimitMw).Post("/emails", h.Email.Send)
-		r.With(batchLimitMw).Post("/emails/batch", h.Email.BatchSend)
-		r.Get("/emails", h.Email.List)
-		r.Get("/emails/{emailId}", h.Email.Get)
-		r.Patch("/emails/{emailId}", h.Email.Update)
-		r.Post("/emails/{emailId}/cancel", h.Email.Cancel)
+		r.With(scope("emails:send"), sendLimitMw).Post("/emails", h.Email.Send)
+		r.With(scope("emails:send"), batchLimitMw).Post("/emails/batch", h.Email.BatchSend)
+		r.With(scope("emails:read")).Get("/emails", h.Email.List)
+		r.With(scope("emails:read")).Get("/emails/{emailId}", h.Email.Get)
+		r.With(scope("emails:write")).Patch("/emails/{emailId}", h.Email.Update)
+		r.With(scope("emails:write")).Post("/emails/{emailId}/cancel", h.Email.Cancel)
 
 		// Domains
-		r.Post("/domains", h.Domain.Create)
-		r.Get("/domains", h.Domain.List)
-		r.Get("/domains/{domainId}", h.Domain.Get)
-		r.Patch("/domains/{domainId}", h.Domain.Update)
-		r.Delete("/domains/{domainId}", h.Domain.Delete)
-		r.Post("/domains/{domainId}/verify", h.Domain.Verify)
+		r.With(scope("domains:write")).Post("/domains", h.Domain.Create)
+		r.With(scope("domains:read")).Get("/domains", h.Domain.List)
+		r.With(scope("domains:read")).Get("/domains/{domainId}", h.Domain.Get)
+		r.With(scope("domains:write")).Patch("/domains/{domainId}", h.Domain.Update)
+		r.With(scope("domains:write")).Delete("/domains/{domainId}", h.Domain.Delete)
+		r.With(scope("domains:write")).Post("/domains/{domainId}/verify", h.Domain.Verify)
+		r.With(scope("domains:write")).Post("/domains/{domainId}/dkim/rotate", h.Domain.RotateDKIM)
+		r.With(scope("domains:write")).Post("/domains/{domainId}/dkim/import", h.Domain.ImportDKIM)
+		r.With(scope("domains:read")).Get("/domains/{domainId}/dmarc/reports", h.DMARC.ListReports)
+		r.With(scope("domains:read")).Get("/domains/{domainId}/dmarc/stats", h.DMARC.GetStats)
 
 		// API Keys
-		r.Post("/api-keys", h.APIKey.Create)
-		r.Get("/api-keys", h.APIKey.List)
-		r.Delete("/api-keys/{apiKeyId}", h.APIKey.Delete)
+		r.With(scope("api_keys:write")).Post("/api-keys", h.APIKey.Create)
+		r.With(scope("api_keys:read")).Get("/api-keys", h.APIKey.List)
+		r.With(scope("api_keys:write")).Delete("/api-keys/{apiKeyId}", h.APIKey.Delete)
 
 		// Audiences
-		r.Post("/audiences", h.Audience.Create)
-		r.Get("/audiences", h.Audience.List)
-		r.Get("/audiences/{audienceId}", h.Audience.Get)
-		r.Delete("/audiences/{audienceId}", h.Audience.Delete)
+		r.With(scope("contacts:write")).Post("/audiences", h.Audience.Create)
+		r.With(scope("contacts:read")).Get("/audiences", h.Audience.List)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}", h.Audience.Get)
+		r.With(scope("contacts:write")).Delete("/audiences/{audienceId}", h.Audience.Delete)
 
 		// Contacts
-		r.Post("/audiences/{audienceId}/contacts", h.Contact.Create)
-		r.Get("/audiences/{audienceId}/contacts", h.Contact.List)
-		r.Get("/audiences/{audienceId}/contacts/export", h.Contact.Export)
-		r.Post("/audiences/{audienceId}/contacts/import", h.ContactImport.Import)
-		r.Get("/audiences/{audienceId}/contacts/import/{jobId}", h.ContactImport.GetImportStatus)
-		r.Get("/audiences/{audienceId}/contacts/{contactId}", h.Contact.Get)
-		r.Patch("/audiences/{audienceId}/contacts/{contactId}", h.Contact.Update)
-		r.Delete("/audiences/{audienceId}/contacts/{contactId}", h.Contact.Delete)
+		r.With(scope("contacts:write")).Post("/audiences/{audienceId}/contacts", h.Contact.Create)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}/contacts", h.Contact.List)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}/contacts/export", h.Contact.Export)
+		r.With(scope("contacts:write")).Post("/audiences/{audienceId}/contacts/import", h.ContactImport.Import)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}/contacts/import/{jobId}", h.ContactImport.GetImportStatus)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}/contacts/{contactId}", h.Contact.Get)
+		r.With(scope("contacts:write")).Patch("/audiences/{audienceId}/contacts/{contactId}", h.Contact.Update)
+		r.With(scope("contacts:write")).Delete("/audiences/{audienceId}/contacts/{contactId}", h.Contact.Delete)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}/contacts/{contactId}/topics", h.Contact.ListTopics)
+		r.With(scope("contacts:write")).Patch("/audiences/{audienceId}/contacts/{contactId}/topics", h.Contact.UpdateTopics)
 
 		// Contact Properties
-		r.Post("/contact-properties", h.ContactProperty.Create)
-		r.Get("/contact-properties", h.ContactProperty.List)
-		r.Patch("/contact-properties/{propertyId}", h.ContactProperty.Update)
-		r.Delete("/contact-properties/{propertyId}", h.ContactProperty.Delete)
+		r.With(scope("contacts:write")).Post("/contact-properties", h.ContactProperty.Create)
+		r.With(scope("contacts:read")).Get("/contact-properties", h.ContactProperty.List)
+		r.With(scope("contacts:write")).Patch("/contact-properties/{propertyId}", h.ContactProperty.Update)
+		r.With(scope("contacts:write")).Delete("/contact-properties/{propertyId}", h.ContactProperty.Delete)
 
 		// Topics
-		r.Post("/topics", h.Topic.Create)
-		r.Get("/topics", h.Topic.List)
-		r.Patch("/topics/{topicId}", h.Topic.Update)
-		r.Delete("/topics/{topicId}", h.Topic.Delete)
+		r.With(scope("contacts:write")).Post("/topics", h.Topic.Create)
+		r.With(scope("contacts:read")).Get("/topics", h.Topic.List)
+		r.With(scope("contacts:write")).Patch("/topics/{topicId}", h.Topic.Update)
+		r.With(scope("contacts:write")).Delete("/topics/{topicId}", h.Topic.Delete)
 
 		// Segments
-		r.Post("/audiences/{audienceId}/segments", h.Segment.Create)
-		r.Get("/audiences/{audienceId}/segments", h.Segment.List)
-		r.Patch("/audiences/{audienceId}/segments/{segmentId}", h.Segment.Update)
-		r.Delete("/audiences/{audienceId}/segments/{segmentId}", h.Segment.Delete)
+		r.With(scope("contacts:write")).Post("/audiences/{audienceId}/segments", h.Segment.Create)
+		r.With(scope("contacts:read")).Get("/audiences/{audienceId}/segments", h.Segment.List)
+		r.With(scope("contacts:write")).Patch("/audiences/{audienceId}/segments/{segmentId}", h.Segment.Update)
+		r.With(scope("contacts:write")).Delete("/audiences/{audienceId}/segments/{segmentId}", h.Segment.Delete)
+		r.With(scope("contacts:write")).Post("/audiences/{audienceId}/segments/{segmentId}/refresh", h.Segment.Refresh)
 
 		// Templates
-		r.Post("/templates", h.Template.Create)
-		r.Get("/templates", h.Template.List)
-		r.Get("/templates/{templateId}", h.Template.Get)
-		r.Patch("/templates/{templateId}", h.Template.Update)
-		r.Delete("/templates/{templateId}", h.Template.Delete)
-		r.Post("/templates/{templateId}/publish", h.Template.Publish)
+		r.With(scope("templates:write")).Post("/templates", h.Template.Create)
+		r.With(scope("templates:read")).Get("/templates", h.Template.List)
+		r.With(scope("templates:read")).Get("/templates/{templateId}", h.Template.Get)
+		r.With(scope("templates:write")).Patch("/templates/{templateId}", h.Template.Update)
+		r.With(scope("templates:write")).Delete("/templates/{templateId}", h.Template.Delete)
+		r.With(scope("templates:write")).Post("/templates/{templateId}/publish", h.Template.Publish)
+		r.With(scope("templates:read")).Post("/templates/{templateId}/preview", h.Template.Preview)
+		r.With(scope("emails:send"), sendLimitMw).Post("/templates/{templateId}/test", h.Template.SendTest)
+		r.With(scope("templates:read")).Get("/templates/{templateId}/versions", h.Template.ListVersions)
+		r.With(scope("templates:read")).Get("/templates/{templateId}/versions/diff", h.Template.Diff)
+		r.With(scope("templates:write")).Post("/templates/{templateId}/versions/{version}/publish", h.Template.PublishVersion)
 
 		// Broadcasts
-		r.Post("/broadcasts", h.Broadcast.Create)
-		r.Get("/broadcasts", h.Broadcast.List)
-		r.Get("/broadcasts/{broadcastId}", h.Broadcast.Get)
-		r.Patch("/broadcasts/{broadcastId}", h.Broadcast.Update)
-		r.Delete("/broadcasts/{broadcastId}", h.Broadcast.Delete)
-		r.Post("/broadcasts/{broadcastId}/send", h.Broadcast.Send)
+		r.With(scope("broadcasts:write")).Post("/broadcasts", h.Broadcast.Create)
+		r.With(scope("broadcasts:read")).Get("/broadcasts", h.Broadcast.List)
+		r.With(scope("broadcasts:read")).Get("/broadcasts/{broadcastId}", h.Broadcast.Get)
+		r.With(scope("broadcasts:write")).Patch("/broadcasts/{broadcastId}", h.Broadcast.Update)
+		r.With(scope("broadcasts:write")).Delete("/broadcasts/{broadcastId}", h.Broadcast.Delete)
+		r.With(scope("broadcasts:write")).Post("/broadcasts/{broadcastId}/send", h.Broadcast.Send)
+		r.With(scope("broadcasts:write")).Post("/broadcasts/{broadcastId}/schedule", h.Broadcast.Schedule)
+		r.With(scope("broadcasts:write")).Post("/broadcasts/{broadcastId}/unschedule", h.Broadcast.Unschedule)
+		r.With(scope("broadcasts:write")).Post("/broadcasts/{broadcastId}/pause", h.Broadcast.Pause)
+		r.With(scope("broadcasts:write")).Post("/broadcasts/{broadcastId}/resume", h.Broadcast.Resume)
+		r.With(scope("broadcasts:write")).Post("/broadcasts/{broadcastId}/cancel", h.Broadcast.Cancel)
+		r.With(scope("broadcasts:read")).Get("/broadcasts/{broadcastId}/stats", h.Broadcast.Stats)
+		r.With(scope("broadcasts:read")).Get("/broadcasts/{broadcastId}/recipients", h.Broadcast.ListRecipients)
+		r.With(scope("broadcasts:read")).Get("/broadcasts/{broadcastId}/recipients/export", h.Broadcast.ExportRecipients)
 
 		// Webhooks
-		r.Post("/webhooks", h.Webhook.Create)
-		r.Get("/webhooks", h.Webhook.List)
-		r.Get("/webhooks/{webhookId}", h.Webhook.Get)
-		r.Patch("/webhooks/{webhookId}", h.Webhook.Update)
-		r.Delete("/webhooks/{webhookId}", h.Webhook.Delete)
+		r.With(scope("webhooks:write")).Post("/webhooks", h.Webhook.Create)
+		r.With(scope("webhooks:read")).Get("/webhooks", h.Webhook.List)
+		r.With(scope("webhooks:read")).Get("/webhooks/{webhookId}", h.Webhook.Get)
+		r.With(scope("webhooks:write")).Patch("/webhooks/{webhookId}", h.Webhook.Update)
+		r.With(scope("webhooks:write")).Delete("/webhooks/{webhookId}", h.Webhook.Delete)
+		r.With(scope("webhooks:write")).Post("/webhooks/{webhookId}/enable", h.Webhook.Enable)
+		r.With(scope("webhooks:write")).Post("/webhooks/{webhookId}/test", h.Webhook.Test)
+		r.With(scope("webhooks:read")).Get("/webhooks/{webhookId}/events", h.Webhook.ListEvents)
+		r.With(scope("webhooks:write")).Post("/webhooks/{webhookId}/events/replay", h.Webhook.ReplayEvents)
+		r.With(scope("webhooks:write")).Post("/webhooks/{webhookId}/events/{eventId}/replay", h.Webhook.ReplayEvent)
+
+		// Suppressions
+		r.With(scope("suppressions:read")).Get("/suppressions", h.Suppression.List)
+		r.With(scope("suppressions:write")).Post("/suppressions", h.Suppression.Create)
+		r.With(scope("suppressions:read")).Get("/suppressions/export", h.Suppression.Export)
+		r.With(scope("suppressions:write")).Post("/suppressions/import", h.Suppression.Import)
+		r.With(scope("suppressions:write")).Delete("/suppressions/{suppressionId}", h.Suppression.Delete)
 
 		// Inbound Emails
-		r.Get("/inbound/emails", h.InboundEmail.List)
-		r.Get("/inbound/emails/{emailId}", h.InboundEmail.Get)
+		r.With(scope("inbound:read")).Get("/inbound/emails", h.InboundEmail.List)
+		r.With(scope("inbound:read")).Get("/inbound/emails/{emailId}", h.InboundEmail.Get)
 
 		// Logs
-		r.Get("/logs", h.Log.List)
+		r.With(scope("logs:read")).Get("/logs", h.Log.List)
 
 		// Metrics
-		r.Get("/metrics", h.Metrics.Get)
+		r.With(scope("metrics:read")).Get("/metrics", h.Metrics.Get)
 
 		// Settings
-		r.Get("/settings/usage", h.Settings.GetUsage)
-		r.Get("/settings/team", h.Settings.GetTeam)
-		r.Patch("/settings/team", h.Settings.UpdateTeam)
-		r.Get("/settings/smtp", h.Settings.GetSMTP)
-		r.Post("/settings/team/invite", h.Settings.InviteMember)
+		r.With(scope("settings:read")).Get("/settings/usage", h.Settings.GetUsage)
+		r.With(scope("settings:read")).Get("/settings/team", h.Settings.GetTeam)
+		r.With(scope("settings:write")).Patch("/settings/team", h.Settings.UpdateTeam)
+		r.With(scope("settings:read")).Get("/settings/smtp", h.Settings.GetSMTP)
+		r.With(scope("settings:write")).Post("/settings/team/invite", h.Settings.InviteMember)
+		r.With(scope("settings:read")).Get("/settings/team/members", h.Settings.ListMembers)
+		r.With(scope("settings:write")).Patch("/settings/team/members/{memberId}", h.Settings.UpdateMemberRole)
+		r.With(scope("settings:write")).Delete("/settings/team/members/{memberId}", h.Settings.RemoveMember)
+		r.With(scope("settings:write")).Post("/settings/team/transfer-ownership", h.Settings.TransferOwnership)
+		r.With(scope("settings:read")).Get("/settings/team/invitations", h.Settings.ListInvitations)
+		r.With(scope("settings:write")).Delete("/settings/team/invitations/{invitationId}", h.Settings.RevokeInvitation)
 	})
 
 	return &http.Server{
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,13 +23,15 @@ type APIKeyService interface {
 
 type apiKeyService struct {
 	apiKeyRepo   postgres.APIKeyRepository
+	domainRepo   postgres.DomainRepository
 	apiKeyPrefix string
 }
 
 // NewAPIKeyService creates a new APIKeyService.
-func NewAPIKeyService(apiKeyRepo postgres.APIKeyRepository, apiKeyPrefix string) APIKeyService {
+func NewAPIKeyService(apiKeyRepo postgres.APIKeyRepository, domainRepo postgres.DomainRepository, apiKeyPrefix string) APIKeyService {
 	return &apiKeyService{
 		apiKeyRepo:   apiKeyRepo,
+		domainRepo:   domainRepo,
 		apiKeyPrefix: apiKeyPrefix,
 	}
 }
@@ -38,30 +41,66 @@ func (s *apiKeyService) Create(ctx context.Context, teamID uuid.UUID, req *dto.C
 		return nil, fmt.Errorf("validation: %w", err)
 	}
 
-	// Default permission to "full" if not specified.
-	permission := req.Permission
-	if permission == "" {
-		permission = model.PermissionFull
-	}
-
-	// Generate the API key.
-	plaintext, hash, keyPrefix, err := pkg.GenerateAPIKey(s.apiKeyPrefix)
-	if err != nil {
-		return nil, fmt.Errorf("generating API key: %w", err)
+	// Default permission to "full", or "custom" if only scopes are given.
+	permission := model.ResolvePermission(req.Permission, req.Scopes)
+	var scopes []string
+	if permission == model.PermissionCustom {
+		if len(req.Scopes) == 0 {
+			return nil, fmt.Errorf("%w: custom permission requires scopes", pkg.ErrValidation)
+		}
+		for _, scope := range req.Scopes {
+			if !model.ValidScope(scope) {
+				return nil, fmt.Errorf("%w: unknown scope %q", pkg.ErrValidation, scope)
+			}
+		}
+		scopes = req.Scopes
+	} else if len(req.Scopes) > 0 {
+		return nil, fmt.Errorf("%w: scopes require the custom permission", pkg.ErrValidation)
 	}
 
-	// Parse optional domain ID.
+	// Parse optional domain ID; the domain must belong to the team.
 	var domainID *uuid.UUID
 	if req.DomainID != nil && *req.DomainID != "" {
 		id, err := uuid.Parse(*req.DomainID)
 		if err != nil {
-			return nil, fmt.Errorf("invalid domain_id: %w", err)
+			return nil, fmt.Errorf("%w: invalid domain_id: %w", pkg.ErrValidation, err)
+		}
+		if _, err := s.domainRepo.GetByTeamAndID(ctx, teamID, id); err != nil {
+			if errors.Is(err, postgres.ErrNotFound) {
+				return nil, fmt.Errorf("%w: domain %s not found", pkg.ErrValidation, id)
+			}
+			return nil, fmt.Errorf("getting domain: %w", err)
 		}
 		domainID = &id
 	}
 
+	for _, ip := range req.AllowedIPs {
+		if !model.ValidIPRange(ip) {
+			return nil, fmt.Errorf("%w: invalid allowed_ips entry %q: must be an IP address or CIDR range", pkg.ErrValidation, ip)
+		}
+	}
+
 	now := time.Now().UTC()
 
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil && *req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid expires_at format, expected RFC 3339", pkg.ErrValidation)
+		}
+		if !t.After(now) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", pkg.ErrValidation)
+		}
+		t = t.UTC()
+		expiresAt = &t
+	}
+
+	// Generate the API key.
+	plaintext, hash, keyPrefix, err := pkg.GenerateAPIKey(s.apiKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("generating API key: %w", err)
+	}
+
 	apiKey := &model.APIKey{
 		ID:         uuid.New(),
 		TeamID:     teamID,
@@ -69,7 +108,10 @@ func (s *apiKeyService) Create(ctx context.Context, teamID uuid.UUID, req *dto.C
 		KeyHash:    hash,
 		KeyPrefix:  keyPrefix,
 		Permission: permission,
+		Scopes:     scopes,
 		DomainID:   domainID,
+		AllowedIPs: req.AllowedIPs,
+		ExpiresAt:  expiresAt,
 		CreatedAt:  now,
 	}
 
@@ -78,14 +120,9 @@ func (s *apiKeyService) Create(ctx context.Context, teamID uuid.UUID, req *dto.C
 	}
 
 	// Return the plaintext token only on creation.
-	return &dto.APIKeyResponse{
-		ID:         apiKey.ID.String(),
-		Name:       apiKey.Name,
-		Token:      plaintext,
-		KeyPrefix:  apiKey.KeyPrefix,
-		Permission: apiKey.Permission,
-		CreatedAt:  apiKey.CreatedAt.Format(time.RFC3339),
-	}, nil
+	resp := apiKeyToResponse(apiKey)
+	resp.Token = plaintext
+	return &resp, nil
 }
 
 func (s *apiKeyService) List(ctx context.Context, teamID uuid.UUID) (*dto.ListResponse[dto.APIKeyResponse], error) {
@@ -95,14 +132,8 @@ func (s *apiKeyService) List(ctx context.Context, teamID uuid.UUID) (*dto.ListRe
 	}
 
 	responses := make([]dto.APIKeyResponse, 0, len(keys))
-	for _, k := range keys {
-		responses = append(responses, dto.APIKeyResponse{
-			ID:         k.ID.String(),
-			Name:       k.Name,
-			KeyPrefix:  k.KeyPrefix,
-			Permission: k.Permission,
-			CreatedAt:  k.CreatedAt.Format(time.RFC3339),
-		})
+	for i := range keys {
+		responses = append(responses, apiKeyToResponse(&keys[i]))
 	}
 
 	return &dto.ListResponse[dto.APIKeyResponse]{Data: responses}, nil
@@ -132,3 +163,22 @@ func (s *apiKeyService) Delete(ctx context.Context, teamID uuid.UUID, apiKeyID u
 
 	return nil
 }
+
+func apiKeyToResponse(k *model.APIKey) dto.APIKeyResponse {
+	resp := dto.APIKeyResponse{
+		ID:         k.ID.String(),
+		Name:       k.Name,
+		KeyPrefix:  k.KeyPrefix,
+		Permission: k.Permission,
+		Scopes:     k.Scopes,
+		AllowedIPs: k.AllowedIPs,
+		ExpiresAt:  formatOptionalTime(k.ExpiresAt),
+		LastUsedAt: formatOptionalTime(k.LastUsedAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.DomainID != nil {
+		id := k.DomainID.String()
+		resp.DomainID = &id
+	}
+	return resp
+}
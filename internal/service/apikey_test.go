@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -11,13 +12,15 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
 )
 
 func TestAPIKeyService_Create_HappyPath(t *testing.T) {
 	apiKeyRepo := new(tmock.MockAPIKeyRepository)
-	svc := NewAPIKeyService(apiKeyRepo, "re_")
+	svc := NewAPIKeyService(apiKeyRepo, new(tmock.MockDomainRepository), "re_")
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -42,7 +45,7 @@ func TestAPIKeyService_Create_HappyPath(t *testing.T) {
 
 func TestAPIKeyService_List_ReturnsKeysWithoutPlaintext(t *testing.T) {
 	apiKeyRepo := new(tmock.MockAPIKeyRepository)
-	svc := NewAPIKeyService(apiKeyRepo, "re_")
+	svc := NewAPIKeyService(apiKeyRepo, new(tmock.MockDomainRepository), "re_")
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -66,7 +69,7 @@ func TestAPIKeyService_List_ReturnsKeysWithoutPlaintext(t *testing.T) {
 
 func TestAPIKeyService_Delete_HappyPath(t *testing.T) {
 	apiKeyRepo := new(tmock.MockAPIKeyRepository)
-	svc := NewAPIKeyService(apiKeyRepo, "re_")
+	svc := NewAPIKeyService(apiKeyRepo, new(tmock.MockDomainRepository), "re_")
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -83,7 +86,7 @@ func TestAPIKeyService_Delete_HappyPath(t *testing.T) {
 
 func TestAPIKeyService_Delete_NotFound(t *testing.T) {
 	apiKeyRepo := new(tmock.MockAPIKeyRepository)
-	svc := NewAPIKeyService(apiKeyRepo, "re_")
+	svc := NewAPIKeyService(apiKeyRepo, new(tmock.MockDomainRepository), "re_")
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -98,3 +101,64 @@ func TestAPIKeyService_Delete_NotFound(t *testing.T) {
 
 	apiKeyRepo.AssertExpectations(t)
 }
+
+func TestAPIKeyService_Create_Restricted(t *testing.T) {
+	apiKeyRepo := new(tmock.MockAPIKeyRepository)
+	domainRepo := new(tmock.MockDomainRepository)
+	svc := NewAPIKeyService(apiKeyRepo, domainRepo, "re_")
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domain := testutil.NewTestDomain()
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domain.ID).Return(domain, nil)
+	apiKeyRepo.On("Create", ctx, mock.AnythingOfType("*model.APIKey")).Return(nil)
+
+	domainID := domain.ID.String()
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	resp, err := svc.Create(ctx, teamID, &dto.CreateAPIKeyRequest{
+		Name:       "CI",
+		Scopes:     []string{"emails:send", "templates:read"},
+		DomainID:   &domainID,
+		AllowedIPs: []string{"203.0.113.7", "10.0.0.0/8"},
+		ExpiresAt:  &expiresAt,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, model.PermissionCustom, resp.Permission)
+	assert.Equal(t, []string{"emails:send", "templates:read"}, resp.Scopes)
+	assert.Equal(t, &domainID, resp.DomainID)
+	assert.Equal(t, []string{"203.0.113.7", "10.0.0.0/8"}, resp.AllowedIPs)
+	assert.Equal(t, &expiresAt, resp.ExpiresAt)
+	assert.NotEmpty(t, resp.Token)
+}
+
+func TestAPIKeyService_Create_Invalid(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	otherDomain := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		req     dto.CreateAPIKeyRequest
+		wantErr string
+	}{
+		{"unknown scope", dto.CreateAPIKeyRequest{Name: "k", Scopes: []string{"emails:delete"}}, `unknown scope "emails:delete"`},
+		{"custom without scopes", dto.CreateAPIKeyRequest{Name: "k", Permission: "custom"}, "custom permission requires scopes"},
+		{"scopes with preset", dto.CreateAPIKeyRequest{Name: "k", Permission: "sending", Scopes: []string{"emails:read"}}, "scopes require the custom permission"},
+		{"bad ip", dto.CreateAPIKeyRequest{Name: "k", AllowedIPs: []string{"10.0.0.0/33"}}, `invalid allowed_ips entry "10.0.0.0/33"`},
+		{"expired", dto.CreateAPIKeyRequest{Name: "k", ExpiresAt: &past}, "expires_at must be in the future"},
+		{"foreign domain", dto.CreateAPIKeyRequest{Name: "k", DomainID: &otherDomain}, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domainRepo := new(tmock.MockDomainRepository)
+			domainRepo.On("GetByTeamAndID", mock.Anything, testutil.TestTeamID, mock.Anything).Return(nil, postgres.ErrNotFound)
+			svc := NewAPIKeyService(new(tmock.MockAPIKeyRepository), domainRepo, "re_")
+
+			_, err := svc.Create(context.Background(), testutil.TestTeamID, &tt.req)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, pkg.ErrValidation)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -5,17 +5,36 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
-// AttachmentStorage stores and retrieves attachment content.
+// AttachmentStorage stores and retrieves attachment content. Store returns a
+// key relative to the storage backend; the key is what gets saved in the
+// attachments JSONB, so any replica configured with the same backend can
+// open it.
 type AttachmentStorage interface {
-	Store(ctx context.Context, teamID uuid.UUID, filename string, content io.Reader) (path string, err error)
+	Store(ctx context.Context, teamID uuid.UUID, filename string, content io.Reader) (key string, err error)
+	// Open returns the attachment content. It returns an error wrapping
+	// fs.ErrNotExist when the key does not exist.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the attachment. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
 }
 
-// LocalAttachmentStorage stores attachments on the local filesystem.
+// attachmentKey builds a unique storage key of the form
+// teamID/uuid_filename.
+func attachmentKey(teamID uuid.UUID, filename string) string {
+	// Unique prefix to avoid collisions.
+	return path.Join(teamID.String(), uuid.New().String()+"_"+filepath.Base(filename))
+}
+
+// LocalAttachmentStorage stores attachments on the local filesystem. Several
+// replicas can share it only when basePath is on a shared volume; use
+// S3AttachmentStorage otherwise.
 type LocalAttachmentStorage struct {
 	basePath string
 }
@@ -27,15 +46,13 @@ func NewLocalAttachmentStorage(basePath string) *LocalAttachmentStorage {
 
 // Store writes the attachment content to a file under basePath/teamID/unique-filename.
 func (s *LocalAttachmentStorage) Store(ctx context.Context, teamID uuid.UUID, filename string, content io.Reader) (string, error) {
-	dir := filepath.Join(s.basePath, teamID.String())
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	key := attachmentKey(teamID, filename)
+	fullPath := filepath.Join(s.basePath, filepath.FromSlash(key))
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
 		return "", fmt.Errorf("creating attachment directory: %w", err)
 	}
 
-	// Unique prefix to avoid collisions.
-	safeFilename := uuid.New().String() + "_" + filepath.Base(filename)
-	fullPath := filepath.Join(dir, safeFilename)
-
 	f, err := os.Create(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("creating attachment file: %w", err)
@@ -46,5 +63,50 @@ func (s *LocalAttachmentStorage) Store(ctx context.Context, teamID uuid.UUID, fi
 		return "", fmt.Errorf("writing attachment content: %w", err)
 	}
 
+	return key, nil
+}
+
+// Open opens the attachment file for the given key.
+func (s *LocalAttachmentStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening attachment: %w", err)
+	}
+	return f, nil
+}
+
+// Delete removes the attachment file for the given key.
+func (s *LocalAttachmentStorage) Delete(ctx context.Context, key string) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("deleting attachment: %w", err)
+	}
+	return nil
+}
+
+// resolve maps a key to a file path, refusing keys that escape basePath.
+// Attachments stored before keys were introduced saved the full file path,
+// which is accepted as long as it lies under basePath.
+func (s *LocalAttachmentStorage) resolve(key string) (string, error) {
+	base := filepath.Clean(s.basePath)
+
+	fullPath := filepath.Clean(key)
+	if !strings.HasPrefix(fullPath, base+string(filepath.Separator)) {
+		fullPath = filepath.Join(base, filepath.FromSlash(key))
+	}
+
+	rel, err := filepath.Rel(base, fullPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid attachment key %q", key)
+	}
 	return fullPath, nil
 }
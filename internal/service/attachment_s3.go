@@ -0,0 +1,230 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// S3StorageConfig holds the settings for an S3AttachmentStorage.
+type S3StorageConfig struct {
+	Bucket string
+	Region string
+	// Endpoint is the base URL of an S3-compatible service such as MinIO.
+	// When set, objects are addressed path-style (endpoint/bucket/key);
+	// otherwise the AWS virtual-hosted endpoint for Region is used.
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+}
+
+// S3AttachmentStorage stores attachments as objects in an S3-compatible
+// bucket, so that every replica of the server shares the same attachments.
+// Requests are signed with AWS Signature Version 4.
+type S3AttachmentStorage struct {
+	cfg    S3StorageConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3AttachmentStorage creates a new S3 attachment storage.
+func NewS3AttachmentStorage(cfg S3StorageConfig) (*S3AttachmentStorage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage: bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("s3 storage: invalid endpoint %q", cfg.Endpoint)
+		}
+		cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	}
+
+	return &S3AttachmentStorage{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 60 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+// Store uploads the attachment content as a new object.
+func (s *S3AttachmentStorage) Store(ctx context.Context, teamID uuid.UUID, filename string, content io.Reader) (string, error) {
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return "", fmt.Errorf("reading attachment content: %w", err)
+	}
+
+	key := attachmentKey(teamID, filename)
+	resp, err := s.do(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return "", fmt.Errorf("uploading attachment: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", s3Error("uploading attachment", resp)
+	}
+	return key, nil
+}
+
+// Open downloads the object for the given key. The caller must close the
+// returned reader.
+func (s *S3AttachmentStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("downloading attachment: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("downloading attachment %s: %w", key, fs.ErrNotExist)
+	default:
+		defer func() { _ = resp.Body.Close() }()
+		return nil, s3Error("downloading attachment", resp)
+	}
+}
+
+// Delete removes the object for the given key.
+func (s *S3AttachmentStorage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("deleting attachment: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error("deleting attachment", resp)
+	}
+}
+
+// do sends a signed request for the object at key.
+func (s *S3AttachmentStorage) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	var objectURL string
+	if s.cfg.Endpoint != "" {
+		objectURL = s.cfg.Endpoint + "/" + s3URIEncode(s.cfg.Bucket) + "/" + s3URIEncode(key)
+	} else {
+		objectURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.cfg.Bucket, s.cfg.Region, s3URIEncode(key))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	sum := sha256.Sum256(body)
+	signS3Request(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, hex.EncodeToString(sum[:]), s.now())
+
+	return s.client.Do(req)
+}
+
+// s3Error builds an error from a failed S3 response, including the S3 error
+// code from the XML body when there is one.
+func s3Error(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	msg := strings.TrimSpace(string(body))
+	if start := strings.Index(msg, "<Code>"); start >= 0 {
+		if end := strings.Index(msg[start:], "</Code>"); end >= 0 {
+			msg = msg[start+len("<Code>") : start+end]
+		}
+	}
+	return fmt.Errorf("%s: s3 returned %d: %s", action, resp.StatusCode, msg)
+}
+
+// signS3Request adds AWS Signature Version 4 headers to req. The host header
+// and every header already set on the request are signed.
+func signS3Request(req *http.Request, accessKey, secretKey, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3URIEncode percent-encodes an object key as S3 expects in the canonical
+// URI: every byte except unreserved characters and '/' is encoded.
+func s3URIEncode(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
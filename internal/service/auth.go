@@ -21,6 +21,9 @@ import (
 type AuthService interface {
 	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.AuthResponse, error)
 	Login(ctx context.Context, req *dto.LoginRequest) (*dto.AuthResponse, error)
+	ListTeams(ctx context.Context, userID, currentTeamID uuid.UUID) ([]dto.TeamSummaryResponse, error)
+	SwitchTeam(ctx context.Context, userID uuid.UUID, req *dto.SwitchTeamRequest) (*dto.AuthResponse, error)
+	CreateTeam(ctx context.Context, userID uuid.UUID, req *dto.CreateTeamRequest) (*dto.AuthResponse, error)
 }
 
 type authService struct {
@@ -181,3 +184,102 @@ func (s *authService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Au
 
 	return resp, nil
 }
+
+func (s *authService) ListTeams(ctx context.Context, userID, currentTeamID uuid.UUID) ([]dto.TeamSummaryResponse, error) {
+	teams, err := s.teamRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing teams: %w", err)
+	}
+
+	resp := make([]dto.TeamSummaryResponse, len(teams))
+	for i, t := range teams {
+		resp[i] = teamSummary(&t.Team, t.Role, t.ID == currentTeamID)
+	}
+	return resp, nil
+}
+
+// SwitchTeam issues a token for another team the user belongs to.
+func (s *authService) SwitchTeam(ctx context.Context, userID uuid.UUID, req *dto.SwitchTeamRequest) (*dto.AuthResponse, error) {
+	teamID, err := uuid.Parse(req.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid team_id", pkg.ErrValidation)
+	}
+
+	// Non-members get the same not-found error as for teams that do not exist.
+	member, err := s.teamMemberRepo.GetByTeamAndUser(ctx, teamID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting team membership: %w", err)
+	}
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("getting team: %w", err)
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+
+	return s.teamAuthResponse(user, team, member.Role)
+}
+
+// CreateTeam creates a team owned by the user and issues a token for it.
+func (s *authService) CreateTeam(ctx context.Context, userID uuid.UUID, req *dto.CreateTeamRequest) (*dto.AuthResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+
+	// Slugs are unique; fall back to a suffixed slug if the name is taken.
+	now := time.Now().UTC()
+	team := &model.Team{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		Slug:      slugify(req.Name),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if existing, _ := s.teamRepo.GetBySlug(ctx, team.Slug); existing != nil {
+		team.Slug += "-" + team.ID.String()[:8]
+	}
+
+	member := &model.TeamMember{
+		ID:        uuid.New(),
+		TeamID:    team.ID,
+		UserID:    user.ID,
+		Role:      model.RoleOwner,
+		CreatedAt: now,
+	}
+	if err := s.teamRepo.CreateWithOwner(ctx, team, member); err != nil {
+		return nil, fmt.Errorf("creating team: %w", err)
+	}
+
+	return s.teamAuthResponse(user, team, member.Role)
+}
+
+func (s *authService) teamAuthResponse(user *model.User, team *model.Team, role string) (*dto.AuthResponse, error) {
+	token, err := middleware.GenerateJWT(s.jwtSecret, user.ID, team.ID, s.jwtExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("generating token: %w", err)
+	}
+
+	summary := teamSummary(team, role, true)
+	resp := &dto.AuthResponse{
+		Token: token,
+		Team:  &summary,
+	}
+	resp.User.ID = user.ID.String()
+	resp.User.Email = user.Email
+	resp.User.Name = user.Name
+
+	return resp, nil
+}
+
+func teamSummary(team *model.Team, role string, current bool) dto.TeamSummaryResponse {
+	return dto.TeamSummaryResponse{
+		ID:      team.ID.String(),
+		Name:    team.Name,
+		Slug:    team.Slug,
+		Role:    role,
+		Current: current,
+	}
+}
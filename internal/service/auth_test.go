@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -174,3 +176,85 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 
 	userRepo.AssertExpectations(t)
 }
+
+func TestAuthService_SwitchTeam(t *testing.T) {
+	userRepo := new(tmock.MockUserRepository)
+	teamRepo := new(tmock.MockTeamRepository)
+	memberRepo := new(tmock.MockTeamMemberRepository)
+
+	svc := NewAuthService(userRepo, teamRepo, memberRepo, "test-secret", time.Hour, bcrypt.MinCost)
+	ctx := context.Background()
+
+	user := &model.User{ID: uuid.New(), Email: "alice@example.com", Name: "Alice"}
+	team := &model.Team{ID: uuid.New(), Name: "Client B", Slug: "client-b"}
+	otherTeamID := uuid.New()
+
+	memberRepo.On("GetByTeamAndUser", ctx, team.ID, user.ID).Return(&model.TeamMember{TeamID: team.ID, UserID: user.ID, Role: model.RoleAdmin}, nil)
+	memberRepo.On("GetByTeamAndUser", ctx, otherTeamID, user.ID).Return(nil, postgres.ErrNotFound)
+	teamRepo.On("GetByID", ctx, team.ID).Return(team, nil)
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	resp, err := svc.SwitchTeam(ctx, user.ID, &dto.SwitchTeamRequest{TeamID: team.ID.String()})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Team)
+	assert.Equal(t, team.ID.String(), resp.Team.ID)
+	assert.Equal(t, model.RoleAdmin, resp.Team.Role)
+
+	// The new token is scoped to the chosen team.
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, team.ID.String(), claims["team_id"])
+
+	_, err = svc.SwitchTeam(ctx, user.ID, &dto.SwitchTeamRequest{TeamID: otherTeamID.String()})
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+}
+
+func TestAuthService_CreateTeam_SlugTaken(t *testing.T) {
+	userRepo := new(tmock.MockUserRepository)
+	teamRepo := new(tmock.MockTeamRepository)
+	memberRepo := new(tmock.MockTeamMemberRepository)
+
+	svc := NewAuthService(userRepo, teamRepo, memberRepo, "test-secret", time.Hour, bcrypt.MinCost)
+	ctx := context.Background()
+
+	user := &model.User{ID: uuid.New(), Email: "alice@example.com", Name: "Alice"}
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	teamRepo.On("GetBySlug", ctx, "client-c").Return(&model.Team{ID: uuid.New(), Slug: "client-c"}, nil)
+	teamRepo.On("CreateWithOwner", ctx, mock.MatchedBy(func(team *model.Team) bool {
+		return team.Name == "Client C" && team.Slug == "client-c-"+team.ID.String()[:8]
+	}), mock.MatchedBy(func(m *model.TeamMember) bool {
+		return m.UserID == user.ID && m.Role == model.RoleOwner
+	})).Return(nil)
+
+	resp, err := svc.CreateTeam(ctx, user.ID, &dto.CreateTeamRequest{Name: "Client C"})
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleOwner, resp.Team.Role)
+	assert.NotEmpty(t, resp.Token)
+
+	teamRepo.AssertExpectations(t)
+	memberRepo.AssertExpectations(t)
+}
+
+func TestAuthService_CreateTeam_OwnerNotCreated(t *testing.T) {
+	userRepo := new(tmock.MockUserRepository)
+	teamRepo := new(tmock.MockTeamRepository)
+	memberRepo := new(tmock.MockTeamMemberRepository)
+
+	svc := NewAuthService(userRepo, teamRepo, memberRepo, "test-secret", time.Hour, bcrypt.MinCost)
+	ctx := context.Background()
+
+	user := &model.User{ID: uuid.New(), Email: "alice@example.com", Name: "Alice"}
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	teamRepo.On("GetBySlug", ctx, "client-d").Return(nil, postgres.ErrNotFound)
+	teamRepo.On("CreateWithOwner", ctx, mock.AnythingOfType("*model.Team"), mock.AnythingOfType("*model.TeamMember")).Return(assert.AnError)
+
+	// The team and its owner are created together, so neither is left behind.
+	resp, err := svc.CreateTeam(ctx, user.ID, &dto.CreateTeamRequest{Name: "Client D"})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, resp)
+	teamRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	memberRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,18 +28,63 @@ type BroadcastService interface {
 	Update(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, req *dto.UpdateBroadcastRequest) (*dto.BroadcastResponse, error)
 	Delete(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) error
 	Send(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error)
+	Schedule(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, req *dto.ScheduleBroadcastRequest) (*dto.BroadcastResponse, error)
+	Unschedule(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error)
+	Pause(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error)
+	Resume(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error)
+	Cancel(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error)
+	Stats(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastStatsResponse, error)
+	ListRecipients(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, status, event, search string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.BroadcastRecipientResponse], error)
+}
+
+// broadcastRecipientStatuses lists the email statuses a broadcast's
+// recipients can be filtered by.
+var broadcastRecipientStatuses = map[string]bool{
+	model.EmailStatusHeld:      true,
+	model.EmailStatusQueued:    true,
+	model.EmailStatusSending:   true,
+	model.EmailStatusSent:      true,
+	model.EmailStatusDelivered: true,
+	model.EmailStatusBounced:   true,
+	model.EmailStatusFailed:    true,
+	model.EmailStatusCancelled: true,
+}
+
+// broadcastRecipientEvents lists the email events a broadcast's recipients
+// can be filtered by.
+var broadcastRecipientEvents = map[string]bool{
+	model.EventSent:         true,
+	model.EventDelivered:    true,
+	model.EventBounced:      true,
+	model.EventFailed:       true,
+	model.EventOpened:       true,
+	model.EventClicked:      true,
+	model.EventUnsubscribed: true,
+	model.EventComplained:   true,
 }
 
 type broadcastService struct {
-	broadcastRepo postgres.BroadcastRepository
-	asynqClient   *asynq.Client
+	broadcastRepo       postgres.BroadcastRepository
+	variantRepo         postgres.BroadcastVariantRepository
+	topicRepo           postgres.TopicRepository
+	propertyRepo        postgres.ContactPropertyRepository
+	templateVersionRepo postgres.TemplateVersionRepository
+	emailRepo           postgres.EmailRepository
+	domainRepo          postgres.DomainRepository
+	asynqClient         *asynq.Client
 }
 
 // NewBroadcastService creates a new BroadcastService.
-func NewBroadcastService(broadcastRepo postgres.BroadcastRepository, asynqClient *asynq.Client) BroadcastService {
+func NewBroadcastService(broadcastRepo postgres.BroadcastRepository, variantRepo postgres.BroadcastVariantRepository, topicRepo postgres.TopicRepository, propertyRepo postgres.ContactPropertyRepository, templateVersionRepo postgres.TemplateVersionRepository, emailRepo postgres.EmailRepository, domainRepo postgres.DomainRepository, asynqClient *asynq.Client) BroadcastService {
 	return &broadcastService{
-		broadcastRepo: broadcastRepo,
-		asynqClient:   asynqClient,
+		broadcastRepo:       broadcastRepo,
+		variantRepo:         variantRepo,
+		topicRepo:           topicRepo,
+		propertyRepo:        propertyRepo,
+		templateVersionRepo: templateVersionRepo,
+		emailRepo:           emailRepo,
+		domainRepo:          domainRepo,
+		asynqClient:         asynqClient,
 	}
 }
 
@@ -56,6 +104,7 @@ func (s *broadcastService) Create(ctx context.Context, teamID uuid.UUID, req *dt
 		Subject:     req.Subject,
 		HTMLBody:    req.HTML,
 		TextBody:    req.Text,
+		SendRate:    req.SendRate,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -82,12 +131,41 @@ func (s *broadcastService) Create(ctx context.Context, teamID uuid.UUID, req *dt
 		}
 		broadcast.TemplateID = &id
 	}
+	if req.TopicID != nil && *req.TopicID != "" {
+		id, err := s.resolveTopic(ctx, teamID, *req.TopicID)
+		if err != nil {
+			return nil, err
+		}
+		broadcast.TopicID = id
+	}
+	var variants []model.BroadcastVariant
+	if req.ABTest != nil {
+		var err error
+		if variants, err = s.applyABTest(ctx, broadcast, req.ABTest); err != nil {
+			return nil, err
+		}
+	}
+	if err := applySchedule(broadcast, req.ScheduledAt, req.SendInLocalTime, req.TimezoneProperty); err != nil {
+		return nil, err
+	}
+
+	// Inline content may only use the variables every broadcast provides.
+	if err := checkTemplateContent(broadcast.Subject, broadcast.HTMLBody, broadcast.TextBody, nil); err != nil {
+		return nil, err
+	}
 
 	if err := s.broadcastRepo.Create(ctx, broadcast); err != nil {
 		return nil, fmt.Errorf("creating broadcast: %w", err)
 	}
+	if len(variants) > 0 {
+		if err := s.variantRepo.ReplaceForBroadcast(ctx, broadcast.ID, variants); err != nil {
+			return nil, fmt.Errorf("creating broadcast variants: %w", err)
+		}
+	}
 
-	return broadcastToResponse(broadcast), nil
+	resp := broadcastToResponse(broadcast)
+	resp.ABTest = abTestToResponse(broadcast, variants, nil)
+	return resp, nil
 }
 
 func (s *broadcastService) List(ctx context.Context, teamID uuid.UUID, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.BroadcastResponse], error) {
@@ -129,7 +207,19 @@ func (s *broadcastService) Get(ctx context.Context, teamID uuid.UUID, broadcastI
 		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
 	}
 
-	return broadcastToResponse(broadcast), nil
+	resp := broadcastToResponse(broadcast)
+	if broadcast.ABTestPercentage != nil {
+		variants, err := s.variantRepo.ListByBroadcastID(ctx, broadcast.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing broadcast variants: %w", err)
+		}
+		stats, err := s.variantRepo.ListStats(ctx, broadcast.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching broadcast variant stats: %w", err)
+		}
+		resp.ABTest = abTestToResponse(broadcast, variants, stats)
+	}
+	return resp, nil
 }
 
 func (s *broadcastService) Update(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, req *dto.UpdateBroadcastRequest) (*dto.BroadcastResponse, error) {
@@ -163,6 +253,16 @@ func (s *broadcastService) Update(ctx context.Context, teamID uuid.UUID, broadca
 	if req.Text != nil {
 		broadcast.TextBody = req.Text
 	}
+	// A send rate of 0 removes the cap.
+	if req.SendRate != nil {
+		if *req.SendRate < 0 {
+			return nil, fmt.Errorf("%w: send_rate must not be negative", pkg.ErrValidation)
+		}
+		broadcast.SendRate = nil
+		if *req.SendRate > 0 {
+			broadcast.SendRate = req.SendRate
+		}
+	}
 
 	if req.AudienceID != nil && *req.AudienceID != "" {
 		id, err := uuid.Parse(*req.AudienceID)
@@ -178,6 +278,30 @@ func (s *broadcastService) Update(ctx context.Context, teamID uuid.UUID, broadca
 		}
 		broadcast.SegmentID = &id
 	}
+	// An empty topic_id detaches the broadcast from its topic.
+	if req.TopicID != nil {
+		broadcast.TopicID = nil
+		if *req.TopicID != "" {
+			id, err := s.resolveTopic(ctx, teamID, *req.TopicID)
+			if err != nil {
+				return nil, err
+			}
+			broadcast.TopicID = id
+		}
+	}
+	var variants []model.BroadcastVariant
+	if req.ABTest != nil {
+		if variants, err = s.applyABTest(ctx, broadcast, req.ABTest); err != nil {
+			return nil, err
+		}
+	}
+	if err := applySchedule(broadcast, req.ScheduledAt, req.SendInLocalTime, req.TimezoneProperty); err != nil {
+		return nil, err
+	}
+
+	if err := checkTemplateContent(broadcast.Subject, broadcast.HTMLBody, broadcast.TextBody, nil); err != nil {
+		return nil, err
+	}
 
 	broadcast.UpdatedAt = time.Now().UTC()
 
@@ -185,7 +309,20 @@ func (s *broadcastService) Update(ctx context.Context, teamID uuid.UUID, broadca
 		return nil, fmt.Errorf("updating broadcast: %w", err)
 	}
 
-	return broadcastToResponse(broadcast), nil
+	switch {
+	case req.ABTest != nil:
+		if err := s.variantRepo.ReplaceForBroadcast(ctx, broadcast.ID, variants); err != nil {
+			return nil, fmt.Errorf("updating broadcast variants: %w", err)
+		}
+	case broadcast.ABTestPercentage != nil:
+		if variants, err = s.variantRepo.ListByBroadcastID(ctx, broadcast.ID); err != nil {
+			return nil, fmt.Errorf("listing broadcast variants: %w", err)
+		}
+	}
+
+	resp := broadcastToResponse(broadcast)
+	resp.ABTest = abTestToResponse(broadcast, variants, nil)
+	return resp, nil
 }
 
 func (s *broadcastService) Delete(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) error {
@@ -199,9 +336,10 @@ func (s *broadcastService) Delete(ctx context.Context, teamID uuid.UUID, broadca
 		return fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
 	}
 
-	// Prevent deleting broadcasts that are actively sending.
-	if broadcast.Status == model.BroadcastStatusSending {
-		return fmt.Errorf("cannot delete a broadcast that is currently sending")
+	// Prevent deleting broadcasts that are actively sending. A paused
+	// broadcast still holds emails; cancel it first.
+	if broadcast.Status == model.BroadcastStatusSending || broadcast.Status == model.BroadcastStatusPaused {
+		return fmt.Errorf("cannot delete a broadcast that is currently sending or paused")
 	}
 
 	if err := s.broadcastRepo.Delete(ctx, broadcastID); err != nil {
@@ -226,25 +364,24 @@ func (s *broadcastService) Send(ctx context.Context, teamID uuid.UUID, broadcast
 	if broadcast.Status != model.BroadcastStatusDraft {
 		return nil, fmt.Errorf("only draft broadcasts can be sent")
 	}
-	if broadcast.AudienceID == nil {
-		return nil, fmt.Errorf("broadcast must have an audience before sending")
-	}
-	if broadcast.FromAddress == nil || *broadcast.FromAddress == "" {
-		return nil, fmt.Errorf("broadcast must have a from address before sending")
+	if err := checkReadyToSend(broadcast); err != nil {
+		return nil, err
 	}
-	// Must have content: either a template or inline HTML/text.
-	hasTemplate := broadcast.TemplateID != nil
-	hasInlineContent := (broadcast.HTMLBody != nil && *broadcast.HTMLBody != "") || (broadcast.TextBody != nil && *broadcast.TextBody != "")
-	if !hasTemplate && !hasInlineContent {
-		return nil, fmt.Errorf("broadcast must have content (template or inline HTML/text) before sending")
+	if err := s.checkABTestTracking(ctx, broadcast); err != nil {
+		return nil, err
 	}
-	if broadcast.Subject == nil || *broadcast.Subject == "" {
-		return nil, fmt.Errorf("broadcast must have a subject before sending")
+
+	// A broadcast with a future schedule waits until it is due.
+	now := time.Now().UTC()
+	if broadcast.ScheduledAt != nil && (broadcast.SendInLocalTime || broadcast.ScheduledAt.After(now)) {
+		return s.schedule(ctx, broadcast, now)
 	}
 
-	// Update status to queued.
+	// Update status to queued. The broadcast is due now, and stays due until
+	// the broadcast:send task has created all its emails.
 	broadcast.Status = model.BroadcastStatusQueued
-	broadcast.UpdatedAt = time.Now().UTC()
+	broadcast.NextSendAt = &now
+	broadcast.UpdatedAt = now
 
 	if err := s.broadcastRepo.Update(ctx, broadcast); err != nil {
 		return nil, fmt.Errorf("updating broadcast status: %w", err)
@@ -259,14 +396,473 @@ func (s *broadcastService) Send(ctx context.Context, teamID uuid.UUID, broadcast
 		return nil, fmt.Errorf("marshalling task payload: %w", err)
 	}
 
+	// The task has the ID the broadcast:dispatch task gives it, so that it
+	// isn't queued again while it is running.
 	task := asynq.NewTask(worker.TaskBroadcastSend, payload)
-	if _, err := s.asynqClient.Enqueue(task, asynq.Queue(worker.QueueCritical), asynq.MaxRetry(3)); err != nil {
+	if _, err := s.asynqClient.Enqueue(task, asynq.Queue(worker.QueueCritical), asynq.MaxRetry(3), asynq.TaskID(worker.BroadcastSendTaskID(broadcast.ID, now))); err != nil {
 		return nil, fmt.Errorf("enqueueing broadcast send task: %w", err)
 	}
 
 	return broadcastToResponse(broadcast), nil
 }
 
+func (s *broadcastService) Schedule(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, req *dto.ScheduleBroadcastRequest) (*dto.BroadcastResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation: %w", err)
+	}
+
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	// Drafts are scheduled, and broadcasts still waiting are rescheduled.
+	if broadcast.Status != model.BroadcastStatusDraft && broadcast.Status != model.BroadcastStatusScheduled {
+		return nil, fmt.Errorf("%w: only draft or scheduled broadcasts can be scheduled", pkg.ErrValidation)
+	}
+	if err := applySchedule(broadcast, &req.ScheduledAt, req.SendInLocalTime, req.TimezoneProperty); err != nil {
+		return nil, err
+	}
+	if broadcast.ScheduledAt == nil {
+		return nil, fmt.Errorf("%w: scheduled_at is required", pkg.ErrValidation)
+	}
+	now := time.Now().UTC()
+	if !broadcast.SendInLocalTime && !broadcast.ScheduledAt.After(now) {
+		return nil, fmt.Errorf("%w: scheduled_at must be in the future", pkg.ErrValidation)
+	}
+	if err := checkReadyToSend(broadcast); err != nil {
+		return nil, err
+	}
+	if err := s.checkABTestTracking(ctx, broadcast); err != nil {
+		return nil, err
+	}
+
+	return s.schedule(ctx, broadcast, now)
+}
+
+func (s *broadcastService) Unschedule(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	// Once a broadcast has started sending, including a first local-time
+	// wave, it can no longer be unscheduled.
+	if broadcast.Status != model.BroadcastStatusScheduled {
+		return nil, fmt.Errorf("%w: only scheduled broadcasts can be unscheduled", pkg.ErrValidation)
+	}
+
+	broadcast.Status = model.BroadcastStatusDraft
+	broadcast.ScheduledAt = nil
+	broadcast.NextSendAt = nil
+	broadcast.LocalSentUntil = nil
+	broadcast.UpdatedAt = time.Now().UTC()
+
+	if err := s.broadcastRepo.Update(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("updating broadcast: %w", err)
+	}
+
+	return broadcastToResponse(broadcast), nil
+}
+
+func (s *broadcastService) Pause(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	if broadcast.Status != model.BroadcastStatusSending {
+		return nil, fmt.Errorf("%w: only sending broadcasts can be paused", pkg.ErrValidation)
+	}
+
+	broadcast.Status = model.BroadcastStatusPaused
+	broadcast.UpdatedAt = time.Now().UTC()
+
+	if err := s.broadcastRepo.Update(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("updating broadcast: %w", err)
+	}
+
+	// Emails already queued go back on hold. Those being sent right now
+	// still go out.
+	if _, err := s.emailRepo.UpdateStatusByBroadcast(ctx, broadcast.ID, []string{model.EmailStatusQueued}, model.EmailStatusHeld); err != nil {
+		return nil, fmt.Errorf("holding queued emails: %w", err)
+	}
+
+	return broadcastToResponse(broadcast), nil
+}
+
+func (s *broadcastService) Resume(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	if broadcast.Status != model.BroadcastStatusPaused {
+		return nil, fmt.Errorf("%w: only paused broadcasts can be resumed", pkg.ErrValidation)
+	}
+
+	broadcast.Status = model.BroadcastStatusSending
+	broadcast.UpdatedAt = time.Now().UTC()
+
+	if err := s.broadcastRepo.Update(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("updating broadcast: %w", err)
+	}
+
+	// Release the held emails now rather than at the next scheduled
+	// dispatch. That dispatch comes within a minute and releases them
+	// anyway, so a failure to queue one here is not an error.
+	if task, err := worker.NewBroadcastDispatchTask(); err == nil {
+		_, _ = s.asynqClient.Enqueue(task)
+	}
+
+	return broadcastToResponse(broadcast), nil
+}
+
+func (s *broadcastService) Cancel(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	switch broadcast.Status {
+	case model.BroadcastStatusScheduled, model.BroadcastStatusQueued, model.BroadcastStatusSending, model.BroadcastStatusPaused:
+	default:
+		return nil, fmt.Errorf("%w: a %s broadcast cannot be cancelled", pkg.ErrValidation, broadcast.Status)
+	}
+
+	broadcast.Status = model.BroadcastStatusCancelled
+	broadcast.NextSendAt = nil
+	broadcast.UpdatedAt = time.Now().UTC()
+
+	if err := s.broadcastRepo.Update(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("updating broadcast: %w", err)
+	}
+
+	// Emails already sent, or being sent, are not recalled.
+	if _, err := s.emailRepo.UpdateStatusByBroadcast(ctx, broadcast.ID, []string{model.EmailStatusHeld, model.EmailStatusQueued}, model.EmailStatusCancelled); err != nil {
+		return nil, fmt.Errorf("cancelling outstanding emails: %w", err)
+	}
+
+	return broadcastToResponse(broadcast), nil
+}
+
+func (s *broadcastService) Stats(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastStatsResponse, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	stats, err := s.broadcastRepo.GetStats(ctx, broadcast.ID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching broadcast stats: %w", err)
+	}
+	links, err := s.broadcastRepo.ListLinkStats(ctx, broadcast.ID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching broadcast link stats: %w", err)
+	}
+
+	reached := stats.Delivered + stats.Bounced
+	resp := &dto.BroadcastStatsResponse{
+		BroadcastID:     broadcast.ID.String(),
+		Recipients:      stats.Recipients,
+		Delivered:       stats.Delivered,
+		Bounced:         stats.Bounced,
+		Opened:          stats.Opened,
+		Clicked:         stats.Clicked,
+		Unsubscribed:    stats.Unsubscribed,
+		Complained:      stats.Complained,
+		DeliveryRate:    shareOf(stats.Delivered, reached),
+		BounceRate:      shareOf(stats.Bounced, reached),
+		OpenRate:        shareOf(stats.Opened, stats.Delivered),
+		ClickRate:       shareOf(stats.Clicked, stats.Delivered),
+		UnsubscribeRate: shareOf(stats.Unsubscribed, stats.Delivered),
+		ComplaintRate:   shareOf(stats.Complained, stats.Delivered),
+		Links:           make([]dto.BroadcastLinkStatsResponse, 0, len(links)),
+	}
+	for _, l := range links {
+		resp.Links = append(resp.Links, dto.BroadcastLinkStatsResponse{URL: l.URL, Clicks: l.Clicks, UniqueClicks: l.UniqueClicks})
+	}
+	return resp, nil
+}
+
+func (s *broadcastService) ListRecipients(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, status, event, search string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.BroadcastRecipientResponse], error) {
+	if status != "" && !broadcastRecipientStatuses[status] {
+		return nil, fmt.Errorf("%w: invalid status %q", pkg.ErrValidation, status)
+	}
+	if event != "" && !broadcastRecipientEvents[event] {
+		return nil, fmt.Errorf("%w: invalid event %q", pkg.ErrValidation, event)
+	}
+
+	broadcast, err := s.broadcastRepo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast not found: %w", err)
+	}
+
+	// Verify the broadcast belongs to the team.
+	if broadcast.TeamID != teamID {
+		return nil, fmt.Errorf("broadcast not found: %w", postgres.ErrNotFound)
+	}
+
+	params.Normalize()
+
+	recipients, total, err := s.broadcastRepo.ListRecipients(ctx, broadcast.ID, status, event, strings.TrimSpace(search), params.PerPage, params.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("listing broadcast recipients: %w", err)
+	}
+
+	data := make([]dto.BroadcastRecipientResponse, 0, len(recipients))
+	for _, rc := range recipients {
+		data = append(data, broadcastRecipientToResponse(&rc))
+	}
+
+	totalPages := 0
+	if params.PerPage > 0 {
+		totalPages = (total + params.PerPage - 1) / params.PerPage
+	}
+
+	return &dto.PaginatedResponse[dto.BroadcastRecipientResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       params.Page,
+		PerPage:    params.PerPage,
+		TotalPages: totalPages,
+		HasMore:    params.Page < totalPages,
+	}, nil
+}
+
+// schedule marks a broadcast ready to send as scheduled. The broadcast:dispatch
+// task queues it when it is due.
+func (s *broadcastService) schedule(ctx context.Context, broadcast *model.Broadcast, now time.Time) (*dto.BroadcastResponse, error) {
+	if broadcast.SendInLocalTime {
+		if !worker.LocalTimeSendEnds(*broadcast.ScheduledAt).After(now) {
+			return nil, fmt.Errorf("%w: scheduled_at has passed in every time zone", pkg.ErrValidation)
+		}
+		if err := s.checkTimezoneProperty(ctx, broadcast); err != nil {
+			return nil, err
+		}
+	}
+
+	next := worker.FirstBroadcastSendAt(broadcast, now)
+	broadcast.Status = model.BroadcastStatusScheduled
+	broadcast.NextSendAt = &next
+	broadcast.LocalSentUntil = nil
+	broadcast.UpdatedAt = now
+
+	if err := s.broadcastRepo.Update(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("updating broadcast status: %w", err)
+	}
+
+	return broadcastToResponse(broadcast), nil
+}
+
+// checkTimezoneProperty verifies that the contact property a broadcast sent
+// in local time reads time zones from is a string property of the team.
+func (s *broadcastService) checkTimezoneProperty(ctx context.Context, broadcast *model.Broadcast) error {
+	name := model.DefaultTimezoneProperty
+	if broadcast.TimezoneProperty != nil {
+		name = *broadcast.TimezoneProperty
+	}
+
+	properties, err := s.propertyRepo.ListByTeamID(ctx, broadcast.TeamID)
+	if err != nil {
+		return fmt.Errorf("listing contact properties: %w", err)
+	}
+	for _, p := range properties {
+		if p.Name != name {
+			continue
+		}
+		if p.Type != model.ValueTypeString {
+			return fmt.Errorf("%w: time zone property %q must be a string property", pkg.ErrValidation, name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: contact property %q not found; create it to hold recipients' time zones", pkg.ErrValidation, name)
+}
+
+// applySchedule sets the schedule fields of a broadcast from a request. An
+// empty scheduledAt clears the schedule.
+func applySchedule(broadcast *model.Broadcast, scheduledAt *string, localTime *bool, timezoneProperty *string) error {
+	if scheduledAt != nil {
+		broadcast.ScheduledAt = nil
+		if *scheduledAt != "" {
+			t, err := time.Parse(time.RFC3339, *scheduledAt)
+			if err != nil {
+				return fmt.Errorf("%w: invalid scheduled_at format, expected RFC 3339", pkg.ErrValidation)
+			}
+			t = t.UTC()
+			broadcast.ScheduledAt = &t
+		}
+	}
+	if localTime != nil {
+		broadcast.SendInLocalTime = *localTime
+	}
+	if timezoneProperty != nil {
+		broadcast.TimezoneProperty = nil
+		if *timezoneProperty != "" {
+			broadcast.TimezoneProperty = timezoneProperty
+		}
+	}
+	if broadcast.SendInLocalTime && broadcast.ScheduledAt == nil {
+		return fmt.Errorf("%w: send_in_local_time requires scheduled_at", pkg.ErrValidation)
+	}
+	if broadcast.SendInLocalTime && broadcast.ABTestPercentage != nil {
+		return fmt.Errorf("%w: an A/B tested broadcast can't be sent in local time", pkg.ErrValidation)
+	}
+	return nil
+}
+
+// maxABTestVariants is how many variants an A/B test may have.
+const maxABTestVariants = 5
+
+// applyABTest sets up the A/B test of a broadcast from a request and returns
+// its variants. A request without variants removes the test.
+func (s *broadcastService) applyABTest(ctx context.Context, broadcast *model.Broadcast, req *dto.BroadcastABTestRequest) ([]model.BroadcastVariant, error) {
+	broadcast.ABTestEndsAt = nil
+	broadcast.ABWinnerVariantID = nil
+	broadcast.ABTestInconclusive = false
+	if len(req.Variants) == 0 {
+		broadcast.ABTestPercentage = nil
+		broadcast.ABTestWaitMinutes = nil
+		broadcast.ABWinnerMetric = nil
+		return nil, nil
+	}
+
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation: %w", err)
+	}
+	if len(req.Variants) < 2 || len(req.Variants) > maxABTestVariants {
+		return nil, fmt.Errorf("%w: an A/B test needs 2 to %d variants", pkg.ErrValidation, maxABTestVariants)
+	}
+	if len(req.Variants)*req.TestPercentage > 100 {
+		return nil, fmt.Errorf("%w: %d variants at test_percentage %d need more than the whole audience", pkg.ErrValidation, len(req.Variants), req.TestPercentage)
+	}
+
+	now := time.Now().UTC()
+	variants := make([]model.BroadcastVariant, 0, len(req.Variants))
+	for i, v := range req.Variants {
+		name := v.Name
+		if name == "" {
+			name = string(rune('A' + i))
+		}
+
+		if v.TemplateVersion != nil {
+			if broadcast.TemplateID == nil {
+				return nil, fmt.Errorf("%w: variant %s: template_version requires the broadcast to have a template", pkg.ErrValidation, name)
+			}
+			if _, err := s.templateVersionRepo.GetByTemplateIDAndVersion(ctx, *broadcast.TemplateID, *v.TemplateVersion); err != nil {
+				if errors.Is(err, postgres.ErrNotFound) {
+					return nil, fmt.Errorf("%w: variant %s: template version %d not found", pkg.ErrValidation, name, *v.TemplateVersion)
+				}
+				return nil, fmt.Errorf("fetching template version: %w", err)
+			}
+		}
+		if err := checkTemplateContent(v.Subject, v.HTML, v.Text, nil); err != nil {
+			return nil, fmt.Errorf("variant %s: %w", name, err)
+		}
+
+		variants = append(variants, model.BroadcastVariant{
+			ID:              uuid.New(),
+			BroadcastID:     broadcast.ID,
+			Name:            name,
+			Position:        i,
+			Subject:         v.Subject,
+			FromName:        v.FromName,
+			TemplateVersion: v.TemplateVersion,
+			HTMLBody:        v.HTML,
+			TextBody:        v.Text,
+			CreatedAt:       now,
+		})
+	}
+
+	broadcast.ABTestPercentage = &req.TestPercentage
+	broadcast.ABTestWaitMinutes = &req.WaitMinutes
+	broadcast.ABWinnerMetric = &req.WinnerMetric
+	return variants, nil
+}
+
+// checkABTestTracking rejects an A/B test whose winner metric isn't tracked
+// on the sending domain, as none of its variants could ever win.
+func (s *broadcastService) checkABTestTracking(ctx context.Context, broadcast *model.Broadcast) error {
+	if broadcast.ABWinnerMetric == nil {
+		return nil
+	}
+	from := *broadcast.FromAddress
+	if addr, err := mail.ParseAddress(from); err == nil {
+		from = addr.Address
+	}
+	name := strings.ToLower(from[strings.LastIndex(from, "@")+1:])
+	domain, err := s.domainRepo.GetByTeamAndName(ctx, broadcast.TeamID, name)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("%w: domain %s is not registered for this team; add and verify it before sending", pkg.ErrValidation, name)
+		}
+		return fmt.Errorf("fetching sending domain: %w", err)
+	}
+
+	switch *broadcast.ABWinnerMetric {
+	case model.ABWinnerMetricOpenRate:
+		if !domain.OpenTracking {
+			return fmt.Errorf("%w: the A/B test picks its winner by open_rate, but open tracking is off for domain %s", pkg.ErrValidation, name)
+		}
+	case model.ABWinnerMetricClickRate:
+		if !domain.ClickTracking {
+			return fmt.Errorf("%w: the A/B test picks its winner by click_rate, but click tracking is off for domain %s", pkg.ErrValidation, name)
+		}
+	}
+	return nil
+}
+
+// checkReadyToSend verifies a broadcast has everything it needs to be sent.
+func checkReadyToSend(broadcast *model.Broadcast) error {
+	if broadcast.AudienceID == nil {
+		return fmt.Errorf("broadcast must have an audience before sending")
+	}
+	if broadcast.FromAddress == nil || *broadcast.FromAddress == "" {
+		return fmt.Errorf("broadcast must have a from address before sending")
+	}
+	// Must have content: either a template or inline HTML/text.
+	hasTemplate := broadcast.TemplateID != nil
+	hasInlineContent := (broadcast.HTMLBody != nil && *broadcast.HTMLBody != "") || (broadcast.TextBody != nil && *broadcast.TextBody != "")
+	if !hasTemplate && !hasInlineContent {
+		return fmt.Errorf("broadcast must have content (template or inline HTML/text) before sending")
+	}
+	if broadcast.Subject == nil || *broadcast.Subject == "" {
+		return fmt.Errorf("broadcast must have a subject before sending")
+	}
+
+	return nil
+}
+
 // broadcastToResponse converts a model.Broadcast to a dto.BroadcastResponse.
 func broadcastToResponse(b *model.Broadcast) *dto.BroadcastResponse {
 	resp := &dto.BroadcastResponse{
@@ -283,6 +879,116 @@ func broadcastToResponse(b *model.Broadcast) *dto.BroadcastResponse {
 		resp.AudienceID = &aid
 	}
 	resp.AudienceName = b.AudienceName
+	if b.TopicID != nil {
+		tid := b.TopicID.String()
+		resp.TopicID = &tid
+	}
+	if b.ScheduledAt != nil {
+		s := b.ScheduledAt.Format(time.RFC3339)
+		resp.ScheduledAt = &s
+	}
+	resp.SendInLocalTime = b.SendInLocalTime
+	resp.TimezoneProperty = b.TimezoneProperty
+	if b.NextSendAt != nil {
+		s := b.NextSendAt.Format(time.RFC3339)
+		resp.NextSendAt = &s
+	}
+	resp.SendRate = b.SendRate
+
+	return resp
+}
 
+// abTestToResponse converts the A/B test of a broadcast, with its variants
+// and their stats if loaded, to a dto.BroadcastABTestResponse. It returns nil
+// for a broadcast without an A/B test.
+func abTestToResponse(b *model.Broadcast, variants []model.BroadcastVariant, stats []model.BroadcastVariantStats) *dto.BroadcastABTestResponse {
+	if b.ABTestPercentage == nil || b.ABTestWaitMinutes == nil || b.ABWinnerMetric == nil {
+		return nil
+	}
+
+	resp := &dto.BroadcastABTestResponse{
+		TestPercentage: *b.ABTestPercentage,
+		WaitMinutes:    *b.ABTestWaitMinutes,
+		WinnerMetric:   *b.ABWinnerMetric,
+		Inconclusive:   b.ABTestInconclusive,
+		Variants:       make([]dto.BroadcastVariantResponse, 0, len(variants)),
+	}
+	if b.ABTestEndsAt != nil {
+		t := b.ABTestEndsAt.Format(time.RFC3339)
+		resp.TestEndsAt = &t
+	}
+	if b.ABWinnerVariantID != nil {
+		id := b.ABWinnerVariantID.String()
+		resp.WinnerVariantID = &id
+	}
+
+	byVariant := make(map[uuid.UUID]model.BroadcastVariantStats, len(stats))
+	for _, s := range stats {
+		byVariant[s.VariantID] = s
+	}
+	for _, v := range variants {
+		st := byVariant[v.ID]
+		resp.Variants = append(resp.Variants, dto.BroadcastVariantResponse{
+			ID:              v.ID.String(),
+			Name:            v.Name,
+			Subject:         v.Subject,
+			FromName:        v.FromName,
+			TemplateVersion: v.TemplateVersion,
+			Recipients:      st.Recipients,
+			Sent:            st.Sent,
+			Opened:          st.Opened,
+			Clicked:         st.Clicked,
+			OpenRate:        st.OpenRate(),
+			ClickRate:       st.ClickRate(),
+		})
+	}
+	return resp
+}
+
+func broadcastRecipientToResponse(rc *model.BroadcastRecipient) dto.BroadcastRecipientResponse {
+	resp := dto.BroadcastRecipientResponse{
+		EmailID:   rc.EmailID.String(),
+		Email:     rc.Email,
+		Status:    rc.Status,
+		LastEvent: rc.LastEvent,
+	}
+	if rc.VariantID != nil {
+		id := rc.VariantID.String()
+		resp.VariantID = &id
+	}
+	if rc.SentAt != nil {
+		t := rc.SentAt.Format(time.RFC3339)
+		resp.SentAt = &t
+	}
+	if rc.LastEventAt != nil {
+		t := rc.LastEventAt.Format(time.RFC3339)
+		resp.LastEventAt = &t
+	}
 	return resp
 }
+
+// shareOf returns n as a share of total, or 0 if total is 0.
+func shareOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// resolveTopic parses a topic_id from a broadcast request and verifies that
+// the topic belongs to the team.
+func (s *broadcastService) resolveTopic(ctx context.Context, teamID uuid.UUID, raw string) (*uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid topic_id %q", pkg.ErrValidation, raw)
+	}
+
+	if _, err := s.topicRepo.GetByTeamAndID(ctx, teamID, id); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: topic %s not found", pkg.ErrValidation, id)
+		}
+		return nil, fmt.Errorf("fetching topic: %w", err)
+	}
+
+	return &id, nil
+}
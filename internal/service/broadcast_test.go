@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
@@ -27,7 +29,7 @@ func newBroadcastTestDeps(t *testing.T) (*tmock.MockBroadcastRepository, *asynq.
 
 func TestBroadcastService_Create_HappyPath(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -50,9 +52,71 @@ func TestBroadcastService_Create_HappyPath(t *testing.T) {
 	broadcastRepo.AssertExpectations(t)
 }
 
+func TestBroadcastService_Create_WithTopic(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), topicRepo, new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	topicID := uuid.New()
+	topicRepo.On("GetByTeamAndID", ctx, teamID, topicID).Return(&model.Topic{ID: topicID, TeamID: teamID}, nil)
+	broadcastRepo.On("Create", ctx, mock.MatchedBy(func(b *model.Broadcast) bool {
+		return b.TopicID != nil && *b.TopicID == topicID
+	})).Return(nil)
+
+	resp, err := svc.Create(ctx, teamID, &dto.CreateBroadcastRequest{
+		Name:    "Product news",
+		TopicID: testutil.StringPtr(topicID.String()),
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.TopicID)
+	assert.Equal(t, topicID.String(), *resp.TopicID)
+
+	broadcastRepo.AssertExpectations(t)
+}
+
+func TestBroadcastService_Create_UnknownTopic(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), topicRepo, new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	topicID := uuid.New()
+	topicRepo.On("GetByTeamAndID", ctx, teamID, topicID).Return(nil, postgres.ErrNotFound)
+
+	resp, err := svc.Create(ctx, teamID, &dto.CreateBroadcastRequest{
+		Name:    "Product news",
+		TopicID: testutil.StringPtr(topicID.String()),
+	})
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	broadcastRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestBroadcastService_Create_InvalidContent(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+
+	req := &dto.CreateBroadcastRequest{
+		Name:    "Weekly Newsletter",
+		Subject: testutil.StringPtr("Hi {{contact.first_name}}"),
+		HTML:    testutil.StringPtr("<p>{{coupon_code}}</p>"),
+	}
+
+	_, err := svc.Create(context.Background(), testutil.TestTeamID, req)
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "undeclared variables: coupon_code")
+	broadcastRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestBroadcastService_List_Paginated(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -71,7 +135,7 @@ func TestBroadcastService_List_Paginated(t *testing.T) {
 
 func TestBroadcastService_Get_HappyPath(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -88,7 +152,7 @@ func TestBroadcastService_Get_HappyPath(t *testing.T) {
 
 func TestBroadcastService_Get_WrongTeam(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -106,7 +170,7 @@ func TestBroadcastService_Get_WrongTeam(t *testing.T) {
 
 func TestBroadcastService_Update_OnlyDraft(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -130,7 +194,7 @@ func TestBroadcastService_Update_OnlyDraft(t *testing.T) {
 
 func TestBroadcastService_Update_NonDraftFails(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -153,7 +217,7 @@ func TestBroadcastService_Update_NonDraftFails(t *testing.T) {
 
 func TestBroadcastService_Delete_CannotDeleteSending(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -171,7 +235,7 @@ func TestBroadcastService_Delete_CannotDeleteSending(t *testing.T) {
 
 func TestBroadcastService_Delete_DraftOK(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -189,7 +253,7 @@ func TestBroadcastService_Delete_DraftOK(t *testing.T) {
 
 func TestBroadcastService_Send_HappyPath(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -201,13 +265,14 @@ func TestBroadcastService_Send_HappyPath(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Equal(t, model.BroadcastStatusQueued, resp.Status)
+	assert.NotNil(t, resp.NextSendAt, "the broadcast is due until its emails are created")
 
 	broadcastRepo.AssertExpectations(t)
 }
 
 func TestBroadcastService_Send_NotDraftFails(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -224,7 +289,7 @@ func TestBroadcastService_Send_NotDraftFails(t *testing.T) {
 
 func TestBroadcastService_Send_NoAudienceFails(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -241,7 +306,7 @@ func TestBroadcastService_Send_NoAudienceFails(t *testing.T) {
 
 func TestBroadcastService_Send_NoFromFails(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -258,7 +323,7 @@ func TestBroadcastService_Send_NoFromFails(t *testing.T) {
 
 func TestBroadcastService_Send_NoSubjectFails(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -273,9 +338,34 @@ func TestBroadcastService_Send_NoSubjectFails(t *testing.T) {
 	assert.Contains(t, err.Error(), "subject")
 }
 
+func TestBroadcastService_Send_ABTestMetricNotTracked(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	domainRepo := new(tmock.MockDomainRepository)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), domainRepo, asynqClient)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	bc := testutil.NewTestBroadcast()
+	metric := model.ABWinnerMetricClickRate
+	bc.ABWinnerMetric = &metric
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+
+	// The domain tracks opens, but not the clicks the test compares.
+	domain := testutil.NewTestDomain()
+	domain.OpenTracking = true
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").Return(domain, nil)
+
+	resp, err := svc.Send(ctx, teamID, bc.ID)
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "click tracking is off")
+	broadcastRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
 func TestBroadcastService_Get_NotFound(t *testing.T) {
 	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
-	svc := NewBroadcastService(broadcastRepo, asynqClient)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 	badID := uuid.New()
@@ -289,3 +379,436 @@ func TestBroadcastService_Get_NotFound(t *testing.T) {
 
 	broadcastRepo.AssertExpectations(t)
 }
+
+func TestBroadcastService_Send_ScheduledInFuture(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	bc := testutil.NewTestBroadcast()
+	scheduledAt := time.Now().UTC().Add(24 * time.Hour)
+	bc.ScheduledAt = &scheduledAt
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+
+	resp, err := svc.Send(ctx, teamID, bc.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusScheduled, resp.Status)
+	require.NotNil(t, bc.NextSendAt)
+	assert.True(t, bc.NextSendAt.Equal(scheduledAt))
+}
+
+func TestBroadcastService_Schedule_LocalTime(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), propertyRepo, new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	bc := testutil.NewTestBroadcast()
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+	propertyRepo.On("ListByTeamID", ctx, teamID).Return([]model.ContactProperty{
+		{Name: "tz", Type: model.ValueTypeString},
+	}, nil)
+
+	scheduledAt := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+	localTime := true
+	resp, err := svc.Schedule(ctx, teamID, bc.ID, &dto.ScheduleBroadcastRequest{
+		ScheduledAt:      scheduledAt.Format(time.RFC3339),
+		SendInLocalTime:  &localTime,
+		TimezoneProperty: testutil.StringPtr("tz"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusScheduled, resp.Status)
+	assert.True(t, resp.SendInLocalTime)
+	require.NotNil(t, bc.NextSendAt)
+	assert.True(t, bc.NextSendAt.Equal(scheduledAt.Add(-14*time.Hour)), "first wave goes out in UTC+14")
+}
+
+func TestBroadcastService_Schedule_Invalid(t *testing.T) {
+	localTime := true
+	tests := []struct {
+		name       string
+		req        *dto.ScheduleBroadcastRequest
+		properties []model.ContactProperty
+		wantErr    string
+	}{
+		{
+			name:    "malformed time",
+			req:     &dto.ScheduleBroadcastRequest{ScheduledAt: "tomorrow"},
+			wantErr: "RFC 3339",
+		},
+		{
+			name:    "time in the past",
+			req:     &dto.ScheduleBroadcastRequest{ScheduledAt: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			wantErr: "in the future",
+		},
+		{
+			name:    "local time passed everywhere",
+			req:     &dto.ScheduleBroadcastRequest{ScheduledAt: time.Now().Add(-13 * time.Hour).Format(time.RFC3339), SendInLocalTime: &localTime},
+			wantErr: "every time zone",
+		},
+		{
+			name:    "missing time zone property",
+			req:     &dto.ScheduleBroadcastRequest{ScheduledAt: time.Now().Add(time.Hour).Format(time.RFC3339), SendInLocalTime: &localTime},
+			wantErr: "not found",
+		},
+		{
+			name:       "time zone property not a string",
+			req:        &dto.ScheduleBroadcastRequest{ScheduledAt: time.Now().Add(time.Hour).Format(time.RFC3339), SendInLocalTime: &localTime},
+			properties: []model.ContactProperty{{Name: model.DefaultTimezoneProperty, Type: model.ValueTypeNumber}},
+			wantErr:    "must be a string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+			propertyRepo := new(tmock.MockContactPropertyRepository)
+			svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), propertyRepo, new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+			ctx := context.Background()
+
+			bc := testutil.NewTestBroadcast()
+			broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+			propertyRepo.On("ListByTeamID", ctx, testutil.TestTeamID).Return(tt.properties, nil)
+
+			resp, err := svc.Schedule(ctx, testutil.TestTeamID, bc.ID, tt.req)
+
+			assert.Nil(t, resp)
+			assert.ErrorIs(t, err, pkg.ErrValidation)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			broadcastRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestBroadcastService_Unschedule(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	scheduledAt := time.Now().UTC().Add(time.Hour)
+	bc.Status = model.BroadcastStatusScheduled
+	bc.ScheduledAt = &scheduledAt
+	bc.NextSendAt = &scheduledAt
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+
+	resp, err := svc.Unschedule(ctx, testutil.TestTeamID, bc.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusDraft, resp.Status)
+	assert.Nil(t, bc.ScheduledAt)
+	assert.Nil(t, bc.NextSendAt)
+
+	t.Run("already sending", func(t *testing.T) {
+		bc.Status = model.BroadcastStatusSending
+		_, err := svc.Unschedule(ctx, testutil.TestTeamID, bc.ID)
+		assert.ErrorIs(t, err, pkg.ErrValidation)
+	})
+}
+
+func TestBroadcastService_Pause(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	emailRepo := new(tmock.MockEmailRepository)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), emailRepo, new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	bc.Status = model.BroadcastStatusSending
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+	emailRepo.On("UpdateStatusByBroadcast", ctx, bc.ID, []string{model.EmailStatusQueued}, model.EmailStatusHeld).Return(int64(12), nil)
+
+	resp, err := svc.Pause(ctx, testutil.TestTeamID, bc.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusPaused, resp.Status)
+	emailRepo.AssertExpectations(t)
+
+	t.Run("not sending", func(t *testing.T) {
+		_, err := svc.Pause(ctx, testutil.TestTeamID, bc.ID)
+		assert.ErrorIs(t, err, pkg.ErrValidation)
+	})
+}
+
+func TestBroadcastService_Resume(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	bc.Status = model.BroadcastStatusPaused
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+
+	resp, err := svc.Resume(ctx, testutil.TestTeamID, bc.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusSending, resp.Status)
+
+	t.Run("not paused", func(t *testing.T) {
+		_, err := svc.Resume(ctx, testutil.TestTeamID, bc.ID)
+		assert.ErrorIs(t, err, pkg.ErrValidation)
+	})
+}
+
+func TestBroadcastService_Cancel(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	emailRepo := new(tmock.MockEmailRepository)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), emailRepo, new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	bc.Status = model.BroadcastStatusPaused
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+	emailRepo.On("UpdateStatusByBroadcast", ctx, bc.ID, []string{model.EmailStatusHeld, model.EmailStatusQueued}, model.EmailStatusCancelled).Return(int64(40), nil)
+
+	resp, err := svc.Cancel(ctx, testutil.TestTeamID, bc.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusCancelled, resp.Status)
+	emailRepo.AssertExpectations(t)
+
+	t.Run("already cancelled", func(t *testing.T) {
+		_, err := svc.Cancel(ctx, testutil.TestTeamID, bc.ID)
+		assert.ErrorIs(t, err, pkg.ErrValidation)
+	})
+}
+
+func TestBroadcastService_Update_SendRate(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("Update", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+
+	rate := 600
+	resp, err := svc.Update(ctx, testutil.TestTeamID, bc.ID, &dto.UpdateBroadcastRequest{SendRate: &rate})
+	require.NoError(t, err)
+	require.NotNil(t, resp.SendRate)
+	assert.Equal(t, 600, *resp.SendRate)
+
+	// A rate of 0 removes the cap.
+	none := 0
+	resp, err = svc.Update(ctx, testutil.TestTeamID, bc.ID, &dto.UpdateBroadcastRequest{SendRate: &none})
+	require.NoError(t, err)
+	assert.Nil(t, resp.SendRate)
+}
+
+func TestBroadcastService_Create_ABTest(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	variantRepo := new(tmock.MockBroadcastVariantRepository)
+	svc := NewBroadcastService(broadcastRepo, variantRepo, new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	broadcastRepo.On("Create", ctx, mock.AnythingOfType("*model.Broadcast")).Return(nil)
+	variantRepo.On("ReplaceForBroadcast", ctx, mock.AnythingOfType("uuid.UUID"), mock.MatchedBy(func(v []model.BroadcastVariant) bool {
+		return len(v) == 2 && v[0].Name == "A" && *v[1].Subject == "Last chance"
+	})).Return(nil)
+
+	req := &dto.CreateBroadcastRequest{
+		Name:    "Spring sale",
+		From:    testutil.StringPtr("news@example.com"),
+		Subject: testutil.StringPtr("Spring sale"),
+		Text:    testutil.StringPtr("20% off"),
+		ABTest: &dto.BroadcastABTestRequest{
+			Variants:       []dto.BroadcastVariantRequest{{}, {Subject: testutil.StringPtr("Last chance")}},
+			TestPercentage: 10,
+			WaitMinutes:    120,
+			WinnerMetric:   model.ABWinnerMetricOpenRate,
+		},
+	}
+
+	resp, err := svc.Create(ctx, testutil.TestTeamID, req)
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.ABTest)
+	assert.Equal(t, 10, resp.ABTest.TestPercentage)
+	require.Len(t, resp.ABTest.Variants, 2)
+	assert.Equal(t, "B", resp.ABTest.Variants[1].Name)
+	variantRepo.AssertExpectations(t)
+}
+
+func TestBroadcastService_Create_ABTestInvalid(t *testing.T) {
+	future := time.Now().UTC().Add(24 * time.Hour).Format(time.RFC3339)
+	abTest := func(variants, percentage int) *dto.BroadcastABTestRequest {
+		return &dto.BroadcastABTestRequest{
+			Variants:       make([]dto.BroadcastVariantRequest, variants),
+			TestPercentage: percentage,
+			WaitMinutes:    60,
+			WinnerMetric:   model.ABWinnerMetricClickRate,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(req *dto.CreateBroadcastRequest)
+		wantErr string
+	}{
+		{"one variant", func(req *dto.CreateBroadcastRequest) { req.ABTest = abTest(1, 10) }, "2 to 5 variants"},
+		{"more than the audience", func(req *dto.CreateBroadcastRequest) { req.ABTest = abTest(3, 40) }, "more than the whole audience"},
+		{"template version without template", func(req *dto.CreateBroadcastRequest) {
+			req.ABTest = abTest(2, 10)
+			version := 2
+			req.ABTest.Variants[1].TemplateVersion = &version
+		}, "requires the broadcast to have a template"},
+		{"local time", func(req *dto.CreateBroadcastRequest) {
+			req.ABTest = abTest(2, 10)
+			req.ScheduledAt = &future
+			req.SendInLocalTime = testutil.BoolPtr(true)
+		}, "can't be sent in local time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+			svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+
+			req := &dto.CreateBroadcastRequest{Name: "Spring sale", Subject: testutil.StringPtr("Spring sale")}
+			tt.modify(req)
+			_, err := svc.Create(context.Background(), testutil.TestTeamID, req)
+
+			assert.ErrorIs(t, err, pkg.ErrValidation)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			broadcastRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestBroadcastService_Get_ABTestStats(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	variantRepo := new(tmock.MockBroadcastVariantRepository)
+	svc := NewBroadcastService(broadcastRepo, variantRepo, new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	percentage, wait, metric := 20, 60, model.ABWinnerMetricOpenRate
+	bc.ABTestPercentage, bc.ABTestWaitMinutes, bc.ABWinnerMetric = &percentage, &wait, &metric
+	variants := []model.BroadcastVariant{{ID: uuid.New(), Name: "A"}, {ID: uuid.New(), Name: "B"}}
+	bc.ABWinnerVariantID = &variants[1].ID
+
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	variantRepo.On("ListByBroadcastID", ctx, bc.ID).Return(variants, nil)
+	variantRepo.On("ListStats", ctx, bc.ID).Return([]model.BroadcastVariantStats{
+		{VariantID: variants[0].ID, Recipients: 50, Sent: 40, Opened: 10, Clicked: 2},
+		{VariantID: variants[1].ID, Recipients: 50, Sent: 50, Opened: 20, Clicked: 5},
+	}, nil)
+
+	resp, err := svc.Get(ctx, testutil.TestTeamID, bc.ID)
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.ABTest)
+	assert.Equal(t, variants[1].ID.String(), *resp.ABTest.WinnerVariantID)
+	require.Len(t, resp.ABTest.Variants, 2)
+	assert.InDelta(t, 0.25, resp.ABTest.Variants[0].OpenRate, 1e-9)
+	assert.InDelta(t, 0.4, resp.ABTest.Variants[1].OpenRate, 1e-9)
+	assert.InDelta(t, 0.1, resp.ABTest.Variants[1].ClickRate, 1e-9)
+}
+
+func TestBroadcastService_Stats(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("GetStats", ctx, bc.ID).Return(&model.BroadcastStats{
+		Recipients: 100, Delivered: 80, Bounced: 20, Opened: 40, Clicked: 10, Unsubscribed: 2, Complained: 1,
+	}, nil)
+	broadcastRepo.On("ListLinkStats", ctx, bc.ID).Return([]model.BroadcastLinkStats{
+		{URL: "https://example.com/launch", Clicks: 12, UniqueClicks: 9},
+		{URL: "https://example.com/pricing", Clicks: 1, UniqueClicks: 1},
+	}, nil)
+
+	resp, err := svc.Stats(ctx, testutil.TestTeamID, bc.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 100, resp.Recipients)
+	assert.InDelta(t, 0.8, resp.DeliveryRate, 1e-9)
+	assert.InDelta(t, 0.2, resp.BounceRate, 1e-9)
+	assert.InDelta(t, 0.5, resp.OpenRate, 1e-9, "engagement rates are shares of delivered emails")
+	assert.InDelta(t, 0.125, resp.ClickRate, 1e-9)
+	assert.InDelta(t, 0.025, resp.UnsubscribeRate, 1e-9)
+	assert.InDelta(t, 0.0125, resp.ComplaintRate, 1e-9)
+	assert.Equal(t, []dto.BroadcastLinkStatsResponse{
+		{URL: "https://example.com/launch", Clicks: 12, UniqueClicks: 9},
+		{URL: "https://example.com/pricing", Clicks: 1, UniqueClicks: 1},
+	}, resp.Links)
+
+	t.Run("nothing sent yet", func(t *testing.T) {
+		broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+		svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+		broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+		broadcastRepo.On("GetStats", ctx, bc.ID).Return(&model.BroadcastStats{Recipients: 10}, nil)
+		broadcastRepo.On("ListLinkStats", ctx, bc.ID).Return([]model.BroadcastLinkStats{}, nil)
+
+		resp, err := svc.Stats(ctx, testutil.TestTeamID, bc.ID)
+
+		require.NoError(t, err)
+		assert.Zero(t, resp.DeliveryRate)
+		assert.Zero(t, resp.OpenRate)
+		assert.NotNil(t, resp.Links)
+	})
+}
+
+func TestBroadcastService_Stats_WrongTeam(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	bc.TeamID = uuid.New()
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+
+	_, err := svc.Stats(ctx, testutil.TestTeamID, bc.ID)
+
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+	broadcastRepo.AssertNotCalled(t, "GetStats", mock.Anything, mock.Anything)
+}
+
+func TestBroadcastService_ListRecipients(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	bc := testutil.NewTestBroadcast()
+	variantID := uuid.New()
+	event, at := model.EventClicked, time.Date(2026, 3, 2, 9, 15, 0, 0, time.UTC)
+	broadcastRepo.On("GetByID", ctx, bc.ID).Return(bc, nil)
+	broadcastRepo.On("ListRecipients", ctx, bc.ID, model.EmailStatusSent, model.EventClicked, "example.com", 20, 20).Return([]model.BroadcastRecipient{
+		{EmailID: uuid.New(), Email: "reader@example.com", Status: model.EmailStatusSent, VariantID: &variantID, LastEvent: &event, LastEventAt: &at},
+	}, 21, nil)
+
+	resp, err := svc.ListRecipients(ctx, testutil.TestTeamID, bc.ID, model.EmailStatusSent, model.EventClicked, " example.com ", &dto.PaginationParams{Page: 2, PerPage: 20})
+
+	require.NoError(t, err)
+	assert.Equal(t, 21, resp.Total)
+	assert.False(t, resp.HasMore)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, variantID.String(), *resp.Data[0].VariantID)
+	assert.Equal(t, "clicked", *resp.Data[0].LastEvent)
+	assert.Equal(t, "2026-03-02T09:15:00Z", *resp.Data[0].LastEventAt)
+	assert.Nil(t, resp.Data[0].SentAt)
+}
+
+func TestBroadcastService_ListRecipients_InvalidFilter(t *testing.T) {
+	broadcastRepo, asynqClient := newBroadcastTestDeps(t)
+	svc := NewBroadcastService(broadcastRepo, new(tmock.MockBroadcastVariantRepository), new(tmock.MockTopicRepository), new(tmock.MockContactPropertyRepository), new(tmock.MockTemplateVersionRepository), new(tmock.MockEmailRepository), new(tmock.MockDomainRepository), asynqClient)
+	ctx := context.Background()
+
+	_, err := svc.ListRecipients(ctx, testutil.TestTeamID, uuid.New(), "lost", "", "", &dto.PaginationParams{})
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+
+	_, err = svc.ListRecipients(ctx, testutil.TestTeamID, uuid.New(), "", "read", "", &dto.PaginationParams{})
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	broadcastRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
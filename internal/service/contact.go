@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,18 +21,41 @@ type ContactService interface {
 	Get(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) (*dto.ContactResponse, error)
 	Update(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID, req *dto.UpdateContactRequest) (*dto.ContactResponse, error)
 	Delete(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) error
+	ListTopics(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) (*dto.ListResponse[dto.ContactTopicResponse], error)
+	UpdateTopics(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID, req *dto.UpdateContactTopicsRequest) (*dto.ListResponse[dto.ContactTopicResponse], error)
 }
 
 type contactService struct {
-	contactRepo  postgres.ContactRepository
-	audienceRepo postgres.AudienceRepository
+	contactRepo       postgres.ContactRepository
+	audienceRepo      postgres.AudienceRepository
+	propertyRepo      postgres.ContactPropertyRepository
+	propertyValueRepo postgres.ContactPropertyValueRepository
+	segmentRepo       postgres.SegmentRepository
+	topicRepo         postgres.TopicRepository
+	contactTopicRepo  postgres.ContactTopicRepository
+	logger            *slog.Logger
 }
 
 // NewContactService creates a new ContactService.
-func NewContactService(contactRepo postgres.ContactRepository, audienceRepo postgres.AudienceRepository) ContactService {
+func NewContactService(
+	contactRepo postgres.ContactRepository,
+	audienceRepo postgres.AudienceRepository,
+	propertyRepo postgres.ContactPropertyRepository,
+	propertyValueRepo postgres.ContactPropertyValueRepository,
+	segmentRepo postgres.SegmentRepository,
+	topicRepo postgres.TopicRepository,
+	contactTopicRepo postgres.ContactTopicRepository,
+	logger *slog.Logger,
+) ContactService {
 	return &contactService{
-		contactRepo:  contactRepo,
-		audienceRepo: audienceRepo,
+		contactRepo:       contactRepo,
+		audienceRepo:      audienceRepo,
+		propertyRepo:      propertyRepo,
+		propertyValueRepo: propertyValueRepo,
+		segmentRepo:       segmentRepo,
+		topicRepo:         topicRepo,
+		contactTopicRepo:  contactTopicRepo,
+		logger:            logger,
 	}
 }
 
@@ -56,6 +80,11 @@ func (s *contactService) Create(ctx context.Context, teamID uuid.UUID, audienceI
 		return nil, err
 	}
 
+	props, err := s.resolveProperties(ctx, teamID, req.Properties)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check for duplicate email within the audience.
 	existing, _ := s.contactRepo.GetByAudienceAndEmail(ctx, audienceID, req.Email)
 	if existing != nil {
@@ -82,7 +111,13 @@ func (s *contactService) Create(ctx context.Context, teamID uuid.UUID, audienceI
 		return nil, fmt.Errorf("creating contact: %w", err)
 	}
 
-	return contactToResponse(contact), nil
+	if err := s.applyProperties(ctx, contact.ID, props, now); err != nil {
+		return nil, err
+	}
+
+	s.refreshSegments(ctx, contact.ID)
+
+	return s.contactResponse(ctx, teamID, contact)
 }
 
 func (s *contactService) List(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.ContactResponse], error) {
@@ -132,7 +167,7 @@ func (s *contactService) Get(ctx context.Context, teamID uuid.UUID, audienceID u
 		return nil, fmt.Errorf("contact not found: %w", postgres.ErrNotFound)
 	}
 
-	return contactToResponse(contact), nil
+	return s.contactResponse(ctx, teamID, contact)
 }
 
 func (s *contactService) Update(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID, req *dto.UpdateContactRequest) (*dto.ContactResponse, error) {
@@ -160,13 +195,24 @@ func (s *contactService) Update(ctx context.Context, teamID uuid.UUID, audienceI
 		contact.Unsubscribed = *req.Unsubscribed
 	}
 
+	props, err := s.resolveProperties(ctx, teamID, req.Properties)
+	if err != nil {
+		return nil, err
+	}
+
 	contact.UpdatedAt = time.Now().UTC()
 
 	if err := s.contactRepo.Update(ctx, contact); err != nil {
 		return nil, fmt.Errorf("updating contact: %w", err)
 	}
 
-	return contactToResponse(contact), nil
+	if err := s.applyProperties(ctx, contact.ID, props, contact.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	s.refreshSegments(ctx, contact.ID)
+
+	return s.contactResponse(ctx, teamID, contact)
 }
 
 func (s *contactService) Delete(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) error {
@@ -191,6 +237,226 @@ func (s *contactService) Delete(ctx context.Context, teamID uuid.UUID, audienceI
 	return nil
 }
 
+func (s *contactService) ListTopics(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) (*dto.ListResponse[dto.ContactTopicResponse], error) {
+	contact, err := s.getContact(ctx, teamID, audienceID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.contactTopics(ctx, teamID, contact.ID)
+}
+
+func (s *contactService) UpdateTopics(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID, req *dto.UpdateContactTopicsRequest) (*dto.ListResponse[dto.ContactTopicResponse], error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	contact, err := s.getContact(ctx, teamID, audienceID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := s.topicRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing topics: %w", err)
+	}
+	known := make(map[uuid.UUID]bool, len(topics))
+	for _, t := range topics {
+		known[t.ID] = true
+	}
+
+	// Validate every entry before writing so a bad topic id leaves the
+	// contact's subscriptions untouched.
+	updates := make([]model.ContactTopic, 0, len(req.Topics))
+	now := time.Now().UTC()
+	for _, u := range req.Topics {
+		topicID, err := uuid.Parse(u.ID)
+		if err != nil || !known[topicID] {
+			return nil, fmt.Errorf("%w: unknown topic %q", pkg.ErrValidation, u.ID)
+		}
+		updates = append(updates, model.ContactTopic{
+			ID:         uuid.New(),
+			ContactID:  contact.ID,
+			TopicID:    topicID,
+			Subscribed: u.Subscription == model.TopicDefaultOptIn,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+
+	for i := range updates {
+		if err := s.contactTopicRepo.Upsert(ctx, &updates[i]); err != nil {
+			return nil, fmt.Errorf("updating topic subscription: %w", err)
+		}
+	}
+
+	s.refreshSegments(ctx, contact.ID)
+
+	return s.contactTopics(ctx, teamID, contact.ID)
+}
+
+// getContact loads a contact after checking that both the audience and the
+// contact belong to the team.
+func (s *contactService) getContact(ctx context.Context, teamID, audienceID, contactID uuid.UUID) (*model.Contact, error) {
+	if err := s.verifyAudienceOwnership(ctx, teamID, audienceID); err != nil {
+		return nil, err
+	}
+
+	contact, err := s.contactRepo.GetByID(ctx, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("contact not found: %w", err)
+	}
+	if contact.AudienceID != audienceID {
+		return nil, fmt.Errorf("contact not found: %w", postgres.ErrNotFound)
+	}
+	return contact, nil
+}
+
+// contactTopics lists the contact's effective subscription state for every
+// topic of the team. Topics the contact has no preference for follow the
+// topic's default subscription.
+func (s *contactService) contactTopics(ctx context.Context, teamID, contactID uuid.UUID) (*dto.ListResponse[dto.ContactTopicResponse], error) {
+	topics, err := s.topicRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing topics: %w", err)
+	}
+
+	cts, err := s.contactTopicRepo.ListByContactID(ctx, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("listing topic subscriptions: %w", err)
+	}
+	explicit := make(map[uuid.UUID]bool, len(cts))
+	for _, ct := range cts {
+		explicit[ct.TopicID] = ct.Subscribed
+	}
+
+	data := make([]dto.ContactTopicResponse, 0, len(topics))
+	for _, t := range topics {
+		subscribed, ok := explicit[t.ID]
+		if !ok {
+			subscribed = t.SubscribedByDefault()
+		}
+		subscription := model.TopicDefaultOptOut
+		if subscribed {
+			subscription = model.TopicDefaultOptIn
+		}
+		data = append(data, dto.ContactTopicResponse{
+			ID:           t.ID.String(),
+			Name:         t.Name,
+			Subscription: subscription,
+			Explicit:     ok,
+		})
+	}
+
+	return &dto.ListResponse[dto.ContactTopicResponse]{Data: data}, nil
+}
+
+// propertyUpdate is a validated property value ready to be written. A nil
+// value removes the property from the contact.
+type propertyUpdate struct {
+	property model.ContactProperty
+	value    *string
+}
+
+// resolveProperties validates the requested property values against the
+// team's property definitions and normalises them to their stored form.
+func (s *contactService) resolveProperties(ctx context.Context, teamID uuid.UUID, values map[string]interface{}) ([]propertyUpdate, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	properties, err := s.propertyRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing contact properties: %w", err)
+	}
+	byName := make(map[string]model.ContactProperty, len(properties))
+	for _, p := range properties {
+		byName[p.Name] = p
+	}
+
+	updates := make([]propertyUpdate, 0, len(values))
+	for name, raw := range values {
+		prop, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown contact property %q", pkg.ErrValidation, name)
+		}
+		if raw == nil {
+			updates = append(updates, propertyUpdate{property: prop})
+			continue
+		}
+		value, err := model.NormalizePropertyValue(prop.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: property %q: %v", pkg.ErrValidation, name, err)
+		}
+		updates = append(updates, propertyUpdate{property: prop, value: &value})
+	}
+	return updates, nil
+}
+
+// applyProperties writes resolved property values for a contact.
+func (s *contactService) applyProperties(ctx context.Context, contactID uuid.UUID, updates []propertyUpdate, now time.Time) error {
+	for _, u := range updates {
+		if u.value == nil {
+			if err := s.propertyValueRepo.Delete(ctx, contactID, u.property.ID); err != nil {
+				return fmt.Errorf("removing property %s: %w", u.property.Name, err)
+			}
+			continue
+		}
+		value := &model.ContactPropertyValue{
+			ID:         uuid.New(),
+			ContactID:  contactID,
+			PropertyID: u.property.ID,
+			Value:      u.value,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := s.propertyValueRepo.Upsert(ctx, value); err != nil {
+			return fmt.Errorf("setting property %s: %w", u.property.Name, err)
+		}
+	}
+	return nil
+}
+
+// refreshSegments re-evaluates segment membership for a changed contact.
+// Membership is recomputed again before every broadcast send, so a failure
+// here only delays the change and is logged rather than failing the contact
+// write.
+func (s *contactService) refreshSegments(ctx context.Context, contactID uuid.UUID) {
+	if err := s.segmentRepo.RefreshContact(ctx, contactID); err != nil {
+		s.logger.Error("failed to refresh segments for contact", "contact_id", contactID, "error", err)
+	}
+}
+
+// contactResponse builds a contact response including its custom properties.
+func (s *contactService) contactResponse(ctx context.Context, teamID uuid.UUID, contact *model.Contact) (*dto.ContactResponse, error) {
+	resp := contactToResponse(contact)
+
+	values, err := s.propertyValueRepo.ListByContactID(ctx, contact.ID)
+	if err != nil {
+		return nil, fmt.Errorf("listing contact property values: %w", err)
+	}
+	if len(values) == 0 {
+		return resp, nil
+	}
+
+	properties, err := s.propertyRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing contact properties: %w", err)
+	}
+	names := make(map[uuid.UUID]string, len(properties))
+	for _, p := range properties {
+		names[p.ID] = p.Name
+	}
+
+	resp.Properties = make(map[string]string, len(values))
+	for _, v := range values {
+		if name, ok := names[v.PropertyID]; ok && v.Value != nil {
+			resp.Properties[name] = *v.Value
+		}
+	}
+	return resp, nil
+}
+
 // contactToResponse converts a model.Contact to a dto.ContactResponse.
 func contactToResponse(c *model.Contact) *dto.ContactResponse {
 	return &dto.ContactResponse{
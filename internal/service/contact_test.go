@@ -1,7 +1,10 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"log/slog"
 	"testing"
 
 	"github.com/google/uuid"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
@@ -19,7 +23,10 @@ import (
 func TestContactService_Create_HappyPath(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -27,6 +34,8 @@ func TestContactService_Create_HappyPath(t *testing.T) {
 	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
 	contactRepo.On("GetByAudienceAndEmail", ctx, aud.ID, "john@example.com").Return(nil, postgres.ErrNotFound)
 	contactRepo.On("Create", ctx, mock.AnythingOfType("*model.Contact")).Return(nil)
+	segmentRepo.On("RefreshContact", ctx, mock.AnythingOfType("uuid.UUID")).Return(nil)
+	valueRepo.On("ListByContactID", ctx, mock.AnythingOfType("uuid.UUID")).Return([]model.ContactPropertyValue{}, nil)
 
 	req := &dto.CreateContactRequest{
 		Email:     "john@example.com",
@@ -45,10 +54,36 @@ func TestContactService_Create_HappyPath(t *testing.T) {
 	audienceRepo.AssertExpectations(t)
 }
 
+func TestContactService_Create_SegmentRefreshFails(t *testing.T) {
+	contactRepo := new(tmock.MockContactRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	var logs bytes.Buffer
+	svc := NewContactService(contactRepo, audienceRepo, new(tmock.MockContactPropertyRepository), valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(&logs, nil)))
+	ctx := context.Background()
+
+	aud := testutil.NewTestAudience()
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	contactRepo.On("GetByAudienceAndEmail", ctx, aud.ID, "john@example.com").Return(nil, postgres.ErrNotFound)
+	contactRepo.On("Create", ctx, mock.AnythingOfType("*model.Contact")).Return(nil)
+	segmentRepo.On("RefreshContact", ctx, mock.AnythingOfType("uuid.UUID")).Return(assert.AnError)
+	valueRepo.On("ListByContactID", ctx, mock.AnythingOfType("uuid.UUID")).Return([]model.ContactPropertyValue{}, nil)
+
+	// The contact is still created; the failure is logged.
+	resp, err := svc.Create(ctx, testutil.TestTeamID, aud.ID, &dto.CreateContactRequest{Email: "john@example.com"})
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "failed to refresh segments for contact")
+	assert.Contains(t, logs.String(), "contact_id="+resp.ID)
+}
+
 func TestContactService_Create_DuplicateEmail(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -74,7 +109,10 @@ func TestContactService_Create_DuplicateEmail(t *testing.T) {
 func TestContactService_Create_AudienceOwnershipCheck(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -97,7 +135,10 @@ func TestContactService_Create_AudienceOwnershipCheck(t *testing.T) {
 func TestContactService_List(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -120,7 +161,10 @@ func TestContactService_List(t *testing.T) {
 func TestContactService_Get_HappyPath(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -128,6 +172,7 @@ func TestContactService_Get_HappyPath(t *testing.T) {
 	contact := testutil.NewTestContact(aud.ID)
 	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
 	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
+	valueRepo.On("ListByContactID", ctx, contact.ID).Return([]model.ContactPropertyValue{}, nil)
 
 	resp, err := svc.Get(ctx, teamID, aud.ID, contact.ID)
 
@@ -141,7 +186,10 @@ func TestContactService_Get_HappyPath(t *testing.T) {
 func TestContactService_Update(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -150,6 +198,8 @@ func TestContactService_Update(t *testing.T) {
 	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
 	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
 	contactRepo.On("Update", ctx, mock.AnythingOfType("*model.Contact")).Return(nil)
+	segmentRepo.On("RefreshContact", ctx, contact.ID).Return(nil)
+	valueRepo.On("ListByContactID", ctx, contact.ID).Return([]model.ContactPropertyValue{}, nil)
 
 	req := &dto.UpdateContactRequest{
 		FirstName:    testutil.StringPtr("Jane"),
@@ -164,12 +214,89 @@ func TestContactService_Update(t *testing.T) {
 
 	contactRepo.AssertExpectations(t)
 	audienceRepo.AssertExpectations(t)
+	segmentRepo.AssertExpectations(t)
+}
+
+func TestContactService_Update_Properties(t *testing.T) {
+	contactRepo := new(tmock.MockContactRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	aud := testutil.NewTestAudience()
+	contact := testutil.NewTestContact(aud.ID)
+	plan := model.ContactProperty{ID: uuid.New(), TeamID: teamID, Name: "plan", Type: model.ValueTypeString}
+	seats := model.ContactProperty{ID: uuid.New(), TeamID: teamID, Name: "seats", Type: model.ValueTypeNumber}
+
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
+	contactRepo.On("Update", ctx, mock.AnythingOfType("*model.Contact")).Return(nil)
+	propertyRepo.On("ListByTeamID", ctx, teamID).Return([]model.ContactProperty{plan, seats}, nil)
+	valueRepo.On("Upsert", ctx, mock.MatchedBy(func(v *model.ContactPropertyValue) bool {
+		return v.PropertyID == seats.ID && *v.Value == "12"
+	})).Return(nil)
+	valueRepo.On("Delete", ctx, contact.ID, plan.ID).Return(nil)
+	segmentRepo.On("RefreshContact", ctx, contact.ID).Return(nil)
+	valueRepo.On("ListByContactID", ctx, contact.ID).Return([]model.ContactPropertyValue{
+		{ContactID: contact.ID, PropertyID: seats.ID, Value: testutil.StringPtr("12")},
+	}, nil)
+
+	req := &dto.UpdateContactRequest{
+		Properties: map[string]interface{}{"seats": float64(12), "plan": nil},
+	}
+
+	resp, err := svc.Update(ctx, teamID, aud.ID, contact.ID, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"seats": "12"}, resp.Properties)
+
+	valueRepo.AssertExpectations(t)
+	segmentRepo.AssertExpectations(t)
+}
+
+func TestContactService_Update_InvalidProperty(t *testing.T) {
+	contactRepo := new(tmock.MockContactRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	aud := testutil.NewTestAudience()
+	contact := testutil.NewTestContact(aud.ID)
+	seats := model.ContactProperty{ID: uuid.New(), TeamID: teamID, Name: "seats", Type: model.ValueTypeNumber}
+
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
+	propertyRepo.On("ListByTeamID", ctx, teamID).Return([]model.ContactProperty{seats}, nil)
+
+	tests := []map[string]interface{}{
+		{"seats": "many"},
+		{"unknown": "value"},
+	}
+	for _, props := range tests {
+		resp, err := svc.Update(ctx, teamID, aud.ID, contact.ID, &dto.UpdateContactRequest{Properties: props})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, pkg.ErrValidation)
+	}
+
+	contactRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 }
 
 func TestContactService_Delete(t *testing.T) {
 	contactRepo := new(tmock.MockContactRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewContactService(contactRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	valueRepo := new(tmock.MockContactPropertyValueRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	svc := NewContactService(contactRepo, audienceRepo, propertyRepo, valueRepo, segmentRepo, new(tmock.MockTopicRepository), new(tmock.MockContactTopicRepository), slog.New(slog.NewTextHandler(io.Discard, nil)))
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -186,3 +313,91 @@ func TestContactService_Delete(t *testing.T) {
 	contactRepo.AssertExpectations(t)
 	audienceRepo.AssertExpectations(t)
 }
+
+func TestContactService_ListTopics(t *testing.T) {
+	contactRepo := new(tmock.MockContactRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	contactTopicRepo := new(tmock.MockContactTopicRepository)
+	svc := NewContactService(contactRepo, audienceRepo, new(tmock.MockContactPropertyRepository),
+		new(tmock.MockContactPropertyValueRepository), new(tmock.MockSegmentRepository), topicRepo, contactTopicRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	aud := testutil.NewTestAudience()
+	contact := testutil.NewTestContact(aud.ID)
+	news := model.Topic{ID: uuid.New(), TeamID: teamID, Name: "Newsletter", DefaultSubscription: model.TopicDefaultOptIn}
+	beta := model.Topic{ID: uuid.New(), TeamID: teamID, Name: "Beta program", DefaultSubscription: model.TopicDefaultOptOut}
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
+	topicRepo.On("ListByTeamID", ctx, teamID).Return([]model.Topic{news, beta}, nil)
+	contactTopicRepo.On("ListByContactID", ctx, contact.ID).
+		Return([]model.ContactTopic{{ContactID: contact.ID, TopicID: news.ID, Subscribed: false}}, nil)
+
+	resp, err := svc.ListTopics(ctx, teamID, aud.ID, contact.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []dto.ContactTopicResponse{
+		{ID: news.ID.String(), Name: "Newsletter", Subscription: model.TopicDefaultOptOut, Explicit: true},
+		{ID: beta.ID.String(), Name: "Beta program", Subscription: model.TopicDefaultOptOut, Explicit: false},
+	}, resp.Data)
+}
+
+func TestContactService_UpdateTopics(t *testing.T) {
+	contactRepo := new(tmock.MockContactRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	segmentRepo := new(tmock.MockSegmentRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	contactTopicRepo := new(tmock.MockContactTopicRepository)
+	svc := NewContactService(contactRepo, audienceRepo, new(tmock.MockContactPropertyRepository),
+		new(tmock.MockContactPropertyValueRepository), segmentRepo, topicRepo, contactTopicRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	aud := testutil.NewTestAudience()
+	contact := testutil.NewTestContact(aud.ID)
+	beta := model.Topic{ID: uuid.New(), TeamID: teamID, Name: "Beta program", DefaultSubscription: model.TopicDefaultOptOut}
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
+	topicRepo.On("ListByTeamID", ctx, teamID).Return([]model.Topic{beta}, nil)
+	contactTopicRepo.On("Upsert", ctx, mock.MatchedBy(func(ct *model.ContactTopic) bool {
+		return ct.ContactID == contact.ID && ct.TopicID == beta.ID && ct.Subscribed
+	})).Return(nil)
+	segmentRepo.On("RefreshContact", ctx, contact.ID).Return(nil)
+	contactTopicRepo.On("ListByContactID", ctx, contact.ID).
+		Return([]model.ContactTopic{{ContactID: contact.ID, TopicID: beta.ID, Subscribed: true}}, nil)
+
+	resp, err := svc.UpdateTopics(ctx, teamID, aud.ID, contact.ID, &dto.UpdateContactTopicsRequest{
+		Topics: []dto.ContactTopicUpdate{{ID: beta.ID.String(), Subscription: model.TopicDefaultOptIn}},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, model.TopicDefaultOptIn, resp.Data[0].Subscription)
+	contactTopicRepo.AssertExpectations(t)
+}
+
+func TestContactService_UpdateTopics_UnknownTopic(t *testing.T) {
+	contactRepo := new(tmock.MockContactRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	contactTopicRepo := new(tmock.MockContactTopicRepository)
+	svc := NewContactService(contactRepo, audienceRepo, new(tmock.MockContactPropertyRepository),
+		new(tmock.MockContactPropertyValueRepository), new(tmock.MockSegmentRepository), topicRepo, contactTopicRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	aud := testutil.NewTestAudience()
+	contact := testutil.NewTestContact(aud.ID)
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	contactRepo.On("GetByID", ctx, contact.ID).Return(contact, nil)
+	topicRepo.On("ListByTeamID", ctx, teamID).Return([]model.Topic{}, nil)
+
+	resp, err := svc.UpdateTopics(ctx, teamID, aud.ID, contact.ID, &dto.UpdateContactTopicsRequest{
+		Topics: []dto.ContactTopicUpdate{{ID: uuid.New().String(), Subscription: model.TopicDefaultOptOut}},
+	})
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	contactTopicRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// maxDMARCRange bounds the time range of DMARC report queries.
+const maxDMARCRange = 366 * 24 * time.Hour
+
+// DMARCService defines operations for querying the DMARC aggregate reports
+// received for a team's domains. Reports cover [from, to) by the start of
+// their date range.
+type DMARCService interface {
+	ListReports(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.DMARCReportResponse], error)
+	GetStats(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time) (*dto.DMARCStatsResponse, error)
+}
+
+type dmarcService struct {
+	domainRepo      postgres.DomainRepository
+	dmarcReportRepo postgres.DMARCReportRepository
+}
+
+// NewDMARCService creates a new DMARCService.
+func NewDMARCService(domainRepo postgres.DomainRepository, dmarcReportRepo postgres.DMARCReportRepository) DMARCService {
+	return &dmarcService{
+		domainRepo:      domainRepo,
+		dmarcReportRepo: dmarcReportRepo,
+	}
+}
+
+func (s *dmarcService) ListReports(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.DMARCReportResponse], error) {
+	if err := s.checkQuery(ctx, teamID, domainID, from, to); err != nil {
+		return nil, err
+	}
+
+	params.Normalize()
+
+	reports, total, err := s.dmarcReportRepo.ListByDomainID(ctx, domainID, from, to, params.PerPage, params.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("listing DMARC reports: %w", err)
+	}
+
+	data := make([]dto.DMARCReportResponse, 0, len(reports))
+	for _, r := range reports {
+		data = append(data, dto.DMARCReportResponse{
+			ID:             r.ID.String(),
+			OrgName:        r.OrgName,
+			ReporterEmail:  r.ReporterEmail,
+			ReportID:       r.ReportID,
+			PolicyDomain:   r.PolicyDomain,
+			Policy:         r.Policy,
+			DateBegin:      r.DateBegin.Format(time.RFC3339),
+			DateEnd:        r.DateEnd.Format(time.RFC3339),
+			MessageCount:   r.MessageCount,
+			FailedMessages: r.FailedMessages,
+			CreatedAt:      r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	totalPages := 0
+	if params.PerPage > 0 {
+		totalPages = (total + params.PerPage - 1) / params.PerPage
+	}
+
+	return &dto.PaginatedResponse[dto.DMARCReportResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       params.Page,
+		PerPage:    params.PerPage,
+		TotalPages: totalPages,
+		HasMore:    params.Page < totalPages,
+	}, nil
+}
+
+func (s *dmarcService) GetStats(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time) (*dto.DMARCStatsResponse, error) {
+	if err := s.checkQuery(ctx, teamID, domainID, from, to); err != nil {
+		return nil, err
+	}
+
+	sources, err := s.dmarcReportRepo.SourceStats(ctx, domainID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating DMARC sources: %w", err)
+	}
+	daily, err := s.dmarcReportRepo.DailyStats(ctx, domainID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating DMARC results: %w", err)
+	}
+
+	resp := &dto.DMARCStatsResponse{
+		From:    from,
+		To:      to,
+		Sources: make([]dto.DMARCSourceResponse, 0, len(sources)),
+		Data:    make([]dto.DMARCDataPoint, 0, len(daily)),
+	}
+	for _, src := range sources {
+		resp.Totals.Messages += src.Messages
+		resp.Totals.Passed += src.Passed
+		resp.Totals.Failed += src.Failed
+		resp.Sources = append(resp.Sources, dto.DMARCSourceResponse{
+			SourceIP:    src.SourceIP,
+			Messages:    src.Messages,
+			Passed:      src.Passed,
+			Failed:      src.Failed,
+			DKIMAligned: src.DKIMAligned,
+			SPFAligned:  src.SPFAligned,
+			DKIMDomains: src.DKIMDomains,
+			SPFDomains:  src.SPFDomains,
+		})
+	}
+	for _, d := range daily {
+		resp.Data = append(resp.Data, dto.DMARCDataPoint{
+			Date:     d.Day.Format("2006-01-02"),
+			Messages: d.Messages,
+			Passed:   d.Passed,
+			Failed:   d.Failed,
+		})
+	}
+	if resp.Totals.Messages > 0 {
+		resp.Totals.PassRate = float64(resp.Totals.Passed) / float64(resp.Totals.Messages) * 100
+	}
+
+	return resp, nil
+}
+
+// checkQuery validates the time range and that the domain belongs to the team.
+func (s *dmarcService) checkQuery(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time) error {
+	if !from.Before(to) {
+		return fmt.Errorf("%w: from must be before to", pkg.ErrValidation)
+	}
+	if to.Sub(from) > maxDMARCRange {
+		return fmt.Errorf("%w: time range must not exceed 366 days", pkg.ErrValidation)
+	}
+	if _, err := s.domainRepo.GetByTeamAndID(ctx, teamID, domainID); err != nil {
+		return fmt.Errorf("domain not found: %w", err)
+	}
+	return nil
+}
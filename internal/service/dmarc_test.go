@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func TestDMARCService_GetStats(t *testing.T) {
+	domainRepo := new(tmock.MockDomainRepository)
+	reportRepo := new(tmock.MockDMARCReportRepository)
+	svc := NewDMARCService(domainRepo, reportRepo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+	domainID := uuid.New()
+	to := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -30)
+
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domainID).Return(&model.Domain{ID: domainID, TeamID: teamID}, nil)
+	reportRepo.On("SourceStats", ctx, domainID, from, to).Return([]model.DMARCSourceStats{
+		{SourceIP: "198.51.100.7", Messages: 30, Failed: 30, SPFDomains: []string{"crm-vendor.net"}},
+		{SourceIP: "203.0.113.10", Messages: 90, Passed: 90, DKIMAligned: 90, SPFAligned: 85, DKIMDomains: []string{"example.com"}},
+	}, nil)
+	reportRepo.On("DailyStats", ctx, domainID, from, to).Return([]model.DMARCDailyStats{
+		{Day: to.AddDate(0, 0, -2), Messages: 50, Passed: 40, Failed: 10},
+		{Day: to.AddDate(0, 0, -1), Messages: 70, Passed: 50, Failed: 20},
+	}, nil)
+
+	resp, err := svc.GetStats(ctx, teamID, domainID, from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, 120, resp.Totals.Messages)
+	assert.Equal(t, 30, resp.Totals.Failed)
+	assert.InDelta(t, 75.0, resp.Totals.PassRate, 0.001)
+	require.Len(t, resp.Sources, 2)
+	assert.Equal(t, "198.51.100.7", resp.Sources[0].SourceIP)
+	assert.Equal(t, []string{"crm-vendor.net"}, resp.Sources[0].SPFDomains)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "2026-10-14", resp.Data[0].Date)
+
+	reportRepo.AssertExpectations(t)
+}
+
+func TestDMARCService_ListReports(t *testing.T) {
+	domainRepo := new(tmock.MockDomainRepository)
+	reportRepo := new(tmock.MockDMARCReportRepository)
+	svc := NewDMARCService(domainRepo, reportRepo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+	domainID := uuid.New()
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+
+	report := model.DMARCReport{
+		ID: uuid.New(), DomainID: domainID, OrgName: "google.com", ReportID: "r-1", PolicyDomain: "example.com",
+		Policy: "none", DateBegin: from, DateEnd: from.Add(24 * time.Hour), MessageCount: 12, FailedMessages: 3,
+	}
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domainID).Return(&model.Domain{ID: domainID, TeamID: teamID}, nil)
+	reportRepo.On("ListByDomainID", ctx, domainID, from, to, 20, 0).Return([]model.DMARCReport{report}, 1, nil)
+
+	resp, err := svc.ListReports(ctx, teamID, domainID, from, to, &dto.PaginationParams{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Total)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "google.com", resp.Data[0].OrgName)
+	assert.Equal(t, 3, resp.Data[0].FailedMessages)
+}
+
+func TestDMARCService_InvalidQuery(t *testing.T) {
+	domainRepo := new(tmock.MockDomainRepository)
+	svc := NewDMARCService(domainRepo, new(tmock.MockDMARCReportRepository))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+	domainID := uuid.New()
+	now := time.Now().UTC()
+
+	_, err := svc.GetStats(ctx, teamID, domainID, now, now.Add(-time.Hour))
+	assert.True(t, errors.Is(err, pkg.ErrValidation))
+
+	_, err = svc.GetStats(ctx, teamID, domainID, now.AddDate(-2, 0, 0), now)
+	assert.True(t, errors.Is(err, pkg.ErrValidation))
+
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domainID).Return(nil, postgres.ErrNotFound)
+	_, err = svc.GetStats(ctx, teamID, domainID, now.AddDate(0, 0, -1), now)
+	assert.True(t, errors.Is(err, postgres.ErrNotFound))
+}
@@ -2,11 +2,7 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"time"
 
@@ -14,6 +10,7 @@ import (
 	"github.com/hibiken/asynq"
 
 	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/engine"
 	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
@@ -33,6 +30,8 @@ type DomainService interface {
 	Update(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.UpdateDomainRequest) (*dto.DomainResponse, error)
 	Delete(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID) error
 	Verify(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID) (*dto.DomainResponse, error)
+	RotateDKIM(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.RotateDKIMRequest) (*dto.DomainResponse, error)
+	ImportDKIM(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.ImportDKIMRequest) (*dto.DomainResponse, error)
 }
 
 type domainService struct {
@@ -40,23 +39,24 @@ type domainService struct {
 	dnsRecordRepo postgres.DomainDNSRecordRepository
 	asynqClient   *asynq.Client
 	dkimSelector  string
-	encryptionKey string
+	keyring       *engine.Keyring
 }
 
-// NewDomainService creates a new DomainService.
+// NewDomainService creates a new DomainService. DKIM private keys are sealed
+// with keyring before they are stored; a nil keyring stores them in the clear.
 func NewDomainService(
 	domainRepo postgres.DomainRepository,
 	dnsRecordRepo postgres.DomainDNSRecordRepository,
 	asynqClient *asynq.Client,
 	dkimSelector string,
-	encryptionKey string,
+	keyring *engine.Keyring,
 ) DomainService {
 	return &domainService{
 		domainRepo:    domainRepo,
 		dnsRecordRepo: dnsRecordRepo,
 		asynqClient:   asynqClient,
 		dkimSelector:  dkimSelector,
-		encryptionKey: encryptionKey,
+		keyring:       keyring,
 	}
 }
 
@@ -71,37 +71,37 @@ func (s *domainService) Create(ctx context.Context, teamID uuid.UUID, req *dto.C
 		return nil, fmt.Errorf("domain %s already exists for this team", req.Name)
 	}
 
-	// Generate DKIM RSA key pair.
-	privateKey, err := rsa.GenerateKey(rand.Reader, dkimKeyBits)
-	if err != nil {
-		return nil, fmt.Errorf("generating DKIM key pair: %w", err)
+	// Generate the DKIM key pair, or take the imported one.
+	algorithm := req.DKIMAlgorithm
+	selector := s.selector()
+	var privKeyStr, dkimValue string
+	if req.DKIMPrivateKey != "" {
+		imported, value, err := importedDKIMKey(req.DKIMPrivateKey, algorithm)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, privKeyStr, dkimValue = imported, req.DKIMPrivateKey, value
+		if req.DKIMSelector != "" {
+			selector = req.DKIMSelector
+		}
+	} else {
+		if algorithm == "" {
+			algorithm = engine.DKIMAlgorithmRSA
+		}
+		var err error
+		privKeyStr, dkimValue, err = engine.GenerateDKIMKey(algorithm, dkimKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("generating DKIM key pair: %w", err)
+		}
 	}
 
-	// Encode private key to PEM.
-	privPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-	})
-
-	// Encode public key for DNS TXT record.
-	pubDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	// The private key is stored sealed with the master key.
+	privKeyStr, err := s.keyring.Seal(ctx, privKeyStr)
 	if err != nil {
-		return nil, fmt.Errorf("marshalling DKIM public key: %w", err)
+		return nil, fmt.Errorf("encrypting DKIM private key: %w", err)
 	}
-	pubPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: pubDER,
-	})
-
-	// Store private key. In production this would be encrypted with the master key.
-	// For now, store as PEM string (encryption can be layered on top).
-	privKeyStr := string(privPEM)
 
 	now := time.Now().UTC()
-	selector := s.dkimSelector
-	if selector == "" {
-		selector = "mailit"
-	}
 
 	domain := &model.Domain{
 		ID:             uuid.New(),
@@ -110,6 +110,7 @@ func (s *domainService) Create(ctx context.Context, teamID uuid.UUID, req *dto.C
 		Status:         model.DomainStatusPending,
 		DKIMPrivateKey: &privKeyStr,
 		DKIMSelector:   selector,
+		DKIMAlgorithm:  algorithm,
 		OpenTracking:   false,
 		ClickTracking:  false,
 		TLSPolicy:      "opportunistic",
@@ -122,7 +123,7 @@ func (s *domainService) Create(ctx context.Context, teamID uuid.UUID, req *dto.C
 	}
 
 	// Create DNS records for the domain.
-	records := s.buildDNSRecords(domain.ID, req.Name, selector, string(pubPEM), now)
+	records := s.buildDNSRecords(domain.ID, req.Name, selector, dkimValue, now)
 	for i := range records {
 		if err := s.dnsRecordRepo.Create(ctx, &records[i]); err != nil {
 			return nil, fmt.Errorf("creating DNS record: %w", err)
@@ -200,8 +201,26 @@ func (s *domainService) Update(ctx context.Context, teamID uuid.UUID, domainID u
 	if req.TLSPolicy != nil {
 		domain.TLSPolicy = *req.TLSPolicy
 	}
+	if req.DKIMRotationIntervalDays != nil {
+		if *req.DKIMRotationIntervalDays == 0 {
+			domain.DKIMRotationIntervalDays = nil
+		} else {
+			domain.DKIMRotationIntervalDays = req.DKIMRotationIntervalDays
+		}
+	}
 
-	domain.UpdatedAt = time.Now().UTC()
+	now := time.Now().UTC()
+	// Turning warm-up on again while it runs doesn't restart it.
+	if req.Warmup != nil {
+		switch {
+		case !*req.Warmup:
+			domain.WarmupStartedAt = nil
+		case domain.WarmupStartedAt == nil:
+			domain.WarmupStartedAt = &now
+		}
+	}
+
+	domain.UpdatedAt = now
 
 	if err := s.domainRepo.Update(ctx, domain); err != nil {
 		return nil, fmt.Errorf("updating domain: %w", err)
@@ -249,8 +268,123 @@ func (s *domainService) Verify(ctx context.Context, teamID uuid.UUID, domainID u
 	return s.buildDomainResponse(domain, records), nil
 }
 
+// RotateDKIM starts a DKIM key rotation: a new key is generated under a new
+// selector and its DNS record is added as pending. Signing switches to the new
+// key once domain verification finds the record published.
+func (s *domainService) RotateDKIM(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.RotateDKIMRequest) (*dto.DomainResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation: %w", err)
+	}
+
+	domain, err := s.domainRepo.GetByTeamAndID(ctx, teamID, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("domain not found: %w", err)
+	}
+	if err := checkRotationAllowed(domain); err != nil {
+		return nil, err
+	}
+
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = domain.DKIMAlgorithm
+	}
+	privateKey, dkimValue, err := engine.GenerateDKIMKey(algorithm, dkimKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("generating DKIM key pair: %w", err)
+	}
+
+	now := time.Now().UTC()
+	return s.startRotation(ctx, domain, model.DKIMRotationSelector(s.selector(), now), algorithm, privateKey, dkimValue, now)
+}
+
+// ImportDKIM imports an existing DKIM key and selector, e.g. from another
+// provider, as a pending key. As with a rotation, signing switches to it once
+// domain verification finds its record published, which for a key already in
+// use elsewhere is typically on the next check.
+func (s *domainService) ImportDKIM(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.ImportDKIMRequest) (*dto.DomainResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation: %w", err)
+	}
+
+	domain, err := s.domainRepo.GetByTeamAndID(ctx, teamID, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("domain not found: %w", err)
+	}
+	if err := checkRotationAllowed(domain); err != nil {
+		return nil, err
+	}
+	if req.Selector == domain.DKIMSelector {
+		return nil, fmt.Errorf("%w: selector %s is already the active DKIM selector", pkg.ErrValidation, req.Selector)
+	}
+
+	algorithm, dkimValue, err := importedDKIMKey(req.PrivateKey, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startRotation(ctx, domain, req.Selector, algorithm, req.PrivateKey, dkimValue, time.Now().UTC())
+}
+
+// checkRotationAllowed rejects starting a new DKIM key while a rotation is
+// still in progress.
+func checkRotationAllowed(domain *model.Domain) error {
+	if domain.DKIMPendingSelector != nil {
+		return fmt.Errorf("%w: a DKIM rotation to selector %s is already pending", pkg.ErrValidation, *domain.DKIMPendingSelector)
+	}
+	if domain.DKIMPreviousSelector != nil {
+		return fmt.Errorf("%w: the previous DKIM selector %s is published until %s; rotate again after it is retired",
+			pkg.ErrValidation, *domain.DKIMPreviousSelector, domain.DKIMPreviousRetireAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// importedDKIMKey validates an imported DKIM private key, returning its
+// algorithm and DNS record value. If algorithm is set, the key must match it.
+func importedDKIMKey(privateKeyPEM, algorithm string) (string, string, error) {
+	keyAlgorithm, dkimValue, err := engine.DKIMKeyRecord(privateKeyPEM)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: invalid DKIM private key: %v", pkg.ErrValidation, err)
+	}
+	if algorithm != "" && algorithm != keyAlgorithm {
+		return "", "", fmt.Errorf("%w: DKIM private key is %s, not %s", pkg.ErrValidation, keyAlgorithm, algorithm)
+	}
+	return keyAlgorithm, dkimValue, nil
+}
+
+// startRotation adds a pending DKIM key to the domain and queues the
+// verification that promotes it.
+func (s *domainService) startRotation(ctx context.Context, domain *model.Domain, selector, algorithm, privateKey, dkimValue string, now time.Time) (*dto.DomainResponse, error) {
+	sealed, err := s.keyring.Seal(ctx, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting DKIM private key: %w", err)
+	}
+
+	record := domain.StartDKIMRotation(selector, algorithm, sealed, dkimValue, now)
+	domain.UpdatedAt = now
+	if err := s.domainRepo.StartDKIMRotation(ctx, domain, &record); err != nil {
+		return nil, fmt.Errorf("starting DKIM rotation: %w", err)
+	}
+
+	s.enqueueVerifyTask(domain.ID, domain.TeamID)
+
+	records, err := s.dnsRecordRepo.ListByDomainID(ctx, domain.ID)
+	if err != nil {
+		return nil, fmt.Errorf("listing DNS records: %w", err)
+	}
+
+	return s.buildDomainResponse(domain, records), nil
+}
+
+// selector returns the configured base DKIM selector.
+func (s *domainService) selector() string {
+	if s.dkimSelector == "" {
+		return "mailit"
+	}
+	return s.dkimSelector
+}
+
 // buildDNSRecords creates the set of required DNS records for a new domain.
-func (s *domainService) buildDNSRecords(domainID uuid.UUID, domainName, selector, pubKeyPEM string, now time.Time) []model.DomainDNSRecord {
+func (s *domainService) buildDNSRecords(domainID uuid.UUID, domainName, selector, dkimValue string, now time.Time) []model.DomainDNSRecord {
 	mxPriority := 10
 
 	return []model.DomainDNSRecord{
@@ -271,7 +405,7 @@ func (s *domainService) buildDNSRecords(domainID uuid.UUID, domainName, selector
 			RecordType: "DKIM",
 			DNSType:    "TXT",
 			Name:       selector + "._domainkey." + domainName,
-			Value:      "v=DKIM1; k=rsa; p=" + extractBase64Key(pubKeyPEM),
+			Value:      dkimValue,
 			Status:     model.DomainStatusPending,
 			CreatedAt:  now,
 			UpdatedAt:  now,
@@ -294,7 +428,7 @@ func (s *domainService) buildDNSRecords(domainID uuid.UUID, domainName, selector
 			RecordType: "DMARC",
 			DNSType:    "TXT",
 			Name:       "_dmarc." + domainName,
-			Value:      "v=DMARC1; p=none;",
+			Value:      "v=DMARC1; p=none; rua=mailto:dmarc@" + domainName,
 			Status:     model.DomainStatusPending,
 			CreatedAt:  now,
 			UpdatedAt:  now,
@@ -327,46 +461,60 @@ func (s *domainService) enqueueVerifyTask(domainID, teamID uuid.UUID) {
 func (s *domainService) buildDomainResponse(domain *model.Domain, records []model.DomainDNSRecord) *dto.DomainResponse {
 	dnsRecords := make([]dto.DNSRecordResponse, 0, len(records))
 	for _, r := range records {
-		dnsRecords = append(dnsRecords, dto.DNSRecordResponse{
+		record := dto.DNSRecordResponse{
 			Type:     r.RecordType,
 			Name:     r.Name,
 			Value:    r.Value,
 			Priority: r.Priority,
 			Status:   r.Status,
 			TTL:      "Auto",
-		})
+		}
+		if r.LastCheckedAt != nil {
+			t := r.LastCheckedAt.Format(time.RFC3339)
+			record.LastCheckedAt = &t
+		}
+		dnsRecords = append(dnsRecords, record)
 	}
 
-	return &dto.DomainResponse{
+	dkim := dto.DKIMResponse{
+		Selector:             domain.DKIMSelector,
+		Algorithm:            domain.DKIMAlgorithm,
+		PendingSelector:      domain.DKIMPendingSelector,
+		PendingAlgorithm:     domain.DKIMPendingAlgorithm,
+		PreviousSelector:     domain.DKIMPreviousSelector,
+		RotationIntervalDays: domain.DKIMRotationIntervalDays,
+	}
+	if domain.DKIMPreviousRetireAt != nil {
+		t := domain.DKIMPreviousRetireAt.Format(time.RFC3339)
+		dkim.PreviousRetireAt = &t
+	}
+	if domain.DKIMRotatedAt != nil {
+		t := domain.DKIMRotatedAt.Format(time.RFC3339)
+		dkim.RotatedAt = &t
+	}
+
+	resp := &dto.DomainResponse{
 		ID:         domain.ID.String(),
 		Name:       domain.Name,
 		Status:     domain.Status,
 		Region:     domain.Region,
 		DNSRecords: dnsRecords,
+		DKIM:       dkim,
 		CreatedAt:  domain.CreatedAt.Format(time.RFC3339),
 	}
-}
-
-// extractBase64Key strips the PEM headers and newlines to produce a raw base64 key string.
-func extractBase64Key(pemStr string) string {
-	block, _ := pem.Decode([]byte(pemStr))
-	if block == nil {
-		return ""
-	}
-	// Encode the raw DER bytes as a continuous base64 string.
-	// The pem package already provides the base64 content in block.Bytes,
-	// but we need to re-encode to remove line breaks.
-	import64 := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: block.Bytes})
-	// Strip header, footer, and newlines.
-	result := string(import64)
-	result = result[len("-----BEGIN PUBLIC KEY-----\n"):]
-	result = result[:len(result)-len("-----END PUBLIC KEY-----\n")]
-	// Remove remaining newlines.
-	cleaned := ""
-	for _, c := range result {
-		if c != '\n' && c != '\r' {
-			cleaned += string(c)
+	if domain.SendingPausedAt != nil {
+		t := domain.SendingPausedAt.Format(time.RFC3339)
+		resp.SendingPausedAt = &t
+	}
+	if domain.LastCheckedAt != nil {
+		t := domain.LastCheckedAt.Format(time.RFC3339)
+		resp.LastCheckedAt = &t
+	}
+	if limit := domain.WarmupDailyLimit(time.Now().UTC()); limit > 0 {
+		resp.Warmup = &dto.WarmupResponse{
+			StartedAt:  domain.WarmupStartedAt.Format(time.RFC3339),
+			DailyLimit: limit,
 		}
 	}
-	return cleaned
+	return resp
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/alicebob/miniredis/v2"
@@ -12,7 +13,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/engine"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
@@ -27,7 +30,7 @@ func newDomainTestDeps(t *testing.T) (*tmock.MockDomainRepository, *tmock.MockDo
 
 func TestDomainService_Create_HappyPath(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -51,7 +54,7 @@ func TestDomainService_Create_HappyPath(t *testing.T) {
 
 func TestDomainService_Create_DuplicateDomain(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -70,7 +73,7 @@ func TestDomainService_Create_DuplicateDomain(t *testing.T) {
 
 func TestDomainService_List_Paginated(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -91,7 +94,7 @@ func TestDomainService_List_Paginated(t *testing.T) {
 
 func TestDomainService_Get_HappyPath(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -111,7 +114,7 @@ func TestDomainService_Get_HappyPath(t *testing.T) {
 
 func TestDomainService_Update_TrackingSettings(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -133,9 +136,31 @@ func TestDomainService_Update_TrackingSettings(t *testing.T) {
 	dnsRepo.AssertExpectations(t)
 }
 
+func TestDomainService_Update_Warmup(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domain := testutil.NewTestDomain()
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domain.ID).Return(domain, nil)
+	domainRepo.On("Update", ctx, mock.AnythingOfType("*model.Domain")).Return(nil)
+	dnsRepo.On("ListByDomainID", ctx, domain.ID).Return([]model.DomainDNSRecord{}, nil)
+
+	resp, err := svc.Update(ctx, teamID, domain.ID, &dto.UpdateDomainRequest{Warmup: testutil.BoolPtr(true)})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Warmup)
+	assert.Equal(t, model.WarmupInitialDailyLimit, resp.Warmup.DailyLimit)
+
+	resp, err = svc.Update(ctx, teamID, domain.ID, &dto.UpdateDomainRequest{Warmup: testutil.BoolPtr(false)})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Warmup)
+	assert.Nil(t, domain.WarmupStartedAt)
+}
+
 func TestDomainService_Delete_HappyPath(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -154,7 +179,7 @@ func TestDomainService_Delete_HappyPath(t *testing.T) {
 
 func TestDomainService_Verify_EnqueuesTask(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -173,7 +198,7 @@ func TestDomainService_Verify_EnqueuesTask(t *testing.T) {
 
 func TestDomainService_Get_NotFound(t *testing.T) {
 	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
-	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", "")
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 	badID := uuid.New()
@@ -188,3 +213,180 @@ func TestDomainService_Get_NotFound(t *testing.T) {
 
 	domainRepo.AssertExpectations(t)
 }
+
+func TestDomainService_Create_Ed25519(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").Return(nil, postgres.ErrNotFound)
+	domainRepo.On("Create", ctx, mock.MatchedBy(func(d *model.Domain) bool {
+		return d.DKIMAlgorithm == "ed25519" && strings.Contains(*d.DKIMPrivateKey, "BEGIN PRIVATE KEY")
+	})).Return(nil)
+	dnsRepo.On("Create", ctx, mock.AnythingOfType("*model.DomainDNSRecord")).Return(nil).Times(5)
+
+	resp, err := svc.Create(ctx, teamID, &dto.CreateDomainRequest{Name: "example.com", DKIMAlgorithm: "ed25519"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "ed25519", resp.DKIM.Algorithm)
+	assert.Equal(t, "mailit._domainkey.example.com", resp.DNSRecords[1].Name)
+	assert.True(t, strings.HasPrefix(resp.DNSRecords[1].Value, "v=DKIM1; k=ed25519; p="))
+	domainRepo.AssertExpectations(t)
+}
+
+func TestDomainService_RotateDKIM(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domain := testutil.NewTestDomain()
+	domain.Status = model.DomainStatusVerified
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domain.ID).Return(domain, nil)
+
+	var pending model.DomainDNSRecord
+	domainRepo.On("StartDKIMRotation", ctx, mock.AnythingOfType("*model.Domain"), mock.AnythingOfType("*model.DomainDNSRecord")).Run(func(args mock.Arguments) {
+		pending = *args.Get(2).(*model.DomainDNSRecord)
+	}).Return(nil)
+	dnsRepo.On("ListByDomainID", ctx, domain.ID).Return([]model.DomainDNSRecord{}, nil)
+
+	resp, err := svc.RotateDKIM(ctx, teamID, domain.ID, &dto.RotateDKIMRequest{Algorithm: "ed25519"})
+
+	require.NoError(t, err)
+	// Signing stays on the active key until the new record verifies.
+	assert.Equal(t, "mailit", resp.DKIM.Selector)
+	require.NotNil(t, resp.DKIM.PendingSelector)
+	assert.True(t, strings.HasPrefix(*resp.DKIM.PendingSelector, "mailit-"))
+	assert.Equal(t, "ed25519", *resp.DKIM.PendingAlgorithm)
+	assert.Equal(t, *resp.DKIM.PendingSelector+"._domainkey.example.com", pending.Name)
+	assert.True(t, strings.HasPrefix(pending.Value, "v=DKIM1; k=ed25519; p="))
+	assert.Equal(t, model.DomainStatusPending, pending.Status)
+
+	domainRepo.AssertExpectations(t)
+	dnsRepo.AssertExpectations(t)
+}
+
+func TestDomainService_RotateDKIM_InProgress(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	pending := "mailit-202610161200"
+	domain := testutil.NewTestDomain()
+	domain.DKIMPendingSelector = &pending
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domain.ID).Return(domain, nil)
+
+	_, err := svc.RotateDKIM(ctx, teamID, domain.ID, &dto.RotateDKIMRequest{})
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "already pending")
+	domainRepo.AssertNotCalled(t, "StartDKIMRotation", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func testKeyring(t *testing.T) *engine.Keyring {
+	t.Helper()
+	w, err := engine.NewLocalKeyWrapper("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	require.NoError(t, err)
+	return engine.NewKeyring(w)
+}
+
+func TestDomainService_Create_ImportedKey(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	keyring := testKeyring(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", keyring)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	privateKey, dnsValue, err := engine.GenerateDKIMKey(engine.DKIMAlgorithmEd25519, 0)
+	require.NoError(t, err)
+
+	var created *model.Domain
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").Return(nil, postgres.ErrNotFound)
+	domainRepo.On("Create", ctx, mock.AnythingOfType("*model.Domain")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*model.Domain)
+	}).Return(nil)
+	dnsRepo.On("Create", ctx, mock.AnythingOfType("*model.DomainDNSRecord")).Return(nil).Times(5)
+
+	resp, err := svc.Create(ctx, teamID, &dto.CreateDomainRequest{Name: "example.com", DKIMSelector: "s1", DKIMPrivateKey: privateKey})
+	require.NoError(t, err)
+
+	assert.Equal(t, "s1", resp.DKIM.Selector)
+	assert.Equal(t, "ed25519", resp.DKIM.Algorithm)
+	assert.Equal(t, "s1._domainkey.example.com", resp.DNSRecords[1].Name)
+	assert.Equal(t, dnsValue, resp.DNSRecords[1].Value)
+
+	// The key is stored sealed, not as PEM.
+	require.NotNil(t, created.DKIMPrivateKey)
+	assert.False(t, strings.Contains(*created.DKIMPrivateKey, "PRIVATE KEY"))
+	opened, err := keyring.Open(ctx, *created.DKIMPrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, privateKey, opened)
+}
+
+func TestDomainService_Create_ImportedKeyMismatch(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	privateKey, _, err := engine.GenerateDKIMKey(engine.DKIMAlgorithmEd25519, 0)
+	require.NoError(t, err)
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").Return(nil, postgres.ErrNotFound)
+
+	_, err = svc.Create(ctx, teamID, &dto.CreateDomainRequest{Name: "example.com", DKIMAlgorithm: "rsa", DKIMPrivateKey: privateKey})
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+
+	_, err = svc.Create(ctx, teamID, &dto.CreateDomainRequest{Name: "example.com", DKIMPrivateKey: "not a key"})
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	domainRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestDomainService_ImportDKIM(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	keyring := testKeyring(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", keyring)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domain := testutil.NewTestDomain()
+	domain.Status = model.DomainStatusVerified
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domain.ID).Return(domain, nil)
+
+	privateKey, dnsValue, err := engine.GenerateDKIMKey(engine.DKIMAlgorithmRSA, 1024)
+	require.NoError(t, err)
+
+	var pending model.DomainDNSRecord
+	domainRepo.On("StartDKIMRotation", ctx, mock.AnythingOfType("*model.Domain"), mock.AnythingOfType("*model.DomainDNSRecord")).Run(func(args mock.Arguments) {
+		pending = *args.Get(2).(*model.DomainDNSRecord)
+	}).Return(nil)
+	dnsRepo.On("ListByDomainID", ctx, domain.ID).Return([]model.DomainDNSRecord{}, nil)
+
+	resp, err := svc.ImportDKIM(ctx, teamID, domain.ID, &dto.ImportDKIMRequest{Selector: "google", PrivateKey: privateKey})
+	require.NoError(t, err)
+
+	assert.Equal(t, "mailit", resp.DKIM.Selector)
+	assert.Equal(t, "google", *resp.DKIM.PendingSelector)
+	assert.Equal(t, "google._domainkey.example.com", pending.Name)
+	assert.Equal(t, dnsValue, pending.Value)
+	opened, err := keyring.Open(ctx, *domain.DKIMPendingPrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, privateKey, opened)
+}
+
+func TestDomainService_ImportDKIM_ActiveSelector(t *testing.T) {
+	domainRepo, dnsRepo, asynqClient := newDomainTestDeps(t)
+	svc := NewDomainService(domainRepo, dnsRepo, asynqClient, "mailit", nil)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domain := testutil.NewTestDomain()
+	domainRepo.On("GetByTeamAndID", ctx, teamID, domain.ID).Return(domain, nil)
+
+	privateKey, _, err := engine.GenerateDKIMKey(engine.DKIMAlgorithmEd25519, 0)
+	require.NoError(t, err)
+
+	_, err = svc.ImportDKIM(ctx, teamID, domain.ID, &dto.ImportDKIMRequest{Selector: domain.DKIMSelector, PrivateKey: privateKey})
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	domainRepo.AssertNotCalled(t, "StartDKIMRotation", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +18,7 @@ import (
 	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/templating"
 	"github.com/mailit-dev/mailit/internal/worker"
 )
 
@@ -37,30 +41,55 @@ type EmailService interface {
 }
 
 type emailService struct {
-	emailRepo       postgres.EmailRepository
-	suppressionRepo postgres.SuppressionRepository
-	asynqClient     *asynq.Client
-	redisClient     *redis.Client
+	emailRepo           postgres.EmailRepository
+	domainRepo          postgres.DomainRepository
+	teamRepo            postgres.TeamRepository
+	teamMemberRepo      postgres.TeamMemberRepository
+	suppressionRepo     postgres.SuppressionRepository
+	templateRepo        postgres.TemplateRepository
+	templateVersionRepo postgres.TemplateVersionRepository
+	asynqClient         *asynq.Client
+	redisClient         *redis.Client
+
+	attachmentStorage AttachmentStorage
+	inlineMaxBytes    int
 }
 
-// NewEmailService creates a new EmailService.
+// NewEmailService creates a new EmailService. Attachments larger than
+// inlineMaxBytes are written to attachmentStorage and referenced by key;
+// smaller ones, or all of them when attachmentStorage is nil, are kept
+// inline in the email record.
 func NewEmailService(
 	emailRepo postgres.EmailRepository,
+	domainRepo postgres.DomainRepository,
+	teamRepo postgres.TeamRepository,
+	teamMemberRepo postgres.TeamMemberRepository,
 	suppressionRepo postgres.SuppressionRepository,
+	templateRepo postgres.TemplateRepository,
+	templateVersionRepo postgres.TemplateVersionRepository,
 	asynqClient *asynq.Client,
 	redisClient *redis.Client,
+	attachmentStorage AttachmentStorage,
+	inlineMaxBytes int,
 ) EmailService {
 	return &emailService{
-		emailRepo:       emailRepo,
-		suppressionRepo: suppressionRepo,
-		asynqClient:     asynqClient,
-		redisClient:     redisClient,
+		emailRepo:           emailRepo,
+		domainRepo:          domainRepo,
+		teamRepo:            teamRepo,
+		teamMemberRepo:      teamMemberRepo,
+		suppressionRepo:     suppressionRepo,
+		templateRepo:        templateRepo,
+		templateVersionRepo: templateVersionRepo,
+		asynqClient:         asynqClient,
+		redisClient:         redisClient,
+		attachmentStorage:   attachmentStorage,
+		inlineMaxBytes:      inlineMaxBytes,
 	}
 }
 
 func (s *emailService) Send(ctx context.Context, teamID uuid.UUID, req *dto.SendEmailRequest) (*dto.SendEmailResponse, error) {
 	if err := pkg.Validate(req); err != nil {
-		return nil, fmt.Errorf("validation: %w", err)
+		return nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
 	}
 
 	// Check idempotency key to prevent duplicate sends.
@@ -73,6 +102,11 @@ func (s *emailService) Send(ctx context.Context, teamID uuid.UUID, req *dto.Send
 		}
 	}
 
+	domain, err := s.resolveSendingDomain(ctx, teamID, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check suppression list for each recipient.
 	for _, addr := range req.To {
 		entry, err := s.suppressionRepo.GetByTeamAndEmail(ctx, teamID, addr)
@@ -80,10 +114,22 @@ func (s *emailService) Send(ctx context.Context, teamID uuid.UUID, req *dto.Send
 			return nil, fmt.Errorf("checking suppression list: %w", err)
 		}
 		if entry != nil {
-			return nil, fmt.Errorf("recipient %s is on the suppression list", addr)
+			return nil, fmt.Errorf("%w: recipient %s is on the suppression list", pkg.ErrValidation, addr)
 		}
 	}
 
+	// Render the template, if one was given, into the email content.
+	subject, htmlBody, textBody := req.Subject, req.HTML, req.Text
+	if req.TemplateID != nil {
+		var err error
+		subject, htmlBody, textBody, err = s.renderTemplate(ctx, teamID, req)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(req.Variables) > 0 {
+		return nil, fmt.Errorf("%w: variables require a template_id", pkg.ErrValidation)
+	}
+
 	now := time.Now().UTC()
 
 	// Determine initial status.
@@ -110,27 +156,23 @@ func (s *emailService) Send(ctx context.Context, teamID uuid.UUID, req *dto.Send
 		headers[k] = v
 	}
 
-	// Convert attachments to JSONArray.
-	attachments := make(model.JSONArray, 0, len(req.Attachments))
-	for _, a := range req.Attachments {
-		attachments = append(attachments, map[string]interface{}{
-			"filename":     a.Filename,
-			"content":      a.Content,
-			"content_type": a.ContentType,
-		})
+	attachments, storedKeys, err := s.storeAttachments(ctx, teamID, req.Attachments)
+	if err != nil {
+		return nil, err
 	}
 
 	email := &model.Email{
 		ID:             uuid.New(),
 		TeamID:         teamID,
+		DomainID:       &domain.ID,
 		FromAddress:    req.From,
 		ToAddresses:    req.To,
 		CcAddresses:    req.Cc,
 		BccAddresses:   req.Bcc,
 		ReplyTo:        req.ReplyTo,
-		Subject:        req.Subject,
-		HTMLBody:       req.HTML,
-		TextBody:       req.Text,
+		Subject:        subject,
+		HTMLBody:       htmlBody,
+		TextBody:       textBody,
 		Status:         status,
 		ScheduledAt:    scheduledAt,
 		Tags:           tags,
@@ -143,6 +185,7 @@ func (s *emailService) Send(ctx context.Context, teamID uuid.UUID, req *dto.Send
 	}
 
 	if err := s.emailRepo.Create(ctx, email); err != nil {
+		s.deleteAttachments(ctx, storedKeys)
 		return nil, fmt.Errorf("creating email record: %w", err)
 	}
 
@@ -177,6 +220,153 @@ func (s *emailService) Send(ctx context.Context, teamID uuid.UUID, req *dto.Send
 	return &dto.SendEmailResponse{ID: email.ID.String()}, nil
 }
 
+// resolveSendingDomain returns the team's domain the email is sent from,
+// which must be verified. Teams in sandbox mode may also send from unverified
+// domains, but only to their own members.
+func (s *emailService) resolveSendingDomain(ctx context.Context, teamID uuid.UUID, req *dto.SendEmailRequest) (*model.Domain, error) {
+	name := strings.ToLower(req.From[strings.LastIndex(req.From, "@")+1:])
+	domain, err := s.domainRepo.GetByTeamAndName(ctx, teamID, name)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: domain %s is not registered for this team; add and verify it before sending", pkg.ErrValidation, name)
+		}
+		return nil, fmt.Errorf("fetching sending domain: %w", err)
+	}
+	if domain.SendingPausedAt != nil {
+		return nil, fmt.Errorf("%w: sending from domain %s is paused because its DKIM record is missing from DNS; restore the record and verify the domain", pkg.ErrValidation, name)
+	}
+	if domain.Sendable() {
+		return domain, nil
+	}
+
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching team: %w", err)
+	}
+	if !team.SandboxMode {
+		return nil, fmt.Errorf("%w: domain %s is not verified; verify its DNS records or enable sandbox mode to send to team members", pkg.ErrValidation, name)
+	}
+
+	members, err := s.teamMemberRepo.ListEmailsByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing team member emails: %w", err)
+	}
+	isMember := make(map[string]bool, len(members))
+	for _, m := range members {
+		isMember[strings.ToLower(m)] = true
+	}
+	for _, list := range [][]string{req.To, req.Cc, req.Bcc} {
+		for _, addr := range list {
+			if !isMember[strings.ToLower(addr)] {
+				return nil, fmt.Errorf("%w: domain %s is not verified; in sandbox mode it can only send to team members, not %s", pkg.ErrValidation, name, addr)
+			}
+		}
+	}
+	return domain, nil
+}
+
+// renderTemplate renders the published version of the requested template
+// with the request's variables. Subject, HTML or text given in the request
+// replace the corresponding template part. Variables are checked against the
+// version's declarations, so mistakes are rejected here rather than sent.
+func (s *emailService) renderTemplate(ctx context.Context, teamID uuid.UUID, req *dto.SendEmailRequest) (string, *string, *string, error) {
+	templateID, err := uuid.Parse(*req.TemplateID)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%w: invalid template_id", pkg.ErrValidation)
+	}
+	if _, err := s.templateRepo.GetByTeamAndID(ctx, teamID, templateID); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return "", nil, nil, fmt.Errorf("%w: template %s not found", pkg.ErrValidation, templateID)
+		}
+		return "", nil, nil, fmt.Errorf("fetching template: %w", err)
+	}
+
+	version, err := s.templateVersionRepo.GetPublishedByTemplateID(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return "", nil, nil, fmt.Errorf("%w: template %s has no published version", pkg.ErrValidation, templateID)
+		}
+		return "", nil, nil, fmt.Errorf("fetching published template version: %w", err)
+	}
+
+	subject, htmlBody, textBody := version.Subject, version.HTMLBody, version.TextBody
+	if req.Subject != "" {
+		subject = &req.Subject
+	}
+	if req.HTML != nil {
+		htmlBody = req.HTML
+	}
+	if req.Text != nil {
+		textBody = req.Text
+	}
+
+	variables := templateVariables(version.Variables)
+	data, err := templating.CheckVariables(variables, req.Variables)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%w: invalid variables: %w", pkg.ErrValidation, err)
+	}
+
+	content, err := templating.ParseContent(subject, htmlBody, textBody)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%w: invalid template %w", pkg.ErrValidation, err)
+	}
+	if err := content.CheckReferences(variables); err != nil {
+		return "", nil, nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	renderedSubject, renderedHTML, renderedText := content.Render(data)
+	if renderedSubject == nil || *renderedSubject == "" {
+		return "", nil, nil, fmt.Errorf("%w: subject is required", pkg.ErrValidation)
+	}
+	return *renderedSubject, renderedHTML, renderedText, nil
+}
+
+// storeAttachments converts request attachments to their JSONB form. Content
+// over the inline limit is moved to attachment storage and recorded by key, so
+// large files do not bloat the emails table. The keys written are returned so
+// the caller can clean them up if the email is never created.
+func (s *emailService) storeAttachments(ctx context.Context, teamID uuid.UUID, reqAttachments []dto.Attachment) (model.JSONArray, []string, error) {
+	attachments := make(model.JSONArray, 0, len(reqAttachments))
+	var storedKeys []string
+	for _, a := range reqAttachments {
+		content, err := base64.StdEncoding.DecodeString(a.Content)
+		if err != nil {
+			s.deleteAttachments(ctx, storedKeys)
+			return nil, nil, fmt.Errorf("%w: attachment %s is not valid base64", pkg.ErrValidation, a.Filename)
+		}
+
+		if s.attachmentStorage == nil || len(content) <= s.inlineMaxBytes {
+			attachments = append(attachments, map[string]interface{}{
+				"filename":     a.Filename,
+				"content":      a.Content,
+				"content_type": a.ContentType,
+			})
+			continue
+		}
+
+		key, err := s.attachmentStorage.Store(ctx, teamID, a.Filename, bytes.NewReader(content))
+		if err != nil {
+			s.deleteAttachments(ctx, storedKeys)
+			return nil, nil, fmt.Errorf("storing attachment %s: %w", a.Filename, err)
+		}
+		storedKeys = append(storedKeys, key)
+		attachments = append(attachments, map[string]interface{}{
+			"filename":     a.Filename,
+			"content_type": a.ContentType,
+			"size":         len(content),
+			"path":         key,
+		})
+	}
+	return attachments, storedKeys, nil
+}
+
+// deleteAttachments removes stored attachments on a best-effort basis.
+func (s *emailService) deleteAttachments(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		_ = s.attachmentStorage.Delete(ctx, key)
+	}
+}
+
 func (s *emailService) BatchSend(ctx context.Context, teamID uuid.UUID, req *dto.BatchSendEmailRequest) (*dto.BatchSendEmailResponse, error) {
 	if err := pkg.Validate(req); err != nil {
 		return nil, fmt.Errorf("validation: %w", err)
@@ -282,9 +472,11 @@ func (s *emailService) Cancel(ctx context.Context, teamID uuid.UUID, emailID uui
 		return nil, fmt.Errorf("email not found: %w", postgres.ErrNotFound)
 	}
 
-	// Only queued or scheduled emails can be cancelled.
-	if email.Status != model.EmailStatusQueued && email.Status != model.EmailStatusScheduled {
-		return nil, fmt.Errorf("only queued or scheduled emails can be cancelled")
+	// Only queued, scheduled or held emails can be cancelled.
+	switch email.Status {
+	case model.EmailStatusQueued, model.EmailStatusScheduled, model.EmailStatusHeld:
+	default:
+		return nil, fmt.Errorf("only queued, scheduled or held emails can be cancelled")
 	}
 
 	email.Status = model.EmailStatusCancelled
@@ -293,6 +485,10 @@ func (s *emailService) Cancel(ctx context.Context, teamID uuid.UUID, emailID uui
 	if err := s.emailRepo.Update(ctx, email); err != nil {
 		return nil, fmt.Errorf("cancelling email: %w", err)
 	}
+	// A cancelled email is never sent, so its stored attachments can go.
+	if s.attachmentStorage != nil {
+		s.deleteAttachments(ctx, email.StoredAttachmentKeys())
+	}
 
 	resp := emailToResponse(email)
 	return &resp, nil
@@ -323,6 +519,10 @@ func emailToResponse(e *model.Email) dto.EmailResponse {
 		s := e.SentAt.Format(time.RFC3339)
 		resp.SentAt = &s
 	}
+	if e.BroadcastID != nil {
+		s := e.BroadcastID.String()
+		resp.BroadcastID = &s
+	}
 
 	return resp
 }
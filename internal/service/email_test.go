@@ -2,7 +2,12 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
@@ -14,6 +19,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
@@ -27,9 +33,18 @@ func newEmailTestDeps(t *testing.T) (*tmock.MockEmailRepository, *tmock.MockSupp
 	return new(tmock.MockEmailRepository), new(tmock.MockSuppressionRepository), asynqClient, redisClient, mr
 }
 
+// verifiedDomainRepo returns a domain repository in which example.com is
+// the team's verified sending domain.
+func verifiedDomainRepo() *tmock.MockDomainRepository {
+	domainRepo := new(tmock.MockDomainRepository)
+	domainRepo.On("GetByTeamAndName", mock.Anything, testutil.TestTeamID, "example.com").
+		Return(&model.Domain{ID: uuid.New(), TeamID: testutil.TestTeamID, Name: "example.com", Status: model.DomainStatusVerified}, nil)
+	return domainRepo
+}
+
 func TestEmailService_Send_HappyPath(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -53,9 +68,167 @@ func TestEmailService_Send_HappyPath(t *testing.T) {
 	suppressionRepo.AssertExpectations(t)
 }
 
+func TestEmailService_Send_LargeAttachmentStoredByReference(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	storage := NewLocalAttachmentStorage(t.TempDir())
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, storage, 8)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "recipient@example.com").Return(nil, postgres.ErrNotFound)
+	var created *model.Email
+	emailRepo.On("Create", ctx, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*model.Email) }).
+		Return(nil)
+
+	req := &dto.SendEmailRequest{
+		From:    "sender@example.com",
+		To:      []string{"recipient@example.com"},
+		Subject: "Hello",
+		Text:    testutil.StringPtr("Hello"),
+		Attachments: []dto.Attachment{
+			{Filename: "small.txt", Content: base64.StdEncoding.EncodeToString([]byte("tiny")), ContentType: "text/plain"},
+			{Filename: "large.txt", Content: base64.StdEncoding.EncodeToString([]byte("much larger content")), ContentType: "text/plain"},
+		},
+	}
+
+	_, err := svc.Send(ctx, teamID, req)
+	require.NoError(t, err)
+	require.Len(t, created.Attachments, 2)
+
+	small := created.Attachments[0].(map[string]interface{})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("tiny")), small["content"])
+	assert.NotContains(t, small, "path")
+
+	large := created.Attachments[1].(map[string]interface{})
+	assert.NotContains(t, large, "content")
+	rc, err := storage.Open(ctx, large["path"].(string))
+	require.NoError(t, err)
+	content, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	assert.Equal(t, "much larger content", string(content))
+}
+
+func TestEmailService_Send_InvalidAttachmentContent(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "recipient@example.com").Return(nil, postgres.ErrNotFound)
+
+	req := &dto.SendEmailRequest{
+		From:        "sender@example.com",
+		To:          []string{"recipient@example.com"},
+		Subject:     "Hello",
+		Text:        testutil.StringPtr("Hello"),
+		Attachments: []dto.Attachment{{Filename: "a.txt", Content: "not base64!"}},
+	}
+
+	_, err := svc.Send(ctx, teamID, req)
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	emailRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestEmailService_Send_WithTemplate(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, templateRepo, versionRepo, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	tmpl := testutil.NewTestTemplate()
+	version := testutil.NewTestTemplateVersion(tmpl.ID)
+	version.Published = true
+	version.HTMLBody = testutil.StringPtr(`<p>Hi {{name}}</p>{{#each items}}<li>{{this}}</li>{{/each}}`)
+	version.Variables = model.JSONArray{
+		map[string]interface{}{"name": "name", "type": "string", "required": true},
+		map[string]interface{}{"name": "items", "type": "array"},
+	}
+
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "recipient@example.com").Return(nil, postgres.ErrNotFound)
+	templateRepo.On("GetByTeamAndID", ctx, teamID, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetPublishedByTemplateID", ctx, tmpl.ID).Return(version, nil)
+	var created *model.Email
+	emailRepo.On("Create", ctx, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*model.Email) }).
+		Return(nil)
+
+	req := &dto.SendEmailRequest{
+		From:       "sender@example.com",
+		To:         []string{"recipient@example.com"},
+		TemplateID: testutil.StringPtr(tmpl.ID.String()),
+		Variables:  map[string]interface{}{"name": "<Ada>", "items": []interface{}{"a", "b"}},
+	}
+
+	_, err := svc.Send(ctx, teamID, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Hello <Ada>", created.Subject)
+	assert.Equal(t, "<p>Hi &lt;Ada&gt;</p><li>a</li><li>b</li>", *created.HTMLBody)
+	assert.Equal(t, "Hello <Ada>", *created.TextBody)
+}
+
+func TestEmailService_Send_WithTemplate_InvalidVariables(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, templateRepo, versionRepo, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	tmpl := testutil.NewTestTemplate()
+	version := testutil.NewTestTemplateVersion(tmpl.ID)
+	version.Variables = model.JSONArray{map[string]interface{}{"name": "name", "type": "string", "required": true}}
+
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "recipient@example.com").Return(nil, postgres.ErrNotFound)
+	templateRepo.On("GetByTeamAndID", ctx, teamID, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetPublishedByTemplateID", ctx, tmpl.ID).Return(version, nil)
+
+	req := &dto.SendEmailRequest{
+		From:       "sender@example.com",
+		To:         []string{"recipient@example.com"},
+		TemplateID: testutil.StringPtr(tmpl.ID.String()),
+		Variables:  map[string]interface{}{"nmae": "Ada"},
+	}
+
+	_, err := svc.Send(ctx, teamID, req)
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "name is required; nmae is not a declared variable")
+	emailRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestEmailService_Send_WithTemplate_NotPublished(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, templateRepo, versionRepo, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	tmpl := testutil.NewTestTemplate()
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "recipient@example.com").Return(nil, postgres.ErrNotFound)
+	templateRepo.On("GetByTeamAndID", ctx, teamID, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetPublishedByTemplateID", ctx, tmpl.ID).Return(nil, postgres.ErrNotFound)
+
+	req := &dto.SendEmailRequest{
+		From:       "sender@example.com",
+		To:         []string{"recipient@example.com"},
+		TemplateID: testutil.StringPtr(tmpl.ID.String()),
+	}
+
+	_, err := svc.Send(ctx, teamID, req)
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "has no published version")
+}
+
 func TestEmailService_Send_IdempotencyKey_DuplicateReturnsSameID(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -84,7 +257,7 @@ func TestEmailService_Send_IdempotencyKey_DuplicateReturnsSameID(t *testing.T) {
 
 func TestEmailService_Send_SuppressedRecipient(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -103,6 +276,7 @@ func TestEmailService_Send_SuppressedRecipient(t *testing.T) {
 
 	assert.Nil(t, resp)
 	assert.Error(t, err)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
 	assert.Contains(t, err.Error(), "suppression list")
 
 	suppressionRepo.AssertExpectations(t)
@@ -110,7 +284,7 @@ func TestEmailService_Send_SuppressedRecipient(t *testing.T) {
 
 func TestEmailService_List_Paginated(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -131,7 +305,7 @@ func TestEmailService_List_Paginated(t *testing.T) {
 
 func TestEmailService_Get_HappyPath(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -149,7 +323,7 @@ func TestEmailService_Get_HappyPath(t *testing.T) {
 
 func TestEmailService_Get_WrongTeam(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -167,7 +341,7 @@ func TestEmailService_Get_WrongTeam(t *testing.T) {
 
 func TestEmailService_Cancel_HappyPath(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -184,9 +358,31 @@ func TestEmailService_Cancel_HappyPath(t *testing.T) {
 	emailRepo.AssertExpectations(t)
 }
 
+func TestEmailService_Cancel_DeletesStoredAttachments(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	storage := NewLocalAttachmentStorage(t.TempDir())
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, storage, 8)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	key, err := storage.Store(ctx, teamID, "large.txt", strings.NewReader("much larger content"))
+	require.NoError(t, err)
+	email := testutil.NewTestEmail()
+	email.Status = model.EmailStatusScheduled
+	email.Attachments = model.JSONArray{map[string]interface{}{"filename": "large.txt", "path": key}}
+	emailRepo.On("GetByID", ctx, email.ID).Return(email, nil)
+	emailRepo.On("Update", ctx, mock.AnythingOfType("*model.Email")).Return(nil)
+
+	_, err = svc.Cancel(ctx, teamID, email.ID)
+	require.NoError(t, err)
+
+	_, err = storage.Open(ctx, key)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
 func TestEmailService_Cancel_WrongStatus(t *testing.T) {
 	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
-	svc := NewEmailService(emailRepo, suppressionRepo, asynqClient, redisClient)
+	svc := NewEmailService(emailRepo, verifiedDomainRepo(), nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -198,7 +394,113 @@ func TestEmailService_Cancel_WrongStatus(t *testing.T) {
 
 	assert.Nil(t, resp)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "only queued or scheduled")
+	assert.Contains(t, err.Error(), "only queued, scheduled or held")
 
 	emailRepo.AssertExpectations(t)
 }
+
+func TestEmailService_Send_StoresDomainID(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	domainRepo := new(tmock.MockDomainRepository)
+	svc := NewEmailService(emailRepo, domainRepo, nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domain := &model.Domain{ID: uuid.New(), TeamID: teamID, Name: "example.com", Status: model.DomainStatusVerified}
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").Return(domain, nil)
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "recipient@example.net").Return(nil, postgres.ErrNotFound)
+	var created *model.Email
+	emailRepo.On("Create", ctx, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*model.Email) }).
+		Return(nil)
+
+	_, err := svc.Send(ctx, teamID, &dto.SendEmailRequest{
+		From:    "Sender@Example.com",
+		To:      []string{"recipient@example.net"},
+		Subject: "Hello",
+		Text:    testutil.StringPtr("Hello"),
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, created.DomainID)
+	assert.Equal(t, domain.ID, *created.DomainID)
+}
+
+func TestEmailService_Send_UnknownOrUnverifiedDomain(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	domainRepo := new(tmock.MockDomainRepository)
+	teamRepo := new(tmock.MockTeamRepository)
+	svc := NewEmailService(emailRepo, domainRepo, teamRepo, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "other.com").Return(nil, postgres.ErrNotFound)
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").
+		Return(&model.Domain{ID: uuid.New(), TeamID: teamID, Name: "example.com", Status: model.DomainStatusPending}, nil)
+	teamRepo.On("GetByID", ctx, teamID).Return(&model.Team{ID: teamID}, nil)
+
+	_, err := svc.Send(ctx, teamID, &dto.SendEmailRequest{From: "sender@other.com", To: []string{"a@example.net"}, Subject: "Hi", Text: testutil.StringPtr("Hi")})
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "domain other.com is not registered")
+
+	_, err = svc.Send(ctx, teamID, &dto.SendEmailRequest{From: "sender@example.com", To: []string{"a@example.net"}, Subject: "Hi", Text: testutil.StringPtr("Hi")})
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "domain example.com is not verified")
+
+	emailRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestEmailService_Send_DegradedAndPausedDomain(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	domainRepo := new(tmock.MockDomainRepository)
+	svc := NewEmailService(emailRepo, domainRepo, nil, nil, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	pausedAt := time.Now().UTC()
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").
+		Return(&model.Domain{ID: uuid.New(), TeamID: teamID, Name: "example.com", Status: model.DomainStatusDegraded}, nil)
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "paused.com").
+		Return(&model.Domain{ID: uuid.New(), TeamID: teamID, Name: "paused.com", Status: model.DomainStatusDegraded, SendingPausedAt: &pausedAt}, nil)
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "a@example.net").Return(nil, postgres.ErrNotFound)
+	emailRepo.On("Create", ctx, mock.AnythingOfType("*model.Email")).Return(nil)
+
+	// A degraded domain can still send.
+	_, err := svc.Send(ctx, teamID, &dto.SendEmailRequest{From: "sender@example.com", To: []string{"a@example.net"}, Subject: "Hi", Text: testutil.StringPtr("Hi")})
+	require.NoError(t, err)
+
+	_, err = svc.Send(ctx, teamID, &dto.SendEmailRequest{From: "sender@paused.com", To: []string{"a@example.net"}, Subject: "Hi", Text: testutil.StringPtr("Hi")})
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "paused")
+
+	emailRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestEmailService_Send_SandboxMode(t *testing.T) {
+	emailRepo, suppressionRepo, asynqClient, redisClient, _ := newEmailTestDeps(t)
+	domainRepo := new(tmock.MockDomainRepository)
+	teamRepo := new(tmock.MockTeamRepository)
+	memberRepo := new(tmock.MockTeamMemberRepository)
+	svc := NewEmailService(emailRepo, domainRepo, teamRepo, memberRepo, suppressionRepo, nil, nil, asynqClient, redisClient, nil, 0)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	domainRepo.On("GetByTeamAndName", ctx, teamID, "example.com").
+		Return(&model.Domain{ID: uuid.New(), TeamID: teamID, Name: "example.com", Status: model.DomainStatusPending}, nil)
+	teamRepo.On("GetByID", ctx, teamID).Return(&model.Team{ID: teamID, SandboxMode: true}, nil)
+	memberRepo.On("ListEmailsByTeamID", ctx, teamID).Return([]string{"Dev@Example.net"}, nil)
+	suppressionRepo.On("GetByTeamAndEmail", ctx, teamID, "dev@example.net").Return(nil, postgres.ErrNotFound)
+	emailRepo.On("Create", ctx, mock.AnythingOfType("*model.Email")).Return(nil)
+
+	_, err := svc.Send(ctx, teamID, &dto.SendEmailRequest{From: "sender@example.com", To: []string{"dev@example.net"}, Subject: "Hi", Text: testutil.StringPtr("Hi")})
+	require.NoError(t, err)
+
+	_, err = svc.Send(ctx, teamID, &dto.SendEmailRequest{
+		From: "sender@example.com", To: []string{"dev@example.net"}, Bcc: []string{"customer@example.org"},
+		Subject: "Hi", Text: testutil.StringPtr("Hi"),
+	})
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "customer@example.org")
+
+	emailRepo.AssertNumberOfCalls(t, "Create", 1)
+}
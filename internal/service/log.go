@@ -3,17 +3,20 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 // LogService defines operations for querying application logs.
 type LogService interface {
-	List(ctx context.Context, teamID uuid.UUID, level string, params *dto.PaginationParams) (*dto.PaginatedResponse[model.Log], error)
+	List(ctx context.Context, teamID uuid.UUID, params *dto.LogListParams) (*dto.PaginatedResponse[model.Log], error)
 }
 
 type logService struct {
@@ -27,10 +30,31 @@ func NewLogService(logRepo postgres.LogRepository) LogService {
 	}
 }
 
-func (s *logService) List(ctx context.Context, teamID uuid.UUID, level string, params *dto.PaginationParams) (*dto.PaginatedResponse[model.Log], error) {
+func (s *logService) List(ctx context.Context, teamID uuid.UUID, params *dto.LogListParams) (*dto.PaginatedResponse[model.Log], error) {
 	params.Normalize()
 
-	logs, total, err := s.logRepo.List(ctx, teamID, level, params.PerPage, params.Offset())
+	filter := postgres.LogFilter{
+		Level:      params.Level,
+		Method:     strings.ToUpper(params.Method),
+		PathPrefix: params.Path,
+		RequestID:  params.RequestID,
+	}
+	if params.Status != "" {
+		from, to, err := parseStatusFilter(params.Status)
+		if err != nil {
+			return nil, err
+		}
+		filter.StatusFrom, filter.StatusTo = from, to
+	}
+	if params.APIKeyID != "" {
+		id, err := uuid.Parse(params.APIKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid api_key_id", pkg.ErrValidation)
+		}
+		filter.APIKeyID = &id
+	}
+
+	logs, total, err := s.logRepo.List(ctx, teamID, filter, params.PerPage, params.Offset())
 	if err != nil {
 		return nil, fmt.Errorf("listing logs: %w", err)
 	}
@@ -49,3 +73,17 @@ func (s *logService) List(ctx context.Context, teamID uuid.UUID, level string, p
 		HasMore:    params.Page < totalPages,
 	}, nil
 }
+
+// parseStatusFilter parses a status filter, either a status code such as 404
+// or a class such as 4xx, into an inclusive range of status codes.
+func parseStatusFilter(status string) (from, to int, err error) {
+	if class, ok := strings.CutSuffix(strings.ToLower(status), "xx"); ok && len(class) == 1 && class >= "1" && class <= "5" {
+		from = int(class[0]-'0') * 100
+		return from, from + 99, nil
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("%w: status must be a status code such as 404 or a class such as 4xx", pkg.ErrValidation)
+	}
+	return code, code, nil
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
 )
@@ -27,10 +29,10 @@ func TestLogService_List_WithLevelFilter(t *testing.T) {
 		Message:  "Something went wrong",
 		Metadata: model.JSONMap{},
 	}
-	logRepo.On("List", ctx, teamID, "error", 20, 0).Return([]model.Log{log1}, 1, nil)
+	logRepo.On("List", ctx, teamID, postgres.LogFilter{Level: "error"}, 20, 0).Return([]model.Log{log1}, 1, nil)
 
-	params := &dto.PaginationParams{Page: 1, PerPage: 20}
-	resp, err := svc.List(ctx, teamID, "error", params)
+	params := &dto.LogListParams{PaginationParams: dto.PaginationParams{Page: 1, PerPage: 20}, Level: "error"}
+	resp, err := svc.List(ctx, teamID, params)
 
 	require.NoError(t, err)
 	assert.Equal(t, 1, resp.Total)
@@ -48,10 +50,10 @@ func TestLogService_List_AllLevels(t *testing.T) {
 
 	log1 := model.Log{ID: uuid.New(), TeamID: teamID, Level: "info", Message: "Info message", Metadata: model.JSONMap{}}
 	log2 := model.Log{ID: uuid.New(), TeamID: teamID, Level: "error", Message: "Error message", Metadata: model.JSONMap{}}
-	logRepo.On("List", ctx, teamID, "", 20, 0).Return([]model.Log{log1, log2}, 2, nil)
+	logRepo.On("List", ctx, teamID, postgres.LogFilter{}, 20, 0).Return([]model.Log{log1, log2}, 2, nil)
 
-	params := &dto.PaginationParams{Page: 1, PerPage: 20}
-	resp, err := svc.List(ctx, teamID, "", params)
+	params := &dto.LogListParams{PaginationParams: dto.PaginationParams{Page: 1, PerPage: 20}}
+	resp, err := svc.List(ctx, teamID, params)
 
 	require.NoError(t, err)
 	assert.Equal(t, 2, resp.Total)
@@ -66,10 +68,10 @@ func TestLogService_List_EmptyResult(t *testing.T) {
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
-	logRepo.On("List", ctx, teamID, "debug", 20, 0).Return([]model.Log{}, 0, nil)
+	logRepo.On("List", ctx, teamID, postgres.LogFilter{Level: "debug"}, 20, 0).Return([]model.Log{}, 0, nil)
 
-	params := &dto.PaginationParams{Page: 1, PerPage: 20}
-	resp, err := svc.List(ctx, teamID, "debug", params)
+	params := &dto.LogListParams{PaginationParams: dto.PaginationParams{Page: 1, PerPage: 20}, Level: "debug"}
+	resp, err := svc.List(ctx, teamID, params)
 
 	require.NoError(t, err)
 	assert.Equal(t, 0, resp.Total)
@@ -87,10 +89,10 @@ func TestLogService_List_Pagination(t *testing.T) {
 
 	// Page 2, requesting 10 per page, total of 25 records.
 	log1 := model.Log{ID: uuid.New(), TeamID: teamID, Level: "info", Message: "Page 2 log", Metadata: model.JSONMap{}}
-	logRepo.On("List", ctx, teamID, "", 10, 10).Return([]model.Log{log1}, 25, nil)
+	logRepo.On("List", ctx, teamID, postgres.LogFilter{}, 10, 10).Return([]model.Log{log1}, 25, nil)
 
-	params := &dto.PaginationParams{Page: 2, PerPage: 10}
-	resp, err := svc.List(ctx, teamID, "", params)
+	params := &dto.LogListParams{PaginationParams: dto.PaginationParams{Page: 2, PerPage: 10}}
+	resp, err := svc.List(ctx, teamID, params)
 
 	require.NoError(t, err)
 	assert.Equal(t, 25, resp.Total)
@@ -100,3 +102,78 @@ func TestLogService_List_Pagination(t *testing.T) {
 
 	logRepo.AssertExpectations(t)
 }
+
+func TestLogService_List_RequestFilters(t *testing.T) {
+	logRepo := new(tmock.MockLogRepository)
+	svc := NewLogService(logRepo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+	apiKeyID := uuid.New()
+
+	filter := postgres.LogFilter{
+		Method:     "POST",
+		PathPrefix: "/emails",
+		StatusFrom: 400,
+		StatusTo:   499,
+		APIKeyID:   &apiKeyID,
+		RequestID:  "req-123",
+	}
+	logRepo.On("List", ctx, teamID, filter, 20, 0).Return([]model.Log{}, 0, nil)
+
+	params := &dto.LogListParams{
+		PaginationParams: dto.PaginationParams{Page: 1, PerPage: 20},
+		Method:           "post",
+		Path:             "/emails",
+		Status:           "4xx",
+		APIKeyID:         apiKeyID.String(),
+		RequestID:        "req-123",
+	}
+	_, err := svc.List(ctx, teamID, params)
+
+	require.NoError(t, err)
+	logRepo.AssertExpectations(t)
+}
+
+func TestLogService_List_InvalidFilters(t *testing.T) {
+	logRepo := new(tmock.MockLogRepository)
+	svc := NewLogService(logRepo)
+	ctx := context.Background()
+
+	for name, params := range map[string]*dto.LogListParams{
+		"status":     {Status: "4x"},
+		"api key id": {APIKeyID: "not-a-uuid"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.List(ctx, testutil.TestTeamID, params)
+			assert.ErrorIs(t, err, pkg.ErrValidation)
+		})
+	}
+	logRepo.AssertNotCalled(t, "List")
+}
+
+func TestParseStatusFilter(t *testing.T) {
+	tests := []struct {
+		status   string
+		from, to int
+		wantErr  bool
+	}{
+		{status: "404", from: 404, to: 404},
+		{status: "2xx", from: 200, to: 299},
+		{status: "5XX", from: 500, to: 599},
+		{status: "6xx", wantErr: true},
+		{status: "99", wantErr: true},
+		{status: "ok", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			from, to, err := parseStatusFilter(tt.status)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, pkg.ErrValidation)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// logWriteTimeout bounds writing a single batch of logs.
+const logWriteTimeout = 10 * time.Second
+
+// LogWriter writes API request logs to the database in batches, off the
+// request path. Logs are buffered in memory; when the buffer is full, new
+// logs are dropped rather than slowing requests down.
+type LogWriter struct {
+	logRepo       postgres.LogRepository
+	batchSize     int
+	flushInterval time.Duration
+	logger        *slog.Logger
+
+	entries   chan *model.Log
+	dropped   atomic.Int64
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+// NewLogWriter creates a LogWriter that holds up to bufferSize logs and
+// writes them batchSize at a time, or every flushInterval if fewer arrive.
+func NewLogWriter(logRepo postgres.LogRepository, batchSize, bufferSize int, flushInterval time.Duration, logger *slog.Logger) *LogWriter {
+	return &LogWriter{
+		logRepo:       logRepo,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		logger:        logger,
+		entries:       make(chan *model.Log, bufferSize),
+		closed:        make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Record queues a log for writing. It never blocks.
+func (w *LogWriter) Record(entry *model.Log) {
+	select {
+	case w.entries <- entry:
+	default:
+		if w.dropped.Add(1) == 1 {
+			w.logger.Warn("request log buffer full, dropping logs")
+		}
+	}
+}
+
+// Run writes queued logs until Close is called, then writes the logs still
+// queued and returns.
+func (w *LogWriter) Run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.Log, 0, w.batchSize)
+	for {
+		select {
+		case entry := <-w.entries:
+			batch = append(batch, *entry)
+			if len(batch) >= w.batchSize {
+				batch = w.flush(batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(batch)
+		case <-w.closed:
+			for {
+				select {
+				case entry := <-w.entries:
+					batch = append(batch, *entry)
+					if len(batch) >= w.batchSize {
+						batch = w.flush(batch)
+					}
+				default:
+					w.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close stops Run and waits for it to write the logs still queued.
+func (w *LogWriter) Close() {
+	w.closeOnce.Do(func() { close(w.closed) })
+	<-w.done
+}
+
+// flush writes batch and returns it emptied for reuse. Failed batches are
+// logged and dropped.
+func (w *LogWriter) flush(batch []model.Log) []model.Log {
+	if dropped := w.dropped.Swap(0); dropped > 0 {
+		w.logger.Warn("request logs dropped", "count", dropped)
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), logWriteTimeout)
+	defer cancel()
+	if err := w.logRepo.CreateBatch(ctx, batch); err != nil {
+		w.logger.Error("writing request logs failed", "count", len(batch), "error", err)
+	}
+	return batch[:0]
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func newTestLogEntry() *model.Log {
+	return &model.Log{ID: uuid.New(), TeamID: testutil.TestTeamID, Level: "info", Message: "GET /emails 200"}
+}
+
+func TestLogWriter_WritesFullBatches(t *testing.T) {
+	logRepo := new(tmock.MockLogRepository)
+	written := make(chan int, 10)
+	logRepo.On("CreateBatch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		written <- len(args.Get(1).([]model.Log))
+	}).Return(nil)
+
+	w := NewLogWriter(logRepo, 2, 10, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	go w.Run()
+
+	w.Record(newTestLogEntry())
+	w.Record(newTestLogEntry())
+	select {
+	case n := <-written:
+		assert.Equal(t, 2, n)
+	case <-time.After(time.Second):
+		t.Fatal("full batch was not written")
+	}
+
+	w.Record(newTestLogEntry())
+	w.Close()
+	assert.Equal(t, 1, <-written, "Close must write the remaining logs")
+}
+
+func TestLogWriter_FlushesOnInterval(t *testing.T) {
+	logRepo := new(tmock.MockLogRepository)
+	written := make(chan int, 10)
+	logRepo.On("CreateBatch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		written <- len(args.Get(1).([]model.Log))
+	}).Return(nil)
+
+	w := NewLogWriter(logRepo, 100, 10, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	go w.Run()
+	defer w.Close()
+
+	w.Record(newTestLogEntry())
+	select {
+	case n := <-written:
+		assert.Equal(t, 1, n)
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not written")
+	}
+}
+
+func TestLogWriter_DropsWhenBufferFull(t *testing.T) {
+	logRepo := new(tmock.MockLogRepository)
+	logRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+	// Run is not started, so nothing leaves the buffer until Close.
+	w := NewLogWriter(logRepo, 100, 2, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for range 5 {
+		w.Record(newTestLogEntry())
+	}
+	assert.Equal(t, int64(3), w.dropped.Load())
+
+	go w.Run()
+	w.Close()
+	logRepo.AssertCalled(t, "CreateBatch", mock.Anything, mock.MatchedBy(func(logs []model.Log) bool {
+		return len(logs) == 2
+	}))
+}
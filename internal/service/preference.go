@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/worker"
+)
+
+// PreferenceService backs the hosted preference center and the RFC 8058
+// one-click unsubscribe endpoint. Recipients are identified by a token signed
+// with pkg.SignPreferenceToken rather than by a login.
+type PreferenceService interface {
+	Get(ctx context.Context, token string) (*dto.PreferencesResponse, error)
+	Update(ctx context.Context, token string, req *dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error)
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+type preferenceService struct {
+	contactRepo      postgres.ContactRepository
+	topicRepo        postgres.TopicRepository
+	contactTopicRepo postgres.ContactTopicRepository
+	segmentRepo      postgres.SegmentRepository
+	webhookDispatch  worker.WebhookDispatchFunc
+	tokenSecret      string
+	logger           *slog.Logger
+}
+
+// NewPreferenceService creates a new PreferenceService. tokenSecret must be
+// the secret the unsubscribe links were signed with.
+func NewPreferenceService(
+	contactRepo postgres.ContactRepository,
+	topicRepo postgres.TopicRepository,
+	contactTopicRepo postgres.ContactTopicRepository,
+	segmentRepo postgres.SegmentRepository,
+	webhookDispatch worker.WebhookDispatchFunc,
+	tokenSecret string,
+	logger *slog.Logger,
+) PreferenceService {
+	return &preferenceService{
+		contactRepo:      contactRepo,
+		topicRepo:        topicRepo,
+		contactTopicRepo: contactTopicRepo,
+		segmentRepo:      segmentRepo,
+		webhookDispatch:  webhookDispatch,
+		tokenSecret:      tokenSecret,
+		logger:           logger,
+	}
+}
+
+func (s *preferenceService) Get(ctx context.Context, token string) (*dto.PreferencesResponse, error) {
+	teamID, email, contacts, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.preferences(ctx, teamID, email, contacts)
+}
+
+func (s *preferenceService) Update(ctx context.Context, token string, req *dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error) {
+	teamID, email, contacts, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := s.topicRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing topics: %w", err)
+	}
+
+	keep := make(map[uuid.UUID]bool, len(req.Topics))
+	for _, id := range req.Topics {
+		topicID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid topic id %q", pkg.ErrValidation, id)
+		}
+		keep[topicID] = true
+	}
+
+	now := time.Now().UTC()
+	wasSubscribed := false
+	for i := range contacts {
+		c := &contacts[i]
+		wasSubscribed = wasSubscribed || !c.Unsubscribed
+
+		for _, t := range topics {
+			ct := &model.ContactTopic{
+				ID:         uuid.New(),
+				ContactID:  c.ID,
+				TopicID:    t.ID,
+				Subscribed: keep[t.ID] && !req.UnsubscribeAll,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			if err := s.contactTopicRepo.Upsert(ctx, ct); err != nil {
+				return nil, fmt.Errorf("updating topic subscription: %w", err)
+			}
+		}
+
+		if c.Unsubscribed != req.UnsubscribeAll {
+			c.Unsubscribed = req.UnsubscribeAll
+			c.UpdatedAt = now
+			if err := s.contactRepo.Update(ctx, c); err != nil {
+				return nil, fmt.Errorf("updating contact %s: %w", c.ID, err)
+			}
+		}
+
+		s.refreshSegments(ctx, c.ID)
+	}
+
+	if req.UnsubscribeAll && wasSubscribed {
+		s.dispatchUnsubscribed(ctx, teamID, email)
+	}
+
+	return s.preferences(ctx, teamID, email, contacts)
+}
+
+func (s *preferenceService) Unsubscribe(ctx context.Context, token string) error {
+	teamID, email, err := pkg.VerifyPreferenceToken(s.tokenSecret, token)
+	if err != nil {
+		return err
+	}
+
+	contacts, err := s.contactRepo.ListByTeamAndEmail(ctx, teamID, email)
+	if err != nil {
+		return fmt.Errorf("listing contacts: %w", err)
+	}
+
+	now := time.Now().UTC()
+	changed := false
+	for i := range contacts {
+		c := &contacts[i]
+		if c.Unsubscribed {
+			continue
+		}
+		c.Unsubscribed = true
+		c.UpdatedAt = now
+		if err := s.contactRepo.Update(ctx, c); err != nil {
+			return fmt.Errorf("unsubscribing contact %s: %w", c.ID, err)
+		}
+		s.refreshSegments(ctx, c.ID)
+		changed = true
+	}
+
+	// Repeated one-click requests are acknowledged without side effects.
+	if changed {
+		s.dispatchUnsubscribed(ctx, teamID, email)
+	}
+
+	return nil
+}
+
+// resolve verifies the token and loads the recipient's contacts.
+func (s *preferenceService) resolve(ctx context.Context, token string) (uuid.UUID, string, []model.Contact, error) {
+	teamID, email, err := pkg.VerifyPreferenceToken(s.tokenSecret, token)
+	if err != nil {
+		return uuid.Nil, "", nil, err
+	}
+
+	contacts, err := s.contactRepo.ListByTeamAndEmail(ctx, teamID, email)
+	if err != nil {
+		return uuid.Nil, "", nil, fmt.Errorf("listing contacts: %w", err)
+	}
+	if len(contacts) == 0 {
+		return uuid.Nil, "", nil, fmt.Errorf("contact not found: %w", postgres.ErrNotFound)
+	}
+
+	return teamID, email, contacts, nil
+}
+
+// preferences builds the response for a recipient. A topic is shown as
+// subscribed when any of the recipient's contacts is subscribed to it, either
+// explicitly or through the topic's default, and the recipient as
+// unsubscribed only when every contact is.
+func (s *preferenceService) preferences(ctx context.Context, teamID uuid.UUID, email string, contacts []model.Contact) (*dto.PreferencesResponse, error) {
+	topics, err := s.topicRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing topics: %w", err)
+	}
+
+	subscribed := make(map[uuid.UUID]bool)
+	unsubscribed := true
+	for _, c := range contacts {
+		unsubscribed = unsubscribed && c.Unsubscribed
+
+		cts, err := s.contactTopicRepo.ListByContactID(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing topic subscriptions: %w", err)
+		}
+		explicit := make(map[uuid.UUID]bool, len(cts))
+		for _, ct := range cts {
+			explicit[ct.TopicID] = ct.Subscribed
+		}
+		for _, t := range topics {
+			sub, ok := explicit[t.ID]
+			if !ok {
+				sub = t.SubscribedByDefault()
+			}
+			if sub {
+				subscribed[t.ID] = true
+			}
+		}
+	}
+
+	resp := &dto.PreferencesResponse{
+		Email:        email,
+		Unsubscribed: unsubscribed,
+		Topics:       make([]dto.TopicPreference, 0, len(topics)),
+	}
+	for _, t := range topics {
+		resp.Topics = append(resp.Topics, dto.TopicPreference{
+			ID:          t.ID.String(),
+			Name:        t.Name,
+			Description: t.Description,
+			Subscribed:  subscribed[t.ID],
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *preferenceService) dispatchUnsubscribed(ctx context.Context, teamID uuid.UUID, email string) {
+	if s.webhookDispatch == nil {
+		return
+	}
+	s.webhookDispatch(ctx, teamID, "contact.unsubscribed", map[string]interface{}{
+		"recipient": email,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// refreshSegments re-evaluates segment membership for a contact whose
+// subscription changed. A failure is only logged: membership is recomputed
+// again before every broadcast send.
+func (s *preferenceService) refreshSegments(ctx context.Context, contactID uuid.UUID) {
+	if err := s.segmentRepo.RefreshContact(ctx, contactID); err != nil {
+		s.logger.Error("failed to refresh segments for contact", "contact_id", contactID, "error", err)
+	}
+}
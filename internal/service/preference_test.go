@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+const testPreferenceSecret = "preference-secret"
+
+type preferenceMocks struct {
+	contactRepo      *tmock.MockContactRepository
+	topicRepo        *tmock.MockTopicRepository
+	contactTopicRepo *tmock.MockContactTopicRepository
+	segmentRepo      *tmock.MockSegmentRepository
+	dispatched       []string
+	logs             bytes.Buffer
+}
+
+func newPreferenceService() (PreferenceService, *preferenceMocks) {
+	m := &preferenceMocks{
+		contactRepo:      new(tmock.MockContactRepository),
+		topicRepo:        new(tmock.MockTopicRepository),
+		contactTopicRepo: new(tmock.MockContactTopicRepository),
+		segmentRepo:      new(tmock.MockSegmentRepository),
+	}
+	dispatch := func(ctx context.Context, teamID uuid.UUID, eventType string, payload interface{}) {
+		m.dispatched = append(m.dispatched, eventType)
+	}
+	svc := NewPreferenceService(m.contactRepo, m.topicRepo, m.contactTopicRepo, m.segmentRepo, dispatch, testPreferenceSecret, slog.New(slog.NewTextHandler(&m.logs, nil)))
+	return svc, m
+}
+
+func TestPreferenceService_Get(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "john@example.com")
+
+	first := testutil.NewTestContact(uuid.New())
+	second := testutil.NewTestContact(uuid.New())
+	second.Unsubscribed = true
+	news := model.Topic{ID: uuid.New(), TeamID: testutil.TestTeamID, Name: "Newsletter", DefaultSubscription: model.TopicDefaultOptOut}
+	product := model.Topic{ID: uuid.New(), TeamID: testutil.TestTeamID, Name: "Product updates", DefaultSubscription: model.TopicDefaultOptOut}
+	digest := model.Topic{ID: uuid.New(), TeamID: testutil.TestTeamID, Name: "Weekly digest", DefaultSubscription: model.TopicDefaultOptIn}
+
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "john@example.com").
+		Return([]model.Contact{*first, *second}, nil)
+	m.topicRepo.On("ListByTeamID", ctx, testutil.TestTeamID).Return([]model.Topic{news, product, digest}, nil)
+	m.contactTopicRepo.On("ListByContactID", ctx, first.ID).
+		Return([]model.ContactTopic{{ContactID: first.ID, TopicID: news.ID, Subscribed: false}}, nil)
+	m.contactTopicRepo.On("ListByContactID", ctx, second.ID).
+		Return([]model.ContactTopic{{ContactID: second.ID, TopicID: news.ID, Subscribed: true}}, nil)
+
+	resp, err := svc.Get(ctx, token)
+
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", resp.Email)
+	assert.False(t, resp.Unsubscribed, "one contact is still subscribed")
+	require.Len(t, resp.Topics, 3)
+	assert.True(t, resp.Topics[0].Subscribed)
+	assert.False(t, resp.Topics[1].Subscribed)
+	assert.True(t, resp.Topics[2].Subscribed, "opt-in topics apply to contacts without a preference")
+}
+
+func TestPreferenceService_Get_InvalidToken(t *testing.T) {
+	svc, m := newPreferenceService()
+
+	resp, err := svc.Get(context.Background(), "forged.token")
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrInvalidToken)
+	m.contactRepo.AssertNotCalled(t, "ListByTeamAndEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPreferenceService_Get_NoContacts(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "gone@example.com")
+
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "gone@example.com").Return([]model.Contact{}, nil)
+
+	_, err := svc.Get(ctx, token)
+
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+}
+
+func TestPreferenceService_Update(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "john@example.com")
+
+	contact := testutil.NewTestContact(uuid.New())
+	news := model.Topic{ID: uuid.New(), TeamID: testutil.TestTeamID, Name: "Newsletter"}
+	product := model.Topic{ID: uuid.New(), TeamID: testutil.TestTeamID, Name: "Product updates"}
+
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "john@example.com").Return([]model.Contact{*contact}, nil)
+	m.topicRepo.On("ListByTeamID", ctx, testutil.TestTeamID).Return([]model.Topic{news, product}, nil)
+
+	saved := make(map[uuid.UUID]bool)
+	m.contactTopicRepo.On("Upsert", ctx, mock.AnythingOfType("*model.ContactTopic")).
+		Run(func(args mock.Arguments) {
+			ct := args.Get(1).(*model.ContactTopic)
+			saved[ct.TopicID] = ct.Subscribed
+		}).
+		Return(nil)
+	m.segmentRepo.On("RefreshContact", ctx, contact.ID).Return(nil)
+	m.contactTopicRepo.On("ListByContactID", ctx, contact.ID).
+		Return([]model.ContactTopic{{ContactID: contact.ID, TopicID: news.ID, Subscribed: true}}, nil)
+
+	resp, err := svc.Update(ctx, token, &dto.UpdatePreferencesRequest{Topics: []string{news.ID.String()}})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]bool{news.ID: true, product.ID: false}, saved)
+	assert.False(t, resp.Unsubscribed)
+	assert.Empty(t, m.dispatched)
+	m.contactRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestPreferenceService_Update_InvalidTopicID(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "john@example.com")
+
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "john@example.com").
+		Return([]model.Contact{*testutil.NewTestContact(uuid.New())}, nil)
+	m.topicRepo.On("ListByTeamID", ctx, testutil.TestTeamID).Return([]model.Topic{}, nil)
+
+	_, err := svc.Update(ctx, token, &dto.UpdatePreferencesRequest{Topics: []string{"newsletter"}})
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+}
+
+func TestPreferenceService_Unsubscribe(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "john@example.com")
+
+	subscribed := testutil.NewTestContact(uuid.New())
+	already := testutil.NewTestContact(uuid.New())
+	already.Unsubscribed = true
+
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "john@example.com").
+		Return([]model.Contact{*subscribed, *already}, nil)
+	m.contactRepo.On("Update", ctx, mock.MatchedBy(func(c *model.Contact) bool {
+		return c.ID == subscribed.ID && c.Unsubscribed
+	})).Return(nil)
+	m.segmentRepo.On("RefreshContact", ctx, subscribed.ID).Return(nil)
+
+	err := svc.Unsubscribe(ctx, token)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"contact.unsubscribed"}, m.dispatched)
+	m.contactRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestPreferenceService_Unsubscribe_SegmentRefreshFails(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "john@example.com")
+
+	contact := testutil.NewTestContact(uuid.New())
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "john@example.com").Return([]model.Contact{*contact}, nil)
+	m.contactRepo.On("Update", ctx, mock.AnythingOfType("*model.Contact")).Return(nil)
+	m.segmentRepo.On("RefreshContact", ctx, contact.ID).Return(assert.AnError)
+
+	// The unsubscribe still goes through; the failure is logged.
+	require.NoError(t, svc.Unsubscribe(ctx, token))
+	assert.Equal(t, []string{"contact.unsubscribed"}, m.dispatched)
+	assert.Contains(t, m.logs.String(), "failed to refresh segments for contact")
+	assert.Contains(t, m.logs.String(), "contact_id="+contact.ID.String())
+}
+
+func TestPreferenceService_Unsubscribe_AlreadyUnsubscribed(t *testing.T) {
+	svc, m := newPreferenceService()
+	ctx := context.Background()
+	token := pkg.SignPreferenceToken(testPreferenceSecret, testutil.TestTeamID, "john@example.com")
+
+	contact := testutil.NewTestContact(uuid.New())
+	contact.Unsubscribed = true
+	m.contactRepo.On("ListByTeamAndEmail", ctx, testutil.TestTeamID, "john@example.com").Return([]model.Contact{*contact}, nil)
+
+	err := svc.Unsubscribe(ctx, token)
+
+	require.NoError(t, err)
+	assert.Empty(t, m.dispatched)
+	m.contactRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -19,18 +19,28 @@ type SegmentService interface {
 	List(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID) (*dto.ListResponse[dto.SegmentResponse], error)
 	Update(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, segmentID uuid.UUID, req *dto.UpdateSegmentRequest) (*dto.SegmentResponse, error)
 	Delete(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, segmentID uuid.UUID) error
+	Refresh(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, segmentID uuid.UUID) (*dto.SegmentRefreshResponse, error)
 }
 
 type segmentService struct {
 	segmentRepo  postgres.SegmentRepository
 	audienceRepo postgres.AudienceRepository
+	propertyRepo postgres.ContactPropertyRepository
+	topicRepo    postgres.TopicRepository
 }
 
 // NewSegmentService creates a new SegmentService.
-func NewSegmentService(segmentRepo postgres.SegmentRepository, audienceRepo postgres.AudienceRepository) SegmentService {
+func NewSegmentService(
+	segmentRepo postgres.SegmentRepository,
+	audienceRepo postgres.AudienceRepository,
+	propertyRepo postgres.ContactPropertyRepository,
+	topicRepo postgres.TopicRepository,
+) SegmentService {
 	return &segmentService{
 		segmentRepo:  segmentRepo,
 		audienceRepo: audienceRepo,
+		propertyRepo: propertyRepo,
+		topicRepo:    topicRepo,
 	}
 }
 
@@ -60,6 +70,9 @@ func (s *segmentService) Create(ctx context.Context, teamID uuid.UUID, audienceI
 	if err != nil {
 		return nil, fmt.Errorf("invalid conditions format: %w", err)
 	}
+	if err := s.validateConditions(ctx, teamID, conditions); err != nil {
+		return nil, err
+	}
 
 	now := time.Now().UTC()
 
@@ -76,6 +89,10 @@ func (s *segmentService) Create(ctx context.Context, teamID uuid.UUID, audienceI
 		return nil, fmt.Errorf("creating segment: %w", err)
 	}
 
+	if _, err := s.segmentRepo.RefreshMembers(ctx, segment.ID); err != nil {
+		return nil, fmt.Errorf("computing segment members: %w", err)
+	}
+
 	return segmentToResponse(segment), nil
 }
 
@@ -120,6 +137,9 @@ func (s *segmentService) Update(ctx context.Context, teamID uuid.UUID, audienceI
 		if err != nil {
 			return nil, fmt.Errorf("invalid conditions format: %w", err)
 		}
+		if err := s.validateConditions(ctx, teamID, conditions); err != nil {
+			return nil, err
+		}
 		segment.Conditions = conditions
 	}
 
@@ -129,6 +149,12 @@ func (s *segmentService) Update(ctx context.Context, teamID uuid.UUID, audienceI
 		return nil, fmt.Errorf("updating segment: %w", err)
 	}
 
+	if req.Conditions != nil {
+		if _, err := s.segmentRepo.RefreshMembers(ctx, segment.ID); err != nil {
+			return nil, fmt.Errorf("computing segment members: %w", err)
+		}
+	}
+
 	return segmentToResponse(segment), nil
 }
 
@@ -154,6 +180,32 @@ func (s *segmentService) Delete(ctx context.Context, teamID uuid.UUID, audienceI
 	return nil
 }
 
+func (s *segmentService) Refresh(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, segmentID uuid.UUID) (*dto.SegmentRefreshResponse, error) {
+	if err := s.verifyAudienceOwnership(ctx, teamID, audienceID); err != nil {
+		return nil, err
+	}
+
+	segment, err := s.segmentRepo.GetByID(ctx, segmentID)
+	if err != nil {
+		return nil, fmt.Errorf("segment not found: %w", err)
+	}
+
+	// Verify the segment belongs to the audience.
+	if segment.AudienceID != audienceID {
+		return nil, fmt.Errorf("segment not found: %w", postgres.ErrNotFound)
+	}
+
+	count, err := s.segmentRepo.RefreshMembers(ctx, segmentID)
+	if err != nil {
+		return nil, fmt.Errorf("computing segment members: %w", err)
+	}
+
+	return &dto.SegmentRefreshResponse{
+		ID:       segment.ID.String(),
+		Contacts: count,
+	}, nil
+}
+
 // segmentToResponse converts a model.Segment to a dto.SegmentResponse.
 func segmentToResponse(seg *model.Segment) *dto.SegmentResponse {
 	var conditions interface{} = seg.Conditions
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+)
+
+// conditionValidator checks a segment condition tree against the team's
+// contact properties and topics before the segment is saved, so that
+// membership evaluation never meets a condition it cannot compile.
+type conditionValidator struct {
+	ctx        context.Context
+	teamID     uuid.UUID
+	svc        *segmentService
+	properties map[string]model.ContactProperty
+	topics     map[uuid.UUID]bool
+}
+
+// validateConditions parses raw conditions and reports the first problem
+// found, wrapped in pkg.ErrValidation.
+func (s *segmentService) validateConditions(ctx context.Context, teamID uuid.UUID, raw model.JSONArray) error {
+	conds, err := model.ParseSegmentConditions(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid conditions: %v", pkg.ErrValidation, err)
+	}
+
+	v := &conditionValidator{
+		ctx:    ctx,
+		teamID: teamID,
+		svc:    s,
+		topics: make(map[uuid.UUID]bool),
+	}
+	for i, c := range conds {
+		if err := v.check(c, fmt.Sprintf("conditions[%d]", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *conditionValidator) invalid(path, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %s", pkg.ErrValidation, path, fmt.Sprintf(format, args...))
+}
+
+func (v *conditionValidator) check(c model.SegmentCondition, path string) error {
+	kinds := 0
+	for _, set := range []bool{c.Field != "", c.Property != "", c.Topic != "", c.Event != "", len(c.All) > 0, len(c.Any) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return v.invalid(path, "must set exactly one of field, property, topic, event, all or any")
+	}
+
+	switch {
+	case len(c.All) > 0:
+		for i, child := range c.All {
+			if err := v.check(child, fmt.Sprintf("%s.all[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case len(c.Any) > 0:
+		for i, child := range c.Any {
+			if err := v.check(child, fmt.Sprintf("%s.any[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case c.Field != "":
+		valueType, ok := model.SegmentContactFields[c.Field]
+		if !ok {
+			return v.invalid(path, "unknown contact field %q", c.Field)
+		}
+		return v.comparison(c, valueType, path)
+	case c.Property != "":
+		prop, err := v.property(c.Property)
+		if err != nil {
+			return err
+		}
+		if prop == nil {
+			return v.invalid(path, "unknown contact property %q", c.Property)
+		}
+		return v.comparison(c, prop.Type, path)
+	case c.Topic != "":
+		return v.topic(c, path)
+	default:
+		return v.event(c, path)
+	}
+}
+
+// comparison checks the operator and value of a field or property condition.
+func (v *conditionValidator) comparison(c model.SegmentCondition, valueType, path string) error {
+	if !model.SegmentOperatorAllowed(valueType, c.Op) {
+		return v.invalid(path, "operator %q is not valid for %s values", c.Op, valueType)
+	}
+	if c.Op == model.SegmentOpIsSet || c.Op == model.SegmentOpIsNotSet {
+		return nil
+	}
+
+	switch valueType {
+	case model.ValueTypeString:
+		if _, ok := c.Value.(string); !ok {
+			return v.invalid(path, "value must be a string")
+		}
+	case model.ValueTypeNumber:
+		f, ok := c.Value.(float64)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return v.invalid(path, "value must be a number")
+		}
+	case model.ValueTypeBoolean:
+		if _, ok := c.Value.(bool); !ok {
+			return v.invalid(path, "value must be a boolean")
+		}
+	case model.ValueTypeDate:
+		s, ok := c.Value.(string)
+		if !ok {
+			return v.invalid(path, "value must be a date string")
+		}
+		if _, err := model.ParseDateValue(s); err != nil {
+			return v.invalid(path, "%v", err)
+		}
+	}
+	return nil
+}
+
+func (v *conditionValidator) topic(c model.SegmentCondition, path string) error {
+	if c.Op != model.SegmentOpSubscribed && c.Op != model.SegmentOpNotSubscribed {
+		return v.invalid(path, "operator %q is not valid for topic conditions", c.Op)
+	}
+	topicID, err := uuid.Parse(c.Topic)
+	if err != nil {
+		return v.invalid(path, "invalid topic id %q", c.Topic)
+	}
+	if v.topics[topicID] {
+		return nil
+	}
+	if _, err := v.svc.topicRepo.GetByTeamAndID(v.ctx, v.teamID, topicID); err != nil {
+		return v.invalid(path, "topic %s not found", c.Topic)
+	}
+	v.topics[topicID] = true
+	return nil
+}
+
+func (v *conditionValidator) event(c model.SegmentCondition, path string) error {
+	if !model.SegmentEventTypes[c.Event] {
+		return v.invalid(path, "unknown event type %q", c.Event)
+	}
+	if c.Op != model.SegmentOpPerformed && c.Op != model.SegmentOpNotPerformed {
+		return v.invalid(path, "operator %q is not valid for event conditions", c.Op)
+	}
+	if c.WithinDays < 0 {
+		return v.invalid(path, "within_days must not be negative")
+	}
+	return nil
+}
+
+// property looks up a team property by name, loading the definitions once.
+func (v *conditionValidator) property(name string) (*model.ContactProperty, error) {
+	if v.properties == nil {
+		properties, err := v.svc.propertyRepo.ListByTeamID(v.ctx, v.teamID)
+		if err != nil {
+			return nil, fmt.Errorf("listing contact properties: %w", err)
+		}
+		v.properties = make(map[string]model.ContactProperty, len(properties))
+		for _, p := range properties {
+			v.properties[p.Name] = p
+		}
+	}
+	if p, ok := v.properties[name]; ok {
+		return &p, nil
+	}
+	return nil, nil
+}
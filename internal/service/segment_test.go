@@ -11,6 +11,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
@@ -30,13 +31,16 @@ func newTestSegment(audienceID uuid.UUID) *model.Segment {
 func TestSegmentService_Create_HappyPath(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
 	aud := testutil.NewTestAudience()
 	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
 	segmentRepo.On("Create", ctx, mock.AnythingOfType("*model.Segment")).Return(nil)
+	segmentRepo.On("RefreshMembers", ctx, mock.AnythingOfType("uuid.UUID")).Return(3, nil)
 
 	conditions := []interface{}{
 		map[string]interface{}{"field": "email", "op": "contains", "value": "@test.com"},
@@ -59,7 +63,9 @@ func TestSegmentService_Create_HappyPath(t *testing.T) {
 func TestSegmentService_Create_AudienceOwnershipFails(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -84,7 +90,9 @@ func TestSegmentService_Create_AudienceOwnershipFails(t *testing.T) {
 func TestSegmentService_List(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -106,7 +114,9 @@ func TestSegmentService_List(t *testing.T) {
 func TestSegmentService_Update_HappyPath(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -132,7 +142,9 @@ func TestSegmentService_Update_HappyPath(t *testing.T) {
 func TestSegmentService_Delete_HappyPath(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -153,7 +165,9 @@ func TestSegmentService_Delete_HappyPath(t *testing.T) {
 func TestSegmentService_Delete_WrongAudience(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -175,7 +189,9 @@ func TestSegmentService_Delete_WrongAudience(t *testing.T) {
 func TestSegmentService_Get_NotFound(t *testing.T) {
 	segmentRepo := new(tmock.MockSegmentRepository)
 	audienceRepo := new(tmock.MockAudienceRepository)
-	svc := NewSegmentService(segmentRepo, audienceRepo)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -191,3 +207,97 @@ func TestSegmentService_Get_NotFound(t *testing.T) {
 
 	segmentRepo.AssertExpectations(t)
 }
+
+func TestSegmentService_Create_InvalidConditions(t *testing.T) {
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+	topicID := uuid.New()
+
+	tests := []struct {
+		name       string
+		conditions []interface{}
+		wantErr    string
+	}{
+		{
+			name:       "unknown field",
+			conditions: []interface{}{map[string]interface{}{"field": "age", "op": "gt", "value": 30}},
+			wantErr:    `unknown contact field "age"`,
+		},
+		{
+			name:       "operator not valid for type",
+			conditions: []interface{}{map[string]interface{}{"field": "email", "op": "gt", "value": "a"}},
+			wantErr:    `operator "gt" is not valid for string values`,
+		},
+		{
+			name:       "wrong value type",
+			conditions: []interface{}{map[string]interface{}{"property": "seats", "op": "gte", "value": "ten"}},
+			wantErr:    "value must be a number",
+		},
+		{
+			name:       "unknown property",
+			conditions: []interface{}{map[string]interface{}{"property": "plan", "op": "eq", "value": "pro"}},
+			wantErr:    `unknown contact property "plan"`,
+		},
+		{
+			name:       "topic from another team",
+			conditions: []interface{}{map[string]interface{}{"topic": topicID.String(), "op": "subscribed"}},
+			wantErr:    "not found",
+		},
+		{
+			name: "nested group with two kinds",
+			conditions: []interface{}{map[string]interface{}{"any": []interface{}{
+				map[string]interface{}{"field": "email", "event": "opened", "op": "eq", "value": "x"},
+			}}},
+			wantErr: "conditions[0].any[0]: must set exactly one of",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segmentRepo := new(tmock.MockSegmentRepository)
+			audienceRepo := new(tmock.MockAudienceRepository)
+			propertyRepo := new(tmock.MockContactPropertyRepository)
+			topicRepo := new(tmock.MockTopicRepository)
+			svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
+
+			aud := testutil.NewTestAudience()
+			audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+			propertyRepo.On("ListByTeamID", ctx, teamID).Return([]model.ContactProperty{
+				{ID: uuid.New(), TeamID: teamID, Name: "seats", Type: model.ValueTypeNumber},
+			}, nil)
+			topicRepo.On("GetByTeamAndID", ctx, teamID, topicID).Return(nil, postgres.ErrNotFound)
+
+			req := &dto.CreateSegmentRequest{Name: "Bad", Conditions: tt.conditions}
+			resp, err := svc.Create(ctx, teamID, aud.ID, req)
+
+			assert.Nil(t, resp)
+			require.ErrorIs(t, err, pkg.ErrValidation)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			segmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSegmentService_Refresh(t *testing.T) {
+	segmentRepo := new(tmock.MockSegmentRepository)
+	audienceRepo := new(tmock.MockAudienceRepository)
+	propertyRepo := new(tmock.MockContactPropertyRepository)
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewSegmentService(segmentRepo, audienceRepo, propertyRepo, topicRepo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	aud := testutil.NewTestAudience()
+	seg := newTestSegment(aud.ID)
+	audienceRepo.On("GetByID", ctx, aud.ID).Return(aud, nil)
+	segmentRepo.On("GetByID", ctx, seg.ID).Return(seg, nil)
+	segmentRepo.On("RefreshMembers", ctx, seg.ID).Return(42, nil)
+
+	resp, err := svc.Refresh(ctx, teamID, aud.ID, seg.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, seg.ID.String(), resp.ID)
+	assert.Equal(t, 42, resp.Contacts)
+
+	segmentRepo.AssertExpectations(t)
+}
@@ -16,3 +16,5 @@ package service
 //   WebhookService         -> webhook.go
 //   InboundEmailService    -> inbound_email.go
 //   LogService             -> log.go
+//   SuppressionService     -> suppression.go
+//   PreferenceService      -> preference.go
@@ -5,6 +5,7 @@ type Services struct {
 	Auth            AuthService
 	Email           EmailService
 	Domain          DomainService
+	DMARC           DMARCService
 	APIKey          APIKeyService
 	Audience        AudienceService
 	Contact         ContactService
@@ -19,4 +20,6 @@ type Services struct {
 	Metrics         MetricsService
 	Settings        SettingsService
 	Tracking        TrackingService
+	Suppression     SuppressionService
+	Preference      PreferenceService
 }
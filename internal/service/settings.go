@@ -13,6 +13,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/server/middleware"
 )
@@ -25,6 +26,12 @@ type SettingsService interface {
 	GetSMTPConfig() *dto.SMTPConfigResponse
 	InviteMember(ctx context.Context, teamID uuid.UUID, req *dto.InviteMemberRequest) (*model.TeamInvitation, error)
 	AcceptInvite(ctx context.Context, req *dto.AcceptInviteRequest) (*dto.AuthResponse, error)
+	ListMembers(ctx context.Context, teamID uuid.UUID) ([]dto.TeamMemberResponse, error)
+	UpdateMemberRole(ctx context.Context, teamID, memberID uuid.UUID, req *dto.UpdateMemberRoleRequest) (*model.TeamMember, error)
+	RemoveMember(ctx context.Context, teamID, memberID uuid.UUID) error
+	TransferOwnership(ctx context.Context, teamID, actorUserID uuid.UUID, req *dto.TransferOwnershipRequest) error
+	ListInvitations(ctx context.Context, teamID uuid.UUID) ([]model.TeamInvitation, error)
+	RevokeInvitation(ctx context.Context, teamID, invitationID uuid.UUID) error
 }
 
 // SMTPDisplayConfig holds the SMTP settings to display to users.
@@ -85,8 +92,15 @@ func (s *settingsService) GetTeam(ctx context.Context, teamID uuid.UUID) (*dto.T
 }
 
 func (s *settingsService) UpdateTeam(ctx context.Context, teamID uuid.UUID, req *dto.UpdateTeamRequest) error {
-	if err := s.settingsRepo.UpdateTeamName(ctx, teamID, req.Name); err != nil {
-		return fmt.Errorf("updating team name: %w", err)
+	if req.Name != "" {
+		if err := s.settingsRepo.UpdateTeamName(ctx, teamID, req.Name); err != nil {
+			return fmt.Errorf("updating team name: %w", err)
+		}
+	}
+	if req.SandboxMode != nil {
+		if err := s.settingsRepo.UpdateSandboxMode(ctx, teamID, *req.SandboxMode); err != nil {
+			return fmt.Errorf("updating sandbox mode: %w", err)
+		}
 	}
 	return nil
 }
@@ -146,25 +160,23 @@ func (s *settingsService) AcceptInvite(ctx context.Context, req *dto.AcceptInvit
 		return nil, fmt.Errorf("invitation has expired")
 	}
 
-	// Hash the password.
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
-	if err != nil {
-		return nil, fmt.Errorf("hashing password: %w", err)
-	}
-
-	// Create the user.
-	user := &model.User{
-		ID:            uuid.New(),
-		Email:         invitation.Email,
-		PasswordHash:  string(hash),
-		Name:          req.Name,
-		EmailVerified: true,
-		CreatedAt:     time.Now().UTC(),
-		UpdatedAt:     time.Now().UTC(),
-	}
-
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, fmt.Errorf("creating user: %w", err)
+	// Users who already have an account join with their existing password;
+	// everyone else gets a new account.
+	user, err := s.userRepo.GetByEmail(ctx, invitation.Email)
+	switch {
+	case err == nil:
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+			return nil, fmt.Errorf("invalid password for existing account")
+		}
+		if _, err := s.teamMemberRepo.GetByTeamAndUser(ctx, invitation.TeamID, user.ID); err == nil {
+			return nil, fmt.Errorf("already a member of this team")
+		}
+	case errors.Is(err, postgres.ErrNotFound):
+		if user, err = s.createInvitedUser(ctx, invitation.Email, req); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("looking up user: %w", err)
 	}
 
 	// Add as team member.
@@ -200,3 +212,120 @@ func (s *settingsService) AcceptInvite(ctx context.Context, req *dto.AcceptInvit
 
 	return resp, nil
 }
+
+func (s *settingsService) createInvitedUser(ctx context.Context, email string, req *dto.AcceptInviteRequest) (*model.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("hashing password: %w", err)
+	}
+
+	user := &model.User{
+		ID:            uuid.New(),
+		Email:         email,
+		PasswordHash:  string(hash),
+		Name:          req.Name,
+		EmailVerified: true,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("creating user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *settingsService) ListMembers(ctx context.Context, teamID uuid.UUID) ([]dto.TeamMemberResponse, error) {
+	team, err := s.settingsRepo.GetTeamWithMembers(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing team members: %w", err)
+	}
+	return team.Members, nil
+}
+
+// UpdateMemberRole changes a member's role. The owner's role can only change
+// by transferring ownership, so a team always has exactly one owner.
+func (s *settingsService) UpdateMemberRole(ctx context.Context, teamID, memberID uuid.UUID, req *dto.UpdateMemberRoleRequest) (*model.TeamMember, error) {
+	member, err := s.teamMemberRepo.GetByTeamAndID(ctx, teamID, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("getting team member: %w", err)
+	}
+	if member.Role == model.RoleOwner {
+		return nil, fmt.Errorf("%w: the owner's role can only change by transferring ownership", pkg.ErrForbidden)
+	}
+
+	if err := s.teamMemberRepo.UpdateRole(ctx, teamID, memberID, req.Role); err != nil {
+		return nil, fmt.Errorf("updating team member role: %w", err)
+	}
+	member.Role = req.Role
+	return member, nil
+}
+
+func (s *settingsService) RemoveMember(ctx context.Context, teamID, memberID uuid.UUID) error {
+	member, err := s.teamMemberRepo.GetByTeamAndID(ctx, teamID, memberID)
+	if err != nil {
+		return fmt.Errorf("getting team member: %w", err)
+	}
+	if member.Role == model.RoleOwner {
+		return fmt.Errorf("%w: the team owner cannot be removed", pkg.ErrForbidden)
+	}
+
+	if err := s.teamMemberRepo.Delete(ctx, teamID, memberID); err != nil {
+		return fmt.Errorf("removing team member: %w", err)
+	}
+	return nil
+}
+
+// TransferOwnership makes another member the team's owner. Only the current
+// owner may do this; they become an admin.
+func (s *settingsService) TransferOwnership(ctx context.Context, teamID, actorUserID uuid.UUID, req *dto.TransferOwnershipRequest) error {
+	memberID, err := uuid.Parse(req.MemberID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid member_id", pkg.ErrValidation)
+	}
+
+	owner, err := s.teamMemberRepo.GetByTeamAndUser(ctx, teamID, actorUserID)
+	if err != nil {
+		return fmt.Errorf("getting team member: %w", err)
+	}
+	if owner.Role != model.RoleOwner {
+		return fmt.Errorf("%w: only the team owner can transfer ownership", pkg.ErrForbidden)
+	}
+	if owner.ID == memberID {
+		return fmt.Errorf("%w: you already own this team", pkg.ErrValidation)
+	}
+
+	if _, err := s.teamMemberRepo.GetByTeamAndID(ctx, teamID, memberID); err != nil {
+		return fmt.Errorf("getting team member: %w", err)
+	}
+	if err := s.teamMemberRepo.TransferOwnership(ctx, teamID, owner.ID, memberID); err != nil {
+		return fmt.Errorf("transferring ownership: %w", err)
+	}
+	return nil
+}
+
+func (s *settingsService) ListInvitations(ctx context.Context, teamID uuid.UUID) ([]model.TeamInvitation, error) {
+	invitations, err := s.invitationRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("listing invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation deletes a pending invitation so its token can no longer be
+// accepted.
+func (s *settingsService) RevokeInvitation(ctx context.Context, teamID, invitationID uuid.UUID) error {
+	invitations, err := s.invitationRepo.ListByTeamID(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("listing invitations: %w", err)
+	}
+	for _, inv := range invitations {
+		if inv.ID == invitationID {
+			if err := s.invitationRepo.Delete(ctx, inv.ID); err != nil {
+				return fmt.Errorf("deleting invitation: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("invitation not found: %w", postgres.ErrNotFound)
+}
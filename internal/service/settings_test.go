@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func newTestSettingsService(memberRepo *tmock.MockTeamMemberRepository, invitationRepo *tmock.MockTeamInvitationRepository) SettingsService {
+	return NewSettingsService(new(tmock.MockSettingsRepository), invitationRepo, new(tmock.MockUserRepository), memberRepo,
+		SMTPDisplayConfig{}, "secret", time.Hour, 4)
+}
+
+func TestSettingsService_UpdateMemberRole(t *testing.T) {
+	memberRepo := new(tmock.MockTeamMemberRepository)
+	svc := newTestSettingsService(memberRepo, new(tmock.MockTeamInvitationRepository))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	member := &model.TeamMember{ID: uuid.New(), TeamID: teamID, Role: model.RoleMember}
+	owner := &model.TeamMember{ID: uuid.New(), TeamID: teamID, Role: model.RoleOwner}
+	memberRepo.On("GetByTeamAndID", ctx, teamID, member.ID).Return(member, nil)
+	memberRepo.On("GetByTeamAndID", ctx, teamID, owner.ID).Return(owner, nil)
+	memberRepo.On("UpdateRole", ctx, teamID, member.ID, model.RoleAdmin).Return(nil)
+
+	updated, err := svc.UpdateMemberRole(ctx, teamID, member.ID, &dto.UpdateMemberRoleRequest{Role: model.RoleAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, updated.Role)
+
+	_, err = svc.UpdateMemberRole(ctx, teamID, owner.ID, &dto.UpdateMemberRoleRequest{Role: model.RoleMember})
+	assert.True(t, errors.Is(err, pkg.ErrForbidden))
+
+	memberRepo.AssertNumberOfCalls(t, "UpdateRole", 1)
+}
+
+func TestSettingsService_RemoveMember_Owner(t *testing.T) {
+	memberRepo := new(tmock.MockTeamMemberRepository)
+	svc := newTestSettingsService(memberRepo, new(tmock.MockTeamInvitationRepository))
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	owner := &model.TeamMember{ID: uuid.New(), TeamID: teamID, Role: model.RoleOwner}
+	memberRepo.On("GetByTeamAndID", ctx, teamID, owner.ID).Return(owner, nil)
+
+	err := svc.RemoveMember(ctx, teamID, owner.ID)
+	assert.True(t, errors.Is(err, pkg.ErrForbidden))
+	memberRepo.AssertNotCalled(t, "Delete")
+}
+
+func TestSettingsService_TransferOwnership(t *testing.T) {
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+	ownerUserID, adminUserID := uuid.New(), uuid.New()
+	owner := &model.TeamMember{ID: uuid.New(), TeamID: teamID, UserID: ownerUserID, Role: model.RoleOwner}
+	admin := &model.TeamMember{ID: uuid.New(), TeamID: teamID, UserID: adminUserID, Role: model.RoleAdmin}
+
+	t.Run("owner transfers", func(t *testing.T) {
+		memberRepo := new(tmock.MockTeamMemberRepository)
+		svc := newTestSettingsService(memberRepo, new(tmock.MockTeamInvitationRepository))
+		memberRepo.On("GetByTeamAndUser", ctx, teamID, ownerUserID).Return(owner, nil)
+		memberRepo.On("GetByTeamAndID", ctx, teamID, admin.ID).Return(admin, nil)
+		memberRepo.On("TransferOwnership", ctx, teamID, owner.ID, admin.ID).Return(nil)
+
+		err := svc.TransferOwnership(ctx, teamID, ownerUserID, &dto.TransferOwnershipRequest{MemberID: admin.ID.String()})
+		require.NoError(t, err)
+		memberRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin cannot transfer", func(t *testing.T) {
+		memberRepo := new(tmock.MockTeamMemberRepository)
+		svc := newTestSettingsService(memberRepo, new(tmock.MockTeamInvitationRepository))
+		memberRepo.On("GetByTeamAndUser", ctx, teamID, adminUserID).Return(admin, nil)
+
+		err := svc.TransferOwnership(ctx, teamID, adminUserID, &dto.TransferOwnershipRequest{MemberID: admin.ID.String()})
+		assert.True(t, errors.Is(err, pkg.ErrForbidden))
+		memberRepo.AssertNotCalled(t, "TransferOwnership")
+	})
+}
+
+func TestSettingsService_RevokeInvitation(t *testing.T) {
+	invitationRepo := new(tmock.MockTeamInvitationRepository)
+	svc := newTestSettingsService(new(tmock.MockTeamMemberRepository), invitationRepo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	pending := model.TeamInvitation{ID: uuid.New(), TeamID: teamID, Email: "new@example.com", Role: model.RoleMember}
+	invitationRepo.On("ListByTeamID", ctx, teamID).Return([]model.TeamInvitation{pending}, nil)
+	invitationRepo.On("Delete", ctx, pending.ID).Return(nil)
+
+	require.NoError(t, svc.RevokeInvitation(ctx, teamID, pending.ID))
+
+	// Invitations of other teams are not found.
+	err := svc.RevokeInvitation(ctx, teamID, uuid.New())
+	assert.True(t, errors.Is(err, postgres.ErrNotFound))
+	invitationRepo.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestSettingsService_AcceptInvite_ExistingUser(t *testing.T) {
+	userRepo := new(tmock.MockUserRepository)
+	memberRepo := new(tmock.MockTeamMemberRepository)
+	invitationRepo := new(tmock.MockTeamInvitationRepository)
+	svc := NewSettingsService(new(tmock.MockSettingsRepository), invitationRepo, userRepo, memberRepo,
+		SMTPDisplayConfig{}, "secret", time.Hour, bcrypt.MinCost)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("existing-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &model.User{ID: uuid.New(), Email: "consultant@example.com", Name: "Consultant", PasswordHash: string(hash)}
+	invitation := &model.TeamInvitation{
+		ID: uuid.New(), TeamID: uuid.New(), Email: user.Email, Role: model.RoleAdmin,
+		Token: "tok", ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	invitationRepo.On("GetByToken", ctx, "tok").Return(invitation, nil)
+	userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	memberRepo.On("GetByTeamAndUser", ctx, invitation.TeamID, user.ID).Return(nil, postgres.ErrNotFound)
+	memberRepo.On("Create", ctx, mock.MatchedBy(func(m *model.TeamMember) bool {
+		return m.UserID == user.ID && m.TeamID == invitation.TeamID && m.Role == model.RoleAdmin
+	})).Return(nil)
+	invitationRepo.On("MarkAccepted", ctx, invitation.ID).Return(nil)
+
+	_, err = svc.AcceptInvite(ctx, &dto.AcceptInviteRequest{Token: "tok", Name: "Ignored", Password: "wrong-password"})
+	require.Error(t, err)
+
+	resp, err := svc.AcceptInvite(ctx, &dto.AcceptInviteRequest{Token: "tok", Name: "Ignored", Password: "existing-password"})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), resp.User.ID)
+	userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	memberRepo.AssertExpectations(t)
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// SuppressionService defines operations for managing a team's suppression list.
+type SuppressionService interface {
+	List(ctx context.Context, teamID uuid.UUID, search, reason string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.SuppressionResponse], error)
+	Create(ctx context.Context, teamID uuid.UUID, req *dto.CreateSuppressionRequest) (*dto.SuppressionResponse, error)
+	Import(ctx context.Context, teamID uuid.UUID, r io.Reader) (*dto.SuppressionImportResponse, error)
+	Delete(ctx context.Context, teamID uuid.UUID, suppressionID uuid.UUID) error
+}
+
+type suppressionService struct {
+	suppressionRepo postgres.SuppressionRepository
+}
+
+// NewSuppressionService creates a new SuppressionService.
+func NewSuppressionService(suppressionRepo postgres.SuppressionRepository) SuppressionService {
+	return &suppressionService{
+		suppressionRepo: suppressionRepo,
+	}
+}
+
+// suppressionReasons lists the reasons accepted by the suppression_list table.
+var suppressionReasons = map[string]bool{
+	model.SuppressionBounce:      true,
+	model.SuppressionComplaint:   true,
+	model.SuppressionUnsubscribe: true,
+	model.SuppressionManual:      true,
+}
+
+const (
+	// suppressionImportBatchSize bounds the number of rows inserted per batch.
+	suppressionImportBatchSize = 500
+	// suppressionImportMaxErrors bounds the row errors reported back to the caller.
+	suppressionImportMaxErrors = 20
+)
+
+func (s *suppressionService) List(ctx context.Context, teamID uuid.UUID, search, reason string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.SuppressionResponse], error) {
+	if reason != "" && !suppressionReasons[reason] {
+		return nil, fmt.Errorf("%w: invalid reason %q", pkg.ErrValidation, reason)
+	}
+
+	params.Normalize()
+
+	entries, total, err := s.suppressionRepo.ListByTeamID(ctx, teamID, strings.TrimSpace(search), reason, params.PerPage, params.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("listing suppressions: %w", err)
+	}
+
+	data := make([]dto.SuppressionResponse, 0, len(entries))
+	for _, e := range entries {
+		data = append(data, *suppressionToResponse(&e))
+	}
+
+	totalPages := 0
+	if params.PerPage > 0 {
+		totalPages = (total + params.PerPage - 1) / params.PerPage
+	}
+
+	return &dto.PaginatedResponse[dto.SuppressionResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       params.Page,
+		PerPage:    params.PerPage,
+		TotalPages: totalPages,
+		HasMore:    params.Page < totalPages,
+	}, nil
+}
+
+func (s *suppressionService) Create(ctx context.Context, teamID uuid.UUID, req *dto.CreateSuppressionRequest) (*dto.SuppressionResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation: %w", err)
+	}
+
+	email := normalizeSuppressionEmail(req.Email)
+
+	existing, err := s.suppressionRepo.GetByTeamAndEmail(ctx, teamID, email)
+	if err != nil && !errors.Is(err, postgres.ErrNotFound) {
+		return nil, fmt.Errorf("checking suppression list: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: %s is already suppressed", pkg.ErrValidation, email)
+	}
+
+	entry := &model.SuppressionEntry{
+		ID:        uuid.New(),
+		TeamID:    teamID,
+		Email:     email,
+		Reason:    model.SuppressionManual,
+		Details:   req.Details,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.suppressionRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("creating suppression: %w", err)
+	}
+
+	return suppressionToResponse(entry), nil
+}
+
+// Import adds the addresses of a CSV file to the suppression list. The file
+// must have an "email" column and may have "reason" (defaults to manual) and
+// "details" columns. Invalid rows are reported and skipped.
+func (s *suppressionService) Import(ctx context.Context, teamID uuid.UUID, r io.Reader) (*dto.SuppressionImportResponse, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading CSV header: %v", pkg.ErrValidation, err)
+	}
+
+	colMap := make(map[string]int, len(header))
+	for i, col := range header {
+		colMap[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	emailCol, ok := colMap["email"]
+	if !ok {
+		return nil, fmt.Errorf("%w: CSV must have an 'email' column", pkg.ErrValidation)
+	}
+	reasonCol, hasReason := colMap["reason"]
+	detailsCol, hasDetails := colMap["details"]
+
+	result := &dto.SuppressionImportResponse{}
+	invalid := func(row int, format string, args ...interface{}) {
+		result.Invalid++
+		if len(result.Errors) < suppressionImportMaxErrors {
+			result.Errors = append(result.Errors, fmt.Sprintf("row %d: %s", row, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	now := time.Now().UTC()
+	seen := make(map[string]bool)
+	var pending []*model.SuppressionEntry
+
+	flush := func() error {
+		inserted, err := s.suppressionRepo.CreateBatch(ctx, pending)
+		if err != nil {
+			return fmt.Errorf("importing suppressions: %w", err)
+		}
+		result.Imported += inserted
+		result.Skipped += len(pending) - inserted
+		pending = pending[:0]
+		return nil
+	}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		result.Total++
+		if err != nil {
+			invalid(row, "%v", err)
+			continue
+		}
+
+		field := func(col int, ok bool) string {
+			if !ok || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+
+		email := normalizeSuppressionEmail(field(emailCol, true))
+		if err := pkg.Validate(&dto.CreateSuppressionRequest{Email: email}); err != nil {
+			invalid(row, "invalid email %q", email)
+			continue
+		}
+
+		reason := strings.ToLower(field(reasonCol, hasReason))
+		if reason == "" {
+			reason = model.SuppressionManual
+		}
+		if !suppressionReasons[reason] {
+			invalid(row, "invalid reason %q", reason)
+			continue
+		}
+
+		if seen[email] {
+			result.Skipped++
+			continue
+		}
+		seen[email] = true
+
+		entry := &model.SuppressionEntry{
+			ID:        uuid.New(),
+			TeamID:    teamID,
+			Email:     email,
+			Reason:    reason,
+			CreatedAt: now,
+		}
+		if details := field(detailsCol, hasDetails); details != "" {
+			entry.Details = &details
+		}
+		pending = append(pending, entry)
+
+		if len(pending) >= suppressionImportBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *suppressionService) Delete(ctx context.Context, teamID uuid.UUID, suppressionID uuid.UUID) error {
+	if _, err := s.suppressionRepo.GetByTeamAndID(ctx, teamID, suppressionID); err != nil {
+		return fmt.Errorf("suppression not found: %w", err)
+	}
+
+	if err := s.suppressionRepo.Delete(ctx, suppressionID); err != nil {
+		return fmt.Errorf("deleting suppression: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeSuppressionEmail trims and lowercases an address so entries added
+// by hand or by import match regardless of how the address was typed.
+func normalizeSuppressionEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// suppressionToResponse converts a model.SuppressionEntry to a dto.SuppressionResponse.
+func suppressionToResponse(e *model.SuppressionEntry) *dto.SuppressionResponse {
+	return &dto.SuppressionResponse{
+		ID:        e.ID.String(),
+		Email:     e.Email,
+		Reason:    e.Reason,
+		Details:   e.Details,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+}
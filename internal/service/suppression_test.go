@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/testutil"
+	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
+)
+
+func TestSuppressionService_List(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	entry := *testutil.NewTestSuppressionEntry()
+	repo.On("ListByTeamID", ctx, teamID, "example.com", model.SuppressionBounce, 20, 20).
+		Return([]model.SuppressionEntry{entry}, 21, nil)
+
+	params := &dto.PaginationParams{Page: 2, PerPage: 20}
+	resp, err := svc.List(ctx, teamID, " example.com ", model.SuppressionBounce, params)
+
+	require.NoError(t, err)
+	assert.Equal(t, 21, resp.Total)
+	assert.Equal(t, 2, resp.TotalPages)
+	assert.False(t, resp.HasMore)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, entry.Email, resp.Data[0].Email)
+
+	repo.AssertExpectations(t)
+}
+
+func TestSuppressionService_List_InvalidReason(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+
+	resp, err := svc.List(context.Background(), testutil.TestTeamID, "", "spam", &dto.PaginationParams{})
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	repo.AssertNotCalled(t, "ListByTeamID", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSuppressionService_Create_HappyPath(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	repo.On("GetByTeamAndEmail", ctx, teamID, "blocked@example.com").Return(nil, postgres.ErrNotFound)
+	repo.On("Create", ctx, mock.MatchedBy(func(e *model.SuppressionEntry) bool {
+		return e.Reason == model.SuppressionManual && e.Email == "blocked@example.com"
+	})).Return(nil)
+
+	req := &dto.CreateSuppressionRequest{
+		Email:   "Blocked@Example.com",
+		Details: testutil.StringPtr("requested by customer"),
+	}
+	resp, err := svc.Create(ctx, teamID, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "blocked@example.com", resp.Email)
+	assert.Equal(t, model.SuppressionManual, resp.Reason)
+	assert.Equal(t, req.Details, resp.Details)
+
+	repo.AssertExpectations(t)
+}
+
+func TestSuppressionService_Create_AlreadySuppressed(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	repo.On("GetByTeamAndEmail", ctx, teamID, "suppressed@example.com").Return(testutil.NewTestSuppressionEntry(), nil)
+
+	resp, err := svc.Create(ctx, teamID, &dto.CreateSuppressionRequest{Email: "suppressed@example.com"})
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "already suppressed")
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSuppressionService_Import(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	csvData := strings.Join([]string{
+		"Email,Reason,Details",
+		"one@example.com,,",
+		"TWO@example.com,complaint,feedback loop",
+		"one@example.com,manual,",
+		"not-an-email,manual,",
+		"three@example.com,spam,",
+		"four@example.com",
+	}, "\n")
+
+	var imported []*model.SuppressionEntry
+	repo.On("CreateBatch", ctx, mock.AnythingOfType("[]*model.SuppressionEntry")).
+		Run(func(args mock.Arguments) {
+			imported = append(imported, args.Get(1).([]*model.SuppressionEntry)...)
+		}).
+		Return(2, nil)
+
+	resp, err := svc.Import(ctx, teamID, strings.NewReader(csvData))
+
+	require.NoError(t, err)
+	assert.Equal(t, 6, resp.Total)
+	assert.Equal(t, 2, resp.Imported)
+	// One in-file duplicate plus one address the repository already had.
+	assert.Equal(t, 2, resp.Skipped)
+	assert.Equal(t, 2, resp.Invalid)
+	assert.Len(t, resp.Errors, 2)
+
+	require.Len(t, imported, 3)
+	assert.Equal(t, "one@example.com", imported[0].Email)
+	assert.Equal(t, model.SuppressionManual, imported[0].Reason)
+	assert.Equal(t, "two@example.com", imported[1].Email)
+	assert.Equal(t, model.SuppressionComplaint, imported[1].Reason)
+	assert.Equal(t, testutil.StringPtr("feedback loop"), imported[1].Details)
+	assert.Equal(t, "four@example.com", imported[2].Email)
+}
+
+func TestSuppressionService_Import_MissingEmailColumn(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+
+	resp, err := svc.Import(context.Background(), testutil.TestTeamID, strings.NewReader("address\nfoo@example.com\n"))
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+}
+
+func TestSuppressionService_Delete(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	entry := testutil.NewTestSuppressionEntry()
+	repo.On("GetByTeamAndID", ctx, teamID, entry.ID).Return(entry, nil)
+	repo.On("Delete", ctx, entry.ID).Return(nil)
+
+	err := svc.Delete(ctx, teamID, entry.ID)
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestSuppressionService_Delete_OtherTeam(t *testing.T) {
+	repo := new(tmock.MockSuppressionRepository)
+	svc := NewSuppressionService(repo)
+	ctx := context.Background()
+	otherTeamID := uuid.New()
+	entryID := uuid.New()
+
+	repo.On("GetByTeamAndID", ctx, otherTeamID, entryID).Return(nil, postgres.ErrNotFound)
+
+	err := svc.Delete(ctx, otherTeamID, entryID)
+
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
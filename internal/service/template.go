@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +13,7 @@ import (
 	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/templating"
 )
 
 // TemplateService defines operations for managing email templates and their versions.
@@ -21,18 +24,30 @@ type TemplateService interface {
 	Update(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.UpdateTemplateRequest) (*dto.TemplateResponse, error)
 	Delete(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID) error
 	Publish(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID) (*dto.TemplateResponse, error)
+	ListVersions(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID) (*dto.ListResponse[dto.TemplateVersionResponse], error)
+	PublishVersion(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, version int) (*dto.TemplateResponse, error)
+	Diff(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, from, to int) (*dto.TemplateDiffResponse, error)
+	Preview(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.PreviewTemplateRequest) (*dto.PreviewTemplateResponse, error)
+	SendTest(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.TestTemplateRequest) (*dto.SendEmailResponse, error)
 }
 
+// previewUnsubscribeURL stands in for the per-contact unsubscribe link when
+// rendering previews and test sends.
+const previewUnsubscribeURL = "#unsubscribe"
+
 type templateService struct {
 	templateRepo        postgres.TemplateRepository
 	templateVersionRepo postgres.TemplateVersionRepository
+	emailService        EmailService
 }
 
-// NewTemplateService creates a new TemplateService.
-func NewTemplateService(templateRepo postgres.TemplateRepository, templateVersionRepo postgres.TemplateVersionRepository) TemplateService {
+// NewTemplateService creates a new TemplateService. Test sends are submitted
+// through emailService like any other email.
+func NewTemplateService(templateRepo postgres.TemplateRepository, templateVersionRepo postgres.TemplateVersionRepository, emailService EmailService) TemplateService {
 	return &templateService{
 		templateRepo:        templateRepo,
 		templateVersionRepo: templateVersionRepo,
+		emailService:        emailService,
 	}
 }
 
@@ -41,6 +56,11 @@ func (s *templateService) Create(ctx context.Context, teamID uuid.UUID, req *dto
 		return nil, fmt.Errorf("validation: %w", err)
 	}
 
+	variables := variablesFromDTO(req.Variables)
+	if err := checkTemplateContent(req.Subject, req.HTML, req.Text, variables); err != nil {
+		return nil, err
+	}
+
 	now := time.Now().UTC()
 
 	// Create the template record.
@@ -65,7 +85,7 @@ func (s *templateService) Create(ctx context.Context, teamID uuid.UUID, req *dto
 		Subject:    req.Subject,
 		HTMLBody:   req.HTML,
 		TextBody:   req.Text,
-		Variables:  model.JSONArray{},
+		Variables:  variablesToJSON(variables),
 		Published:  false,
 		CreatedAt:  now,
 	}
@@ -130,6 +150,7 @@ func (s *templateService) Get(ctx context.Context, teamID uuid.UUID, templateID
 		resp.Subject = latest.Subject
 		resp.HTML = latest.HTMLBody
 		resp.Text = latest.TextBody
+		resp.Variables = variablesToDTO(templateVariables(latest.Variables))
 		resp.Published = latest.Published
 	}
 
@@ -147,28 +168,16 @@ func (s *templateService) Update(ctx context.Context, teamID uuid.UUID, template
 		return nil, fmt.Errorf("template not found: %w", postgres.ErrNotFound)
 	}
 
-	// Update template metadata.
-	if req.Name != nil {
-		template.Name = *req.Name
-	}
-	if req.Description != nil {
-		template.Description = req.Description
-	}
-
-	template.UpdatedAt = time.Now().UTC()
-
-	if err := s.templateRepo.Update(ctx, template); err != nil {
-		return nil, fmt.Errorf("updating template: %w", err)
-	}
-
-	// If content fields are provided, create a new version.
-	if req.Subject != nil || req.HTML != nil || req.Text != nil {
+	// If content fields are provided, prepare a new version. It is checked
+	// before anything is saved so an invalid template leaves no changes.
+	var newVersion *model.TemplateVersion
+	if req.Subject != nil || req.HTML != nil || req.Text != nil || req.Variables != nil {
 		latest, err := s.templateVersionRepo.GetLatestByTemplateID(ctx, template.ID)
 		if err != nil {
 			return nil, fmt.Errorf("getting latest template version: %w", err)
 		}
 
-		newVersion := &model.TemplateVersion{
+		newVersion = &model.TemplateVersion{
 			ID:         uuid.New(),
 			TemplateID: template.ID,
 			Version:    latest.Version + 1,
@@ -190,7 +199,32 @@ func (s *templateService) Update(ctx context.Context, teamID uuid.UUID, template
 		if req.Text != nil {
 			newVersion.TextBody = req.Text
 		}
+		variables := templateVariables(newVersion.Variables)
+		if req.Variables != nil {
+			variables = variablesFromDTO(req.Variables)
+			newVersion.Variables = variablesToJSON(variables)
+		}
+
+		if err := checkTemplateContent(newVersion.Subject, newVersion.HTMLBody, newVersion.TextBody, variables); err != nil {
+			return nil, err
+		}
+	}
+
+	// Update template metadata.
+	if req.Name != nil {
+		template.Name = *req.Name
+	}
+	if req.Description != nil {
+		template.Description = req.Description
+	}
+
+	template.UpdatedAt = time.Now().UTC()
+
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		return nil, fmt.Errorf("updating template: %w", err)
+	}
 
+	if newVersion != nil {
 		if err := s.templateVersionRepo.Create(ctx, newVersion); err != nil {
 			return nil, fmt.Errorf("creating new template version: %w", err)
 		}
@@ -250,6 +284,298 @@ func (s *templateService) Publish(ctx context.Context, teamID uuid.UUID, templat
 	return templateToResponse(template), nil
 }
 
+func (s *templateService) ListVersions(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID) (*dto.ListResponse[dto.TemplateVersionResponse], error) {
+	if _, err := s.getTemplate(ctx, teamID, templateID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.templateVersionRepo.ListByTemplateID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("listing template versions: %w", err)
+	}
+
+	data := make([]dto.TemplateVersionResponse, 0, len(versions))
+	for i := range versions {
+		data = append(data, *templateVersionToResponse(&versions[i]))
+	}
+	return &dto.ListResponse[dto.TemplateVersionResponse]{Data: data}, nil
+}
+
+// PublishVersion publishes a specific version, which may be older than the
+// currently published one. This is how a template is rolled back.
+func (s *templateService) PublishVersion(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, version int) (*dto.TemplateResponse, error) {
+	template, err := s.getTemplate(ctx, teamID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.getVersion(ctx, templateID, &version)
+	if err != nil {
+		return nil, err
+	}
+	if v.Published {
+		return nil, fmt.Errorf("%w: version %d is already published", pkg.ErrValidation, v.Version)
+	}
+
+	if err := s.templateVersionRepo.Publish(ctx, v.ID); err != nil {
+		return nil, fmt.Errorf("publishing template version: %w", err)
+	}
+
+	template.UpdatedAt = time.Now().UTC()
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		return nil, fmt.Errorf("updating template: %w", err)
+	}
+
+	return templateToResponse(template), nil
+}
+
+func (s *templateService) Diff(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, from, to int) (*dto.TemplateDiffResponse, error) {
+	if _, err := s.getTemplate(ctx, teamID, templateID); err != nil {
+		return nil, err
+	}
+
+	a, err := s.getVersion(ctx, templateID, &from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.getVersion(ctx, templateID, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	varsA, err := json.MarshalIndent(variablesToDTO(templateVariables(a.Variables)), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding variables: %w", err)
+	}
+	varsB, err := json.MarshalIndent(variablesToDTO(templateVariables(b.Variables)), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding variables: %w", err)
+	}
+
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"subject", ptrToString(a.Subject), ptrToString(b.Subject)},
+		{"html", ptrToString(a.HTMLBody), ptrToString(b.HTMLBody)},
+		{"text", ptrToString(a.TextBody), ptrToString(b.TextBody)},
+		{"variables", string(varsA), string(varsB)},
+	}
+
+	resp := &dto.TemplateDiffResponse{From: from, To: to, Changes: []dto.TemplateFieldDiff{}}
+	for _, f := range fields {
+		diff := templating.DiffLines(f.from, f.to)
+		if !templating.Changed(diff) {
+			continue
+		}
+		lines := make([]dto.TemplateDiffLine, 0, len(diff))
+		for _, l := range diff {
+			lines = append(lines, dto.TemplateDiffLine{Op: l.Op, Text: l.Text})
+		}
+		resp.Changes = append(resp.Changes, dto.TemplateFieldDiff{Field: f.name, Lines: lines})
+	}
+	return resp, nil
+}
+
+func (s *templateService) Preview(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.PreviewTemplateRequest) (*dto.PreviewTemplateResponse, error) {
+	if _, err := s.getTemplate(ctx, teamID, templateID); err != nil {
+		return nil, err
+	}
+
+	v, err := s.getVersion(ctx, templateID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, htmlBody, textBody, err := renderVersion(v, req.Variables, req.Contact)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PreviewTemplateResponse{
+		Version: v.Version,
+		Subject: subject,
+		HTML:    htmlBody,
+		Text:    textBody,
+	}, nil
+}
+
+// SendTest renders a version, published or not, and sends it to a single
+// address. The subject is prefixed with "[Test]".
+func (s *templateService) SendTest(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.TestTemplateRequest) (*dto.SendEmailResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	if _, err := s.getTemplate(ctx, teamID, templateID); err != nil {
+		return nil, err
+	}
+
+	v, err := s.getVersion(ctx, templateID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	contact := req.Contact
+	if contact == nil {
+		contact = map[string]interface{}{"email": req.To}
+	}
+
+	subject, htmlBody, textBody, err := renderVersion(v, req.Variables, contact)
+	if err != nil {
+		return nil, err
+	}
+	if subject == nil || *subject == "" {
+		return nil, fmt.Errorf("%w: template version %d has no subject", pkg.ErrValidation, v.Version)
+	}
+
+	return s.emailService.Send(ctx, teamID, &dto.SendEmailRequest{
+		From:    req.From,
+		To:      []string{req.To},
+		Subject: "[Test] " + *subject,
+		HTML:    htmlBody,
+		Text:    textBody,
+		Tags:    []dto.Tag{{Name: "template_test", Value: strconv.Itoa(v.Version)}},
+	})
+}
+
+// getTemplate loads a template and checks that it belongs to the team.
+func (s *templateService) getTemplate(ctx context.Context, teamID, templateID uuid.UUID) (*model.Template, error) {
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	if template.TeamID != teamID {
+		return nil, fmt.Errorf("template not found: %w", postgres.ErrNotFound)
+	}
+	return template, nil
+}
+
+// getVersion loads the given version of a template, or its latest version
+// when version is nil.
+func (s *templateService) getVersion(ctx context.Context, templateID uuid.UUID, version *int) (*model.TemplateVersion, error) {
+	var (
+		v   *model.TemplateVersion
+		err error
+	)
+	if version == nil {
+		v, err = s.templateVersionRepo.GetLatestByTemplateID(ctx, templateID)
+	} else {
+		v, err = s.templateVersionRepo.GetByTemplateIDAndVersion(ctx, templateID, *version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("template version not found: %w", err)
+	}
+	return v, nil
+}
+
+// renderVersion renders a template version with the given variables and
+// sample contact data.
+func renderVersion(v *model.TemplateVersion, variables, contact map[string]interface{}) (subject, htmlBody, textBody *string, err error) {
+	data, err := templating.CheckVariables(templateVariables(v.Variables), variables)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	content, err := templating.ParseContent(v.Subject, v.HTMLBody, v.TextBody)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: invalid template %w", pkg.ErrValidation, err)
+	}
+
+	if contact == nil {
+		contact = map[string]interface{}{}
+	}
+	data[templating.VarContact] = contact
+	data[templating.VarUnsubscribeURL] = previewUnsubscribeURL
+
+	subject, htmlBody, textBody = content.Render(data)
+	return subject, htmlBody, textBody, nil
+}
+
+// checkTemplateContent rejects content that does not parse, or that
+// references variables it does not declare.
+func checkTemplateContent(subject, html, text *string, variables []templating.Variable) error {
+	if err := templating.CheckDeclarations(variables, templating.Builtins()); err != nil {
+		return fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	content, err := templating.ParseContent(subject, html, text)
+	if err != nil {
+		return fmt.Errorf("%w: invalid template %w", pkg.ErrValidation, err)
+	}
+	if err := content.CheckReferences(variables); err != nil {
+		return fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+	return nil
+}
+
+// templateVariables decodes the variable declarations stored on a template
+// version.
+func templateVariables(raw model.JSONArray) []templating.Variable {
+	return templating.VariablesFromJSON(raw)
+}
+
+// variablesToJSON encodes variable declarations for storage on a template
+// version.
+func variablesToJSON(variables []templating.Variable) model.JSONArray {
+	arr := make(model.JSONArray, 0, len(variables))
+	for _, v := range variables {
+		entry := map[string]interface{}{"name": v.Name}
+		if v.Type != "" {
+			entry["type"] = v.Type
+		}
+		if v.Required {
+			entry["required"] = true
+		}
+		if v.Default != nil {
+			entry["default"] = v.Default
+		}
+		arr = append(arr, entry)
+	}
+	return arr
+}
+
+func variablesFromDTO(vars []dto.TemplateVariable) []templating.Variable {
+	variables := make([]templating.Variable, 0, len(vars))
+	for _, v := range vars {
+		variables = append(variables, templating.Variable{
+			Name:     v.Name,
+			Type:     v.Type,
+			Required: v.Required,
+			Default:  v.Default,
+		})
+	}
+	return variables
+}
+
+func variablesToDTO(variables []templating.Variable) []dto.TemplateVariable {
+	vars := make([]dto.TemplateVariable, 0, len(variables))
+	for _, v := range variables {
+		vars = append(vars, dto.TemplateVariable{
+			Name:     v.Name,
+			Type:     v.Type,
+			Required: v.Required,
+			Default:  v.Default,
+		})
+	}
+	return vars
+}
+
+// templateVersionToResponse converts a model.TemplateVersion to a
+// dto.TemplateVersionResponse.
+func templateVersionToResponse(v *model.TemplateVersion) *dto.TemplateVersionResponse {
+	return &dto.TemplateVersionResponse{
+		ID:        v.ID.String(),
+		Version:   v.Version,
+		Subject:   v.Subject,
+		HTML:      v.HTMLBody,
+		Text:      v.TextBody,
+		Variables: variablesToDTO(templateVariables(v.Variables)),
+		Published: v.Published,
+		CreatedAt: v.CreatedAt.Format(time.RFC3339),
+	}
+}
+
 // templateToResponse converts a model.Template to a dto.TemplateResponse.
 func templateToResponse(t *model.Template) *dto.TemplateResponse {
 	return &dto.TemplateResponse{
@@ -260,3 +586,11 @@ func templateToResponse(t *model.Template) *dto.TemplateResponse {
 		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
 	}
 }
+
+// ptrToString safely dereferences a string pointer, returning empty string for nil.
+func ptrToString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
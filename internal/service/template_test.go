@@ -11,6 +11,8 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
 )
@@ -18,7 +20,7 @@ import (
 func TestTemplateService_Create_HappyPath(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -41,10 +43,76 @@ func TestTemplateService_Create_HappyPath(t *testing.T) {
 	versionRepo.AssertExpectations(t)
 }
 
+func TestTemplateService_Create_StoresVariables(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	templateRepo.On("Create", ctx, mock.AnythingOfType("*model.Template")).Return(nil)
+	var saved *model.TemplateVersion
+	versionRepo.On("Create", ctx, mock.AnythingOfType("*model.TemplateVersion")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*model.TemplateVersion) }).
+		Return(nil)
+
+	req := &dto.CreateTemplateRequest{
+		Name:      "Receipt",
+		Subject:   testutil.StringPtr("Order {{order_id}}"),
+		HTML:      testutil.StringPtr("<p>Hi {{contact.first_name | default \"there\"}}</p>"),
+		Variables: []dto.TemplateVariable{{Name: "order_id", Type: "string", Required: true}},
+	}
+
+	_, err := svc.Create(ctx, testutil.TestTeamID, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.JSONArray{map[string]interface{}{"name": "order_id", "type": "string", "required": true}}, saved.Variables)
+}
+
+func TestTemplateService_Create_InvalidTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *dto.CreateTemplateRequest
+		wantErr string
+	}{
+		{
+			name:    "syntax error",
+			req:     &dto.CreateTemplateRequest{Name: "Broken", HTML: testutil.StringPtr("{{#if vip}}VIP")},
+			wantErr: "html: line 1: unclosed {{#if}}",
+		},
+		{
+			name:    "undeclared variable",
+			req:     &dto.CreateTemplateRequest{Name: "Receipt", Subject: testutil.StringPtr("Order {{order_id}}")},
+			wantErr: "undeclared variables: order_id",
+		},
+		{
+			name: "reserved variable",
+			req: &dto.CreateTemplateRequest{
+				Name:      "Receipt",
+				Variables: []dto.TemplateVariable{{Name: "contact"}},
+			},
+			wantErr: `variable "contact" is declared twice or reserved`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateRepo := new(tmock.MockTemplateRepository)
+			versionRepo := new(tmock.MockTemplateVersionRepository)
+			svc := NewTemplateService(templateRepo, versionRepo, nil)
+
+			_, err := svc.Create(context.Background(), testutil.TestTeamID, tt.req)
+
+			assert.ErrorIs(t, err, pkg.ErrValidation)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			templateRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestTemplateService_List_Paginated(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -64,7 +132,7 @@ func TestTemplateService_List_Paginated(t *testing.T) {
 func TestTemplateService_Get_HappyPath(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -87,7 +155,7 @@ func TestTemplateService_Get_HappyPath(t *testing.T) {
 func TestTemplateService_Get_WrongTeam(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -107,7 +175,7 @@ func TestTemplateService_Get_WrongTeam(t *testing.T) {
 func TestTemplateService_Update_WithContentCreatesNewVersion(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -135,7 +203,7 @@ func TestTemplateService_Update_WithContentCreatesNewVersion(t *testing.T) {
 func TestTemplateService_Delete_HappyPath(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -153,7 +221,7 @@ func TestTemplateService_Delete_HappyPath(t *testing.T) {
 func TestTemplateService_Publish_HappyPath(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -178,7 +246,7 @@ func TestTemplateService_Publish_HappyPath(t *testing.T) {
 func TestTemplateService_Publish_AlreadyPublished(t *testing.T) {
 	templateRepo := new(tmock.MockTemplateRepository)
 	versionRepo := new(tmock.MockTemplateVersionRepository)
-	svc := NewTemplateService(templateRepo, versionRepo)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -198,3 +266,205 @@ func TestTemplateService_Publish_AlreadyPublished(t *testing.T) {
 	templateRepo.AssertExpectations(t)
 	versionRepo.AssertExpectations(t)
 }
+
+func TestTemplateService_Preview(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	version := testutil.NewTestTemplateVersion(tmpl.ID)
+	html := `<p>Hi {{contact.first_name}}, {{name}}</p><a href="{{unsubscribe_url}}">x</a>`
+	version.HTMLBody = &html
+
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 1).Return(version, nil)
+
+	versionNumber := 1
+	resp, err := svc.Preview(ctx, testutil.TestTeamID, tmpl.ID, &dto.PreviewTemplateRequest{
+		Version:   &versionNumber,
+		Variables: map[string]interface{}{"name": "<Ada>"},
+		Contact:   map[string]interface{}{"first_name": "Grace"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Version)
+	assert.Equal(t, "Hello <Ada>", *resp.Subject)
+	assert.Equal(t, `<p>Hi Grace, &lt;Ada&gt;</p><a href="#unsubscribe">x</a>`, *resp.HTML)
+	versionRepo.AssertExpectations(t)
+}
+
+func TestTemplateService_Preview_InvalidVariables(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetLatestByTemplateID", ctx, tmpl.ID).Return(testutil.NewTestTemplateVersion(tmpl.ID), nil)
+
+	_, err := svc.Preview(ctx, testutil.TestTeamID, tmpl.ID, &dto.PreviewTemplateRequest{
+		Variables: map[string]interface{}{"name": 1.0},
+	})
+
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "name must be a string")
+}
+
+func TestTemplateService_SendTest(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	emailSvc := new(tmock.MockEmailService)
+	svc := NewTemplateService(templateRepo, versionRepo, emailSvc)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	version := testutil.NewTestTemplateVersion(tmpl.ID)
+	version.Version = 3
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetLatestByTemplateID", ctx, tmpl.ID).Return(version, nil)
+
+	var sent *dto.SendEmailRequest
+	emailSvc.On("Send", ctx, testutil.TestTeamID, mock.AnythingOfType("*dto.SendEmailRequest")).
+		Run(func(args mock.Arguments) { sent = args.Get(2).(*dto.SendEmailRequest) }).
+		Return(&dto.SendEmailResponse{ID: "email-1"}, nil)
+
+	resp, err := svc.SendTest(ctx, testutil.TestTeamID, tmpl.ID, &dto.TestTemplateRequest{
+		From:      "sender@example.com",
+		To:        "me@example.com",
+		Variables: map[string]interface{}{"name": "Ada"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "email-1", resp.ID)
+	require.NotNil(t, sent)
+	assert.Equal(t, []string{"me@example.com"}, sent.To)
+	assert.Equal(t, "[Test] Hello Ada", sent.Subject)
+	assert.Equal(t, "<p>Hello Ada</p>", *sent.HTML)
+	assert.Nil(t, sent.TemplateID)
+	assert.Equal(t, []dto.Tag{{Name: "template_test", Value: "3"}}, sent.Tags)
+	emailSvc.AssertExpectations(t)
+}
+
+func TestTemplateService_SendTest_WrongTeam(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, new(tmock.MockEmailService))
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+
+	_, err := svc.SendTest(ctx, uuid.New(), tmpl.ID, &dto.TestTemplateRequest{From: "sender@example.com", To: "me@example.com"})
+
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+}
+
+func TestTemplateService_ListVersions(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	v1 := testutil.NewTestTemplateVersion(tmpl.ID)
+	v2 := testutil.NewTestTemplateVersion(tmpl.ID)
+	v2.Version = 2
+	v2.Published = true
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("ListByTemplateID", ctx, tmpl.ID).Return([]model.TemplateVersion{*v2, *v1}, nil)
+
+	resp, err := svc.ListVersions(ctx, testutil.TestTeamID, tmpl.ID)
+
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, 2, resp.Data[0].Version)
+	assert.True(t, resp.Data[0].Published)
+	assert.Equal(t, []dto.TemplateVariable{{Name: "name", Type: "string"}}, resp.Data[1].Variables)
+}
+
+func TestTemplateService_Diff(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	v1 := testutil.NewTestTemplateVersion(tmpl.ID)
+	v2 := testutil.NewTestTemplateVersion(tmpl.ID)
+	v2.Version = 2
+	html := "<p>Hello {{name}}</p>\n<p>Thanks</p>"
+	v2.HTMLBody = &html
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 1).Return(v1, nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 2).Return(v2, nil)
+
+	resp, err := svc.Diff(ctx, testutil.TestTeamID, tmpl.ID, 1, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.From)
+	assert.Equal(t, 2, resp.To)
+	require.Len(t, resp.Changes, 1)
+	assert.Equal(t, "html", resp.Changes[0].Field)
+	assert.Equal(t, []dto.TemplateDiffLine{
+		{Op: "equal", Text: "<p>Hello {{name}}</p>"},
+		{Op: "insert", Text: "<p>Thanks</p>"},
+	}, resp.Changes[0].Lines)
+}
+
+func TestTemplateService_Diff_MissingVersion(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 1).Return(testutil.NewTestTemplateVersion(tmpl.ID), nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 9).Return(nil, postgres.ErrNotFound)
+
+	_, err := svc.Diff(ctx, testutil.TestTeamID, tmpl.ID, 1, 9)
+
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+}
+
+func TestTemplateService_PublishVersion_Rollback(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	v1 := testutil.NewTestTemplateVersion(tmpl.ID)
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 1).Return(v1, nil)
+	versionRepo.On("Publish", ctx, v1.ID).Return(nil)
+	templateRepo.On("Update", ctx, mock.AnythingOfType("*model.Template")).Return(nil)
+
+	resp, err := svc.PublishVersion(ctx, testutil.TestTeamID, tmpl.ID, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.ID.String(), resp.ID)
+	versionRepo.AssertExpectations(t)
+	templateRepo.AssertExpectations(t)
+}
+
+func TestTemplateService_PublishVersion_AlreadyPublished(t *testing.T) {
+	templateRepo := new(tmock.MockTemplateRepository)
+	versionRepo := new(tmock.MockTemplateVersionRepository)
+	svc := NewTemplateService(templateRepo, versionRepo, nil)
+	ctx := context.Background()
+
+	tmpl := testutil.NewTestTemplate()
+	v1 := testutil.NewTestTemplateVersion(tmpl.ID)
+	v1.Published = true
+	templateRepo.On("GetByID", ctx, tmpl.ID).Return(tmpl, nil)
+	versionRepo.On("GetByTemplateIDAndVersion", ctx, tmpl.ID, 1).Return(v1, nil)
+
+	_, err := svc.PublishVersion(ctx, testutil.TestTeamID, tmpl.ID, 1)
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	versionRepo.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
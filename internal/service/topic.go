@@ -40,12 +40,16 @@ func (s *topicService) Create(ctx context.Context, teamID uuid.UUID, req *dto.Cr
 	now := time.Now().UTC()
 
 	topic := &model.Topic{
-		ID:          uuid.New(),
-		TeamID:      teamID,
-		Name:        req.Name,
-		Description: req.Description,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:                  uuid.New(),
+		TeamID:              teamID,
+		Name:                req.Name,
+		Description:         req.Description,
+		DefaultSubscription: req.DefaultSubscription,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if topic.DefaultSubscription == "" {
+		topic.DefaultSubscription = model.TopicDefaultOptIn
 	}
 
 	if err := s.topicRepo.Create(ctx, topic); err != nil {
@@ -86,6 +90,15 @@ func (s *topicService) Update(ctx context.Context, teamID uuid.UUID, topicID uui
 	if req.Description != nil {
 		topic.Description = req.Description
 	}
+	if req.DefaultSubscription != nil {
+		switch *req.DefaultSubscription {
+		case model.TopicDefaultOptIn, model.TopicDefaultOptOut:
+			topic.DefaultSubscription = *req.DefaultSubscription
+		default:
+			return nil, fmt.Errorf("%w: default_subscription must be %q or %q",
+				pkg.ErrValidation, model.TopicDefaultOptIn, model.TopicDefaultOptOut)
+		}
+	}
 
 	topic.UpdatedAt = time.Now().UTC()
 
@@ -117,9 +130,10 @@ func (s *topicService) Delete(ctx context.Context, teamID uuid.UUID, topicID uui
 // topicToResponse converts a model.Topic to a dto.TopicResponse.
 func topicToResponse(t *model.Topic) *dto.TopicResponse {
 	return &dto.TopicResponse{
-		ID:          t.ID.String(),
-		Name:        t.Name,
-		Description: t.Description,
-		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+		ID:                  t.ID.String(),
+		Name:                t.Name,
+		Description:         t.Description,
+		DefaultSubscription: t.DefaultSubscription,
+		CreatedAt:           t.CreatedAt.Format(time.RFC3339),
 	}
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
@@ -46,6 +47,7 @@ func TestTopicService_Create(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, resp.ID)
 	assert.Equal(t, "Product Updates", resp.Name)
+	assert.Equal(t, model.TopicDefaultOptIn, resp.DefaultSubscription)
 
 	topicRepo.AssertExpectations(t)
 }
@@ -90,6 +92,31 @@ func TestTopicService_Update_HappyPath(t *testing.T) {
 	topicRepo.AssertExpectations(t)
 }
 
+func TestTopicService_Update_DefaultSubscription(t *testing.T) {
+	topicRepo := new(tmock.MockTopicRepository)
+	svc := NewTopicService(topicRepo)
+	ctx := context.Background()
+	teamID := testutil.TestTeamID
+
+	topic := newTestTopic()
+	topicRepo.On("GetByID", ctx, topic.ID).Return(topic, nil)
+	topicRepo.On("Update", ctx, mock.AnythingOfType("*model.Topic")).Return(nil)
+
+	resp, err := svc.Update(ctx, teamID, topic.ID, &dto.UpdateTopicRequest{
+		DefaultSubscription: testutil.StringPtr(model.TopicDefaultOptOut),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, model.TopicDefaultOptOut, resp.DefaultSubscription)
+
+	_, err = svc.Update(ctx, teamID, topic.ID, &dto.UpdateTopicRequest{
+		DefaultSubscription: testutil.StringPtr("sometimes"),
+	})
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+	topicRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
 func TestTopicService_Update_WrongTeam(t *testing.T) {
 	topicRepo := new(tmock.MockTopicRepository)
 	svc := NewTopicService(topicRepo)
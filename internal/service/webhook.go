@@ -6,11 +6,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
 	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/webhook"
+	"github.com/mailit-dev/mailit/internal/worker"
 )
 
 // WebhookService defines operations for managing webhook endpoints.
@@ -20,16 +23,37 @@ type WebhookService interface {
 	Get(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID) (*dto.WebhookResponse, error)
 	Update(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.UpdateWebhookRequest) (*dto.WebhookResponse, error)
 	Delete(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID) error
+	Enable(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.EnableWebhookRequest) (*dto.EnableWebhookResponse, error)
+	ListEvents(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, status, eventType string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.WebhookEventResponse], error)
+	ReplayEvent(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, eventID uuid.UUID) (*dto.WebhookEventResponse, error)
+	ReplayEvents(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.ReplayWebhookEventsRequest) (*dto.ReplayWebhookEventsResponse, error)
+	Test(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.TestWebhookRequest) (*dto.WebhookEventResponse, error)
 }
 
+// WebhookDeliverer redelivers and test-sends webhook events. It is
+// implemented by webhook.Dispatcher.
+type WebhookDeliverer interface {
+	Redeliver(ctx context.Context, event *model.WebhookEvent) error
+	SendTest(ctx context.Context, wh *model.Webhook, eventType string) (*model.WebhookEvent, error)
+}
+
+// maxReplayEvents caps how many events a single bulk replay may redeliver.
+const maxReplayEvents = 1000
+
 type webhookService struct {
-	webhookRepo postgres.WebhookRepository
+	webhookRepo      postgres.WebhookRepository
+	webhookEventRepo postgres.WebhookEventRepository
+	deliverer        WebhookDeliverer
+	asynqClient      *asynq.Client
 }
 
 // NewWebhookService creates a new WebhookService.
-func NewWebhookService(webhookRepo postgres.WebhookRepository) WebhookService {
+func NewWebhookService(webhookRepo postgres.WebhookRepository, webhookEventRepo postgres.WebhookEventRepository, deliverer WebhookDeliverer, asynqClient *asynq.Client) WebhookService {
 	return &webhookService{
-		webhookRepo: webhookRepo,
+		webhookRepo:      webhookRepo,
+		webhookEventRepo: webhookEventRepo,
+		deliverer:        deliverer,
+		asynqClient:      asynqClient,
 	}
 }
 
@@ -113,8 +137,9 @@ func (s *webhookService) Update(ctx context.Context, teamID uuid.UUID, webhookID
 	if req.Events != nil {
 		webhook.Events = req.Events
 	}
-	if req.Active != nil {
+	if req.Active != nil && *req.Active != webhook.Active {
 		webhook.Active = *req.Active
+		clearDisabled(webhook)
 	}
 
 	webhook.UpdatedAt = time.Now().UTC()
@@ -144,6 +169,227 @@ func (s *webhookService) Delete(ctx context.Context, teamID uuid.UUID, webhookID
 	return nil
 }
 
+// Enable re-activates a webhook, typically one disabled after repeated
+// failures, and resets its failure streak. With req.Backfill set, the events
+// skipped while it was disabled are queued for delivery.
+func (s *webhookService) Enable(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.EnableWebhookRequest) (*dto.EnableWebhookResponse, error) {
+	wh, err := s.getWebhook(ctx, teamID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	wh.Active = true
+	clearDisabled(wh)
+	wh.UpdatedAt = time.Now().UTC()
+
+	if err := s.webhookRepo.Update(ctx, wh); err != nil {
+		return nil, fmt.Errorf("updating webhook: %w", err)
+	}
+
+	// The skipped events are delivered in pages by a webhook:backfill task,
+	// so that a long backlog neither holds up the request nor floods the
+	// endpoint that was just re-enabled.
+	backfilling := 0
+	if req.Backfill {
+		filter := postgres.WebhookEventFilter{Status: webhook.EventStatusSkipped}
+		_, total, err := s.webhookEventRepo.ListByWebhookID(ctx, wh.ID, filter, 1, 0)
+		if err != nil {
+			return nil, fmt.Errorf("counting skipped webhook events: %w", err)
+		}
+		if total > 0 {
+			task, err := worker.NewWebhookBackfillTask(wh.ID)
+			if err != nil {
+				return nil, fmt.Errorf("creating webhook:backfill task: %w", err)
+			}
+			if _, err := s.asynqClient.Enqueue(task); err != nil {
+				return nil, fmt.Errorf("enqueueing webhook:backfill task: %w", err)
+			}
+			backfilling = total
+		}
+	}
+
+	return &dto.EnableWebhookResponse{Webhook: *webhookToResponse(wh), Backfilling: backfilling}, nil
+}
+
+func (s *webhookService) ListEvents(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, status, eventType string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.WebhookEventResponse], error) {
+	if _, err := s.getWebhook(ctx, teamID, webhookID); err != nil {
+		return nil, err
+	}
+	if status != "" && !validWebhookEventStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", pkg.ErrValidation, status)
+	}
+
+	params.Normalize()
+
+	filter := postgres.WebhookEventFilter{Status: status, EventType: eventType}
+	events, total, err := s.webhookEventRepo.ListByWebhookID(ctx, webhookID, filter, params.PerPage, params.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook events: %w", err)
+	}
+
+	data := make([]dto.WebhookEventResponse, 0, len(events))
+	for i := range events {
+		data = append(data, *webhookEventToResponse(&events[i]))
+	}
+
+	totalPages := 0
+	if params.PerPage > 0 {
+		totalPages = (total + params.PerPage - 1) / params.PerPage
+	}
+
+	return &dto.PaginatedResponse[dto.WebhookEventResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       params.Page,
+		PerPage:    params.PerPage,
+		TotalPages: totalPages,
+		HasMore:    params.Page < totalPages,
+	}, nil
+}
+
+func (s *webhookService) ReplayEvent(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, eventID uuid.UUID) (*dto.WebhookEventResponse, error) {
+	wh, err := s.getWebhook(ctx, teamID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !wh.Active {
+		return nil, fmt.Errorf("%w: webhook is disabled", pkg.ErrValidation)
+	}
+
+	event, err := s.webhookEventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook event not found: %w", err)
+	}
+	if event.WebhookID != wh.ID {
+		return nil, fmt.Errorf("webhook event not found: %w", postgres.ErrNotFound)
+	}
+	if event.Status == webhook.EventStatusPending {
+		return nil, fmt.Errorf("%w: event is already pending delivery", pkg.ErrValidation)
+	}
+
+	if err := s.deliverer.Redeliver(ctx, event); err != nil {
+		return nil, fmt.Errorf("replaying webhook event: %w", err)
+	}
+
+	return webhookEventToResponse(event), nil
+}
+
+// ReplayEvents redelivers the webhook's events created in a time range.
+// Events still pending delivery are skipped.
+func (s *webhookService) ReplayEvents(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.ReplayWebhookEventsRequest) (*dto.ReplayWebhookEventsResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	wh, err := s.getWebhook(ctx, teamID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !wh.Active {
+		return nil, fmt.Errorf("%w: webhook is disabled", pkg.ErrValidation)
+	}
+
+	since, err := time.Parse(time.RFC3339, req.Since)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid since: %w", pkg.ErrValidation, err)
+	}
+	until, err := time.Parse(time.RFC3339, req.Until)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid until: %w", pkg.ErrValidation, err)
+	}
+	if !since.Before(until) {
+		return nil, fmt.Errorf("%w: since must be before until", pkg.ErrValidation)
+	}
+
+	filter := postgres.WebhookEventFilter{Status: req.Status, EventType: req.EventType, Since: &since, Until: &until}
+	events, total, err := s.webhookEventRepo.ListByWebhookID(ctx, webhookID, filter, maxReplayEvents, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook events: %w", err)
+	}
+	if total > maxReplayEvents {
+		return nil, fmt.Errorf("%w: %d events match; narrow the range to at most %d", pkg.ErrValidation, total, maxReplayEvents)
+	}
+
+	replayed := 0
+	for i := range events {
+		if events[i].Status == webhook.EventStatusPending {
+			continue
+		}
+		if err := s.deliverer.Redeliver(ctx, &events[i]); err != nil {
+			return nil, fmt.Errorf("replaying webhook event %s: %w", events[i].ID, err)
+		}
+		replayed++
+	}
+
+	return &dto.ReplayWebhookEventsResponse{Replayed: replayed}, nil
+}
+
+// Test delivers a sample event of the requested type to the webhook and
+// reports the outcome.
+func (s *webhookService) Test(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.TestWebhookRequest) (*dto.WebhookEventResponse, error) {
+	if err := pkg.Validate(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", pkg.ErrValidation, err)
+	}
+
+	wh, err := s.getWebhook(ctx, teamID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := s.deliverer.SendTest(ctx, wh, req.EventType)
+	if err != nil {
+		return nil, fmt.Errorf("sending test webhook: %w", err)
+	}
+	return webhookEventToResponse(event), nil
+}
+
+// clearDisabled forgets an automatic disable: the reason, the time and the
+// failure streak that led to it.
+func clearDisabled(wh *model.Webhook) {
+	wh.ConsecutiveFailures = 0
+	wh.DisabledAt = nil
+	wh.DisabledReason = nil
+}
+
+// getWebhook loads a webhook and checks that it belongs to the team.
+func (s *webhookService) getWebhook(ctx context.Context, teamID, webhookID uuid.UUID) (*model.Webhook, error) {
+	wh, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook not found: %w", err)
+	}
+	if wh.TeamID != teamID {
+		return nil, fmt.Errorf("webhook not found: %w", postgres.ErrNotFound)
+	}
+	return wh, nil
+}
+
+func validWebhookEventStatus(status string) bool {
+	switch status {
+	case webhook.EventStatusPending, webhook.EventStatusDelivered, webhook.EventStatusFailed, webhook.EventStatusSkipped:
+		return true
+	}
+	return false
+}
+
+// webhookEventToResponse converts a model.WebhookEvent to a dto.WebhookEventResponse.
+func webhookEventToResponse(ev *model.WebhookEvent) *dto.WebhookEventResponse {
+	resp := &dto.WebhookEventResponse{
+		ID:           ev.ID.String(),
+		EventType:    ev.EventType,
+		Payload:      ev.Payload,
+		Status:       ev.Status,
+		ResponseCode: ev.ResponseCode,
+		ResponseBody: ev.ResponseBody,
+		Attempts:     ev.Attempts,
+		CreatedAt:    ev.CreatedAt.Format(time.RFC3339),
+	}
+	if ev.NextRetryAt != nil {
+		next := ev.NextRetryAt.Format(time.RFC3339)
+		resp.NextRetryAt = &next
+	}
+	return resp
+}
+
 // webhookToResponse converts a model.Webhook to a dto.WebhookResponse.
 func webhookToResponse(w *model.Webhook) *dto.WebhookResponse {
 	return &dto.WebhookResponse{
@@ -151,6 +397,32 @@ func webhookToResponse(w *model.Webhook) *dto.WebhookResponse {
 		URL:       w.URL,
 		Events:    w.Events,
 		Active:    w.Active,
+		Health:    webhookHealthToResponse(w),
 		CreatedAt: w.CreatedAt.Format(time.RFC3339),
 	}
 }
+
+func webhookHealthToResponse(w *model.Webhook) dto.WebhookHealthResponse {
+	health := dto.WebhookHealthResponse{
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DeliveredCount:      w.DeliveredCount,
+		FailedCount:         w.FailedCount,
+		LastDeliveredAt:     formatOptionalTime(w.LastDeliveredAt),
+		LastFailedAt:        formatOptionalTime(w.LastFailedAt),
+		DisabledAt:          formatOptionalTime(w.DisabledAt),
+		DisabledReason:      w.DisabledReason,
+	}
+	if attempts := w.DeliveredCount + w.FailedCount; attempts > 0 {
+		rate := float64(w.DeliveredCount) / float64(attempts)
+		health.SuccessRate = &rate
+	}
+	return health
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
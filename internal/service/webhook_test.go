@@ -3,22 +3,27 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 	"github.com/mailit-dev/mailit/internal/testutil"
 	tmock "github.com/mailit-dev/mailit/internal/testutil/mock"
+	"github.com/mailit-dev/mailit/internal/worker"
 )
 
 func TestWebhookService_Create_HappyPath(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -42,7 +47,7 @@ func TestWebhookService_Create_HappyPath(t *testing.T) {
 
 func TestWebhookService_List_ReturnsWebhooks(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -60,7 +65,7 @@ func TestWebhookService_List_ReturnsWebhooks(t *testing.T) {
 
 func TestWebhookService_Get_HappyPath(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -78,7 +83,7 @@ func TestWebhookService_Get_HappyPath(t *testing.T) {
 
 func TestWebhookService_Get_WrongTeam(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -96,7 +101,7 @@ func TestWebhookService_Get_WrongTeam(t *testing.T) {
 
 func TestWebhookService_Update_Fields(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -121,7 +126,7 @@ func TestWebhookService_Update_Fields(t *testing.T) {
 
 func TestWebhookService_Delete_HappyPath(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 
@@ -138,7 +143,7 @@ func TestWebhookService_Delete_HappyPath(t *testing.T) {
 
 func TestWebhookService_Delete_WrongTeam(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	wrongTeamID := uuid.New()
 
@@ -156,7 +161,7 @@ func TestWebhookService_Delete_WrongTeam(t *testing.T) {
 
 func TestWebhookService_Get_NotFound(t *testing.T) {
 	webhookRepo := new(tmock.MockWebhookRepository)
-	svc := NewWebhookService(webhookRepo)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
 	ctx := context.Background()
 	teamID := testutil.TestTeamID
 	badID := uuid.New()
@@ -171,3 +176,278 @@ func TestWebhookService_Get_NotFound(t *testing.T) {
 
 	webhookRepo.AssertExpectations(t)
 }
+
+type mockWebhookDeliverer struct{ mock.Mock }
+
+func (m *mockWebhookDeliverer) Redeliver(ctx context.Context, event *model.WebhookEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+func (m *mockWebhookDeliverer) SendTest(ctx context.Context, wh *model.Webhook, eventType string) (*model.WebhookEvent, error) {
+	args := m.Called(ctx, wh, eventType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEvent), args.Error(1)
+}
+
+func newTestWebhookEvent(webhookID uuid.UUID, status string) model.WebhookEvent {
+	code := 500
+	return model.WebhookEvent{
+		ID:           uuid.New(),
+		WebhookID:    webhookID,
+		EventType:    "email.sent",
+		Payload:      model.JSONMap{"email_id": "e1"},
+		Status:       status,
+		ResponseCode: &code,
+		Attempts:     5,
+		CreatedAt:    testutil.FixedTime,
+	}
+}
+
+func TestWebhookService_ListEvents(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	svc := NewWebhookService(webhookRepo, eventRepo, nil, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	filter := postgres.WebhookEventFilter{Status: "failed", EventType: "email.sent"}
+	eventRepo.On("ListByWebhookID", ctx, wh.ID, filter, 20, 0).
+		Return([]model.WebhookEvent{newTestWebhookEvent(wh.ID, "failed")}, 1, nil)
+
+	resp, err := svc.ListEvents(ctx, testutil.TestTeamID, wh.ID, "failed", "email.sent", &dto.PaginationParams{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "failed", resp.Data[0].Status)
+	assert.Equal(t, 500, *resp.Data[0].ResponseCode)
+	assert.Equal(t, 5, resp.Data[0].Attempts)
+	eventRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_ListEvents_UnknownStatus(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	svc := NewWebhookService(webhookRepo, new(tmock.MockWebhookEventRepository), nil, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+
+	_, err := svc.ListEvents(ctx, testutil.TestTeamID, wh.ID, "sent", "", &dto.PaginationParams{})
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+}
+
+func TestWebhookService_ReplayEvent(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	deliverer := new(mockWebhookDeliverer)
+	svc := NewWebhookService(webhookRepo, eventRepo, deliverer, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	event := newTestWebhookEvent(wh.ID, "failed")
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	eventRepo.On("GetByID", ctx, event.ID).Return(&event, nil)
+	deliverer.On("Redeliver", ctx, &event).Return(nil)
+
+	resp, err := svc.ReplayEvent(ctx, testutil.TestTeamID, wh.ID, event.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, event.ID.String(), resp.ID)
+	deliverer.AssertExpectations(t)
+}
+
+func TestWebhookService_ReplayEvent_OtherWebhook(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	deliverer := new(mockWebhookDeliverer)
+	svc := NewWebhookService(webhookRepo, eventRepo, deliverer, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	event := newTestWebhookEvent(uuid.New(), "failed")
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	eventRepo.On("GetByID", ctx, event.ID).Return(&event, nil)
+
+	_, err := svc.ReplayEvent(ctx, testutil.TestTeamID, wh.ID, event.ID)
+
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+	deliverer.AssertNotCalled(t, "Redeliver", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_ReplayEvent_Pending(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	svc := NewWebhookService(webhookRepo, eventRepo, new(mockWebhookDeliverer), nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	event := newTestWebhookEvent(wh.ID, "pending")
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	eventRepo.On("GetByID", ctx, event.ID).Return(&event, nil)
+
+	_, err := svc.ReplayEvent(ctx, testutil.TestTeamID, wh.ID, event.ID)
+
+	assert.ErrorIs(t, err, pkg.ErrValidation)
+}
+
+func TestWebhookService_ReplayEvents(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	deliverer := new(mockWebhookDeliverer)
+	svc := NewWebhookService(webhookRepo, eventRepo, deliverer, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	events := []model.WebhookEvent{
+		newTestWebhookEvent(wh.ID, "failed"),
+		newTestWebhookEvent(wh.ID, "pending"),
+		newTestWebhookEvent(wh.ID, "delivered"),
+	}
+	eventRepo.On("ListByWebhookID", ctx, wh.ID, mock.MatchedBy(func(f postgres.WebhookEventFilter) bool {
+		return f.Since != nil && f.Until != nil && f.Until.Sub(*f.Since) == 2*time.Hour && f.EventType == "email.sent"
+	}), maxReplayEvents, 0).Return(events, 3, nil)
+	deliverer.On("Redeliver", ctx, mock.AnythingOfType("*model.WebhookEvent")).Return(nil)
+
+	resp, err := svc.ReplayEvents(ctx, testutil.TestTeamID, wh.ID, &dto.ReplayWebhookEventsRequest{
+		Since:     "2026-01-01T10:00:00Z",
+		Until:     "2026-01-01T12:00:00Z",
+		EventType: "email.sent",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Replayed)
+	deliverer.AssertNumberOfCalls(t, "Redeliver", 2)
+}
+
+func TestWebhookService_ReplayEvents_Validation(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	svc := NewWebhookService(webhookRepo, eventRepo, new(mockWebhookDeliverer), nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+
+	_, err := svc.ReplayEvents(ctx, testutil.TestTeamID, wh.ID, &dto.ReplayWebhookEventsRequest{
+		Since: "2026-01-01T12:00:00Z",
+		Until: "2026-01-01T10:00:00Z",
+	})
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "since must be before until")
+
+	eventRepo.On("ListByWebhookID", ctx, wh.ID, mock.Anything, maxReplayEvents, 0).
+		Return([]model.WebhookEvent{}, maxReplayEvents+1, nil)
+	_, err = svc.ReplayEvents(ctx, testutil.TestTeamID, wh.ID, &dto.ReplayWebhookEventsRequest{
+		Since: "2026-01-01T00:00:00Z",
+		Until: "2026-02-01T00:00:00Z",
+	})
+	require.ErrorIs(t, err, pkg.ErrValidation)
+	assert.Contains(t, err.Error(), "1001 events match")
+}
+
+func TestWebhookService_Test(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	deliverer := new(mockWebhookDeliverer)
+	svc := NewWebhookService(webhookRepo, nil, deliverer, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	event := newTestWebhookEvent(wh.ID, "delivered")
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	deliverer.On("SendTest", ctx, wh, "email.bounced").Return(&event, nil)
+
+	resp, err := svc.Test(ctx, testutil.TestTeamID, wh.ID, &dto.TestWebhookRequest{EventType: "email.bounced"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", resp.Status)
+	deliverer.AssertExpectations(t)
+}
+
+func TestWebhookService_Get_Health(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	svc := NewWebhookService(webhookRepo, nil, nil, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	wh.DeliveredCount = 3
+	wh.FailedCount = 1
+	wh.ConsecutiveFailures = 1
+	wh.LastFailedAt = &testutil.FixedTime
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+
+	resp, err := svc.Get(ctx, testutil.TestTeamID, wh.ID)
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.Health.SuccessRate)
+	assert.InDelta(t, 0.75, *resp.Health.SuccessRate, 1e-9)
+	assert.Equal(t, 1, resp.Health.ConsecutiveFailures)
+	assert.Equal(t, testutil.FixedTime.Format(time.RFC3339), *resp.Health.LastFailedAt)
+	assert.Nil(t, resp.Health.LastDeliveredAt)
+	assert.Nil(t, resp.Health.DisabledAt)
+}
+
+func TestWebhookService_Enable_Backfill(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	mr := miniredis.RunT(t)
+	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = asynqClient.Close() }()
+	deliverer := new(mockWebhookDeliverer)
+	svc := NewWebhookService(webhookRepo, eventRepo, deliverer, asynqClient)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	reason := "50 consecutive failed deliveries"
+	wh.Active = false
+	wh.ConsecutiveFailures = 50
+	wh.DisabledAt = &testutil.FixedTime
+	wh.DisabledReason = &reason
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	webhookRepo.On("Update", ctx, mock.MatchedBy(func(w *model.Webhook) bool {
+		return w.Active && w.ConsecutiveFailures == 0 && w.DisabledAt == nil && w.DisabledReason == nil
+	})).Return(nil)
+
+	skipped := []model.WebhookEvent{newTestWebhookEvent(wh.ID, "skipped")}
+	filter := postgres.WebhookEventFilter{Status: "skipped"}
+	eventRepo.On("ListByWebhookID", ctx, wh.ID, filter, 1, 0).Return(skipped, 1500, nil)
+
+	resp, err := svc.Enable(ctx, testutil.TestTeamID, wh.ID, &dto.EnableWebhookRequest{Backfill: true})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Webhook.Active)
+	assert.Equal(t, 1500, resp.Backfilling)
+	webhookRepo.AssertExpectations(t)
+	// The events are delivered by the background task, not the request.
+	deliverer.AssertNotCalled(t, "Redeliver")
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(worker.QueueLow)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, worker.TaskWebhookBackfill, tasks[0].Type)
+}
+
+func TestWebhookService_Enable_WithoutBackfill(t *testing.T) {
+	webhookRepo := new(tmock.MockWebhookRepository)
+	eventRepo := new(tmock.MockWebhookEventRepository)
+	svc := NewWebhookService(webhookRepo, eventRepo, nil, nil)
+	ctx := context.Background()
+
+	wh := testutil.NewTestWebhook()
+	wh.Active = false
+	wh.DisabledAt = &testutil.FixedTime
+	webhookRepo.On("GetByID", ctx, wh.ID).Return(wh, nil)
+	webhookRepo.On("Update", ctx, mock.AnythingOfType("*model.Webhook")).Return(nil)
+
+	resp, err := svc.Enable(ctx, testutil.TestTeamID, wh.ID, &dto.EnableWebhookRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, resp.Backfilling)
+	assert.Nil(t, resp.Webhook.Health.DisabledAt)
+	eventRepo.AssertNotCalled(t, "ListByWebhookID")
+}
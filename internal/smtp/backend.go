@@ -16,7 +16,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 
+	"github.com/mailit-dev/mailit/internal/engine"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/service"
 	"github.com/mailit-dev/mailit/internal/worker"
 )
@@ -32,31 +34,52 @@ type InboundEmailStore interface {
 	Create(ctx context.Context, email *model.InboundEmail) error
 }
 
+// DMARCReportStore is the interface the SMTP backend needs for persisting
+// DMARC aggregate reports.
+type DMARCReportStore interface {
+	Create(ctx context.Context, report *model.DMARCReport) (bool, error)
+}
+
+// DMARCReportLocalPart is the local part of the address, at each domain,
+// that DMARC aggregate reports are sent to: the rua=mailto: address of the
+// domain's DMARC record.
+const DMARCReportLocalPart = "dmarc"
+
 // Backend implements the go-smtp Backend interface for receiving inbound emails.
 type Backend struct {
 	domainLookup      DomainLookup
 	inboundEmailRepo  InboundEmailStore
+	dmarcReportRepo   DMARCReportStore
 	attachmentStorage service.AttachmentStorage
 	asynqClient       *asynq.Client
 	maxMessageBytes   int64
+	returnPathSecret  string
 	logger            *slog.Logger
 }
 
-// NewBackend creates a new inbound SMTP backend.
+// NewBackend creates a new inbound SMTP backend. returnPathSecret is the
+// secret VERP return paths were signed with; bounces and feedback reports
+// addressed to them are queued for bounce processing instead of being stored
+// as inbound email. Likewise, mail to a domain's DMARCReportLocalPart
+// address is stored as a DMARC aggregate report.
 func NewBackend(
 	domainLookup DomainLookup,
 	inboundEmailRepo InboundEmailStore,
+	dmarcReportRepo DMARCReportStore,
 	attachmentStorage service.AttachmentStorage,
 	asynqClient *asynq.Client,
 	maxMessageBytes int64,
+	returnPathSecret string,
 	logger *slog.Logger,
 ) *Backend {
 	return &Backend{
 		domainLookup:      domainLookup,
 		inboundEmailRepo:  inboundEmailRepo,
+		dmarcReportRepo:   dmarcReportRepo,
 		attachmentStorage: attachmentStorage,
 		asynqClient:       asynqClient,
 		maxMessageBytes:   maxMessageBytes,
+		returnPathSecret:  returnPathSecret,
 		logger:            logger,
 	}
 }
@@ -75,7 +98,10 @@ type Session struct {
 	from    string
 	to      []string
 	domain  *model.Domain // resolved on first valid Rcpt
-	logger  *slog.Logger
+	reports []uuid.UUID   // emails whose VERP return path was a recipient
+	// dmarcDomains are the domains whose DMARC report address was a recipient.
+	dmarcDomains []*model.Domain
+	logger       *slog.Logger
 }
 
 // Mail is called with the MAIL FROM address.
@@ -86,7 +112,22 @@ func (s *Session) Mail(from string, opts *gosmtp.MailOptions) error {
 
 // Rcpt is called for each RCPT TO address.
 // It validates that the domain part of the recipient is registered and verified.
+// VERP return paths are accepted without a domain lookup when their signature
+// checks out.
 func (s *Session) Rcpt(to string, opts *gosmtp.RcptOptions) error {
+	if s.backend.returnPathSecret != "" && pkg.IsVERPAddress(to) {
+		emailID, err := pkg.ParseVERPAddress(s.backend.returnPathSecret, to)
+		if err != nil {
+			return &gosmtp.SMTPError{
+				Code:         550,
+				EnhancedCode: gosmtp.EnhancedCode{5, 1, 1},
+				Message:      "no such recipient",
+			}
+		}
+		s.reports = append(s.reports, emailID)
+		return nil
+	}
+
 	domainName, err := extractDomain(to)
 	if err != nil {
 		return &gosmtp.SMTPError{
@@ -114,6 +155,11 @@ func (s *Session) Rcpt(to string, opts *gosmtp.RcptOptions) error {
 		}
 	}
 
+	if s.backend.dmarcReportRepo != nil && strings.EqualFold(to[:strings.LastIndex(to, "@")], DMARCReportLocalPart) {
+		s.dmarcDomains = append(s.dmarcDomains, domain)
+		return nil
+	}
+
 	// Keep a reference to the first resolved domain for the team association.
 	if s.domain == nil {
 		s.domain = domain
@@ -125,7 +171,7 @@ func (s *Session) Rcpt(to string, opts *gosmtp.RcptOptions) error {
 
 // Data is called when the full message body is received.
 func (s *Session) Data(r io.Reader) error {
-	if s.domain == nil {
+	if s.domain == nil && len(s.reports) == 0 && len(s.dmarcDomains) == 0 {
 		return &gosmtp.SMTPError{
 			Code:         503,
 			EnhancedCode: gosmtp.EnhancedCode{5, 5, 1},
@@ -145,6 +191,18 @@ func (s *Session) Data(r io.Reader) error {
 		}
 	}
 
+	if len(s.dmarcDomains) > 0 {
+		if err := s.storeDMARCReport(body); err != nil {
+			return err
+		}
+	}
+	if len(s.reports) > 0 {
+		s.queueReports(body)
+	}
+	if s.domain == nil {
+		return nil
+	}
+
 	// Parse basic headers from the raw message.
 	msg, parseErr := mail.ReadMessage(bytes.NewReader(body))
 
@@ -295,6 +353,8 @@ func (s *Session) Reset() {
 	s.from = ""
 	s.to = nil
 	s.domain = nil
+	s.reports = nil
+	s.dmarcDomains = nil
 }
 
 // Logout is called when the SMTP session ends.
@@ -302,6 +362,137 @@ func (s *Session) Logout() error {
 	return nil
 }
 
+// queueReports classifies a message sent to VERP return paths as a delivery
+// status notification or an ARF feedback report and queues a bounce task for
+// each email it concerns. Reports that cannot be parsed, and DSNs reporting
+// successful delivery, are logged and dropped; the message is accepted either
+// way so the reporting MTA does not retry it.
+func (s *Session) queueReports(body []byte) {
+	info, err := engine.ClassifyReport(body)
+	if err != nil {
+		s.logger.Warn("inbound SMTP: failed to parse bounce report", "from", s.from, "error", err)
+		return
+	}
+	if info.Type == "" {
+		return
+	}
+
+	source := worker.BounceSourceDSN
+	if info.Type == engine.BounceComplaint {
+		source = worker.BounceSourceARF
+	}
+
+	for _, emailID := range s.reports {
+		task, err := worker.NewBounceReportTask(emailID, string(info.Type), info.Code, info.Message, info.Recipient, source)
+		if err != nil {
+			s.logger.Error("inbound SMTP: failed to create bounce task", "email_id", emailID, "error", err)
+			continue
+		}
+		if _, err := s.backend.asynqClient.Enqueue(task); err != nil {
+			s.logger.Error("inbound SMTP: failed to enqueue bounce task", "email_id", emailID, "error", err)
+			continue
+		}
+		s.logger.Info("inbound SMTP: bounce report queued",
+			"email_id", emailID,
+			"type", info.Type,
+			"source", source,
+			"recipient", info.Recipient,
+		)
+	}
+}
+
+// storeDMARCReport parses a message sent to DMARC report addresses and stores
+// the aggregate report for the recipient domain whose policy it covers.
+// Messages without a valid report, and reports for other domains, are logged
+// and dropped. Only a storage failure is returned, as a temporary error, so
+// that the reporter retries.
+func (s *Session) storeDMARCReport(body []byte) error {
+	parsed, err := engine.ParseDMARCReportMessage(body)
+	if err != nil {
+		s.logger.Warn("inbound SMTP: failed to parse DMARC report", "from", s.from, "error", err)
+		return nil
+	}
+
+	var domain *model.Domain
+	for _, d := range s.dmarcDomains {
+		if parsed.Domain == d.Name || strings.HasSuffix(parsed.Domain, "."+d.Name) {
+			domain = d
+			break
+		}
+	}
+	if domain == nil {
+		s.logger.Warn("inbound SMTP: DMARC report for a domain it was not sent to",
+			"from", s.from,
+			"policy_domain", parsed.Domain,
+		)
+		return nil
+	}
+
+	report := newDMARCReport(domain, parsed)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := s.backend.dmarcReportRepo.Create(ctx, report)
+	if err != nil {
+		s.logger.Error("inbound SMTP: failed to save DMARC report",
+			"domain_id", domain.ID,
+			"report_id", parsed.ReportID,
+			"error", err,
+		)
+		return &gosmtp.SMTPError{
+			Code:         451,
+			EnhancedCode: gosmtp.EnhancedCode{4, 3, 0},
+			Message:      "temporary error storing message",
+		}
+	}
+
+	s.logger.Info("inbound SMTP: DMARC report received",
+		"domain_id", domain.ID,
+		"org_name", parsed.OrgName,
+		"report_id", parsed.ReportID,
+		"records", len(parsed.Records),
+		"duplicate", !created,
+	)
+	return nil
+}
+
+// newDMARCReport converts a parsed aggregate report into the report stored
+// for domain.
+func newDMARCReport(domain *model.Domain, parsed *engine.DMARCReport) *model.DMARCReport {
+	report := &model.DMARCReport{
+		ID:            uuid.New(),
+		TeamID:        domain.TeamID,
+		DomainID:      domain.ID,
+		OrgName:       parsed.OrgName,
+		ReporterEmail: parsed.Email,
+		ReportID:      parsed.ReportID,
+		PolicyDomain:  parsed.Domain,
+		Policy:        parsed.Policy,
+		DateBegin:     parsed.DateBegin,
+		DateEnd:       parsed.DateEnd,
+		CreatedAt:     time.Now().UTC(),
+		Records:       make([]model.DMARCReportRecord, 0, len(parsed.Records)),
+	}
+	for _, r := range parsed.Records {
+		report.Records = append(report.Records, model.DMARCReportRecord{
+			ID:             uuid.New(),
+			ReportID:       report.ID,
+			SourceIP:       r.SourceIP,
+			MessageCount:   r.Count,
+			Disposition:    r.Disposition,
+			DKIMResult:     r.DKIM,
+			SPFResult:      r.SPF,
+			HeaderFrom:     r.HeaderFrom,
+			EnvelopeFrom:   r.EnvelopeFrom,
+			DKIMDomain:     r.DKIMDomain,
+			DKIMAuthResult: r.DKIMAuthResult,
+			SPFDomain:      r.SPFDomain,
+			SPFAuthResult:  r.SPFAuthResult,
+		})
+	}
+	return report
+}
+
 // parseMIMEParts walks a multipart message and extracts text/html bodies and attachments.
 func (s *Session) parseMIMEParts(body io.Reader, boundary string, teamID uuid.UUID) (htmlBody, textBody string, attachments model.JSONArray) {
 	attachments = make(model.JSONArray, 0)
@@ -0,0 +1,177 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	gosmtp "github.com/emersion/go-smtp"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/worker"
+)
+
+func TestSession_BounceReport(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	// No domain lookup or inbound store: reports must not touch either.
+	b := NewBackend(nil, nil, nil, nil, client, 1<<20, "secret", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	sess, err := b.NewSession(nil)
+	require.NoError(t, err)
+
+	emailID := uuid.New()
+	require.NoError(t, sess.Mail("", nil))
+	require.NoError(t, sess.Rcpt(pkg.VERPAddress("secret", emailID, "bounce.example.com"), nil))
+
+	dsn := strings.Join([]string{
+		"From: MAILER-DAEMON@mx.example.net",
+		"Subject: Undelivered Mail Returned to Sender",
+		`Content-Type: multipart/report; report-type=delivery-status; boundary="b"`,
+		"",
+		"--b",
+		"Content-Type: text/plain",
+		"",
+		"Delivery failed.",
+		"--b",
+		"Content-Type: message/delivery-status",
+		"",
+		"Reporting-MTA: dns; mx.example.net",
+		"",
+		"Final-Recipient: rfc822; alice@example.net",
+		"Action: failed",
+		"Status: 5.1.1",
+		"Diagnostic-Code: smtp; 550 5.1.1 user unknown",
+		"--b--",
+		"",
+	}, "\r\n")
+	require.NoError(t, sess.Data(strings.NewReader(dsn)))
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(worker.QueueDefault)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, worker.TaskBounceProcess, tasks[0].Type)
+
+	var p worker.BounceProcessPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &p))
+	assert.Equal(t, emailID, p.EmailID)
+	assert.Equal(t, "hard", p.Type)
+	assert.Equal(t, "alice@example.net", p.Recipient)
+	assert.Equal(t, worker.BounceSourceDSN, p.Source)
+}
+
+func TestSession_RejectsForgedReturnPath(t *testing.T) {
+	b := NewBackend(nil, nil, nil, nil, nil, 1<<20, "secret", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	sess, err := b.NewSession(nil)
+	require.NoError(t, err)
+
+	forged := pkg.VERPAddress("other-secret", uuid.New(), "bounce.example.com")
+	err = sess.Rcpt(forged, nil)
+	var smtpErr *gosmtp.SMTPError
+	require.ErrorAs(t, err, &smtpErr)
+	assert.Equal(t, 550, smtpErr.Code)
+
+	err = sess.Data(strings.NewReader("Subject: hi\r\n\r\nhello\r\n"))
+	require.ErrorAs(t, err, &smtpErr)
+	assert.Equal(t, 503, smtpErr.Code)
+}
+
+type stubDomainLookup map[string]*model.Domain
+
+func (l stubDomainLookup) GetVerifiedByName(_ context.Context, name string) (*model.Domain, error) {
+	if d, ok := l[name]; ok {
+		return d, nil
+	}
+	return nil, postgres.ErrNotFound
+}
+
+type stubDMARCReportStore struct {
+	reports []*model.DMARCReport
+	err     error
+}
+
+func (s *stubDMARCReportStore) Create(_ context.Context, report *model.DMARCReport) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	s.reports = append(s.reports, report)
+	return true, nil
+}
+
+const dmarcReportXML = `<?xml version="1.0"?>
+<feedback>
+  <report_metadata><org_name>example.net</org_name><report_id>r-1</report_id>
+    <date_range><begin>1760572800</begin><end>1760659199</end></date_range></report_metadata>
+  <policy_published><domain>example.com</domain><p>none</p></policy_published>
+  <record>
+    <row><source_ip>198.51.100.7</source_ip><count>3</count>
+      <policy_evaluated><disposition>none</disposition><dkim>fail</dkim><spf>fail</spf></policy_evaluated></row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+  </record>
+</feedback>`
+
+func TestSession_DMARCReport(t *testing.T) {
+	domain := &model.Domain{ID: uuid.New(), TeamID: uuid.New(), Name: "example.com"}
+	lookup := stubDomainLookup{"example.com": domain}
+	message := "From: noreply-dmarc@example.net\r\nSubject: Report domain: example.com\r\n" +
+		"Content-Type: text/xml\r\n\r\n" + dmarcReportXML + "\r\n"
+
+	t.Run("stored for the domain", func(t *testing.T) {
+		store := &stubDMARCReportStore{}
+		// No inbound store: the report must not be stored as inbound email.
+		b := NewBackend(lookup, nil, store, nil, nil, 1<<20, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+		sess, err := b.NewSession(nil)
+		require.NoError(t, err)
+
+		require.NoError(t, sess.Mail("noreply-dmarc@example.net", nil))
+		require.NoError(t, sess.Rcpt("DMARC@example.com", nil))
+		require.NoError(t, sess.Data(strings.NewReader(message)))
+
+		require.Len(t, store.reports, 1)
+		report := store.reports[0]
+		assert.Equal(t, domain.ID, report.DomainID)
+		assert.Equal(t, domain.TeamID, report.TeamID)
+		assert.Equal(t, "r-1", report.ReportID)
+		require.Len(t, report.Records, 1)
+		assert.Equal(t, "198.51.100.7", report.Records[0].SourceIP)
+		assert.Equal(t, 3, report.Records[0].MessageCount)
+	})
+
+	t.Run("report for another domain is dropped", func(t *testing.T) {
+		store := &stubDMARCReportStore{}
+		other := stubDomainLookup{"other.com": {ID: uuid.New(), TeamID: uuid.New(), Name: "other.com"}}
+		b := NewBackend(other, nil, store, nil, nil, 1<<20, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+		sess, err := b.NewSession(nil)
+		require.NoError(t, err)
+
+		require.NoError(t, sess.Rcpt("dmarc@other.com", nil))
+		require.NoError(t, sess.Data(strings.NewReader(message)))
+		assert.Empty(t, store.reports)
+	})
+
+	t.Run("storage failure is temporary", func(t *testing.T) {
+		store := &stubDMARCReportStore{err: assert.AnError}
+		b := NewBackend(lookup, nil, store, nil, nil, 1<<20, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+		sess, err := b.NewSession(nil)
+		require.NoError(t, err)
+
+		require.NoError(t, sess.Rcpt("dmarc@example.com", nil))
+		err = sess.Data(strings.NewReader(message))
+		var smtpErr *gosmtp.SMTPError
+		require.ErrorAs(t, err, &smtpErr)
+		assert.Equal(t, 451, smtpErr.Code)
+	})
+}
@@ -2,6 +2,7 @@ package smtp
 
 import (
 	"crypto/tls"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -49,3 +50,56 @@ func NewServer(cfg ServerConfig, backend *Backend, logger *slog.Logger) *gosmtp.
 
 	return s
 }
+
+// SubmissionServerConfig holds the configuration for the SMTP submission
+// listeners.
+type SubmissionServerConfig struct {
+	ListenAddr        string // STARTTLS listener, usually :587
+	TLSListenAddr     string // implicit TLS listener, usually :465 (requires TLS)
+	Domain            string
+	MaxMessageBytes   int64
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	TLSCert           string
+	TLSKey            string
+	AllowInsecureAuth bool // allow AUTH without TLS, e.g. behind a TLS-terminating proxy
+}
+
+// NewSubmissionServers creates the SMTP submission servers backed by the given
+// SubmissionBackend: one on ListenAddr offering STARTTLS, and, when a TLS
+// certificate is configured, one on TLSListenAddr speaking implicit TLS. The
+// implicit TLS server is nil when it cannot be started.
+func NewSubmissionServers(cfg SubmissionServerConfig, backend *SubmissionBackend, logger *slog.Logger) (starttls, implicitTLS *gosmtp.Server, err error) {
+	var tlsConfig *tls.Config
+	if cfg.TLSCert != "" && cfg.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading TLS certificate for SMTP submission: %w", err)
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	} else if !cfg.AllowInsecureAuth {
+		logger.Warn("SMTP submission has no TLS certificate; clients will not be able to authenticate")
+	}
+
+	newServer := func(addr string) *gosmtp.Server {
+		s := gosmtp.NewServer(backend)
+		s.Addr = addr
+		s.Domain = cfg.Domain
+		s.MaxMessageBytes = cfg.MaxMessageBytes
+		s.ReadTimeout = cfg.ReadTimeout
+		s.WriteTimeout = cfg.WriteTimeout
+		s.TLSConfig = tlsConfig
+		s.AllowInsecureAuth = cfg.AllowInsecureAuth
+		return s
+	}
+
+	starttls = newServer(cfg.ListenAddr)
+	if tlsConfig != nil && cfg.TLSListenAddr != "" {
+		implicitTLS = newServer(cfg.TLSListenAddr)
+	}
+
+	return starttls, implicitTLS, nil
+}
@@ -0,0 +1,438 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-sasl"
+	gosmtp "github.com/emersion/go-smtp"
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+	"github.com/mailit-dev/mailit/internal/pkg"
+)
+
+// APIKeyAuthenticator resolves the API key presented as the SMTP AUTH password,
+// by a client at remoteIP, to the team that owns it and the domain the key is
+// restricted to, if any. It fails for keys that may not send email from
+// remoteIP.
+type APIKeyAuthenticator func(ctx context.Context, apiKey string, remoteIP net.IP) (teamID uuid.UUID, domain string, err error)
+
+// SendLimiter counts a message submitted by teamID against the team's send
+// rate limit and reports whether it may be sent now.
+type SendLimiter func(ctx context.Context, teamID uuid.UUID) bool
+
+// EmailSender is the interface the submission backend needs to queue outbound
+// emails. It is satisfied by service.EmailService, so submitted messages go
+// through the same pipeline as POST /emails.
+type EmailSender interface {
+	Send(ctx context.Context, teamID uuid.UUID, req *dto.SendEmailRequest) (*dto.SendEmailResponse, error)
+}
+
+// SubmissionBackend implements the go-smtp Backend interface for the
+// authenticated submission listener (ports 587 and 465).
+type SubmissionBackend struct {
+	authenticate APIKeyAuthenticator
+	emailSender  EmailSender
+	allowSend    SendLimiter
+	logger       *slog.Logger
+}
+
+// NewSubmissionBackend creates a new SMTP submission backend. Submitted
+// messages count against the same send rate limit as POST /emails through
+// allowSend.
+func NewSubmissionBackend(authenticate APIKeyAuthenticator, emailSender EmailSender, allowSend SendLimiter, logger *slog.Logger) *SubmissionBackend {
+	return &SubmissionBackend{
+		authenticate: authenticate,
+		emailSender:  emailSender,
+		allowSend:    allowSend,
+		logger:       logger,
+	}
+}
+
+// NewSession is called when a new submission connection is established.
+func (b *SubmissionBackend) NewSession(c *gosmtp.Conn) (gosmtp.Session, error) {
+	var remoteIP net.IP
+	if addr, ok := c.Conn().RemoteAddr().(*net.TCPAddr); ok {
+		remoteIP = addr.IP
+	}
+	return &SubmissionSession{
+		backend:  b,
+		remoteIP: remoteIP,
+		logger:   b.logger,
+	}, nil
+}
+
+// SubmissionSession represents an SMTP session submitting outbound mail.
+// Clients must authenticate with AUTH PLAIN or LOGIN, using any username and
+// an API key as the password, before MAIL FROM is accepted.
+type SubmissionSession struct {
+	backend  *SubmissionBackend
+	remoteIP net.IP
+	teamID   uuid.UUID
+	domain   string // sending domain the API key is restricted to, if any
+	from     string
+	to       []string
+	logger   *slog.Logger
+}
+
+// AuthMechanisms returns the SASL mechanisms advertised in EHLO.
+func (s *SubmissionSession) AuthMechanisms() []string {
+	return []string{sasl.Plain, sasl.Login}
+}
+
+// Auth returns the SASL server for the requested mechanism.
+func (s *SubmissionSession) Auth(mech string) (sasl.Server, error) {
+	switch mech {
+	case sasl.Plain:
+		return sasl.NewPlainServer(func(identity, username, password string) error {
+			return s.login(password)
+		}), nil
+	case sasl.Login:
+		return &loginServer{authenticate: func(username, password string) error {
+			return s.login(password)
+		}}, nil
+	default:
+		return nil, gosmtp.ErrAuthUnknownMechanism
+	}
+}
+
+// login authenticates the session with the given API key.
+func (s *SubmissionSession) login(apiKey string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	teamID, domain, err := s.backend.authenticate(ctx, apiKey, s.remoteIP)
+	if err != nil {
+		s.logger.Warn("SMTP submission: authentication failed", "error", err)
+		return gosmtp.ErrAuthFailed
+	}
+
+	s.teamID = teamID
+	s.domain = domain
+	return nil
+}
+
+// Mail is called with the MAIL FROM address.
+func (s *SubmissionSession) Mail(from string, opts *gosmtp.MailOptions) error {
+	if s.teamID == uuid.Nil {
+		return gosmtp.ErrAuthRequired
+	}
+	s.from = from
+	return nil
+}
+
+// Rcpt is called for each RCPT TO address.
+func (s *SubmissionSession) Rcpt(to string, opts *gosmtp.RcptOptions) error {
+	if s.teamID == uuid.Nil {
+		return gosmtp.ErrAuthRequired
+	}
+	if _, err := extractDomain(to); err != nil {
+		return &gosmtp.SMTPError{
+			Code:         550,
+			EnhancedCode: gosmtp.EnhancedCode{5, 1, 3},
+			Message:      "invalid recipient address",
+		}
+	}
+	s.to = append(s.to, to)
+	return nil
+}
+
+// Data is called when the full message body is received. The message is
+// converted to a send request and queued like an API send.
+func (s *SubmissionSession) Data(r io.Reader) error {
+	if len(s.to) == 0 {
+		return &gosmtp.SMTPError{
+			Code:         503,
+			EnhancedCode: gosmtp.EnhancedCode{5, 5, 1},
+			Message:      "no valid recipients",
+		}
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		s.logger.Error("SMTP submission: failed to read message body", "error", err)
+		return &gosmtp.SMTPError{
+			Code:         451,
+			EnhancedCode: gosmtp.EnhancedCode{4, 3, 0},
+			Message:      "failed to read message",
+		}
+	}
+
+	req, err := parseSubmission(body, s.from, s.to)
+	if err != nil {
+		return &gosmtp.SMTPError{
+			Code:         554,
+			EnhancedCode: gosmtp.EnhancedCode{5, 6, 0},
+			Message:      fmt.Sprintf("malformed message: %v", err),
+		}
+	}
+
+	if s.domain != "" {
+		if fromDomain, _ := extractDomain(req.From); !strings.EqualFold(fromDomain, s.domain) {
+			return &gosmtp.SMTPError{
+				Code:         550,
+				EnhancedCode: gosmtp.EnhancedCode{5, 7, 1},
+				Message:      fmt.Sprintf("API key can only send from %s", s.domain),
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !s.backend.allowSend(ctx, s.teamID) {
+		return &gosmtp.SMTPError{
+			Code:         451,
+			EnhancedCode: gosmtp.EnhancedCode{4, 7, 0},
+			Message:      "rate limit exceeded, try again later",
+		}
+	}
+
+	resp, err := s.backend.emailSender.Send(ctx, s.teamID, req)
+	if err != nil {
+		if errors.Is(err, pkg.ErrValidation) {
+			return &gosmtp.SMTPError{
+				Code:         550,
+				EnhancedCode: gosmtp.EnhancedCode{5, 7, 1},
+				Message:      err.Error(),
+			}
+		}
+		s.logger.Error("SMTP submission: failed to queue email",
+			"error", err,
+			"team_id", s.teamID,
+			"from", req.From,
+		)
+		return &gosmtp.SMTPError{
+			Code:         451,
+			EnhancedCode: gosmtp.EnhancedCode{4, 3, 0},
+			Message:      "temporary error queueing message",
+		}
+	}
+
+	s.logger.Info("SMTP submission: email queued",
+		"email_id", resp.ID,
+		"team_id", s.teamID,
+		"from", req.From,
+		"recipients", len(s.to),
+	)
+
+	return nil
+}
+
+// Reset is called between messages in the same SMTP session. The
+// authenticated team is kept so that several messages can be sent per login.
+func (s *SubmissionSession) Reset() {
+	s.from = ""
+	s.to = nil
+}
+
+// Logout is called when the SMTP session ends.
+func (s *SubmissionSession) Logout() error {
+	return nil
+}
+
+// loginServer implements the server side of the obsolete but still widely
+// used LOGIN SASL mechanism, which go-sasl only provides as a client.
+type loginServer struct {
+	authenticate func(username, password string) error
+	username     string
+	step         int
+}
+
+// Next implements sasl.Server.
+func (a *loginServer) Next(response []byte) (challenge []byte, done bool, err error) {
+	switch a.step {
+	case 0:
+		a.step++
+		// The username may be sent as an initial response.
+		if response == nil {
+			return []byte("Username:"), false, nil
+		}
+		fallthrough
+	case 1:
+		a.step = 2
+		a.username = string(response)
+		return []byte("Password:"), false, nil
+	case 2:
+		a.step++
+		return nil, true, a.authenticate(a.username, string(response))
+	default:
+		return nil, false, sasl.ErrUnexpectedClientResponse
+	}
+}
+
+// submissionSkipHeaders lists the headers that are derived from the send
+// request or generated at delivery time, and therefore not copied as custom
+// headers.
+var submissionSkipHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Reply-To":                  true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+	"Content-Disposition":       true,
+	"Received":                  true,
+	"Return-Path":               true,
+	"Dkim-Signature":            true,
+}
+
+// parseSubmission converts a raw RFC 5322 message into a send request.
+// Envelope recipients that do not appear in the To or Cc headers are sent
+// as Bcc, and the Message-ID, when present, is used as the idempotency key so
+// that clients retrying after a dropped connection do not send twice.
+func parseSubmission(raw []byte, envelopeFrom string, envelopeTo []string) (*dto.SendEmailRequest, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	req := &dto.SendEmailRequest{
+		From: envelopeFrom,
+	}
+
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		req.From = from.Address
+	}
+
+	dec := new(mime.WordDecoder)
+	req.Subject = msg.Header.Get("Subject")
+	if decoded, err := dec.DecodeHeader(req.Subject); err == nil {
+		req.Subject = decoded
+	}
+
+	if replyTo, err := mail.ParseAddressList(msg.Header.Get("Reply-To")); err == nil && len(replyTo) > 0 {
+		req.ReplyTo = &replyTo[0].Address
+	}
+
+	listed := make(map[string]bool)
+	headerAddresses := func(key string) []string {
+		list, err := mail.ParseAddressList(msg.Header.Get(key))
+		if err != nil {
+			return nil
+		}
+		addrs := make([]string, 0, len(list))
+		for _, a := range list {
+			addrs = append(addrs, a.Address)
+			listed[strings.ToLower(a.Address)] = true
+		}
+		return addrs
+	}
+	req.To = headerAddresses("To")
+	req.Cc = headerAddresses("Cc")
+
+	if len(req.To) == 0 {
+		// Without a To header, deliver to the envelope recipients directly.
+		req.To = envelopeTo
+	} else {
+		for _, rcpt := range envelopeTo {
+			if !listed[strings.ToLower(rcpt)] {
+				req.Bcc = append(req.Bcc, rcpt)
+			}
+		}
+	}
+
+	if messageID := strings.Trim(msg.Header.Get("Message-ID"), "<> "); messageID != "" {
+		req.IdempotencyKey = &messageID
+	}
+
+	for key, values := range msg.Header {
+		if submissionSkipHeaders[key] || len(values) == 0 {
+			continue
+		}
+		if req.Headers == nil {
+			req.Headers = make(map[string]string)
+		}
+		req.Headers[key] = values[len(values)-1]
+	}
+
+	if err := parseSubmissionPart(textproto.MIMEHeader(msg.Header), msg.Body, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// parseSubmissionPart fills the body and attachments of req from a MIME
+// entity, recursing into multipart containers.
+func parseSubmissionPart(header textproto.MIMEHeader, body io.Reader, req *dto.SendEmailRequest) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := parseSubmissionPart(part.Header, part, req); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if disposition == "attachment" || filename != "" || !isText {
+		if filename == "" {
+			filename = "attachment"
+		}
+		req.Attachments = append(req.Attachments, dto.Attachment{
+			Filename:    filename,
+			Content:     base64.StdEncoding.EncodeToString(content),
+			ContentType: mediaType,
+		})
+		return nil
+	}
+
+	text := string(content)
+	switch {
+	case mediaType == "text/html" && req.HTML == nil:
+		req.HTML = &text
+	case mediaType == "text/plain" && req.Text == nil:
+		req.Text = &text
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	gosmtp "github.com/emersion/go-smtp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/dto"
+)
+
+func TestParseSubmission_Multipart(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: App <app@example.com>",
+		"To: Alice <alice@example.org>",
+		"Cc: bob@example.org",
+		"Reply-To: support@example.com",
+		"Subject: =?UTF-8?Q?Caf=C3=A9_order?=",
+		"Message-ID: <abc123@example.com>",
+		"X-Order-Id: 42",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Caf=C3=A9",
+		"--inner",
+		"Content-Type: text/html; charset=utf-8",
+		"",
+		"<p>Hi</p>",
+		"--inner--",
+		"--outer",
+		`Content-Type: application/pdf; name="invoice.pdf"`,
+		"Content-Disposition: attachment; filename=\"invoice.pdf\"",
+		"Content-Transfer-Encoding: base64",
+		"",
+		base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")),
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	req, err := parseSubmission([]byte(raw), "bounce@example.com",
+		[]string{"alice@example.org", "bob@example.org", "audit@example.net"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "app@example.com", req.From)
+	assert.Equal(t, []string{"alice@example.org"}, req.To)
+	assert.Equal(t, []string{"bob@example.org"}, req.Cc)
+	assert.Equal(t, []string{"audit@example.net"}, req.Bcc)
+	require.NotNil(t, req.ReplyTo)
+	assert.Equal(t, "support@example.com", *req.ReplyTo)
+	assert.Equal(t, "Café order", req.Subject)
+	require.NotNil(t, req.IdempotencyKey)
+	assert.Equal(t, "abc123@example.com", *req.IdempotencyKey)
+	assert.Equal(t, map[string]string{"X-Order-Id": "42"}, req.Headers)
+
+	require.NotNil(t, req.Text)
+	assert.Equal(t, "Café", *req.Text)
+	require.NotNil(t, req.HTML)
+	assert.Equal(t, "<p>Hi</p>", *req.HTML)
+
+	require.Len(t, req.Attachments, 1)
+	assert.Equal(t, "invoice.pdf", req.Attachments[0].Filename)
+	assert.Equal(t, "application/pdf", req.Attachments[0].ContentType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")), req.Attachments[0].Content)
+}
+
+func TestParseSubmission_EnvelopeFallback(t *testing.T) {
+	raw := "Subject: Ping\r\n\r\nhello\r\n"
+
+	req, err := parseSubmission([]byte(raw), "app@example.com", []string{"ops@example.org"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "app@example.com", req.From)
+	assert.Equal(t, []string{"ops@example.org"}, req.To)
+	assert.Empty(t, req.Bcc)
+	assert.Nil(t, req.IdempotencyKey)
+	require.NotNil(t, req.Text)
+	assert.Equal(t, "hello\r\n", *req.Text)
+}
+
+func TestLoginServer(t *testing.T) {
+	var gotUser, gotPass string
+	auth := func(username, password string) error {
+		gotUser, gotPass = username, password
+		return nil
+	}
+
+	t.Run("challenge for username", func(t *testing.T) {
+		s := &loginServer{authenticate: auth}
+
+		challenge, done, err := s.Next(nil)
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.Equal(t, "Username:", string(challenge))
+
+		challenge, done, err = s.Next([]byte("resend"))
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.Equal(t, "Password:", string(challenge))
+
+		_, done, err = s.Next([]byte("re_secret"))
+		require.NoError(t, err)
+		assert.True(t, done)
+		assert.Equal(t, "resend", gotUser)
+		assert.Equal(t, "re_secret", gotPass)
+	})
+
+	t.Run("username as initial response", func(t *testing.T) {
+		s := &loginServer{authenticate: auth}
+
+		challenge, done, err := s.Next([]byte("apikey"))
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.Equal(t, "Password:", string(challenge))
+
+		_, done, err = s.Next([]byte("re_other"))
+		require.NoError(t, err)
+		assert.True(t, done)
+		assert.Equal(t, "apikey", gotUser)
+		assert.Equal(t, "re_other", gotPass)
+	})
+}
+
+type recordingSender struct{ sent []*dto.SendEmailRequest }
+
+func (r *recordingSender) Send(_ context.Context, _ uuid.UUID, req *dto.SendEmailRequest) (*dto.SendEmailResponse, error) {
+	r.sent = append(r.sent, req)
+	return &dto.SendEmailResponse{ID: uuid.New().String()}, nil
+}
+
+func TestSubmissionSession_Data_SendLimit(t *testing.T) {
+	teamID := uuid.New()
+	allowed := 1
+	limiter := func(_ context.Context, id uuid.UUID) bool {
+		assert.Equal(t, teamID, id)
+		allowed--
+		return allowed >= 0
+	}
+	sender := &recordingSender{}
+	backend := NewSubmissionBackend(nil, sender, limiter, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s := &SubmissionSession{backend: backend, teamID: teamID, logger: backend.logger}
+
+	msg := "From: app@example.com\r\nTo: user@example.org\r\nSubject: Hi\r\n\r\nHello\r\n"
+	send := func() error {
+		s.Reset()
+		require.NoError(t, s.Mail("app@example.com", nil))
+		require.NoError(t, s.Rcpt("user@example.org", nil))
+		return s.Data(strings.NewReader(msg))
+	}
+
+	require.NoError(t, send())
+
+	err := send()
+	var smtpErr *gosmtp.SMTPError
+	require.ErrorAs(t, err, &smtpErr)
+	assert.Equal(t, 451, smtpErr.Code)
+	assert.Len(t, sender.sent, 1, "a message over the limit is not queued")
+}
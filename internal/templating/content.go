@@ -0,0 +1,80 @@
+package templating
+
+import "fmt"
+
+// Names the broadcast sender provides to templates in addition to their
+// declared variables.
+const (
+	VarContact        = "contact"
+	VarUnsubscribeURL = "unsubscribe_url"
+)
+
+// Builtins returns the names templates may reference without declaring them.
+func Builtins() []string {
+	return []string{VarContact, VarUnsubscribeURL}
+}
+
+// Content holds the parsed subject, HTML and text parts of an email. Parts
+// that were not provided are nil.
+type Content struct {
+	Subject *Template
+	HTML    *Template
+	Text    *Template
+}
+
+// ParseContent parses the subject and text parts as text templates and the
+// HTML part as an HTML template. Errors name the part they occurred in.
+func ParseContent(subject, htmlBody, textBody *string) (*Content, error) {
+	c := &Content{}
+	var err error
+	if subject != nil {
+		if c.Subject, err = ParseText(*subject); err != nil {
+			return nil, fmt.Errorf("subject: %w", err)
+		}
+	}
+	if htmlBody != nil {
+		if c.HTML, err = ParseHTML(*htmlBody); err != nil {
+			return nil, fmt.Errorf("html: %w", err)
+		}
+	}
+	if textBody != nil {
+		if c.Text, err = ParseText(*textBody); err != nil {
+			return nil, fmt.Errorf("text: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Render renders every part against data. Missing parts render as nil.
+func (c *Content) Render(data map[string]interface{}) (subject, htmlBody, textBody *string) {
+	render := func(t *Template) *string {
+		if t == nil {
+			return nil
+		}
+		out := t.Render(data)
+		return &out
+	}
+	return render(c.Subject), render(c.HTML), render(c.Text)
+}
+
+// CheckReferences reports the names the content references that are neither
+// declared nor builtins.
+func (c *Content) CheckReferences(decls []Variable) error {
+	return CheckReferences(decls, Builtins(), c.Subject, c.HTML, c.Text)
+}
+
+// References reports whether any part of the content references the root
+// name.
+func (c *Content) References(name string) bool {
+	for _, t := range []*Template{c.Subject, c.HTML, c.Text} {
+		if t == nil {
+			continue
+		}
+		for _, ref := range t.Variables() {
+			if ref == name {
+				return true
+			}
+		}
+	}
+	return false
+}
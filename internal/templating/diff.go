@@ -0,0 +1,80 @@
+package templating
+
+import "strings"
+
+// Diff operations.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is one line of a line-based diff.
+type DiffLine struct {
+	Op   string
+	Text string
+}
+
+// DiffLines returns a line-based diff turning a into b. Lines common to both
+// are reported as equal; deletions are listed before insertions where lines
+// were changed.
+func DiffLines(a, b string) []DiffLine {
+	from, to := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of from[i:]
+	// and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, max(len(from), len(to)))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: from[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: to[j]})
+	}
+	return diff
+}
+
+// Changed reports whether a diff contains any insertions or deletions.
+func Changed(diff []DiffLine) bool {
+	for _, l := range diff {
+		if l.Op != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,291 @@
+package templating
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterArgs lists the supported filters and whether each takes an argument.
+var filterArgs = map[string]bool{
+	"default": true,
+	"upper":   false,
+	"lower":   false,
+}
+
+type frame struct {
+	block  *blockNode
+	inElse bool
+	line   int
+}
+
+type parser struct {
+	src   string
+	root  []node
+	stack []*frame
+	refs  []string
+	seen  map[string]bool
+}
+
+func parse(src string, escape bool) (*Template, error) {
+	p := &parser{src: src, seen: make(map[string]bool)}
+
+	pos := 0
+	for {
+		start := strings.Index(src[pos:], "{{")
+		if start < 0 {
+			p.emit(textNode(src[pos:]))
+			break
+		}
+		start += pos
+		if start > pos {
+			p.emit(textNode(src[pos:start]))
+		}
+
+		raw := strings.HasPrefix(src[start:], "{{{")
+		open, closing := "{{", "}}"
+		if raw {
+			open, closing = "{{{", "}}}"
+		}
+		end := strings.Index(src[start+len(open):], closing)
+		if end < 0 {
+			return nil, p.errorf(start, "unclosed %s", open)
+		}
+		end += start + len(open)
+
+		if err := p.tag(start, strings.TrimSpace(src[start+len(open):end]), raw); err != nil {
+			return nil, err
+		}
+		pos = end + len(closing)
+	}
+
+	if len(p.stack) > 0 {
+		top := p.stack[len(p.stack)-1]
+		return nil, fmt.Errorf("line %d: unclosed {{#%s}}", top.line, top.block.kind)
+	}
+
+	return &Template{nodes: p.root, escape: escape, refs: p.refs}, nil
+}
+
+// tag handles the content of a single {{ }} tag found at offset pos.
+func (p *parser) tag(pos int, content string, raw bool) error {
+	if raw {
+		e, err := p.expr(pos, content)
+		if err != nil {
+			return err
+		}
+		p.emit(&outputNode{expr: e, raw: true})
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(content, "!"):
+		return nil
+
+	case strings.HasPrefix(content, "#"):
+		kind, arg, _ := strings.Cut(content[1:], " ")
+		if kind != "if" && kind != "unless" && kind != "each" {
+			return p.errorf(pos, "unknown block {{#%s}}", kind)
+		}
+		if strings.TrimSpace(arg) == "" {
+			return p.errorf(pos, "{{#%s}} needs an argument", kind)
+		}
+		e, err := p.expr(pos, arg)
+		if err != nil {
+			return err
+		}
+		block := &blockNode{kind: kind, expr: e}
+		p.emit(block)
+		p.stack = append(p.stack, &frame{block: block, line: p.line(pos)})
+		return nil
+
+	case content == "else":
+		if len(p.stack) == 0 {
+			return p.errorf(pos, "{{else}} outside a block")
+		}
+		top := p.stack[len(p.stack)-1]
+		if top.inElse {
+			return p.errorf(pos, "duplicate {{else}} in {{#%s}}", top.block.kind)
+		}
+		top.inElse = true
+		return nil
+
+	case strings.HasPrefix(content, "/"):
+		kind := strings.TrimSpace(content[1:])
+		if len(p.stack) == 0 {
+			return p.errorf(pos, "unexpected {{/%s}}", kind)
+		}
+		top := p.stack[len(p.stack)-1]
+		if top.block.kind != kind {
+			return p.errorf(pos, "{{/%s}} does not close {{#%s}} from line %d", kind, top.block.kind, top.line)
+		}
+		p.stack = p.stack[:len(p.stack)-1]
+		return nil
+
+	default:
+		e, err := p.expr(pos, content)
+		if err != nil {
+			return err
+		}
+		p.emit(&outputNode{expr: e})
+		return nil
+	}
+}
+
+// emit appends a node to the innermost open block, or the template root.
+func (p *parser) emit(n node) {
+	if t, ok := n.(textNode); ok && t == "" {
+		return
+	}
+	if len(p.stack) == 0 {
+		p.root = append(p.root, n)
+		return
+	}
+	top := p.stack[len(p.stack)-1]
+	if top.inElse {
+		top.block.elseBody = append(top.block.elseBody, n)
+	} else {
+		top.block.body = append(top.block.body, n)
+	}
+}
+
+// expr parses "operand | filter arg | filter".
+func (p *parser) expr(pos int, s string) (expr, error) {
+	parts, err := splitPipes(s)
+	if err != nil {
+		return expr{}, p.errorf(pos, "%v", err)
+	}
+
+	e, err := p.operand(pos, parts[0])
+	if err != nil {
+		return expr{}, err
+	}
+
+	for _, part := range parts[1:] {
+		name, arg, _ := strings.Cut(part, " ")
+		arg = strings.TrimSpace(arg)
+		wantsArg, ok := filterArgs[name]
+		if !ok {
+			return expr{}, p.errorf(pos, "unknown filter %q", name)
+		}
+		f := filter{name: name}
+		switch {
+		case wantsArg && arg == "":
+			return expr{}, p.errorf(pos, "filter %q needs an argument", name)
+		case !wantsArg && arg != "":
+			return expr{}, p.errorf(pos, "filter %q takes no argument", name)
+		case wantsArg:
+			argExpr, err := p.operand(pos, arg)
+			if err != nil {
+				return expr{}, err
+			}
+			f.arg = &argExpr
+		}
+		e.filters = append(e.filters, f)
+	}
+	return e, nil
+}
+
+// operand parses a literal or a dotted variable path.
+func (p *parser) operand(pos int, s string) (expr, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return expr{}, p.errorf(pos, "empty expression")
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return expr{}, p.errorf(pos, "invalid string %s", s)
+		}
+		return expr{literal: v, isLit: true}, nil
+	case s == "true" || s == "false":
+		return expr{literal: s == "true", isLit: true}, nil
+	case s[0] == '-' || (s[0] >= '0' && s[0] <= '9'):
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return expr{}, p.errorf(pos, "invalid number %s", s)
+		}
+		return expr{literal: v, isLit: true}, nil
+	}
+
+	path := strings.Split(s, ".")
+	for i, seg := range path {
+		if !validName(seg) {
+			return expr{}, p.errorf(pos, "invalid variable %q", s)
+		}
+		if strings.HasPrefix(seg, "@") && (i > 0 || len(path) > 1 || (seg != "@index" && seg != "@first" && seg != "@last")) {
+			return expr{}, p.errorf(pos, "invalid variable %q", s)
+		}
+	}
+	if path[0] == "this" || strings.HasPrefix(path[0], "@") {
+		if !p.inEach() {
+			return expr{}, p.errorf(pos, "%s used outside {{#each}}", path[0])
+		}
+	} else if !p.seen[path[0]] {
+		p.seen[path[0]] = true
+		p.refs = append(p.refs, path[0])
+	}
+	return expr{path: path}, nil
+}
+
+func (p *parser) inEach() bool {
+	for _, f := range p.stack {
+		if f.block.kind == "each" && !f.inElse {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) line(pos int) int {
+	return strings.Count(p.src[:pos], "\n") + 1
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line(pos), fmt.Sprintf(format, args...))
+}
+
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '@' && i == 0:
+		case c == '_' || c == '-':
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// splitPipes splits an expression on | characters outside string literals.
+func splitPipes(s string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && inString && i+1 < len(s):
+			current.WriteByte(c)
+			i++
+			current.WriteByte(s[i])
+			continue
+		case c == '"':
+			inString = !inString
+		case c == '|' && !inString:
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	if inString {
+		return nil, fmt.Errorf("unterminated string in %q", s)
+	}
+	parts = append(parts, strings.TrimSpace(current.String()))
+	return parts, nil
+}
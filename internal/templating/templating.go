@@ -0,0 +1,240 @@
+// Package templating implements the template language used to personalise
+// email and broadcast content.
+//
+// The syntax is a small, Handlebars-like subset:
+//
+//	{{name}}                       output a value (HTML-escaped in HTML templates)
+//	{{{name}}}                     output a value without escaping
+//	{{contact.first_name}}         dotted paths reach into objects
+//	{{name | default "friend"}}    filters: default, upper, lower
+//	{{#if cond}}...{{else}}...{{/if}}
+//	{{#unless cond}}...{{/unless}}
+//	{{#each items}}{{this.name}}{{@index}}{{/each}}
+//	{{! comment }}
+//
+// Inside an each block, the current item is available as this (or this.field)
+// along with @index, @first and @last. Every other name resolves from the
+// root data, so the names a template references can be checked up front.
+package templating
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Template is a parsed template.
+type Template struct {
+	nodes  []node
+	escape bool
+	refs   []string
+}
+
+// ParseHTML parses an HTML template. Values written with {{ }} are
+// HTML-escaped; use {{{ }}} to write trusted markup.
+func ParseHTML(src string) (*Template, error) {
+	return parse(src, true)
+}
+
+// ParseText parses a plain-text template, such as a subject line. Values are
+// written as-is.
+func ParseText(src string) (*Template, error) {
+	return parse(src, false)
+}
+
+// Variables returns the root names the template references, in order of
+// first use. For {{contact.first_name}} the root name is "contact".
+func (t *Template) Variables() []string {
+	return t.refs
+}
+
+// Render executes the template against data. Missing values render as empty
+// strings.
+func (t *Template) Render(data map[string]interface{}) string {
+	var b strings.Builder
+	s := &scope{root: data}
+	for _, n := range t.nodes {
+		n.render(&b, s, t.escape)
+	}
+	return b.String()
+}
+
+// --- AST ---
+
+type node interface {
+	render(b *strings.Builder, s *scope, escape bool)
+}
+
+type textNode string
+
+func (n textNode) render(b *strings.Builder, _ *scope, _ bool) {
+	b.WriteString(string(n))
+}
+
+type outputNode struct {
+	expr expr
+	raw  bool
+}
+
+func (n *outputNode) render(b *strings.Builder, s *scope, escape bool) {
+	out := format(n.expr.eval(s))
+	if escape && !n.raw {
+		out = html.EscapeString(out)
+	}
+	b.WriteString(out)
+}
+
+type blockNode struct {
+	kind     string // if, unless, each
+	expr     expr
+	body     []node
+	elseBody []node
+}
+
+func (n *blockNode) render(b *strings.Builder, s *scope, escape bool) {
+	value := n.expr.eval(s)
+
+	switch n.kind {
+	case "if", "unless":
+		branch := n.body
+		if truthy(value) == (n.kind == "unless") {
+			branch = n.elseBody
+		}
+		renderNodes(b, branch, s, escape)
+	case "each":
+		items, _ := value.([]interface{})
+		if len(items) == 0 {
+			renderNodes(b, n.elseBody, s, escape)
+			return
+		}
+		for i, item := range items {
+			inner := &scope{root: s.root, parent: s, this: item, index: i, last: i == len(items)-1}
+			renderNodes(b, n.body, inner, escape)
+		}
+	}
+}
+
+func renderNodes(b *strings.Builder, nodes []node, s *scope, escape bool) {
+	for _, n := range nodes {
+		n.render(b, s, escape)
+	}
+}
+
+// scope is the lookup context while rendering. Each nested each block adds a
+// scope whose item is reachable as this.
+type scope struct {
+	root   map[string]interface{}
+	parent *scope
+	this   interface{}
+	index  int
+	last   bool
+}
+
+// --- Expressions ---
+
+type expr struct {
+	path    []string
+	literal interface{}
+	isLit   bool
+	filters []filter
+}
+
+type filter struct {
+	name string
+	arg  *expr
+}
+
+func (e *expr) eval(s *scope) interface{} {
+	var v interface{}
+	if e.isLit {
+		v = e.literal
+	} else {
+		v = lookup(s, e.path)
+	}
+
+	for _, f := range e.filters {
+		switch f.name {
+		case "default":
+			if !truthy(v) {
+				v = f.arg.eval(s)
+			}
+		case "upper":
+			v = strings.ToUpper(format(v))
+		case "lower":
+			v = strings.ToLower(format(v))
+		}
+	}
+	return v
+}
+
+func lookup(s *scope, path []string) interface{} {
+	var v interface{}
+	switch path[0] {
+	case "this":
+		v = s.this
+	case "@index":
+		return float64(s.index)
+	case "@first":
+		return s.parent != nil && s.index == 0
+	case "@last":
+		return s.parent != nil && s.last
+	default:
+		v = s.root[path[0]]
+	}
+
+	for _, key := range path[1:] {
+		switch current := v.(type) {
+		case map[string]interface{}:
+			v = current[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(current) {
+				return nil
+			}
+			v = current[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// truthy reports whether a value counts as true in conditionals: nil, false,
+// zero, empty strings and empty collections are false.
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case float64:
+		return val != 0
+	case int:
+		return val != 0
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	default:
+		return true
+	}
+}
+
+// format converts a value to its output text.
+func format(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
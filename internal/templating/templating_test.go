@@ -0,0 +1,186 @@
+package templating
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]interface{}{
+		"contact": map[string]interface{}{
+			"first_name": "Ada",
+			"last_name":  nil,
+			"vip":        true,
+			"plan":       "pro",
+		},
+		"items": []interface{}{
+			map[string]interface{}{"name": "Widget", "qty": float64(2)},
+			map[string]interface{}{"name": "Gadget", "qty": 1.5},
+		},
+		"note": "<b>hi</b>",
+		"zero": float64(0),
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"plain text", "Hello", "Hello"},
+		{"variable", "Hi {{contact.first_name}}!", "Hi Ada!"},
+		{"spaces in tag", "Hi {{ contact.first_name }}", "Hi Ada"},
+		{"missing value", "[{{contact.last_name}}][{{nope.deep}}]", "[][]"},
+		{"default", `{{contact.last_name | default "Lovelace"}}`, "Lovelace"},
+		{"default not used", `{{contact.first_name | default "there"}}`, "Ada"},
+		{"filters chain", `{{contact.plan | upper}} {{contact.last_name | default "X" | lower}}`, "PRO x"},
+		{"escaped", "{{note}}", "&lt;b&gt;hi&lt;/b&gt;"},
+		{"raw", "{{{note}}}", "<b>hi</b>"},
+		{"if", "{{#if contact.vip}}VIP{{/if}}", "VIP"},
+		{"if else", "{{#if zero}}yes{{else}}no{{/if}}", "no"},
+		{"unless", "{{#unless contact.last_name}}anonymous{{/unless}}", "anonymous"},
+		{"each", "{{#each items}}{{@index}}:{{this.name}}x{{this.qty}}{{#unless @last}}, {{/unless}}{{/each}}", "0:Widgetx2, 1:Gadgetx1.5"},
+		{"each else", "{{#each missing}}item{{else}}none{{/each}}", "none"},
+		{"each reads root", "{{#each items}}{{contact.first_name}}{{/each}}", "AdaAda"},
+		{"nested blocks", "{{#each items}}{{#if @first}}first{{else}}rest{{/if}};{{/each}}", "first;rest;"},
+		{"index path", "{{items.1.name}}", "Gadget"},
+		{"comment", "a{{! ignored }}b", "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseHTML(tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tmpl.Render(data))
+		})
+	}
+}
+
+func TestParseText_DoesNotEscape(t *testing.T) {
+	tmpl, err := ParseText("Re: {{subject}}")
+	require.NoError(t, err)
+	assert.Equal(t, "Re: Tom & Jerry", tmpl.Render(map[string]interface{}{"subject": "Tom & Jerry"}))
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{"Hello {{name", "line 1: unclosed {{"},
+		{"{{#if a}}\nyes", "line 1: unclosed {{#if}}"},
+		{"{{#if a}}\n{{/each}}", "line 2: {{/each}} does not close {{#if}} from line 1"},
+		{"{{/if}}", "unexpected {{/if}}"},
+		{"{{else}}", "{{else}} outside a block"},
+		{"{{#with a}}{{/with}}", "unknown block {{#with}}"},
+		{"{{#if}}{{/if}}", "{{#if}} needs an argument"},
+		{"{{name | shout}}", `unknown filter "shout"`},
+		{"{{name | default}}", `filter "default" needs an argument`},
+		{`{{name | default "x}}`, "unterminated string"},
+		{"{{first name}}", `invalid variable "first name"`},
+		{"{{this.name}}", "this used outside {{#each}}"},
+		{"{{}}", "empty expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := ParseHTML(tt.src)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestTemplate_Variables(t *testing.T) {
+	tmpl, err := ParseHTML(`{{contact.first_name}} {{#each items}}{{this.name}}{{/each}} {{order_id | default fallback}} {{contact.email}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"contact", "items", "order_id", "fallback"}, tmpl.Variables())
+}
+
+func TestCheckVariables(t *testing.T) {
+	decls := []Variable{
+		{Name: "order_id", Type: TypeString, Required: true},
+		{Name: "total", Type: TypeNumber},
+		{Name: "greeting", Type: TypeString, Default: "Hello"},
+		{Name: "items", Type: TypeArray},
+	}
+
+	data, err := CheckVariables(decls, map[string]interface{}{"order_id": "A-1", "total": 9.5})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"order_id": "A-1", "total": 9.5, "greeting": "Hello", "items": nil}, data)
+
+	_, err = CheckVariables(decls, map[string]interface{}{"total": "9.50", "items": []interface{}{}, "ordr_id": "A-1"})
+	require.Error(t, err)
+	assert.Equal(t, "order_id is required; total must be a number; ordr_id is not a declared variable", err.Error())
+}
+
+func TestCheckReferences(t *testing.T) {
+	subject, err := ParseText("Order {{order_id}}")
+	require.NoError(t, err)
+	body, err := ParseHTML("Hi {{contact.first_name}}, total {{totl}}")
+	require.NoError(t, err)
+
+	decls := []Variable{{Name: "order_id"}, {Name: "total"}}
+	err = CheckReferences(decls, []string{"contact"}, subject, body, nil)
+	require.Error(t, err)
+	assert.Equal(t, "undeclared variables: totl", err.Error())
+
+	assert.NoError(t, CheckReferences(decls, []string{"contact"}, subject))
+}
+
+func TestCheckDeclarations(t *testing.T) {
+	assert.NoError(t, CheckDeclarations([]Variable{{Name: "order_id", Type: TypeString}, {Name: "count", Type: TypeNumber, Default: float64(1)}}, []string{"contact"}))
+
+	tests := []struct {
+		name    string
+		decls   []Variable
+		wantErr string
+	}{
+		{"invalid name", []Variable{{Name: "order id"}}, `invalid variable name "order id"`},
+		{"duplicate", []Variable{{Name: "a"}, {Name: "a"}}, `variable "a" is declared twice or reserved`},
+		{"reserved", []Variable{{Name: "contact"}}, `variable "contact" is declared twice or reserved`},
+		{"unknown type", []Variable{{Name: "a", Type: "date"}}, `variable a has unknown type "date"`},
+		{"bad default", []Variable{{Name: "a", Type: TypeNumber, Default: "one"}}, "default of variable a must be a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDeclarations(tt.decls, []string{"contact"})
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func TestParseContent(t *testing.T) {
+	subject, html := "Hi {{name}} & co", "<p>{{name}}</p>"
+	content, err := ParseContent(&subject, &html, nil)
+	require.NoError(t, err)
+
+	gotSubject, gotHTML, gotText := content.Render(map[string]interface{}{"name": "<Ada>"})
+	assert.Equal(t, "Hi <Ada> & co", *gotSubject)
+	assert.Equal(t, "<p>&lt;Ada&gt;</p>", *gotHTML)
+	assert.Nil(t, gotText)
+
+	assert.NoError(t, content.CheckReferences([]Variable{{Name: "name"}}))
+	assert.EqualError(t, content.CheckReferences(nil), "undeclared variables: name")
+
+	bad := "{{#if x}}"
+	_, err = ParseContent(nil, nil, &bad)
+	assert.EqualError(t, err, "text: line 1: unclosed {{#if}}")
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines("<p>Hi</p>\n<p>{{name}}</p>\n<p>Bye</p>\n", "<p>Hi</p>\n<p>{{name | upper}}</p>\n<p>Bye</p>\n<p>PS</p>")
+	assert.Equal(t, []DiffLine{
+		{Op: DiffEqual, Text: "<p>Hi</p>"},
+		{Op: DiffDelete, Text: "<p>{{name}}</p>"},
+		{Op: DiffInsert, Text: "<p>{{name | upper}}</p>"},
+		{Op: DiffEqual, Text: "<p>Bye</p>"},
+		{Op: DiffInsert, Text: "<p>PS</p>"},
+	}, diff)
+	assert.True(t, Changed(diff))
+
+	assert.False(t, Changed(DiffLines("a\nb", "a\nb\n")))
+	assert.Equal(t, []DiffLine{{Op: DiffDelete, Text: "a"}}, DiffLines("a", ""))
+}
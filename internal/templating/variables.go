@@ -0,0 +1,175 @@
+package templating
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Variable types a template can declare. An empty type accepts any value.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+// Variable declares a variable a template expects its caller to provide.
+type Variable struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type,omitempty"`
+	Required bool        `json:"required,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
+}
+
+// VariablesFromJSON decodes variable declarations from their JSON-decoded
+// form, as stored on template versions. Malformed entries are skipped.
+func VariablesFromJSON(raw []interface{}) []Variable {
+	variables := make([]Variable, 0, len(raw))
+	for _, entry := range raw {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		if name == "" {
+			continue
+		}
+		v := Variable{Name: name, Default: m["default"]}
+		v.Type, _ = m["type"].(string)
+		v.Required, _ = m["required"].(bool)
+		variables = append(variables, v)
+	}
+	return variables
+}
+
+// CheckDeclarations validates variable declarations: names must be unique,
+// usable in templates and not among reserved, types must be known and
+// defaults must have the declared type.
+func CheckDeclarations(decls []Variable, reserved []string) error {
+	seen := make(map[string]bool, len(decls))
+	for _, name := range reserved {
+		seen[name] = true
+	}
+
+	for _, d := range decls {
+		if !validName(d.Name) || d.Name == "this" || strings.HasPrefix(d.Name, "@") {
+			return fmt.Errorf("invalid variable name %q", d.Name)
+		}
+		if seen[d.Name] {
+			return fmt.Errorf("variable %q is declared twice or reserved", d.Name)
+		}
+		seen[d.Name] = true
+
+		if !ValidType(d.Type) {
+			return fmt.Errorf("variable %s has unknown type %q", d.Name, d.Type)
+		}
+		if d.Default != nil && !hasType(d.Default, d.Type) {
+			return fmt.Errorf("default of variable %s must be a %s", d.Name, d.Type)
+		}
+	}
+	return nil
+}
+
+// CheckVariables validates values against the declarations and returns the
+// data to render with, with defaults filled in for omitted variables. Values
+// are expected in their JSON-decoded form. Undeclared values, missing
+// required values and values of the wrong type are all reported together.
+func CheckVariables(decls []Variable, values map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(decls))
+	declared := make(map[string]bool, len(decls))
+	var problems []string
+
+	for _, d := range decls {
+		declared[d.Name] = true
+		v, ok := values[d.Name]
+		if !ok || v == nil {
+			if d.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", d.Name))
+			}
+			data[d.Name] = d.Default
+			continue
+		}
+		if !hasType(v, d.Type) {
+			problems = append(problems, fmt.Sprintf("%s must be a %s", d.Name, d.Type))
+			continue
+		}
+		data[d.Name] = v
+	}
+
+	var undeclared []string
+	for name := range values {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		problems = append(problems, fmt.Sprintf("%s is not a declared variable", name))
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return data, nil
+}
+
+// CheckReferences reports the names the templates reference that are
+// neither declared nor in builtins.
+func CheckReferences(decls []Variable, builtins []string, templates ...*Template) error {
+	known := make(map[string]bool, len(decls)+len(builtins))
+	for _, d := range decls {
+		known[d.Name] = true
+	}
+	for _, name := range builtins {
+		known[name] = true
+	}
+
+	var missing []string
+	for _, t := range templates {
+		if t == nil {
+			continue
+		}
+		for _, name := range t.Variables() {
+			if !known[name] {
+				known[name] = true
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("undeclared variables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ValidType reports whether t is a variable type templates can declare.
+func ValidType(t string) bool {
+	switch t {
+	case "", TypeString, TypeNumber, TypeBoolean, TypeArray, TypeObject:
+		return true
+	}
+	return false
+}
+
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeNumber:
+		_, ok := v.(float64)
+		return ok
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case TypeArray:
+		_, ok := v.([]interface{})
+		return ok
+	case TypeObject:
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
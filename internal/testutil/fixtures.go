@@ -55,6 +55,7 @@ func NewTestDomain() *model.Domain {
 		Status:         model.DomainStatusPending,
 		DKIMPrivateKey: &privKey,
 		DKIMSelector:   "mailit",
+		DKIMAlgorithm:  "rsa",
 		OpenTracking:   false,
 		ClickTracking:  false,
 		TLSPolicy:      "opportunistic",
@@ -143,7 +144,7 @@ func NewTestTemplateVersion(templateID uuid.UUID) *model.TemplateVersion {
 		Subject:    &subject,
 		HTMLBody:   &html,
 		TextBody:   &text,
-		Variables:  model.JSONArray{},
+		Variables:  model.JSONArray{map[string]interface{}{"name": "name", "type": "string"}},
 		Published:  false,
 		CreatedAt:  FixedTime,
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/mailit-dev/mailit/internal/dto"
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 // --- UserRepository ---
@@ -43,6 +44,9 @@ type MockTeamRepository struct{ mock.Mock }
 func (m *MockTeamRepository) Create(ctx context.Context, team *model.Team) error {
 	return m.Called(ctx, team).Error(0)
 }
+func (m *MockTeamRepository) CreateWithOwner(ctx context.Context, team *model.Team, owner *model.TeamMember) error {
+	return m.Called(ctx, team, owner).Error(0)
+}
 func (m *MockTeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Team, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -57,6 +61,10 @@ func (m *MockTeamRepository) GetBySlug(ctx context.Context, slug string) (*model
 	}
 	return args.Get(0).(*model.Team), args.Error(1)
 }
+func (m *MockTeamRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserTeam, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.UserTeam), args.Error(1)
+}
 
 // --- TeamMemberRepository ---
 
@@ -76,6 +84,26 @@ func (m *MockTeamMemberRepository) GetByTeamAndUser(ctx context.Context, teamID,
 	}
 	return args.Get(0).(*model.TeamMember), args.Error(1)
 }
+func (m *MockTeamMemberRepository) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.TeamMember, error) {
+	args := m.Called(ctx, teamID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TeamMember), args.Error(1)
+}
+func (m *MockTeamMemberRepository) UpdateRole(ctx context.Context, teamID, id uuid.UUID, role string) error {
+	return m.Called(ctx, teamID, id, role).Error(0)
+}
+func (m *MockTeamMemberRepository) Delete(ctx context.Context, teamID, id uuid.UUID) error {
+	return m.Called(ctx, teamID, id).Error(0)
+}
+func (m *MockTeamMemberRepository) TransferOwnership(ctx context.Context, teamID, fromID, toID uuid.UUID) error {
+	return m.Called(ctx, teamID, fromID, toID).Error(0)
+}
+func (m *MockTeamMemberRepository) ListEmailsByTeamID(ctx context.Context, teamID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).([]string), args.Error(1)
+}
 
 // --- EmailRepository ---
 
@@ -105,6 +133,39 @@ func (m *MockEmailRepository) List(ctx context.Context, teamID uuid.UUID, limit,
 func (m *MockEmailRepository) Update(ctx context.Context, email *model.Email) error {
 	return m.Called(ctx, email).Error(0)
 }
+func (m *MockEmailRepository) ReleaseHeld(ctx context.Context, broadcastID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	args := m.Called(ctx, broadcastID, limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+func (m *MockEmailRepository) HoldQueued(ctx context.Context, ids []uuid.UUID) error {
+	return m.Called(ctx, ids).Error(0)
+}
+func (m *MockEmailRepository) UpdateStatusByBroadcast(ctx context.Context, broadcastID uuid.UUID, from []string, to string) (int64, error) {
+	args := m.Called(ctx, broadcastID, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockEmailRepository) CountByBroadcast(ctx context.Context, broadcastID uuid.UUID, statuses []string) (int, error) {
+	args := m.Called(ctx, broadcastID, statuses)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockEmailRepository) LastSentAtByBroadcast(ctx context.Context, broadcastID uuid.UUID) (*time.Time, error) {
+	args := m.Called(ctx, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+func (m *MockEmailRepository) ListAddressesByBroadcast(ctx context.Context, broadcastID uuid.UUID, addresses []string) ([]string, error) {
+	args := m.Called(ctx, broadcastID, addresses)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *MockEmailRepository) CountOutgoingByDomain(ctx context.Context, domainID uuid.UUID, since time.Time) (int, error) {
+	args := m.Called(ctx, domainID, since)
+	return args.Int(0), args.Error(1)
+}
 
 // --- EmailEventRepository ---
 
@@ -157,9 +218,20 @@ func (m *MockDomainRepository) List(ctx context.Context, teamID uuid.UUID, limit
 	args := m.Called(ctx, teamID, limit, offset)
 	return args.Get(0).([]model.Domain), args.Int(1), args.Error(2)
 }
+func (m *MockDomainRepository) ListDKIMMaintenanceDue(ctx context.Context, now time.Time) ([]model.Domain, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]model.Domain), args.Error(1)
+}
+func (m *MockDomainRepository) ListMonitoringDue(ctx context.Context, checkedBefore time.Time) ([]model.Domain, error) {
+	args := m.Called(ctx, checkedBefore)
+	return args.Get(0).([]model.Domain), args.Error(1)
+}
 func (m *MockDomainRepository) Update(ctx context.Context, domain *model.Domain) error {
 	return m.Called(ctx, domain).Error(0)
 }
+func (m *MockDomainRepository) StartDKIMRotation(ctx context.Context, domain *model.Domain, record *model.DomainDNSRecord) error {
+	return m.Called(ctx, domain, record).Error(0)
+}
 func (m *MockDomainRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
@@ -178,6 +250,9 @@ func (m *MockDomainDNSRecordRepository) ListByDomainID(ctx context.Context, doma
 func (m *MockDomainDNSRecordRepository) Update(ctx context.Context, record *model.DomainDNSRecord) error {
 	return m.Called(ctx, record).Error(0)
 }
+func (m *MockDomainDNSRecordRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
 func (m *MockDomainDNSRecordRepository) DeleteByDomainID(ctx context.Context, domainID uuid.UUID) error {
 	return m.Called(ctx, domainID).Error(0)
 }
@@ -264,6 +339,10 @@ func (m *MockContactRepository) GetByAudienceAndEmail(ctx context.Context, audie
 	}
 	return args.Get(0).(*model.Contact), args.Error(1)
 }
+func (m *MockContactRepository) ListByTeamAndEmail(ctx context.Context, teamID uuid.UUID, email string) ([]model.Contact, error) {
+	args := m.Called(ctx, teamID, email)
+	return args.Get(0).([]model.Contact), args.Error(1)
+}
 func (m *MockContactRepository) List(ctx context.Context, audienceID uuid.UUID, limit, offset int) ([]model.Contact, int, error) {
 	args := m.Called(ctx, audienceID, limit, offset)
 	return args.Get(0).([]model.Contact), args.Int(1), args.Error(2)
@@ -311,6 +390,25 @@ func (m *MockContactPropertyRepository) Delete(ctx context.Context, id uuid.UUID
 	return m.Called(ctx, id).Error(0)
 }
 
+// --- ContactPropertyValueRepository ---
+
+type MockContactPropertyValueRepository struct{ mock.Mock }
+
+func (m *MockContactPropertyValueRepository) ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactPropertyValue, error) {
+	args := m.Called(ctx, contactID)
+	return args.Get(0).([]model.ContactPropertyValue), args.Error(1)
+}
+func (m *MockContactPropertyValueRepository) ListByContactIDs(ctx context.Context, contactIDs []uuid.UUID) ([]model.ContactPropertyValue, error) {
+	args := m.Called(ctx, contactIDs)
+	return args.Get(0).([]model.ContactPropertyValue), args.Error(1)
+}
+func (m *MockContactPropertyValueRepository) Upsert(ctx context.Context, value *model.ContactPropertyValue) error {
+	return m.Called(ctx, value).Error(0)
+}
+func (m *MockContactPropertyValueRepository) Delete(ctx context.Context, contactID, propertyID uuid.UUID) error {
+	return m.Called(ctx, contactID, propertyID).Error(0)
+}
+
 // --- TopicRepository ---
 
 type MockTopicRepository struct{ mock.Mock }
@@ -343,6 +441,22 @@ func (m *MockTopicRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+// --- ContactTopicRepository ---
+
+type MockContactTopicRepository struct{ mock.Mock }
+
+func (m *MockContactTopicRepository) ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactTopic, error) {
+	args := m.Called(ctx, contactID)
+	return args.Get(0).([]model.ContactTopic), args.Error(1)
+}
+func (m *MockContactTopicRepository) ListByTopicAndContacts(ctx context.Context, topicID uuid.UUID, contactIDs []uuid.UUID) ([]model.ContactTopic, error) {
+	args := m.Called(ctx, topicID, contactIDs)
+	return args.Get(0).([]model.ContactTopic), args.Error(1)
+}
+func (m *MockContactTopicRepository) Upsert(ctx context.Context, ct *model.ContactTopic) error {
+	return m.Called(ctx, ct).Error(0)
+}
+
 // --- SegmentRepository ---
 
 type MockSegmentRepository struct{ mock.Mock }
@@ -374,6 +488,13 @@ func (m *MockSegmentRepository) Update(ctx context.Context, segment *model.Segme
 func (m *MockSegmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
+func (m *MockSegmentRepository) RefreshMembers(ctx context.Context, segmentID uuid.UUID) (int, error) {
+	args := m.Called(ctx, segmentID)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockSegmentRepository) RefreshContact(ctx context.Context, contactID uuid.UUID) error {
+	return m.Called(ctx, contactID).Error(0)
+}
 
 // --- TemplateRepository ---
 
@@ -428,6 +549,13 @@ func (m *MockTemplateVersionRepository) GetPublishedByTemplateID(ctx context.Con
 	}
 	return args.Get(0).(*model.TemplateVersion), args.Error(1)
 }
+func (m *MockTemplateVersionRepository) GetByTemplateIDAndVersion(ctx context.Context, templateID uuid.UUID, version int) (*model.TemplateVersion, error) {
+	args := m.Called(ctx, templateID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TemplateVersion), args.Error(1)
+}
 func (m *MockTemplateVersionRepository) ListByTemplateID(ctx context.Context, templateID uuid.UUID) ([]model.TemplateVersion, error) {
 	args := m.Called(ctx, templateID)
 	return args.Get(0).([]model.TemplateVersion), args.Error(1)
@@ -464,10 +592,59 @@ func (m *MockBroadcastRepository) List(ctx context.Context, teamID uuid.UUID, li
 func (m *MockBroadcastRepository) Update(ctx context.Context, broadcast *model.Broadcast) error {
 	return m.Called(ctx, broadcast).Error(0)
 }
+func (m *MockBroadcastRepository) ListDue(ctx context.Context, now time.Time) ([]model.Broadcast, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]model.Broadcast), args.Error(1)
+}
+func (m *MockBroadcastRepository) ListReleasable(ctx context.Context) ([]model.Broadcast, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Broadcast), args.Error(1)
+}
+func (m *MockBroadcastRepository) AddRecipients(ctx context.Context, id uuid.UUID, n int) (*model.Broadcast, error) {
+	args := m.Called(ctx, id, n)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Broadcast), args.Error(1)
+}
+func (m *MockBroadcastRepository) IncrementSentCount(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *MockBroadcastRepository) GetStats(ctx context.Context, id uuid.UUID) (*model.BroadcastStats, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BroadcastStats), args.Error(1)
+}
+func (m *MockBroadcastRepository) ListLinkStats(ctx context.Context, id uuid.UUID) ([]model.BroadcastLinkStats, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]model.BroadcastLinkStats), args.Error(1)
+}
+func (m *MockBroadcastRepository) ListRecipients(ctx context.Context, id uuid.UUID, status, event, search string, limit, offset int) ([]model.BroadcastRecipient, int, error) {
+	args := m.Called(ctx, id, status, event, search, limit, offset)
+	return args.Get(0).([]model.BroadcastRecipient), args.Int(1), args.Error(2)
+}
 func (m *MockBroadcastRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+// --- BroadcastVariantRepository ---
+
+type MockBroadcastVariantRepository struct{ mock.Mock }
+
+func (m *MockBroadcastVariantRepository) ListByBroadcastID(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariant, error) {
+	args := m.Called(ctx, broadcastID)
+	return args.Get(0).([]model.BroadcastVariant), args.Error(1)
+}
+func (m *MockBroadcastVariantRepository) ReplaceForBroadcast(ctx context.Context, broadcastID uuid.UUID, variants []model.BroadcastVariant) error {
+	return m.Called(ctx, broadcastID, variants).Error(0)
+}
+func (m *MockBroadcastVariantRepository) ListStats(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariantStats, error) {
+	args := m.Called(ctx, broadcastID)
+	return args.Get(0).([]model.BroadcastVariantStats), args.Error(1)
+}
+
 // --- WebhookRepository ---
 
 type MockWebhookRepository struct{ mock.Mock }
@@ -496,6 +673,17 @@ func (m *MockWebhookRepository) ListByTeamID(ctx context.Context, teamID uuid.UU
 func (m *MockWebhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
 	return m.Called(ctx, webhook).Error(0)
 }
+func (m *MockWebhookRepository) RecordDelivery(ctx context.Context, id uuid.UUID, delivered bool, at time.Time) (*model.Webhook, error) {
+	args := m.Called(ctx, id, delivered, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepository) Disable(ctx context.Context, id uuid.UUID, reason string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, reason, at)
+	return args.Bool(0), args.Error(1)
+}
 func (m *MockWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
@@ -517,8 +705,8 @@ func (m *MockWebhookEventRepository) GetByID(ctx context.Context, id uuid.UUID)
 func (m *MockWebhookEventRepository) Update(ctx context.Context, event *model.WebhookEvent) error {
 	return m.Called(ctx, event).Error(0)
 }
-func (m *MockWebhookEventRepository) ListByWebhookID(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]model.WebhookEvent, int, error) {
-	args := m.Called(ctx, webhookID, limit, offset)
+func (m *MockWebhookEventRepository) ListByWebhookID(ctx context.Context, webhookID uuid.UUID, filter postgres.WebhookEventFilter, limit, offset int) ([]model.WebhookEvent, int, error) {
+	args := m.Called(ctx, webhookID, filter, limit, offset)
 	return args.Get(0).([]model.WebhookEvent), args.Int(1), args.Error(2)
 }
 func (m *MockWebhookEventRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
@@ -540,14 +728,46 @@ func (m *MockSuppressionRepository) GetByTeamAndEmail(ctx context.Context, teamI
 	}
 	return args.Get(0).(*model.SuppressionEntry), args.Error(1)
 }
-func (m *MockSuppressionRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.SuppressionEntry, int, error) {
-	args := m.Called(ctx, teamID, limit, offset)
+func (m *MockSuppressionRepository) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.SuppressionEntry, error) {
+	args := m.Called(ctx, teamID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SuppressionEntry), args.Error(1)
+}
+func (m *MockSuppressionRepository) ListByTeamID(ctx context.Context, teamID uuid.UUID, search, reason string, limit, offset int) ([]model.SuppressionEntry, int, error) {
+	args := m.Called(ctx, teamID, search, reason, limit, offset)
 	return args.Get(0).([]model.SuppressionEntry), args.Int(1), args.Error(2)
 }
+func (m *MockSuppressionRepository) CreateBatch(ctx context.Context, entries []*model.SuppressionEntry) (int, error) {
+	args := m.Called(ctx, entries)
+	return args.Int(0), args.Error(1)
+}
 func (m *MockSuppressionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+// --- DMARCReportRepository ---
+
+type MockDMARCReportRepository struct{ mock.Mock }
+
+func (m *MockDMARCReportRepository) Create(ctx context.Context, report *model.DMARCReport) (bool, error) {
+	args := m.Called(ctx, report)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockDMARCReportRepository) ListByDomainID(ctx context.Context, domainID uuid.UUID, from, to time.Time, limit, offset int) ([]model.DMARCReport, int, error) {
+	args := m.Called(ctx, domainID, from, to, limit, offset)
+	return args.Get(0).([]model.DMARCReport), args.Int(1), args.Error(2)
+}
+func (m *MockDMARCReportRepository) SourceStats(ctx context.Context, domainID uuid.UUID, from, to time.Time) ([]model.DMARCSourceStats, error) {
+	args := m.Called(ctx, domainID, from, to)
+	return args.Get(0).([]model.DMARCSourceStats), args.Error(1)
+}
+func (m *MockDMARCReportRepository) DailyStats(ctx context.Context, domainID uuid.UUID, from, to time.Time) ([]model.DMARCDailyStats, error) {
+	args := m.Called(ctx, domainID, from, to)
+	return args.Get(0).([]model.DMARCDailyStats), args.Error(1)
+}
+
 // --- InboundEmailRepository ---
 
 type MockInboundEmailRepository struct{ mock.Mock }
@@ -584,10 +804,17 @@ type MockLogRepository struct{ mock.Mock }
 func (m *MockLogRepository) Create(ctx context.Context, log *model.Log) error {
 	return m.Called(ctx, log).Error(0)
 }
-func (m *MockLogRepository) List(ctx context.Context, teamID uuid.UUID, level string, limit, offset int) ([]model.Log, int, error) {
-	args := m.Called(ctx, teamID, level, limit, offset)
+func (m *MockLogRepository) CreateBatch(ctx context.Context, logs []model.Log) error {
+	return m.Called(ctx, logs).Error(0)
+}
+func (m *MockLogRepository) List(ctx context.Context, teamID uuid.UUID, filter postgres.LogFilter, limit, offset int) ([]model.Log, int, error) {
+	args := m.Called(ctx, teamID, filter, limit, offset)
 	return args.Get(0).([]model.Log), args.Int(1), args.Error(2)
 }
+func (m *MockLogRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
 
 // --- MetricsRepository ---
 
@@ -632,6 +859,9 @@ func (m *MockSettingsRepository) GetTeamWithMembers(ctx context.Context, teamID
 func (m *MockSettingsRepository) UpdateTeamName(ctx context.Context, teamID uuid.UUID, name string) error {
 	return m.Called(ctx, teamID, name).Error(0)
 }
+func (m *MockSettingsRepository) UpdateSandboxMode(ctx context.Context, teamID uuid.UUID, enabled bool) error {
+	return m.Called(ctx, teamID, enabled).Error(0)
+}
 
 // --- TeamInvitationRepository ---
 
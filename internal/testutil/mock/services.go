@@ -2,6 +2,8 @@ package mock
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -28,6 +30,27 @@ func (m *MockAuthService) Login(ctx context.Context, req *dto.LoginRequest) (*dt
 	}
 	return args.Get(0).(*dto.AuthResponse), args.Error(1)
 }
+func (m *MockAuthService) ListTeams(ctx context.Context, userID, currentTeamID uuid.UUID) ([]dto.TeamSummaryResponse, error) {
+	args := m.Called(ctx, userID, currentTeamID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.TeamSummaryResponse), args.Error(1)
+}
+func (m *MockAuthService) SwitchTeam(ctx context.Context, userID uuid.UUID, req *dto.SwitchTeamRequest) (*dto.AuthResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.AuthResponse), args.Error(1)
+}
+func (m *MockAuthService) CreateTeam(ctx context.Context, userID uuid.UUID, req *dto.CreateTeamRequest) (*dto.AuthResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.AuthResponse), args.Error(1)
+}
 
 // --- EmailService ---
 
@@ -118,6 +141,20 @@ func (m *MockDomainService) Verify(ctx context.Context, teamID uuid.UUID, domain
 	}
 	return args.Get(0).(*dto.DomainResponse), args.Error(1)
 }
+func (m *MockDomainService) RotateDKIM(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.RotateDKIMRequest) (*dto.DomainResponse, error) {
+	args := m.Called(ctx, teamID, domainID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.DomainResponse), args.Error(1)
+}
+func (m *MockDomainService) ImportDKIM(ctx context.Context, teamID uuid.UUID, domainID uuid.UUID, req *dto.ImportDKIMRequest) (*dto.DomainResponse, error) {
+	args := m.Called(ctx, teamID, domainID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.DomainResponse), args.Error(1)
+}
 
 // --- APIKeyService ---
 
@@ -205,6 +242,20 @@ func (m *MockContactService) Update(ctx context.Context, teamID uuid.UUID, audie
 func (m *MockContactService) Delete(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) error {
 	return m.Called(ctx, teamID, audienceID, contactID).Error(0)
 }
+func (m *MockContactService) ListTopics(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID) (*dto.ListResponse[dto.ContactTopicResponse], error) {
+	args := m.Called(ctx, teamID, audienceID, contactID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ListResponse[dto.ContactTopicResponse]), args.Error(1)
+}
+func (m *MockContactService) UpdateTopics(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, contactID uuid.UUID, req *dto.UpdateContactTopicsRequest) (*dto.ListResponse[dto.ContactTopicResponse], error) {
+	args := m.Called(ctx, teamID, audienceID, contactID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ListResponse[dto.ContactTopicResponse]), args.Error(1)
+}
 
 // --- ContactPropertyService ---
 
@@ -292,6 +343,13 @@ func (m *MockSegmentService) Update(ctx context.Context, teamID uuid.UUID, audie
 func (m *MockSegmentService) Delete(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, segmentID uuid.UUID) error {
 	return m.Called(ctx, teamID, audienceID, segmentID).Error(0)
 }
+func (m *MockSegmentService) Refresh(ctx context.Context, teamID uuid.UUID, audienceID uuid.UUID, segmentID uuid.UUID) (*dto.SegmentRefreshResponse, error) {
+	args := m.Called(ctx, teamID, audienceID, segmentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.SegmentRefreshResponse), args.Error(1)
+}
 
 // --- TemplateService ---
 
@@ -335,6 +393,41 @@ func (m *MockTemplateService) Publish(ctx context.Context, teamID uuid.UUID, tem
 	}
 	return args.Get(0).(*dto.TemplateResponse), args.Error(1)
 }
+func (m *MockTemplateService) ListVersions(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID) (*dto.ListResponse[dto.TemplateVersionResponse], error) {
+	args := m.Called(ctx, teamID, templateID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ListResponse[dto.TemplateVersionResponse]), args.Error(1)
+}
+func (m *MockTemplateService) PublishVersion(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, version int) (*dto.TemplateResponse, error) {
+	args := m.Called(ctx, teamID, templateID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.TemplateResponse), args.Error(1)
+}
+func (m *MockTemplateService) Diff(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, from, to int) (*dto.TemplateDiffResponse, error) {
+	args := m.Called(ctx, teamID, templateID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.TemplateDiffResponse), args.Error(1)
+}
+func (m *MockTemplateService) Preview(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.PreviewTemplateRequest) (*dto.PreviewTemplateResponse, error) {
+	args := m.Called(ctx, teamID, templateID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PreviewTemplateResponse), args.Error(1)
+}
+func (m *MockTemplateService) SendTest(ctx context.Context, teamID uuid.UUID, templateID uuid.UUID, req *dto.TestTemplateRequest) (*dto.SendEmailResponse, error) {
+	args := m.Called(ctx, teamID, templateID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.SendEmailResponse), args.Error(1)
+}
 
 // --- BroadcastService ---
 
@@ -378,6 +471,58 @@ func (m *MockBroadcastService) Send(ctx context.Context, teamID uuid.UUID, broad
 	}
 	return args.Get(0).(*dto.BroadcastResponse), args.Error(1)
 }
+func (m *MockBroadcastService) Schedule(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, req *dto.ScheduleBroadcastRequest) (*dto.BroadcastResponse, error) {
+	args := m.Called(ctx, teamID, broadcastID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BroadcastResponse), args.Error(1)
+}
+func (m *MockBroadcastService) Unschedule(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	args := m.Called(ctx, teamID, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BroadcastResponse), args.Error(1)
+}
+
+func (m *MockBroadcastService) Pause(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	args := m.Called(ctx, teamID, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BroadcastResponse), args.Error(1)
+}
+
+func (m *MockBroadcastService) Resume(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	args := m.Called(ctx, teamID, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BroadcastResponse), args.Error(1)
+}
+
+func (m *MockBroadcastService) Cancel(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastResponse, error) {
+	args := m.Called(ctx, teamID, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BroadcastResponse), args.Error(1)
+}
+func (m *MockBroadcastService) Stats(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID) (*dto.BroadcastStatsResponse, error) {
+	args := m.Called(ctx, teamID, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BroadcastStatsResponse), args.Error(1)
+}
+func (m *MockBroadcastService) ListRecipients(ctx context.Context, teamID uuid.UUID, broadcastID uuid.UUID, status, event, search string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.BroadcastRecipientResponse], error) {
+	args := m.Called(ctx, teamID, broadcastID, status, event, search, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PaginatedResponse[dto.BroadcastRecipientResponse]), args.Error(1)
+}
 
 // --- WebhookService ---
 
@@ -414,6 +559,41 @@ func (m *MockWebhookService) Update(ctx context.Context, teamID uuid.UUID, webho
 func (m *MockWebhookService) Delete(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID) error {
 	return m.Called(ctx, teamID, webhookID).Error(0)
 }
+func (m *MockWebhookService) Enable(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.EnableWebhookRequest) (*dto.EnableWebhookResponse, error) {
+	args := m.Called(ctx, teamID, webhookID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.EnableWebhookResponse), args.Error(1)
+}
+func (m *MockWebhookService) ListEvents(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, status, eventType string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.WebhookEventResponse], error) {
+	args := m.Called(ctx, teamID, webhookID, status, eventType, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PaginatedResponse[dto.WebhookEventResponse]), args.Error(1)
+}
+func (m *MockWebhookService) ReplayEvent(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, eventID uuid.UUID) (*dto.WebhookEventResponse, error) {
+	args := m.Called(ctx, teamID, webhookID, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.WebhookEventResponse), args.Error(1)
+}
+func (m *MockWebhookService) ReplayEvents(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.ReplayWebhookEventsRequest) (*dto.ReplayWebhookEventsResponse, error) {
+	args := m.Called(ctx, teamID, webhookID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ReplayWebhookEventsResponse), args.Error(1)
+}
+func (m *MockWebhookService) Test(ctx context.Context, teamID uuid.UUID, webhookID uuid.UUID, req *dto.TestWebhookRequest) (*dto.WebhookEventResponse, error) {
+	args := m.Called(ctx, teamID, webhookID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.WebhookEventResponse), args.Error(1)
+}
 
 // --- InboundEmailService ---
 
@@ -438,14 +618,84 @@ func (m *MockInboundEmailService) Get(ctx context.Context, teamID uuid.UUID, ema
 
 type MockLogService struct{ mock.Mock }
 
-func (m *MockLogService) List(ctx context.Context, teamID uuid.UUID, level string, params *dto.PaginationParams) (*dto.PaginatedResponse[model.Log], error) {
-	args := m.Called(ctx, teamID, level, params)
+func (m *MockLogService) List(ctx context.Context, teamID uuid.UUID, params *dto.LogListParams) (*dto.PaginatedResponse[model.Log], error) {
+	args := m.Called(ctx, teamID, params)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dto.PaginatedResponse[model.Log]), args.Error(1)
 }
 
+// --- DMARCService ---
+
+type MockDMARCService struct{ mock.Mock }
+
+func (m *MockDMARCService) ListReports(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.DMARCReportResponse], error) {
+	args := m.Called(ctx, teamID, domainID, from, to, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PaginatedResponse[dto.DMARCReportResponse]), args.Error(1)
+}
+func (m *MockDMARCService) GetStats(ctx context.Context, teamID, domainID uuid.UUID, from, to time.Time) (*dto.DMARCStatsResponse, error) {
+	args := m.Called(ctx, teamID, domainID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.DMARCStatsResponse), args.Error(1)
+}
+
+// --- SuppressionService ---
+
+type MockSuppressionService struct{ mock.Mock }
+
+func (m *MockSuppressionService) List(ctx context.Context, teamID uuid.UUID, search, reason string, params *dto.PaginationParams) (*dto.PaginatedResponse[dto.SuppressionResponse], error) {
+	args := m.Called(ctx, teamID, search, reason, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PaginatedResponse[dto.SuppressionResponse]), args.Error(1)
+}
+func (m *MockSuppressionService) Create(ctx context.Context, teamID uuid.UUID, req *dto.CreateSuppressionRequest) (*dto.SuppressionResponse, error) {
+	args := m.Called(ctx, teamID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.SuppressionResponse), args.Error(1)
+}
+func (m *MockSuppressionService) Import(ctx context.Context, teamID uuid.UUID, r io.Reader) (*dto.SuppressionImportResponse, error) {
+	args := m.Called(ctx, teamID, r)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.SuppressionImportResponse), args.Error(1)
+}
+func (m *MockSuppressionService) Delete(ctx context.Context, teamID uuid.UUID, suppressionID uuid.UUID) error {
+	return m.Called(ctx, teamID, suppressionID).Error(0)
+}
+
+// --- PreferenceService ---
+
+type MockPreferenceService struct{ mock.Mock }
+
+func (m *MockPreferenceService) Get(ctx context.Context, token string) (*dto.PreferencesResponse, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PreferencesResponse), args.Error(1)
+}
+func (m *MockPreferenceService) Update(ctx context.Context, token string, req *dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error) {
+	args := m.Called(ctx, token, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PreferencesResponse), args.Error(1)
+}
+func (m *MockPreferenceService) Unsubscribe(ctx context.Context, token string) error {
+	return m.Called(ctx, token).Error(0)
+}
+
 // --- MetricsService ---
 
 type MockMetricsService struct{ mock.Mock }
@@ -503,6 +753,36 @@ func (m *MockSettingsService) AcceptInvite(ctx context.Context, req *dto.AcceptI
 	}
 	return args.Get(0).(*dto.AuthResponse), args.Error(1)
 }
+func (m *MockSettingsService) ListMembers(ctx context.Context, teamID uuid.UUID) ([]dto.TeamMemberResponse, error) {
+	args := m.Called(ctx, teamID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.TeamMemberResponse), args.Error(1)
+}
+func (m *MockSettingsService) UpdateMemberRole(ctx context.Context, teamID, memberID uuid.UUID, req *dto.UpdateMemberRoleRequest) (*model.TeamMember, error) {
+	args := m.Called(ctx, teamID, memberID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TeamMember), args.Error(1)
+}
+func (m *MockSettingsService) RemoveMember(ctx context.Context, teamID, memberID uuid.UUID) error {
+	return m.Called(ctx, teamID, memberID).Error(0)
+}
+func (m *MockSettingsService) TransferOwnership(ctx context.Context, teamID, actorUserID uuid.UUID, req *dto.TransferOwnershipRequest) error {
+	return m.Called(ctx, teamID, actorUserID, req).Error(0)
+}
+func (m *MockSettingsService) ListInvitations(ctx context.Context, teamID uuid.UUID) ([]model.TeamInvitation, error) {
+	args := m.Called(ctx, teamID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.TeamInvitation), args.Error(1)
+}
+func (m *MockSettingsService) RevokeInvitation(ctx context.Context, teamID, invitationID uuid.UUID) error {
+	return m.Called(ctx, teamID, invitationID).Error(0)
+}
 
 // --- TrackingService ---
 
@@ -29,19 +29,32 @@ const (
 	EventStatusPending   = "pending"
 	EventStatusDelivered = "delivered"
 	EventStatusFailed    = "failed"
+	// EventStatusSkipped marks events raised while their endpoint was
+	// disabled automatically. They are delivered if the endpoint is
+	// re-enabled with a backfill.
+	EventStatusSkipped = "skipped"
 )
 
+// EventTypeWebhookDisabled is dispatched to a team's other endpoints when
+// one of its webhooks is disabled after repeated failures.
+const EventTypeWebhookDisabled = "webhook.disabled"
+
 // Default configuration values.
 const (
-	defaultTimeout    = 30 * time.Second
-	defaultMaxRetries = 5
-	maxResponseBody   = 4096 // max bytes to store from the response body
+	defaultTimeout              = 30 * time.Second
+	defaultMaxRetries           = 5
+	defaultDisableAfterFailures = 50
+	maxResponseBody             = 4096 // max bytes to store from the response body
 )
 
 // DispatcherConfig holds configuration for the webhook dispatcher.
 type DispatcherConfig struct {
 	Timeout    time.Duration
 	MaxRetries int
+	// DisableAfterFailures is the number of consecutive failed delivery
+	// attempts after which an endpoint is disabled. Zero uses the default;
+	// a negative value never disables endpoints.
+	DisableAfterFailures int
 }
 
 // Dispatcher manages the lifecycle of webhook events: finding matching webhooks,
@@ -52,6 +65,7 @@ type Dispatcher struct {
 	asynqClient      *asynq.Client
 	httpClient       *http.Client
 	maxRetries       int
+	disableAfter     int
 	logger           *slog.Logger
 }
 
@@ -73,6 +87,11 @@ func NewDispatcher(
 		maxRetries = defaultMaxRetries
 	}
 
+	disableAfter := cfg.DisableAfterFailures
+	if disableAfter == 0 {
+		disableAfter = defaultDisableAfterFailures
+	}
+
 	return &Dispatcher{
 		webhookRepo:      webhookRepo,
 		webhookEventRepo: webhookEventRepo,
@@ -80,13 +99,16 @@ func NewDispatcher(
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
-		maxRetries: maxRetries,
-		logger:     logger,
+		maxRetries:   maxRetries,
+		disableAfter: disableAfter,
+		logger:       logger,
 	}
 }
 
 // Dispatch finds all active webhooks for a team that subscribe to the given event type,
 // creates a webhook_event record for each, and enqueues delivery tasks.
+// Webhooks that were disabled automatically get a skipped record instead, so
+// the event can be backfilled once they are re-enabled.
 func (d *Dispatcher) Dispatch(ctx context.Context, teamID uuid.UUID, eventType string, payload interface{}) error {
 	// 1. Find all webhooks for the team.
 	webhooks, err := d.webhookRepo.ListByTeamID(ctx, teamID)
@@ -97,7 +119,7 @@ func (d *Dispatcher) Dispatch(ctx context.Context, teamID uuid.UUID, eventType s
 	// 2. Filter to active webhooks that subscribe to this event type.
 	now := time.Now().UTC()
 	for _, wh := range webhooks {
-		if !wh.Active {
+		if !wh.Active && wh.DisabledAt == nil {
 			continue
 		}
 
@@ -117,12 +139,16 @@ func (d *Dispatcher) Dispatch(ctx context.Context, teamID uuid.UUID, eventType s
 		}
 
 		// 4. Create a webhook_event record.
+		status := EventStatusPending
+		if !wh.Active {
+			status = EventStatusSkipped
+		}
 		event := &model.WebhookEvent{
 			ID:        uuid.New(),
 			WebhookID: wh.ID,
 			EventType: eventType,
 			Payload:   payloadJSON,
-			Status:    EventStatusPending,
+			Status:    status,
 			Attempts:  0,
 			CreatedAt: now,
 		}
@@ -136,17 +162,12 @@ func (d *Dispatcher) Dispatch(ctx context.Context, teamID uuid.UUID, eventType s
 			continue
 		}
 
-		// 5. Enqueue a webhook:deliver task.
-		taskPayload, taskErr := json.Marshal(map[string]string{
-			"webhook_event_id": event.ID.String(),
-		})
-		if taskErr != nil {
-			d.logger.Error("failed to marshal webhook deliver task payload", "error", taskErr)
+		if !wh.Active {
 			continue
 		}
 
-		task := asynq.NewTask(taskWebhookDeliver, taskPayload, asynq.Queue("default"), asynq.MaxRetry(d.maxRetries))
-		if _, enqErr := d.asynqClient.Enqueue(task); enqErr != nil {
+		// 5. Enqueue a webhook:deliver task.
+		if enqErr := d.enqueue(event.ID); enqErr != nil {
 			d.logger.Error("failed to enqueue webhook:deliver task",
 				"webhook_event_id", event.ID,
 				"error", enqErr,
@@ -164,6 +185,62 @@ func (d *Dispatcher) Dispatch(ctx context.Context, teamID uuid.UUID, eventType s
 	return nil
 }
 
+// Redeliver resets an existing webhook event to pending and enqueues it for
+// delivery again. Previous attempts are kept in the attempt count.
+func (d *Dispatcher) Redeliver(ctx context.Context, event *model.WebhookEvent) error {
+	event.Status = EventStatusPending
+	event.NextRetryAt = nil
+	if err := d.webhookEventRepo.Update(ctx, event); err != nil {
+		return fmt.Errorf("resetting webhook event %s: %w", event.ID, err)
+	}
+
+	if err := d.enqueue(event.ID); err != nil {
+		return fmt.Errorf("enqueuing webhook event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// SendTest records a sample event of the given type for the webhook and
+// delivers it immediately, regardless of whether the webhook is active or
+// subscribes to that type. The returned event carries the delivery outcome.
+func (d *Dispatcher) SendTest(ctx context.Context, wh *model.Webhook, eventType string) (*model.WebhookEvent, error) {
+	event := &model.WebhookEvent{
+		ID:        uuid.New(),
+		WebhookID: wh.ID,
+		EventType: eventType,
+		Payload:   SamplePayload(eventType),
+		Status:    EventStatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := d.webhookEventRepo.Create(ctx, event); err != nil {
+		return nil, fmt.Errorf("creating test webhook event: %w", err)
+	}
+
+	// A failed delivery is reported through the event's status and response
+	// rather than as an error. Test sends do not count towards the
+	// endpoint's health.
+	if err := d.deliver(ctx, event, wh); err != nil {
+		d.logger.Debug("test webhook delivery failed", "webhook_id", wh.ID, "error", err)
+	}
+	return event, nil
+}
+
+// enqueue schedules a webhook:deliver task for the event.
+func (d *Dispatcher) enqueue(webhookEventID uuid.UUID) error {
+	taskPayload, err := json.Marshal(map[string]string{
+		"webhook_event_id": webhookEventID.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling webhook deliver task payload: %w", err)
+	}
+
+	task := asynq.NewTask(taskWebhookDeliver, taskPayload, asynq.Queue("default"), asynq.MaxRetry(d.maxRetries))
+	if _, err := d.asynqClient.Enqueue(task); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Deliver performs the actual HTTP POST to the webhook endpoint for a given webhook event.
 func (d *Dispatcher) Deliver(ctx context.Context, webhookEventID uuid.UUID) error {
 	// 1. Get the webhook event.
@@ -178,6 +255,25 @@ func (d *Dispatcher) Deliver(ctx context.Context, webhookEventID uuid.UUID) erro
 		return fmt.Errorf("fetching webhook %s: %w", event.WebhookID, err)
 	}
 
+	// Events queued before the endpoint was disabled are set aside rather
+	// than retried against it.
+	if !wh.Active {
+		event.Status = EventStatusSkipped
+		event.NextRetryAt = nil
+		if err := d.webhookEventRepo.Update(ctx, event); err != nil {
+			return fmt.Errorf("skipping webhook event %s: %w", event.ID, err)
+		}
+		return nil
+	}
+
+	deliveryErr := d.deliver(ctx, event, wh)
+	d.recordHealth(ctx, wh, deliveryErr)
+	return deliveryErr
+}
+
+// deliver sends the event to the webhook's endpoint and records the outcome
+// on the event. Network errors and non-2xx responses are returned as errors.
+func (d *Dispatcher) deliver(ctx context.Context, event *model.WebhookEvent, wh *model.Webhook) error {
 	// 3. Build the JSON payload body.
 	body, err := json.Marshal(map[string]interface{}{
 		"type":       event.EventType,
@@ -234,7 +330,7 @@ func (d *Dispatcher) Deliver(ctx context.Context, webhookEventID uuid.UUID) erro
 	if respCode >= 200 && respCode < 300 {
 		event.Status = EventStatusDelivered
 		d.logger.Info("webhook delivered successfully",
-			"webhook_event_id", webhookEventID,
+			"webhook_event_id", event.ID,
 			"status_code", respCode,
 		)
 	} else {
@@ -245,14 +341,14 @@ func (d *Dispatcher) Deliver(ctx context.Context, webhookEventID uuid.UUID) erro
 			retryAt := calculateRetryTime(now, event.Attempts)
 			event.NextRetryAt = &retryAt
 			d.logger.Warn("webhook delivery failed, will retry",
-				"webhook_event_id", webhookEventID,
+				"webhook_event_id", event.ID,
 				"status_code", respCode,
 				"attempt", event.Attempts,
 				"next_retry_at", retryAt.Format(time.RFC3339),
 			)
 		} else {
 			d.logger.Error("webhook delivery failed permanently",
-				"webhook_event_id", webhookEventID,
+				"webhook_event_id", event.ID,
 				"status_code", respCode,
 				"attempts", event.Attempts,
 			)
@@ -272,6 +368,47 @@ func (d *Dispatcher) Deliver(ctx context.Context, webhookEventID uuid.UUID) erro
 	return nil
 }
 
+// recordHealth updates the endpoint's health after a delivery attempt and
+// disables it once it has failed too many times in a row. Disabling records
+// the reason and dispatches a webhook.disabled event.
+func (d *Dispatcher) recordHealth(ctx context.Context, wh *model.Webhook, deliveryErr error) {
+	now := time.Now().UTC()
+	updated, err := d.webhookRepo.RecordDelivery(ctx, wh.ID, deliveryErr == nil, now)
+	if err != nil {
+		d.logger.Error("failed to record webhook health", "webhook_id", wh.ID, "error", err)
+		return
+	}
+	if deliveryErr == nil || d.disableAfter < 0 || updated.ConsecutiveFailures < d.disableAfter {
+		return
+	}
+
+	reason := fmt.Sprintf("%d consecutive failed deliveries; last error: %v", updated.ConsecutiveFailures, deliveryErr)
+	disabled, err := d.webhookRepo.Disable(ctx, wh.ID, reason, now)
+	if err != nil {
+		d.logger.Error("failed to disable webhook", "webhook_id", wh.ID, "error", err)
+		return
+	}
+	if !disabled {
+		return // already disabled by a concurrent delivery
+	}
+
+	d.logger.Warn("webhook disabled after repeated failures",
+		"webhook_id", wh.ID,
+		"team_id", wh.TeamID,
+		"consecutive_failures", updated.ConsecutiveFailures,
+	)
+
+	if err := d.Dispatch(ctx, wh.TeamID, EventTypeWebhookDisabled, map[string]interface{}{
+		"webhook_id":           wh.ID.String(),
+		"url":                  wh.URL,
+		"reason":               reason,
+		"consecutive_failures": updated.ConsecutiveFailures,
+		"disabled_at":          now.Format(time.RFC3339),
+	}); err != nil {
+		d.logger.Error("failed to dispatch webhook.disabled event", "webhook_id", wh.ID, "error", err)
+	}
+}
+
 // Sign creates an HMAC-SHA256 signature for a webhook payload.
 // The signed content is "{timestamp}.{payload}" to prevent replay attacks.
 func Sign(payload []byte, secret string, timestamp int64) string {
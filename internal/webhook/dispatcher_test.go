@@ -1,11 +1,24 @@
 package webhook
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 func TestSign(t *testing.T) {
@@ -293,3 +306,255 @@ func TestToJSONMap(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// --- local fakes (testutil/mock imports this package through worker) ---
+
+type fakeWebhookRepo struct {
+	webhooks map[uuid.UUID]*model.Webhook
+}
+
+func (f *fakeWebhookRepo) Create(_ context.Context, wh *model.Webhook) error {
+	f.webhooks[wh.ID] = wh
+	return nil
+}
+func (f *fakeWebhookRepo) GetByID(_ context.Context, id uuid.UUID) (*model.Webhook, error) {
+	wh, ok := f.webhooks[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	return wh, nil
+}
+func (f *fakeWebhookRepo) GetByTeamAndID(ctx context.Context, _, id uuid.UUID) (*model.Webhook, error) {
+	return f.GetByID(ctx, id)
+}
+func (f *fakeWebhookRepo) ListByTeamID(_ context.Context, teamID uuid.UUID) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	for _, wh := range f.webhooks {
+		if wh.TeamID == teamID {
+			webhooks = append(webhooks, *wh)
+		}
+	}
+	return webhooks, nil
+}
+func (f *fakeWebhookRepo) Update(_ context.Context, wh *model.Webhook) error {
+	f.webhooks[wh.ID] = wh
+	return nil
+}
+func (f *fakeWebhookRepo) RecordDelivery(_ context.Context, id uuid.UUID, delivered bool, at time.Time) (*model.Webhook, error) {
+	wh, ok := f.webhooks[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	if delivered {
+		wh.ConsecutiveFailures = 0
+		wh.DeliveredCount++
+		wh.LastDeliveredAt = &at
+	} else {
+		wh.ConsecutiveFailures++
+		wh.FailedCount++
+		wh.LastFailedAt = &at
+	}
+	updated := *wh
+	return &updated, nil
+}
+func (f *fakeWebhookRepo) Disable(_ context.Context, id uuid.UUID, reason string, at time.Time) (bool, error) {
+	wh, ok := f.webhooks[id]
+	if !ok || !wh.Active {
+		return false, nil
+	}
+	wh.Active = false
+	wh.DisabledAt = &at
+	wh.DisabledReason = &reason
+	return true, nil
+}
+func (f *fakeWebhookRepo) Delete(_ context.Context, id uuid.UUID) error {
+	delete(f.webhooks, id)
+	return nil
+}
+
+type fakeWebhookEventRepo struct {
+	events  map[uuid.UUID]model.WebhookEvent
+	updates int
+}
+
+func (f *fakeWebhookEventRepo) Create(_ context.Context, event *model.WebhookEvent) error {
+	f.events[event.ID] = *event
+	return nil
+}
+func (f *fakeWebhookEventRepo) GetByID(_ context.Context, id uuid.UUID) (*model.WebhookEvent, error) {
+	ev, ok := f.events[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	return &ev, nil
+}
+func (f *fakeWebhookEventRepo) Update(_ context.Context, event *model.WebhookEvent) error {
+	f.events[event.ID] = *event
+	f.updates++
+	return nil
+}
+func (f *fakeWebhookEventRepo) ListByWebhookID(context.Context, uuid.UUID, postgres.WebhookEventFilter, int, int) ([]model.WebhookEvent, int, error) {
+	return nil, 0, nil
+}
+func (f *fakeWebhookEventRepo) DeleteOlderThan(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newTestDispatcher(client *asynq.Client, webhooks ...*model.Webhook) (*Dispatcher, *fakeWebhookEventRepo) {
+	return newTestDispatcherWithConfig(client, DispatcherConfig{Timeout: 5 * time.Second}, webhooks...)
+}
+
+func newTestDispatcherWithConfig(client *asynq.Client, cfg DispatcherConfig, webhooks ...*model.Webhook) (*Dispatcher, *fakeWebhookEventRepo) {
+	webhookRepo := &fakeWebhookRepo{webhooks: make(map[uuid.UUID]*model.Webhook)}
+	for _, wh := range webhooks {
+		webhookRepo.webhooks[wh.ID] = wh
+	}
+	eventRepo := &fakeWebhookEventRepo{events: make(map[uuid.UUID]model.WebhookEvent)}
+	d := NewDispatcher(webhookRepo, eventRepo, client, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return d, eventRepo
+}
+
+func TestDispatcher_SendTest(t *testing.T) {
+	var gotBody []byte
+	var gotSignature, gotTimestamp string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get("X-Webhook-Signature")
+		gotTimestamp = r.Header.Get("X-Webhook-Timestamp")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("nope"))
+	}))
+	defer srv.Close()
+
+	// Test sends go out even to inactive webhooks.
+	wh := &model.Webhook{ID: uuid.New(), URL: srv.URL, SigningSecret: "whsec_test", Active: false}
+	d, eventRepo := newTestDispatcher(nil, wh)
+
+	event, err := d.SendTest(context.Background(), wh, "email.clicked")
+
+	require.NoError(t, err)
+	assert.Equal(t, EventStatusFailed, event.Status)
+	assert.Equal(t, http.StatusTeapot, *event.ResponseCode)
+	assert.Equal(t, "nope", *event.ResponseBody)
+	assert.Equal(t, 1, event.Attempts)
+	assert.Len(t, eventRepo.events, 1)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(gotBody, &body))
+	assert.Equal(t, "email.clicked", body["type"])
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, true, data["test"])
+	assert.Equal(t, "https://example.com/", data["url"])
+
+	ts, err := strconv.ParseInt(gotTimestamp, 10, 64)
+	require.NoError(t, err)
+	assert.True(t, VerifySignature(gotBody, "whsec_test", ts, gotSignature))
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	d, eventRepo := newTestDispatcher(client)
+
+	retryAt := time.Now()
+	event := &model.WebhookEvent{ID: uuid.New(), Status: EventStatusFailed, Attempts: 5, NextRetryAt: &retryAt}
+
+	require.NoError(t, d.Redeliver(context.Background(), event))
+
+	stored := eventRepo.events[event.ID]
+	assert.Equal(t, EventStatusPending, stored.Status)
+	assert.Nil(t, stored.NextRetryAt)
+	assert.Equal(t, 5, stored.Attempts)
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, taskWebhookDeliver, tasks[0].Type)
+	assert.JSONEq(t, `{"webhook_event_id":"`+event.ID.String()+`"}`, string(tasks[0].Payload))
+}
+
+func TestSamplePayload(t *testing.T) {
+	bounced := SamplePayload("email.bounced")
+	assert.Equal(t, true, bounced["test"])
+	assert.Equal(t, 550, bounced["code"])
+
+	inbound := SamplePayload("email.inbound")
+	assert.Contains(t, inbound, "inbound_email_id")
+	assert.NotContains(t, inbound, "email_id")
+
+	custom := SamplePayload("domain.verified")
+	assert.Contains(t, custom, "timestamp")
+}
+
+func TestDispatcher_Deliver_DisablesAfterConsecutiveFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	teamID := uuid.New()
+	failing := &model.Webhook{ID: uuid.New(), TeamID: teamID, URL: srv.URL, Events: []string{"*"}, SigningSecret: "whsec_a", Active: true, ConsecutiveFailures: 1}
+	alerts := &model.Webhook{ID: uuid.New(), TeamID: teamID, URL: srv.URL, Events: []string{EventTypeWebhookDisabled}, SigningSecret: "whsec_b", Active: true}
+	d, eventRepo := newTestDispatcherWithConfig(client, DispatcherConfig{Timeout: 5 * time.Second, DisableAfterFailures: 2}, failing, alerts)
+
+	event := model.WebhookEvent{ID: uuid.New(), WebhookID: failing.ID, EventType: "email.sent", Payload: model.JSONMap{}, Status: EventStatusPending}
+	eventRepo.events[event.ID] = event
+
+	err := d.Deliver(context.Background(), event.ID)
+	require.Error(t, err)
+
+	assert.False(t, failing.Active)
+	assert.Equal(t, 2, failing.ConsecutiveFailures)
+	assert.Equal(t, 1, failing.FailedCount)
+	require.NotNil(t, failing.DisabledAt)
+	require.NotNil(t, failing.DisabledReason)
+	assert.Contains(t, *failing.DisabledReason, "2 consecutive failed deliveries")
+
+	// The disabled endpoint gets a skipped record of its own alert; the
+	// other subscribed endpoint gets a pending one.
+	alertStatuses := map[uuid.UUID]string{}
+	for _, ev := range eventRepo.events {
+		if ev.EventType == EventTypeWebhookDisabled {
+			alertStatuses[ev.WebhookID] = ev.Status
+			if ev.WebhookID == alerts.ID {
+				assert.Equal(t, failing.ID.String(), ev.Payload["webhook_id"])
+			}
+		}
+	}
+	assert.Equal(t, map[uuid.UUID]string{failing.ID: EventStatusSkipped, alerts.ID: EventStatusPending}, alertStatuses)
+}
+
+func TestDispatcher_Deliver_SkipsInactiveWebhook(t *testing.T) {
+	disabledAt := time.Now()
+	wh := &model.Webhook{ID: uuid.New(), URL: "http://127.0.0.1:0", Active: false, DisabledAt: &disabledAt}
+	d, eventRepo := newTestDispatcher(nil, wh)
+
+	retryAt := time.Now()
+	event := model.WebhookEvent{ID: uuid.New(), WebhookID: wh.ID, EventType: "email.sent", Status: EventStatusPending, Attempts: 2, NextRetryAt: &retryAt}
+	eventRepo.events[event.ID] = event
+
+	require.NoError(t, d.Deliver(context.Background(), event.ID))
+
+	stored := eventRepo.events[event.ID]
+	assert.Equal(t, EventStatusSkipped, stored.Status)
+	assert.Nil(t, stored.NextRetryAt)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, 0, wh.FailedCount)
+}
+
+func TestDispatcher_Dispatch_IgnoresManuallyDeactivatedWebhooks(t *testing.T) {
+	teamID := uuid.New()
+	wh := &model.Webhook{ID: uuid.New(), TeamID: teamID, Events: []string{"*"}, Active: false}
+	d, eventRepo := newTestDispatcher(nil, wh)
+
+	require.NoError(t, d.Dispatch(context.Background(), teamID, "email.sent", map[string]interface{}{"email_id": "x"}))
+	assert.Empty(t, eventRepo.events)
+}
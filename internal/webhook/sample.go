@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+// SamplePayload returns example event data for the given event type, shaped
+// like the data MailIt sends for real events. Unknown event types get the
+// fields common to email events. Every sample carries "test": true.
+func SamplePayload(eventType string) model.JSONMap {
+	now := time.Now().UTC().Format(time.RFC3339)
+	payload := model.JSONMap{
+		"test":      true,
+		"email_id":  "00000000-0000-0000-0000-000000000000",
+		"recipient": "recipient@example.com",
+		"timestamp": now,
+	}
+
+	switch eventType {
+	case "email.bounced":
+		payload["code"] = 550
+		payload["message"] = "5.1.1 The email account that you tried to reach does not exist"
+	case "email.complained":
+		payload["message"] = "feedback-type: abuse"
+	case "email.clicked":
+		payload["url"] = "https://example.com/"
+	case "email.inbound":
+		payload = model.JSONMap{
+			"test":             true,
+			"inbound_email_id": "00000000-0000-0000-0000-000000000000",
+			"from":             "sender@example.com",
+			"to":               []interface{}{"inbox@example.com"},
+			"subject":          "Test inbound email",
+			"text_body":        "This is a test inbound email from MailIt.",
+			"timestamp":        now,
+		}
+	case EventTypeWebhookDisabled:
+		payload = model.JSONMap{
+			"test":                 true,
+			"webhook_id":           "00000000-0000-0000-0000-000000000000",
+			"url":                  "https://example.com/webhooks",
+			"reason":               "50 consecutive failed deliveries; last error: webhook delivery to https://example.com/webhooks returned status 503",
+			"consecutive_failures": 50,
+			"disabled_at":          now,
+		}
+	}
+	return payload
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,12 +24,16 @@ const (
 )
 
 // BounceHandler processes bounce:process tasks by classifying bounces and
-// updating suppression lists.
+// updating suppression lists. Bounces and complaints reported at the return
+// path also update metrics and fire webhooks, which the send handler has
+// already done for rejections during delivery.
 type BounceHandler struct {
-	emailRepo       postgres.EmailRepository
-	eventRepo       postgres.EmailEventRepository
-	suppressionRepo postgres.SuppressionRepository
-	logger          *slog.Logger
+	emailRepo        postgres.EmailRepository
+	eventRepo        postgres.EmailEventRepository
+	suppressionRepo  postgres.SuppressionRepository
+	webhookDispatch  WebhookDispatchFunc
+	metricsIncrement MetricsIncrementFunc
+	logger           *slog.Logger
 }
 
 // NewBounceHandler creates a new BounceHandler.
@@ -35,13 +41,17 @@ func NewBounceHandler(
 	emailRepo postgres.EmailRepository,
 	eventRepo postgres.EmailEventRepository,
 	suppressionRepo postgres.SuppressionRepository,
+	webhookDispatch WebhookDispatchFunc,
+	metricsIncrement MetricsIncrementFunc,
 	logger *slog.Logger,
 ) *BounceHandler {
 	return &BounceHandler{
-		emailRepo:       emailRepo,
-		eventRepo:       eventRepo,
-		suppressionRepo: suppressionRepo,
-		logger:          logger,
+		emailRepo:        emailRepo,
+		eventRepo:        eventRepo,
+		suppressionRepo:  suppressionRepo,
+		webhookDispatch:  webhookDispatch,
+		metricsIncrement: metricsIncrement,
+		logger:           logger,
 	}
 }
 
@@ -54,9 +64,12 @@ func (h *BounceHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
 
 	log := h.logger.With("email_id", p.EmailID, "recipient", p.Recipient, "code", p.Code)
 
-	// 1. Classify the bounce.
-	bounceType := classifyBounce(p.Code, p.Message)
-	log.Info("bounce classified", "bounce_type", bounceType)
+	// 1. Classify the bounce, unless the report already did.
+	bounceType := p.Type
+	if bounceType == "" {
+		bounceType = classifyBounce(p.Code, p.Message)
+	}
+	log.Info("bounce classified", "bounce_type", bounceType, "source", p.Source)
 
 	// 2. Get the email for team context.
 	email, err := h.emailRepo.GetByID(ctx, p.EmailID)
@@ -64,7 +77,26 @@ func (h *BounceHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
 		return fmt.Errorf("fetching email %s: %w", p.EmailID, err)
 	}
 
+	// Feedback reports may redact the recipient; an email with a single
+	// recipient can only have been reported by that recipient.
+	if p.Recipient == "" {
+		if len(email.ToAddresses) != 1 || len(email.CcAddresses)+len(email.BccAddresses) > 0 {
+			log.Warn("bounce report without a recipient for an email with several recipients, ignoring")
+			return nil
+		}
+		p.Recipient = email.ToAddresses[0]
+	}
+
+	// Every recipient sees the return path, so anyone could send a report
+	// for the email naming some other address. Only its own recipients can
+	// bounce or complain.
+	if !emailHasRecipient(email, p.Recipient) {
+		log.Warn("bounce report names an address the email was not sent to, ignoring")
+		return nil
+	}
+
 	now := time.Now().UTC()
+	reported := p.Source != ""
 
 	switch bounceType {
 	case BounceTypeHard:
@@ -86,6 +118,18 @@ func (h *BounceHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
 			"message": p.Message,
 		})
 
+		if reported {
+			h.incrementMetrics(ctx, email.TeamID, model.EventBounced)
+			h.dispatchWebhook(ctx, email.TeamID, "email.bounced", map[string]interface{}{
+				"email_id":  email.ID.String(),
+				"recipient": p.Recipient,
+				"code":      p.Code,
+				"message":   p.Message,
+				"type":      BounceTypeHard,
+				"timestamp": now.Format(time.RFC3339),
+			})
+		}
+
 	case BounceTypeSoft:
 		// Soft bounce: create a bounce event but don't suppress.
 		// The email handler retries via asynq's retry mechanism.
@@ -106,11 +150,35 @@ func (h *BounceHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
 			"code":    p.Code,
 			"message": p.Message,
 		})
+
+		if reported {
+			h.incrementMetrics(ctx, email.TeamID, model.EventComplained)
+			h.dispatchWebhook(ctx, email.TeamID, "email.complained", map[string]interface{}{
+				"email_id":  email.ID.String(),
+				"recipient": p.Recipient,
+				"message":   p.Message,
+				"timestamp": now.Format(time.RFC3339),
+			})
+		}
 	}
 
 	return nil
 }
 
+// dispatchWebhook safely calls the webhook dispatch function if set.
+func (h *BounceHandler) dispatchWebhook(ctx context.Context, teamID uuid.UUID, eventType string, payload map[string]interface{}) {
+	if h.webhookDispatch != nil {
+		h.webhookDispatch(ctx, teamID, eventType, payload)
+	}
+}
+
+// incrementMetrics safely calls the metrics increment function if set.
+func (h *BounceHandler) incrementMetrics(ctx context.Context, teamID uuid.UUID, eventType string) {
+	if h.metricsIncrement != nil {
+		h.metricsIncrement(ctx, teamID, eventType)
+	}
+}
+
 // classifyBounce determines the type of bounce based on the SMTP response code and message.
 func classifyBounce(code int, message string) string {
 	// 5xx codes are permanent failures (hard bounces).
@@ -203,3 +271,26 @@ func (h *BounceHandler) createEvent(ctx context.Context, emailID uuid.UUID, even
 		h.logger.Error("failed to create email event", "error", err, "email_id", emailID, "event_type", eventType)
 	}
 }
+
+// emailHasRecipient reports whether addr is one of the email's To, Cc or Bcc
+// addresses, compared case-insensitively.
+func emailHasRecipient(email *model.Email, addr string) bool {
+	addr = bareAddress(addr)
+	for _, list := range [][]string{email.ToAddresses, email.CcAddresses, email.BccAddresses} {
+		for _, rcpt := range list {
+			if strings.EqualFold(bareAddress(rcpt), addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bareAddress returns the address part of addr, which may carry a display
+// name.
+func bareAddress(addr string) string {
+	if parsed, err := mail.ParseAddress(addr); err == nil {
+		return parsed.Address
+	}
+	return strings.TrimSpace(addr)
+}
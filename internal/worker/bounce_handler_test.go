@@ -11,6 +11,7 @@ import (
 	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/mailit-dev/mailit/internal/model"
 )
@@ -21,14 +22,15 @@ func TestBounceHandler_ProcessTask_HardBounce(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, logger)
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, nil, nil, logger)
 
 	emailID := uuid.New()
 	teamID := uuid.New()
 	email := &model.Email{
-		ID:     emailID,
-		TeamID: teamID,
-		Status: model.EmailStatusSent,
+		ID:          emailID,
+		TeamID:      teamID,
+		Status:      model.EmailStatusSent,
+		ToAddresses: []string{"bounce@example.com"},
 	}
 
 	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
@@ -60,14 +62,15 @@ func TestBounceHandler_ProcessTask_SoftBounce(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, logger)
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, nil, nil, logger)
 
 	emailID := uuid.New()
 	teamID := uuid.New()
 	email := &model.Email{
-		ID:     emailID,
-		TeamID: teamID,
-		Status: model.EmailStatusSent,
+		ID:          emailID,
+		TeamID:      teamID,
+		Status:      model.EmailStatusSent,
+		ToAddresses: []string{"temp@example.com"},
 	}
 
 	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
@@ -93,14 +96,15 @@ func TestBounceHandler_ProcessTask_Complaint(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, logger)
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, nil, nil, logger)
 
 	emailID := uuid.New()
 	teamID := uuid.New()
 	email := &model.Email{
-		ID:     emailID,
-		TeamID: teamID,
-		Status: model.EmailStatusSent,
+		ID:          emailID,
+		TeamID:      teamID,
+		Status:      model.EmailStatusSent,
+		ToAddresses: []string{"complainer@example.com"},
 	}
 
 	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
@@ -128,7 +132,7 @@ func TestBounceHandler_ProcessTask_InvalidPayload(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, logger)
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, nil, nil, logger)
 
 	task := asynq.NewTask(TaskBounceProcess, []byte("invalid json"))
 
@@ -143,14 +147,15 @@ func TestBounceHandler_ProcessTask_AlreadySuppressed(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, logger)
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, nil, nil, logger)
 
 	emailID := uuid.New()
 	teamID := uuid.New()
 	email := &model.Email{
-		ID:     emailID,
-		TeamID: teamID,
-		Status: model.EmailStatusSent,
+		ID:          emailID,
+		TeamID:      teamID,
+		Status:      model.EmailStatusSent,
+		ToAddresses: []string{"already@example.com"},
 	}
 
 	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
@@ -194,3 +199,174 @@ func TestClassifyBounce(t *testing.T) {
 		assert.Equal(t, tt.expected, result, "code=%d message=%s", tt.code, tt.message)
 	}
 }
+
+type dispatchedWebhook struct {
+	teamID    uuid.UUID
+	eventType string
+	payload   map[string]interface{}
+}
+
+func TestBounceHandler_ProcessTask_ReportedComplaint(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var dispatched []dispatchedWebhook
+	var counted []string
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo,
+		func(_ context.Context, teamID uuid.UUID, eventType string, payload interface{}) {
+			dispatched = append(dispatched, dispatchedWebhook{teamID, eventType, payload.(map[string]interface{})})
+		},
+		func(_ context.Context, _ uuid.UUID, eventType string) { counted = append(counted, eventType) },
+		logger,
+	)
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	email := &model.Email{ID: emailID, TeamID: teamID, Status: model.EmailStatusDelivered, ToAddresses: []string{"carol@example.net"}}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "carol@example.net").Return(nil, nil)
+	suppressionRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *model.SuppressionEntry) bool {
+		return e.Reason == model.SuppressionComplaint && e.Email == "carol@example.net"
+	})).Return(nil)
+	eventRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *model.EmailEvent) bool {
+		return e.Type == model.EventComplained
+	})).Return(nil)
+
+	// The feedback report redacted the recipient.
+	task, err := NewBounceReportTask(emailID, BounceTypeComplaint, 0, "feedback-type: abuse", "", BounceSourceARF)
+	require.NoError(t, err)
+
+	require.NoError(t, h.ProcessTask(context.Background(), task))
+
+	suppressionRepo.AssertExpectations(t)
+	eventRepo.AssertExpectations(t)
+	emailRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	require.Len(t, dispatched, 1)
+	assert.Equal(t, "email.complained", dispatched[0].eventType)
+	assert.Equal(t, teamID, dispatched[0].teamID)
+	assert.Equal(t, "carol@example.net", dispatched[0].payload["recipient"])
+	assert.Equal(t, []string{model.EventComplained}, counted)
+}
+
+func TestBounceHandler_ProcessTask_ReportedHardBounce(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var dispatched []dispatchedWebhook
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo,
+		func(_ context.Context, teamID uuid.UUID, eventType string, payload interface{}) {
+			dispatched = append(dispatched, dispatchedWebhook{teamID, eventType, payload.(map[string]interface{})})
+		},
+		nil,
+		logger,
+	)
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	email := &model.Email{ID: emailID, TeamID: teamID, Status: model.EmailStatusSent, ToAddresses: []string{"bob@example.com", "eve@example.com"}}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "bob@example.com").Return(nil, nil)
+	suppressionRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.SuppressionEntry")).Return(nil)
+	emailRepo.On("Update", mock.Anything, mock.MatchedBy(func(e *model.Email) bool {
+		return e.Status == model.EmailStatusBounced
+	})).Return(nil)
+	eventRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.EmailEvent")).Return(nil)
+
+	// A "blocked" message would be classified as a complaint from its text;
+	// the type from the DSN wins.
+	task, err := NewBounceReportTask(emailID, BounceTypeHard, 550, "smtp; 550 5.7.1 blocked", "bob@example.com", BounceSourceDSN)
+	require.NoError(t, err)
+
+	require.NoError(t, h.ProcessTask(context.Background(), task))
+
+	emailRepo.AssertExpectations(t)
+	require.Len(t, dispatched, 1)
+	assert.Equal(t, "email.bounced", dispatched[0].eventType)
+	assert.Equal(t, BounceTypeHard, dispatched[0].payload["type"])
+}
+
+func TestBounceHandler_ProcessTask_ReportWithoutRecipient(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := NewBounceHandler(emailRepo, new(mockEmailEventRepo), suppressionRepo, nil, nil, logger)
+
+	emailID := uuid.New()
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(&model.Email{ID: emailID, ToAddresses: []string{"a@example.com", "b@example.com"}}, nil)
+
+	task, err := NewBounceReportTask(emailID, BounceTypeComplaint, 0, "feedback-type: abuse", "", BounceSourceARF)
+	require.NoError(t, err)
+
+	require.NoError(t, h.ProcessTask(context.Background(), task))
+	suppressionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestBounceHandler_ProcessTask_ReportForOwnRecipient(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo, nil, nil, logger)
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	email := &model.Email{
+		ID:          emailID,
+		TeamID:      teamID,
+		Status:      model.EmailStatusSent,
+		ToAddresses: []string{"bob@example.com"},
+		CcAddresses: []string{"Carol <Carol@Example.net>"},
+	}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "carol@example.NET").Return(nil, nil)
+	suppressionRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.SuppressionEntry")).Return(nil)
+	eventRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.EmailEvent")).Return(nil)
+
+	task, err := NewBounceReportTask(emailID, BounceTypeComplaint, 0, "feedback-type: abuse", "carol@example.NET", BounceSourceARF)
+	require.NoError(t, err)
+
+	require.NoError(t, h.ProcessTask(context.Background(), task))
+	suppressionRepo.AssertExpectations(t)
+	eventRepo.AssertExpectations(t)
+}
+
+func TestBounceHandler_ProcessTask_ReportForOtherAddress(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var dispatched []dispatchedWebhook
+	h := NewBounceHandler(emailRepo, eventRepo, suppressionRepo,
+		func(_ context.Context, teamID uuid.UUID, eventType string, payload interface{}) {
+			dispatched = append(dispatched, dispatchedWebhook{teamID, eventType, payload.(map[string]interface{})})
+		},
+		nil,
+		logger,
+	)
+
+	emailID := uuid.New()
+	email := &model.Email{ID: emailID, TeamID: uuid.New(), Status: model.EmailStatusSent, ToAddresses: []string{"mallory@example.com"}}
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+
+	// A recipient reports a bounce at the return path for someone else.
+	task, err := NewBounceReportTask(emailID, BounceTypeHard, 550, "smtp; 550 5.1.1 unknown user", "victim@example.com", BounceSourceDSN)
+	require.NoError(t, err)
+
+	require.NoError(t, h.ProcessTask(context.Background(), task))
+	suppressionRepo.AssertNotCalled(t, "GetByTeamAndEmail", mock.Anything, mock.Anything, mock.Anything)
+	suppressionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	emailRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	eventRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Empty(t, dispatched)
+	assert.Equal(t, model.EmailStatusSent, email.Status)
+}
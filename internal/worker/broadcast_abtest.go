@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"hash/fnv"
+
+	"github.com/google/uuid"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+// abTestGroup returns the variant of an A/B test a contact is sent as part
+// of the test group, or -1 if the contact is left for the winner. Contacts
+// are split by a hash of their ID, so both phases of the test agree on who
+// is in the test group, and each variant gets about percentage percent of
+// the audience.
+func abTestGroup(broadcastID, contactID uuid.UUID, percentage, variants int) int {
+	h := fnv.New32a()
+	_, _ = h.Write(broadcastID[:])
+	_, _ = h.Write(contactID[:])
+	bucket := int(h.Sum32() % 100)
+	if bucket >= percentage*variants {
+		return -1
+	}
+	return bucket / percentage
+}
+
+// pickABTestWinner returns the variant with the best rate by metric. Ties go
+// to the earlier variant. If no variant has a rate above zero, there is
+// nothing to tell them apart by, and it returns nil.
+func pickABTestWinner(metric string, variants []model.BroadcastVariant, stats []model.BroadcastVariantStats) *model.BroadcastVariant {
+	rates := make(map[uuid.UUID]float64, len(stats))
+	for _, s := range stats {
+		rates[s.VariantID] = s.Rate(metric)
+	}
+
+	var winner *model.BroadcastVariant
+	for i := range variants {
+		if v := &variants[i]; rates[v.ID] > 0 && (winner == nil || rates[v.ID] > rates[winner.ID]) {
+			winner = v
+		}
+	}
+	return winner
+}
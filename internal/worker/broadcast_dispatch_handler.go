@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// BroadcastDispatchSchedule is the cron spec the broadcast:dispatch task is
+// scheduled with.
+const BroadcastDispatchSchedule = "@every 1m"
+
+// BroadcastDispatchHandler processes broadcast:dispatch tasks. Scheduled
+// broadcasts are kept in Postgres rather than as delayed tasks in Redis; this
+// handler queues a broadcast:send task for each broadcast, or local-time
+// wave, that is due, so schedules survive a loss of Redis data. It then
+// releases the held emails of sending broadcasts, within their throttling.
+type BroadcastDispatchHandler struct {
+	broadcastRepo postgres.BroadcastRepository
+	releaser      *broadcastReleaser
+	enqueuer      TaskEnqueuer
+	logger        *slog.Logger
+}
+
+// NewBroadcastDispatchHandler creates a new BroadcastDispatchHandler.
+func NewBroadcastDispatchHandler(
+	broadcastRepo postgres.BroadcastRepository,
+	emailRepo postgres.EmailRepository,
+	domainRepo postgres.DomainRepository,
+	enqueuer TaskEnqueuer,
+	logger *slog.Logger,
+) *BroadcastDispatchHandler {
+	return &BroadcastDispatchHandler{
+		broadcastRepo: broadcastRepo,
+		releaser:      &broadcastReleaser{emailRepo: emailRepo, domainRepo: domainRepo, enqueuer: enqueuer},
+		enqueuer:      enqueuer,
+		logger:        logger,
+	}
+}
+
+// ProcessTask handles the broadcast:dispatch task.
+func (h *BroadcastDispatchHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	log := h.logger.With("task", TaskBroadcastDispatch)
+
+	now := time.Now().UTC()
+	broadcasts, err := h.broadcastRepo.ListDue(ctx, now)
+	if err != nil {
+		return fmt.Errorf("listing due broadcasts: %w", err)
+	}
+
+	var errs []error
+	var queued int
+	for _, b := range broadcasts {
+		task, err := NewBroadcastSendTask(b.ID, b.TeamID)
+		if err == nil {
+			_, err = h.enqueuer.Enqueue(task, asynq.TaskID(BroadcastSendTaskID(b.ID, *b.NextSendAt)))
+		}
+		switch {
+		case err == nil:
+			queued++
+		case errors.Is(err, asynq.ErrTaskIDConflict):
+		default:
+			log.Error("failed to enqueue broadcast send", "broadcast_id", b.ID, "error", err)
+			errs = append(errs, fmt.Errorf("broadcast %s: %w", b.ID, err))
+		}
+	}
+
+	if queued > 0 {
+		log.Info("queued scheduled broadcasts", "count", queued)
+	}
+
+	releasable, err := h.broadcastRepo.ListReleasable(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing broadcasts with held emails: %w", err))
+	}
+	for _, b := range releasable {
+		blog := log.With("broadcast_id", b.ID)
+		released, err := h.releaser.release(ctx, &b, now, blog)
+		if err != nil {
+			blog.Error("failed to release broadcast emails", "error", err)
+			errs = append(errs, fmt.Errorf("broadcast %s: %w", b.ID, err))
+		}
+		if released > 0 {
+			blog.Info("released broadcast emails", "count", released)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("broadcast dispatch completed with %d errors: %v", len(errs), errs)
+	}
+	return nil
+}
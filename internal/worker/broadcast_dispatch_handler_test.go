@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+func TestBroadcastDispatchHandler_ProcessTask(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	broadcastRepo := new(mockBroadcastRepo)
+	h := NewBroadcastDispatchHandler(broadcastRepo, new(mockEmailRepo), new(mockDomainRepo), client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	due := time.Now().UTC().Add(-time.Minute)
+	scheduled := model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusScheduled, NextSendAt: &due}
+	wave := model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusSending, SendInLocalTime: true, NextSendAt: &due}
+	broadcastRepo.On("ListDue", mock.Anything, mock.AnythingOfType("time.Time")).Return([]model.Broadcast{scheduled, wave}, nil)
+	broadcastRepo.On("ListReleasable", mock.Anything).Return([]model.Broadcast(nil), nil)
+
+	require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastDispatch, nil)))
+	// A send that is still queued isn't queued again.
+	require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastDispatch, nil)))
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(QueueCritical)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+
+	var ids []uuid.UUID
+	for _, task := range tasks {
+		assert.Equal(t, TaskBroadcastSend, task.Type)
+		var p BroadcastSendPayload
+		require.NoError(t, json.Unmarshal(task.Payload, &p))
+		ids = append(ids, p.BroadcastID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{scheduled.ID, wave.ID}, ids)
+}
+
+func TestBroadcastDispatchHandler_ProcessTask_ReleasesHeldEmails(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	broadcastRepo, emailRepo, domainRepo := new(mockBroadcastRepo), new(mockEmailRepo), new(mockDomainRepo)
+	h := NewBroadcastDispatchHandler(broadcastRepo, emailRepo, domainRepo, client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	b := model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusSending, FromAddress: strPtr("news@example.com")}
+	broadcastRepo.On("ListDue", mock.Anything, mock.AnythingOfType("time.Time")).Return([]model.Broadcast(nil), nil)
+	broadcastRepo.On("ListReleasable", mock.Anything).Return([]model.Broadcast{b}, nil)
+	domainRepo.On("GetByTeamAndName", mock.Anything, b.TeamID, "example.com").Return(nil, postgres.ErrNotFound)
+	emailRepo.On("ReleaseHeld", mock.Anything, b.ID, releasePageSize).Return([]uuid.UUID{uuid.New()}, nil)
+
+	require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastDispatch, nil)))
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(QueueCritical)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, TaskEmailSend, tasks[0].Type)
+}
+
+func TestBroadcastDispatchHandler_ProcessTask_ListError(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	h := NewBroadcastDispatchHandler(broadcastRepo, new(mockEmailRepo), new(mockDomainRepo), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	broadcastRepo.On("ListDue", mock.Anything, mock.AnythingOfType("time.Time")).Return([]model.Broadcast(nil), assert.AnError)
+
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastDispatch, nil))
+	assert.ErrorContains(t, err, "listing due broadcasts")
+}
@@ -3,47 +3,83 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strings"
+	"net/mail"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/templating"
 )
 
 // BroadcastSendHandler processes broadcast:send tasks by expanding a broadcast
-// into individual email:send tasks for each contact in the target audience.
+// into an email for each contact in the target audience. The emails are held
+// and released to the send queue as the broadcast's throttling allows.
+//
+// An A/B tested broadcast is sent in two phases: first each variant to its
+// part of the test group, then, once the test ends, the winning variant to
+// the rest of the audience.
 type BroadcastSendHandler struct {
 	broadcastRepo       postgres.BroadcastRepository
+	variantRepo         postgres.BroadcastVariantRepository
 	contactRepo         postgres.ContactRepository
 	audienceRepo        postgres.AudienceRepository
+	segmentRepo         postgres.SegmentRepository
+	topicRepo           postgres.TopicRepository
+	contactTopicRepo    postgres.ContactTopicRepository
+	propertyRepo        postgres.ContactPropertyRepository
+	propertyValueRepo   postgres.ContactPropertyValueRepository
 	emailRepo           postgres.EmailRepository
+	domainRepo          postgres.DomainRepository
 	templateVersionRepo postgres.TemplateVersionRepository
 	asynqClient         *asynq.Client
+	baseURL             string
+	preferenceSecret    string
 	logger              *slog.Logger
 }
 
 // NewBroadcastSendHandler creates a new BroadcastSendHandler.
 func NewBroadcastSendHandler(
 	broadcastRepo postgres.BroadcastRepository,
+	variantRepo postgres.BroadcastVariantRepository,
 	contactRepo postgres.ContactRepository,
 	audienceRepo postgres.AudienceRepository,
+	segmentRepo postgres.SegmentRepository,
+	topicRepo postgres.TopicRepository,
+	contactTopicRepo postgres.ContactTopicRepository,
+	propertyRepo postgres.ContactPropertyRepository,
+	propertyValueRepo postgres.ContactPropertyValueRepository,
 	emailRepo postgres.EmailRepository,
+	domainRepo postgres.DomainRepository,
 	templateVersionRepo postgres.TemplateVersionRepository,
 	asynqClient *asynq.Client,
+	baseURL string,
+	preferenceSecret string,
 	logger *slog.Logger,
 ) *BroadcastSendHandler {
 	return &BroadcastSendHandler{
 		broadcastRepo:       broadcastRepo,
+		variantRepo:         variantRepo,
 		contactRepo:         contactRepo,
 		audienceRepo:        audienceRepo,
+		segmentRepo:         segmentRepo,
+		topicRepo:           topicRepo,
+		contactTopicRepo:    contactTopicRepo,
+		propertyRepo:        propertyRepo,
+		propertyValueRepo:   propertyValueRepo,
 		emailRepo:           emailRepo,
+		domainRepo:          domainRepo,
 		templateVersionRepo: templateVersionRepo,
 		asynqClient:         asynqClient,
+		baseURL:             baseURL,
+		preferenceSecret:    preferenceSecret,
 		logger:              logger,
 	}
 }
@@ -63,9 +99,11 @@ func (h *BroadcastSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 		return fmt.Errorf("fetching broadcast %s: %w", p.BroadcastID, err)
 	}
 
-	// Only process queued broadcasts.
-	if broadcast.Status != model.BroadcastStatusQueued {
-		log.Info("skipping broadcast with non-queued status", "status", broadcast.Status)
+	// Only process queued broadcasts, and scheduled broadcasts or
+	// local-time waves that are due.
+	now := time.Now().UTC()
+	if !broadcastDue(broadcast, now) {
+		log.Info("skipping broadcast that is not due", "status", broadcast.Status)
 		return nil
 	}
 
@@ -79,35 +117,130 @@ func (h *BroadcastSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 		return fmt.Errorf("fetching audience %s: %w", broadcast.AudienceID, err)
 	}
 
-	// 3. If broadcast has a TemplateID, fetch the published template version.
-	var tmplSubject, tmplHTMLBody, tmplTextBody *string
-	if broadcast.TemplateID != nil {
-		version, tmplErr := h.templateVersionRepo.GetPublishedByTemplateID(ctx, *broadcast.TemplateID)
-		if tmplErr != nil {
-			return fmt.Errorf("fetching published template version for %s: %w", broadcast.TemplateID, tmplErr)
+	// An A/B tested broadcast first goes to its test group. Once the test
+	// has ended, the variant that did best goes to everyone else. If none
+	// did better than the others, they get the broadcast's own content.
+	var variants []model.BroadcastVariant
+	if broadcast.ABTestPercentage != nil && broadcast.ABTestWaitMinutes != nil {
+		variants, err = h.variantRepo.ListByBroadcastID(ctx, broadcast.ID)
+		if err != nil {
+			return fmt.Errorf("listing broadcast variants: %w", err)
 		}
-		tmplSubject = version.Subject
-		tmplHTMLBody = version.HTMLBody
-		tmplTextBody = version.TextBody
-		log.Info("using template for broadcast", "template_id", broadcast.TemplateID, "version", version.Version)
+	}
+	testEnded := len(variants) > 0 && broadcast.ABTestEndsAt != nil
+	if testEnded {
+		// The test only ends once its wait has passed since the whole test
+		// group was sent. Until then, it is due again when it might end.
+		ends, next, err := h.abTestEnd(ctx, broadcast, now)
+		if err != nil {
+			return err
+		}
+		if ends.After(now) {
+			broadcast.ABTestEndsAt, broadcast.NextSendAt = &ends, &next
+			broadcast.UpdatedAt = now
+			if err := h.broadcastRepo.Update(ctx, broadcast); err != nil {
+				return fmt.Errorf("updating broadcast: %w", err)
+			}
+			log.Info("A/B test has not ended yet", "ends_at", ends)
+			return nil
+		}
+	}
+	var winner *model.BroadcastVariant
+	if testEnded {
+		stats, err := h.variantRepo.ListStats(ctx, broadcast.ID)
+		if err != nil {
+			return fmt.Errorf("fetching broadcast variant stats: %w", err)
+		}
+		metric := ptrToString(broadcast.ABWinnerMetric)
+		if winner = pickABTestWinner(metric, variants, stats); winner != nil {
+			log.Info("picked A/B test winner", "variant_id", winner.ID, "variant", winner.Name)
+		} else {
+			log.Warn("A/B test was inconclusive, no variant had any signal; sending the broadcast's own content", "metric", metric)
+		}
+	}
+
+	// 3. Resolve the content to send: the broadcast's own, the winning
+	// variant's, or each variant's for the test group.
+	var contents []*broadcastContent
+	switch {
+	case testEnded:
+		c, err := h.loadContent(ctx, broadcast, winner, log)
+		if err != nil {
+			return err
+		}
+		contents = append(contents, c)
+	case len(variants) > 0:
+		for i := range variants {
+			c, err := h.loadContent(ctx, broadcast, &variants[i], log)
+			if err != nil {
+				return err
+			}
+			contents = append(contents, c)
+		}
+	default:
+		c, err := h.loadContent(ctx, broadcast, nil, log)
+		if err != nil {
+			return err
+		}
+		contents = append(contents, c)
+	}
+
+	// Custom contact properties are only loaded when the content uses them,
+	// or to read recipients' time zones.
+	usesContact := broadcast.SendInLocalTime
+	for _, c := range contents {
+		usesContact = usesContact || c.content.References(templating.VarContact)
+	}
+	var properties map[uuid.UUID]model.ContactProperty
+	if usesContact {
+		defs, err := h.propertyRepo.ListByTeamID(ctx, p.TeamID)
+		if err != nil {
+			return fmt.Errorf("listing contact properties: %w", err)
+		}
+		properties = make(map[uuid.UUID]model.ContactProperty, len(defs))
+		for _, def := range defs {
+			properties[def.ID] = def
+		}
+	}
+
+	// Recompute segment membership so the send reflects the current contact
+	// data rather than whatever was stored when the segment was last saved.
+	if broadcast.SegmentID != nil {
+		members, err := h.segmentRepo.RefreshMembers(ctx, *broadcast.SegmentID)
+		if err != nil {
+			return fmt.Errorf("refreshing segment %s: %w", broadcast.SegmentID, err)
+		}
+		log.Info("refreshed segment membership", "segment_id", broadcast.SegmentID, "contacts", members)
 	}
 
-	// Resolve subject/body: template takes priority over inline fields.
-	resolveStr := func(tmpl, inline *string) *string {
-		if tmpl != nil && *tmpl != "" {
-			return tmpl
+	// Broadcasts sent on behalf of a topic only reach its subscribers.
+	var topic *model.Topic
+	if broadcast.TopicID != nil {
+		topic, err = h.topicRepo.GetByID(ctx, *broadcast.TopicID)
+		if err != nil {
+			return fmt.Errorf("fetching topic %s: %w", broadcast.TopicID, err)
 		}
-		return inline
 	}
 
-	baseSubject := resolveStr(tmplSubject, broadcast.Subject)
-	baseHTMLBody := resolveStr(tmplHTMLBody, broadcast.HTMLBody)
-	baseTextBody := resolveStr(tmplTextBody, broadcast.TextBody)
+	// Broadcast emails are sent from the team's domain of the From address,
+	// whose warm-up limit applies to them.
+	var domainID *uuid.UUID
+	domain, err := h.domainRepo.GetByTeamAndName(ctx, p.TeamID, extractDomain(ptrToString(broadcast.FromAddress)))
+	if err != nil && !errors.Is(err, postgres.ErrNotFound) {
+		return fmt.Errorf("fetching sending domain: %w", err)
+	}
+	if domain != nil {
+		domainID = &domain.ID
+	}
 
-	// 4. Update broadcast status to "sending".
+	// 4. Update broadcast status to "sending". The broadcast stays due until
+	// all its emails have been created, so that if creating them fails, the
+	// task's retry picks up where it left off.
+	sentUntil := broadcast.LocalSentUntil
 	broadcast.Status = model.BroadcastStatusSending
-	now := time.Now().UTC()
-	broadcast.SentAt = &now
+	if broadcast.SentAt == nil {
+		broadcast.SentAt = &now
+	}
 	broadcast.UpdatedAt = now
 	if err := h.broadcastRepo.Update(ctx, broadcast); err != nil {
 		return fmt.Errorf("updating broadcast to sending: %w", err)
@@ -115,7 +248,7 @@ func (h *BroadcastSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 
 	// 5. Fetch contacts — from segment if specified, otherwise from audience.
 	const pageSize = 500
-	var totalEnqueued int
+	var recipients int
 	offset := 0
 
 	listContacts := func(limit, off int) ([]model.Contact, int, error) {
@@ -125,41 +258,111 @@ func (h *BroadcastSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 		return h.contactRepo.List(ctx, *broadcast.AudienceID, limit, off)
 	}
 
+	tzProperty := model.DefaultTimezoneProperty
+	if broadcast.TimezoneProperty != nil && *broadcast.TimezoneProperty != "" {
+		tzProperty = *broadcast.TimezoneProperty
+	}
+	locations := locationCache{}
+
+	current := broadcast
 	for {
 		contacts, total, err := listContacts(pageSize, offset)
 		if err != nil {
 			return fmt.Errorf("listing contacts at offset %d: %w", offset, err)
 		}
 
+		propertyValues, err := h.contactProperties(ctx, properties, contacts)
+		if err != nil {
+			return fmt.Errorf("loading contact properties at offset %d: %w", offset, err)
+		}
+
+		var topicSubscribed map[uuid.UUID]bool
+		if topic != nil {
+			topicSubscribed, err = h.topicSubscribers(ctx, topic, contacts)
+			if err != nil {
+				return fmt.Errorf("loading topic subscriptions at offset %d: %w", offset, err)
+			}
+		}
+
+		// Contacts who already have an email of the broadcast were reached
+		// by an earlier attempt that failed part of the way through.
+		addresses := make([]string, 0, len(contacts))
+		for _, contact := range contacts {
+			addresses = append(addresses, contact.Email)
+		}
+		existing, err := h.emailRepo.ListAddressesByBroadcast(ctx, broadcast.ID, addresses)
+		if err != nil {
+			return fmt.Errorf("listing broadcast recipients at offset %d: %w", offset, err)
+		}
+		alreadySent := make(map[string]bool, len(existing))
+		for _, addr := range existing {
+			alreadySent[addr] = true
+		}
+
+		var created int
 		for _, contact := range contacts {
+			if broadcast.SendInLocalTime {
+				sendAt := localSendAt(*broadcast.ScheduledAt, locations.get(propertyValues[contact.ID][tzProperty]))
+				if sendAt.After(now) || (sentUntil != nil && !sendAt.After(*sentUntil)) {
+					continue
+				}
+			}
+
+			c, tags := contents[0], []string{model.BroadcastTag(broadcast.ID)}
+			var variantID *uuid.UUID
+			if len(variants) > 0 {
+				group := abTestGroup(broadcast.ID, contact.ID, *broadcast.ABTestPercentage, len(variants))
+				// The test sends to the test group, the winner to the rest.
+				if (group >= 0) == testEnded {
+					continue
+				}
+				if !testEnded {
+					c, variantID = contents[group], &variants[group].ID
+					tags = append(tags, model.BroadcastVariantTag(variants[group].ID))
+				}
+			}
+
 			// Skip unsubscribed contacts.
 			if contact.Unsubscribed {
 				log.Debug("skipping unsubscribed contact", "contact_id", contact.ID, "email", contact.Email)
 				continue
 			}
+			if topic != nil && !topicSubscribed[contact.ID] {
+				log.Debug("skipping contact not subscribed to topic", "contact_id", contact.ID, "topic_id", topic.ID)
+				continue
+			}
+			if alreadySent[contact.Email] {
+				continue
+			}
 
-			// 6. Substitute contact variables in subject/body.
-			subject := substituteVars(ptrToString(baseSubject), &contact)
-			htmlBody := substituteVars(ptrToString(baseHTMLBody), &contact)
-			textBody := substituteVars(ptrToString(baseTextBody), &contact)
+			// 6. Render the subject/body for this contact.
+			headers, prefsURL := h.unsubscribeHeaders(p.TeamID, contact.Email)
+			data := make(map[string]interface{}, len(c.defaults)+2)
+			for k, v := range c.defaults {
+				data[k] = v
+			}
+			data[templating.VarContact] = contactData(&contact, propertyValues[contact.ID])
+			data[templating.VarUnsubscribeURL] = prefsURL
+			subject, htmlBody, textBody := c.content.Render(data)
 
-			// 7. Create an email record for this contact.
-			emailID := uuid.New()
+			// 7. Create a held email for this contact.
 			email := &model.Email{
-				ID:          emailID,
-				TeamID:      p.TeamID,
-				DomainID:    nil,
-				FromAddress: ptrToString(broadcast.FromAddress),
-				ToAddresses: []string{contact.Email},
-				Subject:     subject,
-				HTMLBody:    strPtrIfNotEmpty(htmlBody),
-				TextBody:    strPtrIfNotEmpty(textBody),
-				Status:      model.EmailStatusQueued,
-				Tags:        []string{"broadcast:" + p.BroadcastID.String()},
-				Headers:     model.JSONMap{},
-				Attachments: model.JSONArray{},
-				CreatedAt:   now,
-				UpdatedAt:   now,
+				ID:                 uuid.New(),
+				TeamID:             p.TeamID,
+				DomainID:           domainID,
+				BroadcastID:        &broadcast.ID,
+				BroadcastVariantID: variantID,
+				FromAddress:        c.from,
+				ToAddresses:        []string{contact.Email},
+				Subject:            ptrToString(subject),
+				HTMLBody:           strPtrIfNotEmpty(ptrToString(htmlBody)),
+				TextBody:           strPtrIfNotEmpty(ptrToString(textBody)),
+				Status:             model.EmailStatusHeld,
+				Tags:               tags,
+				Headers:            headers,
+				Attachments:        model.JSONArray{},
+				CreatedAt:          now,
+				UpdatedAt:          now,
 			}
 
 			if err := h.emailRepo.Create(ctx, email); err != nil {
@@ -167,19 +370,19 @@ func (h *BroadcastSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 				continue
 			}
 
-			// 8. Enqueue the email:send task.
-			task, taskErr := NewEmailSendTask(emailID, p.TeamID)
-			if taskErr != nil {
-				log.Error("failed to create email:send task", "email_id", emailID, "error", taskErr)
-				continue
-			}
-
-			if _, enqErr := h.asynqClient.Enqueue(task); enqErr != nil {
-				log.Error("failed to enqueue email:send task", "email_id", emailID, "error", enqErr)
-				continue
-			}
+			created++
+		}
+		recipients += created
 
-			totalEnqueued++
+		// 8. Count the page's emails as recipients. The broadcast is read
+		// back, as it may have been paused or cancelled meanwhile; if it was
+		// cancelled, the rest of the audience is not expanded.
+		current, err = h.broadcastRepo.AddRecipients(ctx, p.BroadcastID, created)
+		if err != nil {
+			return fmt.Errorf("updating broadcast recipient count: %w", err)
+		}
+		if current.Status == model.BroadcastStatusCancelled {
+			break
 		}
 
 		offset += pageSize
@@ -187,37 +390,289 @@ func (h *BroadcastSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 			break
 		}
 	}
+	broadcast = current
 
-	// 9. Update broadcast with recipient count.
-	broadcast.TotalRecipients = totalEnqueued
-	broadcast.UpdatedAt = time.Now().UTC()
+	if broadcast.Status == model.BroadcastStatusCancelled {
+		// Cancel the emails created after the broadcast was cancelled.
+		if _, err := h.emailRepo.UpdateStatusByBroadcast(ctx, p.BroadcastID, []string{model.EmailStatusHeld}, model.EmailStatusCancelled); err != nil {
+			return fmt.Errorf("cancelling broadcast emails: %w", err)
+		}
+		log.Info("broadcast was cancelled while its emails were created")
+		return nil
+	}
 
-	// If no recipients were enqueued, mark as sent immediately.
-	if totalEnqueued == 0 {
+	// A broadcast sent in local time sent a wave to the recipients whose
+	// local send time has come since the last one, and is due again for the
+	// next wave. An A/B test is due again when it ends at the earliest, to
+	// send the winner.
+	broadcast.NextSendAt = nil
+	if broadcast.SendInLocalTime {
+		broadcast.LocalSentUntil = &now
+		if next, ok := nextLocalTimeWave(*broadcast.ScheduledAt, now); ok {
+			broadcast.NextSendAt = &next
+		}
+	}
+	switch {
+	case winner != nil:
+		broadcast.ABWinnerVariantID = &winner.ID
+	case testEnded:
+		broadcast.ABTestInconclusive = true
+	case len(variants) > 0:
+		ends := now.Add(time.Duration(*broadcast.ABTestWaitMinutes) * time.Minute)
+		broadcast.ABTestEndsAt = &ends
+		broadcast.NextSendAt = &ends
+	}
+	if broadcast.NextSendAt == nil && broadcast.TotalRecipients == 0 {
+		// If there were no recipients, mark as sent immediately.
 		broadcast.Status = model.BroadcastStatusSent
 		log.Warn("broadcast had zero eligible recipients")
 	}
-
+	broadcast.UpdatedAt = time.Now().UTC()
 	if err := h.broadcastRepo.Update(ctx, broadcast); err != nil {
-		return fmt.Errorf("updating broadcast recipient count: %w", err)
+		return fmt.Errorf("updating broadcast: %w", err)
 	}
 
-	log.Info("broadcast expanded into email tasks", "total_enqueued", totalEnqueued)
+	// 9. Release the first emails to the send queue. Whatever is still held
+	// is released by the broadcast:dispatch task.
+	released, err := h.releaser().release(ctx, broadcast, time.Now().UTC(), log)
+	if err != nil {
+		log.Error("failed to release broadcast emails", "error", err)
+	}
+
+	log.Info("broadcast expanded into emails", "recipients", recipients, "released", released, "next_wave", broadcast.NextSendAt)
 	return nil
 }
 
-// substituteVars replaces {{contact.field}} placeholders with contact values.
-func substituteVars(text string, contact *model.Contact) string {
-	if text == "" {
-		return text
-	}
-	r := strings.NewReplacer(
-		"{{contact.email}}", contact.Email,
-		"{{contact.first_name}}", ptrToString(contact.FirstName),
-		"{{contact.last_name}}", ptrToString(contact.LastName),
-		"{{contact.id}}", contact.ID.String(),
-	)
-	return r.Replace(text)
+// abTestCheckInterval is how often an A/B test whose test group isn't all
+// sent yet is checked on.
+const abTestCheckInterval = time.Minute
+
+// abTestEnd returns when a broadcast's A/B test ends: its wait after the last
+// of the test emails was sent. While test emails are still waiting to be
+// sent, the wait hasn't started, and the test is checked on again after
+// abTestCheckInterval; next is when to come back to it.
+func (h *BroadcastSendHandler) abTestEnd(ctx context.Context, broadcast *model.Broadcast, now time.Time) (ends, next time.Time, err error) {
+	wait := time.Duration(*broadcast.ABTestWaitMinutes) * time.Minute
+	unsent, err := h.emailRepo.CountByBroadcast(ctx, broadcast.ID, []string{model.EmailStatusHeld, model.EmailStatusQueued, model.EmailStatusSending})
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("counting unsent A/B test emails: %w", err)
+	}
+	if unsent > 0 {
+		return now.Add(wait), now.Add(abTestCheckInterval), nil
+	}
+
+	lastSent, err := h.emailRepo.LastSentAtByBroadcast(ctx, broadcast.ID)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("fetching when the A/B test was sent: %w", err)
+	}
+	if lastSent == nil {
+		// None of the test emails was sent, so there is nothing to wait for.
+		return now, now, nil
+	}
+	ends = lastSent.Add(wait)
+	return ends, ends, nil
+}
+
+// broadcastContent is what a broadcast, or one variant of it, sends: its
+// parsed subject and bodies, the defaults of its template variables, and its
+// From address.
+type broadcastContent struct {
+	content  *templating.Content
+	defaults map[string]interface{}
+	from     string
+}
+
+// loadContent resolves and parses the content a broadcast sends, with the
+// overrides of variant if one is given. A template takes priority over the
+// broadcast's inline fields, and a variant's fields over both.
+func (h *BroadcastSendHandler) loadContent(ctx context.Context, broadcast *model.Broadcast, variant *model.BroadcastVariant, log *slog.Logger) (*broadcastContent, error) {
+	// Use the published template version, or the one the variant picks.
+	var tmplSubject, tmplHTMLBody, tmplTextBody *string
+	var tmplVariables []templating.Variable
+	if broadcast.TemplateID != nil {
+		var version *model.TemplateVersion
+		var err error
+		if variant != nil && variant.TemplateVersion != nil {
+			version, err = h.templateVersionRepo.GetByTemplateIDAndVersion(ctx, *broadcast.TemplateID, *variant.TemplateVersion)
+			if err != nil {
+				return nil, fmt.Errorf("fetching version %d of template %s: %w", *variant.TemplateVersion, broadcast.TemplateID, err)
+			}
+		} else {
+			version, err = h.templateVersionRepo.GetPublishedByTemplateID(ctx, *broadcast.TemplateID)
+			if err != nil {
+				return nil, fmt.Errorf("fetching published template version for %s: %w", broadcast.TemplateID, err)
+			}
+		}
+		tmplSubject = version.Subject
+		tmplHTMLBody = version.HTMLBody
+		tmplTextBody = version.TextBody
+		tmplVariables = templating.VariablesFromJSON(version.Variables)
+		log.Info("using template for broadcast", "template_id", broadcast.TemplateID, "version", version.Version)
+	}
+
+	resolveStr := func(preferred, fallback *string) *string {
+		if preferred != nil && *preferred != "" {
+			return preferred
+		}
+		return fallback
+	}
+
+	subject := resolveStr(tmplSubject, broadcast.Subject)
+	htmlBody := resolveStr(tmplHTMLBody, broadcast.HTMLBody)
+	textBody := resolveStr(tmplTextBody, broadcast.TextBody)
+	from := ptrToString(broadcast.FromAddress)
+	if variant != nil {
+		subject = resolveStr(variant.Subject, subject)
+		htmlBody = resolveStr(variant.HTMLBody, htmlBody)
+		textBody = resolveStr(variant.TextBody, textBody)
+		if variant.FromName != nil && *variant.FromName != "" {
+			from = (&mail.Address{Name: *variant.FromName, Address: from}).String()
+		}
+	}
+
+	// Parse the content once; it is rendered for each contact.
+	content, err := templating.ParseContent(subject, htmlBody, textBody)
+	if err != nil {
+		return nil, fmt.Errorf("parsing broadcast content: %w", err)
+	}
+
+	// Declared template variables have no per-recipient values in a
+	// broadcast, so they render with their defaults.
+	defaults := make(map[string]interface{}, len(tmplVariables))
+	for _, v := range tmplVariables {
+		defaults[v.Name] = v.Default
+	}
+
+	return &broadcastContent{content: content, defaults: defaults, from: from}, nil
+}
+
+// releaser returns a broadcastReleaser using the handler's repositories.
+func (h *BroadcastSendHandler) releaser() *broadcastReleaser {
+	return &broadcastReleaser{emailRepo: h.emailRepo, domainRepo: h.domainRepo, enqueuer: h.asynqClient}
+}
+
+// broadcastDue reports whether a broadcast is ready to be sent: it was queued
+// to be sent now, or its scheduled time or next local-time wave has come.
+func broadcastDue(b *model.Broadcast, now time.Time) bool {
+	switch b.Status {
+	case model.BroadcastStatusQueued:
+		return true
+	case model.BroadcastStatusScheduled, model.BroadcastStatusSending:
+		return b.NextSendAt != nil && !b.NextSendAt.After(now)
+	}
+	return false
+}
+
+// locationCache loads the time zones named by contacts' time zone property.
+type locationCache map[string]*time.Location
+
+// get returns the time zone named by value, or UTC if value isn't the name
+// of a time zone.
+func (c locationCache) get(value interface{}) *time.Location {
+	name, ok := value.(string)
+	if !ok || name == "" {
+		return time.UTC
+	}
+	if loc, ok := c[name]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	c[name] = loc
+	return loc
+}
+
+// topicSubscribers reports which of the given contacts are subscribed to the
+// topic: an explicit contact_topics row wins, otherwise the topic's default
+// subscription applies.
+func (h *BroadcastSendHandler) topicSubscribers(ctx context.Context, topic *model.Topic, contacts []model.Contact) (map[uuid.UUID]bool, error) {
+	ids := make([]uuid.UUID, 0, len(contacts))
+	subscribed := make(map[uuid.UUID]bool, len(contacts))
+	for _, c := range contacts {
+		ids = append(ids, c.ID)
+		subscribed[c.ID] = topic.SubscribedByDefault()
+	}
+
+	cts, err := h.contactTopicRepo.ListByTopicAndContacts(ctx, topic.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, ct := range cts {
+		subscribed[ct.ContactID] = ct.Subscribed
+	}
+
+	return subscribed, nil
+}
+
+// unsubscribeHeaders returns the RFC 8058 one-click List-Unsubscribe headers
+// for a broadcast recipient, along with the URL of their preference page for
+// the {{unsubscribe_url}} placeholder. Both links carry a signed token, so
+// they keep working after tracking links are cleaned up.
+func (h *BroadcastSendHandler) unsubscribeHeaders(teamID uuid.UUID, email string) (model.JSONMap, string) {
+	headers := model.JSONMap{}
+	if h.baseURL == "" || h.preferenceSecret == "" {
+		return headers, ""
+	}
+
+	token := url.QueryEscape(pkg.SignPreferenceToken(h.preferenceSecret, teamID, email))
+	headers["List-Unsubscribe"] = fmt.Sprintf("<%s/preferences/unsubscribe?token=%s>", h.baseURL, token)
+	headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+
+	return headers, fmt.Sprintf("%s/preferences?token=%s", h.baseURL, token)
+}
+
+// contactProperties loads the custom property values of a page of contacts,
+// keyed by contact ID and then property name. It returns nil when no
+// property definitions are given.
+func (h *BroadcastSendHandler) contactProperties(ctx context.Context, properties map[uuid.UUID]model.ContactProperty, contacts []model.Contact) (map[uuid.UUID]map[string]interface{}, error) {
+	if len(properties) == 0 || len(contacts) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(contacts))
+	for _, c := range contacts {
+		ids = append(ids, c.ID)
+	}
+
+	values, err := h.propertyValueRepo.ListByContactIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byContact := make(map[uuid.UUID]map[string]interface{}, len(contacts))
+	for _, v := range values {
+		def, ok := properties[v.PropertyID]
+		if !ok || v.Value == nil {
+			continue
+		}
+		if byContact[v.ContactID] == nil {
+			byContact[v.ContactID] = make(map[string]interface{})
+		}
+		byContact[v.ContactID][def.Name] = model.PropertyValue(def.Type, *v.Value)
+	}
+	return byContact, nil
+}
+
+// contactData builds the object templates see as {{contact.*}}. Custom
+// properties are included by name; the built-in fields take precedence.
+func contactData(contact *model.Contact, properties map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(properties)+4)
+	for name, value := range properties {
+		data[name] = value
+	}
+	data["id"] = contact.ID.String()
+	data["email"] = contact.Email
+	data["first_name"] = nil
+	if contact.FirstName != nil {
+		data["first_name"] = *contact.FirstName
+	}
+	data["last_name"] = nil
+	if contact.LastName != nil {
+		data["last_name"] = *contact.LastName
+	}
+	return data
 }
 
 // strPtrIfNotEmpty returns a pointer to s if it's non-empty, nil otherwise.
@@ -3,16 +3,24 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 // --- local mocks for broadcast handler ---
@@ -43,10 +51,57 @@ func (m *mockBroadcastRepo) List(ctx context.Context, teamID uuid.UUID, limit, o
 func (m *mockBroadcastRepo) Update(ctx context.Context, broadcast *model.Broadcast) error {
 	return m.Called(ctx, broadcast).Error(0)
 }
+func (m *mockBroadcastRepo) ListDue(ctx context.Context, now time.Time) ([]model.Broadcast, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]model.Broadcast), args.Error(1)
+}
+func (m *mockBroadcastRepo) ListReleasable(ctx context.Context) ([]model.Broadcast, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Broadcast), args.Error(1)
+}
+func (m *mockBroadcastRepo) AddRecipients(ctx context.Context, id uuid.UUID, n int) (*model.Broadcast, error) {
+	args := m.Called(ctx, id, n)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Broadcast), args.Error(1)
+}
+func (m *mockBroadcastRepo) IncrementSentCount(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *mockBroadcastRepo) GetStats(ctx context.Context, id uuid.UUID) (*model.BroadcastStats, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BroadcastStats), args.Error(1)
+}
+func (m *mockBroadcastRepo) ListLinkStats(ctx context.Context, id uuid.UUID) ([]model.BroadcastLinkStats, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]model.BroadcastLinkStats), args.Error(1)
+}
+func (m *mockBroadcastRepo) ListRecipients(ctx context.Context, id uuid.UUID, status, event, search string, limit, offset int) ([]model.BroadcastRecipient, int, error) {
+	args := m.Called(ctx, id, status, event, search, limit, offset)
+	return args.Get(0).([]model.BroadcastRecipient), args.Int(1), args.Error(2)
+}
 func (m *mockBroadcastRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+type mockBroadcastVariantRepo struct{ mock.Mock }
+
+func (m *mockBroadcastVariantRepo) ListByBroadcastID(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariant, error) {
+	args := m.Called(ctx, broadcastID)
+	return args.Get(0).([]model.BroadcastVariant), args.Error(1)
+}
+func (m *mockBroadcastVariantRepo) ReplaceForBroadcast(ctx context.Context, broadcastID uuid.UUID, variants []model.BroadcastVariant) error {
+	return m.Called(ctx, broadcastID, variants).Error(0)
+}
+func (m *mockBroadcastVariantRepo) ListStats(ctx context.Context, broadcastID uuid.UUID) ([]model.BroadcastVariantStats, error) {
+	args := m.Called(ctx, broadcastID)
+	return args.Get(0).([]model.BroadcastVariantStats), args.Error(1)
+}
+
 type mockContactRepo struct{ mock.Mock }
 
 func (m *mockContactRepo) Create(ctx context.Context, contact *model.Contact) error {
@@ -73,6 +128,10 @@ func (m *mockContactRepo) GetByAudienceAndEmail(ctx context.Context, audienceID
 	}
 	return args.Get(0).(*model.Contact), args.Error(1)
 }
+func (m *mockContactRepo) ListByTeamAndEmail(ctx context.Context, teamID uuid.UUID, email string) ([]model.Contact, error) {
+	args := m.Called(ctx, teamID, email)
+	return args.Get(0).([]model.Contact), args.Error(1)
+}
 func (m *mockContactRepo) List(ctx context.Context, audienceID uuid.UUID, limit, offset int) ([]model.Contact, int, error) {
 	args := m.Called(ctx, audienceID, limit, offset)
 	return args.Get(0).([]model.Contact), args.Int(1), args.Error(2)
@@ -115,6 +174,134 @@ func (m *mockAudienceRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+type mockSegmentRepo struct{ mock.Mock }
+
+func (m *mockSegmentRepo) Create(ctx context.Context, segment *model.Segment) error {
+	return m.Called(ctx, segment).Error(0)
+}
+func (m *mockSegmentRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Segment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Segment), args.Error(1)
+}
+func (m *mockSegmentRepo) GetByAudienceAndID(ctx context.Context, audienceID, id uuid.UUID) (*model.Segment, error) {
+	args := m.Called(ctx, audienceID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Segment), args.Error(1)
+}
+func (m *mockSegmentRepo) ListByAudienceID(ctx context.Context, audienceID uuid.UUID) ([]model.Segment, error) {
+	args := m.Called(ctx, audienceID)
+	return args.Get(0).([]model.Segment), args.Error(1)
+}
+func (m *mockSegmentRepo) Update(ctx context.Context, segment *model.Segment) error {
+	return m.Called(ctx, segment).Error(0)
+}
+func (m *mockSegmentRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *mockSegmentRepo) RefreshMembers(ctx context.Context, segmentID uuid.UUID) (int, error) {
+	args := m.Called(ctx, segmentID)
+	return args.Int(0), args.Error(1)
+}
+func (m *mockSegmentRepo) RefreshContact(ctx context.Context, contactID uuid.UUID) error {
+	return m.Called(ctx, contactID).Error(0)
+}
+
+type mockTopicRepo struct{ mock.Mock }
+
+func (m *mockTopicRepo) Create(ctx context.Context, topic *model.Topic) error {
+	return m.Called(ctx, topic).Error(0)
+}
+func (m *mockTopicRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Topic, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Topic), args.Error(1)
+}
+func (m *mockTopicRepo) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.Topic, error) {
+	args := m.Called(ctx, teamID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Topic), args.Error(1)
+}
+func (m *mockTopicRepo) ListByTeamID(ctx context.Context, teamID uuid.UUID) ([]model.Topic, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).([]model.Topic), args.Error(1)
+}
+func (m *mockTopicRepo) Update(ctx context.Context, topic *model.Topic) error {
+	return m.Called(ctx, topic).Error(0)
+}
+func (m *mockTopicRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type mockContactTopicRepo struct{ mock.Mock }
+
+func (m *mockContactTopicRepo) ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactTopic, error) {
+	args := m.Called(ctx, contactID)
+	return args.Get(0).([]model.ContactTopic), args.Error(1)
+}
+func (m *mockContactTopicRepo) ListByTopicAndContacts(ctx context.Context, topicID uuid.UUID, contactIDs []uuid.UUID) ([]model.ContactTopic, error) {
+	args := m.Called(ctx, topicID, contactIDs)
+	return args.Get(0).([]model.ContactTopic), args.Error(1)
+}
+func (m *mockContactTopicRepo) Upsert(ctx context.Context, ct *model.ContactTopic) error {
+	return m.Called(ctx, ct).Error(0)
+}
+
+type mockContactPropertyRepo struct{ mock.Mock }
+
+func (m *mockContactPropertyRepo) Create(ctx context.Context, property *model.ContactProperty) error {
+	return m.Called(ctx, property).Error(0)
+}
+func (m *mockContactPropertyRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.ContactProperty, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ContactProperty), args.Error(1)
+}
+func (m *mockContactPropertyRepo) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.ContactProperty, error) {
+	args := m.Called(ctx, teamID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ContactProperty), args.Error(1)
+}
+func (m *mockContactPropertyRepo) ListByTeamID(ctx context.Context, teamID uuid.UUID) ([]model.ContactProperty, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).([]model.ContactProperty), args.Error(1)
+}
+func (m *mockContactPropertyRepo) Update(ctx context.Context, property *model.ContactProperty) error {
+	return m.Called(ctx, property).Error(0)
+}
+func (m *mockContactPropertyRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type mockContactPropertyValueRepo struct{ mock.Mock }
+
+func (m *mockContactPropertyValueRepo) ListByContactID(ctx context.Context, contactID uuid.UUID) ([]model.ContactPropertyValue, error) {
+	args := m.Called(ctx, contactID)
+	return args.Get(0).([]model.ContactPropertyValue), args.Error(1)
+}
+func (m *mockContactPropertyValueRepo) ListByContactIDs(ctx context.Context, contactIDs []uuid.UUID) ([]model.ContactPropertyValue, error) {
+	args := m.Called(ctx, contactIDs)
+	return args.Get(0).([]model.ContactPropertyValue), args.Error(1)
+}
+func (m *mockContactPropertyValueRepo) Upsert(ctx context.Context, value *model.ContactPropertyValue) error {
+	return m.Called(ctx, value).Error(0)
+}
+func (m *mockContactPropertyValueRepo) Delete(ctx context.Context, contactID, propertyID uuid.UUID) error {
+	return m.Called(ctx, contactID, propertyID).Error(0)
+}
+
 func TestBroadcastSendHandler_ProcessTask_NotQueued(t *testing.T) {
 	broadcastRepo := new(mockBroadcastRepo)
 	contactRepo := new(mockContactRepo)
@@ -241,3 +428,643 @@ func TestBroadcastSendHandler_ProcessTask_BroadcastNotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "fetching broadcast")
 }
+
+func TestBroadcastSendHandler_ProcessTask_SegmentRefreshFails(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	contactRepo := new(mockContactRepo)
+	audienceRepo := new(mockAudienceRepo)
+	segmentRepo := new(mockSegmentRepo)
+	emailRepo := new(mockEmailRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := &BroadcastSendHandler{
+		broadcastRepo:       broadcastRepo,
+		contactRepo:         contactRepo,
+		audienceRepo:        audienceRepo,
+		segmentRepo:         segmentRepo,
+		emailRepo:           emailRepo,
+		templateVersionRepo: nil,
+		asynqClient:         nil,
+		logger:              logger,
+	}
+
+	broadcastID := uuid.New()
+	teamID := uuid.New()
+	audienceID := uuid.New()
+	segmentID := uuid.New()
+
+	broadcast := &model.Broadcast{
+		ID:         broadcastID,
+		TeamID:     teamID,
+		Status:     model.BroadcastStatusQueued,
+		AudienceID: &audienceID,
+		SegmentID:  &segmentID,
+	}
+
+	broadcastRepo.On("GetByID", mock.Anything, broadcastID).Return(broadcast, nil)
+	audienceRepo.On("GetByID", mock.Anything, audienceID).Return(&model.Audience{ID: audienceID, TeamID: teamID}, nil)
+	segmentRepo.On("RefreshMembers", mock.Anything, segmentID).Return(0, assert.AnError)
+
+	payload, _ := json.Marshal(BroadcastSendPayload{BroadcastID: broadcastID, TeamID: teamID})
+	task := asynq.NewTask(TaskBroadcastSend, payload)
+
+	err := h.ProcessTask(context.Background(), task)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "refreshing segment")
+
+	// The broadcast stays queued so the retry can send it.
+	broadcastRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	contactRepo.AssertNotCalled(t, "ListBySegmentID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBroadcastSendHandler_ProcessTask_TopicNotFound(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	contactRepo := new(mockContactRepo)
+	audienceRepo := new(mockAudienceRepo)
+	topicRepo := new(mockTopicRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := &BroadcastSendHandler{
+		broadcastRepo: broadcastRepo,
+		contactRepo:   contactRepo,
+		audienceRepo:  audienceRepo,
+		topicRepo:     topicRepo,
+		logger:        logger,
+	}
+
+	broadcastID := uuid.New()
+	teamID := uuid.New()
+	audienceID := uuid.New()
+	topicID := uuid.New()
+
+	broadcast := &model.Broadcast{
+		ID:         broadcastID,
+		TeamID:     teamID,
+		Status:     model.BroadcastStatusQueued,
+		AudienceID: &audienceID,
+		TopicID:    &topicID,
+	}
+
+	broadcastRepo.On("GetByID", mock.Anything, broadcastID).Return(broadcast, nil)
+	audienceRepo.On("GetByID", mock.Anything, audienceID).Return(&model.Audience{ID: audienceID, TeamID: teamID}, nil)
+	topicRepo.On("GetByID", mock.Anything, topicID).Return(nil, assert.AnError)
+
+	payload, _ := json.Marshal(BroadcastSendPayload{BroadcastID: broadcastID, TeamID: teamID})
+	task := asynq.NewTask(TaskBroadcastSend, payload)
+
+	err := h.ProcessTask(context.Background(), task)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "fetching topic")
+
+	broadcastRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	contactRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBroadcastSendHandler_TopicSubscribers(t *testing.T) {
+	optedIn := model.Contact{ID: uuid.New()}
+	optedOut := model.Contact{ID: uuid.New()}
+	noPreference := model.Contact{ID: uuid.New()}
+	contacts := []model.Contact{optedIn, optedOut, noPreference}
+	ids := []uuid.UUID{optedIn.ID, optedOut.ID, noPreference.ID}
+
+	tests := []struct {
+		name     string
+		policy   string
+		expected map[uuid.UUID]bool
+	}{
+		{
+			name:     "opt-in topic includes contacts without a preference",
+			policy:   model.TopicDefaultOptIn,
+			expected: map[uuid.UUID]bool{optedIn.ID: true, optedOut.ID: false, noPreference.ID: true},
+		},
+		{
+			name:     "opt-out topic only includes explicit subscribers",
+			policy:   model.TopicDefaultOptOut,
+			expected: map[uuid.UUID]bool{optedIn.ID: true, optedOut.ID: false, noPreference.ID: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topic := &model.Topic{ID: uuid.New(), DefaultSubscription: tt.policy}
+			contactTopicRepo := new(mockContactTopicRepo)
+			contactTopicRepo.On("ListByTopicAndContacts", mock.Anything, topic.ID, ids).Return([]model.ContactTopic{
+				{ContactID: optedIn.ID, TopicID: topic.ID, Subscribed: true},
+				{ContactID: optedOut.ID, TopicID: topic.ID, Subscribed: false},
+			}, nil)
+
+			h := &BroadcastSendHandler{contactTopicRepo: contactTopicRepo}
+			subscribed, err := h.topicSubscribers(context.Background(), topic, contacts)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, subscribed)
+		})
+	}
+}
+
+func TestBroadcastSendHandler_UnsubscribeHeaders(t *testing.T) {
+	teamID := uuid.New()
+
+	t.Run("one-click headers carry a signed token", func(t *testing.T) {
+		h := &BroadcastSendHandler{baseURL: "https://mail.example.com", preferenceSecret: "secret"}
+
+		headers, prefsURL := h.unsubscribeHeaders(teamID, "alice@example.com")
+
+		assert.Equal(t, "List-Unsubscribe=One-Click", headers["List-Unsubscribe-Post"])
+		listUnsub := headers["List-Unsubscribe"].(string)
+		assert.True(t, strings.HasPrefix(listUnsub, "<https://mail.example.com/preferences/unsubscribe?token="))
+
+		u, err := url.Parse(prefsURL)
+		assert.NoError(t, err)
+		assert.Equal(t, "/preferences", u.Path)
+		gotTeam, gotEmail, err := pkg.VerifyPreferenceToken("secret", u.Query().Get("token"))
+		assert.NoError(t, err)
+		assert.Equal(t, teamID, gotTeam)
+		assert.Equal(t, "alice@example.com", gotEmail)
+	})
+
+	t.Run("no headers without a base URL", func(t *testing.T) {
+		h := &BroadcastSendHandler{preferenceSecret: "secret"}
+
+		headers, prefsURL := h.unsubscribeHeaders(teamID, "alice@example.com")
+
+		assert.Empty(t, headers)
+		assert.Empty(t, prefsURL)
+	})
+}
+
+func TestBroadcastSendHandler_ProcessTask_RendersContent(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	contactRepo := new(mockContactRepo)
+	audienceRepo := new(mockAudienceRepo)
+	propertyRepo := new(mockContactPropertyRepo)
+	propertyValueRepo := new(mockContactPropertyValueRepo)
+	emailRepo := new(mockEmailRepo)
+	domainRepo := new(mockDomainRepo)
+	mr := miniredis.RunT(t)
+	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = asynqClient.Close() }()
+
+	h := &BroadcastSendHandler{
+		broadcastRepo:     broadcastRepo,
+		contactRepo:       contactRepo,
+		audienceRepo:      audienceRepo,
+		propertyRepo:      propertyRepo,
+		propertyValueRepo: propertyValueRepo,
+		emailRepo:         emailRepo,
+		domainRepo:        domainRepo,
+		asynqClient:       asynqClient,
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	broadcastID := uuid.New()
+	teamID := uuid.New()
+	audienceID := uuid.New()
+	planID := uuid.New()
+	vipID := uuid.New()
+	firstName := "Ada"
+	contact := model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: "ada@example.com", FirstName: &firstName}
+	anonymous := model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: "anon@example.com"}
+
+	broadcast := &model.Broadcast{
+		ID:          broadcastID,
+		TeamID:      teamID,
+		Status:      model.BroadcastStatusQueued,
+		AudienceID:  &audienceID,
+		FromAddress: strPtr("news@example.com"),
+		Subject:     strPtr(`Hi {{contact.first_name | default "there"}}`),
+		HTMLBody:    strPtr(`<p>{{contact.plan | upper}}</p>{{#if contact.vip}}<p>VIP</p>{{/if}}`),
+	}
+
+	broadcastRepo.On("GetByID", mock.Anything, broadcastID).Return(broadcast, nil)
+	audienceRepo.On("GetByID", mock.Anything, audienceID).Return(&model.Audience{ID: audienceID, TeamID: teamID}, nil)
+	broadcastRepo.On("Update", mock.Anything, broadcast).Return(nil)
+	propertyRepo.On("ListByTeamID", mock.Anything, teamID).Return([]model.ContactProperty{
+		{ID: planID, Name: "plan", Type: model.ValueTypeString},
+		{ID: vipID, Name: "vip", Type: model.ValueTypeBoolean},
+	}, nil)
+	contactRepo.On("List", mock.Anything, audienceID, 500, 0).Return([]model.Contact{contact, anonymous}, 2, nil)
+	propertyValueRepo.On("ListByContactIDs", mock.Anything, []uuid.UUID{contact.ID, anonymous.ID}).Return([]model.ContactPropertyValue{
+		{ContactID: contact.ID, PropertyID: planID, Value: strPtr("pro")},
+		{ContactID: contact.ID, PropertyID: vipID, Value: strPtr("true")},
+		{ContactID: anonymous.ID, PropertyID: vipID, Value: strPtr("false")},
+	}, nil)
+
+	domain := &model.Domain{ID: uuid.New(), TeamID: teamID, Name: "example.com"}
+	domainRepo.On("GetByTeamAndName", mock.Anything, teamID, "example.com").Return(domain, nil)
+	onAddRecipients(broadcastRepo, broadcast)
+
+	var created []*model.Email
+	emailRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) { created = append(created, args.Get(1).(*model.Email)) }).
+		Return(nil)
+	emailRepo.On("ReleaseHeld", mock.Anything, broadcastID, releasePageSize).Return([]uuid.UUID{uuid.New(), uuid.New()}, nil)
+	emailRepo.On("ListAddressesByBroadcast", mock.Anything, broadcastID, mock.Anything).Return([]string{}, nil)
+
+	payload, _ := json.Marshal(BroadcastSendPayload{BroadcastID: broadcastID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload))
+
+	assert.NoError(t, err)
+	assert.Len(t, created, 2)
+	assert.Equal(t, "Hi Ada", created[0].Subject)
+	assert.Equal(t, "<p>PRO</p><p>VIP</p>", *created[0].HTMLBody)
+	assert.Equal(t, "Hi there", created[1].Subject)
+	assert.Equal(t, "<p></p>", *created[1].HTMLBody)
+	assert.Equal(t, model.EmailStatusHeld, created[0].Status)
+	assert.Equal(t, &domain.ID, created[0].DomainID)
+	assert.Equal(t, &broadcastID, created[0].BroadcastID)
+	assert.Equal(t, []string{model.BroadcastTag(broadcastID)}, created[0].Tags)
+	assert.Equal(t, 2, broadcast.TotalRecipients)
+
+	// The broadcast isn't throttled, so every email is released at once.
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(QueueCritical)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
+
+// onAddRecipients makes AddRecipients add to the recipients of b and return it.
+func onAddRecipients(repo *mockBroadcastRepo, b *model.Broadcast) {
+	repo.On("AddRecipients", mock.Anything, b.ID, mock.AnythingOfType("int")).
+		Run(func(args mock.Arguments) { b.TotalRecipients += args.Int(2) }).
+		Return(b, nil)
+}
+
+func TestBroadcastSendHandler_ProcessTask_LocalTimeWave(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	contactRepo := new(mockContactRepo)
+	audienceRepo := new(mockAudienceRepo)
+	propertyRepo := new(mockContactPropertyRepo)
+	propertyValueRepo := new(mockContactPropertyValueRepo)
+	emailRepo := new(mockEmailRepo)
+	domainRepo := new(mockDomainRepo)
+	mr := miniredis.RunT(t)
+	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = asynqClient.Close() }()
+
+	h := &BroadcastSendHandler{
+		broadcastRepo:     broadcastRepo,
+		contactRepo:       contactRepo,
+		audienceRepo:      audienceRepo,
+		propertyRepo:      propertyRepo,
+		propertyValueRepo: propertyValueRepo,
+		emailRepo:         emailRepo,
+		domainRepo:        domainRepo,
+		asynqClient:       asynqClient,
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	teamID := uuid.New()
+	audienceID := uuid.New()
+	tzID := uuid.New()
+	tokyo := model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: "tokyo@example.com"}
+	auckland := model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: "auckland@example.com"}
+	honolulu := model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: "honolulu@example.com"}
+	unknown := model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: "unknown@example.com"}
+
+	// Two hours from now in UTC has already passed in Tokyo and Auckland,
+	// but not in Honolulu, nor for contacts without a time zone.
+	scheduledAt := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Minute)
+	firstWave := scheduledAt.Add(-14 * time.Hour)
+	broadcast := &model.Broadcast{
+		ID:              uuid.New(),
+		TeamID:          teamID,
+		Status:          model.BroadcastStatusScheduled,
+		AudienceID:      &audienceID,
+		FromAddress:     strPtr("news@example.com"),
+		Subject:         strPtr("Good morning"),
+		TextBody:        strPtr("Hello"),
+		ScheduledAt:     &scheduledAt,
+		SendInLocalTime: true,
+		NextSendAt:      &firstWave,
+	}
+
+	broadcastRepo.On("GetByID", mock.Anything, broadcast.ID).Return(broadcast, nil)
+	audienceRepo.On("GetByID", mock.Anything, audienceID).Return(&model.Audience{ID: audienceID, TeamID: teamID}, nil)
+	broadcastRepo.On("Update", mock.Anything, broadcast).Return(nil)
+	propertyRepo.On("ListByTeamID", mock.Anything, teamID).Return([]model.ContactProperty{
+		{ID: tzID, Name: model.DefaultTimezoneProperty, Type: model.ValueTypeString},
+	}, nil)
+	contacts := []model.Contact{tokyo, auckland, honolulu, unknown}
+	contactRepo.On("List", mock.Anything, audienceID, 500, 0).Return(contacts, len(contacts), nil)
+	propertyValueRepo.On("ListByContactIDs", mock.Anything, []uuid.UUID{tokyo.ID, auckland.ID, honolulu.ID, unknown.ID}).Return([]model.ContactPropertyValue{
+		{ContactID: tokyo.ID, PropertyID: tzID, Value: strPtr("Asia/Tokyo")},
+		{ContactID: auckland.ID, PropertyID: tzID, Value: strPtr("Pacific/Auckland")},
+		{ContactID: honolulu.ID, PropertyID: tzID, Value: strPtr("Pacific/Honolulu")},
+	}, nil)
+
+	domainRepo.On("GetByTeamAndName", mock.Anything, teamID, "example.com").Return(nil, postgres.ErrNotFound)
+	onAddRecipients(broadcastRepo, broadcast)
+
+	var recipients []string
+	emailRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) { recipients = append(recipients, args.Get(1).(*model.Email).ToAddresses[0]) }).
+		Return(nil)
+	emailRepo.On("ReleaseHeld", mock.Anything, broadcast.ID, releasePageSize).Return([]uuid.UUID{}, nil)
+	emailRepo.On("ListAddressesByBroadcast", mock.Anything, broadcast.ID, mock.Anything).Return([]string{}, nil)
+
+	payload, _ := json.Marshal(BroadcastSendPayload{BroadcastID: broadcast.ID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload))
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{tokyo.Email, auckland.Email}, recipients)
+	assert.Equal(t, model.BroadcastStatusSending, broadcast.Status)
+	assert.Equal(t, 2, broadcast.TotalRecipients)
+	if assert.NotNil(t, broadcast.LocalSentUntil) && assert.NotNil(t, broadcast.NextSendAt) {
+		assert.True(t, broadcast.NextSendAt.After(*broadcast.LocalSentUntil))
+		assert.Equal(t, time.Duration(0), broadcast.NextSendAt.Sub(firstWave)%time.Hour, "waves are hourly")
+	}
+
+	t.Run("next wave is not due yet", func(t *testing.T) {
+		recipients = nil
+		err := h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload))
+		assert.NoError(t, err)
+		assert.Empty(t, recipients)
+	})
+
+	t.Run("next wave skips recipients already sent to", func(t *testing.T) {
+		recipients = nil
+		past := time.Now().UTC().Add(-time.Second)
+		broadcast.NextSendAt = &past
+		err := h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload))
+		assert.NoError(t, err)
+		assert.Empty(t, recipients)
+		assert.Equal(t, 2, broadcast.TotalRecipients)
+	})
+}
+
+func TestBroadcastSendHandler_ProcessTask_ABTest(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	variantRepo := new(mockBroadcastVariantRepo)
+	contactRepo := new(mockContactRepo)
+	audienceRepo := new(mockAudienceRepo)
+	emailRepo := new(mockEmailRepo)
+	domainRepo := new(mockDomainRepo)
+	mr := miniredis.RunT(t)
+	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = asynqClient.Close() }()
+
+	h := &BroadcastSendHandler{
+		broadcastRepo: broadcastRepo,
+		variantRepo:   variantRepo,
+		contactRepo:   contactRepo,
+		audienceRepo:  audienceRepo,
+		emailRepo:     emailRepo,
+		domainRepo:    domainRepo,
+		asynqClient:   asynqClient,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	teamID := uuid.New()
+	audienceID := uuid.New()
+	percentage, wait := 25, 60
+	broadcast := &model.Broadcast{
+		ID:                uuid.New(),
+		TeamID:            teamID,
+		Status:            model.BroadcastStatusQueued,
+		AudienceID:        &audienceID,
+		FromAddress:       strPtr("news@example.com"),
+		Subject:           strPtr("Our launch"),
+		TextBody:          strPtr("Hello"),
+		ABTestPercentage:  &percentage,
+		ABTestWaitMinutes: &wait,
+		ABWinnerMetric:    strPtr(model.ABWinnerMetricClickRate),
+	}
+	variants := []model.BroadcastVariant{
+		{ID: uuid.New(), BroadcastID: broadcast.ID, Name: "A"},
+		{ID: uuid.New(), BroadcastID: broadcast.ID, Name: "B", Subject: strPtr("You're invited"), FromName: strPtr("Ada at Example")},
+	}
+
+	contacts := make([]model.Contact, 40)
+	for i := range contacts {
+		contacts[i] = model.Contact{ID: uuid.New(), AudienceID: audienceID, Email: fmt.Sprintf("c%d@example.com", i)}
+	}
+
+	broadcastRepo.On("GetByID", mock.Anything, broadcast.ID).Return(broadcast, nil)
+	broadcastRepo.On("Update", mock.Anything, broadcast).Return(nil)
+	audienceRepo.On("GetByID", mock.Anything, audienceID).Return(&model.Audience{ID: audienceID, TeamID: teamID}, nil)
+	variantRepo.On("ListByBroadcastID", mock.Anything, broadcast.ID).Return(variants, nil)
+	contactRepo.On("List", mock.Anything, audienceID, 500, 0).Return(contacts, len(contacts), nil)
+	domainRepo.On("GetByTeamAndName", mock.Anything, teamID, "example.com").Return(nil, postgres.ErrNotFound)
+	onAddRecipients(broadcastRepo, broadcast)
+	emailRepo.On("ReleaseHeld", mock.Anything, broadcast.ID, releasePageSize).Return([]uuid.UUID{}, nil)
+	emailRepo.On("ListAddressesByBroadcast", mock.Anything, broadcast.ID, mock.Anything).Return([]string{}, nil)
+
+	created := map[string]*model.Email{}
+	emailRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) {
+			email := args.Get(1).(*model.Email)
+			created[email.ToAddresses[0]] = email
+		}).
+		Return(nil)
+
+	payload, _ := json.Marshal(BroadcastSendPayload{BroadcastID: broadcast.ID, TeamID: teamID})
+	require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload)))
+
+	// Test phase: the test group gets one variant each; the rest waits.
+	var tested int
+	for _, c := range contacts {
+		group := abTestGroup(broadcast.ID, c.ID, percentage, len(variants))
+		email, ok := created[c.Email]
+		if group < 0 {
+			assert.False(t, ok, "contact outside the test group was sent the test")
+			continue
+		}
+		tested++
+		require.True(t, ok, "contact in the test group was not sent the test")
+		assert.Equal(t, &broadcast.ID, email.BroadcastID)
+		assert.Equal(t, &variants[group].ID, email.BroadcastVariantID)
+		assert.Equal(t, []string{model.BroadcastTag(broadcast.ID), model.BroadcastVariantTag(variants[group].ID)}, email.Tags)
+		if group == 1 {
+			assert.Equal(t, "You're invited", email.Subject)
+			assert.Equal(t, `"Ada at Example" <news@example.com>`, email.FromAddress)
+		} else {
+			assert.Equal(t, "Our launch", email.Subject)
+			assert.Equal(t, "news@example.com", email.FromAddress)
+		}
+	}
+	assert.Equal(t, tested, broadcast.TotalRecipients)
+	require.NotNil(t, broadcast.ABTestEndsAt)
+	assert.Equal(t, broadcast.ABTestEndsAt, broadcast.NextSendAt)
+	assert.Nil(t, broadcast.ABWinnerVariantID)
+
+	unsent := []string{model.EmailStatusHeld, model.EmailStatusQueued, model.EmailStatusSending}
+	t.Run("test waits until its emails are sent", func(t *testing.T) {
+		created = map[string]*model.Email{}
+		ended := time.Now().UTC().Add(-time.Second)
+		broadcast.ABTestEndsAt, broadcast.NextSendAt = &ended, &ended
+		emailRepo.On("CountByBroadcast", mock.Anything, broadcast.ID, unsent).Return(3, nil).Once()
+
+		require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload)))
+
+		// The wait hasn't started, so the test is checked on again shortly.
+		assert.Empty(t, created)
+		assert.WithinDuration(t, time.Now().Add(abTestCheckInterval), *broadcast.NextSendAt, 5*time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Duration(wait)*time.Minute), *broadcast.ABTestEndsAt, 5*time.Second)
+
+		// Once they are sent, the wait counts from the last one.
+		lastSent := time.Now().UTC().Add(-10 * time.Minute)
+		broadcast.NextSendAt = &ended
+		emailRepo.On("CountByBroadcast", mock.Anything, broadcast.ID, unsent).Return(0, nil).Once()
+		emailRepo.On("LastSentAtByBroadcast", mock.Anything, broadcast.ID).Return(&lastSent, nil).Once()
+
+		require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload)))
+
+		assert.Empty(t, created)
+		assert.Equal(t, lastSent.Add(time.Duration(wait)*time.Minute), *broadcast.ABTestEndsAt)
+		assert.Equal(t, broadcast.ABTestEndsAt, broadcast.NextSendAt)
+		assert.Nil(t, broadcast.ABWinnerVariantID)
+	})
+
+	t.Run("winner goes to the rest", func(t *testing.T) {
+		created = map[string]*model.Email{}
+		ended := time.Now().UTC().Add(-time.Second)
+		broadcast.ABTestEndsAt, broadcast.NextSendAt = &ended, &ended
+		sentAt := time.Now().UTC().Add(-2 * time.Hour)
+		emailRepo.On("CountByBroadcast", mock.Anything, broadcast.ID, unsent).Return(0, nil).Once()
+		emailRepo.On("LastSentAtByBroadcast", mock.Anything, broadcast.ID).Return(&sentAt, nil).Once()
+		variantRepo.On("ListStats", mock.Anything, broadcast.ID).Return([]model.BroadcastVariantStats{
+			{VariantID: variants[0].ID, Sent: 10, Opened: 6, Clicked: 1},
+			{VariantID: variants[1].ID, Sent: 10, Opened: 4, Clicked: 3},
+		}, nil).Once()
+
+		require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload)))
+
+		assert.Len(t, created, len(contacts)-tested)
+		for _, email := range created {
+			assert.Equal(t, "You're invited", email.Subject)
+			assert.Equal(t, &broadcast.ID, email.BroadcastID)
+			assert.Nil(t, email.BroadcastVariantID)
+			assert.Equal(t, []string{model.BroadcastTag(broadcast.ID)}, email.Tags)
+		}
+		assert.Equal(t, &variants[1].ID, broadcast.ABWinnerVariantID)
+		assert.False(t, broadcast.ABTestInconclusive)
+		assert.Nil(t, broadcast.NextSendAt)
+		assert.Equal(t, len(contacts), broadcast.TotalRecipients)
+	})
+
+	t.Run("inconclusive test sends the broadcast to the rest", func(t *testing.T) {
+		created = map[string]*model.Email{}
+		ended := time.Now().UTC().Add(-time.Second)
+		broadcast.ABTestEndsAt, broadcast.NextSendAt = &ended, &ended
+		broadcast.ABWinnerVariantID, broadcast.TotalRecipients = nil, tested
+		emailRepo.On("CountByBroadcast", mock.Anything, broadcast.ID, unsent).Return(0, nil).Once()
+		emailRepo.On("LastSentAtByBroadcast", mock.Anything, broadcast.ID).Return(nil, nil).Once()
+		// Variant A was opened, but the test compares clicks.
+		variantRepo.On("ListStats", mock.Anything, broadcast.ID).Return([]model.BroadcastVariantStats{
+			{VariantID: variants[0].ID, Sent: 10, Opened: 6},
+			{VariantID: variants[1].ID, Sent: 10},
+		}, nil).Once()
+
+		require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskBroadcastSend, payload)))
+
+		assert.Len(t, created, len(contacts)-tested)
+		for _, email := range created {
+			assert.Equal(t, "Our launch", email.Subject)
+			assert.Equal(t, "news@example.com", email.FromAddress)
+			assert.Nil(t, email.BroadcastVariantID)
+		}
+		assert.Nil(t, broadcast.ABWinnerVariantID)
+		assert.True(t, broadcast.ABTestInconclusive)
+		assert.Nil(t, broadcast.NextSendAt)
+	})
+}
+
+func TestBroadcastSendHandler_ProcessTask_RetryAfterFailedExpansion(t *testing.T) {
+	broadcastRepo := new(mockBroadcastRepo)
+	contactRepo := new(mockContactRepo)
+	audienceRepo := new(mockAudienceRepo)
+	emailRepo := new(mockEmailRepo)
+	domainRepo := new(mockDomainRepo)
+	mr := miniredis.RunT(t)
+	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = asynqClient.Close() }()
+
+	h := &BroadcastSendHandler{
+		broadcastRepo: broadcastRepo,
+		contactRepo:   contactRepo,
+		audienceRepo:  audienceRepo,
+		emailRepo:     emailRepo,
+		domainRepo:    domainRepo,
+		asynqClient:   asynqClient,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	teamID := uuid.New()
+	audienceID := uuid.New()
+	queuedAt := time.Now().UTC().Add(-time.Second)
+	broadcast := &model.Broadcast{
+		ID:          uuid.New(),
+		TeamID:      teamID,
+		Status:      model.BroadcastStatusQueued,
+		AudienceID:  &audienceID,
+		FromAddress: strPtr("news@example.com"),
+		Subject:     strPtr("Hello"),
+		TextBody:    strPtr("Hello"),
+		NextSendAt:  &queuedAt,
+	}
+	firstPage := []model.Contact{
+		{ID: uuid.New(), AudienceID: audienceID, Email: "a@example.com"},
+		{ID: uuid.New(), AudienceID: audienceID, Email: "b@example.com"},
+	}
+	secondPage := []model.Contact{{ID: uuid.New(), AudienceID: audienceID, Email: "c@example.com"}}
+
+	broadcastRepo.On("GetByID", mock.Anything, broadcast.ID).Return(broadcast, nil)
+	broadcastRepo.On("Update", mock.Anything, broadcast).Return(nil)
+	audienceRepo.On("GetByID", mock.Anything, audienceID).Return(&model.Audience{ID: audienceID, TeamID: teamID}, nil)
+	domainRepo.On("GetByTeamAndName", mock.Anything, teamID, "example.com").Return(nil, postgres.ErrNotFound)
+	onAddRecipients(broadcastRepo, broadcast)
+	emailRepo.On("ReleaseHeld", mock.Anything, broadcast.ID, releasePageSize).Return([]uuid.UUID{}, nil)
+
+	var recipients []string
+	emailRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Email")).
+		Run(func(args mock.Arguments) { recipients = append(recipients, args.Get(1).(*model.Email).ToAddresses[0]) }).
+		Return(nil)
+
+	// The first page is expanded, then listing the second one fails.
+	contactRepo.On("List", mock.Anything, audienceID, 500, 0).Return(firstPage, 501, nil)
+	contactRepo.On("List", mock.Anything, audienceID, 500, 500).Return([]model.Contact(nil), 0, assert.AnError).Once()
+	emailRepo.On("ListAddressesByBroadcast", mock.Anything, broadcast.ID, []string{"a@example.com", "b@example.com"}).Return([]string{}, nil).Once()
+
+	payload, _ := json.Marshal(BroadcastSendPayload{BroadcastID: broadcast.ID, TeamID: teamID})
+	task := asynq.NewTask(TaskBroadcastSend, payload)
+	require.Error(t, h.ProcessTask(context.Background(), task))
+
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, recipients)
+	assert.Equal(t, model.BroadcastStatusSending, broadcast.Status)
+	assert.Equal(t, 2, broadcast.TotalRecipients)
+	assert.True(t, broadcastDue(broadcast, time.Now().UTC()), "the broadcast is still due, so the retry isn't skipped")
+
+	// The retry skips the contacts the first attempt reached.
+	contactRepo.On("List", mock.Anything, audienceID, 500, 500).Return(secondPage, 501, nil)
+	emailRepo.On("ListAddressesByBroadcast", mock.Anything, broadcast.ID, []string{"a@example.com", "b@example.com"}).Return([]string{"a@example.com", "b@example.com"}, nil)
+	emailRepo.On("ListAddressesByBroadcast", mock.Anything, broadcast.ID, []string{"c@example.com"}).Return([]string{}, nil)
+
+	require.NoError(t, h.ProcessTask(context.Background(), task))
+
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "c@example.com"}, recipients)
+	assert.Equal(t, model.BroadcastStatusSending, broadcast.Status)
+	assert.Equal(t, 3, broadcast.TotalRecipients)
+	assert.Nil(t, broadcast.NextSendAt)
+	assert.False(t, broadcastDue(broadcast, time.Now().UTC()))
+}
+
+func TestPickABTestWinner(t *testing.T) {
+	variants := []model.BroadcastVariant{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+	stats := []model.BroadcastVariantStats{
+		{VariantID: variants[0].ID, Sent: 100, Opened: 30, Clicked: 2},
+		{VariantID: variants[1].ID, Sent: 50, Opened: 20, Clicked: 1},
+		{VariantID: variants[2].ID, Sent: 100, Opened: 40, Clicked: 4},
+	}
+
+	assert.Equal(t, variants[1].ID, pickABTestWinner(model.ABWinnerMetricOpenRate, variants, stats).ID, "40% open rate beats 30%, tie goes to the earlier variant")
+	assert.Equal(t, variants[2].ID, pickABTestWinner(model.ABWinnerMetricClickRate, variants, stats).ID)
+	assert.Nil(t, pickABTestWinner(model.ABWinnerMetricOpenRate, variants, nil), "nothing sent yet")
+
+	unclicked := []model.BroadcastVariantStats{
+		{VariantID: variants[0].ID, Sent: 100, Opened: 30},
+		{VariantID: variants[1].ID, Sent: 100, Opened: 20},
+	}
+	assert.Nil(t, pickABTestWinner(model.ABWinnerMetricClickRate, variants, unclicked), "no variant was clicked")
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// releasePageSize is how many held emails are released at a time.
+const releasePageSize = 500
+
+// broadcastReleaser moves the held emails of a sending broadcast to the send
+// queue, as fast as the broadcast's send rate and its domain's warm-up limit
+// allow.
+type broadcastReleaser struct {
+	emailRepo  postgres.EmailRepository
+	domainRepo postgres.DomainRepository
+	enqueuer   TaskEnqueuer
+}
+
+// release queues as many of the broadcast's held emails as it may send now
+// and returns how many it queued.
+//
+// A throttled broadcast keeps at most a minute's worth of emails, SendRate,
+// in the send queue, spread out over the minute. The broadcast:dispatch task
+// tops the queue up every minute.
+func (r *broadcastReleaser) release(ctx context.Context, b *model.Broadcast, now time.Time, log *slog.Logger) (int, error) {
+	if b.Status != model.BroadcastStatusSending {
+		return 0, nil
+	}
+
+	limit := math.MaxInt
+	if b.SendRate != nil {
+		inFlight, err := r.emailRepo.CountByBroadcast(ctx, b.ID, []string{model.EmailStatusQueued, model.EmailStatusSending})
+		if err != nil {
+			return 0, fmt.Errorf("counting queued emails: %w", err)
+		}
+		limit = *b.SendRate - inFlight
+	}
+
+	domain, err := r.domainRepo.GetByTeamAndName(ctx, b.TeamID, extractDomain(ptrToString(b.FromAddress)))
+	if err != nil && !errors.Is(err, postgres.ErrNotFound) {
+		return 0, fmt.Errorf("fetching sending domain: %w", err)
+	}
+	if domain != nil {
+		if daily := domain.WarmupDailyLimit(now); daily > 0 {
+			sent, err := r.emailRepo.CountOutgoingByDomain(ctx, domain.ID, now.Add(-24*time.Hour))
+			if err != nil {
+				return 0, fmt.Errorf("counting emails sent from %s: %w", domain.Name, err)
+			}
+			if left := daily - sent; left < limit {
+				limit = left
+				if limit <= 0 {
+					log.Info("warm-up limit reached for domain, holding broadcast emails", "domain", domain.Name, "daily_limit", daily)
+				}
+			}
+		}
+	}
+
+	var released int
+	for released < limit {
+		ids, err := r.emailRepo.ReleaseHeld(ctx, b.ID, min(releasePageSize, limit-released))
+		if err != nil {
+			return released, fmt.Errorf("releasing held emails: %w", err)
+		}
+
+		// An email whose task can't be queued goes back on hold, or it
+		// would sit in the queue with no task to send it.
+		var unqueued []uuid.UUID
+		var enqueueErr error
+		for _, id := range ids {
+			task, err := NewEmailSendTask(id, b.TeamID)
+			if err == nil {
+				// The task ID keeps an email that was paused and released
+				// again from being queued twice.
+				opts := []asynq.Option{asynq.TaskID(TaskEmailSend + ":" + id.String())}
+				if b.SendRate != nil {
+					opts = append(opts, asynq.ProcessIn(time.Duration(released)*time.Minute/time.Duration(*b.SendRate)))
+				}
+				_, err = r.enqueuer.Enqueue(task, opts...)
+			}
+			if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+				unqueued = append(unqueued, id)
+				enqueueErr = err
+				continue
+			}
+			released++
+		}
+		if len(unqueued) > 0 {
+			if err := r.emailRepo.HoldQueued(ctx, unqueued); err != nil {
+				log.Error("failed to hold emails that could not be queued", "count", len(unqueued), "error", err)
+			}
+			return released, fmt.Errorf("queueing %d email:send tasks: %w", len(unqueued), enqueueErr)
+		}
+
+		if len(ids) < releasePageSize {
+			break
+		}
+	}
+	return released, nil
+}
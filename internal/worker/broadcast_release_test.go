@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+func newTestReleaser(t *testing.T) (*broadcastReleaser, *mockEmailRepo, *mockDomainRepo, *asynq.Inspector) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = inspector.Close()
+	})
+
+	emailRepo, domainRepo := new(mockEmailRepo), new(mockDomainRepo)
+	return &broadcastReleaser{emailRepo: emailRepo, domainRepo: domainRepo, enqueuer: client}, emailRepo, domainRepo, inspector
+}
+
+func TestBroadcastReleaser_SendRate(t *testing.T) {
+	r, emailRepo, domainRepo, inspector := newTestReleaser(t)
+
+	rate := 10
+	b := &model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusSending, FromAddress: strPtr("news@example.com"), SendRate: &rate}
+
+	domainRepo.On("GetByTeamAndName", mock.Anything, b.TeamID, "example.com").Return(nil, postgres.ErrNotFound)
+	emailRepo.On("CountByBroadcast", mock.Anything, b.ID, []string{model.EmailStatusQueued, model.EmailStatusSending}).Return(4, nil)
+	emailRepo.On("ReleaseHeld", mock.Anything, b.ID, 6).Return([]uuid.UUID{uuid.New(), uuid.New(), uuid.New()}, nil)
+
+	released, err := r.release(context.Background(), b, time.Now(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, released)
+	emailRepo.AssertExpectations(t)
+
+	// The released emails are spread out over the minute.
+	pending, err := inspector.ListPendingTasks(QueueCritical)
+	require.NoError(t, err)
+	scheduled, err := inspector.ListScheduledTasks(QueueCritical)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Len(t, scheduled, 2)
+}
+
+func TestBroadcastReleaser_WarmupLimit(t *testing.T) {
+	r, emailRepo, domainRepo, inspector := newTestReleaser(t)
+
+	now := time.Now().UTC()
+	started := now.Add(-49 * time.Hour) // third day: 200 a day
+	domain := &model.Domain{ID: uuid.New(), Name: "example.com", WarmupStartedAt: &started}
+	b := &model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusSending, FromAddress: strPtr("news@example.com")}
+
+	domainRepo.On("GetByTeamAndName", mock.Anything, b.TeamID, "example.com").Return(domain, nil)
+	emailRepo.On("CountOutgoingByDomain", mock.Anything, domain.ID, now.Add(-24*time.Hour)).Return(195, nil)
+	emailRepo.On("ReleaseHeld", mock.Anything, b.ID, 5).Return([]uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}, nil)
+
+	released, err := r.release(context.Background(), b, now, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, released)
+	pending, err := inspector.ListPendingTasks(QueueCritical)
+	require.NoError(t, err)
+	assert.Len(t, pending, 5)
+
+	t.Run("limit reached", func(t *testing.T) {
+		r, emailRepo, domainRepo, _ := newTestReleaser(t)
+		domainRepo.On("GetByTeamAndName", mock.Anything, b.TeamID, "example.com").Return(domain, nil)
+		emailRepo.On("CountOutgoingByDomain", mock.Anything, domain.ID, mock.Anything).Return(200, nil)
+
+		released, err := r.release(context.Background(), b, now, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		require.NoError(t, err)
+		assert.Zero(t, released)
+		emailRepo.AssertNotCalled(t, "ReleaseHeld", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// failingEnqueuer fails to enqueue every task, as when Redis is down.
+type failingEnqueuer struct{}
+
+func (failingEnqueuer) Enqueue(*asynq.Task, ...asynq.Option) (*asynq.TaskInfo, error) {
+	return nil, assert.AnError
+}
+
+func TestBroadcastReleaser_EnqueueFails(t *testing.T) {
+	emailRepo, domainRepo := new(mockEmailRepo), new(mockDomainRepo)
+	r := &broadcastReleaser{emailRepo: emailRepo, domainRepo: domainRepo, enqueuer: failingEnqueuer{}}
+	b := &model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusSending, FromAddress: strPtr("news@example.com")}
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	domainRepo.On("GetByTeamAndName", mock.Anything, b.TeamID, "example.com").Return(nil, postgres.ErrNotFound)
+	emailRepo.On("ReleaseHeld", mock.Anything, b.ID, releasePageSize).Return(ids, nil).Once()
+	emailRepo.On("HoldQueued", mock.Anything, ids).Return(nil)
+
+	// The emails go back on hold for the next dispatch, rather than sitting
+	// in the queue without a task, and nothing more is released meanwhile.
+	released, err := r.release(context.Background(), b, time.Now(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.Error(t, err)
+	assert.Zero(t, released)
+	emailRepo.AssertExpectations(t)
+	emailRepo.AssertNumberOfCalls(t, "ReleaseHeld", 1)
+}
+
+func TestBroadcastReleaser_NotSending(t *testing.T) {
+	r, emailRepo, _, _ := newTestReleaser(t)
+
+	b := &model.Broadcast{ID: uuid.New(), TeamID: uuid.New(), Status: model.BroadcastStatusPaused}
+	released, err := r.release(context.Background(), b, time.Now(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, err)
+	assert.Zero(t, released)
+	emailRepo.AssertNotCalled(t, "ReleaseHeld", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDomain_WarmupDailyLimit(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	d := &model.Domain{WarmupStartedAt: &started}
+
+	assert.Equal(t, 50, d.WarmupDailyLimit(started.Add(time.Hour)))
+	assert.Equal(t, 100, d.WarmupDailyLimit(started.Add(25*time.Hour)))
+	assert.Equal(t, 51200, d.WarmupDailyLimit(started.Add(10*24*time.Hour)))
+	assert.Zero(t, d.WarmupDailyLimit(started.Add(11*24*time.Hour)), "warm-up ends once the limit reaches the final one")
+	assert.Zero(t, (&model.Domain{}).WarmupDailyLimit(started), "not warming up")
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+// A broadcast sent in local time reaches recipients at its scheduled
+// wall-clock time in every time zone, from UTC+14 to UTC-12. It goes out in
+// hourly waves over that span.
+const (
+	localTimeEarliestOffset = 14 * time.Hour
+	localTimeLatestOffset   = 12 * time.Hour
+	localTimeWaveInterval   = time.Hour
+)
+
+// FirstBroadcastSendAt returns when a scheduled broadcast is first due: its
+// scheduled time or, for a broadcast sent in local time, its first wave, when
+// the scheduled time arrives in the easternmost time zone. Waves that have
+// already passed are folded into one at now.
+func FirstBroadcastSendAt(b *model.Broadcast, now time.Time) time.Time {
+	if !b.SendInLocalTime {
+		return *b.ScheduledAt
+	}
+	if first := b.ScheduledAt.Add(-localTimeEarliestOffset); first.After(now) {
+		return first
+	}
+	return now
+}
+
+// LocalTimeSendEnds returns when the last wave of a broadcast sent in local
+// time at scheduledAt goes out, once the time arrives in the westernmost
+// time zone.
+func LocalTimeSendEnds(scheduledAt time.Time) time.Time {
+	return scheduledAt.Add(localTimeLatestOffset)
+}
+
+// nextLocalTimeWave returns the first wave of a broadcast sent in local time
+// at scheduledAt that comes after sentUntil, or false if the last wave has
+// gone out.
+func nextLocalTimeWave(scheduledAt, sentUntil time.Time) (time.Time, bool) {
+	if !sentUntil.Before(LocalTimeSendEnds(scheduledAt)) {
+		return time.Time{}, false
+	}
+	first := scheduledAt.Add(-localTimeEarliestOffset)
+	if sentUntil.Before(first) {
+		return first, true
+	}
+	waves := sentUntil.Sub(first)/localTimeWaveInterval + 1
+	return first.Add(waves * localTimeWaveInterval), true
+}
+
+// localSendAt returns when the wall-clock time of scheduledAt, read in UTC,
+// arrives in loc.
+func localSendAt(scheduledAt time.Time, loc *time.Location) time.Time {
+	t := scheduledAt.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func TestFirstBroadcastSendAt(t *testing.T) {
+	scheduledAt := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, scheduledAt, FirstBroadcastSendAt(&model.Broadcast{ScheduledAt: &scheduledAt}, now))
+
+	local := &model.Broadcast{ScheduledAt: &scheduledAt, SendInLocalTime: true}
+	assert.Equal(t, scheduledAt.Add(-14*time.Hour), FirstBroadcastSendAt(local, now))
+
+	// Waves that have passed go out at once.
+	late := scheduledAt.Add(-2 * time.Hour)
+	assert.Equal(t, late, FirstBroadcastSendAt(local, late))
+}
+
+func TestNextLocalTimeWave(t *testing.T) {
+	scheduledAt := time.Date(2026, 11, 2, 9, 30, 0, 0, time.UTC)
+	first := scheduledAt.Add(-14 * time.Hour)
+
+	next, ok := nextLocalTimeWave(scheduledAt, first.Add(-time.Hour))
+	require.True(t, ok)
+	assert.Equal(t, first, next)
+
+	next, ok = nextLocalTimeWave(scheduledAt, first)
+	require.True(t, ok)
+	assert.Equal(t, first.Add(time.Hour), next)
+
+	next, ok = nextLocalTimeWave(scheduledAt, first.Add(90*time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, first.Add(2*time.Hour), next)
+
+	next, ok = nextLocalTimeWave(scheduledAt, scheduledAt.Add(11*time.Hour))
+	require.True(t, ok)
+	assert.Equal(t, LocalTimeSendEnds(scheduledAt), next)
+
+	_, ok = nextLocalTimeWave(scheduledAt, LocalTimeSendEnds(scheduledAt))
+	assert.False(t, ok)
+}
+
+func TestLocalSendAt(t *testing.T) {
+	scheduledAt := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC), localSendAt(scheduledAt, tokyo).UTC())
+	assert.Equal(t, time.Date(2026, 11, 2, 14, 0, 0, 0, time.UTC), localSendAt(scheduledAt, newYork).UTC())
+	assert.Equal(t, scheduledAt, localSendAt(scheduledAt, time.UTC))
+}
+
+func TestLocationCache(t *testing.T) {
+	c := locationCache{}
+	assert.Equal(t, "Europe/Paris", c.get("Europe/Paris").String())
+	assert.Equal(t, time.UTC, c.get("Not/AZone"))
+	assert.Equal(t, time.UTC, c.get(nil))
+	assert.Equal(t, time.UTC, c.get(42.0))
+	assert.Len(t, c, 2)
+}
@@ -22,18 +22,25 @@ const (
 type CleanupHandler struct {
 	webhookEventRepo postgres.WebhookEventRepository
 	logRepo          postgres.LogRepository
+	logRetention     time.Duration
 	logger           *slog.Logger
 }
 
-// NewCleanupHandler creates a new CleanupHandler.
+// NewCleanupHandler creates a new CleanupHandler that keeps logs for
+// logRetention, or LogRetention if it isn't positive.
 func NewCleanupHandler(
 	webhookEventRepo postgres.WebhookEventRepository,
 	logRepo postgres.LogRepository,
+	logRetention time.Duration,
 	logger *slog.Logger,
 ) *CleanupHandler {
+	if logRetention <= 0 {
+		logRetention = LogRetention
+	}
 	return &CleanupHandler{
 		webhookEventRepo: webhookEventRepo,
 		logRepo:          logRepo,
+		logRetention:     logRetention,
 		logger:           logger,
 	}
 }
@@ -55,9 +62,18 @@ func (h *CleanupHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
 		log.Info("cleaned up old webhook events", "deleted", deletedWebhookEvents, "cutoff", webhookCutoff.Format(time.RFC3339))
 	}
 
-	// 2. Additional cleanup can be added here as retention policies grow:
+	// 2. Clean up old request logs.
+	logCutoff := time.Now().UTC().Add(-h.logRetention)
+	deletedLogs, err := h.logRepo.DeleteOlderThan(ctx, logCutoff)
+	if err != nil {
+		log.Error("failed to clean up logs", "error", err)
+		errs = append(errs, fmt.Errorf("logs cleanup: %w", err))
+	} else {
+		log.Info("cleaned up old logs", "deleted", deletedLogs, "cutoff", logCutoff.Format(time.RFC3339))
+	}
+
+	// 3. Additional cleanup can be added here as retention policies grow:
 	//    - Old email events
-	//    - Old logs
 	//    - Expired Redis keys
 	//    - Orphaned attachments
 
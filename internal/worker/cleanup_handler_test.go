@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 // --- local mocks for cleanup handler ---
@@ -33,8 +34,8 @@ func (m *mockWebhookEventRepo) GetByID(ctx context.Context, id uuid.UUID) (*mode
 func (m *mockWebhookEventRepo) Update(ctx context.Context, event *model.WebhookEvent) error {
 	return m.Called(ctx, event).Error(0)
 }
-func (m *mockWebhookEventRepo) ListByWebhookID(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]model.WebhookEvent, int, error) {
-	args := m.Called(ctx, webhookID, limit, offset)
+func (m *mockWebhookEventRepo) ListByWebhookID(ctx context.Context, webhookID uuid.UUID, filter postgres.WebhookEventFilter, limit, offset int) ([]model.WebhookEvent, int, error) {
+	args := m.Called(ctx, webhookID, filter, limit, offset)
 	return args.Get(0).([]model.WebhookEvent), args.Int(1), args.Error(2)
 }
 func (m *mockWebhookEventRepo) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
@@ -47,25 +48,34 @@ type mockLogRepo struct{ mock.Mock }
 func (m *mockLogRepo) Create(ctx context.Context, log *model.Log) error {
 	return m.Called(ctx, log).Error(0)
 }
-func (m *mockLogRepo) List(ctx context.Context, teamID uuid.UUID, level string, limit, offset int) ([]model.Log, int, error) {
-	args := m.Called(ctx, teamID, level, limit, offset)
+func (m *mockLogRepo) CreateBatch(ctx context.Context, logs []model.Log) error {
+	return m.Called(ctx, logs).Error(0)
+}
+func (m *mockLogRepo) List(ctx context.Context, teamID uuid.UUID, filter postgres.LogFilter, limit, offset int) ([]model.Log, int, error) {
+	args := m.Called(ctx, teamID, filter, limit, offset)
 	return args.Get(0).([]model.Log), args.Int(1), args.Error(2)
 }
+func (m *mockLogRepo) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
 
 func TestCleanupHandler_ProcessTask_Success(t *testing.T) {
 	webhookEventRepo := new(mockWebhookEventRepo)
 	logRepo := new(mockLogRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewCleanupHandler(webhookEventRepo, logRepo, logger)
+	h := NewCleanupHandler(webhookEventRepo, logRepo, 0, logger)
 
 	webhookEventRepo.On("DeleteOlderThan", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(5), nil)
+	logRepo.On("DeleteOlderThan", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(3), nil)
 
 	task := asynq.NewTask(TaskCleanupExpired, nil)
 
 	err := h.ProcessTask(context.Background(), task)
 	assert.NoError(t, err)
 	webhookEventRepo.AssertExpectations(t)
+	logRepo.AssertExpectations(t)
 }
 
 func TestCleanupHandler_ProcessTask_WebhookCleanupError(t *testing.T) {
@@ -73,9 +83,10 @@ func TestCleanupHandler_ProcessTask_WebhookCleanupError(t *testing.T) {
 	logRepo := new(mockLogRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewCleanupHandler(webhookEventRepo, logRepo, logger)
+	h := NewCleanupHandler(webhookEventRepo, logRepo, 0, logger)
 
 	webhookEventRepo.On("DeleteOlderThan", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), errors.New("db error"))
+	logRepo.On("DeleteOlderThan", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
 
 	task := asynq.NewTask(TaskCleanupExpired, nil)
 
@@ -83,4 +94,26 @@ func TestCleanupHandler_ProcessTask_WebhookCleanupError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cleanup completed with 1 errors")
 	webhookEventRepo.AssertExpectations(t)
+	logRepo.AssertExpectations(t)
+}
+
+func TestCleanupHandler_ProcessTask_LogRetention(t *testing.T) {
+	webhookEventRepo := new(mockWebhookEventRepo)
+	logRepo := new(mockLogRepo)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := NewCleanupHandler(webhookEventRepo, logRepo, 7*24*time.Hour, logger)
+
+	webhookEventRepo.On("DeleteOlderThan", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	logRepo.On("DeleteOlderThan", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		age := time.Since(before)
+		return age > 7*24*time.Hour-time.Minute && age < 7*24*time.Hour+time.Minute
+	})).Return(int64(0), errors.New("db error"))
+
+	task := asynq.NewTask(TaskCleanupExpired, nil)
+
+	err := h.ProcessTask(context.Background(), task)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "logs cleanup")
+	logRepo.AssertExpectations(t)
 }
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// DKIMMaintenanceSchedule is the cron spec the domain:dkim_maintenance task
+// is scheduled with.
+const DKIMMaintenanceSchedule = "@hourly"
+
+// DKIMKeyGenerator generates a DKIM key with the given algorithm, returning
+// the private key as it is to be stored, i.e. sealed when keys are encrypted
+// at rest, and the value of its DNS TXT record. bits is the RSA key size.
+type DKIMKeyGenerator func(ctx context.Context, algorithm string, bits int) (storedKey, dnsValue string, err error)
+
+// DKIMMaintenanceHandler processes domain:dkim_maintenance tasks. It retires
+// previous DKIM selectors whose grace period has passed, re-checks the DNS of
+// pending keys so they are promoted once published, and starts the scheduled
+// rotation of domains whose rotation interval has elapsed.
+type DKIMMaintenanceHandler struct {
+	domainRepo    postgres.DomainRepository
+	dnsRecordRepo postgres.DomainDNSRecordRepository
+	enqueuer      TaskEnqueuer
+	generateKey   DKIMKeyGenerator
+	selector      string
+	keyBits       int
+	logger        *slog.Logger
+}
+
+// NewDKIMMaintenanceHandler creates a new DKIMMaintenanceHandler. New keys
+// get selectors derived from selector and RSA keys are keyBits long.
+func NewDKIMMaintenanceHandler(
+	domainRepo postgres.DomainRepository,
+	dnsRecordRepo postgres.DomainDNSRecordRepository,
+	enqueuer TaskEnqueuer,
+	generateKey DKIMKeyGenerator,
+	selector string,
+	keyBits int,
+	logger *slog.Logger,
+) *DKIMMaintenanceHandler {
+	return &DKIMMaintenanceHandler{
+		domainRepo:    domainRepo,
+		dnsRecordRepo: dnsRecordRepo,
+		enqueuer:      enqueuer,
+		generateKey:   generateKey,
+		selector:      selector,
+		keyBits:       keyBits,
+		logger:        logger,
+	}
+}
+
+// ProcessTask handles the domain:dkim_maintenance task.
+func (h *DKIMMaintenanceHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	log := h.logger.With("task", TaskDKIMMaintenance)
+	now := time.Now().UTC()
+
+	domains, err := h.domainRepo.ListDKIMMaintenanceDue(ctx, now)
+	if err != nil {
+		return fmt.Errorf("listing domains due for DKIM maintenance: %w", err)
+	}
+
+	var errs []error
+	for i := range domains {
+		domain := &domains[i]
+		if err := h.maintain(ctx, domain, now); err != nil {
+			log.Error("DKIM maintenance failed", "domain_id", domain.ID, "error", err)
+			errs = append(errs, fmt.Errorf("domain %s: %w", domain.ID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("DKIM maintenance completed with %d errors: %v", len(errs), errs)
+	}
+	return nil
+}
+
+func (h *DKIMMaintenanceHandler) maintain(ctx context.Context, domain *model.Domain, now time.Time) error {
+	log := h.logger.With("domain_id", domain.ID, "domain", domain.Name)
+
+	if domain.DKIMPreviousRetireAt != nil && !now.Before(*domain.DKIMPreviousRetireAt) {
+		if err := h.retirePrevious(ctx, domain, now); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case domain.DKIMPendingSelector != nil:
+		return h.enqueueVerify(domain)
+
+	case h.rotationDue(domain, now):
+		selector := model.DKIMRotationSelector(h.selector, now)
+		privateKey, dnsValue, err := h.generateKey(ctx, domain.DKIMAlgorithm, h.keyBits)
+		if err != nil {
+			return fmt.Errorf("generating DKIM key: %w", err)
+		}
+
+		record := domain.StartDKIMRotation(selector, domain.DKIMAlgorithm, privateKey, dnsValue, now)
+		domain.UpdatedAt = now
+		if err := h.domainRepo.StartDKIMRotation(ctx, domain, &record); err != nil {
+			return fmt.Errorf("starting DKIM rotation: %w", err)
+		}
+		log.Info("started scheduled DKIM rotation", "selector", selector, "record", record.Name)
+		return h.enqueueVerify(domain)
+	}
+	return nil
+}
+
+// rotationDue reports whether the domain's scheduled rotation should start.
+// A rotation waits for the previous one to finish, including the retirement
+// of the selector it replaced.
+func (h *DKIMMaintenanceHandler) rotationDue(domain *model.Domain, now time.Time) bool {
+	if domain.Status != model.DomainStatusVerified || domain.DKIMRotationIntervalDays == nil ||
+		domain.DKIMPendingSelector != nil || domain.DKIMPreviousSelector != nil {
+		return false
+	}
+	last := domain.CreatedAt
+	if domain.DKIMRotatedAt != nil {
+		last = *domain.DKIMRotatedAt
+	}
+	return !now.Before(last.AddDate(0, 0, *domain.DKIMRotationIntervalDays))
+}
+
+// retirePrevious stops publishing the previous selector's DKIM record.
+func (h *DKIMMaintenanceHandler) retirePrevious(ctx context.Context, domain *model.Domain, now time.Time) error {
+	var retired string
+	if domain.DKIMPreviousSelector != nil {
+		retired = *domain.DKIMPreviousSelector
+		records, err := h.dnsRecordRepo.ListByDomainID(ctx, domain.ID)
+		if err != nil {
+			return fmt.Errorf("listing DNS records: %w", err)
+		}
+		name := domain.DKIMRecordName(*domain.DKIMPreviousSelector)
+		for _, r := range records {
+			if r.RecordType == RecordTypeDKIM && r.Name == name {
+				if err := h.dnsRecordRepo.Delete(ctx, r.ID); err != nil {
+					return fmt.Errorf("deleting DKIM DNS record: %w", err)
+				}
+			}
+		}
+	}
+
+	domain.DKIMPreviousSelector = nil
+	domain.DKIMPreviousRetireAt = nil
+	domain.UpdatedAt = now
+	if err := h.domainRepo.Update(ctx, domain); err != nil {
+		return fmt.Errorf("updating domain: %w", err)
+	}
+
+	h.logger.Info("retired previous DKIM selector; its DNS record can be removed",
+		"domain_id", domain.ID, "selector", retired)
+	return nil
+}
+
+func (h *DKIMMaintenanceHandler) enqueueVerify(domain *model.Domain) error {
+	task, err := NewDomainVerifyTask(domain.ID, domain.TeamID)
+	if err != nil {
+		return err
+	}
+	if _, err := h.enqueuer.Enqueue(task); err != nil {
+		return fmt.Errorf("enqueueing domain verification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func fakeDKIMKey(_ context.Context, algorithm string, bits int) (string, string, error) {
+	return "private-" + algorithm, "v=DKIM1; k=" + algorithm + "; p=new", nil
+}
+
+func TestDKIMMaintenanceHandler_ProcessTask(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	domainRepo := new(mockDomainRepo)
+	dnsRecordRepo := new(mockDNSRecordRepo)
+	h := NewDKIMMaintenanceHandler(domainRepo, dnsRecordRepo, client, fakeDKIMKey, "mailit", 2048,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	interval := 90
+	previous := "mailit-old"
+
+	// Due for retirement of its previous selector.
+	retiring := model.Domain{
+		ID: uuid.New(), TeamID: uuid.New(), Name: "retiring.example.com", Status: model.DomainStatusVerified,
+		DKIMSelector: "mailit-new", DKIMAlgorithm: "rsa", DKIMPreviousSelector: &previous, DKIMPreviousRetireAt: &past,
+		DKIMRotatedAt: &past, DKIMRotationIntervalDays: &interval, CreatedAt: now.AddDate(-1, 0, 0),
+	}
+	oldRecord := model.DomainDNSRecord{ID: uuid.New(), RecordType: RecordTypeDKIM, Name: "mailit-old._domainkey.retiring.example.com"}
+	activeRecord := model.DomainDNSRecord{ID: uuid.New(), RecordType: RecordTypeDKIM, Name: "mailit-new._domainkey.retiring.example.com"}
+
+	// Due for its scheduled rotation.
+	due := model.Domain{
+		ID: uuid.New(), TeamID: uuid.New(), Name: "due.example.com", Status: model.DomainStatusVerified,
+		DKIMSelector: "mailit", DKIMAlgorithm: "ed25519", DKIMRotationIntervalDays: &interval,
+		CreatedAt: now.AddDate(0, 0, -91),
+	}
+
+	domainRepo.On("ListDKIMMaintenanceDue", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return([]model.Domain{retiring, due}, nil)
+	dnsRecordRepo.On("ListByDomainID", mock.Anything, retiring.ID).
+		Return([]model.DomainDNSRecord{oldRecord, activeRecord}, nil)
+	dnsRecordRepo.On("Delete", mock.Anything, oldRecord.ID).Return(nil)
+	domainRepo.On("Update", mock.Anything, mock.MatchedBy(func(d *model.Domain) bool {
+		return d.ID == retiring.ID && d.DKIMPreviousSelector == nil && d.DKIMPreviousRetireAt == nil
+	})).Return(nil)
+	domainRepo.On("StartDKIMRotation", mock.Anything, mock.MatchedBy(func(d *model.Domain) bool {
+		return d.ID == due.ID && d.DKIMPendingSelector != nil && *d.DKIMPendingAlgorithm == "ed25519" &&
+			*d.DKIMPendingPrivateKey == "private-ed25519" && d.DKIMSelector == "mailit"
+	}), mock.MatchedBy(func(r *model.DomainDNSRecord) bool {
+		return r.DomainID == due.ID && r.RecordType == RecordTypeDKIM &&
+			strings.HasPrefix(r.Name, "mailit-") && strings.HasSuffix(r.Name, "._domainkey.due.example.com") &&
+			r.Value == "v=DKIM1; k=ed25519; p=new"
+	})).Return(nil)
+
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskDKIMMaintenance, nil))
+	require.NoError(t, err)
+	domainRepo.AssertExpectations(t)
+	dnsRecordRepo.AssertExpectations(t)
+
+	// The retired domain is not rotated again until its interval elapses; the
+	// rotated one is queued for verification of its new record.
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(QueueDefault)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, TaskDomainVerify, tasks[0].Type)
+	var p DomainVerifyPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &p))
+	assert.Equal(t, due.ID, p.DomainID)
+}
+
+func TestDKIMMaintenanceHandler_PendingKeyReverified(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	domainRepo := new(mockDomainRepo)
+	h := NewDKIMMaintenanceHandler(domainRepo, new(mockDNSRecordRepo), client, fakeDKIMKey, "mailit", 2048,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	pending := "mailit-202610161200"
+	domain := model.Domain{ID: uuid.New(), TeamID: uuid.New(), Name: "example.com", Status: model.DomainStatusVerified,
+		DKIMSelector: "mailit", DKIMPendingSelector: &pending}
+	domainRepo.On("ListDKIMMaintenanceDue", mock.Anything, mock.AnythingOfType("time.Time")).Return([]model.Domain{domain}, nil)
+
+	require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskDKIMMaintenance, nil)))
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(QueueDefault)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, TaskDomainVerify, tasks[0].Type)
+}
+
+func TestDomain_PromoteDKIMKey(t *testing.T) {
+	privateKey := "old-key"
+	domain := &model.Domain{Name: "example.com", DKIMSelector: "mailit", DKIMAlgorithm: "rsa", DKIMPrivateKey: &privateKey}
+	now := time.Now().UTC()
+
+	domain.StartDKIMRotation("mailit-2", "ed25519", "new-key", "v=DKIM1; k=ed25519; p=x", now)
+	require.True(t, recordVerified([]model.DomainDNSRecord{
+		{RecordType: RecordTypeDKIM, Name: domain.DKIMRecordName("mailit-2"), Status: DNSStatusVerified},
+	}, RecordTypeDKIM, "mailit-2._domainkey.example.com"))
+
+	domain.PromoteDKIMKey(now, now.Add(time.Hour))
+	assert.Equal(t, "mailit-2", domain.DKIMSelector)
+	assert.Equal(t, "ed25519", domain.DKIMAlgorithm)
+	assert.Equal(t, "new-key", *domain.DKIMPrivateKey)
+	assert.Equal(t, "mailit", *domain.DKIMPreviousSelector)
+	assert.Equal(t, now.Add(time.Hour), *domain.DKIMPreviousRetireAt)
+	assert.Nil(t, domain.DKIMPendingSelector)
+	assert.Nil(t, domain.DKIMPendingPrivateKey)
+}
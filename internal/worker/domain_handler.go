@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -31,24 +32,45 @@ const (
 	DNSStatusFailed   = "failed"
 )
 
+// EventTypeDomainUpdated is the webhook event dispatched when verification
+// changes the status of a domain or of any of its DNS records.
+const EventTypeDomainUpdated = "domain.updated"
+
 // DomainVerifyHandler processes domain:verify tasks by checking each DNS record
 // associated with a domain and updating their verification status.
+//
+// A pending DKIM key whose record verifies is promoted to the active key; the
+// key it replaces is retired after dkimRetireAfter.
+//
+// Verified domains are rechecked periodically. A verified domain whose
+// critical records stop verifying becomes degraded, and with
+// pauseOnDKIMMissing its sending is paused while its active DKIM record is
+// missing.
 type DomainVerifyHandler struct {
-	domainRepo    postgres.DomainRepository
-	dnsRecordRepo postgres.DomainDNSRecordRepository
-	logger        *slog.Logger
+	domainRepo         postgres.DomainRepository
+	dnsRecordRepo      postgres.DomainDNSRecordRepository
+	dkimRetireAfter    time.Duration
+	pauseOnDKIMMissing bool
+	webhookDispatch    WebhookDispatchFunc
+	logger             *slog.Logger
 }
 
 // NewDomainVerifyHandler creates a new DomainVerifyHandler.
 func NewDomainVerifyHandler(
 	domainRepo postgres.DomainRepository,
 	dnsRecordRepo postgres.DomainDNSRecordRepository,
+	dkimRetireAfter time.Duration,
+	pauseOnDKIMMissing bool,
+	webhookDispatch WebhookDispatchFunc,
 	logger *slog.Logger,
 ) *DomainVerifyHandler {
 	return &DomainVerifyHandler{
-		domainRepo:    domainRepo,
-		dnsRecordRepo: dnsRecordRepo,
-		logger:        logger,
+		domainRepo:         domainRepo,
+		dnsRecordRepo:      dnsRecordRepo,
+		dkimRetireAfter:    dkimRetireAfter,
+		pauseOnDKIMMissing: pauseOnDKIMMissing,
+		webhookDispatch:    webhookDispatch,
+		logger:             logger,
 	}
 }
 
@@ -80,12 +102,28 @@ func (h *DomainVerifyHandler) ProcessTask(ctx context.Context, t *asynq.Task) er
 
 	// 3. Verify each record.
 	now := time.Now().UTC()
-	allCriticalVerified := true
+	previousStatus := domain.Status
+	wasPaused := domain.SendingPausedAt != nil
+	var changed []map[string]string
 
 	for i := range records {
 		record := &records[i]
+		previousRecordStatus := record.Status
 		verified, verifyErr := h.verifyRecord(domain.Name, record)
 
+		// A lookup that failed, e.g. timed out or got SERVFAIL, says nothing
+		// about the record, which keeps its status until the next check.
+		if dnsLookupFailed(verifyErr) {
+			log.Warn("DNS lookup failed, keeping record status",
+				"record_type", record.RecordType,
+				"dns_type", record.DNSType,
+				"name", record.Name,
+				"status", record.Status,
+				"error", verifyErr,
+			)
+			continue
+		}
+
 		record.LastCheckedAt = &now
 		record.UpdatedAt = now
 
@@ -112,29 +150,93 @@ func (h *DomainVerifyHandler) ProcessTask(ctx context.Context, t *asynq.Task) er
 			log.Error("failed to update DNS record status", "record_id", record.ID, "error", err)
 		}
 
-		// Track whether all critical records (SPF, DKIM, MX) are verified.
-		if isCriticalRecord(record.RecordType) && record.Status != DNSStatusVerified {
-			allCriticalVerified = false
+		if record.Status != previousRecordStatus {
+			changed = append(changed, map[string]string{
+				"type":            record.RecordType,
+				"name":            record.Name,
+				"status":          record.Status,
+				"previous_status": previousRecordStatus,
+			})
 		}
 	}
 
-	// 4. Update domain status based on verification results.
-	if allCriticalVerified {
-		domain.Status = model.DomainStatusVerified
-		log.Info("domain fully verified")
-	} else {
-		domain.Status = model.DomainStatusFailed
-		log.Info("domain verification incomplete, some critical records failed")
+	// 4. Switch signing over to a pending DKIM key once it is published.
+	if domain.DKIMPendingSelector != nil && recordVerified(records, RecordTypeDKIM, domain.DKIMRecordName(*domain.DKIMPendingSelector)) {
+		previous := domain.DKIMSelector
+		domain.PromoteDKIMKey(now, now.Add(h.dkimRetireAfter))
+		log.Info("DKIM key rotated",
+			"selector", domain.DKIMSelector,
+			"previous_selector", previous,
+			"retire_at", domain.DKIMPreviousRetireAt.Format(time.RFC3339),
+		)
 	}
 
+	// 5. Update the domain status from its critical records, pausing or
+	// resuming sending as needed.
+	h.updateHealth(domain, records, now, log)
+
+	domain.LastCheckedAt = &now
 	domain.UpdatedAt = now
 	if err := h.domainRepo.Update(ctx, domain); err != nil {
 		return fmt.Errorf("updating domain status: %w", err)
 	}
 
+	// 6. Notify the team of any change.
+	paused := domain.SendingPausedAt != nil
+	if h.webhookDispatch != nil && (domain.Status != previousStatus || paused != wasPaused || len(changed) > 0) {
+		h.webhookDispatch(ctx, domain.TeamID, EventTypeDomainUpdated, map[string]interface{}{
+			"domain_id":       domain.ID.String(),
+			"name":            domain.Name,
+			"status":          domain.Status,
+			"previous_status": previousStatus,
+			"sending_paused":  paused,
+			"changed_records": changed,
+			"timestamp":       now.Format(time.RFC3339),
+		})
+	}
+
 	return nil
 }
 
+// updateHealth sets the domain status from whether all critical records (SPF,
+// the active DKIM key, MX) are verified. A domain that was verified before
+// becomes degraded rather than failed, and with pauseOnDKIMMissing its sending
+// is paused while its active DKIM record is missing.
+func (h *DomainVerifyHandler) updateHealth(domain *model.Domain, records []model.DomainDNSRecord, now time.Time, log *slog.Logger) {
+	allCriticalVerified := true
+	for i := range records {
+		if isCriticalRecord(domain, &records[i]) && records[i].Status != DNSStatusVerified {
+			allCriticalVerified = false
+		}
+	}
+
+	switch {
+	case allCriticalVerified:
+		domain.Status = model.DomainStatusVerified
+		log.Info("domain fully verified")
+	case domain.Status == model.DomainStatusVerified || domain.Status == model.DomainStatusDegraded:
+		// The records were published once: this is drift, not a setup
+		// still in progress.
+		domain.Status = model.DomainStatusDegraded
+		log.Warn("domain DNS has drifted, some critical records no longer verify")
+	default:
+		domain.Status = model.DomainStatusFailed
+		log.Info("domain verification incomplete, some critical records failed")
+	}
+
+	dkimPublished := recordVerified(records, RecordTypeDKIM, domain.DKIMRecordName(domain.DKIMSelector))
+	switch {
+	case h.pauseOnDKIMMissing && domain.Status == model.DomainStatusDegraded && !dkimPublished:
+		if domain.SendingPausedAt == nil {
+			domain.SendingPausedAt = &now
+			log.Warn("sending paused, active DKIM record is missing", "selector", domain.DKIMSelector)
+		}
+	case domain.SendingPausedAt != nil:
+		domain.SendingPausedAt = nil
+		log.Info("sending resumed")
+	}
+}
+
 // verifyRecord performs a DNS lookup to verify a single DNS record.
 func (h *DomainVerifyHandler) verifyRecord(domainName string, record *model.DomainDNSRecord) (bool, error) {
 	switch record.RecordType {
@@ -170,15 +272,17 @@ func (h *DomainVerifyHandler) verifySPF(name, expectedValue string) (bool, error
 }
 
 // verifyDKIM checks that the expected DKIM TXT record is published at the selector._domainkey subdomain.
+// Records are compared by public key, so a record for an imported key that
+// was published with other tags or spacing, e.g. by a previous provider, verifies.
 func (h *DomainVerifyHandler) verifyDKIM(name, expectedValue string) (bool, error) {
 	txtRecords, err := net.LookupTXT(name)
 	if err != nil {
 		return false, fmt.Errorf("DKIM TXT lookup for %s: %w", name, err)
 	}
 
-	// DKIM TXT records can be split across multiple strings; join them.
+	want := dkimPublicKey(expectedValue)
 	for _, txt := range txtRecords {
-		if strings.Contains(txt, "v=DKIM1") && strings.Contains(txt, expectedValue) {
+		if want != "" && dkimPublicKey(txt) == want {
 			return true, nil
 		}
 	}
@@ -186,7 +290,25 @@ func (h *DomainVerifyHandler) verifyDKIM(name, expectedValue string) (bool, erro
 	return false, nil
 }
 
-// verifyDMARC checks that the expected DMARC TXT record is published.
+// dkimPublicKey returns the public key (p= tag) of a DKIM TXT record, with
+// any whitespace removed.
+func dkimPublicKey(record string) string {
+	return strings.Join(strings.Fields(txtRecordTag(record, "p")), "")
+}
+
+// txtRecordTag returns the value of a tag of a tag=value; TXT record such as
+// a DKIM or DMARC record.
+func txtRecordTag(record, tag string) string {
+	for _, t := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(t, "=")
+		if ok && strings.TrimSpace(name) == tag {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// verifyDMARC checks that a DMARC TXT record is published.
 func (h *DomainVerifyHandler) verifyDMARC(name, expectedValue string) (bool, error) {
 	txtRecords, err := net.LookupTXT(name)
 	if err != nil {
@@ -194,7 +316,7 @@ func (h *DomainVerifyHandler) verifyDMARC(name, expectedValue string) (bool, err
 	}
 
 	for _, txt := range txtRecords {
-		if strings.Contains(txt, "v=DMARC1") && strings.Contains(txt, expectedValue) {
+		if dmarcRecordMatches(txt, expectedValue) {
 			return true, nil
 		}
 	}
@@ -202,6 +324,34 @@ func (h *DomainVerifyHandler) verifyDMARC(name, expectedValue string) (bool, err
 	return false, nil
 }
 
+// dmarcRecordMatches reports whether a published DMARC record satisfies the
+// expected one. The policy may differ, so that a domain can move on to
+// quarantine or reject, but when the expected record asks for aggregate
+// reports, the published rua tag must still include the report address.
+func dmarcRecordMatches(record, expected string) bool {
+	if !strings.HasPrefix(strings.TrimSpace(record), "v=DMARC1") {
+		return false
+	}
+	published := strings.Split(strings.ToLower(txtRecordTag(record, "rua")), ",")
+	for _, uri := range strings.Split(strings.ToLower(txtRecordTag(expected, "rua")), ",") {
+		uri = strings.TrimSpace(uri)
+		if uri == "" {
+			continue
+		}
+		found := false
+		for _, p := range published {
+			if strings.TrimSpace(p) == uri {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // verifyMX checks that the expected MX record is published with the correct priority.
 func (h *DomainVerifyHandler) verifyMX(name, expectedHost string, expectedPriority *int) (bool, error) {
 	mxRecords, err := net.LookupMX(name)
@@ -236,13 +386,35 @@ func (h *DomainVerifyHandler) verifyCNAME(name, expectedValue string) (bool, err
 	return strings.EqualFold(cnameClean, expectedClean), nil
 }
 
-// isCriticalRecord returns true for record types that must be verified for the domain
-// to be considered fully verified.
-func isCriticalRecord(recordType string) bool {
-	switch recordType {
-	case RecordTypeSPF, RecordTypeDKIM, RecordTypeMX:
+// dnsLookupFailed reports whether err is a DNS lookup that failed, rather
+// than one that found the name has no such record (NXDOMAIN or an empty
+// answer).
+func dnsLookupFailed(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && !dnsErr.IsNotFound
+}
+
+// isCriticalRecord returns true for records that must be verified for the domain
+// to be considered fully verified. Of the DKIM records, only the active key's is:
+// pending and previous keys are published alongside it during a rotation.
+func isCriticalRecord(domain *model.Domain, record *model.DomainDNSRecord) bool {
+	switch record.RecordType {
+	case RecordTypeSPF, RecordTypeMX:
 		return true
+	case RecordTypeDKIM:
+		return record.Name == domain.DKIMRecordName(domain.DKIMSelector)
 	default:
 		return false
 	}
 }
+
+// recordVerified reports whether the record of the given type and name is
+// among records and verified.
+func recordVerified(records []model.DomainDNSRecord, recordType, name string) bool {
+	for _, r := range records {
+		if r.RecordType == recordType && r.Name == name {
+			return r.Status == DNSStatusVerified
+		}
+	}
+	return false
+}
@@ -3,8 +3,10 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"testing"
 	"time"
 
@@ -30,6 +32,9 @@ func (m *mockDNSRecordRepo) ListByDomainID(ctx context.Context, domainID uuid.UU
 func (m *mockDNSRecordRepo) Update(ctx context.Context, record *model.DomainDNSRecord) error {
 	return m.Called(ctx, record).Error(0)
 }
+func (m *mockDNSRecordRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
 func (m *mockDNSRecordRepo) DeleteByDomainID(ctx context.Context, domainID uuid.UUID) error {
 	return m.Called(ctx, domainID).Error(0)
 }
@@ -39,7 +44,7 @@ func TestDomainVerifyHandler_ProcessTask_NoRecords(t *testing.T) {
 	dnsRecordRepo := new(mockDNSRecordRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewDomainVerifyHandler(domainRepo, dnsRecordRepo, logger)
+	h := NewDomainVerifyHandler(domainRepo, dnsRecordRepo, time.Hour, false, nil, logger)
 
 	domainID := uuid.New()
 	teamID := uuid.New()
@@ -68,7 +73,7 @@ func TestDomainVerifyHandler_ProcessTask_InvalidPayload(t *testing.T) {
 	dnsRecordRepo := new(mockDNSRecordRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewDomainVerifyHandler(domainRepo, dnsRecordRepo, logger)
+	h := NewDomainVerifyHandler(domainRepo, dnsRecordRepo, time.Hour, false, nil, logger)
 
 	task := asynq.NewTask(TaskDomainVerify, []byte("invalid json"))
 
@@ -82,7 +87,7 @@ func TestDomainVerifyHandler_ProcessTask_DomainNotFound(t *testing.T) {
 	dnsRecordRepo := new(mockDNSRecordRepo)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := NewDomainVerifyHandler(domainRepo, dnsRecordRepo, logger)
+	h := NewDomainVerifyHandler(domainRepo, dnsRecordRepo, time.Hour, false, nil, logger)
 
 	domainID := uuid.New()
 	teamID := uuid.New()
@@ -98,9 +103,91 @@ func TestDomainVerifyHandler_ProcessTask_DomainNotFound(t *testing.T) {
 }
 
 func TestIsCriticalRecord(t *testing.T) {
-	assert.True(t, isCriticalRecord(RecordTypeSPF))
-	assert.True(t, isCriticalRecord(RecordTypeDKIM))
-	assert.True(t, isCriticalRecord(RecordTypeMX))
-	assert.False(t, isCriticalRecord(RecordTypeDMARC))
-	assert.False(t, isCriticalRecord(RecordTypeReturnPath))
+	pending := "mailit-202610161200"
+	domain := &model.Domain{Name: "example.com", DKIMSelector: "mailit", DKIMPendingSelector: &pending}
+	record := func(recordType, name string) *model.DomainDNSRecord {
+		return &model.DomainDNSRecord{RecordType: recordType, Name: name}
+	}
+
+	assert.True(t, isCriticalRecord(domain, record(RecordTypeSPF, "example.com")))
+	assert.True(t, isCriticalRecord(domain, record(RecordTypeDKIM, "mailit._domainkey.example.com")))
+	assert.True(t, isCriticalRecord(domain, record(RecordTypeMX, "example.com")))
+	assert.False(t, isCriticalRecord(domain, record(RecordTypeDKIM, "mailit-202610161200._domainkey.example.com")))
+	assert.False(t, isCriticalRecord(domain, record(RecordTypeDMARC, "_dmarc.example.com")))
+	assert.False(t, isCriticalRecord(domain, record(RecordTypeReturnPath, "bounce.example.com")))
+}
+
+func TestDKIMPublicKey(t *testing.T) {
+	assert.Equal(t, "MIIBIjAN", dkimPublicKey("v=DKIM1; k=rsa; p=MIIBIjAN"))
+	assert.Equal(t, "MIIBIjAN", dkimPublicKey("k=rsa;t=s;p= MIIB IjAN "))
+	assert.Equal(t, "", dkimPublicKey("v=DKIM1; k=rsa"))
+	assert.Equal(t, "", dkimPublicKey("v=spf1 -all"))
+}
+
+func TestDMARCRecordMatches(t *testing.T) {
+	expected := "v=DMARC1; p=none; rua=mailto:dmarc@example.com"
+
+	assert.True(t, dmarcRecordMatches(expected, expected))
+	assert.True(t, dmarcRecordMatches("v=DMARC1; p=reject; rua=mailto:DMARC@example.com, mailto:reports@vendor.net; pct=100", expected))
+	assert.False(t, dmarcRecordMatches("v=DMARC1; p=reject", expected))
+	assert.False(t, dmarcRecordMatches("v=DMARC1; p=none; rua=mailto:reports@vendor.net", expected))
+	assert.False(t, dmarcRecordMatches("v=spf1 -all", expected))
+
+	// Domains created before reports were collected expect no rua.
+	assert.True(t, dmarcRecordMatches("v=DMARC1; p=quarantine", "v=DMARC1; p=none;"))
+}
+
+func TestDNSLookupFailed(t *testing.T) {
+	lookup := func(err *net.DNSError) error { return fmt.Errorf("DKIM TXT lookup for %s: %w", err.Name, err) }
+
+	assert.False(t, dnsLookupFailed(nil))
+	assert.False(t, dnsLookupFailed(lookup(&net.DNSError{Err: "no such host", Name: "mailit._domainkey.example.com", IsNotFound: true})), "NXDOMAIN means the record is missing")
+	assert.True(t, dnsLookupFailed(lookup(&net.DNSError{Err: "i/o timeout", Name: "mailit._domainkey.example.com", IsTimeout: true})))
+	assert.True(t, dnsLookupFailed(lookup(&net.DNSError{Err: "server misbehaving", Name: "mailit._domainkey.example.com", IsTemporary: true})), "SERVFAIL")
+	assert.False(t, dnsLookupFailed(fmt.Errorf("unknown record type: %s", "SRV")))
+}
+
+func TestDomainVerifyHandler_UpdateHealth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Now().UTC()
+	records := func(dkimStatus, spfStatus string) []model.DomainDNSRecord {
+		return []model.DomainDNSRecord{
+			{RecordType: RecordTypeSPF, Name: "example.com", Status: spfStatus},
+			{RecordType: RecordTypeDKIM, Name: "mailit._domainkey.example.com", Status: dkimStatus},
+			{RecordType: RecordTypeMX, Name: "example.com", Status: DNSStatusVerified},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		pause      bool
+		status     string
+		paused     bool
+		records    []model.DomainDNSRecord
+		wantStatus string
+		wantPaused bool
+	}{
+		{"pending domain verifies", true, model.DomainStatusPending, false, records(DNSStatusVerified, DNSStatusVerified), model.DomainStatusVerified, false},
+		{"pending domain fails", true, model.DomainStatusPending, false, records(DNSStatusFailed, DNSStatusVerified), model.DomainStatusFailed, false},
+		{"SPF drift degrades", true, model.DomainStatusVerified, false, records(DNSStatusVerified, DNSStatusFailed), model.DomainStatusDegraded, false},
+		{"DKIM drift pauses", true, model.DomainStatusVerified, false, records(DNSStatusFailed, DNSStatusVerified), model.DomainStatusDegraded, true},
+		{"DKIM drift without pausing", false, model.DomainStatusVerified, false, records(DNSStatusFailed, DNSStatusVerified), model.DomainStatusDegraded, false},
+		{"still degraded stays paused", true, model.DomainStatusDegraded, true, records(DNSStatusFailed, DNSStatusFailed), model.DomainStatusDegraded, true},
+		{"recovery resumes", true, model.DomainStatusDegraded, true, records(DNSStatusVerified, DNSStatusVerified), model.DomainStatusVerified, false},
+		{"DKIM back, SPF still missing", true, model.DomainStatusDegraded, true, records(DNSStatusVerified, DNSStatusFailed), model.DomainStatusDegraded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewDomainVerifyHandler(nil, nil, time.Hour, tt.pause, nil, logger)
+			domain := &model.Domain{Name: "example.com", DKIMSelector: "mailit", Status: tt.status}
+			if tt.paused {
+				pausedAt := now.Add(-time.Hour)
+				domain.SendingPausedAt = &pausedAt
+			}
+
+			h.updateHealth(domain, tt.records, now, logger)
+			assert.Equal(t, tt.wantStatus, domain.Status)
+			assert.Equal(t, tt.wantPaused, domain.SendingPausedAt != nil)
+		})
+	}
 }
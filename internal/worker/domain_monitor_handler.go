@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// DomainMonitorSchedule is the cron spec the domain:monitor task is
+// scheduled with.
+const DomainMonitorSchedule = "@hourly"
+
+// DomainMonitorHandler processes domain:monitor tasks. It queues a
+// re-verification of every verified or degraded domain whose DNS records
+// were last checked longer than the monitoring interval ago, so that records
+// removed or changed after verification are noticed.
+type DomainMonitorHandler struct {
+	domainRepo postgres.DomainRepository
+	enqueuer   TaskEnqueuer
+	interval   time.Duration
+	logger     *slog.Logger
+}
+
+// NewDomainMonitorHandler creates a new DomainMonitorHandler that re-checks
+// each domain once per interval.
+func NewDomainMonitorHandler(
+	domainRepo postgres.DomainRepository,
+	enqueuer TaskEnqueuer,
+	interval time.Duration,
+	logger *slog.Logger,
+) *DomainMonitorHandler {
+	return &DomainMonitorHandler{
+		domainRepo: domainRepo,
+		enqueuer:   enqueuer,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// ProcessTask handles the domain:monitor task.
+func (h *DomainMonitorHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	log := h.logger.With("task", TaskDomainMonitor)
+
+	domains, err := h.domainRepo.ListMonitoringDue(ctx, time.Now().UTC().Add(-h.interval))
+	if err != nil {
+		return fmt.Errorf("listing domains due for monitoring: %w", err)
+	}
+
+	var errs []error
+	for _, domain := range domains {
+		task, err := NewDomainVerifyTask(domain.ID, domain.TeamID)
+		if err == nil {
+			_, err = h.enqueuer.Enqueue(task)
+		}
+		if err != nil {
+			log.Error("failed to enqueue domain verification", "domain_id", domain.ID, "error", err)
+			errs = append(errs, fmt.Errorf("domain %s: %w", domain.ID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("domain monitoring completed with %d errors: %v", len(errs), errs)
+	}
+	if len(domains) > 0 {
+		log.Info("queued domain health checks", "count", len(domains))
+	}
+	return nil
+}
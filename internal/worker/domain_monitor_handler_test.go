@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+)
+
+func TestDomainMonitorHandler_ProcessTask(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	domainRepo := new(mockDomainRepo)
+	h := NewDomainMonitorHandler(domainRepo, client, 6*time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	verified := model.Domain{ID: uuid.New(), TeamID: uuid.New(), Name: "verified.example.com", Status: model.DomainStatusVerified}
+	degraded := model.Domain{ID: uuid.New(), TeamID: uuid.New(), Name: "degraded.example.com", Status: model.DomainStatusDegraded}
+
+	before := time.Now().UTC()
+	domainRepo.On("ListMonitoringDue", mock.Anything, mock.MatchedBy(func(checkedBefore time.Time) bool {
+		return !checkedBefore.After(before.Add(-6*time.Hour).Add(time.Minute)) &&
+			checkedBefore.After(before.Add(-6*time.Hour).Add(-time.Minute))
+	})).Return([]model.Domain{verified, degraded}, nil)
+
+	require.NoError(t, h.ProcessTask(context.Background(), asynq.NewTask(TaskDomainMonitor, nil)))
+	domainRepo.AssertExpectations(t)
+
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer func() { _ = inspector.Close() }()
+	tasks, err := inspector.ListPendingTasks(QueueDefault)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+
+	var ids []uuid.UUID
+	for _, task := range tasks {
+		assert.Equal(t, TaskDomainVerify, task.Type)
+		var p DomainVerifyPayload
+		require.NoError(t, json.Unmarshal(task.Payload, &p))
+		ids = append(ids, p.DomainID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{verified.ID, degraded.ID}, ids)
+}
+
+func TestDomainMonitorHandler_ProcessTask_ListError(t *testing.T) {
+	domainRepo := new(mockDomainRepo)
+	h := NewDomainMonitorHandler(domainRepo, nil, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	domainRepo.On("ListMonitoringDue", mock.Anything, mock.AnythingOfType("time.Time")).Return([]model.Domain(nil), assert.AnError)
+
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskDomainMonitor, nil))
+	assert.ErrorContains(t, err, "listing domains due for monitoring")
+}
@@ -2,9 +2,14 @@ package worker
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net/mail"
 	"regexp"
 	"strings"
 	"time"
@@ -13,6 +18,7 @@ import (
 	"github.com/hibiken/asynq"
 
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
 	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
@@ -28,6 +34,7 @@ type EmailSender interface {
 type OutboundMessage struct {
 	MessageID    string
 	From         string
+	ReturnPath   string // VERP envelope sender; empty to use From
 	To           []string
 	Cc           []string
 	Bcc          []string
@@ -39,8 +46,28 @@ type OutboundMessage struct {
 	DKIMDomain   string
 	DKIMSelector string
 	DKIMKey      []byte
+	Attachments  []OutboundAttachment
 }
 
+// OutboundAttachment is a file attached to an OutboundMessage.
+type OutboundAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// AttachmentStore reads attachment content that was stored by reference
+// rather than inline in the email record, and deletes it once the email has
+// been sent or has failed for good.
+type AttachmentStore interface {
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// errBadAttachment marks attachments that can never be loaded, so retrying
+// the send would not help.
+var errBadAttachment = errors.New("bad attachment")
+
 // RecipientResult captures the delivery outcome for a single recipient.
 type RecipientResult struct {
 	Recipient string
@@ -48,6 +75,16 @@ type RecipientResult struct {
 	Code      int
 	Message   string
 	Permanent bool // true if the error is permanent (5xx), false if temporary (4xx)
+	TLS       *DeliveryTLS
+}
+
+// DeliveryTLS records how the connection a recipient was handled on was
+// secured.
+type DeliveryTLS struct {
+	Policy   string // dane, mta-sts, mta-sts-testing, enforce or opportunistic
+	Version  string // negotiated TLS version; empty if no TLS was used
+	Cipher   string
+	Verified bool // the server certificate was authenticated
 }
 
 // WebhookDispatchFunc is the function signature for dispatching webhook events.
@@ -58,12 +95,16 @@ type EmailSendHandler struct {
 	emailRepo        postgres.EmailRepository
 	eventRepo        postgres.EmailEventRepository
 	domainRepo       postgres.DomainRepository
+	dnsRecordRepo    postgres.DomainDNSRecordRepository
 	suppressionRepo  postgres.SuppressionRepository
 	trackingRepo     postgres.TrackingLinkRepository
+	broadcastRepo    postgres.BroadcastRepository
+	attachments      AttachmentStore
 	sender           EmailSender
 	webhookDispatch  WebhookDispatchFunc
 	metricsIncrement MetricsIncrementFunc
 	baseURL          string
+	returnPathSecret string
 	logger           *slog.Logger
 }
 
@@ -72,24 +113,32 @@ func NewEmailSendHandler(
 	emailRepo postgres.EmailRepository,
 	eventRepo postgres.EmailEventRepository,
 	domainRepo postgres.DomainRepository,
+	dnsRecordRepo postgres.DomainDNSRecordRepository,
 	suppressionRepo postgres.SuppressionRepository,
 	trackingRepo postgres.TrackingLinkRepository,
+	broadcastRepo postgres.BroadcastRepository,
+	attachments AttachmentStore,
 	sender EmailSender,
 	webhookDispatch WebhookDispatchFunc,
 	metricsIncrement MetricsIncrementFunc,
 	baseURL string,
+	returnPathSecret string,
 	logger *slog.Logger,
 ) *EmailSendHandler {
 	return &EmailSendHandler{
 		emailRepo:        emailRepo,
 		eventRepo:        eventRepo,
 		domainRepo:       domainRepo,
+		dnsRecordRepo:    dnsRecordRepo,
 		suppressionRepo:  suppressionRepo,
 		trackingRepo:     trackingRepo,
+		broadcastRepo:    broadcastRepo,
+		attachments:      attachments,
 		sender:           sender,
 		webhookDispatch:  webhookDispatch,
 		metricsIncrement: metricsIncrement,
 		baseURL:          baseURL,
+		returnPathSecret: returnPathSecret,
 		logger:           logger,
 	}
 }
@@ -115,6 +164,13 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 		return nil
 	}
 
+	// A held email waits for its broadcast to release it again, as when the
+	// broadcast was paused after the email was queued.
+	if email.Status == model.EmailStatusHeld {
+		log.Info("skipping email held by its broadcast")
+		return nil
+	}
+
 	// 2. Filter out suppressed recipients.
 	filteredTo := h.filterSuppressed(ctx, email.TeamID, email.ToAddresses, log)
 	filteredCc := h.filterSuppressed(ctx, email.TeamID, email.CcAddresses, log)
@@ -133,6 +189,26 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 		email.UpdatedAt = time.Now().UTC()
 		if updateErr := h.emailRepo.Update(ctx, email); updateErr != nil {
 			log.Error("failed to update email status", "error", updateErr)
+		} else {
+			h.deleteStoredAttachments(ctx, email, log)
+		}
+		return nil
+	}
+
+	// 2b. Load attachment content, from storage where it was stored by reference.
+	attachments, err := h.loadAttachments(ctx, email.Attachments)
+	if err != nil {
+		if !errors.Is(err, errBadAttachment) {
+			return fmt.Errorf("loading attachments: %w", err)
+		}
+		log.Warn("attachment cannot be loaded, marking email as failed", "error", err)
+		email.Status = model.EmailStatusFailed
+		email.LastError = strPtr(err.Error())
+		email.UpdatedAt = time.Now().UTC()
+		if updateErr := h.emailRepo.Update(ctx, email); updateErr != nil {
+			log.Error("failed to update email status", "error", updateErr)
+		} else {
+			h.deleteStoredAttachments(ctx, email, log)
 		}
 		return nil
 	}
@@ -143,19 +219,33 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 	var domainObj *model.Domain
 
 	if email.DomainID != nil {
+		// The domain was resolved when the email was accepted; never fall back
+		// to sending it unsigned.
 		domain, domErr := h.domainRepo.GetByID(ctx, *email.DomainID)
-		if domErr != nil {
-			log.Warn("failed to fetch domain for DKIM, sending without DKIM", "error", domErr)
-		} else {
-			domainObj = domain
-			if domain.DKIMPrivateKey != nil {
-				dkimDomain = domain.Name
-				dkimSelector = domain.DKIMSelector
-				dkimKey = []byte(*domain.DKIMPrivateKey)
+		if errors.Is(domErr, postgres.ErrNotFound) {
+			log.Warn("sending domain was deleted, marking email as failed")
+			email.Status = model.EmailStatusFailed
+			email.LastError = strPtr("sending domain no longer exists")
+			email.UpdatedAt = time.Now().UTC()
+			if updateErr := h.emailRepo.Update(ctx, email); updateErr != nil {
+				log.Error("failed to update email status", "error", updateErr)
+			} else {
+				h.deleteStoredAttachments(ctx, email, log)
 			}
+			return nil
+		}
+		if domErr != nil {
+			return fmt.Errorf("fetching sending domain: %w", domErr)
+		}
+		domainObj = domain
+		if domain.DKIMPrivateKey != nil {
+			dkimDomain = domain.Name
+			dkimSelector = domain.DKIMSelector
+			dkimKey = []byte(*domain.DKIMPrivateKey)
 		}
 	} else {
-		// Try to resolve the domain from the From address.
+		// Emails accepted before domains were resolved up front, and
+		// broadcast emails: resolve the domain from the From address.
 		fromDomain := extractDomain(email.FromAddress)
 		if fromDomain != "" {
 			domain, domErr := h.domainRepo.GetByTeamAndName(ctx, email.TeamID, fromDomain)
@@ -170,6 +260,13 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 		}
 	}
 
+	// Hold mail from a domain whose sending is paused. The task is retried
+	// and the email goes out once the domain's DKIM record is back.
+	if domainObj != nil && domainObj.SendingPausedAt != nil {
+		log.Warn("sending paused for domain, retrying later", "domain", domainObj.Name)
+		return fmt.Errorf("sending from domain %s is paused: its DKIM record is missing", domainObj.Name)
+	}
+
 	// 3b. Inject tracking (open pixel, click rewriting, unsubscribe headers).
 	htmlBody := ptrToString(email.HTMLBody)
 	extraHeaders := make(map[string]string)
@@ -179,12 +276,15 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 	}
 
 	if h.trackingRepo != nil && h.baseURL != "" && primaryRecipient != "" {
-		// Unsubscribe link (always inject for compliance).
-		unsubLink := h.createTrackingLink(ctx, email.ID, email.TeamID, model.TrackingTypeUnsubscribe, "", primaryRecipient)
-		if unsubLink != nil {
-			unsubURL := fmt.Sprintf("%s/unsubscribe?token=%s", h.baseURL, unsubLink.ID)
-			extraHeaders["List-Unsubscribe"] = "<" + unsubURL + ">"
-			extraHeaders["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+		// Unsubscribe link (always inject for compliance), unless the email
+		// already carries one, like the signed preference link of broadcasts.
+		if !hasHeader(email.Headers, "List-Unsubscribe") {
+			unsubLink := h.createTrackingLink(ctx, email.ID, email.TeamID, model.TrackingTypeUnsubscribe, "", primaryRecipient)
+			if unsubLink != nil {
+				unsubURL := fmt.Sprintf("%s/unsubscribe?token=%s", h.baseURL, unsubLink.ID)
+				extraHeaders["List-Unsubscribe"] = "<" + unsubURL + ">"
+				extraHeaders["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+			}
 		}
 
 		if htmlBody != "" && domainObj != nil {
@@ -223,6 +323,7 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 	msg := &OutboundMessage{
 		MessageID:    ptrToString(email.MessageID),
 		From:         email.FromAddress,
+		ReturnPath:   h.returnPath(ctx, domainObj, email.ID, log),
 		To:           filteredTo,
 		Cc:           filteredCc,
 		Bcc:          filteredBcc,
@@ -234,6 +335,7 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 		DKIMDomain:   dkimDomain,
 		DKIMSelector: dkimSelector,
 		DKIMKey:      dkimKey,
+		Attachments:  attachments,
 	}
 
 	// 6. Send via SMTP engine.
@@ -257,10 +359,10 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 
 	for _, r := range results {
 		if r.Success {
-			h.createEvent(ctx, email.ID, model.EventSent, &r.Recipient, model.JSONMap{
+			h.createEvent(ctx, email.ID, model.EventSent, &r.Recipient, withTLS(model.JSONMap{
 				"code":    r.Code,
 				"message": r.Message,
-			})
+			}, r.TLS))
 			h.incrementMetrics(ctx, email.TeamID, model.EventSent)
 			if h.webhookDispatch != nil {
 				h.webhookDispatch(ctx, email.TeamID, "email.sent", map[string]interface{}{
@@ -271,11 +373,11 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 			}
 		} else if r.Permanent {
 			allSucceeded = false
-			h.createEvent(ctx, email.ID, model.EventBounced, &r.Recipient, model.JSONMap{
+			h.createEvent(ctx, email.ID, model.EventBounced, &r.Recipient, withTLS(model.JSONMap{
 				"code":    r.Code,
 				"message": r.Message,
 				"type":    "hard",
-			})
+			}, r.TLS))
 			h.incrementMetrics(ctx, email.TeamID, model.EventBounced)
 			// Enqueue a bounce:process task for hard bounces.
 			bounceTask, taskErr := NewBounceProcessTask(email.ID, r.Code, r.Message, r.Recipient)
@@ -299,12 +401,12 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 			// Temporary failure: mark for retry.
 			allSucceeded = false
 			hasTemporaryFailure = true
-			h.createEvent(ctx, email.ID, model.EventFailed, &r.Recipient, model.JSONMap{
+			h.createEvent(ctx, email.ID, model.EventFailed, &r.Recipient, withTLS(model.JSONMap{
 				"code":      r.Code,
 				"message":   r.Message,
 				"type":      "temporary",
 				"will_retry": true,
-			})
+			}, r.TLS))
 			h.incrementMetrics(ctx, email.TeamID, model.EventFailed)
 		}
 	}
@@ -333,10 +435,39 @@ func (h *EmailSendHandler) ProcessTask(ctx context.Context, t *asynq.Task) error
 		log.Error("failed to update final email status", "error", err)
 		return fmt.Errorf("updating final email status: %w", err)
 	}
+	h.deleteStoredAttachments(ctx, email, log)
+
+	// Count the email towards the progress of its broadcast.
+	if email.Status == model.EmailStatusSent && email.BroadcastID != nil && h.broadcastRepo != nil {
+		if err := h.broadcastRepo.IncrementSentCount(ctx, *email.BroadcastID); err != nil {
+			log.Error("failed to update broadcast sent count", "broadcast_id", *email.BroadcastID, "error", err)
+		}
+	}
 
 	return nil
 }
 
+// returnPath returns the VERP return path for an email sent from domain, so
+// that asynchronous bounces and feedback reports can be matched to it. It is
+// empty, and the From address is used instead, unless the domain's
+// RETURN_PATH record has been verified.
+func (h *EmailSendHandler) returnPath(ctx context.Context, domain *model.Domain, emailID uuid.UUID, log *slog.Logger) string {
+	if domain == nil || h.dnsRecordRepo == nil || h.returnPathSecret == "" {
+		return ""
+	}
+	records, err := h.dnsRecordRepo.ListByDomainID(ctx, domain.ID)
+	if err != nil {
+		log.Warn("failed to fetch DNS records for return path, sending without VERP", "error", err)
+		return ""
+	}
+	for _, r := range records {
+		if r.RecordType == RecordTypeReturnPath && r.Status == DNSStatusVerified {
+			return pkg.VERPAddress(h.returnPathSecret, emailID, r.Name)
+		}
+	}
+	return ""
+}
+
 // filterSuppressed removes suppressed addresses from the given list.
 func (h *EmailSendHandler) filterSuppressed(ctx context.Context, teamID uuid.UUID, addresses []string, log *slog.Logger) []string {
 	if len(addresses) == 0 {
@@ -354,6 +485,64 @@ func (h *EmailSendHandler) filterSuppressed(ctx context.Context, teamID uuid.UUI
 	return filtered
 }
 
+// loadAttachments resolves the attachments JSONB into outbound attachments.
+// Entries carry either base64 "content" inline or a storage key in "path".
+func (h *EmailSendHandler) loadAttachments(ctx context.Context, entries model.JSONArray) ([]OutboundAttachment, error) {
+	attachments := make([]OutboundAttachment, 0, len(entries))
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: unexpected attachment entry", errBadAttachment)
+		}
+		filename, _ := m["filename"].(string)
+		contentType, _ := m["content_type"].(string)
+
+		var content []byte
+		if encoded, ok := m["content"].(string); ok {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("%w: decoding %s: %v", errBadAttachment, filename, err)
+			}
+			content = decoded
+		} else if key, ok := m["path"].(string); ok && h.attachments != nil {
+			rc, err := h.attachments.Open(ctx, key)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil, fmt.Errorf("%w: %s is missing from storage", errBadAttachment, filename)
+				}
+				return nil, err
+			}
+			content, err = io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading attachment %s: %w", filename, err)
+			}
+		} else {
+			return nil, fmt.Errorf("%w: %s has no content", errBadAttachment, filename)
+		}
+
+		attachments = append(attachments, OutboundAttachment{
+			Filename:    filename,
+			ContentType: contentType,
+			Content:     content,
+		})
+	}
+	return attachments, nil
+}
+
+// deleteStoredAttachments removes an email's attachments from storage once
+// it has been sent or has failed for good, as it is never sent again.
+func (h *EmailSendHandler) deleteStoredAttachments(ctx context.Context, email *model.Email, log *slog.Logger) {
+	if h.attachments == nil {
+		return
+	}
+	for _, key := range email.StoredAttachmentKeys() {
+		if err := h.attachments.Delete(ctx, key); err != nil {
+			log.Warn("failed to delete stored attachment", "key", key, "error", err)
+		}
+	}
+}
+
 // createEvent is a helper to create an email event record.
 func (h *EmailSendHandler) createEvent(ctx context.Context, emailID uuid.UUID, eventType string, recipient *string, payload model.JSONMap) {
 	event := &model.EmailEvent{
@@ -369,6 +558,19 @@ func (h *EmailSendHandler) createEvent(ctx context.Context, emailID uuid.UUID, e
 	}
 }
 
+// withTLS adds the TLS outcome of a delivery, if known, to an event payload.
+func withTLS(payload model.JSONMap, t *DeliveryTLS) model.JSONMap {
+	if t != nil {
+		payload["tls"] = map[string]interface{}{
+			"policy":   t.Policy,
+			"version":  t.Version,
+			"cipher":   t.Cipher,
+			"verified": t.Verified,
+		}
+	}
+	return payload
+}
+
 // incrementMetrics safely calls the metrics increment function if set.
 func (h *EmailSendHandler) incrementMetrics(ctx context.Context, teamID uuid.UUID, eventType string) {
 	if h.metricsIncrement != nil {
@@ -376,8 +578,12 @@ func (h *EmailSendHandler) incrementMetrics(ctx context.Context, teamID uuid.UUI
 	}
 }
 
-// extractDomain extracts the domain part from an email address.
+// extractDomain extracts the domain part from an email address, which may
+// carry a display name.
 func extractDomain(email string) string {
+	if addr, err := mail.ParseAddress(email); err == nil {
+		email = addr.Address
+	}
 	parts := strings.SplitN(email, "@", 2)
 	if len(parts) != 2 {
 		return ""
@@ -412,6 +618,16 @@ func jsonMapToStringMap(m model.JSONMap) map[string]string {
 	return result
 }
 
+// hasHeader reports whether m contains the header key, ignoring case.
+func hasHeader(m model.JSONMap, key string) bool {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
 // createTrackingLink creates a tracking link record in the database.
 func (h *EmailSendHandler) createTrackingLink(ctx context.Context, emailID, teamID uuid.UUID, linkType, originalURL, recipient string) *model.TrackingLink {
 	link := &model.TrackingLink{
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"io/fs"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +16,8 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/pkg"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
 )
 
 // --- local mocks to avoid import cycle with testutil/mock ---
@@ -44,6 +48,39 @@ func (m *mockEmailRepo) List(ctx context.Context, teamID uuid.UUID, limit, offse
 func (m *mockEmailRepo) Update(ctx context.Context, email *model.Email) error {
 	return m.Called(ctx, email).Error(0)
 }
+func (m *mockEmailRepo) ReleaseHeld(ctx context.Context, broadcastID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	args := m.Called(ctx, broadcastID, limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+func (m *mockEmailRepo) HoldQueued(ctx context.Context, ids []uuid.UUID) error {
+	return m.Called(ctx, ids).Error(0)
+}
+func (m *mockEmailRepo) UpdateStatusByBroadcast(ctx context.Context, broadcastID uuid.UUID, from []string, to string) (int64, error) {
+	args := m.Called(ctx, broadcastID, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *mockEmailRepo) CountByBroadcast(ctx context.Context, broadcastID uuid.UUID, statuses []string) (int, error) {
+	args := m.Called(ctx, broadcastID, statuses)
+	return args.Int(0), args.Error(1)
+}
+func (m *mockEmailRepo) LastSentAtByBroadcast(ctx context.Context, broadcastID uuid.UUID) (*time.Time, error) {
+	args := m.Called(ctx, broadcastID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+func (m *mockEmailRepo) ListAddressesByBroadcast(ctx context.Context, broadcastID uuid.UUID, addresses []string) ([]string, error) {
+	args := m.Called(ctx, broadcastID, addresses)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockEmailRepo) CountOutgoingByDomain(ctx context.Context, domainID uuid.UUID, since time.Time) (int, error) {
+	args := m.Called(ctx, domainID, since)
+	return args.Int(0), args.Error(1)
+}
 
 type mockEmailEventRepo struct{ mock.Mock }
 
@@ -92,9 +129,20 @@ func (m *mockDomainRepo) List(ctx context.Context, teamID uuid.UUID, limit, offs
 	args := m.Called(ctx, teamID, limit, offset)
 	return args.Get(0).([]model.Domain), args.Int(1), args.Error(2)
 }
+func (m *mockDomainRepo) ListDKIMMaintenanceDue(ctx context.Context, now time.Time) ([]model.Domain, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]model.Domain), args.Error(1)
+}
+func (m *mockDomainRepo) ListMonitoringDue(ctx context.Context, checkedBefore time.Time) ([]model.Domain, error) {
+	args := m.Called(ctx, checkedBefore)
+	return args.Get(0).([]model.Domain), args.Error(1)
+}
 func (m *mockDomainRepo) Update(ctx context.Context, domain *model.Domain) error {
 	return m.Called(ctx, domain).Error(0)
 }
+func (m *mockDomainRepo) StartDKIMRotation(ctx context.Context, domain *model.Domain, record *model.DomainDNSRecord) error {
+	return m.Called(ctx, domain, record).Error(0)
+}
 func (m *mockDomainRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
@@ -111,10 +159,21 @@ func (m *mockSuppressionRepo) GetByTeamAndEmail(ctx context.Context, teamID uuid
 	}
 	return args.Get(0).(*model.SuppressionEntry), args.Error(1)
 }
-func (m *mockSuppressionRepo) ListByTeamID(ctx context.Context, teamID uuid.UUID, limit, offset int) ([]model.SuppressionEntry, int, error) {
-	args := m.Called(ctx, teamID, limit, offset)
+func (m *mockSuppressionRepo) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.SuppressionEntry, error) {
+	args := m.Called(ctx, teamID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SuppressionEntry), args.Error(1)
+}
+func (m *mockSuppressionRepo) ListByTeamID(ctx context.Context, teamID uuid.UUID, search, reason string, limit, offset int) ([]model.SuppressionEntry, int, error) {
+	args := m.Called(ctx, teamID, search, reason, limit, offset)
 	return args.Get(0).([]model.SuppressionEntry), args.Int(1), args.Error(2)
 }
+func (m *mockSuppressionRepo) CreateBatch(ctx context.Context, entries []*model.SuppressionEntry) (int, error) {
+	args := m.Called(ctx, entries)
+	return args.Int(0), args.Error(1)
+}
 func (m *mockSuppressionRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return m.Called(ctx, id).Error(0)
 }
@@ -129,6 +188,22 @@ func (m *mockSender) SendEmail(ctx context.Context, msg *OutboundMessage) ([]Rec
 	return args.Get(0).([]RecipientResult), args.Error(1)
 }
 
+// mapAttachmentStore serves stored attachments from memory.
+type mapAttachmentStore map[string]string
+
+func (m mapAttachmentStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	content, ok := m[key]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (m mapAttachmentStore) Delete(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
 func newDiscardLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
@@ -145,7 +220,7 @@ func TestEmailSendHandler_ProcessTask_Success(t *testing.T) {
 		webhookCalled = true
 	}
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, webhookDispatch, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, webhookDispatch, nil, "", "", newDiscardLogger())
 
 	emailID := uuid.New()
 	teamID := uuid.New()
@@ -167,10 +242,15 @@ func TestEmailSendHandler_ProcessTask_Success(t *testing.T) {
 	emailRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.Email")).Return(nil)
 
 	results := []RecipientResult{
-		{Recipient: "recipient@example.com", Success: true, Code: 250, Message: "OK"},
+		{Recipient: "recipient@example.com", Success: true, Code: 250, Message: "OK", TLS: &DeliveryTLS{
+			Policy: "mta-sts", Version: "TLS 1.3", Cipher: "TLS_AES_128_GCM_SHA256", Verified: true,
+		}},
 	}
 	sender.On("SendEmail", mock.Anything, mock.AnythingOfType("*worker.OutboundMessage")).Return(results, nil)
-	eventRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.EmailEvent")).Return(nil)
+	eventRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *model.EmailEvent) bool {
+		tls, ok := e.Payload["tls"].(map[string]interface{})
+		return e.Type == model.EventSent && ok && tls["policy"] == "mta-sts" && tls["verified"] == true
+	})).Return(nil)
 
 	payload, _ := json.Marshal(EmailSendPayload{EmailID: emailID, TeamID: teamID})
 	task := asynq.NewTask(TaskEmailSend, payload)
@@ -179,6 +259,7 @@ func TestEmailSendHandler_ProcessTask_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, webhookCalled)
 	emailRepo.AssertExpectations(t)
+	eventRepo.AssertExpectations(t)
 	sender.AssertExpectations(t)
 }
 
@@ -189,7 +270,7 @@ func TestEmailSendHandler_ProcessTask_CancelledEmail(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	sender := new(mockSender)
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, nil, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
 
 	emailID := uuid.New()
 	teamID := uuid.New()
@@ -209,6 +290,61 @@ func TestEmailSendHandler_ProcessTask_CancelledEmail(t *testing.T) {
 	sender.AssertNotCalled(t, "SendEmail")
 }
 
+func TestEmailSendHandler_ProcessTask_HeldEmail(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	sender := new(mockSender)
+
+	h := NewEmailSendHandler(emailRepo, new(mockEmailEventRepo), new(mockDomainRepo), nil, new(mockSuppressionRepo), nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
+
+	email := &model.Email{ID: uuid.New(), TeamID: uuid.New(), Status: model.EmailStatusHeld}
+	emailRepo.On("GetByID", mock.Anything, email.ID).Return(email, nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: email.ID, TeamID: email.TeamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+
+	assert.NoError(t, err)
+	sender.AssertNotCalled(t, "SendEmail")
+	emailRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestEmailSendHandler_ProcessTask_CountsBroadcastProgress(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	domainRepo := new(mockDomainRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	broadcastRepo := new(mockBroadcastRepo)
+	sender := new(mockSender)
+
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, broadcastRepo, nil, sender, nil, nil, "", "", newDiscardLogger())
+
+	broadcastID := uuid.New()
+	email := &model.Email{
+		ID:          uuid.New(),
+		TeamID:      uuid.New(),
+		FromAddress: "news@example.com",
+		ToAddresses: []string{"reader@example.com"},
+		Subject:     "News",
+		TextBody:    strPtr("Hello"),
+		BroadcastID: &broadcastID,
+		Status:      model.EmailStatusQueued,
+	}
+
+	emailRepo.On("GetByID", mock.Anything, email.ID).Return(email, nil)
+	emailRepo.On("Update", mock.Anything, email).Return(nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, email.TeamID, "reader@example.com").Return(nil, nil)
+	domainRepo.On("GetByTeamAndName", mock.Anything, email.TeamID, "example.com").Return(nil, postgres.ErrNotFound)
+	sender.On("SendEmail", mock.Anything, mock.Anything).Return([]RecipientResult{{Recipient: "reader@example.com", Success: true, Code: 250}}, nil)
+	eventRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	broadcastRepo.On("IncrementSentCount", mock.Anything, broadcastID).Return(nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: email.ID, TeamID: email.TeamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.EmailStatusSent, email.Status)
+	broadcastRepo.AssertExpectations(t)
+}
+
 func TestEmailSendHandler_ProcessTask_AllSuppressed(t *testing.T) {
 	emailRepo := new(mockEmailRepo)
 	eventRepo := new(mockEmailEventRepo)
@@ -216,7 +352,7 @@ func TestEmailSendHandler_ProcessTask_AllSuppressed(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	sender := new(mockSender)
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, nil, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
 
 	emailID := uuid.New()
 	teamID := uuid.New()
@@ -247,6 +383,91 @@ func TestEmailSendHandler_ProcessTask_AllSuppressed(t *testing.T) {
 	emailRepo.AssertExpectations(t)
 }
 
+func TestEmailSendHandler_ProcessTask_Attachments(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	domainRepo := new(mockDomainRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	sender := new(mockSender)
+	storage := mapAttachmentStore{"team/large.pdf": "stored content"}
+
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, storage, sender, nil, nil, "", "", newDiscardLogger())
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	email := &model.Email{
+		ID:          emailID,
+		TeamID:      teamID,
+		FromAddress: "sender@example.com",
+		ToAddresses: []string{"recipient@example.com"},
+		Subject:     "Test",
+		Status:      model.EmailStatusQueued,
+		Headers:     model.JSONMap{},
+		Attachments: model.JSONArray{
+			map[string]interface{}{"filename": "small.txt", "content": "aW5saW5l", "content_type": "text/plain"},
+			map[string]interface{}{"filename": "large.pdf", "path": "team/large.pdf", "content_type": "application/pdf"},
+		},
+	}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "recipient@example.com").Return(nil, nil)
+	domainRepo.On("GetByTeamAndName", mock.Anything, teamID, "example.com").Return(nil, assert.AnError)
+	emailRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.Email")).Return(nil)
+	eventRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.EmailEvent")).Return(nil)
+
+	var sent *OutboundMessage
+	sender.On("SendEmail", mock.Anything, mock.AnythingOfType("*worker.OutboundMessage")).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(*OutboundMessage) }).
+		Return([]RecipientResult{{Recipient: "recipient@example.com", Success: true, Code: 250}}, nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: emailID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []OutboundAttachment{
+		{Filename: "small.txt", ContentType: "text/plain", Content: []byte("inline")},
+		{Filename: "large.pdf", ContentType: "application/pdf", Content: []byte("stored content")},
+	}, sent.Attachments)
+	assert.Empty(t, storage, "the stored attachment is deleted once the email is sent")
+}
+
+func TestEmailSendHandler_ProcessTask_MissingAttachment(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	domainRepo := new(mockDomainRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	sender := new(mockSender)
+
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, mapAttachmentStore{}, sender, nil, nil, "", "", newDiscardLogger())
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	email := &model.Email{
+		ID:          emailID,
+		TeamID:      teamID,
+		FromAddress: "sender@example.com",
+		ToAddresses: []string{"recipient@example.com"},
+		Status:      model.EmailStatusQueued,
+		Headers:     model.JSONMap{},
+		Attachments: model.JSONArray{
+			map[string]interface{}{"filename": "gone.pdf", "path": "team/gone.pdf"},
+		},
+	}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "recipient@example.com").Return(nil, nil)
+	emailRepo.On("Update", mock.Anything, mock.MatchedBy(func(e *model.Email) bool {
+		return e.Status == model.EmailStatusFailed
+	})).Return(nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: emailID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+
+	assert.NoError(t, err, "a missing attachment is not retried")
+	sender.AssertNotCalled(t, "SendEmail")
+	emailRepo.AssertExpectations(t)
+}
+
 func TestEmailSendHandler_ProcessTask_AlreadySent(t *testing.T) {
 	emailRepo := new(mockEmailRepo)
 	eventRepo := new(mockEmailEventRepo)
@@ -254,7 +475,7 @@ func TestEmailSendHandler_ProcessTask_AlreadySent(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	sender := new(mockSender)
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, nil, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
 
 	emailID := uuid.New()
 	teamID := uuid.New()
@@ -281,7 +502,7 @@ func TestEmailSendHandler_ProcessTask_WithDKIM(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	sender := new(mockSender)
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, nil, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
 
 	emailID := uuid.New()
 	teamID := uuid.New()
@@ -329,6 +550,146 @@ func TestEmailSendHandler_ProcessTask_WithDKIM(t *testing.T) {
 	sender.AssertExpectations(t)
 }
 
+func TestEmailSendHandler_ProcessTask_DeletedDomain(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	domainRepo := new(mockDomainRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	sender := new(mockSender)
+
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	domainID := uuid.New()
+	text := "Hello"
+
+	email := &model.Email{
+		ID:          emailID,
+		TeamID:      teamID,
+		DomainID:    &domainID,
+		FromAddress: "sender@example.com",
+		ToAddresses: []string{"recipient@example.com"},
+		Subject:     "Test",
+		TextBody:    &text,
+		Status:      model.EmailStatusQueued,
+		Headers:     model.JSONMap{},
+	}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "recipient@example.com").Return(nil, nil)
+	domainRepo.On("GetByID", mock.Anything, domainID).Return(nil, postgres.ErrNotFound)
+	emailRepo.On("Update", mock.Anything, mock.MatchedBy(func(e *model.Email) bool {
+		return e.Status == model.EmailStatusFailed
+	})).Return(nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: emailID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+
+	assert.NoError(t, err)
+	sender.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything)
+	emailRepo.AssertExpectations(t)
+}
+
+func TestEmailSendHandler_ProcessTask_PausedDomain(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	domainRepo := new(mockDomainRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	sender := new(mockSender)
+
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	domainID := uuid.New()
+	text := "Hello"
+	pausedAt := time.Now().Add(-time.Hour)
+
+	email := &model.Email{
+		ID:          emailID,
+		TeamID:      teamID,
+		DomainID:    &domainID,
+		FromAddress: "sender@example.com",
+		ToAddresses: []string{"recipient@example.com"},
+		Subject:     "Test",
+		TextBody:    &text,
+		Status:      model.EmailStatusQueued,
+		Headers:     model.JSONMap{},
+	}
+	domain := &model.Domain{
+		ID:              domainID,
+		TeamID:          teamID,
+		Name:            "example.com",
+		Status:          model.DomainStatusDegraded,
+		SendingPausedAt: &pausedAt,
+	}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "recipient@example.com").Return(nil, nil)
+	domainRepo.On("GetByID", mock.Anything, domainID).Return(domain, nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: emailID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+
+	assert.ErrorContains(t, err, "paused")
+	sender.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything)
+	emailRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestEmailSendHandler_ProcessTask_VERPReturnPath(t *testing.T) {
+	emailRepo := new(mockEmailRepo)
+	eventRepo := new(mockEmailEventRepo)
+	domainRepo := new(mockDomainRepo)
+	dnsRecordRepo := new(mockDNSRecordRepo)
+	suppressionRepo := new(mockSuppressionRepo)
+	sender := new(mockSender)
+
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, dnsRecordRepo, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "secret", newDiscardLogger())
+
+	emailID := uuid.New()
+	teamID := uuid.New()
+	domainID := uuid.New()
+	html := "<p>Hello</p>"
+
+	email := &model.Email{
+		ID:          emailID,
+		TeamID:      teamID,
+		DomainID:    &domainID,
+		FromAddress: "sender@example.com",
+		ToAddresses: []string{"recipient@example.com"},
+		Subject:     "Test",
+		HTMLBody:    &html,
+		Status:      model.EmailStatusQueued,
+		Headers:     model.JSONMap{},
+	}
+
+	emailRepo.On("GetByID", mock.Anything, emailID).Return(email, nil)
+	suppressionRepo.On("GetByTeamAndEmail", mock.Anything, teamID, "recipient@example.com").Return(nil, nil)
+	domainRepo.On("GetByID", mock.Anything, domainID).Return(&model.Domain{ID: domainID, Name: "example.com"}, nil)
+	dnsRecordRepo.On("ListByDomainID", mock.Anything, domainID).Return([]model.DomainDNSRecord{
+		{RecordType: RecordTypeSPF, Name: "example.com", Status: DNSStatusVerified},
+		{RecordType: RecordTypeReturnPath, Name: "bounce.example.com", Status: DNSStatusVerified},
+	}, nil)
+	emailRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.Email")).Return(nil)
+
+	var returnPath string
+	sender.On("SendEmail", mock.Anything, mock.MatchedBy(func(msg *OutboundMessage) bool {
+		returnPath = msg.ReturnPath
+		return true
+	})).Return([]RecipientResult{{Recipient: "recipient@example.com", Success: true, Code: 250}}, nil)
+	eventRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.EmailEvent")).Return(nil)
+
+	payload, _ := json.Marshal(EmailSendPayload{EmailID: emailID, TeamID: teamID})
+	err := h.ProcessTask(context.Background(), asynq.NewTask(TaskEmailSend, payload))
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasSuffix(returnPath, "@bounce.example.com"), returnPath)
+	parsedID, err := pkg.ParseVERPAddress("secret", returnPath)
+	assert.NoError(t, err)
+	assert.Equal(t, emailID, parsedID)
+}
+
 func TestEmailSendHandler_ProcessTask_InvalidPayload(t *testing.T) {
 	emailRepo := new(mockEmailRepo)
 	eventRepo := new(mockEmailEventRepo)
@@ -336,7 +697,7 @@ func TestEmailSendHandler_ProcessTask_InvalidPayload(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	sender := new(mockSender)
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, nil, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
 
 	task := asynq.NewTask(TaskEmailSend, []byte("invalid json"))
 
@@ -383,7 +744,7 @@ func TestFilterSuppressed(t *testing.T) {
 	suppressionRepo := new(mockSuppressionRepo)
 	sender := new(mockSender)
 
-	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, suppressionRepo, nil, sender, nil, nil, "", newDiscardLogger())
+	h := NewEmailSendHandler(emailRepo, eventRepo, domainRepo, nil, suppressionRepo, nil, nil, nil, sender, nil, nil, "", "", newDiscardLogger())
 
 	teamID := uuid.New()
 	ctx := context.Background()
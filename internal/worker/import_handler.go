@@ -21,6 +21,7 @@ import (
 type ContactImportHandler struct {
 	importJobRepo postgres.ContactImportJobRepository
 	contactRepo   postgres.ContactRepository
+	segmentRepo   postgres.SegmentRepository
 	logger        *slog.Logger
 }
 
@@ -28,11 +29,13 @@ type ContactImportHandler struct {
 func NewContactImportHandler(
 	importJobRepo postgres.ContactImportJobRepository,
 	contactRepo postgres.ContactRepository,
+	segmentRepo postgres.SegmentRepository,
 	logger *slog.Logger,
 ) *ContactImportHandler {
 	return &ContactImportHandler{
 		importJobRepo: importJobRepo,
 		contactRepo:   contactRepo,
+		segmentRepo:   segmentRepo,
 		logger:        logger,
 	}
 }
@@ -188,6 +191,9 @@ func (h *ContactImportHandler) ProcessTask(ctx context.Context, t *asynq.Task) e
 		return fmt.Errorf("updating import job to completed: %w", err)
 	}
 
+	// 6. Recompute membership of the audience's segments for the imported contacts.
+	h.refreshSegments(ctx, log, job.AudienceID)
+
 	log.Info("contact import completed",
 		"total", job.TotalRows,
 		"created", job.CreatedRows,
@@ -208,3 +214,18 @@ func (h *ContactImportHandler) failJob(ctx context.Context, job *model.ContactIm
 	}
 	return fmt.Errorf("import failed: %s", errMsg)
 }
+
+// refreshSegments recomputes every segment of the audience. Failures are only
+// logged: membership is recomputed again before each broadcast send.
+func (h *ContactImportHandler) refreshSegments(ctx context.Context, log *slog.Logger, audienceID uuid.UUID) {
+	segments, err := h.segmentRepo.ListByAudienceID(ctx, audienceID)
+	if err != nil {
+		log.Error("failed to list segments after import", "error", err)
+		return
+	}
+	for _, seg := range segments {
+		if _, err := h.segmentRepo.RefreshMembers(ctx, seg.ID); err != nil {
+			log.Error("failed to refresh segment after import", "segment_id", seg.ID, "error", err)
+		}
+	}
+}
@@ -34,11 +34,15 @@ type Handlers struct {
 	EmailSend      *EmailSendHandler
 	EmailBatchSend *BatchEmailSendHandler
 	BroadcastSend  *BroadcastSendHandler
+	BroadcastDispatch *BroadcastDispatchHandler
 	DomainVerify   *DomainVerifyHandler
+	DKIMMaintenance *DKIMMaintenanceHandler
+	DomainMonitor   *DomainMonitorHandler
 	Bounce         *BounceHandler
 	Inbound        *InboundHandler
 	Cleanup        *CleanupHandler
 	WebhookDeliver   *WebhookDeliverHandler
+	WebhookBackfill  *WebhookBackfillHandler
 	MetricsAggregate *MetricsAggregateHandler
 	ContactImport    *ContactImportHandler
 }
@@ -88,9 +92,18 @@ func NewMux(h Handlers) *asynq.ServeMux {
 	if h.BroadcastSend != nil {
 		mux.HandleFunc(TaskBroadcastSend, h.BroadcastSend.ProcessTask)
 	}
+	if h.BroadcastDispatch != nil {
+		mux.HandleFunc(TaskBroadcastDispatch, h.BroadcastDispatch.ProcessTask)
+	}
 	if h.DomainVerify != nil {
 		mux.HandleFunc(TaskDomainVerify, h.DomainVerify.ProcessTask)
 	}
+	if h.DKIMMaintenance != nil {
+		mux.HandleFunc(TaskDKIMMaintenance, h.DKIMMaintenance.ProcessTask)
+	}
+	if h.DomainMonitor != nil {
+		mux.HandleFunc(TaskDomainMonitor, h.DomainMonitor.ProcessTask)
+	}
 	if h.Bounce != nil {
 		mux.HandleFunc(TaskBounceProcess, h.Bounce.ProcessTask)
 	}
@@ -103,6 +116,9 @@ func NewMux(h Handlers) *asynq.ServeMux {
 	if h.WebhookDeliver != nil {
 		mux.HandleFunc(TaskWebhookDeliver, h.WebhookDeliver.ProcessTask)
 	}
+	if h.WebhookBackfill != nil {
+		mux.HandleFunc(TaskWebhookBackfill, h.WebhookBackfill.ProcessTask)
+	}
 	if h.MetricsAggregate != nil {
 		mux.HandleFunc(TaskMetricsAggregate, h.MetricsAggregate.ProcessTask)
 	}
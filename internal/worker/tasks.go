@@ -2,6 +2,8 @@ package worker
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
@@ -12,8 +14,12 @@ const (
 	TaskEmailSend      = "email:send"
 	TaskEmailBatchSend = "email:send_batch"
 	TaskBroadcastSend  = "broadcast:send"
+	TaskBroadcastDispatch = "broadcast:dispatch"
 	TaskDomainVerify   = "domain:verify"
+	TaskDKIMMaintenance = "domain:dkim_maintenance"
+	TaskDomainMonitor   = "domain:monitor"
 	TaskWebhookDeliver = "webhook:deliver"
+	TaskWebhookBackfill = "webhook:backfill"
 	TaskBounceProcess  = "bounce:process"
 	TaskInboundProcess = "inbound:process"
 	TaskCleanupExpired    = "cleanup:expired"
@@ -57,14 +63,33 @@ type WebhookDeliverPayload struct {
 	WebhookEventID uuid.UUID `json:"webhook_event_id"`
 }
 
+// WebhookBackfillPayload is the payload for delivering the events a webhook
+// skipped while it was disabled.
+type WebhookBackfillPayload struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+}
+
 // BounceProcessPayload is the payload for processing a bounce.
 type BounceProcessPayload struct {
 	EmailID   uuid.UUID `json:"email_id"`
 	Code      int       `json:"code"`
 	Message   string    `json:"message"`
 	Recipient string    `json:"recipient"`
+	// Type is the bounce type when it is already known, as for reports
+	// received at the return path. Otherwise it is classified from Code and
+	// Message.
+	Type string `json:"type,omitempty"`
+	// Source is BounceSourceDSN or BounceSourceARF for reports received at
+	// the return path, and empty for rejections during delivery.
+	Source string `json:"source,omitempty"`
 }
 
+// Bounce sources for reports received asynchronously at the return path.
+const (
+	BounceSourceDSN = "dsn"
+	BounceSourceARF = "arf"
+)
+
 // InboundProcessPayload is the payload for processing an inbound email.
 type InboundProcessPayload struct {
 	InboundEmailID uuid.UUID `json:"inbound_email_id"`
@@ -97,6 +122,13 @@ func NewBroadcastSendTask(broadcastID, teamID uuid.UUID) (*asynq.Task, error) {
 	return asynq.NewTask(TaskBroadcastSend, payload, asynq.Queue(QueueCritical), asynq.MaxRetry(3)), nil
 }
 
+// BroadcastSendTaskID returns the ID of the broadcast:send task for a send of
+// a broadcast that is due at dueAt. The ID names the send, so a send that is
+// still queued or running isn't queued twice.
+func BroadcastSendTaskID(broadcastID uuid.UUID, dueAt time.Time) string {
+	return fmt.Sprintf("%s:%s:%d", TaskBroadcastSend, broadcastID, dueAt.Unix())
+}
+
 // NewDomainVerifyTask creates an asynq task for verifying a domain's DNS records.
 func NewDomainVerifyTask(domainID, teamID uuid.UUID) (*asynq.Task, error) {
 	payload, err := json.Marshal(DomainVerifyPayload{DomainID: domainID, TeamID: teamID})
@@ -115,6 +147,16 @@ func NewWebhookDeliverTask(webhookEventID uuid.UUID) (*asynq.Task, error) {
 	return asynq.NewTask(TaskWebhookDeliver, payload, asynq.Queue(QueueDefault), asynq.MaxRetry(5)), nil
 }
 
+// NewWebhookBackfillTask creates an asynq task for delivering the next page
+// of events a webhook skipped while it was disabled.
+func NewWebhookBackfillTask(webhookID uuid.UUID) (*asynq.Task, error) {
+	payload, err := json.Marshal(WebhookBackfillPayload{WebhookID: webhookID})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskWebhookBackfill, payload, asynq.Queue(QueueLow), asynq.MaxRetry(3)), nil
+}
+
 // NewBounceProcessTask creates an asynq task for processing a bounce notification.
 func NewBounceProcessTask(emailID uuid.UUID, code int, message, recipient string) (*asynq.Task, error) {
 	payload, err := json.Marshal(BounceProcessPayload{
@@ -129,6 +171,23 @@ func NewBounceProcessTask(emailID uuid.UUID, code int, message, recipient string
 	return asynq.NewTask(TaskBounceProcess, payload, asynq.Queue(QueueDefault), asynq.MaxRetry(3)), nil
 }
 
+// NewBounceReportTask creates an asynq task for processing a bounce or
+// complaint reported asynchronously at an email's return path.
+func NewBounceReportTask(emailID uuid.UUID, bounceType string, code int, message, recipient, source string) (*asynq.Task, error) {
+	payload, err := json.Marshal(BounceProcessPayload{
+		EmailID:   emailID,
+		Code:      code,
+		Message:   message,
+		Recipient: recipient,
+		Type:      bounceType,
+		Source:    source,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskBounceProcess, payload, asynq.Queue(QueueDefault), asynq.MaxRetry(3)), nil
+}
+
 // NewInboundProcessTask creates an asynq task for processing an inbound email.
 func NewInboundProcessTask(inboundEmailID uuid.UUID) (*asynq.Task, error) {
 	payload, err := json.Marshal(InboundProcessPayload{InboundEmailID: inboundEmailID})
@@ -158,6 +217,26 @@ func NewCleanupExpiredTask() (*asynq.Task, error) {
 	return asynq.NewTask(TaskCleanupExpired, nil, asynq.Queue(QueueLow), asynq.MaxRetry(1)), nil
 }
 
+// NewDKIMMaintenanceTask creates an asynq task for DKIM key retirement and
+// scheduled rotation. It is unique for an hour, so that the task is enqueued
+// once per schedule tick however many instances run the scheduler.
+func NewDKIMMaintenanceTask() (*asynq.Task, error) {
+	return asynq.NewTask(TaskDKIMMaintenance, nil, asynq.Queue(QueueLow), asynq.MaxRetry(1), asynq.Unique(time.Hour)), nil
+}
+
+// NewDomainMonitorTask creates an asynq task for re-verifying the DNS of
+// verified domains. Like NewDKIMMaintenanceTask, it is unique for an hour.
+func NewDomainMonitorTask() (*asynq.Task, error) {
+	return asynq.NewTask(TaskDomainMonitor, nil, asynq.Queue(QueueLow), asynq.MaxRetry(1), asynq.Unique(time.Hour)), nil
+}
+
+// NewBroadcastDispatchTask creates an asynq task for queueing the scheduled
+// broadcasts that are due. It is unique for a minute, like
+// NewDKIMMaintenanceTask is for an hour.
+func NewBroadcastDispatchTask() (*asynq.Task, error) {
+	return asynq.NewTask(TaskBroadcastDispatch, nil, asynq.Queue(QueueCritical), asynq.MaxRetry(1), asynq.Unique(time.Minute)), nil
+}
+
 // NewMetricsAggregateTask creates an asynq task for aggregating email metrics.
 func NewMetricsAggregateTask() (*asynq.Task, error) {
 	return asynq.NewTask(TaskMetricsAggregate, nil, asynq.Queue(QueueLow), asynq.MaxRetry(1)), nil
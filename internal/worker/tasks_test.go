@@ -92,6 +92,21 @@ func TestNewWebhookDeliverTask(t *testing.T) {
 	assert.Equal(t, webhookEventID, payload.WebhookEventID)
 }
 
+func TestNewWebhookBackfillTask(t *testing.T) {
+	webhookID := uuid.New()
+
+	task, err := NewWebhookBackfillTask(webhookID)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+
+	assert.Equal(t, TaskWebhookBackfill, task.Type())
+
+	var payload WebhookBackfillPayload
+	err = json.Unmarshal(task.Payload(), &payload)
+	require.NoError(t, err)
+	assert.Equal(t, webhookID, payload.WebhookID)
+}
+
 func TestNewBounceProcessTask(t *testing.T) {
 	emailID := uuid.New()
 	code := 550
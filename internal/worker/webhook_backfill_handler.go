@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+	"github.com/mailit-dev/mailit/internal/webhook"
+)
+
+// Backfills deliver webhookBackfillPageSize events at a time, one page every
+// webhookBackfillInterval, so that a long backlog doesn't flood the endpoint
+// it was skipped for.
+const (
+	webhookBackfillPageSize = 100
+	webhookBackfillInterval = 30 * time.Second
+)
+
+// WebhookRedeliverer queues a webhook event for delivery again. It is
+// implemented by webhook.Dispatcher.
+type WebhookRedeliverer interface {
+	Redeliver(ctx context.Context, event *model.WebhookEvent) error
+}
+
+// WebhookBackfillHandler processes webhook:backfill tasks, which deliver the
+// events a webhook skipped while it was disabled. Each task redelivers a page
+// of them and queues the next page.
+type WebhookBackfillHandler struct {
+	webhookRepo      postgres.WebhookRepository
+	webhookEventRepo postgres.WebhookEventRepository
+	redeliverer      WebhookRedeliverer
+	enqueuer         TaskEnqueuer
+	logger           *slog.Logger
+}
+
+// NewWebhookBackfillHandler creates a new WebhookBackfillHandler.
+func NewWebhookBackfillHandler(
+	webhookRepo postgres.WebhookRepository,
+	webhookEventRepo postgres.WebhookEventRepository,
+	redeliverer WebhookRedeliverer,
+	enqueuer TaskEnqueuer,
+	logger *slog.Logger,
+) *WebhookBackfillHandler {
+	return &WebhookBackfillHandler{
+		webhookRepo:      webhookRepo,
+		webhookEventRepo: webhookEventRepo,
+		redeliverer:      redeliverer,
+		enqueuer:         enqueuer,
+		logger:           logger,
+	}
+}
+
+// ProcessTask handles the webhook:backfill task.
+func (h *WebhookBackfillHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	var p WebhookBackfillPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("unmarshalling webhook:backfill payload: %w", err)
+	}
+
+	log := h.logger.With("webhook_id", p.WebhookID)
+
+	// A webhook that was deleted or disabled again keeps what it skipped.
+	wh, err := h.webhookRepo.GetByID(ctx, p.WebhookID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			log.Info("webhook was deleted, stopping backfill")
+			return nil
+		}
+		return fmt.Errorf("fetching webhook %s: %w", p.WebhookID, err)
+	}
+	if !wh.Active {
+		log.Info("webhook was disabled again, stopping backfill")
+		return nil
+	}
+
+	// Redelivered events leave the skipped status, so each page is read from
+	// the start.
+	filter := postgres.WebhookEventFilter{Status: webhook.EventStatusSkipped}
+	events, _, err := h.webhookEventRepo.ListByWebhookID(ctx, wh.ID, filter, webhookBackfillPageSize, 0)
+	if err != nil {
+		return fmt.Errorf("listing skipped webhook events: %w", err)
+	}
+	for i := range events {
+		if err := h.redeliverer.Redeliver(ctx, &events[i]); err != nil {
+			return fmt.Errorf("backfilling webhook event %s: %w", events[i].ID, err)
+		}
+	}
+
+	if len(events) < webhookBackfillPageSize {
+		log.Info("webhook backfill completed", "redelivered", len(events))
+		return nil
+	}
+	if err := h.enqueueNext(wh.ID); err != nil {
+		return err
+	}
+	log.Info("webhook backfill page redelivered", "redelivered", len(events), "next_page_in", webhookBackfillInterval)
+	return nil
+}
+
+// enqueueNext queues the next page of a webhook's backfill.
+func (h *WebhookBackfillHandler) enqueueNext(webhookID uuid.UUID) error {
+	task, err := NewWebhookBackfillTask(webhookID)
+	if err != nil {
+		return fmt.Errorf("creating webhook:backfill task: %w", err)
+	}
+	if _, err := h.enqueuer.Enqueue(task, asynq.ProcessIn(webhookBackfillInterval)); err != nil {
+		return fmt.Errorf("enqueueing webhook:backfill task: %w", err)
+	}
+	return nil
+}
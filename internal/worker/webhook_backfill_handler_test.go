@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mailit-dev/mailit/internal/model"
+	"github.com/mailit-dev/mailit/internal/repository/postgres"
+)
+
+// --- local mocks for webhook backfill handler ---
+
+type mockWebhookRepo struct{ mock.Mock }
+
+func (m *mockWebhookRepo) Create(ctx context.Context, webhook *model.Webhook) error {
+	return m.Called(ctx, webhook).Error(0)
+}
+func (m *mockWebhookRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Webhook), args.Error(1)
+}
+func (m *mockWebhookRepo) GetByTeamAndID(ctx context.Context, teamID, id uuid.UUID) (*model.Webhook, error) {
+	args := m.Called(ctx, teamID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Webhook), args.Error(1)
+}
+func (m *mockWebhookRepo) ListByTeamID(ctx context.Context, teamID uuid.UUID) ([]model.Webhook, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+func (m *mockWebhookRepo) Update(ctx context.Context, webhook *model.Webhook) error {
+	return m.Called(ctx, webhook).Error(0)
+}
+func (m *mockWebhookRepo) RecordDelivery(ctx context.Context, id uuid.UUID, delivered bool, at time.Time) (*model.Webhook, error) {
+	args := m.Called(ctx, id, delivered, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Webhook), args.Error(1)
+}
+func (m *mockWebhookRepo) Disable(ctx context.Context, id uuid.UUID, reason string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, reason, at)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockWebhookRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type mockWebhookRedeliverer struct{ mock.Mock }
+
+func (m *mockWebhookRedeliverer) Redeliver(ctx context.Context, event *model.WebhookEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func TestWebhookBackfillHandler_ProcessTask(t *testing.T) {
+	newTask := func(t *testing.T, webhookID uuid.UUID) *asynq.Task {
+		task, err := NewWebhookBackfillTask(webhookID)
+		require.NoError(t, err)
+		return task
+	}
+	skipped := func(webhookID uuid.UUID, n int) []model.WebhookEvent {
+		events := make([]model.WebhookEvent, n)
+		for i := range events {
+			events[i] = model.WebhookEvent{ID: uuid.New(), WebhookID: webhookID, Status: "skipped"}
+		}
+		return events
+	}
+	filter := postgres.WebhookEventFilter{Status: "skipped"}
+
+	t.Run("full page queues the next one", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+		defer func() { _ = client.Close() }()
+
+		webhookRepo, eventRepo, redeliverer := new(mockWebhookRepo), new(mockWebhookEventRepo), new(mockWebhookRedeliverer)
+		h := NewWebhookBackfillHandler(webhookRepo, eventRepo, redeliverer, client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		wh := &model.Webhook{ID: uuid.New(), Active: true}
+		webhookRepo.On("GetByID", mock.Anything, wh.ID).Return(wh, nil)
+		eventRepo.On("ListByWebhookID", mock.Anything, wh.ID, filter, webhookBackfillPageSize, 0).
+			Return(skipped(wh.ID, webhookBackfillPageSize), 250, nil)
+		redeliverer.On("Redeliver", mock.Anything, mock.AnythingOfType("*model.WebhookEvent")).Return(nil).Times(webhookBackfillPageSize)
+
+		require.NoError(t, h.ProcessTask(context.Background(), newTask(t, wh.ID)))
+		redeliverer.AssertExpectations(t)
+
+		inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+		defer func() { _ = inspector.Close() }()
+		tasks, err := inspector.ListScheduledTasks(QueueLow)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, TaskWebhookBackfill, tasks[0].Type)
+		var p WebhookBackfillPayload
+		require.NoError(t, json.Unmarshal(tasks[0].Payload, &p))
+		assert.Equal(t, wh.ID, p.WebhookID)
+	})
+
+	t.Run("last page completes the backfill", func(t *testing.T) {
+		webhookRepo, eventRepo, redeliverer := new(mockWebhookRepo), new(mockWebhookEventRepo), new(mockWebhookRedeliverer)
+		h := NewWebhookBackfillHandler(webhookRepo, eventRepo, redeliverer, failingEnqueuer{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		wh := &model.Webhook{ID: uuid.New(), Active: true}
+		webhookRepo.On("GetByID", mock.Anything, wh.ID).Return(wh, nil)
+		eventRepo.On("ListByWebhookID", mock.Anything, wh.ID, filter, webhookBackfillPageSize, 0).Return(skipped(wh.ID, 3), 3, nil)
+		redeliverer.On("Redeliver", mock.Anything, mock.AnythingOfType("*model.WebhookEvent")).Return(nil).Times(3)
+
+		require.NoError(t, h.ProcessTask(context.Background(), newTask(t, wh.ID)))
+		redeliverer.AssertExpectations(t)
+	})
+
+	t.Run("disabled webhook stops the backfill", func(t *testing.T) {
+		webhookRepo, eventRepo, redeliverer := new(mockWebhookRepo), new(mockWebhookEventRepo), new(mockWebhookRedeliverer)
+		h := NewWebhookBackfillHandler(webhookRepo, eventRepo, redeliverer, failingEnqueuer{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		wh := &model.Webhook{ID: uuid.New(), Active: false}
+		webhookRepo.On("GetByID", mock.Anything, wh.ID).Return(wh, nil)
+
+		require.NoError(t, h.ProcessTask(context.Background(), newTask(t, wh.ID)))
+		eventRepo.AssertNotCalled(t, "ListByWebhookID")
+		redeliverer.AssertNotCalled(t, "Redeliver")
+	})
+
+	t.Run("deleted webhook stops the backfill", func(t *testing.T) {
+		webhookRepo, eventRepo := new(mockWebhookRepo), new(mockWebhookEventRepo)
+		h := NewWebhookBackfillHandler(webhookRepo, eventRepo, new(mockWebhookRedeliverer), failingEnqueuer{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		webhookID := uuid.New()
+		webhookRepo.On("GetByID", mock.Anything, webhookID).Return(nil, postgres.ErrNotFound)
+
+		require.NoError(t, h.ProcessTask(context.Background(), newTask(t, webhookID)))
+		eventRepo.AssertNotCalled(t, "ListByWebhookID")
+	})
+}
@@ -18,6 +18,7 @@ const statusVariants: Record<string, string> = {
   scheduled: "bg-yellow-500/10 text-yellow-400 border-yellow-500/20",
   sending: "bg-yellow-500/10 text-yellow-400 border-yellow-500/20",
   draft: "bg-yellow-500/10 text-yellow-400 border-yellow-500/20",
+  degraded: "bg-yellow-500/10 text-yellow-400 border-yellow-500/20",
   // Blue
   opened: "bg-blue-500/10 text-blue-400 border-blue-500/20",
   clicked: "bg-blue-500/10 text-blue-400 border-blue-500/20",